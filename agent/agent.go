@@ -8,7 +8,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"runtime"
+	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -19,7 +21,9 @@ import (
 	"github.com/orb-community/orb/agent/backend"
 	"github.com/orb-community/orb/agent/cloud_config"
 	"github.com/orb-community/orb/agent/config"
+	"github.com/orb-community/orb/agent/localexporter"
 	manager "github.com/orb-community/orb/agent/policyMgr"
+	"github.com/orb-community/orb/agent/tags"
 	"github.com/orb-community/orb/buildinfo"
 	"go.uber.org/zap"
 )
@@ -33,16 +37,20 @@ type Agent interface {
 	Stop(ctx context.Context)
 	RestartAll(ctx context.Context, reason string) error
 	RestartBackend(ctx context.Context, backend string, reason string) error
+	Reload(ctx context.Context, c config.Config) error
 }
 
 type orbAgent struct {
 	logger            *zap.Logger
 	config            config.Config
 	client            mqtt.Client
+	localClient       mqtt.Client
 	agent_id          string
 	db                *sqlx.DB
 	backends          map[string]backend.Backend
 	backendState      map[string]*backend.State
+	stateMu           sync.Mutex // guards backends, backendState and the backends configuration
+	reloadMu          sync.Mutex // serializes configuration reloads with backend restarts
 	cancelFunction    context.CancelFunc
 	rpcFromCancelFunc context.CancelFunc
 
@@ -60,22 +68,28 @@ type orbAgent struct {
 	heartbeatsTopic   string
 	logTopic          string
 
-	// Retry Mechanism to ensure the Request is received
-	groupRequestTicker     *time.Ticker
-	groupRequestSucceeded  context.CancelFunc
-	policyRequestTicker    *time.Ticker
-	policyRequestSucceeded context.CancelFunc
+	// requests sent to core it did not acknowledge yet, sent again with the heartbeats
+	rpcRequests pendingRequests
+
+	// outcome of the RPCs recently received from core, which sends them again until they are acknowledged
+	rpcAcks rpcAckCache
 
 	// AgentGroup channels sent from core
 	groupsInfos map[string]GroupInfo
 
 	policyManager manager.PolicyManager
-}
 
-const retryRequestDuration = time.Second
-const retryRequestFixedTime = 15
-const retryDurationIncrPerAttempts = 10
-const retryMaxAttempts = 4
+	// tagResolver merges the static agent tags with the tag providers, nil without providers
+	tagResolver *tags.Resolver
+	tagsMu      sync.Mutex
+
+	// local status server and the self-telemetry it serves
+	metrics      *agentMetrics
+	statusServer *http.Server
+
+	// last log entries, collected in the diagnostic bundles
+	logs *backend.LineBuffer
+}
 
 type GroupInfo struct {
 	Name      string
@@ -85,6 +99,8 @@ type GroupInfo struct {
 var _ Agent = (*orbAgent)(nil)
 
 func New(logger *zap.Logger, c config.Config) (Agent, error) {
+	logs := backend.NewLineBuffer(diagnosticsLogLines)
+	logger = withLogBuffer(logger, logs)
 	logger.Info("using local config db", zap.String("filename", c.OrbAgent.DB.File))
 	db, err := sqlx.Connect("sqlite3", c.OrbAgent.DB.File)
 	if err != nil {
@@ -100,7 +116,27 @@ func New(logger *zap.Logger, c config.Config) (Agent, error) {
 		logger.Error("policy manager failed to get repository", zap.Error(err))
 		return nil, err
 	}
-	return &orbAgent{logger: logger, config: c, policyManager: pm, db: db, groupsInfos: make(map[string]GroupInfo)}, nil
+	var tagResolver *tags.Resolver
+	if len(c.OrbAgent.TagProviders.Providers) > 0 {
+		tagResolver, err = tags.NewResolver(logger, c.OrbAgent.Tags, c.OrbAgent.TagProviders.Providers)
+		if err != nil {
+			return nil, err
+		}
+		c.OrbAgent.Tags = tagResolver.Resolve(context.Background())
+		logger.Info("agent tags resolved", zap.Any("tags", c.OrbAgent.Tags))
+	}
+	var localClient mqtt.Client
+	if c.OrbAgent.LocalExporter.OTLPHTTP.Endpoint != "" {
+		lc, err := localexporter.New(logger, c.OrbAgent.LocalExporter.OTLPHTTP)
+		if err != nil {
+			return nil, err
+		}
+		localClient = lc
+	} else if c.OrbAgent.Standalone.Enabled {
+		return nil, localexporter.ErrMissingEndpoint
+	}
+	return &orbAgent{logger: logger, config: c, policyManager: pm, db: db, localClient: localClient,
+		tagResolver: tagResolver, groupsInfos: make(map[string]GroupInfo), metrics: newAgentMetrics(), logs: logs}, nil
 }
 
 func (a *orbAgent) startBackends(agentCtx context.Context) error {
@@ -109,48 +145,80 @@ func (a *orbAgent) startBackends(agentCtx context.Context) error {
 	if len(a.config.OrbAgent.Backends) == 0 {
 		return errors.New("no backends specified")
 	}
+	a.stateMu.Lock()
 	a.backends = make(map[string]backend.Backend, len(a.config.OrbAgent.Backends))
 	a.backendState = make(map[string]*backend.State)
+	a.stateMu.Unlock()
 	for name, configurationEntry := range a.config.OrbAgent.Backends {
-		if !backend.HaveBackend(name) {
-			return errors.New("specified backend does not exist: " + name)
-		}
-		be := backend.GetBackend(name)
-		configuration := structs.Map(a.config.OrbAgent.Otel)
-		configuration["agent_tags"] = a.config.OrbAgent.Tags
-		if err := be.Configure(a.logger, a.policyManager.GetRepo(), configurationEntry, configuration); err != nil {
-			a.logger.Info("failed to configure backend", zap.String("backend", name), zap.Error(err))
+		if err := a.startBackend(agentCtx, name, configurationEntry); err != nil {
 			return err
 		}
-		backendCtx := context.WithValue(agentCtx, "routine", name)
-		if a.config.OrbAgent.Cloud.MQTT.Id != "" {
-			backendCtx = context.WithValue(backendCtx, "agent_id", a.config.OrbAgent.Cloud.MQTT.Id)
-		} else {
-			backendCtx = context.WithValue(backendCtx, "agent_id", "auto-provisioning-without-id")
+	}
+	return nil
+}
+
+func (a *orbAgent) startBackend(agentCtx context.Context, name string, configurationEntry map[string]string) error {
+	if !backend.HaveBackend(name) {
+		return errors.New("specified backend does not exist: " + name)
+	}
+	be := backend.GetBackend(name)
+	configuration := structs.Map(a.config.OrbAgent.Otel)
+	configuration["agent_tags"] = a.agentTags()
+	if err := be.Configure(a.logger, a.policyManager.GetRepo(), configurationEntry, configuration); err != nil {
+		a.logger.Info("failed to configure backend", zap.String("backend", name), zap.Error(err))
+		return err
+	}
+	backendCtx := context.WithValue(agentCtx, "routine", name)
+	if a.config.OrbAgent.Cloud.MQTT.Id != "" {
+		backendCtx = context.WithValue(backendCtx, "agent_id", a.config.OrbAgent.Cloud.MQTT.Id)
+	} else {
+		backendCtx = context.WithValue(backendCtx, "agent_id", "auto-provisioning-without-id")
+	}
+	initialState := be.GetInitialState()
+	a.stateMu.Lock()
+	a.backends[name] = be
+	a.backendState[name] = &backend.State{
+		Status:        initialState,
+		LastRestartTS: time.Now(),
+	}
+	a.stateMu.Unlock()
+	if err := be.Start(context.WithCancel(backendCtx)); err != nil {
+		a.logger.Info("failed to start backend", zap.String("backend", name), zap.Error(err))
+		var errMessage string
+		if initialState == backend.BackendError {
+			errMessage = err.Error()
 		}
-		a.backends[name] = be
-		initialState := be.GetInitialState()
+		a.stateMu.Lock()
 		a.backendState[name] = &backend.State{
 			Status:        initialState,
+			LastError:     errMessage,
 			LastRestartTS: time.Now(),
 		}
-		if err := be.Start(context.WithCancel(backendCtx)); err != nil {
-			a.logger.Info("failed to start backend", zap.String("backend", name), zap.Error(err))
-			var errMessage string
-			if initialState == backend.BackendError {
-				errMessage = err.Error()
-			}
-			a.backendState[name] = &backend.State{
-				Status:        initialState,
-				LastError:     errMessage,
-				LastRestartTS: time.Now(),
-			}
-			return err
-		}
+		a.stateMu.Unlock()
+		return err
 	}
 	return nil
 }
 
+// runningBackends returns a copy of the backends, to range over them while the configuration may be reloaded
+func (a *orbAgent) runningBackends() map[string]backend.Backend {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	backends := make(map[string]backend.Backend, len(a.backends))
+	for name, be := range a.backends {
+		backends[name] = be
+	}
+	return backends
+}
+
+// getBackend returns the backend with its configuration entry, while the configuration may be reloaded
+func (a *orbAgent) getBackend(name string) (backend.Backend, map[string]string, bool) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	be, ok := a.backends[name]
+	return be, a.config.OrbAgent.Backends[name], ok
+}
+
 func (a *orbAgent) Start(ctx context.Context, cancelFunc context.CancelFunc) error {
 	startTime := time.Now()
 	defer func(t time.Time) {
@@ -170,6 +238,25 @@ func (a *orbAgent) Start(ctx context.Context, cancelFunc context.CancelFunc) err
 		mqtt.DEBUG = &agentLoggerDebug{a: a}
 	}
 
+	if a.config.OrbAgent.StatusServer.Enabled {
+		if err := a.startStatusServer(); err != nil {
+			return err
+		}
+	}
+
+	if a.tagResolver != nil && a.config.OrbAgent.TagProviders.RefreshInterval > 0 {
+		tagsCtx, _ := a.extendContext("tags")
+		go a.refreshTags(tagsCtx, a.config.OrbAgent.TagProviders.RefreshInterval)
+	}
+
+	if a.config.OrbAgent.Standalone.Enabled {
+		if err := a.startBackends(ctx); err != nil {
+			return err
+		}
+		a.startStandalone()
+		return nil
+	}
+
 	ccm, err := cloud_config.New(a.logger, a.config, a.db)
 	if err != nil {
 		return err
@@ -180,7 +267,7 @@ func (a *orbAgent) Start(ctx context.Context, cancelFunc context.CancelFunc) err
 	}
 
 	commsCtx := context.WithValue(agentCtx, "routine", "comms")
-	if err := a.startComms(commsCtx, cloudConfig); err != nil {
+	if err := a.startCommsWithRotatedKey(commsCtx, ccm, cloudConfig); err != nil {
 		a.logger.Error("could not start mqtt client")
 		return err
 	}
@@ -218,7 +305,7 @@ func (a *orbAgent) Stop(ctx context.Context) {
 	if a.rpcFromCancelFunc != nil {
 		a.rpcFromCancelFunc()
 	}
-	for name, b := range a.backends {
+	for name, b := range a.runningBackends() {
 		if state, _, _ := b.GetRunningStatus(); state == backend.Running {
 			a.logger.Debug("stopping backend", zap.String("backend", name))
 			if err := b.Stop(ctx); err != nil {
@@ -230,13 +317,8 @@ func (a *orbAgent) Stop(ctx context.Context) {
 	if a.client != nil && a.client.IsConnected() {
 		a.client.Disconnect(0)
 	}
+	a.stopStatusServer(ctx)
 	a.logger.Debug("stopping agent with number of go routines and go calls", zap.Int("goroutines", runtime.NumGoroutine()), zap.Int64("gocalls", runtime.NumCgoCall()))
-	if a.policyRequestSucceeded != nil {
-		a.policyRequestSucceeded()
-	}
-	if a.groupRequestSucceeded != nil {
-		a.groupRequestSucceeded()
-	}
 	defer a.cancelFunction()
 }
 
@@ -245,28 +327,46 @@ func (a *orbAgent) RestartBackend(ctx context.Context, name string, reason strin
 		return errors.New("specified backend does not exist: " + name)
 	}
 
-	be := a.backends[name]
+	be, configurationEntry, ok := a.getBackend(name)
+	if !ok {
+		return errors.New("backend is not running: " + name)
+	}
 	a.logger.Info("restarting backend", zap.String("backend", name), zap.String("reason", reason))
-	a.backendState[name].RestartCount += 1
-	a.backendState[name].LastRestartTS = time.Now()
-	a.backendState[name].LastRestartReason = reason
+	a.stateMu.Lock()
+	if state, ok := a.backendState[name]; ok {
+		state.RestartCount += 1
+		state.LastRestartTS = time.Now()
+		state.LastRestartReason = reason
+	}
+	a.stateMu.Unlock()
+	if a.metrics != nil {
+		a.metrics.backendRestarts.WithLabelValues(name).Inc()
+	}
 	a.logger.Info("removing policies", zap.String("backend", name))
 	if err := a.policyManager.RemoveBackendPolicies(be, true); err != nil {
 		a.logger.Error("failed to remove policies", zap.String("backend", name), zap.Error(err))
 	}
 	configuration := structs.Map(a.config.OrbAgent.Otel)
-	configuration["agent_tags"] = a.config.OrbAgent.Tags
-	if err := be.Configure(a.logger, a.policyManager.GetRepo(), a.config.OrbAgent.Backends[name], configuration); err != nil {
+	configuration["agent_tags"] = a.agentTags()
+	if err := be.Configure(a.logger, a.policyManager.GetRepo(), configurationEntry, configuration); err != nil {
 		return err
 	}
 	a.logger.Info("resetting backend", zap.String("backend", name))
 
 	if err := be.FullReset(ctx); err != nil {
-		a.backendState[name].LastError = fmt.Sprintf("failed to reset backend: %v", err)
+		a.stateMu.Lock()
+		if state, ok := a.backendState[name]; ok {
+			state.LastError = fmt.Sprintf("failed to reset backend: %v", err)
+		}
+		a.stateMu.Unlock()
 		a.logger.Error("failed to reset backend", zap.String("backend", name), zap.Error(err))
 	}
-	be.SetCommsClient(a.agent_id, &a.client, fmt.Sprintf("%s/?/%s", a.baseTopic, name))
+	be.SetCommsClient(a.agent_id, a.exportClient(&a.client), fmt.Sprintf("%s/?/%s", a.baseTopic, name))
+	a.policyManager.ApplyLocalPolicies()
 
+	if a.config.OrbAgent.Standalone.Enabled {
+		return nil
+	}
 	if err := a.sendAgentPoliciesReq(); err != nil {
 		a.logger.Error("failed to send agent policies request", zap.Error(err))
 	}
@@ -285,7 +385,7 @@ func (a *orbAgent) restartComms(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := a.startComms(ctx, cloudConfig); err != nil {
+	if err := a.startCommsWithRotatedKey(ctx, ccm, cloudConfig); err != nil {
 		a.logger.Error("could not restart mqtt client")
 		return err
 	}
@@ -299,11 +399,15 @@ func (a *orbAgent) RestartAll(ctx context.Context, reason string) error {
 		ctx = context.WithValue(ctx, "agent_id", "auto-provisioning-without-id")
 	}
 	a.logoffWithHeartbeat(ctx)
-	a.logger.Info("restarting comms", zap.String("reason", reason))
-	if err := a.restartComms(ctx); err != nil {
-		a.logger.Error("failed to restart comms", zap.Error(err))
+	if !a.config.OrbAgent.Standalone.Enabled {
+		a.logger.Info("restarting comms", zap.String("reason", reason))
+		if err := a.restartComms(ctx); err != nil {
+			a.logger.Error("failed to restart comms", zap.Error(err))
+		}
 	}
-	for name := range a.backends {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	for name := range a.runningBackends() {
 		a.logger.Info("restarting backend", zap.String("backend", name), zap.String("reason", reason))
 		err := a.RestartBackend(ctx, name, reason)
 		if err != nil {
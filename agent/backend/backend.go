@@ -69,6 +69,25 @@ type Backend interface {
 
 	ApplyPolicy(data policies.PolicyData, updatePolicy bool) error
 	RemovePolicy(data policies.PolicyData) error
+	TestPolicy(data policies.PolicyData) error
+}
+
+// ComponentHealth is the last status reported by a component running inside a backend
+type ComponentHealth struct {
+	Status string
+	Error  string
+}
+
+// ComponentHealthReporter is implemented by backends that run their components in process and can report
+// the health of each one, keyed by the policy and component they belong to
+type ComponentHealthReporter interface {
+	GetComponentHealth() map[string]ComponentHealth
+}
+
+// DiagnosticsReporter is implemented by backends that add their own state to the diagnostic bundles of the agent,
+// keyed by the file name it is stored under
+type DiagnosticsReporter interface {
+	GetDiagnostics() map[string][]byte
 }
 
 var registry = make(map[string]Backend)
@@ -17,10 +17,12 @@ import (
 	"go.uber.org/zap"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 )
 
 var _ backend.Backend = (*openTelemetryBackend)(nil)
+var _ backend.DiagnosticsReporter = (*openTelemetryBackend)(nil)
 
 const DefaultPath = "/usr/local/bin/otelcol-contrib"
 const DefaultHost = "localhost"
@@ -37,8 +39,10 @@ type openTelemetryBackend struct {
 
 	// Context for controlling the context cancellation
 	mainContext        context.Context
-	runningCollectors  map[string]runningPolicy
 	mainCancelFunction context.CancelFunc
+	// runningCollectorsMu guards runningCollectors, read by the diagnostics and status requests while policies change
+	runningCollectorsMu sync.RWMutex
+	runningCollectors   map[string]runningPolicy
 
 	// MQTT Config for OTEL MQTT Exporter
 	mqttConfig config.MQTTConfig
@@ -135,7 +139,9 @@ func (o *openTelemetryBackend) Version() (string, error) {
 }
 
 func (o *openTelemetryBackend) Start(ctx context.Context, cancelFunc context.CancelFunc) (err error) {
+	o.runningCollectorsMu.Lock()
 	o.runningCollectors = make(map[string]runningPolicy)
+	o.runningCollectorsMu.Unlock()
 	o.mainCancelFunction = cancelFunc
 	o.mainContext = ctx
 	o.startTime = time.Now()
@@ -172,6 +178,8 @@ func (o *openTelemetryBackend) Start(ctx context.Context, cancelFunc context.Can
 func (o *openTelemetryBackend) Stop(_ context.Context) error {
 	o.logger.Info("stopping all running policies")
 	o.mainCancelFunction()
+	o.runningCollectorsMu.RLock()
+	defer o.runningCollectorsMu.RUnlock()
 	for policyID, policyEntry := range o.runningCollectors {
 		o.logger.Debug("stopping policy context", zap.String("policy_id", policyID))
 		policyEntry.ctx.Done()
@@ -180,7 +188,10 @@ func (o *openTelemetryBackend) Stop(_ context.Context) error {
 }
 
 func (o *openTelemetryBackend) FullReset(ctx context.Context) error {
-	o.logger.Info("restarting otel backend", zap.Int("running policies", len(o.runningCollectors)))
+	o.runningCollectorsMu.RLock()
+	running := len(o.runningCollectors)
+	o.runningCollectorsMu.RUnlock()
+	o.logger.Info("restarting otel backend", zap.Int("running policies", running))
 	backendCtx, cancelFunc := context.WithCancel(context.WithValue(ctx, "routine", "otel"))
 	if err := o.Start(backendCtx, cancelFunc); err != nil {
 		return err
@@ -206,7 +217,9 @@ func (o *openTelemetryBackend) GetCapabilities() (capabilities map[string]interf
 
 // GetRunningStatus returns cross-reference the Processes using the os, with the policies and contexts
 func (o *openTelemetryBackend) GetRunningStatus() (backend.RunningStatus, string, error) {
+	o.runningCollectorsMu.RLock()
 	amountCollectors := len(o.runningCollectors)
+	o.runningCollectorsMu.RUnlock()
 	if amountCollectors > 0 {
 		return backend.Running, fmt.Sprintf("opentelemetry backend running with %d policies", amountCollectors), nil
 	}
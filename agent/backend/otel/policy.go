@@ -5,15 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/go-cmd/cmd"
+	"github.com/orb-community/orb/agent/backend"
 	"github.com/orb-community/orb/agent/policies"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
 )
 
-const tempFileNamePattern = "otel-%s-config.yml"
+const (
+	tempFileNamePattern   = "otel-%s-config.yml"
+	testFileNamePattern   = "otel-%s-test.yml"
+	validatePolicyTimeout = 30 * time.Second
+	// stderrLines is how many of the last lines a collector wrote to stderr are kept for diagnostics
+	stderrLines = 500
+)
 
 type runningPolicy struct {
 	ctx           context.Context
@@ -22,6 +31,7 @@ type runningPolicy struct {
 	telemetryPort int
 	policyData    policies.PolicyData
 	statusChan    *cmd.Status
+	stderr        *backend.LineBuffer
 }
 
 func (o *openTelemetryBackend) ApplyPolicy(newPolicyData policies.PolicyData, updatePolicy bool) error {
@@ -93,9 +103,63 @@ func (o *openTelemetryBackend) ApplyPolicy(newPolicyData policies.PolicyData, up
 	return nil
 }
 
+// TestPolicy renders the policy as it would be applied and checks it with the collector validate command, without running it
+func (o *openTelemetryBackend) TestPolicy(data policies.PolicyData) error {
+	o.logger.Debug("testing policy", zap.String("policy_id", data.ID))
+	policyYaml, err := yaml.Marshal(data.Data)
+	if err != nil {
+		return err
+	}
+	builder := getExporterBuilder(o.logger, o.otelReceiverHost, o.otelReceiverPort)
+	otelConfig, err := builder.GetStructFromYaml(string(policyYaml))
+	if err != nil {
+		return err
+	}
+	if err = o.ValidatePolicy(otelConfig); err != nil {
+		return err
+	}
+	otelConfig, err = builder.MergeDefaultValueWithPolicy(otelConfig, data.ID, data.Name)
+	if err != nil {
+		return err
+	}
+	testPolicyYaml, err := yaml.Marshal(otelConfig)
+	if err != nil {
+		return err
+	}
+	testPolicyPath := fmt.Sprintf("%s/%s", o.policyConfigDirectory, fmt.Sprintf(testFileNamePattern, data.ID))
+	if err := os.WriteFile(testPolicyPath, testPolicyYaml, os.ModeTemporary); err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(testPolicyPath); err != nil {
+			o.logger.Warn("failed to remove policy test file", zap.String("policy_id", data.ID), zap.Error(err))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(o.mainContext, validatePolicyTimeout)
+	defer cancel()
+	command := cmd.NewCmd(o.otelExecutablePath, "validate", "--config", testPolicyPath)
+	status := command.Start()
+	select {
+	case finalStatus := <-status:
+		if finalStatus.Error != nil {
+			return finalStatus.Error
+		}
+		if finalStatus.Exit != 0 {
+			return fmt.Errorf("otel validate exited with code %d: %s", finalStatus.Exit, strings.Join(finalStatus.Stderr, "\n"))
+		}
+	case <-ctx.Done():
+		_ = command.Stop()
+		return fmt.Errorf("otel validate timed out: %w", ctx.Err())
+	}
+
+	return nil
+}
+
 func (o *openTelemetryBackend) addRunner(policyData policies.PolicyData, policyFilePath string) error {
 	policyContext, policyCancel := context.WithCancel(context.WithValue(o.mainContext, "policy_id", policyData.ID))
 	command := cmd.NewCmdOptions(cmd.Options{Buffered: false, Streaming: true}, o.otelExecutablePath, "--config", policyFilePath)
+	stderr := backend.NewLineBuffer(stderrLines)
 	go func(ctx context.Context, logger *zap.Logger) {
 		status := command.Start()
 		o.logger.Info("starting otel policy", zap.String("policy_id", policyData.ID),
@@ -115,6 +179,7 @@ func (o *openTelemetryBackend) addRunner(policyData policies.PolicyData, policyF
 				}
 			case line := <-command.Stderr:
 				if line != "" {
+					stderr.Add(line)
 					logger.Warn("otel stderr", zap.String("policy_id", policyData.ID), zap.String("line", line))
 				}
 			case finalStatus := <-status:
@@ -129,6 +194,7 @@ func (o *openTelemetryBackend) addRunner(policyData policies.PolicyData, policyF
 		policyId:   policyData.ID,
 		policyData: policyData,
 		statusChan: &status,
+		stderr:     stderr,
 	}
 	o.addPolicyControl(policyEntry, policyData.ID)
 
@@ -136,11 +202,29 @@ func (o *openTelemetryBackend) addRunner(policyData policies.PolicyData, policyF
 }
 
 func (o *openTelemetryBackend) addPolicyControl(policyEntry runningPolicy, policyID string) {
+	o.runningCollectorsMu.Lock()
+	defer o.runningCollectorsMu.Unlock()
 	o.runningCollectors[policyID] = policyEntry
 }
 
+// GetDiagnostics returns the last lines each collector wrote to stderr
+func (o *openTelemetryBackend) GetDiagnostics() map[string][]byte {
+	o.runningCollectorsMu.RLock()
+	defer o.runningCollectorsMu.RUnlock()
+	diagnostics := make(map[string][]byte, len(o.runningCollectors))
+	for policyID, policy := range o.runningCollectors {
+		if policy.stderr == nil {
+			continue
+		}
+		diagnostics[fmt.Sprintf("otel-%s-stderr.log", policyID)] = []byte(strings.Join(policy.stderr.Lines(), "\n"))
+	}
+	return diagnostics
+}
+
 func (o *openTelemetryBackend) removePolicyControl(policyID string) {
+	o.runningCollectorsMu.RLock()
 	policy, ok := o.runningCollectors[policyID]
+	o.runningCollectorsMu.RUnlock()
 	if !ok {
 		o.logger.Error("did not find a running collector for policy id", zap.String("policy_id", policyID))
 		return
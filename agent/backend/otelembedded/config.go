@@ -0,0 +1,183 @@
+package otelembedded
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"gopkg.in/yaml.v3"
+)
+
+// policyConfig is the part of a collector configuration a policy describes, exporters are ignored since all
+// pipelines export through the agent mqtt connection
+type policyConfig struct {
+	Receivers  map[string]map[string]interface{} `yaml:"receivers"`
+	Processors map[string]map[string]interface{} `yaml:"processors"`
+	Exporters  map[string]interface{}            `yaml:"exporters,omitempty"`
+	Service    struct {
+		Pipelines map[string]pipelineConfig `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+type pipelineConfig struct {
+	Receivers  []string `yaml:"receivers"`
+	Processors []string `yaml:"processors"`
+	Exporters  []string `yaml:"exporters,omitempty"`
+}
+
+// compiledPolicy is a policy with every component resolved against the curated factories and validated
+type compiledPolicy struct {
+	receivers  map[component.ID]component.Config
+	processors map[component.ID]component.Config
+	pipelines  []compiledPipeline
+}
+
+type compiledPipeline struct {
+	id         component.ID
+	receivers  []component.ID
+	processors []component.ID
+}
+
+func (p compiledPipeline) signal() component.DataType {
+	return p.id.Type()
+}
+
+func (f factories) compile(data interface{}) (*compiledPolicy, error) {
+	policyYaml, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var config policyConfig
+	if err := yaml.Unmarshal(policyYaml, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Service.Pipelines) == 0 {
+		return nil, errors.New("no pipelines defined")
+	}
+	if len(config.Receivers) == 0 {
+		return nil, errors.New("no receivers defined")
+	}
+
+	compiled := &compiledPolicy{
+		receivers:  make(map[component.ID]component.Config, len(config.Receivers)),
+		processors: make(map[component.ID]component.Config, len(config.Processors)),
+	}
+	for name, section := range config.Receivers {
+		id, err := parseID(name)
+		if err != nil {
+			return nil, fmt.Errorf("receiver %q: %w", name, err)
+		}
+		factory, ok := f.receivers[id.Type()]
+		if !ok {
+			return nil, fmt.Errorf("receiver %q is not supported by the %s backend", name, BackendName)
+		}
+		cfg, err := unmarshalComponentConfig(factory, section)
+		if err != nil {
+			return nil, fmt.Errorf("receiver %q: %w", name, err)
+		}
+		compiled.receivers[id] = cfg
+	}
+	for name, section := range config.Processors {
+		id, err := parseID(name)
+		if err != nil {
+			return nil, fmt.Errorf("processor %q: %w", name, err)
+		}
+		factory, ok := f.processors[id.Type()]
+		if !ok {
+			return nil, fmt.Errorf("processor %q is not supported by the %s backend", name, BackendName)
+		}
+		cfg, err := unmarshalComponentConfig(factory, section)
+		if err != nil {
+			return nil, fmt.Errorf("processor %q: %w", name, err)
+		}
+		compiled.processors[id] = cfg
+	}
+
+	names := make([]string, 0, len(config.Service.Pipelines))
+	for name := range config.Service.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// a receiver feeding two pipelines of the same signal would need a fan out, which policies do not need
+	receiversBySignal := make(map[component.DataType]map[component.ID]bool)
+	for _, name := range names {
+		pipeline, err := f.compilePipeline(name, config.Service.Pipelines[name], compiled)
+		if err != nil {
+			return nil, err
+		}
+		used, ok := receiversBySignal[pipeline.signal()]
+		if !ok {
+			used = make(map[component.ID]bool)
+			receiversBySignal[pipeline.signal()] = used
+		}
+		for _, id := range pipeline.receivers {
+			if used[id] {
+				return nil, fmt.Errorf("pipeline %q: receiver %q is used by more than one %s pipeline", name, id, pipeline.signal())
+			}
+			used[id] = true
+		}
+		compiled.pipelines = append(compiled.pipelines, pipeline)
+	}
+	return compiled, nil
+}
+
+func (f factories) compilePipeline(name string, config pipelineConfig, compiled *compiledPolicy) (compiledPipeline, error) {
+	id, err := parseID(name)
+	if err != nil {
+		return compiledPipeline{}, fmt.Errorf("pipeline %q: %w", name, err)
+	}
+	signal := id.Type()
+	if signal != component.DataTypeMetrics && signal != component.DataTypeLogs && signal != component.DataTypeTraces {
+		return compiledPipeline{}, fmt.Errorf("pipeline %q: unknown signal %q", name, signal)
+	}
+	if len(config.Receivers) == 0 {
+		return compiledPipeline{}, fmt.Errorf("pipeline %q: no receivers defined", name)
+	}
+	pipeline := compiledPipeline{id: id}
+	for _, ref := range config.Receivers {
+		receiverID, err := parseID(ref)
+		if err != nil {
+			return compiledPipeline{}, fmt.Errorf("pipeline %q: receiver %q: %w", name, ref, err)
+		}
+		if _, ok := compiled.receivers[receiverID]; !ok {
+			return compiledPipeline{}, fmt.Errorf("pipeline %q: receiver %q is not defined", name, ref)
+		}
+		if receiverStability(f.receivers[receiverID.Type()], signal) == component.StabilityLevelUndefined {
+			return compiledPipeline{}, fmt.Errorf("pipeline %q: receiver %q does not support %s", name, ref, signal)
+		}
+		pipeline.receivers = append(pipeline.receivers, receiverID)
+	}
+	for _, ref := range config.Processors {
+		processorID, err := parseID(ref)
+		if err != nil {
+			return compiledPipeline{}, fmt.Errorf("pipeline %q: processor %q: %w", name, ref, err)
+		}
+		if _, ok := compiled.processors[processorID]; !ok {
+			return compiledPipeline{}, fmt.Errorf("pipeline %q: processor %q is not defined", name, ref)
+		}
+		if processorStability(f.processors[processorID.Type()], signal) == component.StabilityLevelUndefined {
+			return compiledPipeline{}, fmt.Errorf("pipeline %q: processor %q does not support %s", name, ref, signal)
+		}
+		pipeline.processors = append(pipeline.processors, processorID)
+	}
+	return pipeline, nil
+}
+
+func parseID(name string) (component.ID, error) {
+	var id component.ID
+	err := id.UnmarshalText([]byte(name))
+	return id, err
+}
+
+func unmarshalComponentConfig(factory component.Factory, section map[string]interface{}) (component.Config, error) {
+	cfg := factory.CreateDefaultConfig()
+	if err := confmap.NewFromStringMap(section).Unmarshal(cfg, confmap.WithErrorUnused()); err != nil {
+		return nil, err
+	}
+	if err := component.ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,196 @@
+package otelembedded
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/policies"
+	"go.uber.org/zap"
+)
+
+var _ backend.Backend = (*embeddedBackend)(nil)
+var _ backend.ComponentHealthReporter = (*embeddedBackend)(nil)
+
+const BackendName = "otel-embedded"
+
+// collectorModule is the module the curated components are versioned with
+const collectorModule = "go.opentelemetry.io/collector/receiver/otlpreceiver"
+
+// embeddedBackend runs the policy pipelines inside the agent process, so policies are added and removed
+// without starting or stopping any collector process
+type embeddedBackend struct {
+	logger    *zap.Logger
+	startTime time.Time
+
+	//policies
+	policyRepo policies.PolicyRepo
+	agentTags  map[string]string
+
+	// Context for controlling the context cancellation
+	mainContext        context.Context
+	mainCancelFunction context.CancelFunc
+
+	mqttClient       *mqtt.Client
+	otlpMetricsTopic string
+	otlpTracesTopic  string
+	otlpLogsTopic    string
+
+	factories factories
+
+	mu       sync.Mutex
+	exporter *sharedExporters
+	running  map[string]*policyPipelines
+
+	// health is guarded on its own, components report their status while the pipelines are being changed
+	healthMu sync.Mutex
+	health   map[string]backend.ComponentHealth
+}
+
+// Configure initializes the backend with the given configuration
+func (e *embeddedBackend) Configure(logger *zap.Logger, repo policies.PolicyRepo,
+	_ map[string]string, otelConfig map[string]interface{}) error {
+	e.logger = logger
+	e.logger.Info("configuring embedded OpenTelemetry backend")
+	e.policyRepo = repo
+	if agentTags, ok := otelConfig["agent_tags"]; ok {
+		e.agentTags = agentTags.(map[string]string)
+	}
+	var err error
+	e.factories, err = curatedFactories()
+	if err != nil {
+		return err
+	}
+	e.running = make(map[string]*policyPipelines)
+	e.health = make(map[string]backend.ComponentHealth)
+	return nil
+}
+
+func (e *embeddedBackend) GetInitialState() backend.RunningStatus {
+	return backend.Waiting
+}
+
+// Version reports the version of the collector components compiled into the agent
+func (e *embeddedBackend) Version() (string, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", fmt.Errorf("build info is not available")
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == collectorModule {
+			return dep.Version, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not part of the build", collectorModule)
+}
+
+func (e *embeddedBackend) Start(ctx context.Context, cancelFunc context.CancelFunc) error {
+	e.mainContext = ctx
+	e.mainCancelFunction = cancelFunc
+	e.startTime = time.Now()
+	currentVersion, err := e.Version()
+	if err != nil {
+		e.logger.Warn("could not determine embedded collector version", zap.Error(err))
+	}
+	e.logger.Info("starting embedded open-telemetry backend using version", zap.String("version", currentVersion))
+	policiesData, err := e.policyRepo.GetAll()
+	if err != nil {
+		cancelFunc()
+		e.logger.Error("failed to start embedded otel backend, policies are absent")
+		return err
+	}
+	for _, policyData := range policiesData {
+		if err := e.ApplyPolicy(policyData, true); err != nil {
+			e.logger.Error("failed to start embedded otel backend, failed to apply policy", zap.Error(err))
+			cancelFunc()
+			return err
+		}
+		e.logger.Info("policy applied successfully", zap.String("policy_id", policyData.ID))
+	}
+	return nil
+}
+
+func (e *embeddedBackend) Stop(ctx context.Context) error {
+	e.logger.Info("stopping all running policies")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for policyID := range e.running {
+		e.stopPolicy(ctx, policyID)
+	}
+	if e.exporter != nil {
+		if err := e.exporter.shutdown(ctx); err != nil {
+			e.logger.Warn("failed to shutdown mqtt exporters", zap.Error(err))
+		}
+		e.exporter = nil
+	}
+	if e.mainCancelFunction != nil {
+		e.mainCancelFunction()
+	}
+	return nil
+}
+
+func (e *embeddedBackend) FullReset(ctx context.Context) error {
+	e.logger.Info("restarting embedded otel backend", zap.Int("running policies", len(e.running)))
+	if err := e.Stop(ctx); err != nil {
+		return err
+	}
+	backendCtx, cancelFunc := context.WithCancel(context.WithValue(ctx, "routine", BackendName))
+	return e.Start(backendCtx, cancelFunc)
+}
+
+func Register() bool {
+	backend.Register(BackendName, &embeddedBackend{})
+	return true
+}
+
+func (e *embeddedBackend) GetStartTime() time.Time {
+	return e.startTime
+}
+
+// GetCapabilities lists the components policies may use
+func (e *embeddedBackend) GetCapabilities() (map[string]interface{}, error) {
+	capabilities := make(map[string]interface{})
+	capabilities["receivers"] = e.factories.receiverTypes()
+	capabilities["processors"] = e.factories.processorTypes()
+	return capabilities, nil
+}
+
+// GetRunningStatus reports running while any policy pipeline is running, component failures are reported
+// through the component health instead, so one broken policy does not restart the others
+func (e *embeddedBackend) GetRunningStatus() (backend.RunningStatus, string, error) {
+	e.mu.Lock()
+	amountPolicies := len(e.running)
+	e.mu.Unlock()
+	if amountPolicies > 0 {
+		return backend.Running, fmt.Sprintf("embedded opentelemetry backend running with %d policies", amountPolicies), nil
+	}
+	return backend.Waiting, "embedded opentelemetry backend is waiting for policy to come to start running", nil
+}
+
+// GetComponentHealth returns the last status each running component reported
+func (e *embeddedBackend) GetComponentHealth() map[string]backend.ComponentHealth {
+	e.healthMu.Lock()
+	defer e.healthMu.Unlock()
+	health := make(map[string]backend.ComponentHealth, len(e.health))
+	for key, h := range e.health {
+		health[key] = h
+	}
+	return health
+}
+
+func (e *embeddedBackend) recordHealth(key string, status string, err error) {
+	h := backend.ComponentHealth{Status: status}
+	if err != nil {
+		h.Error = err.Error()
+	}
+	e.healthMu.Lock()
+	defer e.healthMu.Unlock()
+	// a component of a removed policy may still report while shutting down
+	if _, ok := e.health[key]; ok {
+		e.health[key] = h
+	}
+}
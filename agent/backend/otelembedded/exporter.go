@@ -0,0 +1,79 @@
+package otelembedded
+
+import (
+	"context"
+	"errors"
+
+	"github.com/orb-community/orb/agent/otel"
+	"github.com/orb-community/orb/agent/otel/otlpmqttexporter"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter"
+)
+
+var errCommsNotReady = errors.New("mqtt connection is not ready, policy can not be exported")
+
+// sharedExporters are the otlp mqtt exporters every policy pipeline ends in, the exporter routes the data of
+// each policy by the policy name stamped on it
+type sharedExporters struct {
+	cancel  context.CancelCauseFunc
+	metrics exporter.Metrics
+	logs    exporter.Logs
+	traces  exporter.Traces
+}
+
+// sinkConsumers returns the mqtt exporters, creating them on first use, it must be called holding mu
+func (e *embeddedBackend) sinkConsumers() (sinkConsumers, error) {
+	if e.exporter == nil {
+		if e.mqttClient == nil {
+			return sinkConsumers{}, errCommsNotReady
+		}
+		exporters, err := e.createExporters()
+		if err != nil {
+			return sinkConsumers{}, err
+		}
+		e.exporter = exporters
+	}
+	return sinkConsumers{metrics: e.exporter.metrics, logs: e.exporter.logs, traces: e.exporter.traces}, nil
+}
+
+func (e *embeddedBackend) createExporters() (*sharedExporters, error) {
+	ctx, cancel := context.WithCancelCause(e.mainContext)
+	exporters := &sharedExporters{cancel: cancel}
+	bridgeService := otel.NewBridgeService(ctx, cancel, &e.policyRepo, e.agentTags)
+	set := otlpmqttexporter.CreateDefaultSettings(e.logger)
+
+	var err error
+	cfg := otlpmqttexporter.CreateConfigClient(e.mqttClient, e.otlpMetricsTopic, "", bridgeService)
+	if exporters.metrics, err = otlpmqttexporter.CreateMetricsExporter(ctx, set, cfg); err != nil {
+		cancel(err)
+		return nil, err
+	}
+	cfg = otlpmqttexporter.CreateConfigClient(e.mqttClient, e.otlpLogsTopic, "", bridgeService)
+	if exporters.logs, err = otlpmqttexporter.CreateLogsExporter(ctx, set, cfg); err != nil {
+		cancel(err)
+		return nil, err
+	}
+	cfg = otlpmqttexporter.CreateConfigClient(e.mqttClient, e.otlpTracesTopic, "", bridgeService)
+	if exporters.traces, err = otlpmqttexporter.CreateTracesExporter(ctx, set, cfg); err != nil {
+		cancel(err)
+		return nil, err
+	}
+
+	for _, exp := range []component.Component{exporters.metrics, exporters.logs, exporters.traces} {
+		if err := exp.Start(ctx, nil); err != nil {
+			_ = exporters.shutdown(ctx)
+			return nil, err
+		}
+	}
+	return exporters, nil
+}
+
+func (s *sharedExporters) shutdown(ctx context.Context) error {
+	err := errors.Join(
+		s.metrics.Shutdown(ctx),
+		s.logs.Shutdown(ctx),
+		s.traces.Shutdown(ctx),
+	)
+	s.cancel(context.Canceled)
+	return err
+}
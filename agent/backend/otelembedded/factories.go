@@ -0,0 +1,75 @@
+package otelembedded
+
+import (
+	"sort"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/transformprocessor"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/otlpreceiver"
+)
+
+// factories is the curated set of components policies are allowed to use, exporting is always done by the agent
+type factories struct {
+	receivers  map[component.Type]receiver.Factory
+	processors map[component.Type]processor.Factory
+}
+
+func curatedFactories() (factories, error) {
+	receivers, err := receiver.MakeFactoryMap(
+		otlpreceiver.NewFactory(),
+	)
+	if err != nil {
+		return factories{}, err
+	}
+	processors, err := processor.MakeFactoryMap(
+		transformprocessor.NewFactory(),
+	)
+	if err != nil {
+		return factories{}, err
+	}
+	return factories{receivers: receivers, processors: processors}, nil
+}
+
+func (f factories) receiverTypes() []string {
+	types := make([]string, 0, len(f.receivers))
+	for t := range f.receivers {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (f factories) processorTypes() []string {
+	types := make([]string, 0, len(f.processors))
+	for t := range f.processors {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	return types
+}
+
+func receiverStability(factory receiver.Factory, signal component.DataType) component.StabilityLevel {
+	switch signal {
+	case component.DataTypeMetrics:
+		return factory.MetricsReceiverStability()
+	case component.DataTypeLogs:
+		return factory.LogsReceiverStability()
+	case component.DataTypeTraces:
+		return factory.TracesReceiverStability()
+	}
+	return component.StabilityLevelUndefined
+}
+
+func processorStability(factory processor.Factory, signal component.DataType) component.StabilityLevel {
+	switch signal {
+	case component.DataTypeMetrics:
+		return factory.MetricsProcessorStability()
+	case component.DataTypeLogs:
+		return factory.LogsProcessorStability()
+	case component.DataTypeTraces:
+		return factory.TracesProcessorStability()
+	}
+	return component.StabilityLevelUndefined
+}
@@ -0,0 +1,280 @@
+package otelembedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/policies"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+// Component statuses reported in the heartbeat
+const (
+	healthStarting         = "starting"
+	healthOK               = "ok"
+	healthRecoverableError = "recoverable_error"
+	healthPermanentError   = "permanent_error"
+	healthFatalError       = "fatal_error"
+	healthStopping         = "stopping"
+	healthStopped          = "stopped"
+)
+
+func healthStatus(status component.Status) string {
+	switch status {
+	case component.StatusStarting:
+		return healthStarting
+	case component.StatusOK:
+		return healthOK
+	case component.StatusRecoverableError:
+		return healthRecoverableError
+	case component.StatusPermanentError:
+		return healthPermanentError
+	case component.StatusFatalError:
+		return healthFatalError
+	case component.StatusStopping:
+		return healthStopping
+	case component.StatusStopped:
+		return healthStopped
+	}
+	return healthStarting
+}
+
+// sinkConsumers are where every pipeline of every policy ends
+type sinkConsumers struct {
+	metrics consumer.Metrics
+	logs    consumer.Logs
+	traces  consumer.Traces
+}
+
+type runningComponent struct {
+	key       string
+	component component.Component
+}
+
+// policyPipelines are the components built for one policy, in the order they must be started
+type policyPipelines struct {
+	policyID   string
+	components []runningComponent
+	// data and compiled are the policy version the components were built from, to build them again
+	data     policies.PolicyData
+	compiled *compiledPolicy
+}
+
+// componentHost reports errors raised by a component after it started into the component health
+type componentHost struct {
+	backend *embeddedBackend
+	key     string
+}
+
+func (h *componentHost) ReportFatalError(err error) {
+	h.backend.logger.Error("embedded otel component failed", zap.String("component", h.key), zap.Error(err))
+	h.backend.recordHealth(h.key, healthFatalError, err)
+}
+
+func (h *componentHost) GetFactory(_ component.Kind, _ component.Type) component.Factory {
+	return nil
+}
+
+func (h *componentHost) GetExtensions() map[component.ID]extension.Extension {
+	return nil
+}
+
+func (h *componentHost) GetExporters() map[component.DataType]map[component.ID]component.Component {
+	return nil
+}
+
+func (e *embeddedBackend) telemetrySettings(key string) component.TelemetrySettings {
+	return component.TelemetrySettings{
+		Logger:         e.logger.With(zap.String("component", key)),
+		TracerProvider: noop.NewTracerProvider(),
+		MeterProvider:  metric.NewMeterProvider(),
+		ReportComponentStatus: func(event *component.StatusEvent) error {
+			e.recordHealth(key, healthStatus(event.Status()), event.Err())
+			return nil
+		},
+	}
+}
+
+// buildPipelines creates the components of every pipeline of a policy, from the end of each pipeline to its
+// receivers, with the data stamped with the policy name right before it reaches the sinks
+func (e *embeddedBackend) buildPipelines(ctx context.Context, data policies.PolicyData, compiled *compiledPolicy, sinks sinkConsumers) (*policyPipelines, error) {
+	built := &policyPipelines{policyID: data.ID, data: data, compiled: compiled}
+	added := make(map[string]bool)
+	add := func(key string, c component.Component) {
+		// a receiver shared by pipelines of different signals is a single component
+		if added[key] {
+			return
+		}
+		added[key] = true
+		built.components = append(built.components, runningComponent{key: key, component: c})
+	}
+	for _, pipeline := range compiled.pipelines {
+		signal := pipeline.signal()
+		next, err := policyNameConsumer(signal, data.Name, sinks)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(pipeline.processors) - 1; i >= 0; i-- {
+			id := pipeline.processors[i]
+			key := fmt.Sprintf("%s/pipelines/%s/processors/%s", data.ID, pipeline.id, id)
+			set := processor.CreateSettings{
+				ID:                id,
+				TelemetrySettings: e.telemetrySettings(key),
+				BuildInfo:         component.NewDefaultBuildInfo(),
+			}
+			created, err := createProcessor(ctx, e.factories.processors[id.Type()], set, compiled.processors[id], signal, next)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline %q: processor %q: %w", pipeline.id, id, err)
+			}
+			add(key, created)
+			next = created
+		}
+		for _, id := range pipeline.receivers {
+			key := fmt.Sprintf("%s/receivers/%s", data.ID, id)
+			set := receiver.CreateSettings{
+				ID:                id,
+				TelemetrySettings: e.telemetrySettings(key),
+				BuildInfo:         component.NewDefaultBuildInfo(),
+			}
+			created, err := createReceiver(ctx, e.factories.receivers[id.Type()], set, compiled.receivers[id], signal, next)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline %q: receiver %q: %w", pipeline.id, id, err)
+			}
+			add(key, created)
+		}
+	}
+	return built, nil
+}
+
+// startPipelines starts the components in order, shutting down the ones already started if any fails
+func (e *embeddedBackend) startPipelines(ctx context.Context, p *policyPipelines) error {
+	e.healthMu.Lock()
+	for _, c := range p.components {
+		e.health[c.key] = backend.ComponentHealth{Status: healthStarting}
+	}
+	e.healthMu.Unlock()
+	for i, c := range p.components {
+		if err := c.component.Start(ctx, &componentHost{backend: e, key: c.key}); err != nil {
+			e.recordHealth(c.key, healthPermanentError, err)
+			shutdownErr := shutdownComponents(ctx, p.components[:i])
+			return errors.Join(fmt.Errorf("failed to start %s: %w", c.key, err), shutdownErr)
+		}
+		e.healthMu.Lock()
+		if e.health[c.key].Status == healthStarting {
+			e.health[c.key] = backend.ComponentHealth{Status: healthOK}
+		}
+		e.healthMu.Unlock()
+	}
+	return nil
+}
+
+func shutdownComponents(ctx context.Context, components []runningComponent) error {
+	var errs error
+	for i := len(components) - 1; i >= 0; i-- {
+		if err := components[i].component.Shutdown(ctx); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to shutdown %s: %w", components[i].key, err))
+		}
+	}
+	return errs
+}
+
+func createProcessor(ctx context.Context, factory processor.Factory, set processor.CreateSettings, cfg component.Config,
+	signal component.DataType, next interface{}) (component.Component, error) {
+	switch signal {
+	case component.DataTypeMetrics:
+		return factory.CreateMetricsProcessor(ctx, set, cfg, next.(consumer.Metrics))
+	case component.DataTypeLogs:
+		return factory.CreateLogsProcessor(ctx, set, cfg, next.(consumer.Logs))
+	case component.DataTypeTraces:
+		return factory.CreateTracesProcessor(ctx, set, cfg, next.(consumer.Traces))
+	}
+	return nil, component.ErrDataTypeIsNotSupported
+}
+
+func createReceiver(ctx context.Context, factory receiver.Factory, set receiver.CreateSettings, cfg component.Config,
+	signal component.DataType, next interface{}) (component.Component, error) {
+	switch signal {
+	case component.DataTypeMetrics:
+		return factory.CreateMetricsReceiver(ctx, set, cfg, next.(consumer.Metrics))
+	case component.DataTypeLogs:
+		return factory.CreateLogsReceiver(ctx, set, cfg, next.(consumer.Logs))
+	case component.DataTypeTraces:
+		return factory.CreateTracesReceiver(ctx, set, cfg, next.(consumer.Traces))
+	}
+	return nil, component.ErrDataTypeIsNotSupported
+}
+
+// policyNameConsumer stamps the policy name where the otlp mqtt exporter looks for it, and hands each resource
+// to the sinks on its own since the exporter only reads the first resource of a batch
+func policyNameConsumer(signal component.DataType, policyName string, sinks sinkConsumers) (interface{}, error) {
+	capabilities := consumer.WithCapabilities(consumer.Capabilities{MutatesData: true})
+	switch signal {
+	case component.DataTypeMetrics:
+		return consumer.NewMetrics(func(ctx context.Context, md pmetric.Metrics) error {
+			var errs error
+			resources := md.ResourceMetrics()
+			for i := 0; i < resources.Len(); i++ {
+				scopes := resources.At(i).ScopeMetrics()
+				if scopes.Len() == 0 {
+					continue
+				}
+				for j := 0; j < scopes.Len(); j++ {
+					scopes.At(j).Scope().Attributes().PutStr("policy_name", policyName)
+				}
+				single := pmetric.NewMetrics()
+				resources.At(i).CopyTo(single.ResourceMetrics().AppendEmpty())
+				errs = errors.Join(errs, sinks.metrics.ConsumeMetrics(ctx, single))
+			}
+			return errs
+		}, capabilities)
+	case component.DataTypeLogs:
+		return consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+			var errs error
+			resources := ld.ResourceLogs()
+			for i := 0; i < resources.Len(); i++ {
+				scopes := resources.At(i).ScopeLogs()
+				if scopes.Len() == 0 {
+					continue
+				}
+				for j := 0; j < scopes.Len(); j++ {
+					scopes.At(j).Scope().SetName(policyName)
+				}
+				single := plog.NewLogs()
+				resources.At(i).CopyTo(single.ResourceLogs().AppendEmpty())
+				errs = errors.Join(errs, sinks.logs.ConsumeLogs(ctx, single))
+			}
+			return errs
+		}, capabilities)
+	case component.DataTypeTraces:
+		return consumer.NewTraces(func(ctx context.Context, td ptrace.Traces) error {
+			var errs error
+			resources := td.ResourceSpans()
+			for i := 0; i < resources.Len(); i++ {
+				scopes := resources.At(i).ScopeSpans()
+				if scopes.Len() == 0 {
+					continue
+				}
+				for j := 0; j < scopes.Len(); j++ {
+					scopes.At(j).Scope().SetName(policyName)
+				}
+				single := ptrace.NewTraces()
+				resources.At(i).CopyTo(single.ResourceSpans().AppendEmpty())
+				errs = errors.Join(errs, sinks.traces.ConsumeTraces(ctx, single))
+			}
+			return errs
+		}, capabilities)
+	}
+	return nil, component.ErrDataTypeIsNotSupported
+}
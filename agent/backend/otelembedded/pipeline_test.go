@@ -0,0 +1,341 @@
+package otelembedded
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/agent/policies"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestBackend(t *testing.T) *embeddedBackend {
+	e := &embeddedBackend{}
+	if err := e.Configure(zap.NewNop(), nil, nil, map[string]interface{}{}); err != nil {
+		t.Fatalf("failed to configure backend: %s", err)
+	}
+	e.mainContext = context.Background()
+	return e
+}
+
+func policyFromYaml(t *testing.T, policy string) interface{} {
+	var data map[string]interface{}
+	if err := yaml.Unmarshal([]byte(policy), &data); err != nil {
+		t.Fatalf("invalid test policy: %s", err)
+	}
+	return data
+}
+
+func TestCompilePolicy(t *testing.T) {
+	cases := map[string]struct {
+		policy string
+		err    bool
+	}{
+		"valid policy": {
+			policy: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+processors:
+  transform:
+    metric_statements:
+      - context: datapoint
+        statements:
+          - set(attributes["env"], "test")
+exporters:
+  otlp:
+    endpoint: localhost:4317
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+      processors: [transform]
+      exporters: [otlp]
+    logs:
+      receivers: [otlp]
+`,
+			err: false,
+		},
+		"no pipelines": {
+			policy: `
+receivers:
+  otlp:
+`,
+			err: true,
+		},
+		"no receivers": {
+			policy: `
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+`,
+			err: true,
+		},
+		"receiver not in the curated set": {
+			policy: `
+receivers:
+  hostmetrics:
+service:
+  pipelines:
+    metrics:
+      receivers: [hostmetrics]
+`,
+			err: true,
+		},
+		"unknown receiver option": {
+			policy: `
+receivers:
+  otlp:
+    unknown: true
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+`,
+			err: true,
+		},
+		"undefined receiver in pipeline": {
+			policy: `
+receivers:
+  otlp:
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp/other]
+`,
+			err: true,
+		},
+		"undefined processor in pipeline": {
+			policy: `
+receivers:
+  otlp:
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+      processors: [transform]
+`,
+			err: true,
+		},
+		"unknown pipeline signal": {
+			policy: `
+receivers:
+  otlp:
+service:
+  pipelines:
+    profiles:
+      receivers: [otlp]
+`,
+			err: true,
+		},
+		"receiver shared by pipelines of the same signal": {
+			policy: `
+receivers:
+  otlp:
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+    metrics/other:
+      receivers: [otlp]
+`,
+			err: true,
+		},
+	}
+
+	e := newTestBackend(t)
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := e.TestPolicy(policies.PolicyData{ID: "policy", Data: policyFromYaml(t, tc.policy)})
+			if tc.err && err == nil {
+				t.Errorf("%s: expected an error, got none", desc)
+			}
+			if !tc.err && err != nil {
+				t.Errorf("%s: expected no error, got %s", desc, err)
+			}
+		})
+	}
+}
+
+func freeEndpoint(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %s", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestPolicyPipelineLifecycle(t *testing.T) {
+	endpoint := freeEndpoint(t)
+	data := policies.PolicyData{
+		ID:   "policy-id",
+		Name: "policy-name",
+		Data: policyFromYaml(t, fmt.Sprintf(`
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: %s
+processors:
+  transform:
+    metric_statements:
+      - context: datapoint
+        statements:
+          - set(attributes["env"], "test")
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+      processors: [transform]
+`, endpoint)),
+	}
+
+	e := newTestBackend(t)
+	compiled, err := e.factories.compile(data.Data)
+	if err != nil {
+		t.Fatalf("failed to compile policy: %s", err)
+	}
+	sink := new(consumertest.MetricsSink)
+	pipelines, err := e.buildPipelines(context.Background(), data, compiled, sinkConsumers{metrics: sink})
+	if err != nil {
+		t.Fatalf("failed to build policy pipelines: %s", err)
+	}
+	if err := e.startPipelines(context.Background(), pipelines); err != nil {
+		t.Fatalf("failed to start policy pipelines: %s", err)
+	}
+	e.running[data.ID] = pipelines
+
+	health := e.GetComponentHealth()
+	for _, key := range []string{"policy-id/receivers/otlp", "policy-id/pipelines/metrics/processors/transform"} {
+		if health[key].Status != healthOK {
+			t.Errorf("expected component %s to be %s, got %q", key, healthOK, health[key].Status)
+		}
+	}
+
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial receiver: %s", err)
+	}
+	defer conn.Close()
+	metrics := pmetric.NewMetrics()
+	// two resources, since the mqtt exporter only reads the first resource of a batch
+	for i := 0; i < 2; i++ {
+		m := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName(fmt.Sprintf("metric_%d", i))
+		m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := pmetricotlp.NewGRPCClient(conn).Export(ctx, pmetricotlp.NewExportRequestFromMetrics(metrics)); err != nil {
+		t.Fatalf("failed to export metrics: %s", err)
+	}
+
+	received := sink.AllMetrics()
+	if len(received) != 2 {
+		t.Fatalf("expected 2 batches, one per resource, got %d", len(received))
+	}
+	for _, md := range received {
+		if md.ResourceMetrics().Len() != 1 {
+			t.Errorf("expected a single resource per batch, got %d", md.ResourceMetrics().Len())
+		}
+		scope := md.ResourceMetrics().At(0).ScopeMetrics().At(0)
+		policyName, ok := scope.Scope().Attributes().Get("policy_name")
+		if !ok || policyName.Str() != data.Name {
+			t.Errorf("expected policy_name %s, got %q", data.Name, policyName.Str())
+		}
+		env, _ := scope.Metrics().At(0).Gauge().DataPoints().At(0).Attributes().Get("env")
+		if env.Str() != "test" {
+			t.Errorf("expected the transform processor to set env, got %q", env.Str())
+		}
+	}
+
+	if err := e.RemovePolicy(data); err != nil {
+		t.Fatalf("failed to remove policy: %s", err)
+	}
+	if len(e.GetComponentHealth()) != 0 {
+		t.Errorf("expected the health of removed policy components to be cleared, got %v", e.GetComponentHealth())
+	}
+	l, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		t.Fatalf("expected the receiver endpoint to be released after removal: %s", err)
+	}
+	l.Close()
+}
+
+func TestPolicyUpdateFailureKeepsPreviousVersion(t *testing.T) {
+	policy := func(version int32, endpoint string) policies.PolicyData {
+		return policies.PolicyData{
+			ID:      "policy-id",
+			Name:    "policy-name",
+			Version: version,
+			Data: policyFromYaml(t, fmt.Sprintf(`
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: %s
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+`, endpoint)),
+		}
+	}
+
+	e := newTestBackend(t)
+	exp, err := exportertest.NewNopFactory().CreateMetricsExporter(context.Background(), exportertest.NewNopCreateSettings(), nil)
+	if err != nil {
+		t.Fatalf("failed to create exporter: %s", err)
+	}
+	e.exporter = &sharedExporters{metrics: exp}
+
+	endpoint := freeEndpoint(t)
+	if err := e.ApplyPolicy(policy(1, endpoint), false); err != nil {
+		t.Fatalf("failed to apply policy: %s", err)
+	}
+	defer e.RemovePolicy(policy(1, endpoint))
+
+	// the new version cannot listen on an endpoint already in use
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %s", err)
+	}
+	defer l.Close()
+	if err := e.ApplyPolicy(policy(2, l.Addr().String()), false); err == nil {
+		t.Fatalf("expected the policy update to fail, got no error")
+	}
+
+	running, ok := e.running["policy-id"]
+	if !ok || running.data.Version != 1 {
+		t.Fatalf("expected version 1 of the policy to be running, got %v", running)
+	}
+	health := e.GetComponentHealth()
+	if health["policy-id/receivers/otlp"].Status != healthOK {
+		t.Errorf("expected the receiver of version 1 to be %s, got %q", healthOK, health["policy-id/receivers/otlp"].Status)
+	}
+
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial receiver: %s", err)
+	}
+	defer conn.Close()
+	metrics := pmetric.NewMetrics()
+	metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := pmetricotlp.NewGRPCClient(conn).Export(ctx, pmetricotlp.NewExportRequestFromMetrics(metrics)); err != nil {
+		t.Errorf("expected the receiver of version 1 to still accept metrics: %s", err)
+	}
+}
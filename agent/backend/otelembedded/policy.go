@@ -0,0 +1,121 @@
+package otelembedded
+
+import (
+	"context"
+	"strings"
+
+	"github.com/orb-community/orb/agent/policies"
+	"go.uber.org/zap"
+)
+
+func (e *embeddedBackend) ApplyPolicy(data policies.PolicyData, updatePolicy bool) error {
+	e.logger.Debug("applying policy", zap.String("policy_id", data.ID))
+	compiled, err := e.factories.compile(data.Data)
+	if err != nil {
+		e.logger.Warn("invalid policy", zap.String("policy_id", data.ID), zap.Error(err))
+		return err
+	}
+	if updatePolicy && e.policyRepo.Exists(data.ID) {
+		current, err := e.policyRepo.Get(data.ID)
+		if err != nil {
+			return err
+		}
+		if current.Version > data.Version {
+			e.logger.Info("current policy version is newer than the one being applied, skipping",
+				zap.String("policy_id", data.ID),
+				zap.Int32("current_version", current.Version),
+				zap.Int32("incoming_version", data.Version))
+			return nil
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	sinks, err := e.sinkConsumers()
+	if err != nil {
+		return err
+	}
+	// the previous version is stopped first, since the new one usually listens on the same endpoints
+	previous, updating := e.running[data.ID]
+	if updating {
+		e.logger.Info("received new policy version", zap.String("policy_id", data.ID), zap.Int32("version", data.Version))
+		e.stopPolicy(e.mainContext, data.ID)
+	} else {
+		e.logger.Info("received new policy", zap.String("policy_id", data.ID), zap.Int32("version", data.Version))
+	}
+	pipelines, err := e.buildPipelines(e.mainContext, data, compiled, sinks)
+	if err == nil {
+		if err = e.startPipelines(e.mainContext, pipelines); err != nil {
+			e.clearHealth(data.ID)
+		}
+	}
+	if err != nil {
+		if updating {
+			e.restorePolicy(previous, sinks)
+		}
+		return err
+	}
+	e.running[data.ID] = pipelines
+	return nil
+}
+
+// restorePolicy builds and starts again the previous version of a policy which failed to update, so the policy
+// keeps running. It must be called holding mu
+func (e *embeddedBackend) restorePolicy(previous *policyPipelines, sinks sinkConsumers) {
+	pipelines, err := e.buildPipelines(e.mainContext, previous.data, previous.compiled, sinks)
+	if err == nil {
+		if err = e.startPipelines(e.mainContext, pipelines); err != nil {
+			e.clearHealth(previous.policyID)
+		}
+	}
+	if err != nil {
+		e.logger.Error("failed to restore previous policy version", zap.String("policy_id", previous.policyID),
+			zap.Int32("version", previous.data.Version), zap.Error(err))
+		return
+	}
+	e.logger.Info("policy update failed, previous version restored", zap.String("policy_id", previous.policyID),
+		zap.Int32("version", previous.data.Version))
+	e.running[previous.policyID] = pipelines
+}
+
+// TestPolicy compiles the policy against the curated components without creating any of them
+func (e *embeddedBackend) TestPolicy(data policies.PolicyData) error {
+	e.logger.Debug("testing policy", zap.String("policy_id", data.ID))
+	_, err := e.factories.compile(data.Data)
+	return err
+}
+
+func (e *embeddedBackend) RemovePolicy(data policies.PolicyData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.running[data.ID]; !ok {
+		e.logger.Warn("no policy was removed, policy not found", zap.String("policy_id", data.ID))
+		return nil
+	}
+	e.logger.Info("removing policy", zap.String("policy_id", data.ID))
+	e.stopPolicy(e.mainContext, data.ID)
+	return nil
+}
+
+// stopPolicy shuts down the pipelines of a policy and forgets their health, it must be called holding mu
+func (e *embeddedBackend) stopPolicy(ctx context.Context, policyID string) {
+	pipelines, ok := e.running[policyID]
+	if !ok {
+		return
+	}
+	delete(e.running, policyID)
+	e.clearHealth(policyID)
+	if err := shutdownComponents(ctx, pipelines.components); err != nil {
+		e.logger.Warn("failed to shutdown policy pipelines", zap.String("policy_id", policyID), zap.Error(err))
+	}
+}
+
+func (e *embeddedBackend) clearHealth(policyID string) {
+	e.healthMu.Lock()
+	defer e.healthMu.Unlock()
+	for key := range e.health {
+		if strings.HasPrefix(key, policyID+"/") {
+			delete(e.health, key)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package otelembedded
+
+import "github.com/spf13/viper"
+
+// RegisterBackendSpecificVariables has nothing to default, pipelines are entirely described by the policies
+func RegisterBackendSpecificVariables(_ *viper.Viper) {
+}
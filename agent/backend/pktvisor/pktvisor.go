@@ -26,6 +26,7 @@ import (
 )
 
 var _ backend.Backend = (*pktvisorBackend)(nil)
+var _ backend.DiagnosticsReporter = (*pktvisorBackend)(nil)
 
 const (
 	DefaultBinary       = "/usr/local/sbin/pktvisord"
@@ -6,9 +6,11 @@ package pktvisor
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/orb-community/orb/agent/backend"
 	"github.com/orb-community/orb/agent/policies"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -25,6 +27,50 @@ func (p *pktvisorBackend) ApplyPolicy(data policies.PolicyData, updatePolicy boo
 
 	p.logger.Debug("pktvisor policy apply", zap.String("policy_id", data.ID), zap.Any("data", data.Data))
 
+	policyYaml, err := p.marshalPolicy(data)
+	if err != nil {
+		return err
+	}
+
+	var resp map[string]interface{}
+	err = p.request("policies", &resp, http.MethodPost, bytes.NewBuffer(policyYaml), "application/x-yaml", ApplyPolicyTimeout)
+	if err != nil {
+		p.logger.Warn("yaml policy application failure", zap.String("policy_id", data.ID), zap.ByteString("policy", policyYaml))
+		return err
+	}
+
+	return nil
+}
+
+// TestPolicy asks pktvisord to validate the policy in dry-run mode, without applying it
+func (p *pktvisorBackend) TestPolicy(data policies.PolicyData) error {
+	p.logger.Debug("pktvisor policy test", zap.String("policy_id", data.ID), zap.Any("data", data.Data))
+
+	// request skips the call without an error when pktvisord is not running
+	status, _, err := p.getProcRunningStatus()
+	if status != backend.Running {
+		if err == nil {
+			err = errors.New("pktvisor is not running")
+		}
+		return err
+	}
+
+	policyYaml, err := p.marshalPolicy(data)
+	if err != nil {
+		return err
+	}
+
+	var resp map[string]interface{}
+	err = p.request("policies?dry_run=true", &resp, http.MethodPost, bytes.NewBuffer(policyYaml), "application/x-yaml", ApplyPolicyTimeout)
+	if err != nil {
+		p.logger.Info("yaml policy test failure", zap.String("policy_id", data.ID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (p *pktvisorBackend) marshalPolicy(data policies.PolicyData) ([]byte, error) {
 	fullPolicy := map[string]interface{}{
 		"version": "1.0",
 		"visor": map[string]interface{}{
@@ -37,17 +83,9 @@ func (p *pktvisorBackend) ApplyPolicy(data policies.PolicyData, updatePolicy boo
 	policyYaml, err := yaml.Marshal(fullPolicy)
 	if err != nil {
 		p.logger.Warn("yaml policy marshal failure", zap.String("policy_id", data.ID), zap.Any("policy", fullPolicy))
-		return err
-	}
-
-	var resp map[string]interface{}
-	err = p.request("policies", &resp, http.MethodPost, bytes.NewBuffer(policyYaml), "application/x-yaml", ApplyPolicyTimeout)
-	if err != nil {
-		p.logger.Warn("yaml policy application failure", zap.String("policy_id", data.ID), zap.ByteString("policy", policyYaml))
-		return err
+		return nil, err
 	}
-
-	return nil
+	return policyYaml, nil
 }
 
 func (p *pktvisorBackend) RemovePolicy(data policies.PolicyData) error {
@@ -98,3 +98,12 @@ func (p *pktvisorBackend) getAppInfo() (AppInfo, error) {
 	err := p.request("metrics/app", &appInfo, http.MethodGet, http.NoBody, "application/json", VersionTimeout)
 	return appInfo, err
 }
+
+// GetDiagnostics returns the pktvisor application metrics, or the reason they could not be retrieved
+func (p *pktvisorBackend) GetDiagnostics() map[string][]byte {
+	var app json.RawMessage
+	if err := p.request("metrics/app", &app, http.MethodGet, http.NoBody, "application/json", VersionTimeout); err != nil {
+		return map[string][]byte{"metrics-app.error": []byte(err.Error())}
+	}
+	return map[string][]byte{"metrics-app.json": app}
+}
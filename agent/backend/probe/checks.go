@@ -0,0 +1,277 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/orb-community/orb/pkg/probe"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const maxHTTPBody = 1 << 20
+
+var dnsQueryTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"PTR":   dnsmessage.TypePTR,
+	"SOA":   dnsmessage.TypeSOA,
+	"SRV":   dnsmessage.TypeSRV,
+	"TXT":   dnsmessage.TypeTXT,
+}
+
+var dnsRcodes = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+// result is the outcome of one run of a check
+type result struct {
+	check     probe.Check
+	timestamp time.Time
+	duration  time.Duration
+	success   bool
+	// labels are the check specific result attributes, such as the dns rcode or the http status code
+	labels map[string]string
+	err    error
+}
+
+// checker runs one check, keeping whatever can be reused between runs
+type checker interface {
+	run(ctx context.Context) (success bool, labels map[string]string, err error)
+}
+
+func newChecker(check probe.Check) (checker, error) {
+	switch check.Type {
+	case probe.CheckDNS:
+		return newDNSChecker(check.DNS)
+	case probe.CheckHTTP:
+		return newHTTPChecker(check.HTTP), nil
+	case probe.CheckTCP:
+		return &tcpChecker{address: check.TCP.Address}, nil
+	}
+	return nil, fmt.Errorf("unknown check type '%s'", check.Type)
+}
+
+func runCheck(ctx context.Context, check probe.Check, c checker) result {
+	ctx, cancel := context.WithTimeout(ctx, check.TimeoutDuration())
+	defer cancel()
+	start := time.Now()
+	success, labels, err := c.run(ctx)
+	res := result{
+		check:     check,
+		timestamp: start,
+		duration:  time.Since(start),
+		success:   success && err == nil,
+		labels:    labels,
+		err:       err,
+	}
+	if res.labels == nil {
+		res.labels = make(map[string]string)
+	}
+	if err != nil {
+		res.labels["error"] = errorReason(ctx, err)
+	}
+	return res
+}
+
+// errorReason classifies an error into a low cardinality label value
+func errorReason(ctx context.Context, err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.As(err, &dnsErr):
+		return "name_resolution"
+	}
+	var tlsErr *tls.CertificateVerificationError
+	if errors.As(err, &tlsErr) {
+		return "tls"
+	}
+	return "error"
+}
+
+type dnsChecker struct {
+	server   string
+	protocol string
+	question dnsmessage.Question
+	expect   string
+}
+
+func newDNSChecker(config *probe.DNSCheck) (*dnsChecker, error) {
+	qtype := "A"
+	if config.QType != "" {
+		qtype = strings.ToUpper(config.QType)
+	}
+	t, ok := dnsQueryTypes[qtype]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dns query type '%s'", config.QType)
+	}
+	query := config.Query
+	if !strings.HasSuffix(query, ".") {
+		query += "."
+	}
+	name, err := dnsmessage.NewName(query)
+	if err != nil {
+		return nil, err
+	}
+	server := config.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	protocol := "udp"
+	if config.Protocol != "" {
+		protocol = config.Protocol
+	}
+	expect := "NOERROR"
+	if config.ExpectRcode != "" {
+		expect = strings.ToUpper(config.ExpectRcode)
+	}
+	return &dnsChecker{
+		server:   server,
+		protocol: protocol,
+		question: dnsmessage.Question{Name: name, Type: t, Class: dnsmessage.ClassINET},
+		expect:   expect,
+	}, nil
+}
+
+func (d *dnsChecker) run(ctx context.Context) (bool, map[string]string, error) {
+	id := uint16(rand.Intn(1 << 16))
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{d.question},
+	}).Pack()
+	if err != nil {
+		return false, nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, d.protocol, d.server)
+	if err != nil {
+		return false, nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var response []byte
+	if d.protocol == "tcp" {
+		framed := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(framed, uint16(len(query)))
+		copy(framed[2:], query)
+		if _, err := conn.Write(framed); err != nil {
+			return false, nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return false, nil, err
+		}
+		response = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, response); err != nil {
+			return false, nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return false, nil, err
+		}
+		response = make([]byte, 65535)
+		n, err := conn.Read(response)
+		if err != nil {
+			return false, nil, err
+		}
+		response = response[:n]
+	}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil {
+		return false, nil, err
+	}
+	if header.ID != id {
+		return false, nil, fmt.Errorf("dns response id %d does not match query id %d", header.ID, id)
+	}
+	rcode, ok := dnsRcodes[header.RCode]
+	if !ok {
+		rcode = strconv.Itoa(int(header.RCode))
+	}
+	return rcode == d.expect, map[string]string{"rcode": rcode}, nil
+}
+
+type httpChecker struct {
+	client *http.Client
+	url    string
+	expect []int
+}
+
+func newHTTPChecker(config *probe.HTTPCheck) *httpChecker {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// every run measures a full connection, as a new client would see it
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	return &httpChecker{
+		client: &http.Client{Transport: transport},
+		url:    config.URL,
+		expect: config.ExpectStatus,
+	}
+}
+
+func (h *httpChecker) run(ctx context.Context) (bool, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return false, nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer resp.Body.Close()
+	// the body is read so the latency covers the whole response
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPBody)); err != nil {
+		return false, nil, err
+	}
+	labels := map[string]string{"status_code": strconv.Itoa(resp.StatusCode)}
+	if len(h.expect) == 0 {
+		return resp.StatusCode >= 200 && resp.StatusCode < 300, labels, nil
+	}
+	for _, status := range h.expect {
+		if resp.StatusCode == status {
+			return true, labels, nil
+		}
+	}
+	return false, labels, nil
+}
+
+type tcpChecker struct {
+	address string
+}
+
+func (t *tcpChecker) run(ctx context.Context) (bool, map[string]string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.address)
+	if err != nil {
+		return false, nil, err
+	}
+	_ = conn.Close()
+	return true, nil, nil
+}
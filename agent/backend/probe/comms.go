@@ -0,0 +1,14 @@
+package probe
+
+import (
+	"fmt"
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+func (p *probeBackend) SetCommsClient(agentID string, client *mqtt.Client, baseTopic string) {
+	p.mqttClient = client
+	otelBaseTopic := strings.Replace(baseTopic, "?", "otlp", 1)
+	p.otlpMetricsTopic = fmt.Sprintf("%s/m/%c", otelBaseTopic, agentID[0])
+}
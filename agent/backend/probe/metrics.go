@@ -0,0 +1,80 @@
+package probe
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	scopeName          = "orb.probe"
+	metricSuccess      = "probe_success"
+	metricDuration     = "probe_duration_seconds"
+	policyNameAttr     = "policy_name"
+	checkAttr          = "check"
+	checkTypeAttr      = "check_type"
+	checkTargetAttr    = "target"
+	durationMetricUnit = "s"
+)
+
+// durationBounds are the latency histogram buckets, in seconds
+var durationBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// resultMetrics converts the result of a check run to a success gauge and a single observation delta latency
+// histogram, stamped with the policy name the otlp mqtt exporter routes by
+func resultMetrics(policyName string, res result) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	scope := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	scope.Scope().SetName(scopeName)
+	scope.Scope().Attributes().PutStr(policyNameAttr, policyName)
+	timestamp := pcommon.NewTimestampFromTime(res.timestamp.Add(res.duration))
+
+	success := scope.Metrics().AppendEmpty()
+	success.SetName(metricSuccess)
+	success.SetDescription("Whether the last run of the check succeeded")
+	point := success.SetEmptyGauge().DataPoints().AppendEmpty()
+	point.SetTimestamp(timestamp)
+	if res.success {
+		point.SetIntValue(1)
+	} else {
+		point.SetIntValue(0)
+	}
+	putResultAttributes(point.Attributes(), res)
+
+	duration := scope.Metrics().AppendEmpty()
+	duration.SetName(metricDuration)
+	duration.SetDescription("Duration of the check runs")
+	duration.SetUnit(durationMetricUnit)
+	histogram := duration.SetEmptyHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	observation := histogram.DataPoints().AppendEmpty()
+	observation.SetStartTimestamp(pcommon.NewTimestampFromTime(res.timestamp))
+	observation.SetTimestamp(timestamp)
+	seconds := res.duration.Seconds()
+	observation.SetCount(1)
+	observation.SetSum(seconds)
+	observation.SetMin(seconds)
+	observation.SetMax(seconds)
+	observation.ExplicitBounds().FromRaw(durationBounds)
+	buckets := make([]uint64, len(durationBounds)+1)
+	bucket := len(durationBounds)
+	for i, bound := range durationBounds {
+		if seconds <= bound {
+			bucket = i
+			break
+		}
+	}
+	buckets[bucket] = 1
+	observation.BucketCounts().FromRaw(buckets)
+	putResultAttributes(observation.Attributes(), res)
+
+	return md
+}
+
+func putResultAttributes(attributes pcommon.Map, res result) {
+	attributes.PutStr(checkAttr, res.check.Name)
+	attributes.PutStr(checkTypeAttr, res.check.Type)
+	attributes.PutStr(checkTargetAttr, res.check.Target())
+	for key, value := range res.labels {
+		attributes.PutStr(key, value)
+	}
+}
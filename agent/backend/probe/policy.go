@@ -0,0 +1,136 @@
+package probe
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/pkg/probe"
+	"go.opentelemetry.io/collector/consumer"
+	"go.uber.org/zap"
+)
+
+type runningPolicy struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// compilePolicy validates the policy and prepares a checker per check, without running any
+func compilePolicy(data policies.PolicyData) (probe.Policy, []checker, error) {
+	policy, err := probe.Decode(data.Data)
+	if err != nil {
+		return probe.Policy{}, nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return probe.Policy{}, nil, err
+	}
+	checkers := make([]checker, 0, len(policy.Checks))
+	for _, check := range policy.Checks {
+		c, err := newChecker(check)
+		if err != nil {
+			return probe.Policy{}, nil, err
+		}
+		checkers = append(checkers, c)
+	}
+	return policy, checkers, nil
+}
+
+func (p *probeBackend) ApplyPolicy(data policies.PolicyData, updatePolicy bool) error {
+	p.logger.Debug("applying policy", zap.String("policy_id", data.ID))
+	policy, checkers, err := compilePolicy(data)
+	if err != nil {
+		p.logger.Warn("invalid policy", zap.String("policy_id", data.ID), zap.Error(err))
+		return err
+	}
+	if updatePolicy && p.policyRepo.Exists(data.ID) {
+		current, err := p.policyRepo.Get(data.ID)
+		if err != nil {
+			return err
+		}
+		if current.Version > data.Version {
+			p.logger.Info("current policy version is newer than the one being applied, skipping",
+				zap.String("policy_id", data.ID),
+				zap.Int32("current_version", current.Version),
+				zap.Int32("incoming_version", data.Version))
+			return nil
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	metrics, err := p.metricsConsumer()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.running[data.ID]; ok {
+		p.logger.Info("received new policy version", zap.String("policy_id", data.ID), zap.Int32("version", data.Version))
+		p.stopPolicy(data.ID)
+	} else {
+		p.logger.Info("received new policy", zap.String("policy_id", data.ID), zap.Int32("version", data.Version))
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(p.mainContext, "policy_id", data.ID))
+	running := &runningPolicy{cancel: cancel}
+	for i, check := range policy.Checks {
+		running.wg.Add(1)
+		go p.runCheckLoop(ctx, &running.wg, data, check, checkers[i], metrics)
+	}
+	p.running[data.ID] = running
+	return nil
+}
+
+// runCheckLoop runs a check right away and then on every interval, until the policy is removed
+func (p *probeBackend) runCheckLoop(ctx context.Context, wg *sync.WaitGroup, data policies.PolicyData, check probe.Check,
+	c checker, metrics consumer.Metrics) {
+	defer wg.Done()
+	ticker := time.NewTicker(check.IntervalDuration())
+	defer ticker.Stop()
+	for {
+		res := runCheck(ctx, check, c)
+		if ctx.Err() != nil {
+			return
+		}
+		if res.err != nil {
+			p.logger.Debug("probe check failed", zap.String("policy_id", data.ID), zap.String("check", check.Name), zap.Error(res.err))
+		}
+		if err := metrics.ConsumeMetrics(ctx, resultMetrics(data.Name, res)); err != nil {
+			p.logger.Warn("failed to export probe result", zap.String("policy_id", data.ID), zap.String("check", check.Name), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TestPolicy validates the policy checks without running them
+func (p *probeBackend) TestPolicy(data policies.PolicyData) error {
+	p.logger.Debug("testing policy", zap.String("policy_id", data.ID))
+	_, _, err := compilePolicy(data)
+	return err
+}
+
+func (p *probeBackend) RemovePolicy(data policies.PolicyData) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.running[data.ID]; !ok {
+		p.logger.Warn("no policy was removed, policy not found", zap.String("policy_id", data.ID))
+		return nil
+	}
+	p.logger.Info("removing policy", zap.String("policy_id", data.ID))
+	p.stopPolicy(data.ID)
+	return nil
+}
+
+// stopPolicy cancels the checks of a policy and waits for the runs in flight, it must be called holding mu
+func (p *probeBackend) stopPolicy(policyID string) {
+	running, ok := p.running[policyID]
+	if !ok {
+		return
+	}
+	delete(p.running, policyID)
+	running.cancel()
+	running.wg.Wait()
+}
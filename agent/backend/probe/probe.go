@@ -0,0 +1,176 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/otel"
+	"github.com/orb-community/orb/agent/otel/otlpmqttexporter"
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/buildinfo"
+	"github.com/orb-community/orb/pkg/probe"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.uber.org/zap"
+)
+
+var _ backend.Backend = (*probeBackend)(nil)
+
+const BackendName = "probe"
+
+var errCommsNotReady = errors.New("mqtt connection is not ready, probe results can not be exported")
+
+// probeBackend runs the synthetic checks of probe policies inside the agent, exporting their results as otlp
+// metrics through the agent mqtt connection
+type probeBackend struct {
+	logger    *zap.Logger
+	startTime time.Time
+
+	//policies
+	policyRepo policies.PolicyRepo
+	agentTags  map[string]string
+
+	// Context for controlling the context cancellation
+	mainContext        context.Context
+	mainCancelFunction context.CancelFunc
+
+	mqttClient       *mqtt.Client
+	otlpMetricsTopic string
+
+	mu       sync.Mutex
+	exporter exporter.Metrics
+	// metrics receives the results of every check, it is the mqtt exporter once connected
+	metrics consumer.Metrics
+	running map[string]*runningPolicy
+}
+
+// Configure initializes the backend with the given configuration
+func (p *probeBackend) Configure(logger *zap.Logger, repo policies.PolicyRepo,
+	_ map[string]string, otelConfig map[string]interface{}) error {
+	p.logger = logger
+	p.logger.Info("configuring probe backend")
+	p.policyRepo = repo
+	if agentTags, ok := otelConfig["agent_tags"]; ok {
+		p.agentTags = agentTags.(map[string]string)
+	}
+	p.running = make(map[string]*runningPolicy)
+	return nil
+}
+
+func (p *probeBackend) GetInitialState() backend.RunningStatus {
+	return backend.Waiting
+}
+
+// Version is the agent version, the checks are part of the agent itself
+func (p *probeBackend) Version() (string, error) {
+	return buildinfo.GetVersion(), nil
+}
+
+func (p *probeBackend) Start(ctx context.Context, cancelFunc context.CancelFunc) error {
+	p.mainContext = ctx
+	p.mainCancelFunction = cancelFunc
+	p.startTime = time.Now()
+	p.logger.Info("starting probe backend")
+	policiesData, err := p.policyRepo.GetAll()
+	if err != nil {
+		cancelFunc()
+		p.logger.Error("failed to start probe backend, policies are absent")
+		return err
+	}
+	for _, policyData := range policiesData {
+		if err := p.ApplyPolicy(policyData, true); err != nil {
+			p.logger.Error("failed to start probe backend, failed to apply policy", zap.Error(err))
+			cancelFunc()
+			return err
+		}
+		p.logger.Info("policy applied successfully", zap.String("policy_id", policyData.ID))
+	}
+	return nil
+}
+
+func (p *probeBackend) Stop(ctx context.Context) error {
+	p.logger.Info("stopping all running policies")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for policyID := range p.running {
+		p.stopPolicy(policyID)
+	}
+	if p.exporter != nil {
+		if err := p.exporter.Shutdown(ctx); err != nil {
+			p.logger.Warn("failed to shutdown mqtt exporter", zap.Error(err))
+		}
+		p.exporter = nil
+		p.metrics = nil
+	}
+	if p.mainCancelFunction != nil {
+		p.mainCancelFunction()
+	}
+	return nil
+}
+
+func (p *probeBackend) FullReset(ctx context.Context) error {
+	p.logger.Info("restarting probe backend", zap.Int("running policies", len(p.running)))
+	if err := p.Stop(ctx); err != nil {
+		return err
+	}
+	backendCtx, cancelFunc := context.WithCancel(context.WithValue(ctx, "routine", BackendName))
+	return p.Start(backendCtx, cancelFunc)
+}
+
+func Register() bool {
+	backend.Register(BackendName, &probeBackend{})
+	return true
+}
+
+func (p *probeBackend) GetStartTime() time.Time {
+	return p.startTime
+}
+
+// GetCapabilities lists the check types policies may use
+func (p *probeBackend) GetCapabilities() (map[string]interface{}, error) {
+	capabilities := make(map[string]interface{})
+	capabilities["checks"] = []string{probe.CheckDNS, probe.CheckHTTP, probe.CheckTCP}
+	return capabilities, nil
+}
+
+func (p *probeBackend) GetRunningStatus() (backend.RunningStatus, string, error) {
+	p.mu.Lock()
+	amountPolicies := len(p.running)
+	p.mu.Unlock()
+	if amountPolicies > 0 {
+		return backend.Running, fmt.Sprintf("probe backend running with %d policies", amountPolicies), nil
+	}
+	return backend.Waiting, "probe backend is waiting for policy to come to start running", nil
+}
+
+// metricsConsumer returns where the check results go, creating the mqtt exporter on first use, it must be
+// called holding mu
+func (p *probeBackend) metricsConsumer() (consumer.Metrics, error) {
+	if p.metrics != nil {
+		return p.metrics, nil
+	}
+	if p.mqttClient == nil {
+		return nil, errCommsNotReady
+	}
+	ctx, cancel := context.WithCancelCause(p.mainContext)
+	bridgeService := otel.NewBridgeService(ctx, cancel, &p.policyRepo, p.agentTags)
+	cfg := otlpmqttexporter.CreateConfigClient(p.mqttClient, p.otlpMetricsTopic, "", bridgeService)
+	set := otlpmqttexporter.CreateDefaultSettings(p.logger)
+	exp, err := otlpmqttexporter.CreateMetricsExporter(ctx, set, cfg)
+	if err != nil {
+		cancel(err)
+		return nil, err
+	}
+	if err := exp.Start(ctx, nil); err != nil {
+		cancel(err)
+		return nil, err
+	}
+	p.exporter = exp
+	p.metrics = exp
+	return exp, nil
+}
@@ -0,0 +1,302 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/pkg/probe"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers A queries for known.test. and NXDOMAIN for any other name, until the test ends
+func serveDNS(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start dns stand-in: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: dnsmessage.RCodeNameError},
+				Questions: query.Questions,
+			}
+			if query.Questions[0].Name.String() == "known.test." {
+				response.RCode = dnsmessage.RCodeSuccess
+				response.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: query.Questions[0].Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+					Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+				}}
+			}
+			packed, _ := response.Pack()
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func closedAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %s", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestRunCheck(t *testing.T) {
+	dnsServer := serveDNS(t)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer httpServer.Close()
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start tcp stand-in: %s", err)
+	}
+	defer tcpListener.Close()
+	closed := closedAddress(t)
+
+	cases := map[string]struct {
+		check   probe.Check
+		success bool
+		labels  map[string]string
+	}{
+		"dns query answered": {
+			check:   probe.Check{Name: "dns", Type: probe.CheckDNS, DNS: &probe.DNSCheck{Server: dnsServer, Query: "known.test"}},
+			success: true,
+			labels:  map[string]string{"rcode": "NOERROR"},
+		},
+		"dns query with unexpected rcode": {
+			check:   probe.Check{Name: "dns", Type: probe.CheckDNS, DNS: &probe.DNSCheck{Server: dnsServer, Query: "unknown.test"}},
+			success: false,
+			labels:  map[string]string{"rcode": "NXDOMAIN"},
+		},
+		"dns query with expected nxdomain": {
+			check:   probe.Check{Name: "dns", Type: probe.CheckDNS, DNS: &probe.DNSCheck{Server: dnsServer, Query: "unknown.test", ExpectRcode: "nxdomain"}},
+			success: true,
+			labels:  map[string]string{"rcode": "NXDOMAIN"},
+		},
+		"http get ok": {
+			check:   probe.Check{Name: "http", Type: probe.CheckHTTP, HTTP: &probe.HTTPCheck{URL: httpServer.URL}},
+			success: true,
+			labels:  map[string]string{"status_code": "200"},
+		},
+		"http get with unexpected status": {
+			check:   probe.Check{Name: "http", Type: probe.CheckHTTP, HTTP: &probe.HTTPCheck{URL: httpServer.URL + "/broken"}},
+			success: false,
+			labels:  map[string]string{"status_code": "500"},
+		},
+		"http get with expected status": {
+			check:   probe.Check{Name: "http", Type: probe.CheckHTTP, HTTP: &probe.HTTPCheck{URL: httpServer.URL + "/broken", ExpectStatus: []int{500}}},
+			success: true,
+			labels:  map[string]string{"status_code": "500"},
+		},
+		"tcp connect": {
+			check:   probe.Check{Name: "tcp", Type: probe.CheckTCP, TCP: &probe.TCPCheck{Address: tcpListener.Addr().String()}},
+			success: true,
+			labels:  map[string]string{},
+		},
+		"tcp connect refused": {
+			check:   probe.Check{Name: "tcp", Type: probe.CheckTCP, TCP: &probe.TCPCheck{Address: closed}},
+			success: false,
+			labels:  map[string]string{"error": "connection_refused"},
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			c, err := newChecker(tc.check)
+			if err != nil {
+				t.Fatalf("%s: unexpected error creating checker: %s", desc, err)
+			}
+			res := runCheck(context.Background(), tc.check, c)
+			if res.success != tc.success {
+				t.Errorf("%s: expected success %t got %t (error: %v)", desc, tc.success, res.success, res.err)
+			}
+			if len(res.labels) != len(tc.labels) {
+				t.Errorf("%s: expected labels %v got %v", desc, tc.labels, res.labels)
+			}
+			for key, value := range tc.labels {
+				if res.labels[key] != value {
+					t.Errorf("%s: expected label %s=%s got %s", desc, key, value, res.labels[key])
+				}
+			}
+		})
+	}
+}
+
+func TestTestPolicy(t *testing.T) {
+	cases := map[string]struct {
+		data interface{}
+		err  bool
+	}{
+		"valid checks": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "dns", "type": "dns", "interval": "30s", "timeout": "2s",
+					"dns": map[string]interface{}{"server": "127.0.0.1", "query": "orb.community", "qtype": "aaaa"}},
+				map[string]interface{}{"name": "http", "type": "http", "http": map[string]interface{}{"url": "https://orb.community"}},
+				map[string]interface{}{"name": "tcp", "type": "tcp", "tcp": map[string]interface{}{"address": "orb.community:443"}},
+			}},
+			err: false,
+		},
+		"no checks": {
+			data: map[string]interface{}{"checks": []interface{}{}},
+			err:  true,
+		},
+		"unknown field": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "tcp", "type": "tcp", "tcp": map[string]interface{}{"addres": "orb.community:443"}},
+			}},
+			err: true,
+		},
+		"duplicated check name": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "tcp", "type": "tcp", "tcp": map[string]interface{}{"address": "orb.community:443"}},
+				map[string]interface{}{"name": "tcp", "type": "tcp", "tcp": map[string]interface{}{"address": "orb.community:80"}},
+			}},
+			err: true,
+		},
+		"type without its configuration": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "dns", "type": "dns", "tcp": map[string]interface{}{"address": "orb.community:443"}},
+			}},
+			err: true,
+		},
+		"timeout longer than interval": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "tcp", "type": "tcp", "interval": "5s", "timeout": "10s",
+					"tcp": map[string]interface{}{"address": "orb.community:443"}},
+			}},
+			err: true,
+		},
+		"unsupported dns query type": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "dns", "type": "dns", "dns": map[string]interface{}{"server": "127.0.0.1", "query": "orb.community", "qtype": "AXFR"}},
+			}},
+			err: true,
+		},
+		"relative http url": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "http", "type": "http", "http": map[string]interface{}{"url": "/health"}},
+			}},
+			err: true,
+		},
+		"tcp address without port": {
+			data: map[string]interface{}{"checks": []interface{}{
+				map[string]interface{}{"name": "tcp", "type": "tcp", "tcp": map[string]interface{}{"address": "orb.community"}},
+			}},
+			err: true,
+		},
+	}
+
+	p := &probeBackend{}
+	if err := p.Configure(zap.NewNop(), nil, nil, map[string]interface{}{}); err != nil {
+		t.Fatalf("failed to configure backend: %s", err)
+	}
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := p.TestPolicy(policies.PolicyData{ID: "policy", Data: tc.data})
+			if tc.err && err == nil {
+				t.Errorf("%s: expected an error, got none", desc)
+			}
+			if !tc.err && err != nil {
+				t.Errorf("%s: expected no error, got %s", desc, err)
+			}
+		})
+	}
+}
+
+func TestProbePolicyLifecycle(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start tcp stand-in: %s", err)
+	}
+	defer tcpListener.Close()
+
+	p := &probeBackend{}
+	if err := p.Configure(zap.NewNop(), nil, nil, map[string]interface{}{}); err != nil {
+		t.Fatalf("failed to configure backend: %s", err)
+	}
+	p.mainContext = context.Background()
+	sink := new(consumertest.MetricsSink)
+	p.metrics = sink
+
+	data := policies.PolicyData{
+		ID:   "policy-id",
+		Name: "policy-name",
+		Data: map[string]interface{}{"checks": []interface{}{
+			map[string]interface{}{"name": "tcp", "type": "tcp", "interval": "1s", "timeout": "500ms",
+				"tcp": map[string]interface{}{"address": tcpListener.Addr().String()}},
+		}},
+	}
+	if err := p.ApplyPolicy(data, false); err != nil {
+		t.Fatalf("failed to apply policy: %s", err)
+	}
+	if status, _, _ := p.GetRunningStatus(); status.String() != "running" {
+		t.Errorf("expected backend to be running, got %s", status)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sink.DataPointCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.RemovePolicy(data); err != nil {
+		t.Fatalf("failed to remove policy: %s", err)
+	}
+	received := sink.AllMetrics()
+	if len(received) == 0 {
+		t.Fatalf("expected probe results to be exported")
+	}
+
+	scope := received[0].ResourceMetrics().At(0).ScopeMetrics().At(0)
+	policyName, _ := scope.Scope().Attributes().Get("policy_name")
+	if policyName.Str() != data.Name {
+		t.Errorf("expected policy_name %s, got %q", data.Name, policyName.Str())
+	}
+	metrics := make(map[string]pmetric.Metric)
+	for i := 0; i < scope.Metrics().Len(); i++ {
+		metrics[scope.Metrics().At(i).Name()] = scope.Metrics().At(i)
+	}
+	success, ok := metrics["probe_success"]
+	if !ok || success.Gauge().DataPoints().At(0).IntValue() != 1 {
+		t.Errorf("expected a successful probe_success gauge")
+	}
+	duration, ok := metrics["probe_duration_seconds"]
+	if !ok || duration.Histogram().DataPoints().At(0).Count() != 1 {
+		t.Errorf("expected a single observation probe_duration_seconds histogram")
+	}
+	check, _ := success.Gauge().DataPoints().At(0).Attributes().Get("check")
+	if check.Str() != "tcp" {
+		t.Errorf("expected check attribute tcp, got %q", check.Str())
+	}
+
+	// no more results once the policy is removed
+	count := len(sink.AllMetrics())
+	time.Sleep(1200 * time.Millisecond)
+	if len(sink.AllMetrics()) != count {
+		t.Errorf("expected no results after the policy was removed")
+	}
+}
@@ -0,0 +1,7 @@
+package probe
+
+import "github.com/spf13/viper"
+
+// RegisterBackendSpecificVariables has nothing to default, checks are entirely described by the policies
+func RegisterBackendSpecificVariables(_ *viper.Viper) {
+}
@@ -24,6 +24,13 @@ import (
 
 type CloudConfigManager interface {
 	GetCloudConfig() (config.MQTTConfig, error)
+	// StageKey stores a key handed by a credentials rotation, until the control plane confirms it revoked the current one
+	StageKey(id string, key string) error
+	// CommitStagedKey makes the staged key of the agent the one it connects with, returning it or an empty key if none was staged
+	CommitStagedKey(id string, channelID string) (string, error)
+	// RotatedKey returns a key of the agent other than the current one, for when the current one is rejected: the staged
+	// key if the agent did not get to commit it, else the newest committed one. It returns an empty key if there is none
+	RotatedKey(id string, current string) (key string, staged bool, err error)
 }
 
 var _ CloudConfigManager = (*cloudConfigManager)(nil)
@@ -56,6 +63,19 @@ func (cc *cloudConfigManager) migrateDB() error {
 					"DROP TABLE cloud_config",
 				},
 			},
+			{
+				Id: "cloud_config_2",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS cloud_config_staged (
+						id TEXT	NOT NULL PRIMARY KEY,
+						key TEXT	NOT NULL,
+						ts_created INTEGER NOT NULL
+						)`,
+				},
+				Down: []string{
+					"DROP TABLE cloud_config_staged",
+				},
+			},
 		},
 	}
 
@@ -64,7 +84,7 @@ func (cc *cloudConfigManager) migrateDB() error {
 	return err
 }
 
-func (cc *cloudConfigManager) request(address string, token string, response interface{}, method string, body []byte) error {
+func (cc *cloudConfigManager) request(address string, path string, token string, response interface{}, method string, body []byte) error {
 	tlsConfig := &tls.Config{InsecureSkipVerify: false}
 	if !cc.config.OrbAgent.TLS.Verify {
 		tlsConfig.InsecureSkipVerify = true
@@ -74,7 +94,7 @@ func (cc *cloudConfigManager) request(address string, token string, response int
 		Timeout:   time.Second * 10,
 		Transport: transport,
 	}
-	URL := fmt.Sprintf("%s/api/v1/agents", address)
+	URL := fmt.Sprintf("%s/api/v1/%s", address, path)
 
 	req, err := http.NewRequest(method, URL, bytes.NewBuffer(body))
 	if err != nil {
@@ -112,7 +132,8 @@ func (cc *cloudConfigManager) request(address string, token string, response int
 	return nil
 }
 
-func (cc *cloudConfigManager) autoProvision(apiAddress string, token string) (config.MQTTConfig, error) {
+// autoProvision creates the agent through the cloud API, either with a user API token or exchanging an enrollment token
+func (cc *cloudConfigManager) autoProvision(apiAddress string, path string, token string) (config.MQTTConfig, error) {
 
 	type AgentRes struct {
 		ID        string `json:"id"`
@@ -143,7 +164,7 @@ func (cc *cloudConfigManager) autoProvision(apiAddress string, token string) (co
 	cc.logger.Info("attempting auto provision", zap.String("address", apiAddress))
 
 	var result AgentRes
-	err = cc.request(apiAddress, token, &result, http.MethodPost, body)
+	err = cc.request(apiAddress, path, token, &result, http.MethodPost, body)
 	if err != nil {
 		return config.MQTTConfig{}, err
 	}
@@ -192,7 +213,7 @@ func (cc *cloudConfigManager) GetCloudConfig() (config.MQTTConfig, error) {
 	}
 
 	// see if we have an existing auto provisioned configuration saved locally
-	q := `SELECT id, key, channel FROM cloud_config ORDER BY ts_created DESC LIMIT 1`
+	q := `SELECT id, key, channel FROM cloud_config ORDER BY ts_created DESC, rowid DESC LIMIT 1`
 	dba := config.MQTTConfig{}
 	if err := cc.db.QueryRowx(q).Scan(&dba.Id, &dba.Key, &dba.ChannelID); err != nil {
 		if err != sql.ErrNoRows {
@@ -209,11 +230,15 @@ func (cc *cloudConfigManager) GetCloudConfig() (config.MQTTConfig, error) {
 
 	// attempt a live auto provision
 	apiConfig := cc.config.OrbAgent.Cloud.API
-	if len(apiConfig.Token) == 0 {
-		return config.MQTTConfig{}, errors.New("wanted to auto provision, but no API token was available")
+	path, token := "agents", apiConfig.Token
+	if len(apiConfig.EnrollmentToken) > 0 {
+		path, token = "agents/enroll", apiConfig.EnrollmentToken
+	}
+	if len(token) == 0 {
+		return config.MQTTConfig{}, errors.New("wanted to auto provision, but no API or enrollment token was available")
 	}
 
-	result, err := cc.autoProvision(apiConfig.Address, apiConfig.Token)
+	result, err := cc.autoProvision(apiConfig.Address, path, token)
 	if err != nil {
 		return config.MQTTConfig{}, err
 	}
@@ -225,3 +250,70 @@ func (cc *cloudConfigManager) GetCloudConfig() (config.MQTTConfig, error) {
 	return result, nil
 
 }
+
+func (cc *cloudConfigManager) StageKey(id string, key string) error {
+	if err := cc.migrateDB(); err != nil {
+		return err
+	}
+	_, err := cc.db.Exec(`INSERT OR REPLACE INTO cloud_config_staged VALUES ($1, $2, datetime('now'))`, id, key)
+	return err
+}
+
+func (cc *cloudConfigManager) CommitStagedKey(id string, channelID string) (string, error) {
+	if err := cc.migrateDB(); err != nil {
+		return "", err
+	}
+
+	tx, err := cc.db.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var key string
+	if err := tx.QueryRowx(`SELECT key FROM cloud_config_staged WHERE id = $1`, id).Scan(&key); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	// committed keys are stored like auto provisioned ones, so the newest is picked up on the next start
+	address := ""
+	if _, err := tx.Exec(`INSERT INTO cloud_config VALUES ($1, $2, $3, $4, datetime('now'))`, address, id, key, channelID); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`DELETE FROM cloud_config_staged WHERE id = $1`, id); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (cc *cloudConfigManager) RotatedKey(id string, current string) (string, bool, error) {
+	if err := cc.migrateDB(); err != nil {
+		return "", false, err
+	}
+
+	var key string
+	err := cc.db.QueryRowx(`SELECT key FROM cloud_config_staged WHERE id = $1`, id).Scan(&key)
+	if err == nil && key != current {
+		return key, true, nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return "", false, err
+	}
+
+	q := `SELECT key FROM cloud_config WHERE id = $1 ORDER BY ts_created DESC, rowid DESC LIMIT 1`
+	if err := cc.db.QueryRowx(q, id).Scan(&key); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if key == current {
+		return "", false, nil
+	}
+	return key, false, nil
+}
@@ -28,6 +28,9 @@ func (a *orbAgent) connect(ctx context.Context, config config.MQTTConfig) (mqtt.
 	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
 		a.logger.Error("connection to mqtt lost", zap.Error(err))
 		a.logger.Info("reconnecting....")
+		if a.metrics != nil {
+			a.metrics.reconnects.Inc()
+		}
 		client.Connect()
 	})
 	opts.SetPingTimeout(5 * time.Second)
@@ -43,11 +46,12 @@ func (a *orbAgent) connect(ctx context.Context, config config.MQTTConfig) (mqtt.
 				case <-ctx.Done():
 					return
 				default:
-					if len(a.backends) == 0 {
+					backends := a.runningBackends()
+					if len(backends) == 0 {
 						time.Sleep(time.Duration(i) * time.Second)
 						continue
 					}
-					for name, be := range a.backends {
+					for name, be := range backends {
 						backendStatus, s, _ := be.GetRunningStatus()
 						a.logger.Debug("backend in status", zap.String("backend", name), zap.String("status", s))
 						switch backendStatus {
@@ -82,11 +86,12 @@ func (a *orbAgent) connect(ctx context.Context, config config.MQTTConfig) (mqtt.
 				case <-ctx.Done():
 					return
 				default:
-					if len(a.backends) == 0 {
+					backends := a.runningBackends()
+					if len(backends) == 0 {
 						time.Sleep(time.Duration(i) * time.Second)
 						continue
 					}
-					for name, be := range a.backends {
+					for name, be := range backends {
 						backendStatus, s, _ := be.GetRunningStatus()
 						a.logger.Debug("backend in status", zap.String("backend", name), zap.String("status", s))
 						switch backendStatus {
@@ -123,15 +128,16 @@ func (a *orbAgent) connect(ctx context.Context, config config.MQTTConfig) (mqtt.
 		return nil, token.Error()
 	}
 
-	return c, nil
+	return a.instrument(c), nil
 }
 
 func (a *orbAgent) requestReconnection(ctx context.Context, client mqtt.Client, config config.MQTTConfig) {
 	a.nameAgentRPCTopics(config.ChannelID)
-	for name, be := range a.backends {
-		be.SetCommsClient(config.Id, &client, fmt.Sprintf("%s/?/%s", a.baseTopic, name))
+	for name, be := range a.runningBackends() {
+		be.SetCommsClient(config.Id, a.exportClient(&client), fmt.Sprintf("%s/?/%s", a.baseTopic, name))
 	}
 	a.agent_id = config.Id
+	a.policyManager.ApplyLocalPolicies()
 
 	if token := client.Subscribe(a.rpcFromCoreTopic, 1, a.handleRPCFromCore); token.Wait() && token.Error() != nil {
 		a.logger.Error("failed to subscribe to agent control plane RPC topic", zap.String("topic", a.rpcFromCoreTopic), zap.Error(token.Error()))
@@ -188,7 +194,7 @@ func (a *orbAgent) unsubscribeGroupChannel(channelID string, agentGroupID string
 }
 
 func (a *orbAgent) removeDatasetFromPolicy(datasetID string, policyID string) {
-	for _, be := range a.backends {
+	for _, be := range a.runningBackends() {
 		a.policyManager.RemovePolicyDataset(policyID, datasetID, be)
 	}
 }
@@ -4,6 +4,8 @@
 
 package config
 
+import "time"
+
 type TLS struct {
 	Verify bool `mapstructure:"verify"`
 }
@@ -11,6 +13,8 @@ type TLS struct {
 type APIConfig struct {
 	Address string `mapstructure:"address"`
 	Token   string `mapstructure:"token"`
+	// EnrollmentToken auto provisions the agent without a user API token, it takes precedence over Token
+	EnrollmentToken string `mapstructure:"enrollment_token"`
 }
 
 type DBConfig struct {
@@ -44,14 +48,72 @@ type Debug struct {
 	Enable bool `mapstructure:"enable"`
 }
 
+// LocalPolicy is a policy defined in the agent configuration instead of the control plane. Note that keys of
+// policies defined inline are lowercased by the configuration loader, policies with case-sensitive keys should be
+// placed in the policies directory instead
+type LocalPolicy struct {
+	Name    string                 `mapstructure:"name" yaml:"name"`
+	Backend string                 `mapstructure:"backend" yaml:"backend"`
+	Version int32                  `mapstructure:"version" yaml:"version"`
+	Data    map[string]interface{} `mapstructure:"data" yaml:"data"`
+}
+
+type OTLPHTTPExporter struct {
+	Endpoint string            `mapstructure:"endpoint"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Timeout  time.Duration     `mapstructure:"timeout"`
+}
+
+// LocalExporter sends the telemetry of every policy straight from the agent instead of through the orb mqtt broker
+type LocalExporter struct {
+	OTLPHTTP OTLPHTTPExporter `mapstructure:"otlp_http"`
+}
+
+// Standalone runs the agent without the orb control plane, applying only local policies
+type Standalone struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// StatusServer is the local http server exposing the agent status, health probes and self-telemetry
+type StatusServer struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"`
+}
+
+// TagProvider sources agent tags from the environment, a file or a command, on top of the static tags. Static
+// providers set Tags, env providers take the variables starting with Prefix, file providers read a JSON or YAML
+// object from Path, and command providers run Command, setting Key to its output or, without Key, reading
+// key=value lines
+type TagProvider struct {
+	Type    string            `mapstructure:"type"`
+	Tags    map[string]string `mapstructure:"tags"`
+	Prefix  string            `mapstructure:"prefix"`
+	Path    string            `mapstructure:"path"`
+	Command []string          `mapstructure:"command"`
+	Key     string            `mapstructure:"key"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+}
+
+// TagProviders are merged in order, later providers taking precedence, and refreshed on RefreshInterval
+type TagProviders struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	Providers       []TagProvider `mapstructure:"providers"`
+}
+
 type OrbAgent struct {
-	Backends map[string]map[string]string `mapstructure:"backends"`
-	Tags     map[string]string            `mapstructure:"tags"`
-	Cloud    Cloud                        `mapstructure:"cloud"`
-	TLS      TLS                          `mapstructure:"tls"`
-	DB       DBConfig                     `mapstructure:"db"`
-	Otel     Opentelemetry                `mapstructure:"otel"`
-	Debug    Debug                        `mapstructure:"debug"`
+	Backends      map[string]map[string]string `mapstructure:"backends"`
+	Tags          map[string]string            `mapstructure:"tags"`
+	Cloud         Cloud                        `mapstructure:"cloud"`
+	TLS           TLS                          `mapstructure:"tls"`
+	DB            DBConfig                     `mapstructure:"db"`
+	Otel          Opentelemetry                `mapstructure:"otel"`
+	Debug         Debug                        `mapstructure:"debug"`
+	Policies      []LocalPolicy                `mapstructure:"policies"`
+	PoliciesDir   string                       `mapstructure:"policies_dir"`
+	LocalExporter LocalExporter                `mapstructure:"local_exporter"`
+	Standalone    Standalone                   `mapstructure:"standalone"`
+	StatusServer  StatusServer                 `mapstructure:"status_server"`
+	TagProviders  TagProviders                 `mapstructure:"tag_providers"`
 }
 
 type Config struct {
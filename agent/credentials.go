@@ -0,0 +1,91 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"context"
+
+	"github.com/orb-community/orb/agent/cloud_config"
+	"github.com/orb-community/orb/agent/config"
+	"github.com/orb-community/orb/fleet"
+	"go.uber.org/zap"
+)
+
+// handleAgentCredentials stores the key handed by a credentials rotation, core only revokes the current key once
+// the agent acknowledged it
+func (a *orbAgent) handleAgentCredentials(rpc fleet.AgentCredentialsRPCPayload) {
+	ack := fleet.AgentCredentialsAckRPCPayload{RequestID: rpc.RequestID}
+	ccm, err := cloud_config.New(a.logger, a.config, a.db)
+	if err == nil {
+		err = ccm.StageKey(a.config.OrbAgent.Cloud.MQTT.Id, rpc.Key)
+	}
+	if err != nil {
+		a.logger.Error("failed to store new credentials", zap.String("request_id", rpc.RequestID), zap.Error(err))
+		ack.Error = err.Error()
+	} else {
+		a.logger.Info("new credentials stored, waiting for the current ones to be revoked", zap.String("request_id", rpc.RequestID))
+	}
+	if err := a.sendAgentCredentialsAck(ack); err != nil {
+		a.logger.Error("failed to acknowledge new credentials", zap.String("request_id", rpc.RequestID), zap.Error(err))
+	}
+}
+
+// handleAgentCredentialsCommit switches to the stored key once core revoked the previous one, and reconnects with it
+func (a *orbAgent) handleAgentCredentialsCommit(ctx context.Context, rpc fleet.AgentCredentialsCommitRPCPayload) {
+	ccm, err := cloud_config.New(a.logger, a.config, a.db)
+	if err != nil {
+		a.logger.Error("failed to commit new credentials", zap.String("request_id", rpc.RequestID), zap.Error(err))
+		return
+	}
+	key, err := ccm.CommitStagedKey(a.config.OrbAgent.Cloud.MQTT.Id, a.config.OrbAgent.Cloud.MQTT.ChannelID)
+	if err != nil {
+		a.logger.Error("failed to commit new credentials", zap.String("request_id", rpc.RequestID), zap.Error(err))
+		return
+	}
+	if key == "" {
+		a.logger.Warn("no new credentials were stored, ignoring", zap.String("request_id", rpc.RequestID))
+		return
+	}
+
+	a.logger.Info("credentials rotated, reconnecting", zap.String("request_id", rpc.RequestID))
+	a.config.OrbAgent.Cloud.MQTT.Key = key
+	if a.client != nil && a.client.IsConnected() {
+		a.unsubscribeGroupChannels()
+		a.client.Disconnect(250)
+	}
+	if err := a.restartComms(ctx); err != nil {
+		a.logger.Error("failed to reconnect with new credentials", zap.Error(err))
+	}
+}
+
+// startCommsWithRotatedKey starts comms, falling back to a key from a credentials rotation when the current one is
+// rejected: the agent may have missed the notification that its previous key was revoked, or been restarted with it
+func (a *orbAgent) startCommsWithRotatedKey(ctx context.Context, ccm cloud_config.CloudConfigManager, cloudConfig config.MQTTConfig) error {
+	err := a.startComms(ctx, cloudConfig)
+	if err == nil {
+		return nil
+	}
+
+	key, staged, errR := ccm.RotatedKey(cloudConfig.Id, cloudConfig.Key)
+	if errR != nil {
+		a.logger.Warn("failed to look up rotated credentials", zap.Error(errR))
+		return err
+	}
+	if key == "" {
+		return err
+	}
+
+	a.logger.Info("connection failed, retrying with rotated credentials", zap.String("agent_id", cloudConfig.Id))
+	cloudConfig.Key = key
+	if err := a.startComms(ctx, cloudConfig); err != nil {
+		return err
+	}
+	if staged {
+		if _, err := ccm.CommitStagedKey(cloudConfig.Id, cloudConfig.ChannelID); err != nil {
+			a.logger.Error("failed to commit rotated credentials", zap.Error(err))
+		}
+	}
+	return nil
+}
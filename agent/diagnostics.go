@@ -0,0 +1,290 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/fleet"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// diagnosticsLogLines is how many of the last agent log lines are kept for the diagnostic bundles
+	diagnosticsLogLines = 2000
+	redactedValue       = "<redacted>"
+)
+
+// secretConfigKeys are the configuration keys whose values are redacted from the diagnostic bundles, along with the
+// ones ending with an underscore and any of them
+var secretConfigKeys = []string{"token", "key", "password", "secret"}
+
+// logBufferCore keeps the log entries the agent logger writes in a LineBuffer, at the levels of the core it tees
+type logBufferCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	buf *backend.LineBuffer
+}
+
+func newLogBufferCore(enabler zapcore.LevelEnabler, buf *backend.LineBuffer) zapcore.Core {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	return logBufferCore{LevelEnabler: enabler, enc: enc, buf: buf}
+}
+
+func (c logBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return logBufferCore{LevelEnabler: c.LevelEnabler, enc: enc, buf: c.buf}
+}
+
+func (c logBufferCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c logBufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	b, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	c.buf.Add(strings.TrimSuffix(b.String(), "\n"))
+	b.Free()
+	return nil
+}
+
+func (c logBufferCore) Sync() error {
+	return nil
+}
+
+// withLogBuffer tees the logger into a LineBuffer of its last entries
+func withLogBuffer(logger *zap.Logger, buf *backend.LineBuffer) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, newLogBufferCore(core, buf))
+	}))
+}
+
+func isSecretConfigKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretConfigKeys {
+		if key == secret || strings.HasSuffix(key, "_"+secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedConfig renders a configuration value keyed as in the configuration file, redacting its secrets and the
+// values of any headers
+func redactedConfig(v reflect.Value, key string) interface{} {
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			name := t.Field(i).Tag.Get("mapstructure")
+			if name == "" {
+				name = strings.ToLower(t.Field(i).Name)
+			}
+			m[name] = redactedConfig(v.Field(i), name)
+		}
+		return m
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := fmt.Sprint(iter.Key().Interface())
+			if key == "headers" {
+				m[name] = redactedValue
+				continue
+			}
+			m[name] = redactedConfig(iter.Value(), name)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items[i] = redactedConfig(v.Index(i), key)
+		}
+		return items
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return redactedConfig(v.Elem(), key)
+	case reflect.Invalid:
+		return nil
+	}
+	if isSecretConfigKey(key) && !v.IsZero() {
+		return redactedValue
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+// redactedPolicies renders the policies as JSON, redacting the secrets and headers of their data as for the configuration
+func redactedPolicies(pols []policies.PolicyData) ([]byte, error) {
+	// the policy data is decoded from JSON or YAML, its keys are only known once rendered as JSON
+	body, err := json.Marshal(pols)
+	if err != nil {
+		return nil, err
+	}
+	var rendered interface{}
+	if err := json.Unmarshal(body, &rendered); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactedConfig(reflect.ValueOf(rendered), ""), "", "  ")
+}
+
+// collectDiagnostics assembles the diagnostic bundle, a gzipped tarball of the redacted configuration, the
+// capabilities, the policies and the recent logs of the agent, along with the state of the backends reporting it
+func (a *orbAgent) collectDiagnostics() ([]byte, error) {
+	files := make(map[string][]byte)
+
+	config, err := yaml.Marshal(redactedConfig(reflect.ValueOf(a.config), ""))
+	if err != nil {
+		return nil, err
+	}
+	files["config.yaml"] = config
+
+	backends := a.runningBackends()
+
+	capabilities, err := json.MarshalIndent(a.capabilities(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files["capabilities.json"] = capabilities
+
+	if pols, err := a.policyManager.GetRepo().GetAll(); err != nil {
+		files["policies.error"] = []byte(err.Error())
+	} else if body, err := redactedPolicies(pols); err != nil {
+		files["policies.error"] = []byte(err.Error())
+	} else {
+		files["policies.json"] = body
+	}
+
+	if a.logs != nil {
+		files["agent.log"] = []byte(strings.Join(a.logs.Lines(), "\n"))
+	}
+
+	for name, be := range backends {
+		reporter, ok := be.(backend.DiagnosticsReporter)
+		if !ok {
+			continue
+		}
+		for file, data := range reporter.GetDiagnostics() {
+			files[path.Join("backends", name, path.Base(file))] = data
+		}
+	}
+
+	return tarGz(files)
+}
+
+// tarGz archives files keyed by their path in a gzipped tarball, in path order
+func tarGz(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range names {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(files[name])),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// diagnosticsChunks splits a bundle in chunks of at most size bytes
+func diagnosticsChunks(bundle []byte, size int) [][]byte {
+	if size <= 0 || size > fleet.DiagnosticsChunkSize {
+		size = fleet.DiagnosticsChunkSize
+	}
+	var chunks [][]byte
+	for len(bundle) > size {
+		chunks = append(chunks, bundle[:size])
+		bundle = bundle[size:]
+	}
+	return append(chunks, bundle)
+}
+
+// handleDiagnostics collects a diagnostic bundle and uploads it to core, or the reason it could not
+func (a *orbAgent) handleDiagnostics(rpc fleet.AgentDiagnosticsRPCPayload) {
+	if err := a.uploadDiagnostics(rpc); err != nil {
+		a.logger.Error("failed to upload diagnostic bundle", zap.String("bundle_id", rpc.BundleID), zap.Error(err))
+		if err := a.sendDiagnosticsChunk(fleet.AgentDiagnosticsChunkRPCPayload{BundleID: rpc.BundleID, Error: err.Error()}); err != nil {
+			a.logger.Error("failed to report diagnostic bundle failure", zap.String("bundle_id", rpc.BundleID), zap.Error(err))
+		}
+	}
+}
+
+// uploadDiagnostics collects a diagnostic bundle and uploads it in chunks of the requested size
+func (a *orbAgent) uploadDiagnostics(rpc fleet.AgentDiagnosticsRPCPayload) error {
+	bundle, err := a.collectDiagnostics()
+	if err != nil {
+		return err
+	}
+	chunks := diagnosticsChunks(bundle, rpc.ChunkSize)
+	if len(chunks) > fleet.DiagnosticsMaxChunks {
+		return fmt.Errorf("diagnostic bundle of %d bytes exceeds the %d chunks limit", len(bundle), fleet.DiagnosticsMaxChunks)
+	}
+	a.logger.Info("uploading diagnostic bundle", zap.String("bundle_id", rpc.BundleID), zap.Int("size", len(bundle)),
+		zap.Int("chunks", len(chunks)))
+	for seq, chunk := range chunks {
+		if err := a.sendDiagnosticsChunk(fleet.AgentDiagnosticsChunkRPCPayload{
+			BundleID: rpc.BundleID,
+			Seq:      seq,
+			Total:    len(chunks),
+			Data:     chunk,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/config"
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/fleet"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedactedConfig(t *testing.T) {
+	var c config.Config
+	c.OrbAgent.Cloud.API.Token = "api-token"
+	c.OrbAgent.Cloud.API.EnrollmentToken = "enrollment-token"
+	c.OrbAgent.Cloud.MQTT.Key = "mqtt-key"
+	c.OrbAgent.Cloud.MQTT.Id = "thing-id"
+	c.OrbAgent.LocalExporter.OTLPHTTP.Headers = map[string]string{"Authorization": "Bearer secret"}
+	c.OrbAgent.Backends = map[string]map[string]string{"pktvisor": {"api_key": "backend-key", "binary": "/usr/bin/pktvisord"}}
+
+	orb := redactedConfig(reflect.ValueOf(c), "").(map[string]interface{})["orb"].(map[string]interface{})
+	cloud := orb["cloud"].(map[string]interface{})
+	for desc, tc := range map[string]struct {
+		got      interface{}
+		expected interface{}
+	}{
+		"api token":        {got: cloud["api"].(map[string]interface{})["token"], expected: redactedValue},
+		"enrollment token": {got: cloud["api"].(map[string]interface{})["enrollment_token"], expected: redactedValue},
+		"mqtt key":         {got: cloud["mqtt"].(map[string]interface{})["key"], expected: redactedValue},
+		"mqtt id":          {got: cloud["mqtt"].(map[string]interface{})["id"], expected: "thing-id"},
+		"exporter header": {
+			got:      orb["local_exporter"].(map[string]interface{})["otlp_http"].(map[string]interface{})["headers"].(map[string]interface{})["Authorization"],
+			expected: redactedValue,
+		},
+		"backend key":    {got: orb["backends"].(map[string]interface{})["pktvisor"].(map[string]interface{})["api_key"], expected: redactedValue},
+		"backend binary": {got: orb["backends"].(map[string]interface{})["pktvisor"].(map[string]interface{})["binary"], expected: "/usr/bin/pktvisord"},
+	} {
+		if tc.got != tc.expected {
+			t.Errorf("%s: expected %v got %v", desc, tc.expected, tc.got)
+		}
+	}
+}
+
+func TestRedactedPolicies(t *testing.T) {
+	pols := []policies.PolicyData{{
+		ID:      "policy-1",
+		Backend: "otel",
+		Data: map[string]interface{}{
+			"exporters": map[string]interface{}{
+				"otlphttp": map[string]interface{}{
+					"endpoint": "https://collector:4318",
+					"headers":  map[string]interface{}{"Authorization": "Bearer secret"},
+				},
+				"splunk_hec": map[string]interface{}{"token": "hec-token"},
+			},
+		},
+	}}
+
+	body, err := redactedPolicies(pols)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for desc, tc := range map[string]struct {
+		value    string
+		redacted bool
+	}{
+		"exporter header": {value: "Bearer secret", redacted: true},
+		"exporter token":  {value: "hec-token", redacted: true},
+		"endpoint":        {value: "https://collector:4318", redacted: false},
+		"policy id":       {value: "policy-1", redacted: false},
+	} {
+		if strings.Contains(string(body), tc.value) == tc.redacted {
+			t.Errorf("%s: expected redacted %t for %s", desc, tc.redacted, tc.value)
+		}
+	}
+}
+
+func TestDiagnosticsChunks(t *testing.T) {
+	bundle := bytes.Repeat([]byte("x"), 2*fleet.DiagnosticsChunkSize+1)
+	for desc, tc := range map[string]struct {
+		size   int
+		chunks int
+	}{
+		"requested size":        {size: fleet.DiagnosticsChunkSize, chunks: 3},
+		"smaller size":          {size: fleet.DiagnosticsChunkSize / 2, chunks: 5},
+		"size above the limit":  {size: 10 * fleet.DiagnosticsChunkSize, chunks: 3},
+		"no size":               {size: 0, chunks: 3},
+		"bundle within a chunk": {size: len(bundle), chunks: 3},
+	} {
+		chunks := diagnosticsChunks(bundle, tc.size)
+		if len(chunks) != tc.chunks {
+			t.Errorf("%s: expected %d chunks got %d", desc, tc.chunks, len(chunks))
+		}
+		if joined := bytes.Join(chunks, nil); !bytes.Equal(joined, bundle) {
+			t.Errorf("%s: expected the chunks to rebuild the bundle", desc)
+		}
+	}
+}
+
+func TestLogBuffer(t *testing.T) {
+	logs := backend.NewLineBuffer(2)
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zap.InfoLevel)
+	logger := withLogBuffer(zap.New(core), logs)
+	logger.Debug("below the logger level")
+	logger.Info("first")
+	logger.Info("second", zap.String("policy_id", "policy-1"))
+	logger.Info("third")
+
+	lines := logs.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "second") || !strings.Contains(lines[0], "policy-1") || !strings.Contains(lines[1], "third") {
+		t.Errorf("expected the last entries got %v", lines)
+	}
+}
+
+func TestTarGz(t *testing.T) {
+	files := map[string][]byte{
+		"config.yaml":                    []byte("version: 1"),
+		"backends/pktvisor/metrics.json": []byte("{}"),
+	}
+	bundle, err := tarGz(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tr := tar.NewReader(gz)
+	read := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		read[hdr.Name] = data
+	}
+	if !reflect.DeepEqual(files, read) {
+		t.Errorf("expected %v got %v", files, read)
+	}
+}
@@ -21,63 +21,66 @@ const HeartbeatFreq = 50 * time.Second
 // RestartTimeMin minimum time to wait between restarts
 const RestartTimeMin = 5 * time.Minute
 
-func (a *orbAgent) sendSingleHeartbeat(ctx context.Context, t time.Time, agentsState fleet.State) {
+// measuredStatus is the running status of a backend as retrieved by a heartbeat
+type measuredStatus struct {
+	status backend.RunningStatus
+	errMsg string
+	err    error
+	health map[string]backend.ComponentHealth
+}
 
-	if a.heartbeatsTopic == "" {
-		a.logger.Debug("heartbeat topic not yet set, skipping")
-		return
+// heartbeatData builds the state of the agent, its backends, policies and groups as reported in heartbeats
+func (a *orbAgent) heartbeatData(t time.Time, agentsState fleet.State) fleet.Heartbeat {
+	// backends are queried over http, outside the lock so a slow backend does not hold up reloads and restarts
+	backends := a.runningBackends()
+	measured := make(map[string]measuredStatus, len(backends))
+	if agentsState != fleet.Offline {
+		for name, be := range backends {
+			m := measuredStatus{}
+			m.status, m.errMsg, m.err = be.GetRunningStatus()
+			if reporter, ok := be.(backend.ComponentHealthReporter); ok {
+				m.health = reporter.GetComponentHealth()
+			}
+			measured[name] = m
+		}
 	}
-
-	a.logger.Debug("heartbeat", zap.String("state", agentsState.String()))
+	states := a.recordBackendStatus(measured)
 
 	bes := make(map[string]fleet.BackendStateInfo)
-	for name, be := range a.backends {
+	for name := range backends {
 		if agentsState == fleet.Offline {
 			bes[name] = fleet.BackendStateInfo{State: backend.Offline.String()}
 			continue
 		}
-		besi := fleet.BackendStateInfo{}
-		backendStatus, errMsg, err := be.GetRunningStatus()
-		a.backendState[name].Status = backendStatus
-		besi.State = backendStatus.String()
-		if backendStatus != backend.Running {
-			a.logger.Error("backend not ready", zap.String("backend", name), zap.String("status", backendStatus.String()), zap.String("errMsg", errMsg), zap.Error(err))
-			if err != nil {
-				a.backendState[name].LastError = fmt.Sprintf("failed to retrieve backend status: %v", err)
-			} else if errMsg != "" {
-				a.backendState[name].LastError = errMsg
-			}
+		state, ok := states[name]
+		if !ok {
+			// removed by a reload meanwhile
+			continue
+		}
+		m := measured[name]
+		besi := fleet.BackendStateInfo{State: m.status.String()}
+		if m.status != backend.Running {
+			a.logger.Error("backend not ready", zap.String("backend", name), zap.String("status", m.status.String()), zap.String("errMsg", m.errMsg), zap.Error(m.err))
 			// status is not running so we have a current error
-			besi.Error = a.backendState[name].LastError
-			if time.Now().Sub(be.GetStartTime()) >= RestartTimeMin {
-				a.logger.Info("attempting backend restart due to failed status during heartbeat")
-				if a.config.OrbAgent.Cloud.MQTT.Id != "" {
-					ctx = context.WithValue(ctx, "agent_id", a.config.OrbAgent.Cloud.MQTT.Id)
-				} else {
-					ctx = context.WithValue(ctx, "agent_id", "auto-provisioning-without-id")
-				}
-				err := a.RestartBackend(ctx, name, "failed during heartbeat")
-				if err != nil {
-					a.logger.Error("failed to restart backend", zap.Error(err), zap.String("backend", name))
-				}
-			} else {
-				a.logger.Info("waiting to attempt backend restart due to failed status", zap.Duration("remaining_secs", RestartTimeMin-(time.Now().Sub(be.GetStartTime()))))
-			}
-		} else {
-			// status is Running so no current error
-			besi.Error = ""
+			besi.Error = state.LastError
+		}
+		if state.LastError != "" {
+			besi.LastError = state.LastError
 		}
-		if a.backendState[name].LastError != "" {
-			besi.LastError = a.backendState[name].LastError
+		if !state.LastRestartTS.IsZero() {
+			besi.LastRestartTS = state.LastRestartTS
 		}
-		if !a.backendState[name].LastRestartTS.IsZero() {
-			besi.LastRestartTS = a.backendState[name].LastRestartTS
+		if state.RestartCount > 0 {
+			besi.RestartCount = state.RestartCount
 		}
-		if a.backendState[name].RestartCount > 0 {
-			besi.RestartCount = a.backendState[name].RestartCount
+		if state.LastRestartReason != "" {
+			besi.LastRestartReason = state.LastRestartReason
 		}
-		if a.backendState[name].LastRestartReason != "" {
-			besi.LastRestartReason = a.backendState[name].LastRestartReason
+		if len(m.health) > 0 {
+			besi.Components = make(map[string]fleet.ComponentStateInfo, len(m.health))
+			for key, h := range m.health {
+				besi.Components[key] = fleet.ComponentStateInfo{State: h.Status, Error: h.Error}
+			}
 		}
 		bes[name] = besi
 	}
@@ -92,11 +95,11 @@ func (a *orbAgent) sendSingleHeartbeat(ctx context.Context, t time.Time, agentsS
 				pstate = pd.State.String()
 			}
 			// but if the policy backend is not running, policy isn't either
-			if bestate, ok := a.backendState[pd.Backend]; ok && bestate.Status != backend.Running {
+			if bestate, ok := states[pd.Backend]; ok && bestate.Status != backend.Running {
 				pstate = policies.Unknown.String()
 				pd.BackendErr = "backend is unreachable"
 			}
-			ps[pd.ID] = fleet.PolicyStateInfo{
+			psi := fleet.PolicyStateInfo{
 				Name:            pd.Name,
 				Version:         pd.Version,
 				State:           pstate,
@@ -106,6 +109,10 @@ func (a *orbAgent) sendSingleHeartbeat(ctx context.Context, t time.Time, agentsS
 				LastScrapeBytes: pd.LastScrapeBytes,
 				Backend:         pd.Backend,
 			}
+			if pd.Local {
+				psi.Source = fleet.PolicySourceLocal
+			}
+			ps[pd.ID] = psi
 		}
 	} else {
 		a.logger.Error("unable to retrieved policy state", zap.Error(err))
@@ -119,7 +126,7 @@ func (a *orbAgent) sendSingleHeartbeat(ctx context.Context, t time.Time, agentsS
 		}
 	}
 
-	hbData := fleet.Heartbeat{
+	return fleet.Heartbeat{
 		SchemaVersion: fleet.CurrentHeartbeatSchemaVersion,
 		State:         agentsState,
 		TimeStamp:     t,
@@ -127,6 +134,73 @@ func (a *orbAgent) sendSingleHeartbeat(ctx context.Context, t time.Time, agentsS
 		PolicyState:   ps,
 		GroupState:    ag,
 	}
+}
+
+// recordBackendStatus keeps the running status measured of the backends still running, returning a copy of the state of
+// every backend
+func (a *orbAgent) recordBackendStatus(measured map[string]measuredStatus) map[string]backend.State {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	states := make(map[string]backend.State, len(a.backendState))
+	for name, state := range a.backendState {
+		if m, ok := measured[name]; ok {
+			state.Status = m.status
+			// a running backend keeps its last error
+			if m.status != backend.Running && m.err != nil {
+				state.LastError = fmt.Sprintf("failed to retrieve backend status: %v", m.err)
+			} else if m.status != backend.Running && m.errMsg != "" {
+				state.LastError = m.errMsg
+			}
+		}
+		states[name] = *state
+	}
+	return states
+}
+
+// restartFailedBackends restarts the backends found not running by the last heartbeat, once they had the time to
+// start
+func (a *orbAgent) restartFailedBackends(ctx context.Context) {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	a.stateMu.Lock()
+	failed := make(map[string]backend.Backend)
+	for name, be := range a.backends {
+		if state, ok := a.backendState[name]; ok && state.Status != backend.Running {
+			failed[name] = be
+		}
+	}
+	a.stateMu.Unlock()
+	for name, be := range failed {
+		if time.Now().Sub(be.GetStartTime()) >= RestartTimeMin {
+			a.logger.Info("attempting backend restart due to failed status during heartbeat")
+			if a.config.OrbAgent.Cloud.MQTT.Id != "" {
+				ctx = context.WithValue(ctx, "agent_id", a.config.OrbAgent.Cloud.MQTT.Id)
+			} else {
+				ctx = context.WithValue(ctx, "agent_id", "auto-provisioning-without-id")
+			}
+			err := a.RestartBackend(ctx, name, "failed during heartbeat")
+			if err != nil {
+				a.logger.Error("failed to restart backend", zap.Error(err), zap.String("backend", name))
+			}
+		} else {
+			a.logger.Info("waiting to attempt backend restart due to failed status", zap.Duration("remaining_secs", RestartTimeMin-(time.Now().Sub(be.GetStartTime()))))
+		}
+	}
+}
+
+func (a *orbAgent) sendSingleHeartbeat(ctx context.Context, t time.Time, agentsState fleet.State) {
+
+	if a.heartbeatsTopic == "" {
+		a.logger.Debug("heartbeat topic not yet set, skipping")
+		return
+	}
+
+	a.logger.Debug("heartbeat", zap.String("state", agentsState.String()))
+
+	hbData := a.heartbeatData(t, agentsState)
+	if agentsState != fleet.Offline {
+		a.restartFailedBackends(ctx)
+	}
 
 	body, err := json.Marshal(hbData)
 	if err != nil {
@@ -159,6 +233,7 @@ func (a *orbAgent) sendHeartbeats(ctx context.Context, cancelFunc context.Cancel
 			return
 		case t := <-a.hbTicker.C:
 			a.sendSingleHeartbeat(ctx, t, fleet.Online)
+			a.retryRequests(t)
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/fleet"
+)
+
+// lockCheckingBackend records whether the agent state was locked while its status was retrieved
+type lockCheckingBackend struct {
+	stubBackend
+	agent  *orbAgent
+	locked *bool
+}
+
+func (b lockCheckingBackend) GetRunningStatus() (backend.RunningStatus, string, error) {
+	if b.agent.stateMu.TryLock() {
+		b.agent.stateMu.Unlock()
+	} else {
+		*b.locked = true
+	}
+	return b.status, "", nil
+}
+
+func TestHeartbeatQueriesBackendsUnlocked(t *testing.T) {
+	a := newStatusAgent(t, true, backend.Running)
+	var locked bool
+	a.backends["probe"] = lockCheckingBackend{stubBackend: stubBackend{status: backend.BackendError}, agent: a, locked: &locked}
+
+	hb := a.heartbeatData(time.Now(), fleet.Online)
+	if locked {
+		t.Errorf("backend status retrieved while holding the agent state lock")
+	}
+	if got := hb.BackendState["probe"].State; got != backend.BackendError.String() {
+		t.Errorf("backend state: expected %s got %s", backend.BackendError.String(), got)
+	}
+	if got := a.backendState["probe"].Status; got != backend.BackendError {
+		t.Errorf("recorded backend status: expected %s got %s", backend.BackendError.String(), got.String())
+	}
+	if got := hb.PolicyState["local-dns"].State; got != policies.Unknown.String() {
+		t.Errorf("policy of failed backend: expected %s got %s", policies.Unknown.String(), got)
+	}
+
+	if reasons := a.notReadyReasons(); locked || len(reasons) != 1 {
+		t.Errorf("readiness: expected one reason without locking got %v locked %v", reasons, locked)
+	}
+}
@@ -0,0 +1,167 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package localexporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/orb-community/orb/agent/config"
+	"go.uber.org/zap"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var (
+	ErrMissingEndpoint = errors.New("local exporter requires an otlp_http endpoint")
+	errNotSupported    = errors.New("operation not supported by the local exporter")
+)
+
+// signalPaths maps the signal segment of the otlp topics the backends publish to, to the OTLP/HTTP path
+var signalPaths = map[string]string{
+	"m": "/v1/metrics",
+	"t": "/v1/traces",
+	"l": "/v1/logs",
+}
+
+var _ mqtt.Client = (*Client)(nil)
+
+// Client stands in for the mqtt client handed to the backends, sending the otlp payloads they publish to an
+// OTLP/HTTP endpoint instead of the orb broker. Anything else published to it, such as control plane RPCs, is
+// dropped
+type Client struct {
+	logger     *zap.Logger
+	endpoint   string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+func New(logger *zap.Logger, c config.OTLPHTTPExporter) (*Client, error) {
+	if c.Endpoint == "" {
+		return nil, ErrMissingEndpoint
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{
+		logger:     logger,
+		endpoint:   strings.TrimSuffix(c.Endpoint, "/"),
+		headers:    c.Headers,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (c *Client) Publish(topic string, _ byte, _ bool, payload interface{}) mqtt.Token {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 2 {
+		return &doneToken{}
+	}
+	path, ok := signalPaths[parts[len(parts)-2]]
+	if !ok || !strings.Contains(topic, "/otlp/") {
+		c.logger.Debug("dropping message not meant for the local exporter", zap.String("topic", topic))
+		return &doneToken{}
+	}
+	compressed, ok := payload.([]byte)
+	if !ok {
+		return &doneToken{err: fmt.Errorf("unexpected payload type %T", payload)}
+	}
+	return &doneToken{err: c.export(path, compressed)}
+}
+
+func (c *Client) export(path string, compressed []byte) error {
+	request, err := io.ReadAll(brotli.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return fmt.Errorf("failed to decompress otlp payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, c.endpoint+path, bytes.NewReader(request))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export to %s: %w", c.endpoint+path, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("export to %s failed with status %d", c.endpoint+path, resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) IsConnected() bool {
+	return true
+}
+
+func (c *Client) IsConnectionOpen() bool {
+	return true
+}
+
+func (c *Client) Connect() mqtt.Token {
+	return &doneToken{}
+}
+
+func (c *Client) Disconnect(_ uint) {
+	c.httpClient.CloseIdleConnections()
+}
+
+func (c *Client) Subscribe(_ string, _ byte, _ mqtt.MessageHandler) mqtt.Token {
+	return &doneToken{err: errNotSupported}
+}
+
+func (c *Client) SubscribeMultiple(_ map[string]byte, _ mqtt.MessageHandler) mqtt.Token {
+	return &doneToken{err: errNotSupported}
+}
+
+func (c *Client) Unsubscribe(_ ...string) mqtt.Token {
+	return &doneToken{}
+}
+
+func (c *Client) AddRoute(_ string, _ mqtt.MessageHandler) {
+}
+
+func (c *Client) OptionsReader() mqtt.ClientOptionsReader {
+	return mqtt.ClientOptionsReader{}
+}
+
+// doneToken is the token of an operation completed by the time it is returned
+type doneToken struct {
+	err error
+}
+
+var closedChannel = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+func (t *doneToken) Wait() bool {
+	return true
+}
+
+func (t *doneToken) WaitTimeout(_ time.Duration) bool {
+	return true
+}
+
+func (t *doneToken) Done() <-chan struct{} {
+	return closedChannel
+}
+
+func (t *doneToken) Error() error {
+	return t.err
+}
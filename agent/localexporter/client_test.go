@@ -0,0 +1,148 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package localexporter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/orb-community/orb/agent/config"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.uber.org/zap"
+)
+
+func compress(t *testing.T, data []byte) []byte {
+	var b bytes.Buffer
+	w := brotli.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("failed to compress payload: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to compress payload: %s", err)
+	}
+	return b.Bytes()
+}
+
+func TestPublish(t *testing.T) {
+	type received struct {
+		path        string
+		contentType string
+		token       string
+		metrics     int
+	}
+	var requests []received
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := pmetricotlp.NewExportRequest()
+		if err := req.UnmarshalProto(body); err != nil {
+			t.Errorf("expected an otlp proto export request: %s", err)
+		}
+		requests = append(requests, received{
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			token:       r.Header.Get("X-Token"),
+			metrics:     req.Metrics().MetricCount(),
+		})
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client, err := New(zap.NewNop(), config.OTLPHTTPExporter{Endpoint: server.URL + "/", Headers: map[string]string{"X-Token": "secret"}})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	md := pmetric.NewMetrics()
+	md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("probe_success")
+	request, err := pmetricotlp.NewExportRequestFromMetrics(md).MarshalProto()
+	if err != nil {
+		t.Fatalf("failed to marshal metrics: %s", err)
+	}
+	payload := compress(t, request)
+
+	cases := map[string]struct {
+		topic    string
+		status   int
+		path     string
+		exported bool
+		err      bool
+	}{
+		"metrics": {
+			topic:    "channels/c/messages/otlp/probe/m/a",
+			status:   http.StatusOK,
+			path:     "/v1/metrics",
+			exported: true,
+		},
+		"traces": {
+			topic:    "channels/c/messages/otlp/otel/t/a",
+			status:   http.StatusOK,
+			path:     "/v1/traces",
+			exported: true,
+		},
+		"endpoint failure": {
+			topic:    "channels/c/messages/otlp/otel/l/a",
+			status:   http.StatusServiceUnavailable,
+			path:     "/v1/logs",
+			exported: true,
+			err:      true,
+		},
+		"heartbeat is dropped": {
+			topic:  "channels/c/messages/heartbeat",
+			status: http.StatusOK,
+		},
+		"legacy pktvisor metrics are dropped": {
+			topic:  "channels/c/messages/be/pktvisor/m/a",
+			status: http.StatusOK,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			requests = nil
+			status = tc.status
+			token := client.Publish(tc.topic, 1, false, payload)
+			token.Wait()
+			if tc.err && token.Error() == nil {
+				t.Errorf("%s: expected an error, got none", desc)
+			}
+			if !tc.err && token.Error() != nil {
+				t.Errorf("%s: expected no error, got %s", desc, token.Error())
+			}
+			if !tc.exported {
+				if len(requests) != 0 {
+					t.Errorf("%s: expected no request, got %d", desc, len(requests))
+				}
+				return
+			}
+			if len(requests) != 1 {
+				t.Fatalf("%s: expected a single request, got %d", desc, len(requests))
+			}
+			if requests[0].path != tc.path {
+				t.Errorf("%s: expected path %s got %s", desc, tc.path, requests[0].path)
+			}
+			if requests[0].contentType != "application/x-protobuf" {
+				t.Errorf("%s: expected protobuf content type, got %s", desc, requests[0].contentType)
+			}
+			if requests[0].token != "secret" {
+				t.Errorf("%s: expected configured headers to be sent", desc)
+			}
+			if requests[0].metrics != 1 {
+				t.Errorf("%s: expected the decompressed request to be sent, got %d metrics", desc, requests[0].metrics)
+			}
+		})
+	}
+}
+
+func TestNewWithoutEndpoint(t *testing.T) {
+	if _, err := New(zap.NewNop(), config.OTLPHTTPExporter{}); err != ErrMissingEndpoint {
+		t.Errorf("expected %s, got %v", ErrMissingEndpoint, err)
+	}
+}
@@ -0,0 +1,104 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/orb-community/orb/fleet"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// agentMetrics is the self-telemetry of the agent, served by the status server
+type agentMetrics struct {
+	registry        *prometheus.Registry
+	publishedBytes  *prometheus.CounterVec
+	publishFailures *prometheus.CounterVec
+	reconnects      prometheus.Counter
+	backendRestarts *prometheus.CounterVec
+}
+
+func newAgentMetrics() *agentMetrics {
+	m := &agentMetrics{
+		registry: prometheus.NewRegistry(),
+		publishedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orb_agent",
+			Name:      "published_bytes_total",
+			Help:      "Bytes published by the agent, telemetry is counted compressed per backend.",
+		}, []string{"kind", "backend"}),
+		publishFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orb_agent",
+			Name:      "publish_failures_total",
+			Help:      "Messages the agent failed to publish.",
+		}, []string{"kind", "backend"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "orb_agent",
+			Name:      "mqtt_reconnects_total",
+			Help:      "Times the connection to the orb mqtt broker was lost and reestablished.",
+		}),
+		backendRestarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orb_agent",
+			Name:      "backend_restarts_total",
+			Help:      "Backend restarts, because of failures or requested by the control plane.",
+		}, []string{"backend"}),
+	}
+	m.registry.MustRegister(m.publishedBytes, m.publishFailures, m.reconnects, m.backendRestarts,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// topicKind classifies a topic the agent publishes to, naming the backend of telemetry topics
+func topicKind(topic string) (kind string, backendName string) {
+	parts := strings.Split(topic, "/")
+	for i, part := range parts {
+		if part == "otlp" && i+1 < len(parts) {
+			return "telemetry", parts[i+1]
+		}
+	}
+	switch {
+	case strings.HasSuffix(topic, "/"+fleet.HeartbeatsTopic):
+		return "heartbeat", ""
+	case strings.HasSuffix(topic, "/"+fleet.CapabilitiesTopic):
+		return "capabilities", ""
+	case strings.HasSuffix(topic, "/"+fleet.RPCToCoreTopic):
+		return "rpc", ""
+	case strings.HasSuffix(topic, "/"+fleet.LogTopic):
+		return "log", ""
+	}
+	return "other", ""
+}
+
+var _ mqtt.Client = (*instrumentedClient)(nil)
+
+// instrumentedClient counts the bytes and failures of everything published through the client it wraps
+type instrumentedClient struct {
+	mqtt.Client
+	metrics *agentMetrics
+}
+
+// instrument wraps the client to count what is published through it, unless it already is
+func (a *orbAgent) instrument(c mqtt.Client) mqtt.Client {
+	if _, ok := c.(*instrumentedClient); ok || a.metrics == nil || c == nil {
+		return c
+	}
+	return &instrumentedClient{Client: c, metrics: a.metrics}
+}
+
+func (c *instrumentedClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	kind, backendName := topicKind(topic)
+	if body, ok := payload.([]byte); ok {
+		c.metrics.publishedBytes.WithLabelValues(kind, backendName).Add(float64(len(body)))
+	}
+	token := c.Client.Publish(topic, qos, retained, payload)
+	go func() {
+		<-token.Done()
+		if token.Error() != nil {
+			c.metrics.publishFailures.WithLabelValues(kind, backendName).Inc()
+		}
+	}()
+	return token
+}
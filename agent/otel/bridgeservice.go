@@ -16,6 +16,8 @@ type AgentDataPerPolicy struct {
 	PolicyID  string
 	Datasets  string
 	AgentTags map[string]string
+	// Local is set for policies defined in the agent configuration
+	Local bool
 }
 
 var _ AgentBridgeService = (*BridgeService)(nil)
@@ -45,6 +47,7 @@ func (b *BridgeService) RetrieveAgentInfoByPolicyName(policyName string) (*Agent
 		PolicyID:  pData.ID,
 		Datasets:  strings.Join(pData.GetDatasetIDs(), ","),
 		AgentTags: b.AgentTags,
+		Local:     pData.Local,
 	}, nil
 }
 
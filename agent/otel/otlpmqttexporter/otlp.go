@@ -102,6 +102,25 @@ func (e *baseExporter) start(_ context.Context, _ component.Host) error {
 	return nil
 }
 
+// localPolicyExporter is implemented by clients also exporting straight from the agent, the only way to send the
+// telemetry of local policies as the orb control plane doesn't know about them
+type localPolicyExporter interface {
+	ExportsLocalPolicies() bool
+	PublishLocal(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+}
+
+// localExporter is the client the telemetry of local policies is exported through, if any
+func (e *baseExporter) localExporter() (localPolicyExporter, bool) {
+	if e.config.Client == nil {
+		return nil, false
+	}
+	l, ok := (*e.config.Client).(localPolicyExporter)
+	if !ok || !l.ExportsLocalPolicies() {
+		return nil, false
+	}
+	return l, true
+}
+
 // inject attribute on all ScopeMetrics metrics
 func (e *baseExporter) injectScopeMetricsAttribute(metricsScope pmetric.ScopeMetrics, attribute string, value string) pmetric.ScopeMetrics {
 	metrics := metricsScope.Metrics()
@@ -144,6 +163,9 @@ func (e *baseExporter) injectScopeMetricsAttribute(metricsScope pmetric.ScopeMet
 func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
 	tr := pmetricotlp.NewExportRequest()
 	ref := tr.Metrics().ResourceMetrics().AppendEmpty()
+	localTr := pmetricotlp.NewExportRequest()
+	localRef := localTr.Metrics().ResourceMetrics().AppendEmpty()
+	local, exportsLocal := e.localExporter()
 	scopes := pmetricotlp.NewExportRequestFromMetrics(md).Metrics().ResourceMetrics().At(0).ScopeMetrics()
 	for i := 0; i < scopes.Len(); i++ {
 		scope := scopes.At(i)
@@ -154,6 +176,10 @@ func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) erro
 			e.logger.Warn("Policy is not managed by orb", zap.String("policyName", policyNameStr))
 			continue
 		}
+		if agentData.Local && !exportsLocal {
+			e.logger.Debug("skipping local policy, it is only exported by the local exporter", zap.String("policyName", policyNameStr))
+			continue
+		}
 
 		// sort datasetIDs to send always on same order
 		datasetIDs := strings.Split(agentData.Datasets, ",")
@@ -167,10 +193,27 @@ func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) erro
 		// injecting policyID and datasetIDs attributes
 		scope.Scope().Attributes().PutStr("policy_id", agentData.PolicyID)
 		scope.Scope().Attributes().PutStr("dataset_ids", datasets)
-		scope.CopyTo(ref.ScopeMetrics().AppendEmpty())
+		if agentData.Local {
+			scope.CopyTo(localRef.ScopeMetrics().AppendEmpty())
+		} else {
+			scope.CopyTo(ref.ScopeMetrics().AppendEmpty())
+		}
 		e.logger.Info("scraped metrics for policy", zap.String("policy", policyNameStr), zap.String("policy_id", agentData.PolicyID))
 	}
 
+	if localRef.ScopeMetrics().Len() > 0 {
+		request, err := localTr.MarshalProto()
+		if err != nil {
+			return consumererror.NewPermanent(err)
+		}
+		if err := e.exportLocal(local, e.config.Topic, request); err != nil {
+			return err
+		}
+	}
+	if ref.ScopeMetrics().Len() == 0 {
+		return nil
+	}
+
 	request, err := tr.MarshalProto()
 	if err != nil {
 		defer ctx.Done()
@@ -199,6 +242,9 @@ func (e *baseExporter) injectScopeLogsAttribute(logsScope plog.ScopeLogs, attrib
 func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
 	tr := plogotlp.NewExportRequest()
 	ref := tr.Logs().ResourceLogs().AppendEmpty()
+	localTr := plogotlp.NewExportRequest()
+	localRef := localTr.Logs().ResourceLogs().AppendEmpty()
+	local, exportsLocal := e.localExporter()
 	scopes := plogotlp.NewExportRequestFromLogs(ld).Logs().ResourceLogs().At(0).ScopeLogs()
 	for i := 0; i < scopes.Len(); i++ {
 		scope := scopes.At(i)
@@ -208,6 +254,10 @@ func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
 			e.logger.Warn("Policy is not managed by orb", zap.String("policyName", policyName))
 			continue
 		}
+		if agentData.Local && !exportsLocal {
+			e.logger.Debug("skipping local policy, it is only exported by the local exporter", zap.String("policyName", policyName))
+			continue
+		}
 
 		// sort datasetIDs to send always on same order
 		datasetIDs := strings.Split(agentData.Datasets, ",")
@@ -221,10 +271,27 @@ func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
 		// injecting policyID and datasetIDs attributes
 		scope.Scope().Attributes().PutStr("policy_id", agentData.PolicyID)
 		scope.Scope().Attributes().PutStr("dataset_ids", datasets)
-		scope.CopyTo(ref.ScopeLogs().AppendEmpty())
+		if agentData.Local {
+			scope.CopyTo(localRef.ScopeLogs().AppendEmpty())
+		} else {
+			scope.CopyTo(ref.ScopeLogs().AppendEmpty())
+		}
 		e.logger.Info("scraped logs for policy", zap.String("policy", policyName), zap.String("policy_id", agentData.PolicyID))
 	}
 
+	if localRef.ScopeLogs().Len() > 0 {
+		request, err := localTr.MarshalProto()
+		if err != nil {
+			return consumererror.NewPermanent(err)
+		}
+		if err := e.exportLocal(local, e.config.Topic, request); err != nil {
+			return err
+		}
+	}
+	if ref.ScopeLogs().Len() == 0 {
+		return nil
+	}
+
 	request, err := tr.MarshalProto()
 	if err != nil {
 		defer ctx.Done()
@@ -253,6 +320,9 @@ func (e *baseExporter) injectScopeSpansAttribute(spanScope ptrace.ScopeSpans, at
 func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 	tr := ptraceotlp.NewExportRequest()
 	ref := tr.Traces().ResourceSpans().AppendEmpty()
+	localTr := ptraceotlp.NewExportRequest()
+	localRef := localTr.Traces().ResourceSpans().AppendEmpty()
+	local, exportsLocal := e.localExporter()
 	scopes := ptraceotlp.NewExportRequestFromTraces(td).Traces().ResourceSpans().At(0).ScopeSpans()
 	for i := 0; i < scopes.Len(); i++ {
 		scope := scopes.At(i)
@@ -262,6 +332,10 @@ func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 			e.logger.Warn("Policy is not managed by orb", zap.String("policyName", policyName))
 			continue
 		}
+		if agentData.Local && !exportsLocal {
+			e.logger.Debug("skipping local policy, it is only exported by the local exporter", zap.String("policyName", policyName))
+			continue
+		}
 
 		// sort datasetIDs to send always on same order
 		datasetIDs := strings.Split(agentData.Datasets, ",")
@@ -275,10 +349,27 @@ func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 		// injecting policyID and datasetIDs attributes
 		scope.Scope().Attributes().PutStr("policy_id", agentData.PolicyID)
 		scope.Scope().Attributes().PutStr("dataset_ids", datasets)
-		scope.CopyTo(ref.ScopeSpans().AppendEmpty())
+		if agentData.Local {
+			scope.CopyTo(localRef.ScopeSpans().AppendEmpty())
+		} else {
+			scope.CopyTo(ref.ScopeSpans().AppendEmpty())
+		}
 		e.logger.Info("scraped traces for policy", zap.String("policy", policyName), zap.String("policy_id", agentData.PolicyID))
 	}
 
+	if localRef.ScopeSpans().Len() > 0 {
+		request, err := localTr.MarshalProto()
+		if err != nil {
+			return consumererror.NewPermanent(err)
+		}
+		if err := e.exportLocal(local, e.config.Topic, request); err != nil {
+			return err
+		}
+	}
+	if ref.ScopeSpans().Len() == 0 {
+		return nil
+	}
+
 	request, err := tr.MarshalProto()
 	if err != nil {
 		defer ctx.Done()
@@ -308,3 +399,17 @@ func (e *baseExporter) export(ctx context.Context, topic string, request []byte)
 
 	return nil
 }
+
+// exportLocal sends the telemetry of local policies through the local exporter, a failure of which says nothing
+// about the connection to the control plane
+func (e *baseExporter) exportLocal(local localPolicyExporter, topic string, request []byte) error {
+	compressedPayload := e.compressBrotli(request)
+	if token := local.PublishLocal(topic, 1, false, compressedPayload); token.Wait() && token.Error() != nil {
+		e.logger.Error("error exporting local policies telemetry", zap.String("topic", topic), zap.Error(token.Error()))
+		return token.Error()
+	}
+	e.logger.Debug("scraped and exported local policies telemetry", zap.String("topic", topic),
+		zap.Int("payload_size_b", len(request)),
+		zap.Int("compressed_payload_size_b", len(compressedPayload)))
+	return nil
+}
@@ -23,6 +23,8 @@ type PolicyData struct {
 	LastScrapeBytes    int64
 	LastScrapeTS       time.Time
 	PreviousPolicyData *PolicyData
+	// Local is set for policies defined in the agent configuration, which the control plane doesn't manage
+	Local bool
 }
 
 func (d *PolicyData) GetDatasetIDs() []string {
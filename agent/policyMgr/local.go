@@ -0,0 +1,116 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/config"
+	"github.com/orb-community/orb/agent/policies"
+	"github.com/orb-community/orb/fleet"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// LocalDatasetID is the dataset of every local policy, they don't belong to any dataset of the control plane
+const LocalDatasetID = "local"
+
+const localPolicyIDPrefix = "local-"
+
+// LocalPolicyID is the id a local policy is stored with in the policy repo
+func LocalPolicyID(name string) string {
+	return localPolicyIDPrefix + name
+}
+
+// loadLocalPolicies reads the policies defined inline in the agent configuration and the ones in the policies
+// directory, one policy per yaml file, named after the file unless the file sets a name
+func loadLocalPolicies(c config.Config) ([]config.LocalPolicy, error) {
+	localPolicies := append([]config.LocalPolicy{}, c.OrbAgent.Policies...)
+	if dir := c.OrbAgent.PoliciesDir; dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policies directory: %w", err)
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read policy file %s: %w", entry.Name(), err)
+			}
+			var lp config.LocalPolicy
+			if err := yaml.Unmarshal(content, &lp); err != nil {
+				return nil, fmt.Errorf("failed to parse policy file %s: %w", entry.Name(), err)
+			}
+			if lp.Name == "" {
+				lp.Name = strings.TrimSuffix(entry.Name(), ext)
+			}
+			localPolicies = append(localPolicies, lp)
+		}
+	}
+
+	names := make(map[string]bool, len(localPolicies))
+	for _, lp := range localPolicies {
+		if lp.Name == "" {
+			return nil, fmt.Errorf("local policy without a name")
+		}
+		if lp.Backend == "" {
+			return nil, fmt.Errorf("local policy %s has no backend", lp.Name)
+		}
+		if names[lp.Name] {
+			return nil, fmt.Errorf("local policy %s is defined more than once", lp.Name)
+		}
+		names[lp.Name] = true
+	}
+	return localPolicies, nil
+}
+
+// ApplyLocalPolicies applies the policies defined in the agent configuration, skipping the ones already running on
+// their version, so it is safe to call on every (re)connection
+func (a *policyManager) ApplyLocalPolicies() {
+	for _, lp := range a.localPolicies {
+		var pd = policies.PolicyData{
+			ID:       LocalPolicyID(lp.Name),
+			Name:     lp.Name,
+			Backend:  lp.Backend,
+			Version:  lp.Version,
+			Data:     lp.Data,
+			Datasets: map[string]bool{LocalDatasetID: true},
+			GroupIds: map[string]bool{},
+			State:    policies.Unknown,
+			Local:    true,
+		}
+		var updatePolicy bool
+		if a.repo.Exists(pd.ID) {
+			currentPolicy, err := a.repo.Get(pd.ID)
+			if err == nil && currentPolicy.Version >= pd.Version && currentPolicy.State == policies.Running {
+				continue
+			}
+			updatePolicy = true
+		}
+		a.logger.Info("applying local policy", zap.String("policy_id", pd.ID), zap.String("policy_name", pd.Name),
+			zap.String("backend", pd.Backend), zap.Int32("version", pd.Version))
+		a.configMu.RLock()
+		_, enabled := a.config.OrbAgent.Backends[pd.Backend]
+		a.configMu.RUnlock()
+		if !enabled || !backend.HaveBackend(pd.Backend) {
+			a.logger.Warn("local policy failed to apply because backend is not available", zap.String("policy_id", pd.ID), zap.String("policy_name", pd.Name))
+			pd.State = policies.FailedToApply
+			pd.BackendErr = "backend not available"
+		} else {
+			be := backend.GetBackend(pd.Backend)
+			a.applyPolicy(fleet.AgentPolicyRPCPayload{ID: pd.ID, Name: pd.Name}, be, &pd, updatePolicy)
+		}
+		if err := a.repo.Update(pd); err != nil {
+			a.logger.Error("got error in update last status", zap.String("policy_id", pd.ID), zap.Error(err))
+		}
+	}
+}
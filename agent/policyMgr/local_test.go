@@ -0,0 +1,100 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orb-community/orb/agent/config"
+)
+
+func TestLoadLocalPolicies(t *testing.T) {
+	inline := config.LocalPolicy{Name: "inline", Backend: "probe", Data: map[string]interface{}{"checks": []interface{}{}}}
+
+	cases := map[string]struct {
+		inline []config.LocalPolicy
+		files  map[string]string
+		names  []string
+		err    bool
+	}{
+		"inline and directory policies": {
+			inline: []config.LocalPolicy{inline},
+			files: map[string]string{
+				"dns.yaml":  "backend: probe\nversion: 2\ndata:\n  checks:\n    - name: dns\n      type: dns\n",
+				"named.yml": "name: custom\nbackend: otel-embedded\ndata:\n  Receivers: {}\n",
+				"notes.txt": "not a policy",
+			},
+			names: []string{"inline", "dns", "custom"},
+		},
+		"duplicated name": {
+			inline: []config.LocalPolicy{inline},
+			files:  map[string]string{"inline.yaml": "backend: probe\n"},
+			err:    true,
+		},
+		"missing backend": {
+			files: map[string]string{"dns.yaml": "version: 1\n"},
+			err:   true,
+		},
+		"invalid yaml": {
+			files: map[string]string{"dns.yaml": "backend: [probe\n"},
+			err:   true,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatalf("%s: failed to write policy file: %s", desc, err)
+				}
+			}
+			c := config.Config{OrbAgent: config.OrbAgent{Policies: tc.inline, PoliciesDir: dir}}
+			localPolicies, err := loadLocalPolicies(c)
+			if tc.err {
+				if err == nil {
+					t.Errorf("%s: expected an error, got none", desc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %s", desc, err)
+			}
+			loaded := make(map[string]config.LocalPolicy, len(localPolicies))
+			for _, lp := range localPolicies {
+				loaded[lp.Name] = lp
+			}
+			if len(loaded) != len(tc.names) {
+				t.Errorf("%s: expected policies %v, got %v", desc, tc.names, localPolicies)
+			}
+			for _, name := range tc.names {
+				if _, ok := loaded[name]; !ok {
+					t.Errorf("%s: expected policy %s to be loaded", desc, name)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadLocalPoliciesKeepsKeyCase(t *testing.T) {
+	dir := t.TempDir()
+	content := "backend: otel-embedded\ndata:\n  pipelines:\n    metrics/Main: {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "otel.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write policy file: %s", err)
+	}
+	localPolicies, err := loadLocalPolicies(config.Config{OrbAgent: config.OrbAgent{PoliciesDir: dir}})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	pipelines, ok := localPolicies[0].Data["pipelines"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected pipelines to be a map, got %T", localPolicies[0].Data["pipelines"])
+	}
+	if _, ok := pipelines["metrics/Main"]; !ok {
+		t.Errorf("expected key case to be kept, got %v", pipelines)
+	}
+}
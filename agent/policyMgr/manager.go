@@ -7,6 +7,7 @@ package manager
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/orb-community/orb/agent/backend"
@@ -25,15 +26,28 @@ type PolicyManager interface {
 	ApplyBackendPolicies(be backend.Backend) error
 	RemoveBackendPolicies(be backend.Backend, permanently bool) error
 	RemovePolicy(policyID string, policyName string, beName string) error
+	ApplyLocalPolicies()
+	// SetBackendsConfig replaces the configuration of the enabled backends, once the agent configuration is reloaded
+	SetBackendsConfig(backends map[string]map[string]string)
 }
 
 var _ PolicyManager = (*policyManager)(nil)
 
 type policyManager struct {
 	logger *zap.Logger
-	config config.Config
+	// configMu guards config, whose backends are replaced when the agent configuration is reloaded
+	configMu sync.RWMutex
+	config   config.Config
 
 	repo policies.PolicyRepo
+	// localPolicies are defined in the agent configuration and applied on boot
+	localPolicies []config.LocalPolicy
+}
+
+func (a *policyManager) SetBackendsConfig(backends map[string]map[string]string) {
+	a.configMu.Lock()
+	defer a.configMu.Unlock()
+	a.config.OrbAgent.Backends = backends
 }
 
 func (a *policyManager) GetRepo() policies.PolicyRepo {
@@ -49,7 +63,11 @@ func New(logger *zap.Logger, c config.Config, db *sqlx.DB) (PolicyManager, error
 	if err != nil {
 		return nil, err
 	}
-	return &policyManager{logger: logger, config: c, repo: repo}, nil
+	localPolicies, err := loadLocalPolicies(c)
+	if err != nil {
+		return nil, err
+	}
+	return &policyManager{logger: logger, config: c, repo: repo, localPolicies: localPolicies}, nil
 }
 
 func (a *policyManager) ManagePolicy(payload fleet.AgentPolicyRPCPayload) {
@@ -0,0 +1,197 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/config"
+	"github.com/orb-community/orb/agent/tags"
+	"go.uber.org/zap"
+)
+
+// Reload applies a new configuration without restarting the agent. Tag changes are sent to the control plane as
+// capabilities, backends whose configuration changed are restarted, added and removed ones are started and stopped,
+// leaving the others running. Any other setting only takes effect once the agent restarts. An invalid configuration
+// is rejected and the current one kept
+func (a *orbAgent) Reload(ctx context.Context, c config.Config) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	if err := validateReload(c); err != nil {
+		a.logger.Error("invalid configuration, keeping the current one", zap.Error(err))
+		return err
+	}
+	if sections := restartOnlySections(a.config, c); len(sections) > 0 {
+		a.logger.Warn("configuration changes that require an agent restart were ignored", zap.Strings("sections", sections))
+	}
+
+	var tagResolver *tags.Resolver
+	if len(c.OrbAgent.TagProviders.Providers) > 0 {
+		var err error
+		tagResolver, err = tags.NewResolver(a.logger, c.OrbAgent.Tags, c.OrbAgent.TagProviders.Providers)
+		if err != nil {
+			a.logger.Error("invalid configuration, keeping the current one", zap.Error(err))
+			return err
+		}
+		c.OrbAgent.Tags = tagResolver.Resolve(ctx)
+	}
+	a.tagsMu.Lock()
+	a.tagResolver = tagResolver
+	a.config.OrbAgent.TagProviders.Providers = c.OrbAgent.TagProviders.Providers
+	a.tagsMu.Unlock()
+	tagsChanged := a.setTags(c.OrbAgent.Tags)
+
+	a.stateMu.Lock()
+	added, changed, removed := diffBackends(a.config.OrbAgent.Backends, c.OrbAgent.Backends)
+	a.stateMu.Unlock()
+	for _, name := range removed {
+		a.removeBackend(ctx, name)
+	}
+	// the configuration map is replaced rather than updated, it is shared with the policy manager
+	a.stateMu.Lock()
+	a.config.OrbAgent.Backends = c.OrbAgent.Backends
+	a.stateMu.Unlock()
+	a.policyManager.SetBackendsConfig(c.OrbAgent.Backends)
+	for _, name := range changed {
+		if err := a.RestartBackend(ctx, name, "configuration reloaded"); err != nil {
+			a.logger.Error("failed to restart backend", zap.String("backend", name), zap.Error(err))
+		}
+	}
+	for _, name := range added {
+		if err := a.addBackend(ctx, name, c.OrbAgent.Backends[name]); err != nil {
+			a.logger.Error("failed to start backend", zap.String("backend", name), zap.Error(err))
+		}
+	}
+
+	if !tagsChanged && len(added)+len(changed)+len(removed) == 0 {
+		a.logger.Info("configuration reloaded, nothing changed")
+		return nil
+	}
+	if tagsChanged && len(changed) < len(a.runningBackends()) {
+		a.logger.Info("telemetry of backends not restarted carries the new tags once they restart")
+	}
+	if a.mqttConnected() {
+		if err := a.sendCapabilities(); err != nil {
+			a.logger.Error("failed to send agent capabilities", zap.Error(err))
+		}
+	}
+	a.logger.Info("configuration reloaded", zap.Bool("tags_changed", tagsChanged),
+		zap.Strings("added_backends", added), zap.Strings("restarted_backends", changed), zap.Strings("removed_backends", removed))
+	return nil
+}
+
+func validateReload(c config.Config) error {
+	if len(c.OrbAgent.Backends) == 0 {
+		return errors.New("no backends specified")
+	}
+	for name := range c.OrbAgent.Backends {
+		if !backend.HaveBackend(name) {
+			return errors.New("specified backend does not exist: " + name)
+		}
+	}
+	return nil
+}
+
+// restartOnlySections lists the configuration sections that changed but are only read on agent start
+func restartOnlySections(current config.Config, c config.Config) []string {
+	var sections []string
+	if !reflect.DeepEqual(current.OrbAgent.Cloud.API, c.OrbAgent.Cloud.API) ||
+		!reflect.DeepEqual(current.OrbAgent.Cloud.Config, c.OrbAgent.Cloud.Config) {
+		sections = append(sections, "cloud")
+	}
+	if !reflect.DeepEqual(current.OrbAgent.TLS, c.OrbAgent.TLS) {
+		sections = append(sections, "tls")
+	}
+	if !reflect.DeepEqual(current.OrbAgent.DB, c.OrbAgent.DB) {
+		sections = append(sections, "db")
+	}
+	if !reflect.DeepEqual(current.OrbAgent.Otel, c.OrbAgent.Otel) {
+		sections = append(sections, "otel")
+	}
+	if !reflect.DeepEqual(current.OrbAgent.Policies, c.OrbAgent.Policies) || current.OrbAgent.PoliciesDir != c.OrbAgent.PoliciesDir {
+		sections = append(sections, "policies")
+	}
+	if current.OrbAgent.TagProviders.RefreshInterval != c.OrbAgent.TagProviders.RefreshInterval ||
+		(len(current.OrbAgent.TagProviders.Providers) == 0) != (len(c.OrbAgent.TagProviders.Providers) == 0) {
+		sections = append(sections, "tag_providers.refresh_interval")
+	}
+	if !reflect.DeepEqual(current.OrbAgent.LocalExporter, c.OrbAgent.LocalExporter) {
+		sections = append(sections, "local_exporter")
+	}
+	if current.OrbAgent.Standalone != c.OrbAgent.Standalone {
+		sections = append(sections, "standalone")
+	}
+	if current.OrbAgent.StatusServer != c.OrbAgent.StatusServer {
+		sections = append(sections, "status_server")
+	}
+	return sections
+}
+
+// diffBackends compares the backends configuration, returning the names of backends added, changed and removed
+func diffBackends(current map[string]map[string]string, updated map[string]map[string]string) (added []string, changed []string, removed []string) {
+	for name, entry := range updated {
+		currentEntry, ok := current[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case !reflect.DeepEqual(currentEntry, entry):
+			changed = append(changed, name)
+		}
+	}
+	for name := range current {
+		if _, ok := updated[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return added, changed, removed
+}
+
+// addBackend starts a backend added to the configuration and hands it the agent comms
+func (a *orbAgent) addBackend(ctx context.Context, name string, configurationEntry map[string]string) error {
+	a.logger.Info("starting backend added to the configuration", zap.String("backend", name))
+	if err := a.startBackend(ctx, name, configurationEntry); err != nil {
+		return err
+	}
+	if be, _, ok := a.getBackend(name); ok && a.client != nil && a.baseTopic != "" {
+		be.SetCommsClient(a.agent_id, a.exportClient(&a.client), fmt.Sprintf("%s/?/%s", a.baseTopic, name))
+	}
+	a.policyManager.ApplyLocalPolicies()
+	if a.mqttConnected() {
+		if err := a.sendAgentPoliciesReq(); err != nil {
+			a.logger.Error("failed to send agent policies request", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// removeBackend stops a backend removed from the configuration, dropping its policies
+func (a *orbAgent) removeBackend(ctx context.Context, name string) {
+	a.logger.Info("stopping backend removed from the configuration", zap.String("backend", name))
+	plcies, err := a.policyManager.GetRepo().GetAll()
+	if err != nil {
+		a.logger.Error("failed to retrieve list of policies", zap.Error(err))
+	}
+	for _, policy := range plcies {
+		if policy.Backend != name {
+			continue
+		}
+		if err := a.policyManager.RemovePolicy(policy.ID, policy.Name, policy.Backend); err != nil {
+			a.logger.Warn("failed to remove a policy, ignoring", zap.String("policy_id", policy.ID), zap.String("policy_name", policy.Name), zap.Error(err))
+		}
+	}
+	if be, _, ok := a.getBackend(name); ok {
+		if err := be.Stop(ctx); err != nil {
+			a.logger.Error("error while stopping the backend", zap.String("backend", name), zap.Error(err))
+		}
+	}
+	a.stateMu.Lock()
+	delete(a.backends, name)
+	delete(a.backendState, name)
+	a.stateMu.Unlock()
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/config"
+)
+
+func TestDiffBackends(t *testing.T) {
+	current := map[string]map[string]string{
+		"pktvisor": {"binary": "/usr/local/sbin/pktvisord"},
+		"otel":     {"otlp_port": "4316"},
+		"probe":    {},
+	}
+	updated := map[string]map[string]string{
+		"pktvisor":      {"binary": "/usr/local/sbin/pktvisord"},
+		"otel":          {"otlp_port": "4317"},
+		"otel-embedded": {},
+	}
+
+	added, changed, removed := diffBackends(current, updated)
+	for desc, tc := range map[string]struct {
+		got      []string
+		expected []string
+	}{
+		"added":   {got: added, expected: []string{"otel-embedded"}},
+		"changed": {got: changed, expected: []string{"otel"}},
+		"removed": {got: removed, expected: []string{"probe"}},
+	} {
+		sort.Strings(tc.got)
+		if len(tc.got) != len(tc.expected) {
+			t.Errorf("%s: expected %v got %v", desc, tc.expected, tc.got)
+			continue
+		}
+		for i := range tc.expected {
+			if tc.got[i] != tc.expected[i] {
+				t.Errorf("%s: expected %v got %v", desc, tc.expected, tc.got)
+			}
+		}
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	a := newStatusAgent(t, true, backend.Running)
+	a.config.OrbAgent.Tags = map[string]string{"region": "eu"}
+	a.config.OrbAgent.Backends = map[string]map[string]string{"probe": {}}
+
+	cases := map[string]config.Config{
+		"no backends": {OrbAgent: config.OrbAgent{Tags: map[string]string{"region": "us"}}},
+		"unknown backend": {OrbAgent: config.OrbAgent{
+			Tags:     map[string]string{"region": "us"},
+			Backends: map[string]map[string]string{"unknown": {}},
+		}},
+	}
+	for desc, c := range cases {
+		t.Run(desc, func(t *testing.T) {
+			if err := a.Reload(context.Background(), c); err == nil {
+				t.Errorf("%s: expected an error, got none", desc)
+			}
+			if a.config.OrbAgent.Tags["region"] != "eu" {
+				t.Errorf("%s: expected the current tags to be kept, got %v", desc, a.config.OrbAgent.Tags)
+			}
+			if _, ok := a.config.OrbAgent.Backends["probe"]; !ok || len(a.config.OrbAgent.Backends) != 1 {
+				t.Errorf("%s: expected the current backends to be kept, got %v", desc, a.config.OrbAgent.Backends)
+			}
+		})
+	}
+}
+
+func TestRestartOnlySections(t *testing.T) {
+	current := config.Config{OrbAgent: config.OrbAgent{
+		Tags:         map[string]string{"region": "eu"},
+		TLS:          config.TLS{Verify: true},
+		StatusServer: config.StatusServer{Enabled: true, Address: "localhost:10854"},
+	}}
+	updated := current
+	updated.OrbAgent.Tags = map[string]string{"region": "us"}
+	if sections := restartOnlySections(current, updated); len(sections) != 0 {
+		t.Errorf("expected tags to be reloadable, got %v", sections)
+	}
+	updated.OrbAgent.TLS = config.TLS{Verify: false}
+	updated.OrbAgent.StatusServer.Address = "localhost:10855"
+	sections := restartOnlySections(current, updated)
+	if len(sections) != 2 || sections[0] != "tls" || sections[1] != "status_server" {
+		t.Errorf("expected tls and status_server to require a restart, got %v", sections)
+	}
+}
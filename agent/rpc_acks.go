@@ -0,0 +1,80 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"sync"
+
+	"github.com/orb-community/orb/fleet"
+	"go.uber.org/zap"
+)
+
+// rpcAckCacheSize is how many acknowledgements are remembered, well above what core sends before giving up on them
+const rpcAckCacheSize = 256
+
+// rpcAckCache remembers the outcome of the RPCs recently received from core. Core sends an RPC again until it is
+// acknowledged, so an RPC received twice is acknowledged again instead of being handled twice
+type rpcAckCache struct {
+	mu    sync.Mutex
+	order []string
+	acks  map[string]fleet.RPCAckRPCPayload
+}
+
+func (c *rpcAckCache) get(correlationID string) (fleet.RPCAckRPCPayload, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ack, ok := c.acks[correlationID]
+	return ack, ok
+}
+
+func (c *rpcAckCache) add(ack fleet.RPCAckRPCPayload) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.acks == nil {
+		c.acks = make(map[string]fleet.RPCAckRPCPayload)
+	}
+	if _, ok := c.acks[ack.CorrelationID]; !ok {
+		c.order = append(c.order, ack.CorrelationID)
+	}
+	c.acks[ack.CorrelationID] = ack
+	for len(c.order) > rpcAckCacheSize {
+		delete(c.acks, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// ackRPC reports to core the outcome of an RPC it sent. RPCs without correlation id are not acknowledged
+func (a *orbAgent) ackRPC(correlationID string, status string, err error) {
+	if correlationID == "" {
+		return
+	}
+	ack := fleet.RPCAckRPCPayload{
+		CorrelationID: correlationID,
+		Status:        status,
+	}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	a.rpcAcks.add(ack)
+	if err := a.sendRPCAck(ack); err != nil {
+		a.logger.Error("failed to acknowledge RPC from core", zap.String("correlation_id", correlationID), zap.Error(err))
+	}
+}
+
+// reackRPC acknowledges again an RPC core sent again, returning whether it was already handled
+func (a *orbAgent) reackRPC(correlationID string) bool {
+	if correlationID == "" {
+		return false
+	}
+	ack, ok := a.rpcAcks.get(correlationID)
+	if !ok {
+		return false
+	}
+	a.logger.Debug("RPC from core already handled, acknowledging again", zap.String("correlation_id", correlationID), zap.String("status", ack.Status))
+	if err := a.sendRPCAck(ack); err != nil {
+		a.logger.Error("failed to acknowledge RPC from core", zap.String("correlation_id", correlationID), zap.Error(err))
+	}
+	return true
+}
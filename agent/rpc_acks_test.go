@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/orb-community/orb/fleet"
+)
+
+func TestRPCAckCache(t *testing.T) {
+	var c rpcAckCache
+	if _, ok := c.get("unknown"); ok {
+		t.Errorf("empty cache: expected no acknowledgement")
+	}
+
+	for i := 0; i <= rpcAckCacheSize; i++ {
+		c.add(fleet.RPCAckRPCPayload{CorrelationID: fmt.Sprint(i), Status: fleet.RPCApplied})
+	}
+	c.add(fleet.RPCAckRPCPayload{CorrelationID: "1", Status: fleet.RPCFailed, Error: "failed"})
+
+	for desc, tc := range map[string]struct {
+		id     string
+		ok     bool
+		status string
+	}{
+		"oldest evicted": {id: "0", ok: false},
+		"replaced":       {id: "1", ok: true, status: fleet.RPCFailed},
+		"most recent":    {id: fmt.Sprint(rpcAckCacheSize), ok: true, status: fleet.RPCApplied},
+	} {
+		ack, ok := c.get(tc.id)
+		if ok != tc.ok || ack.Status != tc.status {
+			t.Errorf("%s: expected %v %q got %v %q", desc, tc.ok, tc.status, ok, ack.Status)
+		}
+	}
+	if len(c.order) != rpcAckCacheSize || len(c.acks) != rpcAckCacheSize {
+		t.Errorf("expected %d acknowledgements got %d", rpcAckCacheSize, len(c.acks))
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/orb-community/orb/agent/policies"
 	"github.com/orb-community/orb/fleet"
 	"go.uber.org/zap"
 )
@@ -40,6 +41,10 @@ func (a *orbAgent) handleAgentPolicies(ctx context.Context, rpc []fleet.AgentPol
 		// Create a map with all the old policies
 		policyRemove := map[string]bool{}
 		for _, p := range policies {
+			// local policies are not known by the control plane, they are never part of the list
+			if p.Local {
+				continue
+			}
 			policyRemove[p.ID] = true
 		}
 		for _, payload := range rpc {
@@ -86,12 +91,17 @@ func (a *orbAgent) handleGroupRPCFromCore(_ mqtt.Client, message mqtt.Message) {
 			a.logger.Error("error decoding RPC message from core", zap.Error(fleet.ErrSchemaMalformed))
 			return
 		}
-		if rpc.SchemaVersion != fleet.CurrentRPCSchemaVersion {
+		if a.reackRPC(rpc.CorrelationID) {
+			return
+		}
+		if !fleet.SupportsSchemaVersion(fleet.SupportedRPCSchemaVersions, rpc.SchemaVersion) {
 			a.logger.Error("error decoding RPC message from core", zap.Error(fleet.ErrSchemaVersion))
+			a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaVersion)
 			return
 		}
 		if rpc.Func == "" || rpc.Payload == nil {
 			a.logger.Error("error decoding RPC message from core", zap.Error(fleet.ErrSchemaMalformed))
+			a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 			return
 		}
 
@@ -101,17 +111,15 @@ func (a *orbAgent) handleGroupRPCFromCore(_ mqtt.Client, message mqtt.Message) {
 			var r fleet.AgentPolicyRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding agent policy message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
 			a.handleAgentPolicies(ctx, r.Payload, r.FullList)
-			a.logger.Debug("received agent policies, marking success")
-			if a.policyRequestSucceeded != nil {
-				a.policyRequestSucceeded()
-			}
 		case fleet.GroupRemovedRPCFunc:
 			var r fleet.GroupRemovedRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding agent group removal message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
 			a.handleAgentGroupRemoval(r.Payload)
@@ -119,6 +127,7 @@ func (a *orbAgent) handleGroupRPCFromCore(_ mqtt.Client, message mqtt.Message) {
 			var r fleet.DatasetRemovedRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding dataset removal message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
 			a.handleDatasetRemoval(r.Payload)
@@ -126,7 +135,10 @@ func (a *orbAgent) handleGroupRPCFromCore(_ mqtt.Client, message mqtt.Message) {
 			a.logger.Warn("unsupported/unhandled core RPC, ignoring",
 				zap.String("func", rpc.Func),
 				zap.Any("payload", rpc.Payload))
+			a.ackRPC(rpc.CorrelationID, fleet.RPCIgnored, nil)
+			return
 		}
+		a.ackRPC(rpc.CorrelationID, fleet.RPCApplied, nil)
 	}(handleMsgCtx, handleMsgCtxCancelFunc)
 }
 
@@ -144,6 +156,9 @@ func (a *orbAgent) handleAgentGroupRemoval(rpc fleet.GroupRemovedRPCPayload) {
 	}
 
 	for _, policy := range policies {
+		if policy.Local {
+			continue
+		}
 		delete(policy.GroupIds, rpc.AgentGroupID)
 
 		if len(policy.GroupIds) == 0 {
@@ -178,6 +193,33 @@ func (a *orbAgent) handleAgentReset(ctx context.Context, payload fleet.AgentRese
 	}
 }
 
+// handlePolicyTest validates the policy with its backend without applying it, and reports the outcome to core
+func (a *orbAgent) handlePolicyTest(rpc fleet.PolicyTestRPCPayload) {
+	result := fleet.PolicyTestResultRPCPayload{
+		RequestID: rpc.RequestID,
+		PolicyID:  rpc.Policy.ID,
+		Backend:   rpc.Policy.Backend,
+	}
+	if be, _, ok := a.getBackend(rpc.Policy.Backend); !ok {
+		result.Error = fmt.Sprintf("backend %s is not enabled on this agent", rpc.Policy.Backend)
+	} else if err := be.TestPolicy(policies.PolicyData{
+		ID:      rpc.Policy.ID,
+		Name:    rpc.Policy.Name,
+		Backend: rpc.Policy.Backend,
+		Version: rpc.Policy.Version,
+		Data:    rpc.Policy.Data,
+		State:   policies.Unknown,
+	}); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Valid = true
+	}
+	a.logger.Info("policy tested", zap.String("policy_id", result.PolicyID), zap.Bool("valid", result.Valid), zap.String("error", result.Error))
+	if err := a.sendPolicyTestResult(result); err != nil {
+		a.logger.Error("failed to send policy test result", zap.String("policy_id", result.PolicyID), zap.Error(err))
+	}
+}
+
 func (a *orbAgent) handleRPCFromCore(client mqtt.Client, message mqtt.Message) {
 	handleMsgCtx, handleMsgCtxCancelFunc := a.extendContext("handleRPCFromCore")
 	go func(ctx context.Context, cancelFunc context.CancelFunc) {
@@ -188,12 +230,17 @@ func (a *orbAgent) handleRPCFromCore(client mqtt.Client, message mqtt.Message) {
 			a.logger.Error("error decoding RPC message from core", zap.Error(fleet.ErrSchemaMalformed))
 			return
 		}
-		if rpc.SchemaVersion != fleet.CurrentRPCSchemaVersion {
+		if a.reackRPC(rpc.CorrelationID) {
+			return
+		}
+		if !fleet.SupportsSchemaVersion(fleet.SupportedRPCSchemaVersions, rpc.SchemaVersion) {
 			a.logger.Error("error decoding RPC message from core", zap.Error(fleet.ErrSchemaVersion))
+			a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaVersion)
 			return
 		}
 		if rpc.Func == "" || rpc.Payload == nil {
 			a.logger.Error("error decoding RPC message from core", zap.Error(fleet.ErrSchemaMalformed))
+			a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 			return
 		}
 		// dispatch
@@ -202,42 +249,86 @@ func (a *orbAgent) handleRPCFromCore(client mqtt.Client, message mqtt.Message) {
 			var r fleet.GroupMembershipRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding group membership message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
 			a.handleGroupMembership(r.Payload)
-			a.logger.Debug("received group membership, marking success")
-			if a.groupRequestSucceeded != nil {
-				a.groupRequestSucceeded()
-			}
 		case fleet.AgentPolicyRPCFunc:
 			var r fleet.AgentPolicyRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding agent policy message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
 			a.handleAgentPolicies(ctx, r.Payload, r.FullList)
-			a.logger.Debug("received agent policies, marking success")
-			if a.policyRequestSucceeded != nil {
-				a.policyRequestSucceeded()
-			}
 		case fleet.AgentStopRPCFunc:
 			var r fleet.AgentStopRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding agent stop message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
+			// acknowledged first, the agent terminates
+			a.ackRPC(rpc.CorrelationID, fleet.RPCApplied, nil)
 			a.handleAgentStop(r.Payload)
 		case fleet.AgentResetRPCFunc:
 			var r fleet.AgentResetRPC
 			if err := json.Unmarshal(message.Payload(), &r); err != nil {
 				a.logger.Error("error decoding agent reset message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
 				return
 			}
+			// acknowledged first, the reset reconnects to core
+			a.ackRPC(rpc.CorrelationID, fleet.RPCApplied, nil)
 			a.handleAgentReset(ctx, r.Payload)
+			return
+		case fleet.PolicyTestRPCFunc:
+			var r fleet.PolicyTestRPC
+			if err := json.Unmarshal(message.Payload(), &r); err != nil {
+				a.logger.Error("error decoding policy test message from core", zap.Error(fleet.ErrSchemaMalformed))
+				return
+			}
+			a.handlePolicyTest(r.Payload)
+		case fleet.AgentCredentialsRPCFunc:
+			var r fleet.AgentCredentialsRPC
+			if err := json.Unmarshal(message.Payload(), &r); err != nil {
+				a.logger.Error("error decoding agent credentials message from core", zap.Error(fleet.ErrSchemaMalformed))
+				return
+			}
+			a.handleAgentCredentials(r.Payload)
+		case fleet.AgentCredentialsCommitRPCFunc:
+			var r fleet.AgentCredentialsCommitRPC
+			if err := json.Unmarshal(message.Payload(), &r); err != nil {
+				a.logger.Error("error decoding agent credentials commit message from core", zap.Error(fleet.ErrSchemaMalformed))
+				return
+			}
+			a.handleAgentCredentialsCommit(ctx, r.Payload)
+		case fleet.AgentDiagnosticsRPCFunc:
+			var r fleet.AgentDiagnosticsRPC
+			if err := json.Unmarshal(message.Payload(), &r); err != nil {
+				a.logger.Error("error decoding agent diagnostics message from core", zap.Error(fleet.ErrSchemaMalformed))
+				a.ackRPC(rpc.CorrelationID, fleet.RPCFailed, fleet.ErrSchemaMalformed)
+				return
+			}
+			// acknowledged first, so core does not send it again while the bundle uploads
+			a.ackRPC(rpc.CorrelationID, fleet.RPCApplied, nil)
+			a.handleDiagnostics(r.Payload)
+			return
+		case fleet.RPCAckRPCFunc:
+			var r fleet.RPCAckRPC
+			if err := json.Unmarshal(message.Payload(), &r); err != nil {
+				a.logger.Error("error decoding request acknowledgement message from core", zap.Error(fleet.ErrSchemaMalformed))
+				return
+			}
+			a.handleRequestAck(r.Payload)
+			return
 		default:
 			a.logger.Warn("unsupported/unhandled core RPC, ignoring",
 				zap.String("func", rpc.Func),
 				zap.Any("payload", rpc.Payload))
+			a.ackRPC(rpc.CorrelationID, fleet.RPCIgnored, nil)
+			return
 		}
+		a.ackRPC(rpc.CorrelationID, fleet.RPCApplied, nil)
 	}(handleMsgCtx, handleMsgCtxCancelFunc)
 }
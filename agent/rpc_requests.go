@@ -0,0 +1,115 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+	"go.uber.org/zap"
+)
+
+const (
+	// requestAckTimeout is how long to wait for core to acknowledge a request before sending it again
+	requestAckTimeout = 30 * time.Second
+	// requestMaxAttempts is how many times a request is sent before giving up on it
+	requestMaxAttempts = 5
+)
+
+type pendingRequest struct {
+	rpcFunc       string
+	correlationID string
+	body          []byte
+	attempts      int
+	lastSent      time.Time
+}
+
+// pendingRequests tracks the requests sent to core it did not acknowledge yet, one per func as a newer request
+// replaces the pending one
+type pendingRequests struct {
+	mu       sync.Mutex
+	requests map[string]*pendingRequest
+}
+
+func (p *pendingRequests) add(rpcFunc string, correlationID string, body []byte, sent time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.requests == nil {
+		p.requests = make(map[string]*pendingRequest)
+	}
+	p.requests[rpcFunc] = &pendingRequest{
+		rpcFunc:       rpcFunc,
+		correlationID: correlationID,
+		body:          body,
+		attempts:      1,
+		lastSent:      sent,
+	}
+}
+
+// ack stops tracking the request with the given correlation id, returning whether it was pending
+func (p *pendingRequests) ack(correlationID string) (pendingRequest, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for rpcFunc, r := range p.requests {
+		if r.correlationID == correlationID {
+			delete(p.requests, rpcFunc)
+			return *r, true
+		}
+	}
+	return pendingRequest{}, false
+}
+
+// due returns the requests to send again as core did not acknowledge them in time, counting the new attempt, and
+// stops tracking the ones sent requestMaxAttempts times already
+func (p *pendingRequests) due(now time.Time) (retries []pendingRequest, expired []pendingRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for rpcFunc, r := range p.requests {
+		if now.Sub(r.lastSent) < requestAckTimeout {
+			continue
+		}
+		if r.attempts >= requestMaxAttempts {
+			delete(p.requests, rpcFunc)
+			expired = append(expired, *r)
+			continue
+		}
+		r.attempts++
+		r.lastSent = now
+		retries = append(retries, *r)
+	}
+	return retries, expired
+}
+
+// retryRequests sends again the requests core did not acknowledge in time
+func (a *orbAgent) retryRequests(now time.Time) {
+	retries, expired := a.rpcRequests.due(now)
+	for _, r := range expired {
+		a.logger.Warn("core did not acknowledge request, giving up", zap.String("func", r.rpcFunc),
+			zap.String("correlation_id", r.correlationID), zap.Int("attempts", r.attempts))
+	}
+	for _, r := range retries {
+		a.logger.Info("core did not acknowledge request, sending it again", zap.String("func", r.rpcFunc),
+			zap.String("correlation_id", r.correlationID), zap.Int("attempts", r.attempts))
+		if token := a.client.Publish(a.rpcToCoreTopic, 1, false, r.body); token.Wait() && token.Error() != nil {
+			a.logger.Error("failed to send request again", zap.String("func", r.rpcFunc), zap.Error(token.Error()))
+		}
+	}
+}
+
+// handleRequestAck stops sending again the request core acknowledged
+func (a *orbAgent) handleRequestAck(ack fleet.RPCAckRPCPayload) {
+	r, ok := a.rpcRequests.ack(ack.CorrelationID)
+	if !ok {
+		a.logger.Debug("acknowledgement of a request no longer pending, ignoring", zap.String("correlation_id", ack.CorrelationID))
+		return
+	}
+	if ack.Status == fleet.RPCFailed {
+		a.logger.Warn("core failed to handle request", zap.String("func", r.rpcFunc),
+			zap.String("correlation_id", ack.CorrelationID), zap.String("error", ack.Error))
+		return
+	}
+	a.logger.Debug("request acknowledged by core", zap.String("func", r.rpcFunc), zap.String("correlation_id", ack.CorrelationID))
+}
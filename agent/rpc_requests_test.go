@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+)
+
+func TestPendingRequests(t *testing.T) {
+	var p pendingRequests
+	sent := time.Now()
+	p.add(fleet.GroupMembershipReqRPCFunc, "group-1", nil, sent)
+	p.add(fleet.GroupMembershipReqRPCFunc, "group-2", nil, sent)
+	p.add(fleet.AgentPoliciesReqRPCFunc, "policies-1", nil, sent)
+
+	if _, ok := p.ack("group-1"); ok {
+		t.Errorf("replaced request: expected no pending request")
+	}
+	if r, ok := p.ack("policies-1"); !ok || r.rpcFunc != fleet.AgentPoliciesReqRPCFunc {
+		t.Errorf("acknowledged request: expected %s got %v %s", fleet.AgentPoliciesReqRPCFunc, ok, r.rpcFunc)
+	}
+
+	if retries, _ := p.due(sent.Add(requestAckTimeout / 2)); len(retries) != 0 {
+		t.Errorf("request within timeout: expected no retry got %d", len(retries))
+	}
+
+	now := sent
+	for attempt := 2; attempt <= requestMaxAttempts; attempt++ {
+		now = now.Add(requestAckTimeout)
+		retries, expired := p.due(now)
+		if len(retries) != 1 || retries[0].correlationID != "group-2" || retries[0].attempts != attempt || len(expired) != 0 {
+			t.Fatalf("unacknowledged request: expected attempt %d of group-2 got %v expired %v", attempt, retries, expired)
+		}
+	}
+
+	retries, expired := p.due(now.Add(requestAckTimeout))
+	if len(retries) != 0 || len(expired) != 1 || expired[0].correlationID != "group-2" {
+		t.Errorf("request out of attempts: expected group-2 to expire got %v retried %v", expired, retries)
+	}
+	if _, ok := p.ack("group-2"); ok {
+		t.Errorf("expired request: expected no pending request")
+	}
+}
@@ -5,27 +5,31 @@
 package agent
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"github.com/google/uuid"
 	"github.com/orb-community/orb/buildinfo"
 	"github.com/orb-community/orb/fleet"
 	"go.uber.org/zap"
 	"time"
 )
 
-func (a *orbAgent) sendCapabilities() error {
-
+// capabilities describes the agent and its backends as advertised to core
+func (a *orbAgent) capabilities() fleet.Capabilities {
 	capabilities := fleet.Capabilities{
 		SchemaVersion: fleet.CurrentCapabilitiesSchemaVersion,
-		AgentTags:     a.config.OrbAgent.Tags,
+		AgentTags:     a.agentTags(),
 		OrbAgent: fleet.OrbAgentInfo{
 			Version: buildinfo.GetVersion(),
 		},
+		SchemaVersions: fleet.SchemaVersions{
+			Capabilities: []string{fleet.CurrentCapabilitiesSchemaVersion},
+			Heartbeat:    []string{fleet.CurrentHeartbeatSchemaVersion},
+			RPC:          fleet.SupportedRPCSchemaVersions,
+		},
 	}
 
 	capabilities.Backends = make(map[string]fleet.BackendInfo)
-	for name, be := range a.backends {
+	for name, be := range a.runningBackends() {
 		ver, err := be.Version()
 		if err != nil {
 			a.logger.Error("backend failed to retrieve version, skipping", zap.String("backend", name), zap.Error(err))
@@ -41,8 +45,11 @@ func (a *orbAgent) sendCapabilities() error {
 			Data:    cp,
 		}
 	}
+	return capabilities
+}
 
-	body, err := json.Marshal(capabilities)
+func (a *orbAgent) sendCapabilities() error {
+	body, err := json.Marshal(a.capabilities())
 	if err != nil {
 		a.logger.Error("backend failed to marshal capabilities, skipping", zap.Error(err))
 		return err
@@ -56,76 +63,79 @@ func (a *orbAgent) sendCapabilities() error {
 	return nil
 }
 
-func (a *orbAgent) sendGroupMembershipRequest() error {
+func (a *orbAgent) sendGroupMembershipReq() error {
 	a.logger.Debug("sending group membership request")
-	payload := fleet.GroupMembershipReqRPCPayload{}
+	return a.sendRequest(fleet.GroupMembershipReqRPCFunc, fleet.GroupMembershipReqRPCPayload{})
+}
 
+func (a *orbAgent) sendAgentPoliciesReq() error {
+	a.logger.Debug("sending agent policies request")
+	return a.sendRequest(fleet.AgentPoliciesReqRPCFunc, fleet.AgentPoliciesReqRPCPayload{})
+}
+
+// sendRequest sends a request to core under a new correlation id, tracked until core acknowledges it
+func (a *orbAgent) sendRequest(rpcFunc string, payload interface{}) error {
 	data := fleet.RPC{
 		SchemaVersion: fleet.CurrentRPCSchemaVersion,
-		Func:          fleet.GroupMembershipReqRPCFunc,
+		Func:          rpcFunc,
 		Payload:       payload,
+		CorrelationID: uuid.NewString(),
 	}
 	body, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
+	// tracked first, so the acknowledgement cannot arrive before
+	a.rpcRequests.add(rpcFunc, data.CorrelationID, body, time.Now())
 	if token := a.client.Publish(a.rpcToCoreTopic, 1, false, body); token.Wait() && token.Error() != nil {
 		return token.Error()
 	}
 	return nil
 }
 
-func (a *orbAgent) sendGroupMembershipReq() error {
-	defer a.retryGroupMembershipRequest()
-	return a.sendGroupMembershipRequest()
-}
+func (a *orbAgent) sendPolicyTestResult(payload fleet.PolicyTestResultRPCPayload) error {
+	data := fleet.PolicyTestResultRPC{
+		SchemaVersion: fleet.CurrentRPCSchemaVersion,
+		Func:          fleet.PolicyTestResultRPCFunc,
+		Payload:       payload,
+	}
 
-func (a *orbAgent) retryGroupMembershipRequest() {
-	if a.groupRequestTicker == nil {
-		a.groupRequestTicker = time.NewTicker(retryRequestFixedTime * retryRequestDuration)
-	}
-	var ctx context.Context
-	ctx, a.groupRequestSucceeded = a.extendContext("retryGroupMembershipRequest")
-	go func(ctx context.Context) {
-		defer a.groupRequestTicker.Stop()
-		defer func(t time.Time) {
-			a.logger.Info("execution period of the re-request of retryGroupMembership", zap.Duration("waiting period", time.Now().Sub(t)))
-		}(time.Now())
-		lastT := time.Now()
-		for calls := 1; calls <= retryMaxAttempts; calls++ {
-			select {
-			case <-ctx.Done():
-				return
-			case t := <-a.groupRequestTicker.C:
-				a.logger.Info("agent did not receive any group membership from fleet, re-requesting", zap.Duration("waiting period", lastT.Sub(t)))
-				duration := retryRequestFixedTime + (calls * retryDurationIncrPerAttempts)
-				a.groupRequestTicker.Reset(time.Duration(duration) * retryRequestDuration)
-				err := a.sendGroupMembershipRequest()
-				if err != nil {
-					a.logger.Error("failed to send group membership request", zap.Error(err))
-					return
-				}
-				lastT = t
-			}
-		}
-		a.logger.Warn(fmt.Sprintf("retryGroupMembership retried %d times and still got no response from fleet", retryMaxAttempts))
-		return
-	}(ctx)
-}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
 
-func (a *orbAgent) sendAgentPoliciesReq() error {
-	defer a.retryAgentPolicyResponse()
-	return a.sendAgentPoliciesRequest()
+	if token := a.client.Publish(a.rpcToCoreTopic, 1, false, body); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+
+	return nil
 }
 
-func (a *orbAgent) sendAgentPoliciesRequest() error {
-	a.logger.Debug("sending agent policies request")
-	payload := fleet.AgentPoliciesReqRPCPayload{}
+func (a *orbAgent) sendAgentCredentialsAck(payload fleet.AgentCredentialsAckRPCPayload) error {
+	data := fleet.AgentCredentialsAckRPC{
+		SchemaVersion: fleet.CurrentRPCSchemaVersion,
+		Func:          fleet.AgentCredentialsAckRPCFunc,
+		Payload:       payload,
+	}
 
-	data := fleet.RPC{
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if token := a.client.Publish(a.rpcToCoreTopic, 1, false, body); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+
+	return nil
+}
+
+func (a *orbAgent) sendRPCAck(payload fleet.RPCAckRPCPayload) error {
+	data := fleet.RPCAckRPC{
 		SchemaVersion: fleet.CurrentRPCSchemaVersion,
-		Func:          fleet.AgentPoliciesReqRPCFunc,
+		Func:          fleet.RPCAckRPCFunc,
 		Payload:       payload,
 	}
 
@@ -141,36 +151,21 @@ func (a *orbAgent) sendAgentPoliciesRequest() error {
 	return nil
 }
 
-func (a *orbAgent) retryAgentPolicyResponse() {
-	if a.policyRequestTicker == nil {
-		a.policyRequestTicker = time.NewTicker(retryRequestFixedTime * retryRequestDuration)
-	}
-	var ctx context.Context
-	ctx, a.policyRequestSucceeded = a.extendContext("retryAgentPolicyResponse")
-	go func(ctx context.Context) {
-		defer a.policyRequestTicker.Stop()
-		defer func(t time.Time) {
-			a.logger.Info("execution period of the re-request of retryAgentPolicy", zap.Duration("period", time.Now().Sub(t)))
-		}(time.Now())
-		lastT := time.Now()
-		for calls := 1; calls <= retryMaxAttempts; calls++ {
-			select {
-			case <-ctx.Done():
-				a.policyRequestTicker.Stop()
-				return
-			case t := <-a.policyRequestTicker.C:
-				a.logger.Info("agent did not receive any policy from fleet, re-requesting", zap.Duration("waiting period", lastT.Sub(t)))
-				duration := retryRequestFixedTime + (calls * retryDurationIncrPerAttempts)
-				a.policyRequestTicker.Reset(time.Duration(duration) * retryRequestDuration)
-				err := a.sendAgentPoliciesRequest()
-				if err != nil {
-					a.logger.Error("failed to send agent policies request", zap.Error(err))
-					return
-				}
-				lastT = t
-			}
-		}
-		a.logger.Warn(fmt.Sprintf("retryAgentPolicy retried %d times and still got no response from fleet", retryMaxAttempts))
-		return
-	}(ctx)
+func (a *orbAgent) sendDiagnosticsChunk(payload fleet.AgentDiagnosticsChunkRPCPayload) error {
+	data := fleet.AgentDiagnosticsChunkRPC{
+		SchemaVersion: fleet.CurrentRPCSchemaVersion,
+		Func:          fleet.AgentDiagnosticsChunkRPCFunc,
+		Payload:       payload,
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if token := a.client.Publish(a.rpcToCoreTopic, 1, false, body); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+
+	return nil
 }
@@ -0,0 +1,69 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap"
+)
+
+// standaloneID names the agent and its channel when running standalone without mqtt credentials
+const standaloneID = "standalone"
+
+// exportClient is the client the backends export their telemetry through: the given mqtt client for the control
+// plane policies, and the local exporter, when one is configured, for the local policies
+func (a *orbAgent) exportClient(client *mqtt.Client) *mqtt.Client {
+	r := &exportRouter{Client: a.instrument(*client)}
+	if a.localClient != nil {
+		r.local = a.instrument(a.localClient)
+	}
+	var c mqtt.Client = r
+	return &c
+}
+
+var _ mqtt.Client = (*exportRouter)(nil)
+
+// exportRouter publishes through the mqtt client it wraps, the otlp exporter sends the telemetry of local policies
+// through PublishLocal instead
+type exportRouter struct {
+	mqtt.Client
+	local mqtt.Client
+}
+
+// ExportsLocalPolicies tells the otlp exporter whether the telemetry of local policies can be sent
+func (r *exportRouter) ExportsLocalPolicies() bool {
+	return r.local != nil
+}
+
+// PublishLocal publishes the telemetry of local policies through the local exporter
+func (r *exportRouter) PublishLocal(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	return r.local.Publish(topic, qos, retained, payload)
+}
+
+// startStandalone hands the local exporter to the backends and applies the local policies, the agent does not
+// connect to the control plane in standalone mode. Heartbeats still run as they restart failed backends, they are
+// dropped by the local exporter
+func (a *orbAgent) startStandalone() {
+	a.logger.Info("running in standalone mode, only local policies are applied")
+	agentID := a.config.OrbAgent.Cloud.MQTT.Id
+	if agentID == "" {
+		agentID = standaloneID
+	}
+	channelID := a.config.OrbAgent.Cloud.MQTT.ChannelID
+	if channelID == "" {
+		channelID = standaloneID
+	}
+	a.agent_id = agentID
+	a.nameAgentRPCTopics(channelID)
+	a.client = a.instrument(a.localClient)
+	for name, be := range a.runningBackends() {
+		be.SetCommsClient(agentID, a.exportClient(&a.client), fmt.Sprintf("%s/?/%s", a.baseTopic, name))
+	}
+	a.policyManager.ApplyLocalPolicies()
+	a.logonWithHeartbeat()
+	a.logger.Info("standalone agent started", zap.String("agent_id", agentID))
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/orb-community/orb/agent/otel"
+	"github.com/orb-community/orb/agent/otel/otlpmqttexporter"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.uber.org/zap"
+)
+
+type publishedToken struct{}
+
+func (publishedToken) Wait() bool                     { return true }
+func (publishedToken) WaitTimeout(time.Duration) bool { return true }
+func (publishedToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+func (publishedToken) Error() error { return nil }
+
+// recordingClient keeps the policies of the otlp metrics published through it
+type recordingClient struct {
+	mqtt.Client
+	mu       sync.Mutex
+	policies []string
+}
+
+func (c *recordingClient) Publish(_ string, _ byte, _ bool, payload interface{}) mqtt.Token {
+	body, err := io.ReadAll(brotli.NewReader(bytes.NewReader(payload.([]byte))))
+	if err != nil {
+		return publishedToken{}
+	}
+	request := pmetricotlp.NewExportRequest()
+	if err := request.UnmarshalProto(body); err != nil {
+		return publishedToken{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resources := request.Metrics().ResourceMetrics()
+	for i := 0; i < resources.Len(); i++ {
+		scopes := resources.At(i).ScopeMetrics()
+		for j := 0; j < scopes.Len(); j++ {
+			id, _ := scopes.At(j).Scope().Attributes().Get("policy_id")
+			c.policies = append(c.policies, id.AsString())
+		}
+	}
+	return publishedToken{}
+}
+
+func (c *recordingClient) published() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sort.Strings(c.policies)
+	return c.policies
+}
+
+type policiesBridge map[string]otel.AgentDataPerPolicy
+
+func (b policiesBridge) RetrieveAgentInfoByPolicyName(name string) (*otel.AgentDataPerPolicy, error) {
+	data, ok := b[name]
+	if !ok {
+		return nil, errors.New("unknown policy")
+	}
+	return &data, nil
+}
+
+func (b policiesBridge) NotifyAgentDisconnection(_ context.Context, _ error) {}
+
+func TestExportClientRoutesPolicies(t *testing.T) {
+	mqttClient := &recordingClient{}
+	localClient := &recordingClient{}
+	a := &orbAgent{logger: zap.NewNop(), localClient: localClient}
+
+	var client mqtt.Client = mqttClient
+	cfg := &otlpmqttexporter.Config{
+		Client: a.exportClient(&client),
+		Topic:  "channels/channel/messages/otlp/m/a",
+		OrbAgentService: policiesBridge{
+			"orb_policy":   {PolicyID: "orb-policy-id"},
+			"local_policy": {PolicyID: "local-policy-id", Local: true},
+		},
+	}
+	exp, err := otlpmqttexporter.CreateMetricsExporter(context.Background(), exportertest.NewNopCreateSettings(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := exp.Start(context.Background(), componenttest.NewNopHost()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer exp.Shutdown(context.Background())
+
+	metrics := pmetric.NewMetrics()
+	resource := metrics.ResourceMetrics().AppendEmpty()
+	for _, name := range []string{"orb_policy", "local_policy"} {
+		scope := resource.ScopeMetrics().AppendEmpty()
+		scope.Scope().Attributes().PutStr("policy_name", name)
+		scope.Metrics().AppendEmpty().SetName("test_value")
+	}
+	if err := exp.ConsumeMetrics(context.Background(), metrics); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for desc, tc := range map[string]struct {
+		client   *recordingClient
+		expected string
+	}{
+		"control plane policy over mqtt": {client: mqttClient, expected: "orb-policy-id"},
+		"local policy to local exporter": {client: localClient, expected: "local-policy-id"},
+	} {
+		published := tc.client.published()
+		if len(published) != 1 || published[0] != tc.expected {
+			t.Errorf("%s: expected %s got %v", desc, tc.expected, published)
+		}
+	}
+}
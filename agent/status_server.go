@@ -0,0 +1,135 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/buildinfo"
+	"github.com/orb-community/orb/fleet"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+type statusRes struct {
+	AgentID       string `json:"agent_id"`
+	Version       string `json:"version"`
+	Standalone    bool   `json:"standalone"`
+	MQTTConnected bool   `json:"mqtt_connected"`
+	fleet.Heartbeat
+}
+
+type policiesRes struct {
+	Policies map[string]fleet.PolicyStateInfo `json:"policies"`
+	Groups   map[string]fleet.GroupStateInfo  `json:"groups"`
+}
+
+type readyRes struct {
+	Ready   bool     `json:"ready"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// startStatusServer serves the agent status, health probes and self-telemetry on the configured local address
+func (a *orbAgent) startStatusServer() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", a.handleStatus)
+	mux.HandleFunc("GET /policies", a.handlePolicies)
+	mux.HandleFunc("GET /healthz", a.handleHealthz)
+	mux.HandleFunc("GET /readyz", a.handleReadyz)
+	mux.Handle("GET /metrics", promhttp.HandlerFor(a.metrics.registry, promhttp.HandlerOpts{}))
+
+	listener, err := net.Listen("tcp", a.config.OrbAgent.StatusServer.Address)
+	if err != nil {
+		return fmt.Errorf("failed to start status server: %w", err)
+	}
+	a.statusServer = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Error("status server stopped", zap.Error(err))
+		}
+	}(a.statusServer)
+	a.logger.Info("status server started", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+func (a *orbAgent) stopStatusServer(ctx context.Context) {
+	if a.statusServer == nil {
+		return
+	}
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := a.statusServer.Shutdown(shutdownCtx); err != nil {
+		a.logger.Warn("failed to stop status server", zap.Error(err))
+	}
+	a.statusServer = nil
+}
+
+func (a *orbAgent) mqttConnected() bool {
+	return !a.config.OrbAgent.Standalone.Enabled && a.client != nil && a.client.IsConnected()
+}
+
+func (a *orbAgent) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, statusRes{
+		AgentID:       a.agent_id,
+		Version:       buildinfo.GetVersion(),
+		Standalone:    a.config.OrbAgent.Standalone.Enabled,
+		MQTTConnected: a.mqttConnected(),
+		Heartbeat:     a.heartbeatData(time.Now(), fleet.Online),
+	})
+}
+
+func (a *orbAgent) handlePolicies(w http.ResponseWriter, _ *http.Request) {
+	hb := a.heartbeatData(time.Now(), fleet.Online)
+	writeJSON(w, http.StatusOK, policiesRes{Policies: hb.PolicyState, Groups: hb.GroupState})
+}
+
+// handleHealthz answers as long as the agent process is serving
+func (a *orbAgent) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, readyRes{Ready: true})
+}
+
+// handleReadyz answers ok once every backend is up and, unless standalone, the agent is connected to orb
+func (a *orbAgent) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	reasons := a.notReadyReasons()
+	if len(reasons) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, readyRes{Ready: false, Reasons: reasons})
+		return
+	}
+	writeJSON(w, http.StatusOK, readyRes{Ready: true})
+}
+
+func (a *orbAgent) notReadyReasons() []string {
+	var reasons []string
+	if !a.config.OrbAgent.Standalone.Enabled && !a.mqttConnected() {
+		reasons = append(reasons, "not connected to the orb mqtt broker")
+	}
+	// backends are queried over http, outside the lock
+	backends := a.runningBackends()
+	if len(backends) == 0 {
+		reasons = append(reasons, "no backend started")
+	}
+	for name, be := range backends {
+		status, _, _ := be.GetRunningStatus()
+		if status != backend.Running && status != backend.Waiting {
+			reasons = append(reasons, fmt.Sprintf("backend %s is %s", name, status.String()))
+		}
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+func writeJSON(w http.ResponseWriter, code int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/agent/backend"
+	"github.com/orb-community/orb/agent/config"
+	"github.com/orb-community/orb/agent/policies"
+	manager "github.com/orb-community/orb/agent/policyMgr"
+	"github.com/orb-community/orb/fleet"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+type stubBackend struct {
+	backend.Backend
+	status backend.RunningStatus
+}
+
+func (s stubBackend) GetRunningStatus() (backend.RunningStatus, string, error) {
+	return s.status, "", nil
+}
+
+func (s stubBackend) GetStartTime() time.Time {
+	return time.Now()
+}
+
+func newStatusAgent(t *testing.T, standalone bool, status backend.RunningStatus) *orbAgent {
+	c := config.Config{OrbAgent: config.OrbAgent{Standalone: config.Standalone{Enabled: standalone}}}
+	pm, err := manager.New(zap.NewNop(), c, nil)
+	if err != nil {
+		t.Fatalf("failed to create policy manager: %s", err)
+	}
+	if err := pm.GetRepo().Update(policies.PolicyData{ID: "local-dns", Name: "dns", Backend: "probe", State: policies.Running,
+		Datasets: map[string]bool{manager.LocalDatasetID: true}, Local: true}); err != nil {
+		t.Fatalf("failed to store policy: %s", err)
+	}
+	return &orbAgent{
+		logger:        zap.NewNop(),
+		config:        c,
+		agent_id:      "agent-id",
+		policyManager: pm,
+		backends:      map[string]backend.Backend{"probe": stubBackend{status: status}},
+		backendState:  map[string]*backend.State{"probe": {Status: status}},
+		groupsInfos:   map[string]GroupInfo{},
+		metrics:       newAgentMetrics(),
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	cases := map[string]struct {
+		standalone bool
+		status     backend.RunningStatus
+		code       int
+	}{
+		"standalone with running backend": {
+			standalone: true,
+			status:     backend.Running,
+			code:       http.StatusOK,
+		},
+		"standalone with failed backend": {
+			standalone: true,
+			status:     backend.BackendError,
+			code:       http.StatusServiceUnavailable,
+		},
+		"not connected to orb": {
+			standalone: false,
+			status:     backend.Running,
+			code:       http.StatusServiceUnavailable,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			a := newStatusAgent(t, tc.standalone, tc.status)
+			rec := httptest.NewRecorder()
+			a.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tc.code {
+				t.Errorf("%s: expected status %d got %d: %s", desc, tc.code, rec.Code, rec.Body.String())
+			}
+			var res readyRes
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("%s: failed to decode response: %s", desc, err)
+			}
+			if res.Ready != (tc.code == http.StatusOK) {
+				t.Errorf("%s: expected ready %t, got %t", desc, tc.code == http.StatusOK, res.Ready)
+			}
+			if !res.Ready && len(res.Reasons) == 0 {
+				t.Errorf("%s: expected the reasons the agent is not ready", desc)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	a := newStatusAgent(t, true, backend.Running)
+	rec := httptest.NewRecorder()
+	a.handleStatus(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+	var res statusRes
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if res.AgentID != "agent-id" || !res.Standalone || res.MQTTConnected {
+		t.Errorf("unexpected agent status %+v", res)
+	}
+	if res.BackendState["probe"].State != backend.Running.String() {
+		t.Errorf("expected probe backend running, got %q", res.BackendState["probe"].State)
+	}
+	policy, ok := res.PolicyState["local-dns"]
+	if !ok {
+		t.Fatalf("expected the policy to be reported")
+	}
+	if policy.Source != fleet.PolicySourceLocal || policy.State != policies.Running.String() {
+		t.Errorf("unexpected policy state %+v", policy)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	a := newStatusAgent(t, true, backend.Running)
+	a.metrics.backendRestarts.WithLabelValues("probe").Inc()
+	a.metrics.publishedBytes.WithLabelValues(topicKind("channels/c/messages/otlp/probe/m/a")).Add(42)
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(a.metrics.registry, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, expected := range []string{
+		`orb_agent_backend_restarts_total{backend="probe"} 1`,
+		`orb_agent_published_bytes_total{backend="probe",kind="telemetry"} 42`,
+		"orb_agent_mqtt_reconnects_total 0",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected metrics to contain %s", expected)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package agent
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// agentTags are the current agent tags, the map is replaced and never modified when they change
+func (a *orbAgent) agentTags() map[string]string {
+	a.tagsMu.Lock()
+	defer a.tagsMu.Unlock()
+	return a.config.OrbAgent.Tags
+}
+
+// setTags replaces the agent tags, returning whether they changed
+func (a *orbAgent) setTags(agentTags map[string]string) bool {
+	a.tagsMu.Lock()
+	defer a.tagsMu.Unlock()
+	if reflect.DeepEqual(a.config.OrbAgent.Tags, agentTags) || (len(a.config.OrbAgent.Tags) == 0 && len(agentTags) == 0) {
+		return false
+	}
+	a.logger.Info("agent tags changed", zap.Any("tags", agentTags))
+	a.config.OrbAgent.Tags = agentTags
+	return true
+}
+
+// refreshTags resolves the tag providers on every interval, sending the tags to the control plane as capabilities
+// when they change so the agent group membership follows them
+func (a *orbAgent) refreshTags(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.tagsMu.Lock()
+			resolver := a.tagResolver
+			a.tagsMu.Unlock()
+			if resolver == nil {
+				continue
+			}
+			if !a.setTags(resolver.Resolve(ctx)) {
+				continue
+			}
+			if a.mqttConnected() {
+				if err := a.sendCapabilities(); err != nil {
+					a.logger.Error("failed to send agent capabilities", zap.Error(err))
+				}
+			}
+		}
+	}
+}
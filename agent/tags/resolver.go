@@ -0,0 +1,184 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package tags
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/orb-community/orb/agent/config"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ProviderStatic  = "static"
+	ProviderEnv     = "env"
+	ProviderFile    = "file"
+	ProviderCommand = "command"
+)
+
+const defaultCommandTimeout = 10 * time.Second
+
+var ErrInvalidProvider = errors.New("invalid tag provider")
+
+// Resolver merges the static agent tags with the ones of the tag providers
+type Resolver struct {
+	logger    *zap.Logger
+	static    map[string]string
+	providers []config.TagProvider
+	// lastGood keeps the tags each provider last resolved, used when it fails
+	lastGood []map[string]string
+}
+
+func NewResolver(logger *zap.Logger, static map[string]string, providers []config.TagProvider) (*Resolver, error) {
+	for i, p := range providers {
+		if err := validate(p); err != nil {
+			return nil, fmt.Errorf("%w %d: %s", ErrInvalidProvider, i, err)
+		}
+	}
+	return &Resolver{
+		logger:    logger,
+		static:    static,
+		providers: providers,
+		lastGood:  make([]map[string]string, len(providers)),
+	}, nil
+}
+
+func validate(p config.TagProvider) error {
+	switch p.Type {
+	case ProviderStatic:
+		return nil
+	case ProviderEnv:
+		if p.Prefix == "" {
+			return errors.New("env provider requires a prefix")
+		}
+	case ProviderFile:
+		if p.Path == "" {
+			return errors.New("file provider requires a path")
+		}
+	case ProviderCommand:
+		if len(p.Command) == 0 {
+			return errors.New("command provider requires a command")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", p.Type)
+	}
+	return nil
+}
+
+// Resolve returns the static tags merged with the ones of every provider, later providers taking precedence. A
+// failing provider contributes the tags it last resolved
+func (r *Resolver) Resolve(ctx context.Context) map[string]string {
+	merged := make(map[string]string, len(r.static))
+	for key, value := range r.static {
+		merged[key] = value
+	}
+	for i, p := range r.providers {
+		resolved, err := resolve(ctx, p)
+		if err != nil {
+			r.logger.Warn("failed to resolve agent tags, using the last resolved ones", zap.String("type", p.Type), zap.Int("provider", i), zap.Error(err))
+			resolved = r.lastGood[i]
+		} else {
+			r.lastGood[i] = resolved
+		}
+		for key, value := range resolved {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func resolve(ctx context.Context, p config.TagProvider) (map[string]string, error) {
+	switch p.Type {
+	case ProviderStatic:
+		return p.Tags, nil
+	case ProviderEnv:
+		return fromEnv(p.Prefix), nil
+	case ProviderFile:
+		return fromFile(p.Path)
+	case ProviderCommand:
+		return fromCommand(ctx, p)
+	}
+	return nil, fmt.Errorf("unknown type %q", p.Type)
+}
+
+// fromEnv takes the variables starting with prefix, the tag key is the rest of the name lowercased
+func fromEnv(prefix string) map[string]string {
+	tags := make(map[string]string)
+	for _, env := range os.Environ() {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimPrefix(name, prefix))] = value
+	}
+	return tags
+}
+
+// fromFile reads a JSON or YAML object of scalar values
+func fromFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	tags := make(map[string]string, len(values))
+	for key, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("tag %s in %s is not a single value", key, path)
+		case nil:
+			tags[key] = ""
+		default:
+			tags[key] = fmt.Sprint(value)
+		}
+	}
+	return tags, nil
+}
+
+func fromCommand(ctx context.Context, p config.TagProvider) (map[string]string, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("command %s failed: %w", p.Command[0], err)
+	}
+	if p.Key != "" {
+		value := strings.TrimSpace(string(output))
+		if value == "" {
+			return nil, fmt.Errorf("command %s had no output", p.Command[0])
+		}
+		return map[string]string{p.Key: value}, nil
+	}
+	tags := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("command %s output line %q is not key=value", p.Command[0], line)
+		}
+		tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
@@ -0,0 +1,119 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package tags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orb-community/orb/agent/config"
+	"go.uber.org/zap"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	jsonFacts := filepath.Join(dir, "facts.json")
+	if err := os.WriteFile(jsonFacts, []byte(`{"site": "ams1", "rack": 12, "role": "edge"}`), 0o600); err != nil {
+		t.Fatalf("failed to write facts: %s", err)
+	}
+	yamlFacts := filepath.Join(dir, "facts.yaml")
+	if err := os.WriteFile(yamlFacts, []byte("role: core\n"), 0o600); err != nil {
+		t.Fatalf("failed to write facts: %s", err)
+	}
+	nestedFacts := filepath.Join(dir, "nested.json")
+	if err := os.WriteFile(nestedFacts, []byte(`{"site": {"name": "ams1"}}`), 0o600); err != nil {
+		t.Fatalf("failed to write facts: %s", err)
+	}
+	t.Setenv("ORBTEST_TAG_REGION", "eu")
+
+	static := map[string]string{"region": "us", "env": "prod"}
+	cases := map[string]struct {
+		providers []config.TagProvider
+		expected  map[string]string
+	}{
+		"static only": {
+			providers: []config.TagProvider{{Type: ProviderStatic, Tags: map[string]string{"team": "netops"}}},
+			expected:  map[string]string{"region": "us", "env": "prod", "team": "netops"},
+		},
+		"env overrides static": {
+			providers: []config.TagProvider{{Type: ProviderEnv, Prefix: "ORBTEST_TAG_"}},
+			expected:  map[string]string{"region": "eu", "env": "prod"},
+		},
+		"later files take precedence": {
+			providers: []config.TagProvider{{Type: ProviderFile, Path: jsonFacts}, {Type: ProviderFile, Path: yamlFacts}},
+			expected:  map[string]string{"region": "us", "env": "prod", "site": "ams1", "rack": "12", "role": "core"},
+		},
+		"command with key": {
+			providers: []config.TagProvider{{Type: ProviderCommand, Command: []string{"echo", "example.com"}, Key: "domain"}},
+			expected:  map[string]string{"region": "us", "env": "prod", "domain": "example.com"},
+		},
+		"command with key value lines": {
+			providers: []config.TagProvider{{Type: ProviderCommand, Command: []string{"printf", "site=fra2\\n\\nenv = staging\\n"}}},
+			expected:  map[string]string{"region": "us", "env": "staging", "site": "fra2"},
+		},
+		"failing providers are skipped": {
+			providers: []config.TagProvider{
+				{Type: ProviderFile, Path: filepath.Join(dir, "missing.json")},
+				{Type: ProviderFile, Path: nestedFacts},
+				{Type: ProviderCommand, Command: []string{"false"}, Key: "domain"},
+			},
+			expected: map[string]string{"region": "us", "env": "prod"},
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			r, err := NewResolver(zap.NewNop(), static, tc.providers)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %s", desc, err)
+			}
+			resolved := r.Resolve(context.Background())
+			if len(resolved) != len(tc.expected) {
+				t.Errorf("%s: expected %v got %v", desc, tc.expected, resolved)
+			}
+			for key, value := range tc.expected {
+				if resolved[key] != value {
+					t.Errorf("%s: expected %s=%s got %q", desc, key, value, resolved[key])
+				}
+			}
+		})
+	}
+}
+
+func TestResolveKeepsLastGoodTags(t *testing.T) {
+	facts := filepath.Join(t.TempDir(), "facts.json")
+	if err := os.WriteFile(facts, []byte(`{"site": "ams1"}`), 0o600); err != nil {
+		t.Fatalf("failed to write facts: %s", err)
+	}
+	r, err := NewResolver(zap.NewNop(), nil, []config.TagProvider{{Type: ProviderFile, Path: facts}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resolved := r.Resolve(context.Background()); resolved["site"] != "ams1" {
+		t.Fatalf("expected site ams1, got %v", resolved)
+	}
+	if err := os.WriteFile(facts, []byte(`{"site": `), 0o600); err != nil {
+		t.Fatalf("failed to write facts: %s", err)
+	}
+	if resolved := r.Resolve(context.Background()); resolved["site"] != "ams1" {
+		t.Errorf("expected the last resolved site to be kept, got %v", resolved)
+	}
+}
+
+func TestNewResolverValidation(t *testing.T) {
+	cases := map[string]config.TagProvider{
+		"unknown type":            {Type: "consul"},
+		"env without prefix":      {Type: ProviderEnv},
+		"file without path":       {Type: ProviderFile},
+		"command without command": {Type: ProviderCommand, Key: "domain"},
+	}
+	for desc, p := range cases {
+		if _, err := NewResolver(zap.NewNop(), nil, []config.TagProvider{p}); err == nil {
+			t.Errorf("%s: expected an error, got none", desc)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		err     bool
+	}{
+		"valid config": {
+			content: "version: \"1.0\"\norb:\n  tags:\n    region: eu\n  backends:\n    pktvisor:\n      binary: /usr/local/sbin/pktvisord\n",
+		},
+		"malformed yaml": {
+			content: "version: \"1.0\"\norb:\n  tags: [region\n",
+			err:     true,
+		},
+	}
+
+	previous := cfgFiles
+	defer func() { cfgFiles = previous }()
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "agent.yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatalf("%s: failed to write config: %s", desc, err)
+			}
+			cfgFiles = []string{path}
+			c, err := loadConfig(zap.NewNop())
+			if tc.err {
+				if err == nil {
+					t.Errorf("%s: expected an error, got none", desc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %s", desc, err)
+			}
+			if c.OrbAgent.Tags["region"] != "eu" {
+				t.Errorf("%s: expected tags to be loaded, got %v", desc, c.OrbAgent.Tags)
+			}
+			if _, ok := c.OrbAgent.Backends["pktvisor"]; !ok {
+				t.Errorf("%s: expected pktvisor backend to be loaded, got %v", desc, c.OrbAgent.Backends)
+			}
+			if c.OrbAgent.StatusServer.Address != "localhost:10854" {
+				t.Errorf("%s: expected defaults to be applied, got %q", desc, c.OrbAgent.StatusServer.Address)
+			}
+		})
+	}
+}
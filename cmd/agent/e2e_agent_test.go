@@ -53,7 +53,7 @@ func Test_e2e_orbAgent_ConfigFile(t *testing.T) {
 func Test_main(t *testing.T) {
 	t.Skip("local run only, skip in CICD")
 
-	mergeOrError("/home/lpegoraro/workspace/orb/localconfig/config.yaml")
+	cobra.CheckErr(mergeConfig(viper.GetViper(), "/home/lpegoraro/workspace/orb/localconfig/config.yaml"))
 
 	// configuration
 	var cfg config.Config
@@ -6,15 +6,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/orb-community/orb/agent/backend/otel"
+	"github.com/orb-community/orb/agent/backend/otelembedded"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
 
 	"github.com/orb-community/orb/agent"
 	"github.com/orb-community/orb/agent/backend/pktvisor"
+	"github.com/orb-community/orb/agent/backend/probe"
 	"github.com/orb-community/orb/agent/config"
 	"github.com/orb-community/orb/buildinfo"
 	"github.com/spf13/cobra"
@@ -28,13 +35,19 @@ const (
 )
 
 var (
-	cfgFiles []string
-	Debug    bool
+	cfgFiles    []string
+	Debug       bool
+	WatchConfig bool
 )
 
+// configSettleTime is how long config files must stay unchanged before they are reloaded
+const configSettleTime = time.Second
+
 func init() {
 	pktvisor.Register()
 	otel.Register()
+	otelembedded.Register()
+	probe.Register()
 }
 
 func Version(_ *cobra.Command, _ []string) {
@@ -44,16 +57,6 @@ func Version(_ *cobra.Command, _ []string) {
 
 func Run(_ *cobra.Command, _ []string) {
 
-	initConfig()
-
-	// configuration
-	var configData config.Config
-	err := viper.Unmarshal(&configData)
-	if err != nil {
-		cobra.CheckErr(fmt.Errorf("agent start up error (configData): %w", err))
-		os.Exit(1)
-	}
-
 	// logger
 	var logger *zap.Logger
 	atomicLevel := zap.NewAtomicLevel()
@@ -74,21 +77,11 @@ func Run(_ *cobra.Command, _ []string) {
 		_ = logger.Sync()
 	}(logger)
 
-	// include pktvisor backend by default if binary is at default location
-	_, err = os.Stat(pktvisor.DefaultBinary)
-	logger.Info("backends loaded", zap.Any("backends", configData.OrbAgent.Backends))
-	if err == nil && configData.OrbAgent.Backends == nil {
-		logger.Info("no backends loaded, adding pktvisor as default")
-		configData.OrbAgent.Backends = make(map[string]map[string]string)
-		configData.OrbAgent.Backends["pktvisor"] = make(map[string]string)
-		configData.OrbAgent.Backends["pktvisor"]["binary"] = pktvisor.DefaultBinary
-		configData.OrbAgent.Backends["pktvisor"]["api_host"] = "localhost"
-		if _, ok := configData.OrbAgent.Backends["pktvisor"]["api_port"]; !ok {
-			configData.OrbAgent.Backends["pktvisor"]["api_port"] = "10853"
-		}
-		if len(cfgFiles) > 0 {
-			configData.OrbAgent.Backends["pktvisor"]["config_file"] = cfgFiles[0]
-		}
+	// configuration
+	configData, err := loadConfig(logger)
+	if err != nil {
+		cobra.CheckErr(fmt.Errorf("agent start up error (configData): %w", err))
+		os.Exit(1)
 	}
 
 	// new agent
@@ -124,10 +117,83 @@ func Run(_ *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	// reload configuration on SIGHUP, and on config file changes when watching them
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	if WatchConfig {
+		if err := watchConfigFiles(rootCtx, logger, reloads); err != nil {
+			logger.Error("failed to watch config files, reloading only on SIGHUP", zap.Error(err))
+		}
+	}
+	go func() {
+		for {
+			select {
+			case <-rootCtx.Done():
+				signal.Stop(reloads)
+				return
+			case <-reloads:
+				logger.Info("reloading configuration")
+				reloaded, err := loadConfig(logger)
+				if err != nil {
+					logger.Error("invalid configuration, keeping the current one", zap.Error(err))
+					continue
+				}
+				if err := a.Reload(rootCtx, reloaded); err != nil {
+					logger.Error("failed to reload configuration", zap.Error(err))
+				}
+			}
+		}
+	}()
+
 	<-done
 }
 
-func mergeOrError(path string) {
+// loadConfig reads the defaults, the config files and environment variables into a new configuration, it is called
+// on start and on every reload
+func loadConfig(logger *zap.Logger) (config.Config, error) {
+	root := viper.New()
+	if err := mergeConfig(root, ""); err != nil {
+		return config.Config{}, err
+	}
+	if len(cfgFiles) == 0 {
+		if _, err := os.Stat(defaultConfig); !os.IsNotExist(err) {
+			if err := mergeConfig(root, defaultConfig); err != nil {
+				return config.Config{}, err
+			}
+		}
+	} else {
+		for _, conf := range cfgFiles {
+			if err := mergeConfig(root, conf); err != nil {
+				return config.Config{}, err
+			}
+		}
+	}
+
+	var configData config.Config
+	if err := root.Unmarshal(&configData); err != nil {
+		return config.Config{}, err
+	}
+
+	// include pktvisor backend by default if binary is at default location
+	_, err := os.Stat(pktvisor.DefaultBinary)
+	logger.Info("backends loaded", zap.Any("backends", configData.OrbAgent.Backends))
+	if err == nil && configData.OrbAgent.Backends == nil {
+		logger.Info("no backends loaded, adding pktvisor as default")
+		configData.OrbAgent.Backends = make(map[string]map[string]string)
+		configData.OrbAgent.Backends["pktvisor"] = make(map[string]string)
+		configData.OrbAgent.Backends["pktvisor"]["binary"] = pktvisor.DefaultBinary
+		configData.OrbAgent.Backends["pktvisor"]["api_host"] = "localhost"
+		if _, ok := configData.OrbAgent.Backends["pktvisor"]["api_port"]; !ok {
+			configData.OrbAgent.Backends["pktvisor"]["api_port"] = "10853"
+		}
+		if len(cfgFiles) > 0 {
+			configData.OrbAgent.Backends["pktvisor"]["config_file"] = cfgFiles[0]
+		}
+	}
+	return configData, nil
+}
+
+func mergeConfig(root *viper.Viper, path string) error {
 
 	v := viper.New()
 	if len(path) > 0 {
@@ -142,6 +208,7 @@ func mergeOrError(path string) {
 	// note: viper seems to require a default (or a BindEnv) to be overridden by environment variables
 	v.SetDefault("orb.cloud.api.address", "https://orb.live")
 	v.SetDefault("orb.cloud.api.token", "")
+	v.SetDefault("orb.cloud.api.enrollment_token", "")
 	v.SetDefault("orb.cloud.config.agent_name", "")
 	v.SetDefault("orb.cloud.config.auto_provision", true)
 	v.SetDefault("orb.cloud.mqtt.address", "tls://agents.orb.live:8883")
@@ -153,21 +220,30 @@ func mergeOrError(path string) {
 	v.SetDefault("orb.otel.host", "localhost")
 	v.SetDefault("orb.otel.port", 0)
 	v.SetDefault("orb.debug.enable", Debug)
+	v.SetDefault("orb.policies_dir", "")
+	v.SetDefault("orb.standalone.enabled", false)
+	v.SetDefault("orb.local_exporter.otlp_http.endpoint", "")
+	v.SetDefault("orb.local_exporter.otlp_http.timeout", "10s")
+	v.SetDefault("orb.status_server.enabled", false)
+	v.SetDefault("orb.status_server.address", "localhost:10854")
+	v.SetDefault("orb.tag_providers.refresh_interval", "5m")
 
 	if len(path) > 0 {
-		cobra.CheckErr(v.ReadInConfig())
+		if err := v.ReadInConfig(); err != nil {
+			return err
+		}
 	}
 
 	var fZero float64
 
 	// check that version of config files are all matched up
-	if versionNumber1 := viper.GetFloat64("version"); versionNumber1 != fZero {
+	if versionNumber1 := root.GetFloat64("version"); versionNumber1 != fZero {
 		versionNumber2 := v.GetFloat64("version")
 		if versionNumber2 == fZero {
-			cobra.CheckErr("Failed to parse config version in: " + path)
+			return errors.New("Failed to parse config version in: " + path)
 		}
 		if versionNumber2 != versionNumber1 {
-			cobra.CheckErr("Config file version mismatch in: " + path)
+			return errors.New("Config file version mismatch in: " + path)
 		}
 	}
 
@@ -175,6 +251,8 @@ func mergeOrError(path string) {
 	backendVarsFunction := make(map[string]func(*viper.Viper))
 	backendVarsFunction["pktvisor"] = pktvisor.RegisterBackendSpecificVariables
 	backendVarsFunction["otel"] = otel.RegisterBackendSpecificVariables
+	backendVarsFunction[otelembedded.BackendName] = otelembedded.RegisterBackendSpecificVariables
+	backendVarsFunction[probe.BackendName] = probe.RegisterBackendSpecificVariables
 
 	// check if backends are configured
 	// if not then add pktvisor as default
@@ -183,27 +261,72 @@ func mergeOrError(path string) {
 	} else {
 		for backendName := range v.GetStringMap("orb.backends") {
 			if backend := v.GetStringMap("orb.backends." + backendName); backend != nil {
-				backendVarsFunction[backendName](v)
+				if registerVariables, ok := backendVarsFunction[backendName]; ok {
+					registerVariables(v)
+				}
 			}
 		}
 	}
 
-	cobra.CheckErr(viper.MergeConfigMap(v.AllSettings()))
+	return root.MergeConfigMap(v.AllSettings())
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	// set defaults first
-	mergeOrError("")
-	if len(cfgFiles) == 0 {
-		if _, err := os.Stat(defaultConfig); !os.IsNotExist(err) {
-			mergeOrError(defaultConfig)
+// watchConfigFiles requests a reload when a config file changes. Directories are watched rather than the files, as
+// editors and config management replace files instead of writing them
+func watchConfigFiles(ctx context.Context, logger *zap.Logger, reloads chan<- os.Signal) error {
+	files := cfgFiles
+	if len(files) == 0 {
+		files = []string{defaultConfig}
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	watched := make(map[string]bool, len(files))
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			_ = watcher.Close()
+			return err
 		}
-	} else {
-		for _, conf := range cfgFiles {
-			mergeOrError(conf)
+		watched[path] = true
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			_ = watcher.Close()
+			return err
 		}
 	}
+
+	go func() {
+		defer watcher.Close()
+		// changes often come in bursts, wait for them to settle before reloading
+		debounce := time.NewTimer(time.Hour)
+		debounce.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if watched[filepath.Clean(event.Name)] && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce.Reset(configSettleTime)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("config file watcher error", zap.Error(err))
+			case <-debounce.C:
+				logger.Info("config file changed")
+				select {
+				case reloads <- syscall.SIGHUP:
+				default:
+				}
+			}
+		}
+	}()
+	return nil
 }
 
 func main() {
@@ -227,6 +350,7 @@ func main() {
 
 	runCmd.Flags().StringSliceVarP(&cfgFiles, "config", "c", []string{}, "Path to config files (may be specified multiple times)")
 	runCmd.PersistentFlags().BoolVarP(&Debug, "debug", "d", false, "Enable verbose (debug level) output")
+	runCmd.Flags().BoolVarP(&WatchConfig, "watch-config", "w", false, "Reload the configuration when config files change (SIGHUP always reloads it)")
 
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(versionCmd)
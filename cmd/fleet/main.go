@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/orb-community/orb/fleet/backend/otel"
+	"github.com/orb-community/orb/fleet/backend/probe"
 	"io"
 	"log"
 	"net"
@@ -139,8 +140,11 @@ func main() {
 
 	agentRepo := postgres.NewAgentRepository(db, logger)
 	agentGroupRepo := postgres.NewAgentGroupRepository(db, logger)
+	agentRPCRepo := postgres.NewAgentRPCRepository(db, logger)
+	versionPolicyRepo := postgres.NewAgentVersionPolicyRepository(db, logger)
+	diagnosticsRepo := postgres.NewAgentDiagnosticsRepository(db, logger)
 
-	commsSvc := fleet.NewFleetCommsService(logger, policiesGRPCClient, agentRepo, agentGroupRepo, pubSub)
+	commsSvc := fleet.NewFleetCommsService(logger, policiesGRPCClient, agentRepo, agentGroupRepo, agentRPCRepo, versionPolicyRepo, diagnosticsRepo, redisprod.NewAgentEventPublisher(esClient, logger), pubSub)
 	commsSvc = fleet.CommsMetricsMiddleware(
 		commsSvc,
 		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
@@ -158,7 +162,7 @@ func main() {
 
 	aDone := make(chan bool)
 
-	svc := newFleetService(authGRPCClient, db, logger, esClient, sdkCfg, agentRepo, agentGroupRepo, commsSvc, aDone)
+	svc := newFleetService(authGRPCClient, db, logger, esClient, sdkCfg, agentRepo, agentGroupRepo, agentRPCRepo, versionPolicyRepo, diagnosticsRepo, commsSvc, aDone)
 	defer commsSvc.Stop()
 
 	errs := make(chan error, 2)
@@ -166,6 +170,22 @@ func main() {
 	go startHTTPServer(tracer, svc, svcCfg, logger, errs)
 	go subscribeToPoliciesES(svc, commsSvc, esClient, esCfg, logger)
 	go startGRPCServer(svc, tracer, fleetGRPCCfg, logger, errs)
+	go fleet.MonitorPolicyRollouts(context.Background(), logger, svc, fleet.RolloutCheckFreq)
+	go fleet.MonitorAgentRPCs(context.Background(), logger, commsSvc, fleet.RPCCheckFreq)
+	go fleet.MonitorAgentDiagnostics(context.Background(), logger, diagnosticsRepo, fleet.DiagnosticsCheckFreq)
+	go fleet.MonitorAgentMaintenance(context.Background(), logger, svc, fleet.MaintenanceCheckFreq)
+	go fleet.MonitorSchemaVersions(context.Background(), logger, agentRepo, kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "fleet",
+		Subsystem: "comms",
+		Name:      "agent_schema_versions",
+		Help:      "Number of online agents speaking each schema version.",
+	}, []string{"kind", "version"}), fleet.SchemaVersionsCheckFreq)
+	go fleet.MonitorFleetSummary(context.Background(), logger, agentRepo, kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "fleet",
+		Subsystem: "agents",
+		Name:      "summary",
+		Help:      "Number of agents by state, version, and backend and policy states of their last heartbeat.",
+	}, []string{"kind", "backend", "value"}), fleet.FleetSummaryCheckFreq)
 
 	err = commsSvc.Start()
 	if err != nil {
@@ -231,7 +251,7 @@ func initJaeger(svcName, url string, logger *zap.Logger) (opentracing.Tracer, io
 	return tracer, closer
 }
 
-func newFleetService(auth mainflux.AuthServiceClient, db *sqlx.DB, logger *zap.Logger, esClient *r.Client, sdkCfg config.MFSDKConfig, agentRepo fleet.AgentRepository, agentGroupRepo fleet.AgentGroupRepository, agentComms fleet.AgentCommsService, aDone chan bool) fleet.Service {
+func newFleetService(auth mainflux.AuthServiceClient, db *sqlx.DB, logger *zap.Logger, esClient *r.Client, sdkCfg config.MFSDKConfig, agentRepo fleet.AgentRepository, agentGroupRepo fleet.AgentGroupRepository, agentRPCRepo fleet.AgentRPCRepository, versionPolicyRepo fleet.AgentVersionPolicyRepository, diagnosticsRepo fleet.AgentDiagnosticsRepository, agentComms fleet.AgentCommsService, aDone chan bool) fleet.Service {
 
 	config := mfsdk.Config{
 		ThingsURL: sdkCfg.ThingsURL,
@@ -241,8 +261,12 @@ func newFleetService(auth mainflux.AuthServiceClient, db *sqlx.DB, logger *zap.L
 
 	pktvisor.Register(auth, agentRepo)
 	otel.Register(auth, agentRepo)
+	probe.Register(auth)
 
-	svc := fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, agentComms, mfsdk, aDone)
+	policyRolloutRepo := postgres.NewPolicyRolloutRepository(db, logger)
+	enrollmentTokenRepo := postgres.NewEnrollmentTokenRepository(db, logger)
+
+	svc := fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, policyRolloutRepo, enrollmentTokenRepo, agentRPCRepo, versionPolicyRepo, diagnosticsRepo, agentComms, mfsdk, fleet.NewThingKeyService(sdkCfg.ThingsURL), sdkCfg.ServiceKey, aDone)
 	svc = redisprod.NewEventStoreMiddleware(svc, esClient, logger)
 	svc = fleethttp.NewLoggingMiddleware(svc, logger)
 	svc = fleethttp.MetricsMiddleware(
@@ -138,6 +138,7 @@ func main() {
 	go startGRPCServer(svc, tracer, policiesGRPCCfg, logger, errs)
 	go subscribeToFleetES(svc, esClient, esCfg, logger)
 	go subscribeToSinksES(svc, esClient, esCfg, logger)
+	go policies.MonitorDatasetSchedules(context.Background(), logger, svc, policies.DatasetScheduleCheckFreq)
 
 	go func() {
 		c := make(chan os.Signal)
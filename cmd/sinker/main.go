@@ -12,16 +12,15 @@ import (
 	"fmt"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/go-redis/redis/v8"
-	"github.com/go-zoo/bone"
+	authapi "github.com/mainflux/mainflux/auth/api/grpc"
 	"github.com/opentracing/opentracing-go"
-	"github.com/orb-community/orb/buildinfo"
 	fleetgrpc "github.com/orb-community/orb/fleet/api/grpc"
 	"github.com/orb-community/orb/pkg/config"
 	policiesgrpc "github.com/orb-community/orb/policies/api/grpc"
 	"github.com/orb-community/orb/sinker"
+	sinkerhttp "github.com/orb-community/orb/sinker/api/http"
 	sinksgrpc "github.com/orb-community/orb/sinks/api/grpc"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	jconfig "github.com/uber/jaeger-client-go/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -43,9 +42,10 @@ import (
 )
 
 const (
-	svcName   = "sinker"
-	envPrefix = "orb_sinker"
-	httpPort  = "8201"
+	svcName     = "sinker"
+	mfEnvPrefix = "mf"
+	envPrefix   = "orb_sinker"
+	httpPort    = "8201"
 )
 
 func main() {
@@ -55,6 +55,7 @@ func main() {
 	cacheCfg := config.LoadCacheConfig(envPrefix)
 	svcCfg := config.LoadBaseServiceConfig(envPrefix, httpPort)
 	jCfg := config.LoadJaegerConfig(envPrefix)
+	authCfg := config.LoadGRPCConfig(mfEnvPrefix, "auth")
 	fleetGRPCCfg := config.LoadGRPCConfig("orb", "fleet")
 	policiesGRPCCfg := config.LoadGRPCConfig("orb", "policies")
 	sinksGRPCCfg := config.LoadGRPCConfig("orb", "sinks")
@@ -119,6 +120,7 @@ func main() {
 		}
 	}(tracerCloser)
 
+	// subscriptions join the svcName queue group, so replicas split the agent messages between them
 	pubSub, err := mfnats.NewPubSub(natsCfg.URL, svcName, mflogger)
 	if err != nil {
 		logger.Error("Failed to connect to NATS", zap.Error(err))
@@ -126,6 +128,20 @@ func main() {
 	}
 	defer pubSub.Close()
 
+	authConn := connectToGRPC(authCfg, logger)
+	defer func(authConn *grpc.ClientConn) {
+		err := authConn.Close()
+		if err != nil {
+			log.Fatalf(err.Error())
+		}
+	}(authConn)
+
+	authTimeout, err := time.ParseDuration(authCfg.Timeout)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", authCfg.Timeout, err.Error())
+	}
+	auth := authapi.NewClient(tracer, authConn, authTimeout)
+
 	policiesGRPCConn := connectToGRPC(policiesGRPCCfg, logger)
 	defer func(policiesGRPCConn *grpc.ClientConn) {
 		err := policiesGRPCConn.Close()
@@ -186,12 +202,29 @@ func main() {
 		Name:      "message_inbound",
 		Help:      "Number of messages received",
 	}, []string{"method", "agent_id", "subtopic", "channel", "protocol"})
+	replicaID, err := os.Hostname()
+	if err != nil {
+		logger.Error("failed to retrieve hostname, using replica id unknown", zap.Error(err))
+		replicaID = "unknown"
+	}
+	processingGauge := kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "sinker",
+		Subsystem: "sink",
+		Name:      "messages_processing",
+		Help:      "Number of agent messages being processed by this replica",
+	}, []string{"replica", "signal"}).With("replica", replicaID)
+	deadLetterCounter := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "sinker",
+		Subsystem: "sink",
+		Name:      "dead_letters",
+		Help:      "Number of agent messages that could not be routed or exported, kept as dead letters or dropped",
+	}, []string{"reason", "signal", "kept"})
 
 	otelEnabled := otelCfg.Enable == "true"
 	otelKafkaUrl := otelCfg.KafkaUrl
 
-	svc := sinker.New(logger, pubSub, esClient, cacheClient, policiesGRPCClient, fleetGRPCClient, sinksGRPCClient,
-		otelKafkaUrl, otelEnabled, gauge, counter, inputCounter, inMemoryCacheConfig.DefaultExpiration)
+	svc := sinker.New(logger, auth, pubSub, esClient, cacheClient, policiesGRPCClient, fleetGRPCClient, sinksGRPCClient,
+		otelKafkaUrl, otelEnabled, gauge, counter, inputCounter, processingGauge, deadLetterCounter, inMemoryCacheConfig.DefaultExpiration)
 	defer func(svc sinker.Service) {
 		err := svc.Stop()
 		if err != nil {
@@ -201,7 +234,7 @@ func main() {
 
 	errs := make(chan error, 2)
 
-	go startHTTPServer(svcCfg, errs, logger)
+	go startHTTPServer(sinkerhttp.MakeHandler(tracer, svcName, svc), svcCfg, errs, logger)
 
 	err = svc.Start()
 	if err != nil {
@@ -219,23 +252,16 @@ func main() {
 	logger.Error("sinker service terminated", zap.Error(err))
 }
 
-func makeHandler(svcName string) http.Handler {
-	r := bone.New()
-	r.GetFunc("/version", buildinfo.Version(svcName))
-	r.Handle("/metrics", promhttp.Handler())
-	return r
-}
-
-func startHTTPServer(cfg config.BaseSvcConfig, errs chan error, logger *zap.Logger) {
+func startHTTPServer(handler http.Handler, cfg config.BaseSvcConfig, errs chan error, logger *zap.Logger) {
 	p := fmt.Sprintf(":%s", cfg.HttpPort)
 	if cfg.HttpServerCert != "" || cfg.HttpServerKey != "" {
 		logger.Info(fmt.Sprintf("Sinker service started using https on port %s with cert %s key %s",
 			cfg.HttpPort, cfg.HttpServerCert, cfg.HttpServerKey))
-		errs <- http.ListenAndServeTLS(p, cfg.HttpServerCert, cfg.HttpServerKey, makeHandler(svcName))
+		errs <- http.ListenAndServeTLS(p, cfg.HttpServerCert, cfg.HttpServerKey, handler)
 		return
 	}
 	logger.Info(fmt.Sprintf("Sinker service started using http on port %s", cfg.HttpPort))
-	errs <- http.ListenAndServe(p, makeHandler(svcName))
+	errs <- http.ListenAndServe(p, handler)
 }
 
 func connectToRedis(URL, pass string, cacheDB string, logger *zap.Logger) *redis.Client {
@@ -127,6 +127,7 @@ func main() {
 	go startGRPCServer(svc, tracer, sinksGRPCCfg, logger, errs)
 	go subscribeToSinkerES(svc, esClient, esCfg, logger)
 	go subscribeToMaestroStatusES(svc, esClient, esCfg, logger)
+	go subscribeToSinkerUsageES(svc, esClient, logger)
 
 	go func() {
 		c := make(chan os.Signal)
@@ -298,3 +299,11 @@ func subscribeToMaestroStatusES(svc sinks.SinkService, client *r.Client, cfg con
 		logger.Error("Bootstrap service failed to subscribe to maestro event sourcing", zap.Error(err))
 	}
 }
+
+func subscribeToSinkerUsageES(svc sinks.SinkService, client *r.Client, logger *zap.Logger) {
+	eventStore := rediscons.NewSinkUsageListener(logger, client, svc)
+	logger.Info("Subscribed to Redis Event Store for sinker usage")
+	if err := eventStore.SubscribeToSinkerUsage(context.Background()); err != nil {
+		logger.Error("Bootstrap service failed to subscribe to sinker usage event sourcing", zap.Error(err))
+	}
+}
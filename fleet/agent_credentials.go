@@ -0,0 +1,36 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+var (
+	// ErrCredentialsRotationTimeout indicates the agent did not acknowledge its new credentials in time
+	ErrCredentialsRotationTimeout = errors.New("timed out waiting for the agent to acknowledge its new credentials")
+	// ErrStageAgentCredentials indicates the agent could not store its new credentials
+	ErrStageAgentCredentials = errors.New("agent failed to store its new credentials")
+)
+
+// AgentCredentials is the outcome of rotating the MQTT credentials of an agent.
+// Key is only set when the rotation succeeded, Err when it did not
+type AgentCredentials struct {
+	AgentID   string
+	AgentName string
+	ChannelID string
+	Key       string
+	Err       error
+}
+
+type AgentCredentialsService interface {
+	// RotateAgentCredentials replaces the key of a provided online agent. The agent stores the new key before the
+	// previous one is revoked, then reconnects with it
+	RotateAgentCredentials(ctx context.Context, token string, agentID string) (AgentCredentials, error)
+	// RotateAgentGroupCredentials replaces the keys of the online agents of a provided group, skipping the others
+	RotateAgentGroupCredentials(ctx context.Context, token string, groupID string) ([]AgentCredentials, error)
+}
@@ -0,0 +1,94 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxConcurrentRotations bounds how many agents of a group rotate their credentials at once
+const maxConcurrentRotations = 10
+
+func (svc fleetService) RotateAgentCredentials(ctx context.Context, token string, agentID string) (AgentCredentials, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return AgentCredentials{}, err
+	}
+
+	agent, err := svc.agentRepo.RetrieveByID(ctx, ownerID, agentID)
+	if err != nil {
+		return AgentCredentials{}, err
+	}
+	if agent.State != Online {
+		return AgentCredentials{}, ErrAgentNotOnline
+	}
+
+	creds := svc.rotateAgentCredentials(ctx, token, agent)
+	return creds, creds.Err
+}
+
+func (svc fleetService) RotateAgentGroupCredentials(ctx context.Context, token string, groupID string) ([]AgentCredentials, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := svc.agentGroupRepository.RetrieveByID(ctx, groupID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	agents, err := svc.agentRepo.RetrieveAllByAgentGroupID(ctx, ownerID, group.ID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]AgentCredentials, len(agents))
+	sem := make(chan struct{}, maxConcurrentRotations)
+	var wg sync.WaitGroup
+	for i, agent := range agents {
+		if agent.State != Online {
+			results[i] = AgentCredentials{AgentID: agent.MFThingID, AgentName: agent.Name.String(), ChannelID: agent.MFChannelID, Err: ErrAgentNotOnline}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, agent Agent) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = svc.rotateAgentCredentials(ctx, token, agent)
+		}(i, agent)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// rotateAgentCredentials has the agent store a new key before revoking the previous one, so it never ends up without
+// a working key, then tells it to reconnect
+func (svc fleetService) rotateAgentCredentials(ctx context.Context, token string, agent Agent) AgentCredentials {
+	creds := AgentCredentials{AgentID: agent.MFThingID, AgentName: agent.Name.String(), ChannelID: agent.MFChannelID}
+
+	requestID := uuid.NewString()
+	key := uuid.NewString()
+	if err := svc.agentComms.StageAgentCredentials(ctx, agent, requestID, key); err != nil {
+		creds.Err = err
+		return creds
+	}
+	if err := svc.thingKeys.UpdateThingKey(svc.thingsToken(agent, token), agent.MFThingID, key); err != nil {
+		creds.Err = err
+		return creds
+	}
+	// the previous key is revoked at this point, so the rotation succeeded even if the agent misses the notification:
+	// it falls back to its staged key when reconnecting fails
+	if err := svc.agentComms.CommitAgentCredentials(ctx, agent, requestID); err != nil {
+		svc.logger.Warn("failed to notify agent of its new credentials", zap.String("agent_id", agent.MFThingID), zap.Error(err))
+	}
+	creds.Key = key
+	svc.logger.Info("agent credentials rotated", zap.String("agent_id", agent.MFThingID), zap.String("owner_id", agent.MFOwnerID))
+	return creds
+}
@@ -0,0 +1,122 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateAgentCredentials(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	thingsSVC := newThingsService(users)
+	thingsServer := newThingsServer(thingsSVC)
+	fleetSVC := newFleetService(users, thingsServer.URL, agentGroupRepo, agentRepo)
+
+	createAgent := func(name string, state fleet.State) fleet.Agent {
+		nameID, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		a, err := fleetSVC.CreateAgent(context.Background(), token, fleet.Agent{Name: nameID, AgentTags: types.Tags{"region": "eu"}})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		a.State = state
+		require.Nil(t, agentRepo.UpdateHeartbeatByIDWithChannel(context.Background(), a), "unexpected error setting agent state")
+		return a
+	}
+	online := createAgent("online-agent", fleet.Online)
+	offline := createAgent("offline-agent", fleet.Offline)
+
+	cases := map[string]struct {
+		agentID string
+		token   string
+		err     error
+	}{
+		"rotate the credentials of an online agent": {
+			agentID: online.MFThingID,
+			token:   token,
+			err:     nil,
+		},
+		"rotate the credentials of an offline agent": {
+			agentID: offline.MFThingID,
+			token:   token,
+			err:     fleet.ErrAgentNotOnline,
+		},
+		"rotate the credentials of a non-existent agent": {
+			agentID: wrongID,
+			token:   token,
+			err:     fleet.ErrNotFound,
+		},
+		"rotate the credentials with wrong credentials": {
+			agentID: online.MFThingID,
+			token:   invalidToken,
+			err:     fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			creds, err := fleetSVC.RotateAgentCredentials(context.Background(), tc.token, tc.agentID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err != nil {
+				return
+			}
+			thing, err := thingsSVC.ViewThing(context.Background(), token, tc.agentID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.NotEmpty(t, creds.Key, fmt.Sprintf("%s: expected the new key", desc))
+			assert.Equal(t, creds.Key, thing.Key, fmt.Sprintf("%s: expected the thing key to be replaced", desc))
+		})
+	}
+}
+
+func TestRotateAgentGroupCredentials(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetSVC := newFleetService(users, thingsServer.URL, agentGroupRepo, agentRepo)
+
+	for _, agent := range []struct {
+		name  string
+		state fleet.State
+	}{{"group-agent-1", fleet.Online}, {"group-agent-2", fleet.Online}, {"group-agent-3", fleet.Stale}} {
+		nameID, err := types.NewIdentifier(agent.name)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		a, err := fleetSVC.CreateAgent(context.Background(), token, fleet.Agent{Name: nameID, AgentTags: types.Tags{"region": "eu"}})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		a.State = agent.state
+		require.Nil(t, agentRepo.UpdateHeartbeatByIDWithChannel(context.Background(), a), "unexpected error setting agent state")
+	}
+
+	groupName, err := types.NewIdentifier("rotation-group")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	group, err := fleetSVC.CreateAgentGroup(context.Background(), token, fleet.AgentGroup{Name: groupName, Tags: &types.Tags{"region": "eu"}})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	creds, err := fleetSVC.RotateAgentGroupCredentials(context.Background(), token, group.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, creds, 3, "expected an outcome per agent of the group")
+	rotated := 0
+	for _, c := range creds {
+		if c.Err == nil {
+			rotated++
+			assert.NotEmpty(t, c.Key, fmt.Sprintf("expected a new key for agent %s", c.AgentName))
+			continue
+		}
+		assert.True(t, errors.Contains(c.Err, fleet.ErrAgentNotOnline), fmt.Sprintf("expected %s got %s", fleet.ErrAgentNotOnline, c.Err))
+	}
+	assert.Equal(t, 2, rotated, "expected the online agents credentials to be rotated")
+
+	_, err = fleetSVC.RotateAgentGroupCredentials(context.Background(), token, wrongID)
+	assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("expected %s got %s", fleet.ErrNotFound, err))
+}
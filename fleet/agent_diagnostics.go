@@ -0,0 +1,107 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DiagnosticsCollecting is the status of a bundle the agent is still collecting or uploading
+	DiagnosticsCollecting = "collecting"
+	// DiagnosticsComplete is the status of a bundle fully uploaded, ready for download
+	DiagnosticsComplete = "complete"
+	// DiagnosticsFailed is the status of a bundle the agent failed to collect or upload in time
+	DiagnosticsFailed = "failed"
+
+	// DiagnosticsChunkSize is the most bundle bytes an agent uploads per message, so the base64 encoded chunk stays
+	// below MaxMsgPayloadSize
+	DiagnosticsChunkSize = 16 * 1024
+	// DiagnosticsMaxChunks bounds the size of a bundle to 8MB
+	DiagnosticsMaxChunks = 512
+	// DiagnosticsTimeout is how long an agent has to upload a bundle before it is considered failed
+	DiagnosticsTimeout = 5 * time.Minute
+	// DiagnosticsRetention is how long a bundle is kept for download
+	DiagnosticsRetention = 7 * 24 * time.Hour
+	// DiagnosticsCheckFreq is how often the bundles timing out or past their retention are looked for
+	DiagnosticsCheckFreq = time.Minute
+)
+
+// errDiagnosticsTimeout is the error recorded on the bundles not uploaded in time
+const errDiagnosticsTimeout = "timed out waiting for the agent to upload the bundle"
+
+// DiagnosticsBundle is a gzipped tarball of the configuration, policies, logs and backend state of an agent, uploaded in
+// chunks and assembled once all of them were received
+type DiagnosticsBundle struct {
+	ID        string
+	AgentID   string
+	MFOwnerID string
+	Status    string
+	Error     string
+	// Chunks is the number of chunks the agent announced, ChunksReceived how many of them were received so far
+	Chunks         int
+	ChunksReceived int
+	// Data is the assembled bundle, only set once complete
+	Data      []byte
+	Created   time.Time
+	Completed time.Time
+}
+
+type AgentDiagnosticsService interface {
+	// RequestAgentDiagnostics asks a provided online agent to collect and upload a diagnostic bundle, returning it while
+	// still collecting
+	RequestAgentDiagnostics(ctx context.Context, token string, agentID string) (DiagnosticsBundle, error)
+	// ViewAgentDiagnostics retrieves a diagnostic bundle of a provided agent, along with its data once complete or the
+	// reason it failed
+	ViewAgentDiagnostics(ctx context.Context, token string, agentID string, bundleID string) (DiagnosticsBundle, error)
+}
+
+type AgentDiagnosticsRepository interface {
+	// Save starts collecting a bundle
+	Save(ctx context.Context, b DiagnosticsBundle) error
+	// RetrieveByID retrieves a bundle along with the number of chunks received so far
+	RetrieveByID(ctx context.Context, bundleID string) (DiagnosticsBundle, error)
+	// SaveChunk stores a chunk of a bundle still collecting, returning how many distinct chunks were received so far.
+	// A chunk received twice is stored once
+	SaveChunk(ctx context.Context, bundleID string, seq int, total int, data []byte) (int, error)
+	// RetrieveChunks retrieves the chunks of a bundle in order
+	RetrieveChunks(ctx context.Context, bundleID string) ([][]byte, error)
+	// Complete stores the assembled bundle and drops its chunks, unless it is no longer collecting
+	Complete(ctx context.Context, bundleID string, data []byte) error
+	// Fail marks a bundle still collecting as failed and drops its chunks
+	Fail(ctx context.Context, bundleID string, errMsg string) error
+	// ExpireCollecting marks the bundles still collecting created before the given time as failed
+	ExpireCollecting(ctx context.Context, createdBefore time.Time, errMsg string) (int64, error)
+	// DeleteOlderThan removes the bundles created before the given time
+	DeleteOlderThan(ctx context.Context, t time.Time) (int64, error)
+}
+
+// MonitorAgentDiagnostics fails the bundles not uploaded in time and removes the ones past their retention every freq
+// until the context is done
+func MonitorAgentDiagnostics(ctx context.Context, logger *zap.Logger, repo AgentDiagnosticsRepository, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := repo.ExpireCollecting(ctx, time.Now().Add(-DiagnosticsTimeout), errDiagnosticsTimeout)
+			if err != nil {
+				logger.Error("failed to expire diagnostic bundles", zap.Error(err))
+				continue
+			}
+			if expired > 0 {
+				logger.Warn("agents did not upload diagnostic bundles in time", zap.Int64("timed_out", expired))
+			}
+			if _, err := repo.DeleteOlderThan(ctx, time.Now().Add(-DiagnosticsRetention)); err != nil {
+				logger.Error("failed to remove diagnostic bundles", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (svc fleetService) RequestAgentDiagnostics(ctx context.Context, token string, agentID string) (DiagnosticsBundle, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return DiagnosticsBundle{}, err
+	}
+
+	agent, err := svc.agentRepo.RetrieveByID(ctx, ownerID, agentID)
+	if err != nil {
+		return DiagnosticsBundle{}, err
+	}
+	if agent.State != Online {
+		return DiagnosticsBundle{}, ErrAgentNotOnline
+	}
+
+	b := DiagnosticsBundle{
+		ID:        uuid.NewString(),
+		AgentID:   agent.MFThingID,
+		MFOwnerID: ownerID,
+		Status:    DiagnosticsCollecting,
+		Created:   time.Now(),
+	}
+	if err := svc.diagnosticsRepo.Save(ctx, b); err != nil {
+		return DiagnosticsBundle{}, err
+	}
+	if err := svc.agentComms.RequestAgentDiagnostics(ctx, agent, b.ID); err != nil {
+		if errFail := svc.diagnosticsRepo.Fail(ctx, b.ID, err.Error()); errFail != nil {
+			svc.logger.Warn("failed to mark diagnostic bundle as failed", zap.String("bundle_id", b.ID), zap.Error(errFail))
+		}
+		return DiagnosticsBundle{}, err
+	}
+	svc.logger.Info("agent diagnostic bundle requested", zap.String("agent_id", agent.MFThingID), zap.String("bundle_id", b.ID))
+	return b, nil
+}
+
+func (svc fleetService) ViewAgentDiagnostics(ctx context.Context, token string, agentID string, bundleID string) (DiagnosticsBundle, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return DiagnosticsBundle{}, err
+	}
+
+	b, err := svc.diagnosticsRepo.RetrieveByID(ctx, bundleID)
+	if err != nil {
+		return DiagnosticsBundle{}, err
+	}
+	if b.MFOwnerID != ownerID || b.AgentID != agentID {
+		return DiagnosticsBundle{}, ErrNotFound
+	}
+	return b, nil
+}
@@ -0,0 +1,188 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newDiagnosticsService(t *testing.T) (fleet.Service, fleet.AgentRepository, fleet.AgentDiagnosticsRepository) {
+	t.Helper()
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	diagnosticsRepo := flmocks.NewAgentDiagnosticsRepository()
+	agentComms := flmocks.NewFleetCommService(agentRepo, agentGroupRepo)
+	logger, _ := zap.NewDevelopment()
+	svc := fleet.NewFleetService(logger, users, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), diagnosticsRepo, agentComms, mfsdk.NewSDK(mfsdk.Config{}), fleet.NewThingKeyService(""), "", make(chan bool))
+	return svc, agentRepo, diagnosticsRepo
+}
+
+// saveDiagnosticsAgents saves an online and an offline agent
+func saveDiagnosticsAgents(t *testing.T, agentRepo fleet.AgentRepository) (fleet.Agent, fleet.Agent) {
+	t.Helper()
+	online := newVersionedAgent(t, "diagnostics-online", "0.22.0", "")
+	online.State = fleet.Online
+	offline := newVersionedAgent(t, "diagnostics-offline", "0.22.0", "")
+	offline.State = fleet.Offline
+	for _, a := range []fleet.Agent{online, offline} {
+		require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+	}
+	return online, offline
+}
+
+func TestRequestAgentDiagnostics(t *testing.T) {
+	svc, agentRepo, _ := newDiagnosticsService(t)
+	online, offline := saveDiagnosticsAgents(t, agentRepo)
+
+	cases := map[string]struct {
+		agentID string
+		token   string
+		err     error
+	}{
+		"request diagnostics of an online agent": {
+			agentID: online.MFThingID,
+			token:   token,
+			err:     nil,
+		},
+		"request diagnostics of an offline agent": {
+			agentID: offline.MFThingID,
+			token:   token,
+			err:     fleet.ErrAgentNotOnline,
+		},
+		"request diagnostics of a non-existent agent": {
+			agentID: wrongID,
+			token:   token,
+			err:     fleet.ErrNotFound,
+		},
+		"request diagnostics with wrong credentials": {
+			agentID: online.MFThingID,
+			token:   invalidToken,
+			err:     fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			b, err := svc.RequestAgentDiagnostics(context.Background(), tc.token, tc.agentID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			assert.Equal(t, fleet.DiagnosticsCollecting, b.Status, fmt.Sprintf("%s: expected %s got %s", desc, fleet.DiagnosticsCollecting, b.Status))
+			assert.Equal(t, tc.agentID, b.AgentID, fmt.Sprintf("%s: expected %s got %s", desc, tc.agentID, b.AgentID))
+		})
+	}
+}
+
+func TestViewAgentDiagnostics(t *testing.T) {
+	svc, agentRepo, diagnosticsRepo := newDiagnosticsService(t)
+	online, offline := saveDiagnosticsAgents(t, agentRepo)
+
+	collecting, err := svc.RequestAgentDiagnostics(context.Background(), token, online.MFThingID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	complete, err := svc.RequestAgentDiagnostics(context.Background(), token, online.MFThingID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	for seq, chunk := range []string{"bundle ", "data"} {
+		_, err := diagnosticsRepo.SaveChunk(context.Background(), complete.ID, seq, 2, []byte(chunk))
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+	require.Nil(t, diagnosticsRepo.Complete(context.Background(), complete.ID, []byte("bundle data")), "unexpected error completing bundle")
+
+	cases := map[string]struct {
+		agentID  string
+		bundleID string
+		token    string
+		status   string
+		data     []byte
+		err      error
+	}{
+		"view a bundle still collecting": {
+			agentID:  online.MFThingID,
+			bundleID: collecting.ID,
+			token:    token,
+			status:   fleet.DiagnosticsCollecting,
+			err:      nil,
+		},
+		"view a complete bundle": {
+			agentID:  online.MFThingID,
+			bundleID: complete.ID,
+			token:    token,
+			status:   fleet.DiagnosticsComplete,
+			data:     []byte("bundle data"),
+			err:      nil,
+		},
+		"view a bundle of another agent": {
+			agentID:  offline.MFThingID,
+			bundleID: complete.ID,
+			token:    token,
+			err:      fleet.ErrNotFound,
+		},
+		"view a non-existent bundle": {
+			agentID:  online.MFThingID,
+			bundleID: wrongID,
+			token:    token,
+			err:      fleet.ErrNotFound,
+		},
+		"view a bundle with wrong credentials": {
+			agentID:  online.MFThingID,
+			bundleID: complete.ID,
+			token:    invalidToken,
+			err:      fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			b, err := svc.ViewAgentDiagnostics(context.Background(), tc.token, tc.agentID, tc.bundleID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.status, b.Status, fmt.Sprintf("%s: expected %s got %s", desc, tc.status, b.Status))
+			assert.Equal(t, tc.data, b.Data, fmt.Sprintf("%s: expected %s got %s", desc, tc.data, b.Data))
+		})
+	}
+}
+
+func TestMonitorAgentDiagnostics(t *testing.T) {
+	svc, agentRepo, diagnosticsRepo := newDiagnosticsService(t)
+	online, _ := saveDiagnosticsAgents(t, agentRepo)
+
+	stale := fleet.DiagnosticsBundle{
+		ID:        "6f1a5bc0-7a2e-4c51-9d56-2d1d0d3f8c11",
+		AgentID:   online.MFThingID,
+		MFOwnerID: email,
+		Status:    fleet.DiagnosticsCollecting,
+		Created:   time.Now().Add(-2 * fleet.DiagnosticsTimeout),
+	}
+	require.Nil(t, diagnosticsRepo.Save(context.Background(), stale), "unexpected error saving bundle")
+	recent, err := svc.RequestAgentDiagnostics(context.Background(), token, online.MFThingID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	fleet.MonitorAgentDiagnostics(ctx, zap.NewNop(), diagnosticsRepo, 5*time.Millisecond)
+
+	b, err := svc.ViewAgentDiagnostics(context.Background(), token, online.MFThingID, stale.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsFailed, b.Status, fmt.Sprintf("expected %s got %s", fleet.DiagnosticsFailed, b.Status))
+	assert.NotEmpty(t, b.Error, "expected the timeout to be recorded")
+
+	b, err = svc.ViewAgentDiagnostics(context.Background(), token, online.MFThingID, recent.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsCollecting, b.Status, fmt.Sprintf("expected %s got %s", fleet.DiagnosticsCollecting, b.Status))
+}
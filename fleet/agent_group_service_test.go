@@ -37,6 +37,8 @@ const (
 	token        = "token"
 	invalidToken = "invalid"
 	email        = "user@example.com"
+	serviceKey   = "service-key"
+	serviceEmail = "orb@example.com"
 	channelsNum  = 3
 	maxNameSize  = 1024
 	limit        = 10
@@ -94,7 +96,7 @@ func newService(auth mainflux.AuthServiceClient, url string) fleet.Service {
 	mfsdk := mfsdk.NewSDK(config)
 	pktvisor.Register(auth, agentRepo)
 	aDone := make(chan bool)
-	return fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, agentComms, mfsdk, aDone)
+	return fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), agentComms, mfsdk, fleet.NewThingKeyService(url), serviceKey, aDone)
 }
 
 func TestCreateAgentGroup(t *testing.T) {
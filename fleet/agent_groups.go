@@ -20,6 +20,7 @@ type AgentGroup struct {
 	Tags           *types.Tags
 	Created        time.Time
 	MatchingAgents types.Metadata
+	Maintenance    *Maintenance
 }
 
 type PageAgentGroup struct {
@@ -82,4 +83,9 @@ type AgentGroupRepository interface {
 	Delete(ctx context.Context, groupID string, ownerID string) error
 	// RetrieveMatchingGroups Groups this Agent currently belongs to, according to matching agent and group tags
 	RetrieveMatchingGroups(ctx context.Context, ownerID string, thingID string) (MatchingGroups, error)
+	// SetMaintenance schedules the maintenance window of the agents of a group by owner and id, clearing it when nil
+	SetMaintenance(ctx context.Context, ownerID string, groupID string, m *Maintenance) error
+	// RetrieveAllInMaintenance retrieves the groups of an owner, or of all the owners for an empty owner, with a
+	// maintenance window not over yet
+	RetrieveAllInMaintenance(ctx context.Context, owner string) ([]AgentGroup, error)
 }
@@ -0,0 +1,79 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// MaintenanceCheckFreq is how often the maintenance windows starting or ending are looked for
+const MaintenanceCheckFreq = time.Minute
+
+// maxMaintenanceReason bounds the length of the reason of a maintenance window
+const maxMaintenanceReason = 256
+
+var (
+	// ErrMalformedMaintenance indicates a maintenance window ending before it starts, already over or with a too long reason
+	ErrMalformedMaintenance = errors.New("malformed maintenance window")
+)
+
+// Maintenance is a window during which an agent, or the agents of a group, run no policies. A zero Start opens the
+// window right away and a zero End keeps it open until it is cleared
+type Maintenance struct {
+	Reason string
+	Start  time.Time
+	End    time.Time
+}
+
+// Active tells whether the window is open at the given time
+func (m *Maintenance) Active(t time.Time) bool {
+	return m != nil && !t.Before(m.Start) && (m.End.IsZero() || t.Before(m.End))
+}
+
+func (m Maintenance) validate(now time.Time) error {
+	if len(m.Reason) > maxMaintenanceReason {
+		return ErrMalformedMaintenance
+	}
+	if !m.End.IsZero() && (!m.End.After(m.Start) || !m.End.After(now)) {
+		return ErrMalformedMaintenance
+	}
+	return nil
+}
+
+type AgentMaintenanceService interface {
+	// SetAgentMaintenance schedules a maintenance window on a provided agent, replacing any previous one
+	SetAgentMaintenance(ctx context.Context, token string, agentID string, m Maintenance) (Agent, error)
+	// ClearAgentMaintenance removes the maintenance window of a provided agent, sending its policies again if it was in
+	// maintenance
+	ClearAgentMaintenance(ctx context.Context, token string, agentID string) (Agent, error)
+	// SetAgentGroupMaintenance schedules a maintenance window on the agents of a provided group, replacing any previous one
+	SetAgentGroupMaintenance(ctx context.Context, token string, groupID string, m Maintenance) (AgentGroup, error)
+	// ClearAgentGroupMaintenance removes the maintenance window of a provided group, sending their policies again to the
+	// agents it kept in maintenance
+	ClearAgentGroupMaintenance(ctx context.Context, token string, groupID string) (AgentGroup, error)
+	// CheckAgentMaintenance removes the policies of the agents whose maintenance window, or the one of any of their
+	// groups, started and sends them again to those whose windows ended
+	CheckAgentMaintenance(ctx context.Context) error
+}
+
+// MonitorAgentMaintenance checks the maintenance windows every freq until the context is done
+func MonitorAgentMaintenance(ctx context.Context, logger *zap.Logger, svc Service, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := svc.CheckAgentMaintenance(ctx); err != nil {
+				logger.Error("failed to check agent maintenance windows", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func (svc fleetService) SetAgentMaintenance(ctx context.Context, token string, agentID string, m Maintenance) (Agent, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return Agent{}, err
+	}
+	if err := m.validate(time.Now()); err != nil {
+		return Agent{}, err
+	}
+
+	if err := svc.agentRepo.SetMaintenance(ctx, ownerID, agentID, &m); err != nil {
+		return Agent{}, err
+	}
+	svc.logger.Info("agent maintenance window scheduled", zap.String("agent_id", agentID), zap.Time("start", m.Start),
+		zap.Time("end", m.End), zap.String("reason", m.Reason))
+	svc.applyMaintenance(ctx, ownerID)
+	return svc.agentRepo.RetrieveByID(ctx, ownerID, agentID)
+}
+
+func (svc fleetService) ClearAgentMaintenance(ctx context.Context, token string, agentID string) (Agent, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return Agent{}, err
+	}
+
+	if err := svc.agentRepo.SetMaintenance(ctx, ownerID, agentID, nil); err != nil {
+		return Agent{}, err
+	}
+	svc.logger.Info("agent maintenance window cleared", zap.String("agent_id", agentID))
+	svc.applyMaintenance(ctx, ownerID)
+	return svc.agentRepo.RetrieveByID(ctx, ownerID, agentID)
+}
+
+func (svc fleetService) SetAgentGroupMaintenance(ctx context.Context, token string, groupID string, m Maintenance) (AgentGroup, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return AgentGroup{}, err
+	}
+	if err := m.validate(time.Now()); err != nil {
+		return AgentGroup{}, err
+	}
+
+	if err := svc.agentGroupRepository.SetMaintenance(ctx, ownerID, groupID, &m); err != nil {
+		return AgentGroup{}, err
+	}
+	svc.logger.Info("agent group maintenance window scheduled", zap.String("group_id", groupID), zap.Time("start", m.Start),
+		zap.Time("end", m.End), zap.String("reason", m.Reason))
+	svc.applyMaintenance(ctx, ownerID)
+	return svc.agentGroupRepository.RetrieveByID(ctx, groupID, ownerID)
+}
+
+func (svc fleetService) ClearAgentGroupMaintenance(ctx context.Context, token string, groupID string) (AgentGroup, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return AgentGroup{}, err
+	}
+
+	if err := svc.agentGroupRepository.SetMaintenance(ctx, ownerID, groupID, nil); err != nil {
+		return AgentGroup{}, err
+	}
+	svc.logger.Info("agent group maintenance window cleared", zap.String("group_id", groupID))
+	svc.applyMaintenance(ctx, ownerID)
+	return svc.agentGroupRepository.RetrieveByID(ctx, groupID, ownerID)
+}
+
+func (svc fleetService) CheckAgentMaintenance(ctx context.Context) error {
+	return svc.checkMaintenance(ctx, "")
+}
+
+// applyMaintenance brings the agents of an owner in line with a maintenance window just changed. The window is kept
+// when that fails, the next check applies it
+func (svc fleetService) applyMaintenance(ctx context.Context, ownerID string) {
+	if err := svc.checkMaintenance(ctx, ownerID); err != nil {
+		svc.logger.Warn("failed to apply maintenance windows, retrying on the next check", zap.String("owner_id", ownerID), zap.Error(err))
+	}
+}
+
+// checkMaintenance puts in maintenance the agents of an owner, or of all the owners for an empty owner, whose window
+// or the one of any of their groups is open, and takes out of it the others
+func (svc fleetService) checkMaintenance(ctx context.Context, owner string) error {
+	now := time.Now()
+	agents, err := svc.agentRepo.RetrieveAllInMaintenance(ctx, owner)
+	if err != nil {
+		return err
+	}
+	groups, err := svc.agentGroupRepository.RetrieveAllInMaintenance(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	due := make(map[string]Agent)
+	for _, a := range agents {
+		if a.Maintenance.Active(now) {
+			due[a.MFThingID] = a
+		}
+	}
+	for _, g := range groups {
+		if !g.Maintenance.Active(now) {
+			continue
+		}
+		members, err := svc.agentRepo.RetrieveAllByAgentGroupID(ctx, g.MFOwnerID, g.ID, false)
+		if err != nil {
+			return err
+		}
+		for _, a := range members {
+			if _, ok := due[a.MFThingID]; !ok {
+				due[a.MFThingID] = a
+			}
+		}
+	}
+
+	for _, a := range agents {
+		if _, ok := due[a.MFThingID]; ok || !a.InMaintenance {
+			continue
+		}
+		if err := svc.setInMaintenance(ctx, a, false); err != nil {
+			svc.logger.Error("failed to take agent out of maintenance", zap.String("agent_id", a.MFThingID), zap.Error(err))
+		}
+	}
+	for _, a := range due {
+		if a.InMaintenance {
+			continue
+		}
+		if err := svc.setInMaintenance(ctx, a, true); err != nil {
+			svc.logger.Error("failed to put agent in maintenance", zap.String("agent_id", a.MFThingID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// setInMaintenance records an agent entering or leaving maintenance and sends it the full list of its policies, empty
+// while in maintenance so it removes them. Offline agents receive it when they connect
+func (svc fleetService) setInMaintenance(ctx context.Context, a Agent, inMaintenance bool) error {
+	if err := svc.agentRepo.UpdateInMaintenance(ctx, a.MFThingID, inMaintenance); err != nil {
+		return err
+	}
+	svc.logger.Info("agent maintenance changed", zap.String("agent_id", a.MFThingID), zap.Bool("in_maintenance", inMaintenance))
+	if a.State != Online {
+		return nil
+	}
+	if err := svc.agentComms.NotifyAgentAllDatasets(ctx, a); err != nil {
+		// undone so the next check tries again
+		if errUndo := svc.agentRepo.UpdateInMaintenance(ctx, a.MFThingID, !inMaintenance); errUndo != nil {
+			svc.logger.Warn("failed to undo agent maintenance change", zap.String("agent_id", a.MFThingID), zap.Error(errUndo))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAgentMaintenance(t *testing.T) {
+	svc, agentRepo, _ := newVersionPolicyService(t)
+	a := newVersionedAgent(t, "maintenance-agent", "0.22.0", "")
+	a.State = fleet.Online
+	require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+
+	now := time.Now()
+	cases := map[string]struct {
+		agentID       string
+		token         string
+		maintenance   fleet.Maintenance
+		inMaintenance bool
+		err           error
+	}{
+		"set a maintenance window starting right away": {
+			agentID:       a.MFThingID,
+			token:         token,
+			maintenance:   fleet.Maintenance{Reason: "router upgrade"},
+			inMaintenance: true,
+			err:           nil,
+		},
+		"set a maintenance window starting later": {
+			agentID:       a.MFThingID,
+			token:         token,
+			maintenance:   fleet.Maintenance{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+			inMaintenance: false,
+			err:           nil,
+		},
+		"set a maintenance window ending before it starts": {
+			agentID:     a.MFThingID,
+			token:       token,
+			maintenance: fleet.Maintenance{Start: now.Add(2 * time.Hour), End: now.Add(time.Hour)},
+			err:         fleet.ErrMalformedMaintenance,
+		},
+		"set a maintenance window already over": {
+			agentID:     a.MFThingID,
+			token:       token,
+			maintenance: fleet.Maintenance{End: now.Add(-time.Hour)},
+			err:         fleet.ErrMalformedMaintenance,
+		},
+		"set a maintenance window of a non-existent agent": {
+			agentID:     wrongID,
+			token:       token,
+			maintenance: fleet.Maintenance{},
+			err:         fleet.ErrNotFound,
+		},
+		"set a maintenance window with wrong credentials": {
+			agentID:     a.MFThingID,
+			token:       invalidToken,
+			maintenance: fleet.Maintenance{},
+			err:         fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			got, err := svc.SetAgentMaintenance(context.Background(), tc.token, tc.agentID, tc.maintenance)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.maintenance.Reason, got.Maintenance.Reason, fmt.Sprintf("%s: expected %s got %s", desc, tc.maintenance.Reason, got.Maintenance.Reason))
+			assert.Equal(t, tc.inMaintenance, got.InMaintenance, fmt.Sprintf("%s: expected %t got %t", desc, tc.inMaintenance, got.InMaintenance))
+		})
+	}
+}
+
+func TestClearAgentMaintenance(t *testing.T) {
+	svc, agentRepo, _ := newVersionPolicyService(t)
+	a := newVersionedAgent(t, "maintenance-agent", "0.22.0", "")
+	a.State = fleet.Online
+	require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+	_, err := svc.SetAgentMaintenance(context.Background(), token, a.MFThingID, fleet.Maintenance{Reason: "router upgrade"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		agentID string
+		token   string
+		err     error
+	}{
+		"clear the maintenance window of an agent": {
+			agentID: a.MFThingID,
+			token:   token,
+			err:     nil,
+		},
+		"clear the maintenance window of a non-existent agent": {
+			agentID: wrongID,
+			token:   token,
+			err:     fleet.ErrNotFound,
+		},
+		"clear a maintenance window with wrong credentials": {
+			agentID: a.MFThingID,
+			token:   invalidToken,
+			err:     fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			got, err := svc.ClearAgentMaintenance(context.Background(), tc.token, tc.agentID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			assert.Nil(t, got.Maintenance, fmt.Sprintf("%s: expected no maintenance window got %v", desc, got.Maintenance))
+			assert.False(t, got.InMaintenance, fmt.Sprintf("%s: expected the agent out of maintenance", desc))
+		})
+	}
+}
+
+func TestAgentGroupMaintenance(t *testing.T) {
+	svc, agentRepo, agentGroupRepo := newVersionPolicyService(t)
+	a := newVersionedAgent(t, "maintenance-agent", "0.22.0", "")
+	a.State = fleet.Online
+	require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+	nameID, err := types.NewIdentifier("maintenance-group")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	description := ""
+	groupID, err := agentGroupRepo.Save(context.Background(), fleet.AgentGroup{
+		Name:        nameID,
+		MFOwnerID:   email,
+		Description: &description,
+		Tags:        &types.Tags{"region": "eu"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	group, err := svc.SetAgentGroupMaintenance(context.Background(), token, groupID, fleet.Maintenance{Reason: "change window"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.True(t, group.Maintenance.Active(time.Now()), "expected the group maintenance window to be open")
+	got, err := svc.ViewAgentByID(context.Background(), token, a.MFThingID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.True(t, got.InMaintenance, "expected the agent of the group in maintenance")
+
+	_, err = svc.SetAgentGroupMaintenance(context.Background(), token, wrongID, fleet.Maintenance{})
+	assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("expected %s got %s", fleet.ErrNotFound, err))
+
+	group, err = svc.ClearAgentGroupMaintenance(context.Background(), token, groupID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Nil(t, group.Maintenance, fmt.Sprintf("expected no maintenance window got %v", group.Maintenance))
+	got, err = svc.ViewAgentByID(context.Background(), token, a.MFThingID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.False(t, got.InMaintenance, "expected the agent of the group out of maintenance")
+}
+
+func TestCheckAgentMaintenance(t *testing.T) {
+	svc, agentRepo, _ := newVersionPolicyService(t)
+	now := time.Now()
+
+	starting := newVersionedAgent(t, "maintenance-starting", "0.22.0", "")
+	starting.State = fleet.Online
+	starting.Maintenance = &fleet.Maintenance{Start: now.Add(-time.Minute), End: now.Add(time.Hour)}
+	ended := newVersionedAgent(t, "maintenance-ended", "0.22.0", "")
+	ended.State = fleet.Online
+	ended.Maintenance = &fleet.Maintenance{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Minute)}
+	ended.InMaintenance = true
+	later := newVersionedAgent(t, "maintenance-later", "0.22.0", "")
+	later.Maintenance = &fleet.Maintenance{Start: now.Add(time.Hour)}
+	for _, a := range []fleet.Agent{starting, ended, later} {
+		require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+	}
+
+	require.Nil(t, svc.CheckAgentMaintenance(context.Background()), "unexpected error checking maintenance windows")
+
+	cases := map[string]struct {
+		agentID       string
+		inMaintenance bool
+	}{
+		"agent whose window started":       {agentID: starting.MFThingID, inMaintenance: true},
+		"agent whose window ended":         {agentID: ended.MFThingID, inMaintenance: false},
+		"agent whose window did not start": {agentID: later.MFThingID, inMaintenance: false},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			got, err := svc.ViewAgentByID(context.Background(), token, tc.agentID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.Equal(t, tc.inMaintenance, got.InMaintenance, fmt.Sprintf("%s: expected %t got %t", desc, tc.inMaintenance, got.InMaintenance))
+		})
+	}
+}
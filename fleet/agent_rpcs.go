@@ -0,0 +1,92 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RPCPending is the status of an RPC the agent did not acknowledge yet
+	RPCPending = "pending"
+	// RPCApplied is the status of an RPC the agent acknowledged having handled
+	RPCApplied = "applied"
+	// RPCFailed is the status of an RPC the agent failed to handle
+	RPCFailed = "failed"
+	// RPCIgnored is the status of an RPC the agent does not support
+	RPCIgnored = "ignored"
+	// RPCTimedOut is the status of an RPC the agent did not acknowledge after every attempt
+	RPCTimedOut = "timed_out"
+	// RPCSuperseded is the status of an RPC not sent again, as a newer full list replaced it before the agent acknowledged it
+	RPCSuperseded = "superseded"
+
+	// RPCAckTimeout is how long to wait for the agent to acknowledge an RPC before sending it again
+	RPCAckTimeout = 30 * time.Second
+	// RPCMaxAttempts is how many times an RPC is sent before it is considered timed out
+	RPCMaxAttempts = 5
+	// RPCRetention is how long the outcome of an RPC is kept
+	RPCRetention = 7 * 24 * time.Hour
+	// RPCCheckFreq is how often the unacknowledged RPCs are sent again
+	RPCCheckFreq = 15 * time.Second
+
+	// DefaultAgentRPCsLimit is how many RPC outcomes are listed when no limit is given
+	DefaultAgentRPCsLimit = 100
+	// MaxAgentRPCsLimit is the most RPC outcomes listed at once
+	MaxAgentRPCsLimit = 1000
+)
+
+// AgentRPC tracks the delivery of an RPC to an agent. RPCs published on a group channel are tracked for each of
+// the agents of the group, under the same correlation id
+type AgentRPC struct {
+	CorrelationID string
+	AgentID       string
+	ChannelID     string
+	Func          string
+	// Payload is the published message, sent again as is until the agent acknowledges it
+	Payload  []byte
+	Status   string
+	Error    string
+	Attempts int
+	Created  time.Time
+	LastSent time.Time
+	Updated  time.Time
+}
+
+type AgentRPCRepository interface {
+	// Save starts tracking the delivery of an RPC to an agent
+	Save(ctx context.Context, rpc AgentRPC) error
+	// UpdateStatus records the acknowledgement of an RPC by an agent, unless it already was
+	UpdateStatus(ctx context.Context, agentID string, correlationID string, status string, errMsg string) error
+	// RetrieveAllByAgent retrieves the most recent RPCs sent to an agent
+	RetrieveAllByAgent(ctx context.Context, agentID string, limit uint64) ([]AgentRPC, error)
+	// ClaimRetries retrieves the pending RPCs last sent before the given time with attempts left, counting a new attempt
+	// for each, so concurrent fleet instances do not send them again twice
+	ClaimRetries(ctx context.Context, sentBefore time.Time, maxAttempts int) ([]AgentRPC, error)
+	// Supersede marks the RPCs of the given funcs pending for an agent as superseded, so they are not sent again
+	Supersede(ctx context.Context, agentID string, rpcFuncs []string) (int64, error)
+	// ExpirePending marks the pending RPCs last sent before the given time with no attempts left as timed out
+	ExpirePending(ctx context.Context, sentBefore time.Time, maxAttempts int) (int64, error)
+	// DeleteOlderThan removes the RPCs created before the given time
+	DeleteOlderThan(ctx context.Context, t time.Time) (int64, error)
+}
+
+// MonitorAgentRPCs sends the unacknowledged RPCs again every freq until the context is done
+func MonitorAgentRPCs(ctx context.Context, logger *zap.Logger, comms AgentCommsService, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := comms.RetryAgentRPCs(ctx); err != nil {
+				logger.Error("failed to retry agent RPCs", zap.Error(err))
+			}
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
 	"github.com/orb-community/orb/fleet/backend"
 	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
 	"go.uber.org/zap"
 	"strings"
 )
@@ -26,6 +27,12 @@ var (
 	// It can be due to networking error or invalid/unauthorized request.
 	ErrThings = errors.New("failed to receive response from Things service")
 
+	// ErrAgentNotOnline indicates an action that needs the agent to answer while it is not connected
+	ErrAgentNotOnline = errors.New("agent is not online")
+
+	// ErrPolicyTestTimeout indicates the agent did not report the result of a policy test in time
+	ErrPolicyTestTimeout = errors.New("timed out waiting for the agent policy test result")
+
 	errCreateThing   = errors.New("failed to create thing")
 	errThingNotFound = errors.New("thing not found")
 )
@@ -60,6 +67,15 @@ func (svc fleetService) addAgentToAgentGroupChannels(token string, a Agent) erro
 	return nil
 }
 
+// thingsToken is the Mainflux token managing the Thing and RPC channel of an agent, the service key for the agents
+// enrolled with an enrollment token as they belong to the service account
+func (svc fleetService) thingsToken(a Agent, token string) string {
+	if _, ok := a.AgentMetadata[enrollmentTokenKey]; ok && svc.mfServiceKey != "" {
+		return svc.mfServiceKey
+	}
+	return token
+}
+
 func (svc fleetService) ViewAgentByID(ctx context.Context, token string, thingID string) (Agent, error) {
 	ownerID, err := svc.identify(token)
 	if err != nil {
@@ -96,6 +112,64 @@ func (svc fleetService) ResetAgent(ctx context.Context, token string, agentID st
 	return svc.agentComms.NotifyAgentReset(ctx, agent, true, "Reset initiated from control plane")
 }
 
+func (svc fleetService) ListAgentRPCs(ctx context.Context, token string, agentID string, limit uint64) ([]AgentRPC, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := svc.agentRepo.RetrieveByID(ctx, ownerID, agentID); err != nil {
+		return nil, err
+	}
+
+	if limit == 0 {
+		limit = DefaultAgentRPCsLimit
+	}
+	if limit > MaxAgentRPCsLimit {
+		return nil, ErrMalformedEntity
+	}
+	return svc.agentRPCRepo.RetrieveAllByAgent(ctx, agentID, limit)
+}
+
+func (svc fleetService) ViewFleetSummary(ctx context.Context, token string, tags types.Tags) (FleetSummary, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return FleetSummary{}, err
+	}
+	return svc.agentRepo.RetrieveSummary(ctx, ownerID, tags)
+}
+
+func (svc fleetService) PreviewAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (AgentPolicyRPCPayload, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return AgentPolicyRPCPayload{}, err
+	}
+
+	agent, err := svc.agentRepo.RetrieveByID(ctx, ownerID, agentID)
+	if err != nil {
+		return AgentPolicyRPCPayload{}, err
+	}
+
+	return svc.agentComms.RenderAgentPolicy(ctx, agent, policyID)
+}
+
+func (svc fleetService) TestAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (PolicyTestResultRPCPayload, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return PolicyTestResultRPCPayload{}, err
+	}
+
+	agent, err := svc.agentRepo.RetrieveByID(ctx, ownerID, agentID)
+	if err != nil {
+		return PolicyTestResultRPCPayload{}, err
+	}
+	if agent.State != Online {
+		return PolicyTestResultRPCPayload{}, ErrAgentNotOnline
+	}
+
+	return svc.agentComms.TestAgentPolicy(ctx, agent, policyID)
+}
+
 func (svc fleetService) ViewAgentByIDInternal(ctx context.Context, ownerID string, id string) (Agent, error) {
 	return svc.agentRepo.RetrieveByID(ctx, ownerID, id)
 }
@@ -116,7 +190,11 @@ func (svc fleetService) CreateAgent(ctx context.Context, token string, a Agent)
 	}
 
 	a.MFOwnerID = mfOwnerID
+	return svc.createAgent(ctx, token, a)
+}
 
+// createAgent creates the Thing and RPC channel of an agent of a.MFOwnerID with the given Mainflux token, then saves it
+func (svc fleetService) createAgent(ctx context.Context, token string, a Agent) (Agent, error) {
 	md := map[string]interface{}{"type": "orb_agent"}
 
 	// create new Thing
@@ -244,11 +322,12 @@ func (svc fleetService) RemoveAgent(ctx context.Context, token, thingID string)
 		return nil
 	}
 
-	if errT := svc.mfsdk.DeleteThing(res.MFThingID, token); errT != nil {
+	mfToken := svc.thingsToken(res, token)
+	if errT := svc.mfsdk.DeleteThing(res.MFThingID, mfToken); errT != nil {
 		svc.logger.Error("failed to delete thing", zap.Error(errT), zap.String("thing_id", res.MFThingID))
 	}
 
-	if errT := svc.mfsdk.DeleteChannel(res.MFChannelID, token); errT != nil {
+	if errT := svc.mfsdk.DeleteChannel(res.MFChannelID, mfToken); errT != nil {
 		svc.logger.Error("failed to delete channel", zap.Error(errT), zap.String("channel_id", res.MFChannelID))
 	}
 
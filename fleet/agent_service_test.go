@@ -476,6 +476,64 @@ func TestRemoveAgent(t *testing.T) {
 	}
 }
 
+func TestListAgentRPCs(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetService := newService(users, thingsServer.URL)
+
+	ag, err := createAgent(t, "my-agent-rpcs", fleetService)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id    string
+		token string
+		limit uint64
+		err   error
+	}{
+		"list the RPCs of an existing agent": {
+			id:    ag.MFThingID,
+			token: token,
+			limit: 10,
+			err:   nil,
+		},
+		"list the RPCs of an existing agent with the default limit": {
+			id:    ag.MFThingID,
+			token: token,
+			limit: 0,
+			err:   nil,
+		},
+		"list the RPCs of an existing agent above the maximum limit": {
+			id:    ag.MFThingID,
+			token: token,
+			limit: fleet.MaxAgentRPCsLimit + 1,
+			err:   fleet.ErrMalformedEntity,
+		},
+		"list the RPCs of a non-existing agent": {
+			id:    wrongID,
+			token: token,
+			limit: 10,
+			err:   fleet.ErrNotFound,
+		},
+		"list the RPCs of an agent with wrong credentials": {
+			id:    ag.MFThingID,
+			token: invalidToken,
+			limit: 10,
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			rpcs, err := fleetService.ListAgentRPCs(context.Background(), tc.token, tc.id, tc.limit)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+			if err == nil {
+				assert.Empty(t, rpcs, fmt.Sprintf("%s: expected no RPCs got %d", desc, len(rpcs)))
+			}
+		})
+	}
+}
+
 func TestListBackends(t *testing.T) {
 	users := flmocks.NewAuthService(map[string]string{token: email})
 
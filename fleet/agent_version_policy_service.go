@@ -0,0 +1,161 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+func (svc fleetService) SetAgentVersionPolicy(ctx context.Context, token string, p AgentVersionPolicy) (AgentVersionPolicy, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return AgentVersionPolicy{}, err
+	}
+
+	if p.Enforcement == "" {
+		p.Enforcement = VersionEnforcementStop
+	}
+	if p.Backends == nil {
+		p.Backends = map[string]VersionRequirement{}
+	}
+	if err := p.Validate(); err != nil {
+		return AgentVersionPolicy{}, err
+	}
+	p.MFOwnerID = ownerID
+	p.LastModified = time.Now()
+
+	if err := svc.versionPolicyRepo.Save(ctx, p); err != nil {
+		return AgentVersionPolicy{}, err
+	}
+	return p, nil
+}
+
+func (svc fleetService) ViewAgentVersionPolicy(ctx context.Context, token string) (AgentVersionPolicy, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return AgentVersionPolicy{}, err
+	}
+	return svc.agentVersionPolicy(ctx, ownerID)
+}
+
+// agentVersionPolicy retrieves the version policy of an owner, falling back to the default one
+func (svc fleetService) agentVersionPolicy(ctx context.Context, ownerID string) (AgentVersionPolicy, error) {
+	p, err := svc.versionPolicyRepo.RetrieveByOwner(ctx, ownerID)
+	if errors.Contains(err, ErrNotFound) {
+		return DefaultAgentVersionPolicy(ownerID), nil
+	}
+	if err != nil {
+		return AgentVersionPolicy{}, err
+	}
+	return p, nil
+}
+
+func (svc fleetService) AgentComplianceReport(ctx context.Context, token string) (ComplianceReport, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return ComplianceReport{}, err
+	}
+
+	policy, err := svc.agentVersionPolicy(ctx, ownerID)
+	if err != nil {
+		return ComplianceReport{}, err
+	}
+
+	report := ComplianceReport{Policy: policy}
+	statuses := make(map[string]string)
+	versions := make(map[string]*VersionCompliance)
+	pm := PageMetadata{Limit: complianceAgentsPageSize}
+	for {
+		page, err := svc.agentRepo.RetrieveAll(ctx, ownerID, pm)
+		if err != nil {
+			return ComplianceReport{}, err
+		}
+		for _, a := range page.Agents {
+			c := policy.Compliance(a)
+			statuses[a.MFThingID] = c.Status
+			report.add(c.Status)
+			if c.Status != ComplianceCompliant {
+				report.Agents = append(report.Agents, c)
+			}
+			v, ok := versions[c.Agent.Version]
+			if !ok {
+				v = &VersionCompliance{Version: c.Agent.Version, Status: c.Agent.Status}
+				versions[c.Agent.Version] = v
+			}
+			v.Count++
+		}
+		if uint64(len(page.Agents)) < pm.Limit {
+			break
+		}
+		pm.Offset += pm.Limit
+	}
+
+	for _, v := range versions {
+		report.Versions = append(report.Versions, *v)
+	}
+	sort.Slice(report.Versions, func(i, j int) bool {
+		return versionAfter(report.Versions[i].Version, report.Versions[j].Version)
+	})
+
+	var groups []AgentGroup
+	gpm := PageMetadata{Limit: complianceAgentsPageSize}
+	for {
+		page, err := svc.agentGroupRepository.RetrieveAllAgentGroupsByOwner(ctx, ownerID, gpm)
+		if err != nil {
+			return ComplianceReport{}, err
+		}
+		groups = append(groups, page.AgentGroups...)
+		if uint64(len(page.AgentGroups)) < gpm.Limit {
+			break
+		}
+		gpm.Offset += gpm.Limit
+	}
+	if len(groups) == 0 {
+		return report, nil
+	}
+
+	groupIDs := make([]string, len(groups))
+	for i, g := range groups {
+		groupIDs[i] = g.ID
+	}
+	members, err := svc.agentRepo.RetrieveAllByAgentGroupIDs(ctx, ownerID, groupIDs)
+	if err != nil {
+		return ComplianceReport{}, err
+	}
+	for _, g := range groups {
+		gc := GroupCompliance{GroupID: g.ID, GroupName: g.Name.String()}
+		for _, a := range members[g.ID] {
+			// agents added since their page was read are evaluated on their own
+			status, ok := statuses[a.MFThingID]
+			if !ok {
+				status = policy.Compliance(a).Status
+			}
+			gc.add(status)
+		}
+		report.Groups = append(report.Groups, gc)
+	}
+
+	return report, nil
+}
+
+// versionAfter orders versions most recent first, the unknown ones last
+func versionAfter(a, b string) bool {
+	va, errA := version.NewVersion(a)
+	vb, errB := version.NewVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return a < b
+	case errA != nil:
+		return false
+	case errB != nil:
+		return true
+	}
+	return va.GreaterThan(vb)
+}
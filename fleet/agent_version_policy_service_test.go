@@ -0,0 +1,212 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newVersionPolicyService(t *testing.T) (fleet.Service, fleet.AgentRepository, fleet.AgentGroupRepository) {
+	t.Helper()
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	agentComms := flmocks.NewFleetCommService(agentRepo, agentGroupRepo)
+	logger, _ := zap.NewDevelopment()
+	svc := fleet.NewFleetService(logger, users, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), agentComms, mfsdk.NewSDK(mfsdk.Config{}), fleet.NewThingKeyService(""), "", make(chan bool))
+	return svc, agentRepo, agentGroupRepo
+}
+
+// newVersionedAgent builds an agent which reported the given orb-agent and pktvisor versions, empty ones are left out
+func newVersionedAgent(t *testing.T, name string, agentVersion string, pktvisorVersion string) fleet.Agent {
+	t.Helper()
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	nameID, err := types.NewIdentifier(name)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	md := types.Metadata{}
+	if agentVersion != "" {
+		md["orb_agent"] = fleet.OrbAgentInfo{Version: agentVersion}
+	}
+	if pktvisorVersion != "" {
+		md["backends"] = map[string]fleet.BackendInfo{"pktvisor": {Version: pktvisorVersion}}
+	}
+	return fleet.Agent{
+		Name:          nameID,
+		MFOwnerID:     email,
+		MFThingID:     id.String(),
+		MFChannelID:   id.String(),
+		AgentMetadata: md,
+	}
+}
+
+func TestAgentVersionPolicyCompliance(t *testing.T) {
+	policy := fleet.AgentVersionPolicy{
+		Agent:       fleet.VersionRequirement{Minimum: "0.20.0", Recommended: "0.22.0"},
+		Backends:    map[string]fleet.VersionRequirement{"pktvisor": {Minimum: "4.2.0"}},
+		Enforcement: fleet.VersionEnforcementStop,
+	}
+
+	cases := map[string]struct {
+		agent  fleet.Agent
+		status string
+	}{
+		"agent at the recommended version": {
+			agent:  newVersionedAgent(t, "compliant", "0.22.1", "4.3.0"),
+			status: fleet.ComplianceCompliant,
+		},
+		"agent below the recommended version": {
+			agent:  newVersionedAgent(t, "outdated", "0.21.0", "4.3.0"),
+			status: fleet.ComplianceOutdated,
+		},
+		"agent below the minimum version": {
+			agent:  newVersionedAgent(t, "unsupported", "0.19.0", "4.3.0"),
+			status: fleet.ComplianceUnsupported,
+		},
+		"backend below the minimum version": {
+			agent:  newVersionedAgent(t, "unsupported-backend", "0.22.0", "4.1.0"),
+			status: fleet.ComplianceUnsupported,
+		},
+		"agent without the backend": {
+			agent:  newVersionedAgent(t, "no-backend", "0.22.0", ""),
+			status: fleet.ComplianceCompliant,
+		},
+		"agent which did not report its version": {
+			agent:  newVersionedAgent(t, "unknown", "", ""),
+			status: fleet.ComplianceUnknown,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			c := policy.Compliance(tc.agent)
+			assert.Equal(t, tc.status, c.Status, fmt.Sprintf("%s: expected %s got %s", desc, tc.status, c.Status))
+		})
+	}
+}
+
+func TestSetAgentVersionPolicy(t *testing.T) {
+	svc, _, _ := newVersionPolicyService(t)
+
+	cases := map[string]struct {
+		policy fleet.AgentVersionPolicy
+		token  string
+		err    error
+	}{
+		"set a policy stopping unsupported agents": {
+			policy: fleet.AgentVersionPolicy{
+				Agent:    fleet.VersionRequirement{Minimum: "0.20.0", Recommended: "0.22.0"},
+				Backends: map[string]fleet.VersionRequirement{"otel": {Minimum: "0.1.0"}},
+			},
+			token: token,
+			err:   nil,
+		},
+		"set a policy warning about unsupported agents": {
+			policy: fleet.AgentVersionPolicy{
+				Agent:       fleet.VersionRequirement{Minimum: "0.20.0"},
+				Enforcement: fleet.VersionEnforcementWarn,
+			},
+			token: token,
+			err:   nil,
+		},
+		"set a policy with an invalid version": {
+			policy: fleet.AgentVersionPolicy{Agent: fleet.VersionRequirement{Minimum: "latest"}},
+			token:  token,
+			err:    fleet.ErrMalformedVersionPolicy,
+		},
+		"set a policy recommending a version below the minimum": {
+			policy: fleet.AgentVersionPolicy{Agent: fleet.VersionRequirement{Minimum: "0.22.0", Recommended: "0.20.0"}},
+			token:  token,
+			err:    fleet.ErrMalformedVersionPolicy,
+		},
+		"set a policy with an unknown enforcement": {
+			policy: fleet.AgentVersionPolicy{Enforcement: "ignore"},
+			token:  token,
+			err:    fleet.ErrMalformedVersionPolicy,
+		},
+		"set a policy with wrong credentials": {
+			policy: fleet.AgentVersionPolicy{},
+			token:  invalidToken,
+			err:    fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			saved, err := svc.SetAgentVersionPolicy(context.Background(), tc.token, tc.policy)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			viewed, err := svc.ViewAgentVersionPolicy(context.Background(), tc.token)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.Equal(t, saved, viewed, fmt.Sprintf("%s: expected %v got %v", desc, saved, viewed))
+		})
+	}
+}
+
+func TestViewDefaultAgentVersionPolicy(t *testing.T) {
+	svc, _, _ := newVersionPolicyService(t)
+
+	p, err := svc.ViewAgentVersionPolicy(context.Background(), token)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.VersionEnforcementStop, p.Enforcement, fmt.Sprintf("expected %s got %s", fleet.VersionEnforcementStop, p.Enforcement))
+	assert.Empty(t, p.Agent.Minimum, fmt.Sprintf("expected no minimum version got %s", p.Agent.Minimum))
+}
+
+func TestAgentComplianceReport(t *testing.T) {
+	svc, agentRepo, agentGroupRepo := newVersionPolicyService(t)
+
+	agents := []fleet.Agent{
+		newVersionedAgent(t, "report-compliant", "0.22.0", "4.3.0"),
+		newVersionedAgent(t, "report-outdated-1", "0.21.0", "4.3.0"),
+		newVersionedAgent(t, "report-outdated-2", "0.21.0", "4.3.0"),
+		newVersionedAgent(t, "report-unsupported", "0.22.0", "4.1.0"),
+		newVersionedAgent(t, "report-unknown", "", ""),
+	}
+	for _, a := range agents {
+		require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+	}
+	groupName, err := types.NewIdentifier("report-group")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = agentGroupRepo.Save(context.Background(), fleet.AgentGroup{Name: groupName, MFOwnerID: email})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = svc.SetAgentVersionPolicy(context.Background(), token, fleet.AgentVersionPolicy{
+		Agent:    fleet.VersionRequirement{Minimum: "0.20.0", Recommended: "0.22.0"},
+		Backends: map[string]fleet.VersionRequirement{"pktvisor": {Minimum: "4.2.0"}},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	report, err := svc.AgentComplianceReport(context.Background(), token)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	expected := fleet.ComplianceCounts{Total: 5, Compliant: 1, Outdated: 2, Unsupported: 1, Unknown: 1}
+	assert.Equal(t, expected, report.ComplianceCounts, fmt.Sprintf("expected %v got %v", expected, report.ComplianceCounts))
+	assert.Len(t, report.Agents, 4, fmt.Sprintf("expected 4 non compliant agents got %d", len(report.Agents)))
+
+	require.Len(t, report.Versions, 3, fmt.Sprintf("expected 3 versions got %d", len(report.Versions)))
+	assert.Equal(t, "0.22.0", report.Versions[0].Version, fmt.Sprintf("expected most recent version first got %s", report.Versions[0].Version))
+	assert.Equal(t, fleet.VersionCompliance{Version: "0.21.0", Status: fleet.ComplianceOutdated, Count: 2}, report.Versions[1])
+	assert.Equal(t, "", report.Versions[2].Version, fmt.Sprintf("expected unknown version last got %s", report.Versions[2].Version))
+
+	require.Len(t, report.Groups, 1, fmt.Sprintf("expected 1 group got %d", len(report.Groups)))
+	assert.Equal(t, expected, report.Groups[0].ComplianceCounts, fmt.Sprintf("expected %v got %v", expected, report.Groups[0].ComplianceCounts))
+
+	_, err = svc.AgentComplianceReport(context.Background(), invalidToken)
+	assert.True(t, errors.Contains(err, fleet.ErrUnauthorizedAccess), fmt.Sprintf("expected %s got %s", fleet.ErrUnauthorizedAccess, err))
+}
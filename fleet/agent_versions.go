@@ -0,0 +1,268 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+const (
+	// VersionEnforcementStop stops the agents below the minimum versions when they connect
+	VersionEnforcementStop = "stop"
+	// VersionEnforcementWarn only reports the agents below the minimum versions
+	VersionEnforcementWarn = "warn"
+
+	// ComplianceCompliant is the status of a version meeting the recommended version
+	ComplianceCompliant = "compliant"
+	// ComplianceOutdated is the status of a version meeting the minimum version but not the recommended one
+	ComplianceOutdated = "outdated"
+	// ComplianceUnsupported is the status of a version below the minimum version
+	ComplianceUnsupported = "unsupported"
+	// ComplianceUnknown is the status of an agent that did not report its version yet
+	ComplianceUnknown = "unknown"
+
+	// complianceAgentsPageSize is how many agents are retrieved at once to build a compliance report
+	complianceAgentsPageSize = 100
+)
+
+var (
+	// ErrMalformedVersionPolicy indicates an agent version policy with invalid versions or enforcement
+	ErrMalformedVersionPolicy = errors.New("malformed agent version policy")
+)
+
+// complianceRank orders the compliance statuses from the best to the worst
+var complianceRank = map[string]int{
+	ComplianceCompliant:   0,
+	ComplianceUnknown:     1,
+	ComplianceOutdated:    2,
+	ComplianceUnsupported: 3,
+}
+
+// VersionRequirement is the minimum and recommended versions of a component, either is optional
+type VersionRequirement struct {
+	Minimum     string `json:"minimum,omitempty"`
+	Recommended string `json:"recommended,omitempty"`
+}
+
+func (r VersionRequirement) validate() error {
+	var min, rec *version.Version
+	var err error
+	if r.Minimum != "" {
+		if min, err = version.NewVersion(r.Minimum); err != nil {
+			return errors.Wrap(ErrMalformedVersionPolicy, err)
+		}
+	}
+	if r.Recommended != "" {
+		if rec, err = version.NewVersion(r.Recommended); err != nil {
+			return errors.Wrap(ErrMalformedVersionPolicy, err)
+		}
+	}
+	if min != nil && rec != nil && rec.LessThan(min) {
+		return errors.Wrap(ErrMalformedVersionPolicy, errors.New("recommended version is below the minimum version"))
+	}
+	return nil
+}
+
+// status tells how a reported version meets the requirement
+func (r VersionRequirement) status(reported string) string {
+	if reported == "" {
+		return ComplianceUnknown
+	}
+	v, err := version.NewVersion(reported)
+	if err != nil {
+		return ComplianceUnknown
+	}
+	if r.Minimum != "" {
+		if min, err := version.NewVersion(r.Minimum); err == nil && v.LessThan(min) {
+			return ComplianceUnsupported
+		}
+	}
+	if r.Recommended != "" {
+		if rec, err := version.NewVersion(r.Recommended); err == nil && v.LessThan(rec) {
+			return ComplianceOutdated
+		}
+	}
+	return ComplianceCompliant
+}
+
+// AgentVersionPolicy is the orb-agent and backend versions an owner expects from its agents. It complements the
+// minimum agent version of the control plane, which always stops older agents
+type AgentVersionPolicy struct {
+	MFOwnerID string
+	Agent     VersionRequirement
+	// Backends requirements by backend name, only checked on the agents running the backend
+	Backends map[string]VersionRequirement
+	// Enforcement is what happens to unsupported agents when they connect, VersionEnforcementStop or VersionEnforcementWarn
+	Enforcement  string
+	LastModified time.Time
+}
+
+// DefaultAgentVersionPolicy is the policy of the owners who did not define one, requiring nothing
+func DefaultAgentVersionPolicy(ownerID string) AgentVersionPolicy {
+	return AgentVersionPolicy{
+		MFOwnerID:   ownerID,
+		Backends:    map[string]VersionRequirement{},
+		Enforcement: VersionEnforcementStop,
+	}
+}
+
+// Validate checks the versions of the policy parse and its enforcement is known
+func (p AgentVersionPolicy) Validate() error {
+	if p.Enforcement != VersionEnforcementStop && p.Enforcement != VersionEnforcementWarn {
+		return errors.Wrap(ErrMalformedVersionPolicy, fmt.Errorf("enforcement must be %s or %s", VersionEnforcementStop, VersionEnforcementWarn))
+	}
+	if err := p.Agent.validate(); err != nil {
+		return err
+	}
+	for _, r := range p.Backends {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ComponentCompliance is how the reported version of the orb-agent or one of its backends meets the policy
+type ComponentCompliance struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// AgentCompliance is how an agent meets the version policy of its owner
+type AgentCompliance struct {
+	AgentID   string
+	AgentName string
+	Agent     ComponentCompliance
+	Backends  map[string]ComponentCompliance
+	// Status is the worst status of the orb-agent and its backends
+	Status string
+}
+
+// Compliance tells how the versions an agent reported in its capabilities meet the policy
+func (p AgentVersionPolicy) Compliance(a Agent) AgentCompliance {
+	agentVersion, backendVersions := reportedVersions(a)
+	c := AgentCompliance{
+		AgentID:   a.MFThingID,
+		AgentName: a.Name.String(),
+		Agent:     ComponentCompliance{Version: agentVersion, Status: p.Agent.status(agentVersion)},
+		Backends:  make(map[string]ComponentCompliance),
+	}
+	c.Status = c.Agent.Status
+	for name, v := range backendVersions {
+		bc := ComponentCompliance{Version: v, Status: p.Backends[name].status(v)}
+		c.Backends[name] = bc
+		if complianceRank[bc.Status] > complianceRank[c.Status] {
+			c.Status = bc.Status
+		}
+	}
+	return c
+}
+
+// unsupportedVersions describes the minimum versions of the policy the orb-agent and backends of a compliance are below
+func (p AgentVersionPolicy) unsupportedVersions(c AgentCompliance) []string {
+	var rules []string
+	if c.Agent.Status == ComplianceUnsupported {
+		rules = append(rules, fmt.Sprintf("Minimum orb-agent version: {%s}, running {%s}.", p.Agent.Minimum, c.Agent.Version))
+	}
+	names := make([]string, 0, len(c.Backends))
+	for name, bc := range c.Backends {
+		if bc.Status == ComplianceUnsupported {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rules = append(rules, fmt.Sprintf("Minimum %s backend version: {%s}, running {%s}.", name, p.Backends[name].Minimum, c.Backends[name].Version))
+	}
+	return rules
+}
+
+// reportedVersions reads the orb-agent and backend versions from the capabilities kept in the agent metadata
+func reportedVersions(a Agent) (string, map[string]string) {
+	var md struct {
+		OrbAgent OrbAgentInfo           `json:"orb_agent"`
+		Backends map[string]BackendInfo `json:"backends"`
+	}
+	// stored as is, or decoded back from the database as generic maps
+	if body, err := json.Marshal(a.AgentMetadata); err == nil {
+		_ = json.Unmarshal(body, &md)
+	}
+	backends := make(map[string]string, len(md.Backends))
+	for name, info := range md.Backends {
+		backends[name] = info.Version
+	}
+	return md.OrbAgent.Version, backends
+}
+
+// ComplianceCounts is the number of agents with each compliance status
+type ComplianceCounts struct {
+	Total       int `json:"total"`
+	Compliant   int `json:"compliant"`
+	Outdated    int `json:"outdated"`
+	Unsupported int `json:"unsupported"`
+	Unknown     int `json:"unknown"`
+}
+
+func (c *ComplianceCounts) add(status string) {
+	c.Total++
+	switch status {
+	case ComplianceCompliant:
+		c.Compliant++
+	case ComplianceOutdated:
+		c.Outdated++
+	case ComplianceUnsupported:
+		c.Unsupported++
+	default:
+		c.Unknown++
+	}
+}
+
+// VersionCompliance is the number of agents running an orb-agent version
+type VersionCompliance struct {
+	Version string
+	Status  string
+	Count   int
+}
+
+// GroupCompliance is the compliance of the agents of a group
+type GroupCompliance struct {
+	GroupID   string
+	GroupName string
+	ComplianceCounts
+}
+
+// ComplianceReport summarizes how the agents of an owner meet its version policy
+type ComplianceReport struct {
+	Policy AgentVersionPolicy
+	ComplianceCounts
+	// Versions of the orb-agent run by the agents, most recent first
+	Versions []VersionCompliance
+	Groups   []GroupCompliance
+	// Agents which are not compliant
+	Agents []AgentCompliance
+}
+
+type AgentVersionPolicyService interface {
+	// SetAgentVersionPolicy defines the agent and backend versions the agents of the owner should run
+	SetAgentVersionPolicy(ctx context.Context, token string, p AgentVersionPolicy) (AgentVersionPolicy, error)
+	// ViewAgentVersionPolicy retrieves the version policy of the owner, the default one if it did not define one
+	ViewAgentVersionPolicy(ctx context.Context, token string) (AgentVersionPolicy, error)
+	// AgentComplianceReport summarizes the agents of the owner outdated or unsupported by version and group
+	AgentComplianceReport(ctx context.Context, token string) (ComplianceReport, error)
+}
+
+type AgentVersionPolicyRepository interface {
+	// Save creates or replaces the version policy of an owner
+	Save(ctx context.Context, p AgentVersionPolicy) error
+	// RetrieveByOwner retrieves the version policy of an owner, ErrNotFound if it did not define one
+	RetrieveByOwner(ctx context.Context, ownerID string) (AgentVersionPolicy, error)
+}
@@ -0,0 +1,66 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsupportedVersions(t *testing.T) {
+	policy := AgentVersionPolicy{
+		Agent: VersionRequirement{Minimum: "0.20.0"},
+		Backends: map[string]VersionRequirement{
+			"pktvisor": {Minimum: "4.2.0"},
+			"otel":     {Minimum: "0.2.0"},
+		},
+		Enforcement: VersionEnforcementStop,
+	}
+
+	agent := func(agentVersion string, backends map[string]interface{}) Agent {
+		return Agent{AgentMetadata: map[string]interface{}{
+			"orb_agent": map[string]interface{}{"version": agentVersion},
+			"backends":  backends,
+		}}
+	}
+	backend := func(v string) map[string]interface{} {
+		return map[string]interface{}{"version": v}
+	}
+
+	cases := map[string]struct {
+		agent Agent
+		rules []string
+	}{
+		"orb-agent below its minimum": {
+			agent: agent("0.19.0", map[string]interface{}{"pktvisor": backend("4.2.0")}),
+			rules: []string{"Minimum orb-agent version: {0.20.0}, running {0.19.0}."},
+		},
+		"backend below its minimum only": {
+			agent: agent("0.21.0", map[string]interface{}{"pktvisor": backend("4.1.0"), "otel": backend("0.2.0")}),
+			rules: []string{"Minimum pktvisor backend version: {4.2.0}, running {4.1.0}."},
+		},
+		"orb-agent and backends below their minimum": {
+			agent: agent("0.19.0", map[string]interface{}{"pktvisor": backend("4.1.0"), "otel": backend("0.1.0")}),
+			rules: []string{
+				"Minimum orb-agent version: {0.20.0}, running {0.19.0}.",
+				"Minimum otel backend version: {0.2.0}, running {0.1.0}.",
+				"Minimum pktvisor backend version: {4.2.0}, running {4.1.0}.",
+			},
+		},
+		"versions meeting the policy": {
+			agent: agent("0.20.0", map[string]interface{}{"pktvisor": backend("4.2.0")}),
+			rules: nil,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			rules := policy.unsupportedVersions(policy.Compliance(tc.agent))
+			assert.Equal(t, tc.rules, rules, fmt.Sprintf("%s: expected %v got %v", desc, tc.rules, rules))
+		})
+	}
+}
@@ -61,6 +61,10 @@ type Agent struct {
 	LastHBData     types.Metadata
 	LastHB         time.Time
 	MatchingGroups types.Metadata
+	// Maintenance is the maintenance window scheduled on the agent itself, InMaintenance whether the agent runs no
+	// policies because of it or of the window of any of its groups
+	Maintenance   *Maintenance
+	InMaintenance bool
 }
 
 // Page contains page related metadata as well as list of agents that
@@ -107,6 +111,15 @@ type AgentService interface {
 	ViewAgentInfoByChannelIDInternal(ctx context.Context, channelID string) (Agent, error)
 	// ResetAgent reset a agent on edge by a provided agent
 	ResetAgent(ct context.Context, token string, agentID string) error
+	// PreviewAgentPolicy render a policy with the template variables of a provided agent, without sending it
+	PreviewAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (AgentPolicyRPCPayload, error)
+	// TestAgentPolicy ask a provided online agent to validate a policy with its backend, without running it
+	TestAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (PolicyTestResultRPCPayload, error)
+	// ListAgentRPCs retrieves the delivery status of the most recent RPCs sent to a provided agent
+	ListAgentRPCs(ctx context.Context, token string, agentID string, limit uint64) ([]AgentRPC, error)
+	// ViewFleetSummary counts the agents of the owner matching the provided tags by state, version, backend and
+	// policy states, and group
+	ViewFleetSummary(ctx context.Context, token string, tags types.Tags) (FleetSummary, error)
 	// GetPolicyState get all policies state per agent in a formatted way from a given existent agent
 	GetPolicyState(ctx context.Context, agent Agent) (map[string]interface{}, error)
 	// ViewAgentMatchingGroupsByIDInternal Groups this Agent currently belongs to, according to matching agent and group tags
@@ -127,6 +140,8 @@ type AgentRepository interface {
 	RetrieveAll(ctx context.Context, owner string, pm PageMetadata) (Page, error)
 	// RetrieveAllByAgentGroupID retrieves Agents in the specified group
 	RetrieveAllByAgentGroupID(ctx context.Context, owner string, agentGroupID string, onlinishOnly bool) ([]Agent, error)
+	// RetrieveAllByAgentGroupIDs retrieves the Agents, with their last heartbeat, in each of the specified groups
+	RetrieveAllByAgentGroupIDs(ctx context.Context, owner string, agentGroupIDs []string) (map[string][]Agent, error)
 	// RetrieveMatchingAgents retrieve the matching agents by tags
 	RetrieveMatchingAgents(ctx context.Context, owner string, tags types.Tags) (types.Metadata, error)
 	// UpdateAgentByID update the the tags and name for the Agent having provided ID and owner
@@ -141,6 +156,18 @@ type AgentRepository interface {
 	SetStaleStatus(ctx context.Context, minutes time.Duration) (int64, error)
 	// RetrieveAgentInfoByChannelID gRPC version to retrieve ownerID, name and agent tags by a provided channelID
 	RetrieveAgentInfoByChannelID(ctx context.Context, channelID string) (Agent, error)
+	// RetrieveSchemaVersionCounts counts the online agents speaking each schema version, the agents that did not
+	// advertise their schema versions speaking the legacy ones
+	RetrieveSchemaVersionCounts(ctx context.Context, legacy SchemaVersions) ([]SchemaVersionCount, error)
+	// RetrieveSummary summarizes the agents of an owner matching the tags, or of all the owners for an empty owner
+	RetrieveSummary(ctx context.Context, owner string, tags types.Tags) (FleetSummary, error)
+	// SetMaintenance schedules the maintenance window of the Agent having the provided ID and owner, clearing it when nil
+	SetMaintenance(ctx context.Context, ownerID string, thingID string, m *Maintenance) error
+	// UpdateInMaintenance records whether the Agent having the provided ID runs no policies because of a maintenance window
+	UpdateInMaintenance(ctx context.Context, thingID string, inMaintenance bool) error
+	// RetrieveAllInMaintenance retrieves the agents of an owner, or of all the owners for an empty owner, in maintenance
+	// or with a maintenance window not over yet
+	RetrieveAllInMaintenance(ctx context.Context, owner string) ([]Agent, error)
 }
 
 type AgentHeartbeatRepository interface {
@@ -14,6 +14,7 @@ import (
 	kitot "github.com/go-kit/kit/tracing/opentracing"
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
 	"github.com/opentracing/opentracing-go"
+	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/fleet/pb"
 	"google.golang.org/grpc"
 	"time"
@@ -27,6 +28,7 @@ type grpcClient struct {
 	retrieveAgentGroup           endpoint.Endpoint
 	retrieveOwnerByChannelID     endpoint.Endpoint
 	retrieveAgentInfoByChannelID endpoint.Endpoint
+	retrievePolicyStatus         endpoint.Endpoint
 }
 
 func (g grpcClient) RetrieveAgent(ctx context.Context, in *pb.AgentByIDReq, opts ...grpc.CallOption) (*pb.AgentRes, error) {
@@ -93,6 +95,30 @@ func (g grpcClient) RetrieveAgentInfoByChannelID(ctx context.Context, in *pb.Age
 	return &pb.AgentInfoRes{OwnerID: ir.ownerID, AgentName: ir.agentName, AgentTags: ir.agentTags, OrbTags: ir.orbTags, AgentGroupIDs: ir.agentGroupIDs}, nil
 }
 
+func (g grpcClient) RetrievePolicyStatus(ctx context.Context, in *pb.PolicyStatusReq, opts ...grpc.CallOption) (*pb.PolicyStatusRes, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	ar := accessPolicyStatusReq{
+		OwnerID:  in.GetOwnerID(),
+		PolicyID: in.GetPolicyID(),
+		Version:  in.GetVersion(),
+	}
+	for _, t := range in.GetTargets() {
+		ar.Targets = append(ar.Targets, fleet.PolicyStatusTarget{
+			AgentGroupID:     t.GetAgentGroupID(),
+			ExcludedAgentIDs: t.GetExcludedAgentIDs(),
+		})
+	}
+	res, err := g.retrievePolicyStatus(ctx, ar)
+	if err != nil {
+		return nil, err
+	}
+
+	ir := res.(policyStatusRes)
+	return toPolicyStatusPb(ir), nil
+}
+
 // NewClient returns new gRPC client instance.
 func NewClient(tracer opentracing.Tracer, conn *grpc.ClientConn, timeout time.Duration) pb.FleetServiceClient {
 	svcName := "fleet.FleetService"
@@ -131,6 +157,14 @@ func NewClient(tracer opentracing.Tracer, conn *grpc.ClientConn, timeout time.Du
 			decodeAgentInfoResponse,
 			pb.AgentInfoRes{},
 		).Endpoint()),
+		retrievePolicyStatus: kitot.TraceClient(tracer, "retrieve_policy_status")(kitgrpc.NewClient(
+			conn,
+			svcName,
+			"RetrievePolicyStatus",
+			encodeRetrievePolicyStatusRequest,
+			decodePolicyStatusResponse,
+			pb.PolicyStatusRes{},
+		).Endpoint()),
 	}
 }
 
@@ -200,3 +234,34 @@ func decodeAgentInfoResponse(ctx context.Context, grpcRes interface{}) (interfac
 		agentGroupIDs: res.GetAgentGroupIDs(),
 	}, nil
 }
+
+func encodeRetrievePolicyStatusRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(accessPolicyStatusReq)
+	pbReq := &pb.PolicyStatusReq{
+		OwnerID:  req.OwnerID,
+		PolicyID: req.PolicyID,
+		Version:  req.Version,
+	}
+	for _, t := range req.Targets {
+		pbReq.Targets = append(pbReq.Targets, &pb.PolicyStatusTarget{
+			AgentGroupID:     t.AgentGroupID,
+			ExcludedAgentIDs: t.ExcludedAgentIDs,
+		})
+	}
+	return pbReq, nil
+}
+
+func decodePolicyStatusResponse(ctx context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*pb.PolicyStatusRes)
+	ir := policyStatusRes{
+		total:  res.GetTotal(),
+		states: res.GetStates(),
+	}
+	for _, e := range res.GetErrors() {
+		ir.errors = append(ir.errors, policyStatusErrorRes{error: e.GetError(), count: e.GetCount()})
+	}
+	for _, a := range res.GetStaleAgents() {
+		ir.staleAgents = append(ir.staleAgents, stalePolicyAgentRes{agentID: a.GetAgentID(), agentName: a.GetAgentName(), version: a.GetVersion()})
+	}
+	return ir, nil
+}
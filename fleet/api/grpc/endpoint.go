@@ -89,3 +89,31 @@ func retrieveAgentInfoByChannelIDEndpoint(svc fleet.Service) endpoint.Endpoint {
 		return res, nil
 	}
 }
+
+func retrievePolicyStatusEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(accessPolicyStatusReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		status, err := svc.ViewPolicyStatusInternal(ctx, req.OwnerID, req.PolicyID, req.Version, req.Targets)
+		if err != nil {
+			return nil, err
+		}
+
+		res := policyStatusRes{
+			total:  int32(status.Total),
+			states: make(map[string]int32, len(status.States)),
+		}
+		for state, count := range status.States {
+			res.states[state] = int32(count)
+		}
+		for _, e := range status.Errors {
+			res.errors = append(res.errors, policyStatusErrorRes{error: e.Error, count: int32(e.Count)})
+		}
+		for _, a := range status.StaleAgents {
+			res.staleAgents = append(res.staleAgents, stalePolicyAgentRes{agentID: a.AgentID, agentName: a.AgentName, version: a.Version})
+		}
+		return res, nil
+	}
+}
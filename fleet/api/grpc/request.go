@@ -51,3 +51,22 @@ func (req accessAgentInfoByChannelIDReq) validate() error {
 	}
 	return nil
 }
+
+type accessPolicyStatusReq struct {
+	OwnerID  string
+	PolicyID string
+	Version  int32
+	Targets  []fleet.PolicyStatusTarget
+}
+
+func (req accessPolicyStatusReq) validate() error {
+	if req.OwnerID == "" || req.PolicyID == "" {
+		return fleet.ErrMalformedEntity
+	}
+	for _, t := range req.Targets {
+		if t.AgentGroupID == "" {
+			return fleet.ErrMalformedEntity
+		}
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package grpc
 
+import "github.com/orb-community/orb/fleet/pb"
+
 type agentRes struct {
 	id      string
 	name    string
@@ -25,6 +27,39 @@ type agentInfoRes struct {
 	agentGroupIDs []string
 }
 
+type policyStatusErrorRes struct {
+	error string
+	count int32
+}
+
+type stalePolicyAgentRes struct {
+	agentID   string
+	agentName string
+	version   int32
+}
+
+type policyStatusRes struct {
+	total       int32
+	states      map[string]int32
+	errors      []policyStatusErrorRes
+	staleAgents []stalePolicyAgentRes
+}
+
+// toPolicyStatusPb is shared by the server encoding and the client, which hands the protobuf message back to the caller
+func toPolicyStatusPb(res policyStatusRes) *pb.PolicyStatusRes {
+	pbRes := &pb.PolicyStatusRes{
+		Total:  res.total,
+		States: res.states,
+	}
+	for _, e := range res.errors {
+		pbRes.Errors = append(pbRes.Errors, &pb.PolicyStatusError{Error: e.error, Count: e.count})
+	}
+	for _, a := range res.staleAgents {
+		pbRes.StaleAgents = append(pbRes.StaleAgents, &pb.StalePolicyAgent{AgentID: a.agentID, AgentName: a.agentName, Version: a.version})
+	}
+	return pbRes
+}
+
 type emptyRes struct {
 	err error
 }
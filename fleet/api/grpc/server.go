@@ -19,6 +19,7 @@ type grpcServer struct {
 	retrieveAgentGroup           kitgrpc.Handler
 	retrieveOwnerByChannelID     kitgrpc.Handler
 	retrieveAgentInfoByChannelID kitgrpc.Handler
+	retrievePolicyStatus         kitgrpc.Handler
 }
 
 func NewServer(tracer opentracing.Tracer, svc fleet.Service) pb.FleetServiceServer {
@@ -43,6 +44,11 @@ func NewServer(tracer opentracing.Tracer, svc fleet.Service) pb.FleetServiceServ
 			decodeRetrieveAgentInfoByChannelIDRequest,
 			encodeAgentInfoResponse,
 		),
+		retrievePolicyStatus: kitgrpc.NewServer(
+			kitot.TraceServer(tracer, "retrieve_policy_status")(retrievePolicyStatusEndpoint(svc)),
+			decodeRetrievePolicyStatusRequest,
+			encodePolicyStatusResponse,
+		),
 	}
 }
 
@@ -81,6 +87,15 @@ func (gs *grpcServer) RetrieveAgentInfoByChannelID(ctx context.Context, req *pb.
 	return res.(*pb.AgentInfoRes), nil
 }
 
+func (gs *grpcServer) RetrievePolicyStatus(ctx context.Context, req *pb.PolicyStatusReq) (*pb.PolicyStatusRes, error) {
+	_, res, err := gs.retrievePolicyStatus.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return res.(*pb.PolicyStatusRes), nil
+}
+
 func decodeRetrieveAgentRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.AgentByIDReq)
 	return accessByIDReq{AgentID: req.AgentID, OwnerID: req.OwnerID}, nil
@@ -138,6 +153,23 @@ func encodeAgentInfoResponse(_ context.Context, grpcRes interface{}) (interface{
 	}, nil
 }
 
+func decodeRetrievePolicyStatusRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PolicyStatusReq)
+	targets := make([]fleet.PolicyStatusTarget, 0, len(req.GetTargets()))
+	for _, t := range req.GetTargets() {
+		targets = append(targets, fleet.PolicyStatusTarget{
+			AgentGroupID:     t.GetAgentGroupID(),
+			ExcludedAgentIDs: t.GetExcludedAgentIDs(),
+		})
+	}
+	return accessPolicyStatusReq{OwnerID: req.OwnerID, PolicyID: req.PolicyID, Version: req.Version, Targets: targets}, nil
+}
+
+func encodePolicyStatusResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(policyStatusRes)
+	return toPolicyStatusPb(res), nil
+}
+
 func encodeError(err error) error {
 	switch err {
 	case nil:
@@ -10,6 +10,7 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/errors"
@@ -68,6 +69,7 @@ func viewAgentGroupEndpoint(svc fleet.Service) endpoint.Endpoint {
 			Tags:           *agentGroup.Tags,
 			TsCreated:      agentGroup.Created,
 			MatchingAgents: agentGroup.MatchingAgents,
+			Maintenance:    toMaintenanceRes(agentGroup.Maintenance),
 		}
 		return res, nil
 	}
@@ -103,6 +105,7 @@ func listAgentGroupsEndpoint(svc fleet.Service) endpoint.Endpoint {
 				Tags:           *ag.Tags,
 				TsCreated:      ag.Created,
 				MatchingAgents: ag.MatchingAgents,
+				Maintenance:    toMaintenanceRes(ag.Maintenance),
 			}
 			res.AgentGroups = append(res.AgentGroups, view)
 		}
@@ -148,6 +151,7 @@ func editAgentGroupEndpoint(svc fleet.Service) endpoint.Endpoint {
 			Tags:           *data.Tags,
 			TsCreated:      data.Created,
 			MatchingAgents: data.MatchingAgents,
+			Maintenance:    toMaintenanceRes(data.Maintenance),
 		}
 
 		return res, nil
@@ -233,6 +237,8 @@ func viewAgentEndpoint(svc fleet.Service) endpoint.Endpoint {
 			State:         ag.State.String(),
 			LastHBData:    ag.LastHBData,
 			TsLastHB:      ag.LastHB,
+			Maintenance:   toMaintenanceRes(ag.Maintenance),
+			InMaintenance: ag.InMaintenance,
 		}
 		return res, nil
 	}
@@ -275,6 +281,163 @@ func resetAgentEndpoint(svc fleet.Service) endpoint.Endpoint {
 	}
 }
 
+func listAgentRPCsEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listAgentRPCsReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		rpcs, err := svc.ListAgentRPCs(ctx, req.token, req.id, req.limit)
+		if err != nil {
+			return nil, err
+		}
+		res := agentRPCsRes{
+			RPCs: make([]agentRPCRes, 0, len(rpcs)),
+		}
+		for _, rpc := range rpcs {
+			res.RPCs = append(res.RPCs, agentRPCRes{
+				CorrelationID: rpc.CorrelationID,
+				Func:          rpc.Func,
+				ChannelID:     rpc.ChannelID,
+				Status:        rpc.Status,
+				Error:         rpc.Error,
+				Attempts:      rpc.Attempts,
+				TsCreated:     rpc.Created,
+				TsLastSent:    rpc.LastSent,
+				TsUpdated:     rpc.Updated,
+			})
+		}
+		return res, nil
+	}
+}
+
+func rotateAgentCredentialsEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		creds, err := svc.RotateAgentCredentials(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toAgentCredentialsRes(creds), nil
+	}
+}
+
+func requestAgentDiagnosticsEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		b, err := svc.RequestAgentDiagnostics(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toDiagnosticsBundleRes(b), nil
+	}
+}
+
+func viewAgentDiagnosticsEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewAgentDiagnosticsReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		b, err := svc.ViewAgentDiagnostics(ctx, req.token, req.id, req.bundleID)
+		if err != nil {
+			return nil, err
+		}
+		if b.Status != fleet.DiagnosticsComplete {
+			return toDiagnosticsBundleRes(b), nil
+		}
+		res := diagnosticsDownloadRes{
+			filename: fmt.Sprintf("orb-agent-%s-diagnostics-%s.tar.gz", b.AgentID, b.ID),
+			data:     b.Data,
+		}
+		return res, nil
+	}
+}
+
+func rotateAgentGroupCredentialsEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		list, err := svc.RotateAgentGroupCredentials(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		res := agentsCredentialsRes{
+			Agents: make([]agentCredentialsRes, 0, len(list)),
+		}
+		for _, creds := range list {
+			if creds.Err == nil {
+				res.Rotated++
+			}
+			res.Agents = append(res.Agents, toAgentCredentialsRes(creds))
+		}
+		return res, nil
+	}
+}
+
+func toAgentCredentialsRes(creds fleet.AgentCredentials) agentCredentialsRes {
+	res := agentCredentialsRes{
+		ID:        creds.AgentID,
+		Name:      creds.AgentName,
+		ChannelID: creds.ChannelID,
+		Key:       creds.Key,
+	}
+	if creds.Err != nil {
+		res.Error = creds.Err.Error()
+	}
+	return res
+}
+
+func previewAgentPolicyEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(previewAgentPolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		p, err := svc.PreviewAgentPolicy(ctx, req.token, req.id, req.policyID)
+		if err != nil {
+			return nil, err
+		}
+		res := agentPolicyPreviewRes{
+			ID:      p.ID,
+			Name:    p.Name,
+			Backend: p.Backend,
+			Format:  p.Format,
+			Version: p.Version,
+			Data:    p.Data,
+		}
+		return res, nil
+	}
+}
+
+func testAgentPolicyEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(testAgentPolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		r, err := svc.TestAgentPolicy(ctx, req.token, req.id, req.PolicyID)
+		if err != nil {
+			return nil, err
+		}
+		res := policyTestRes{
+			PolicyID: r.PolicyID,
+			Backend:  r.Backend,
+			Valid:    r.Valid,
+			Error:    r.Error,
+		}
+		return res, nil
+	}
+}
+
 func listAgentsEndpoint(svc fleet.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(listResourcesReq)
@@ -314,6 +477,8 @@ func listAgentsEndpoint(svc fleet.Service) endpoint.Endpoint {
 				TsLastHB:      ag.LastHB,
 				PolicyState:   policyState,
 				AgentMetadata: ag.AgentMetadata,
+				Maintenance:   toMaintenanceRes(ag.Maintenance),
+				InMaintenance: ag.InMaintenance,
 			}
 			res.Agents = append(res.Agents, view)
 		}
@@ -475,3 +640,399 @@ func listAgentBackendsEndpoint(svc fleet.Service) endpoint.Endpoint {
 		}, nil
 	}
 }
+
+func viewPolicyRolloutEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		rollout, err := svc.ViewPolicyRollout(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toPolicyRolloutRes(rollout), nil
+	}
+}
+
+func listPolicyRolloutsEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listPolicyRolloutsReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		rollouts, err := svc.ListPolicyRollouts(ctx, req.token, req.policyID)
+		if err != nil {
+			return nil, err
+		}
+		res := policyRolloutsRes{Rollouts: []policyRolloutRes{}}
+		for _, rollout := range rollouts {
+			res.Rollouts = append(res.Rollouts, toPolicyRolloutRes(rollout))
+		}
+		return res, nil
+	}
+}
+
+func rollbackPolicyRolloutEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		rollout, err := svc.RollbackPolicyRollout(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toPolicyRolloutRes(rollout), nil
+	}
+}
+
+func resumePolicyRolloutEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		rollout, err := svc.ResumePolicyRollout(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toPolicyRolloutRes(rollout), nil
+	}
+}
+
+func toPolicyRolloutRes(rollout fleet.PolicyRollout) policyRolloutRes {
+	res := policyRolloutRes{
+		ID:             rollout.ID,
+		PolicyID:       rollout.PolicyID,
+		Version:        rollout.Version,
+		GroupIDs:       rollout.GroupIDs,
+		Strategy:       rollout.Strategy,
+		State:          rollout.State,
+		CurrentWave:    rollout.CurrentWave,
+		Waves:          rollout.Waves,
+		Progress:       make(map[string]int),
+		Agents:         rollout.Agents,
+		Error:          rollout.Error,
+		TsCreated:      rollout.Created,
+		TsLastModified: rollout.LastModified,
+	}
+	if res.Agents == nil {
+		res.Agents = []fleet.RolloutAgent{}
+	}
+	for _, a := range rollout.Agents {
+		res.Progress[a.State]++
+	}
+	return res
+}
+
+func addEnrollmentTokenEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addEnrollmentTokenReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		et, err := svc.CreateEnrollmentToken(ctx, req.token, fleet.EnrollmentToken{
+			Name:      req.Name,
+			OrbTags:   req.OrbTags,
+			MaxUses:   req.MaxUses,
+			ExpiresAt: req.ExpiresAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		res := toEnrollmentTokenRes(et)
+		res.created = true
+		return res, nil
+	}
+}
+
+func listEnrollmentTokensEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listEnrollmentTokensReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		tokens, err := svc.ListEnrollmentTokens(ctx, req.token)
+		if err != nil {
+			return nil, err
+		}
+		res := enrollmentTokensRes{Tokens: []enrollmentTokenRes{}}
+		for _, et := range tokens {
+			res.Tokens = append(res.Tokens, toEnrollmentTokenRes(et))
+		}
+		return res, nil
+	}
+}
+
+func revokeEnrollmentTokenEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		if err := svc.RevokeEnrollmentToken(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+		return removeRes{}, nil
+	}
+}
+
+func enrollAgentEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enrollAgentReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		nID, err := types.NewIdentifier(req.Name)
+		if err != nil {
+			return nil, err
+		}
+		saved, err := svc.EnrollAgent(ctx, req.enrollmentToken, fleet.Agent{
+			Name:      nID,
+			AgentTags: req.AgentTags,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		res := agentRes{
+			Name:          saved.Name.String(),
+			ID:            saved.MFThingID,
+			State:         saved.State.String(),
+			Key:           saved.MFKeyID,
+			OrbTags:       *saved.OrbTags,
+			AgentTags:     saved.AgentTags,
+			AgentMetadata: saved.AgentMetadata,
+			LastHBData:    saved.LastHBData,
+			TsCreated:     saved.Created,
+			created:       true,
+			ChannelID:     saved.MFChannelID,
+		}
+		return res, nil
+	}
+}
+
+func viewFleetSummaryEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(fleetSummaryReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		summary, err := svc.ViewFleetSummary(ctx, req.token, req.tags)
+		if err != nil {
+			return nil, err
+		}
+		res := fleetSummaryRes{
+			Total:                    summary.Total,
+			States:                   summary.States,
+			AgentVersions:            summary.AgentVersions,
+			BackendVersions:          summary.BackendVersions,
+			BackendStates:            summary.BackendStates,
+			PolicyStates:             summary.PolicyStates,
+			Groups:                   []groupSizeRes{},
+			FailingPolicyAgentsTotal: summary.FailingPolicyAgentsTotal,
+			FailingPolicyAgents:      []failingPolicyAgentRes{},
+		}
+		for _, g := range summary.Groups {
+			res.Groups = append(res.Groups, groupSizeRes{ID: g.GroupID, Name: g.GroupName, Total: g.Total, Online: g.Online})
+		}
+		for _, a := range summary.FailingPolicyAgents {
+			far := failingPolicyAgentRes{ID: a.AgentID, Name: a.AgentName, Policies: []failingPolicyRes{}}
+			for _, p := range a.Policies {
+				far.Policies = append(far.Policies, failingPolicyRes{ID: p.PolicyID, Name: p.PolicyName, Error: p.Error})
+			}
+			res.FailingPolicyAgents = append(res.FailingPolicyAgents, far)
+		}
+		return res, nil
+	}
+}
+
+func setAgentVersionPolicyEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setAgentVersionPolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		p, err := svc.SetAgentVersionPolicy(ctx, req.token, fleet.AgentVersionPolicy{
+			Agent:       req.Agent,
+			Backends:    req.Backends,
+			Enforcement: req.Enforcement,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return toAgentVersionPolicyRes(p), nil
+	}
+}
+
+func viewAgentVersionPolicyEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewAgentVersionPolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		p, err := svc.ViewAgentVersionPolicy(ctx, req.token)
+		if err != nil {
+			return nil, err
+		}
+		return toAgentVersionPolicyRes(p), nil
+	}
+}
+
+func agentComplianceReportEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewAgentVersionPolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		report, err := svc.AgentComplianceReport(ctx, req.token)
+		if err != nil {
+			return nil, err
+		}
+		res := complianceReportRes{
+			Policy:           toAgentVersionPolicyRes(report.Policy),
+			ComplianceCounts: report.ComplianceCounts,
+			Versions:         []versionComplianceRes{},
+			Groups:           []groupComplianceRes{},
+			Agents:           []agentComplianceRes{},
+		}
+		for _, v := range report.Versions {
+			res.Versions = append(res.Versions, versionComplianceRes{Version: v.Version, Status: v.Status, Count: v.Count})
+		}
+		for _, g := range report.Groups {
+			res.Groups = append(res.Groups, groupComplianceRes{ID: g.GroupID, Name: g.GroupName, ComplianceCounts: g.ComplianceCounts})
+		}
+		for _, a := range report.Agents {
+			res.Agents = append(res.Agents, agentComplianceRes{
+				ID:       a.AgentID,
+				Name:     a.AgentName,
+				Status:   a.Status,
+				Agent:    a.Agent,
+				Backends: a.Backends,
+			})
+		}
+		return res, nil
+	}
+}
+
+func toAgentVersionPolicyRes(p fleet.AgentVersionPolicy) agentVersionPolicyRes {
+	backends := p.Backends
+	if backends == nil {
+		backends = map[string]fleet.VersionRequirement{}
+	}
+	return agentVersionPolicyRes{
+		Agent:          p.Agent,
+		Backends:       backends,
+		Enforcement:    p.Enforcement,
+		TsLastModified: p.LastModified,
+	}
+}
+
+func toEnrollmentTokenRes(et fleet.EnrollmentToken) enrollmentTokenRes {
+	res := enrollmentTokenRes{
+		ID:        et.ID,
+		Name:      et.Name,
+		Token:     et.Token,
+		OrbTags:   et.OrbTags,
+		MaxUses:   et.MaxUses,
+		Uses:      et.Uses,
+		ExpiresAt: et.ExpiresAt,
+		Revoked:   et.Revoked,
+		TsCreated: et.Created,
+	}
+	if res.OrbTags == nil {
+		res.OrbTags = types.Tags{}
+	}
+	return res
+}
+
+func setAgentMaintenanceEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setMaintenanceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ag, err := svc.SetAgentMaintenance(ctx, req.token, req.id, req.maintenance())
+		if err != nil {
+			return nil, err
+		}
+		return toAgentMaintenanceRes(ag), nil
+	}
+}
+
+func clearAgentMaintenanceEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ag, err := svc.ClearAgentMaintenance(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toAgentMaintenanceRes(ag), nil
+	}
+}
+
+func setAgentGroupMaintenanceEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setMaintenanceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ag, err := svc.SetAgentGroupMaintenance(ctx, req.token, req.id, req.maintenance())
+		if err != nil {
+			return nil, err
+		}
+		return toAgentGroupMaintenanceRes(ag), nil
+	}
+}
+
+func clearAgentGroupMaintenanceEndpoint(svc fleet.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ag, err := svc.ClearAgentGroupMaintenance(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toAgentGroupMaintenanceRes(ag), nil
+	}
+}
+
+func toAgentMaintenanceRes(ag fleet.Agent) agentRes {
+	return agentRes{
+		ID:            ag.MFThingID,
+		Name:          ag.Name.String(),
+		ChannelID:     ag.MFChannelID,
+		AgentTags:     ag.AgentTags,
+		OrbTags:       *ag.OrbTags,
+		TsCreated:     ag.Created,
+		AgentMetadata: ag.AgentMetadata,
+		State:         ag.State.String(),
+		LastHBData:    ag.LastHBData,
+		TsLastHB:      ag.LastHB,
+		Maintenance:   toMaintenanceRes(ag.Maintenance),
+		InMaintenance: ag.InMaintenance,
+	}
+}
+
+func toAgentGroupMaintenanceRes(ag fleet.AgentGroup) agentGroupRes {
+	return agentGroupRes{
+		ID:             ag.ID,
+		Name:           ag.Name.String(),
+		Description:    *ag.Description,
+		Tags:           *ag.Tags,
+		TsCreated:      ag.Created,
+		MatchingAgents: ag.MatchingAgents,
+		Maintenance:    toMaintenanceRes(ag.Maintenance),
+	}
+}
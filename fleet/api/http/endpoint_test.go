@@ -29,6 +29,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -40,6 +41,8 @@ const (
 	token             = "token"
 	invalidToken      = "invalid"
 	email             = "user@example.com"
+	serviceKey        = "service-key"
+	serviceEmail      = "orb@example.com"
 	validJson         = "{\n	\"name\": \"eu-agents\", \n	\"tags\": {\n		\"region\": \"eu\", \n		\"node_type\": \"dns\"\n	}, \n	\"description\": \"An example agent group representing european dns nodes\", \n	\"validate_only\": false \n}"
 	conflictValidJson = "{\n	\"name\": \"eu-agents-conflict\", \n	\"tags\": {\n		\"region\": \"eu\", \n		\"node_type\": \"dns\"\n	}, \n	\"description\": \"An example agent group representing european dns nodes\", \n	\"validate_only\": false \n}"
 	invalidJson       = "{"
@@ -142,7 +145,7 @@ func newService(auth mainflux.AuthServiceClient, url string) fleet.Service {
 	mfsdk := mfsdk.NewSDK(config)
 	pktvisor.Register(auth, agentRepo)
 	aDone := make(chan bool)
-	return fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, agentComms, mfsdk, aDone)
+	return fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), agentComms, mfsdk, fleet.NewThingKeyService(url), serviceKey, aDone)
 }
 
 func newServer(svc fleet.Service) *httptest.Server {
@@ -863,6 +866,590 @@ func TestViewAgent(t *testing.T) {
 	}
 }
 
+func TestPreviewAgentPolicy(t *testing.T) {
+	cli := newClientServer(t)
+
+	ag, err := createAgent(t, "my-agent-preview", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id       string
+		policyID string
+		auth     string
+		status   int
+	}{
+		"preview a policy for an existing agent": {
+			id:       ag.MFThingID,
+			policyID: wrongID,
+			auth:     token,
+			status:   http.StatusOK,
+		},
+		"preview a policy for a non-existing agent": {
+			id:       wrongID,
+			policyID: wrongID,
+			auth:     token,
+			status:   http.StatusNotFound,
+		},
+		"preview a policy with a invalid token": {
+			id:       ag.MFThingID,
+			policyID: wrongID,
+			auth:     invalidToken,
+			status:   http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/agents/%s/policies/%s/preview", cli.server.URL, tc.id, tc.policyID),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestTestAgentPolicy(t *testing.T) {
+	cli := newClientServer(t)
+
+	ag, err := createAgent(t, "my-agent-policy-test", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	validJson := toJSON(map[string]string{"policy_id": wrongID})
+
+	cases := map[string]struct {
+		id          string
+		req         string
+		contentType string
+		auth        string
+		status      int
+	}{
+		"test a policy on an agent that is not online": {
+			id:          ag.MFThingID,
+			req:         validJson,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusConflict,
+		},
+		"test a policy on a non-existing agent": {
+			id:          wrongID,
+			req:         validJson,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusNotFound,
+		},
+		"test a policy with a invalid token": {
+			id:          ag.MFThingID,
+			req:         validJson,
+			contentType: contentType,
+			auth:        invalidToken,
+			status:      http.StatusUnauthorized,
+		},
+		"test a policy without policy id": {
+			id:          ag.MFThingID,
+			req:         "{}",
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		"test a policy with an invalid content type": {
+			id:          ag.MFThingID,
+			req:         validJson,
+			contentType: "application/text",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client:      cli.server.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/agents/%s/rpc/policy_test", cli.server.URL, tc.id),
+				contentType: tc.contentType,
+				token:       fmt.Sprintf("Bearer %s", tc.auth),
+				body:        strings.NewReader(tc.req),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestRotateAgentCredentials(t *testing.T) {
+	cli := newClientServer(t)
+
+	ag, err := createAgent(t, "my-agent-credentials", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id     string
+		auth   string
+		status int
+	}{
+		"rotate the credentials of an agent that is not online": {
+			id:     ag.MFThingID,
+			auth:   token,
+			status: http.StatusConflict,
+		},
+		"rotate the credentials of a non-existing agent": {
+			id:     wrongID,
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"rotate the credentials with a invalid token": {
+			id:     ag.MFThingID,
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodPost,
+				url:    fmt.Sprintf("%s/agents/%s/rpc/rotate_credentials", cli.server.URL, tc.id),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestRequestAgentDiagnostics(t *testing.T) {
+	cli := newClientServer(t)
+
+	ag, err := createAgent(t, "my-agent-diagnostics", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id     string
+		auth   string
+		status int
+	}{
+		"request the diagnostics of an agent that is not online": {
+			id:     ag.MFThingID,
+			auth:   token,
+			status: http.StatusConflict,
+		},
+		"request the diagnostics of a non-existing agent": {
+			id:     wrongID,
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"request the diagnostics with a invalid token": {
+			id:     ag.MFThingID,
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodPost,
+				url:    fmt.Sprintf("%s/agents/%s/rpc/diagnostics", cli.server.URL, tc.id),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestViewAgentDiagnostics(t *testing.T) {
+	cli := newClientServer(t)
+
+	ag, err := createAgent(t, "my-agent-diagnostics-view", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id       string
+		bundleID string
+		auth     string
+		status   int
+	}{
+		"view a non-existing diagnostic bundle": {
+			id:       ag.MFThingID,
+			bundleID: wrongID,
+			auth:     token,
+			status:   http.StatusNotFound,
+		},
+		"view a diagnostic bundle with a invalid token": {
+			id:       ag.MFThingID,
+			bundleID: wrongID,
+			auth:     invalidToken,
+			status:   http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/agents/%s/diagnostics/%s", cli.server.URL, tc.id, tc.bundleID),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestSetAgentMaintenance(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	ag, err := createAgent(t, "my-agent-maintenance", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id          string
+		req         string
+		contentType string
+		auth        string
+		status      int
+	}{
+		"set a maintenance window of an agent": {
+			id:          ag.MFThingID,
+			req:         `{"reason":"router upgrade"}`,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusOK,
+		},
+		"set a maintenance window ending before it starts": {
+			id:          ag.MFThingID,
+			req:         `{"start":"2030-01-02T00:00:00Z","end":"2030-01-01T00:00:00Z"}`,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		"set a maintenance window with an invalid json": {
+			id:          ag.MFThingID,
+			req:         invalidJson,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		"set a maintenance window of a non-existing agent": {
+			id:          wrongID,
+			req:         "{}",
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusNotFound,
+		},
+		"set a maintenance window with invalid token": {
+			id:          ag.MFThingID,
+			req:         "{}",
+			contentType: contentType,
+			auth:        invalidToken,
+			status:      http.StatusUnauthorized,
+		},
+		"set a maintenance window without content type": {
+			id:          ag.MFThingID,
+			req:         "{}",
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client:      cli.server.Client(),
+				method:      http.MethodPut,
+				url:         fmt.Sprintf("%s/agents/%s/maintenance", cli.server.URL, tc.id),
+				contentType: tc.contentType,
+				token:       fmt.Sprintf("Bearer %s", tc.auth),
+				body:        strings.NewReader(tc.req),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestClearAgentGroupMaintenance(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	ag, err := createAgentGroup(t, "my-group-maintenance", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = cli.service.SetAgentGroupMaintenance(context.Background(), token, ag.ID, fleet.Maintenance{Reason: "change window"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id     string
+		auth   string
+		status int
+	}{
+		"clear the maintenance window of a group": {
+			id:     ag.ID,
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"clear the maintenance window of a non-existing group": {
+			id:     wrongID,
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"clear the maintenance window with invalid token": {
+			id:     ag.ID,
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodDelete,
+				url:    fmt.Sprintf("%s/agent_groups/%s/maintenance", cli.server.URL, tc.id),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestListAgentRPCs(t *testing.T) {
+	cli := newClientServer(t)
+
+	ag, err := createAgent(t, "my-agent-rpcs", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id     string
+		auth   string
+		query  string
+		status int
+	}{
+		"list the RPCs of an existing agent": {
+			id:     ag.MFThingID,
+			auth:   token,
+			query:  "",
+			status: http.StatusOK,
+		},
+		"list the RPCs of an existing agent with a limit": {
+			id:     ag.MFThingID,
+			auth:   token,
+			query:  "?limit=10",
+			status: http.StatusOK,
+		},
+		"list the RPCs of an existing agent above the maximum limit": {
+			id:     ag.MFThingID,
+			auth:   token,
+			query:  fmt.Sprintf("?limit=%d", fleet.MaxAgentRPCsLimit+1),
+			status: http.StatusBadRequest,
+		},
+		"list the RPCs of a non-existing agent": {
+			id:     wrongID,
+			auth:   token,
+			query:  "",
+			status: http.StatusNotFound,
+		},
+		"list the RPCs with a invalid token": {
+			id:     ag.MFThingID,
+			auth:   invalidToken,
+			query:  "",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/agents/%s/rpc%s", cli.server.URL, tc.id, tc.query),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestRotateAgentGroupCredentials(t *testing.T) {
+	cli := newClientServer(t)
+
+	_, err := createAgent(t, "my-agent-group-credentials", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ag, err := createAgentGroup(t, "my-group-credentials", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id      string
+		auth    string
+		status  int
+		rotated int
+	}{
+		"rotate the credentials of an agent group": {
+			id:      ag.ID,
+			auth:    token,
+			status:  http.StatusOK,
+			rotated: 0,
+		},
+		"rotate the credentials of a non-existing agent group": {
+			id:     wrongID,
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"rotate the credentials of an agent group with a invalid token": {
+			id:     ag.ID,
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodPost,
+				url:    fmt.Sprintf("%s/agent_groups/%s/rpc/rotate_credentials", cli.server.URL, tc.id),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+			if tc.status != http.StatusOK {
+				return
+			}
+			var body agentsCredentialsRes
+			require.Nil(t, json.NewDecoder(res.Body).Decode(&body), fmt.Sprintf("%s: unexpected error decoding body", desc))
+			assert.Equal(t, tc.rotated, body.Rotated, fmt.Sprintf("%s: expected %d rotated got %d", desc, tc.rotated, body.Rotated))
+			assert.Len(t, body.Agents, 1, fmt.Sprintf("%s: expected an outcome per agent", desc))
+		})
+	}
+}
+
+func TestViewPolicyRollout(t *testing.T) {
+	cli := newClientServer(t)
+
+	_, err := createAgent(t, "my-agent-rollout", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	rollout, err := cli.service.StartPolicyRolloutInternal(context.Background(), email, wrongID, 2, []string{wrongID}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		url    string
+		auth   string
+		status int
+	}{
+		"view an existing policy rollout": {
+			url:    fmt.Sprintf("%s/policy_rollouts/%s", cli.server.URL, rollout.ID),
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"view a non-existing policy rollout": {
+			url:    fmt.Sprintf("%s/policy_rollouts/%s", cli.server.URL, wrongID),
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"view a policy rollout with a invalid token": {
+			url:    fmt.Sprintf("%s/policy_rollouts/%s", cli.server.URL, rollout.ID),
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+		"list policy rollouts of a policy": {
+			url:    fmt.Sprintf("%s/policy_rollouts?policy_id=%s", cli.server.URL, wrongID),
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"list policy rollouts with a invalid token": {
+			url:    fmt.Sprintf("%s/policy_rollouts", cli.server.URL),
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    tc.url,
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestRollbackPolicyRollout(t *testing.T) {
+	cli := newClientServer(t)
+
+	_, err := createAgent(t, "my-agent-rollout", &cli)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	superseded, err := cli.service.StartPolicyRolloutInternal(context.Background(), email, wrongID, 2, []string{wrongID}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	rollout, err := cli.service.StartPolicyRolloutInternal(context.Background(), email, wrongID, 3, []string{wrongID}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id     string
+		auth   string
+		status int
+	}{
+		"rollback an in progress policy rollout": {
+			id:     rollout.ID,
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"rollback a superseded policy rollout": {
+			id:     superseded.ID,
+			auth:   token,
+			status: http.StatusConflict,
+		},
+		"rollback a non-existing policy rollout": {
+			id:     wrongID,
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"rollback a policy rollout with a invalid token": {
+			id:     rollout.ID,
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodPost,
+				url:    fmt.Sprintf("%s/policy_rollouts/%s/rollback", cli.server.URL, tc.id),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected erro %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
 func TestViewAgentMatchingGroups(t *testing.T) {
 	cli := newClientServer(t)
 
@@ -1464,6 +2051,308 @@ func TestCreateAgent(t *testing.T) {
 
 }
 
+func TestCreateEnrollmentToken(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	cases := map[string]struct {
+		req    string
+		auth   string
+		status int
+	}{
+		"create a valid enrollment token": {
+			req:    `{"name":"edge","orb_tags":{"site":"ams1"},"max_uses":10}`,
+			auth:   token,
+			status: http.StatusCreated,
+		},
+		"create an enrollment token with defaults": {
+			req:    "{}",
+			auth:   token,
+			status: http.StatusCreated,
+		},
+		"create an enrollment token with invalid token": {
+			req:    "{}",
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+		"create an enrollment token expired already": {
+			req:    `{"expires_at":"2000-01-01T00:00:00Z"}`,
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"create an enrollment token with negative uses": {
+			req:    `{"max_uses":-1}`,
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"create an enrollment token with an invalid json": {
+			req:    invalidJson,
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client:      cli.server.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/agents/enrollment_tokens", cli.server.URL),
+				contentType: contentType,
+				token:       fmt.Sprintf("Bearer %s", tc.auth),
+				body:        strings.NewReader(tc.req),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+			if tc.status != http.StatusCreated {
+				return
+			}
+			var body struct {
+				Token   string `json:"token"`
+				MaxUses int    `json:"max_uses"`
+			}
+			require.Nil(t, json.NewDecoder(res.Body).Decode(&body), "unexpected error decoding response")
+			assert.NotEmpty(t, body.Token, fmt.Sprintf("%s: expected the token secret in the response", desc))
+			assert.Greater(t, body.MaxUses, 0, fmt.Sprintf("%s: expected a usage limit", desc))
+		})
+	}
+}
+
+func TestViewFleetSummary(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	cases := map[string]struct {
+		query  string
+		auth   string
+		status int
+	}{
+		"view the fleet summary": {
+			query:  "",
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"view the fleet summary of the agents matching tags": {
+			query:  "?tags=" + url.QueryEscape(`{"region":"eu"}`),
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"view the fleet summary with invalid tags": {
+			query:  "?tags=" + url.QueryEscape(`"region"`),
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"view the fleet summary with invalid token": {
+			query:  "",
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+		"view the fleet summary with empty token": {
+			query:  "",
+			auth:   "",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/fleet/summary%s", cli.server.URL, tc.query),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestSetAgentVersionPolicy(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	cases := map[string]struct {
+		req         string
+		contentType string
+		auth        string
+		status      int
+	}{
+		"set a valid version policy": {
+			req:         `{"agent":{"minimum":"0.20.0","recommended":"0.22.0"},"backends":{"pktvisor":{"minimum":"4.2.0"}},"enforcement":"warn"}`,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusOK,
+		},
+		"set a version policy with an invalid version": {
+			req:         `{"agent":{"minimum":"latest"}}`,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		"set a version policy with an unknown enforcement": {
+			req:         `{"enforcement":"ignore"}`,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		"set a version policy with an invalid json": {
+			req:         invalidJson,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		"set a version policy with invalid token": {
+			req:         "{}",
+			contentType: contentType,
+			auth:        invalidToken,
+			status:      http.StatusUnauthorized,
+		},
+		"set a version policy without content type": {
+			req:         "{}",
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client:      cli.server.Client(),
+				method:      http.MethodPut,
+				url:         fmt.Sprintf("%s/agents/version_policy", cli.server.URL),
+				contentType: tc.contentType,
+				token:       fmt.Sprintf("Bearer %s", tc.auth),
+				body:        strings.NewReader(tc.req),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestAgentComplianceReport(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	_, err := cli.service.SetAgentVersionPolicy(context.Background(), token, fleet.AgentVersionPolicy{
+		Agent: fleet.VersionRequirement{Minimum: "0.20.0"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		auth   string
+		status int
+	}{
+		"view the compliance report": {
+			auth:   token,
+			status: http.StatusOK,
+		},
+		"view the compliance report with invalid token": {
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+		"view the compliance report with empty token": {
+			auth:   "",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/agents/compliance", cli.server.URL),
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+			if tc.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Policy struct {
+					Agent fleet.VersionRequirement `json:"agent"`
+				} `json:"policy"`
+				Total int `json:"total"`
+			}
+			require.Nil(t, json.NewDecoder(res.Body).Decode(&body), "unexpected error decoding response")
+			assert.Equal(t, "0.20.0", body.Policy.Agent.Minimum, fmt.Sprintf("%s: expected the owner policy in the report", desc))
+		})
+	}
+}
+
+func TestEnrollAgent(t *testing.T) {
+	cli := newClientServer(t)
+	defer cli.server.Close()
+
+	et, err := cli.service.CreateEnrollmentToken(context.Background(), token, fleet.EnrollmentToken{OrbTags: types.Tags{"site": "ams1"}, MaxUses: 5})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	used, err := cli.service.CreateEnrollmentToken(context.Background(), token, fleet.EnrollmentToken{MaxUses: 1})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	usedName, err := types.NewIdentifier("used-agent")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = cli.service.EnrollAgent(context.Background(), used.Token, fleet.Agent{Name: usedName})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		req    string
+		auth   string
+		status int
+	}{
+		"enroll an agent": {
+			req:    `{"name":"edge-agent","agent_tags":{"role":"dns"}}`,
+			auth:   et.Token,
+			status: http.StatusCreated,
+		},
+		"enroll an agent with a user token": {
+			req:    `{"name":"user-agent"}`,
+			auth:   token,
+			status: http.StatusUnauthorized,
+		},
+		"enroll an agent with a used up token": {
+			req:    `{"name":"other-agent"}`,
+			auth:   used.Token,
+			status: http.StatusUnauthorized,
+		},
+		"enroll an agent without a name": {
+			req:    "{}",
+			auth:   et.Token,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client:      cli.server.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/agents/enroll", cli.server.URL),
+				contentType: contentType,
+				token:       fmt.Sprintf("Bearer %s", tc.auth),
+				body:        strings.NewReader(tc.req),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+			if tc.status != http.StatusCreated {
+				return
+			}
+			var body agentRes
+			require.Nil(t, json.NewDecoder(res.Body).Decode(&body), "unexpected error decoding response")
+			assert.NotEmpty(t, body.Key, fmt.Sprintf("%s: expected agent credentials", desc))
+			assert.NotEmpty(t, body.ChannelID, fmt.Sprintf("%s: expected agent credentials", desc))
+			assert.Equal(t, "ams1", body.OrbTags["site"], fmt.Sprintf("%s: expected the enrollment token orb tags", desc))
+		})
+	}
+}
+
 func TestDeleteAgent(t *testing.T) {
 
 	cli := newClientServer(t)
@@ -1705,7 +2594,7 @@ func createAgent(t *testing.T, name string, cli *clientServer) (fleet.Agent, err
 
 func newClientServer(t *testing.T) clientServer {
 	t.Helper()
-	users := flmocks.NewAuthService(map[string]string{token: email})
+	users := flmocks.NewAuthService(map[string]string{token: email, serviceKey: serviceEmail})
 
 	thingsServer := newThingsServer(newThingsService(users))
 	fleetService := newService(users, thingsServer.URL)
@@ -1755,6 +2644,17 @@ type agentsPageRes struct {
 	Agents []agentRes `json:"agents"`
 }
 
+type agentCredentialsRes struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+type agentsCredentialsRes struct {
+	Rotated int                   `json:"rotated"`
+	Agents  []agentCredentialsRes `json:"agents"`
+}
+
 type updateAgentGroupReq struct {
 	token       string
 	Name        *string     `json:"name,omitempty"`
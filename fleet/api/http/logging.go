@@ -7,6 +7,7 @@ package http
 import (
 	"context"
 	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/types"
 	"go.uber.org/zap"
 	"time"
 )
@@ -46,6 +47,230 @@ func (l loggingMiddleware) ResetAgent(ct context.Context, token string, agentID
 	return l.svc.ResetAgent(ct, token, agentID)
 }
 
+func (l loggingMiddleware) PreviewAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (_ fleet.AgentPolicyRPCPayload, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: preview_agent_policy",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: preview_agent_policy",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.PreviewAgentPolicy(ctx, token, agentID, policyID)
+}
+
+func (l loggingMiddleware) TestAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (_ fleet.PolicyTestResultRPCPayload, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: test_agent_policy",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: test_agent_policy",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.TestAgentPolicy(ctx, token, agentID, policyID)
+}
+
+func (l loggingMiddleware) ListAgentRPCs(ctx context.Context, token string, agentID string, limit uint64) (_ []fleet.AgentRPC, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: list_agent_rpcs",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: list_agent_rpcs",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ListAgentRPCs(ctx, token, agentID, limit)
+}
+
+func (l loggingMiddleware) RequestAgentDiagnostics(ctx context.Context, token string, agentID string) (_ fleet.DiagnosticsBundle, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: request_agent_diagnostics",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: request_agent_diagnostics",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RequestAgentDiagnostics(ctx, token, agentID)
+}
+
+func (l loggingMiddleware) ViewAgentDiagnostics(ctx context.Context, token string, agentID string, bundleID string) (_ fleet.DiagnosticsBundle, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_agent_diagnostics",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_agent_diagnostics",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewAgentDiagnostics(ctx, token, agentID, bundleID)
+}
+
+func (l loggingMiddleware) SetAgentMaintenance(ctx context.Context, token string, agentID string, m fleet.Maintenance) (_ fleet.Agent, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: set_agent_maintenance",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: set_agent_maintenance",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.SetAgentMaintenance(ctx, token, agentID, m)
+}
+
+func (l loggingMiddleware) ClearAgentMaintenance(ctx context.Context, token string, agentID string) (_ fleet.Agent, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: clear_agent_maintenance",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: clear_agent_maintenance",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ClearAgentMaintenance(ctx, token, agentID)
+}
+
+func (l loggingMiddleware) SetAgentGroupMaintenance(ctx context.Context, token string, groupID string, m fleet.Maintenance) (_ fleet.AgentGroup, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: set_agent_group_maintenance",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: set_agent_group_maintenance",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.SetAgentGroupMaintenance(ctx, token, groupID, m)
+}
+
+func (l loggingMiddleware) ClearAgentGroupMaintenance(ctx context.Context, token string, groupID string) (_ fleet.AgentGroup, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: clear_agent_group_maintenance",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: clear_agent_group_maintenance",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ClearAgentGroupMaintenance(ctx, token, groupID)
+}
+
+func (l loggingMiddleware) CheckAgentMaintenance(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: check_agent_maintenance",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: check_agent_maintenance",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.CheckAgentMaintenance(ctx)
+}
+
+func (l loggingMiddleware) ViewFleetSummary(ctx context.Context, token string, tags types.Tags) (_ fleet.FleetSummary, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_fleet_summary",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_fleet_summary",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewFleetSummary(ctx, token, tags)
+}
+
+func (l loggingMiddleware) SetAgentVersionPolicy(ctx context.Context, token string, p fleet.AgentVersionPolicy) (_ fleet.AgentVersionPolicy, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: set_agent_version_policy",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: set_agent_version_policy",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.SetAgentVersionPolicy(ctx, token, p)
+}
+
+func (l loggingMiddleware) ViewAgentVersionPolicy(ctx context.Context, token string) (_ fleet.AgentVersionPolicy, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_agent_version_policy",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_agent_version_policy",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewAgentVersionPolicy(ctx, token)
+}
+
+func (l loggingMiddleware) AgentComplianceReport(ctx context.Context, token string) (_ fleet.ComplianceReport, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: agent_compliance_report",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: agent_compliance_report",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.AgentComplianceReport(ctx, token)
+}
+
+func (l loggingMiddleware) RotateAgentCredentials(ctx context.Context, token string, agentID string) (_ fleet.AgentCredentials, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: rotate_agent_credentials",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: rotate_agent_credentials",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RotateAgentCredentials(ctx, token, agentID)
+}
+
+func (l loggingMiddleware) RotateAgentGroupCredentials(ctx context.Context, token string, groupID string) (_ []fleet.AgentCredentials, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: rotate_agent_group_credentials",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: rotate_agent_group_credentials",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RotateAgentGroupCredentials(ctx, token, groupID)
+}
+
 func (l loggingMiddleware) ViewAgentInfoByChannelIDInternal(ctx context.Context, channelID string) (_ fleet.Agent, err error) {
 	defer func(begin time.Time) {
 		if err != nil {
@@ -322,6 +547,160 @@ func (l loggingMiddleware) GetPolicyState(ctx context.Context, agent fleet.Agent
 	return l.svc.GetPolicyState(ctx, agent)
 }
 
+func (l loggingMiddleware) StartPolicyRolloutInternal(ctx context.Context, ownerID string, policyID string, version int32, groupIDs []string, strategy fleet.RolloutStrategy) (_ fleet.PolicyRollout, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: start_policy_rollout_internal",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: start_policy_rollout_internal",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.StartPolicyRolloutInternal(ctx, ownerID, policyID, version, groupIDs, strategy)
+}
+
+func (l loggingMiddleware) CheckPolicyRollouts(ctx context.Context) (_ []fleet.PolicyRollout, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: check_policy_rollouts",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: check_policy_rollouts",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.CheckPolicyRollouts(ctx)
+}
+
+func (l loggingMiddleware) ViewPolicyRollout(ctx context.Context, token string, id string) (_ fleet.PolicyRollout, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_policy_rollout",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_policy_rollout",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewPolicyRollout(ctx, token, id)
+}
+
+func (l loggingMiddleware) ListPolicyRollouts(ctx context.Context, token string, policyID string) (_ []fleet.PolicyRollout, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: list_policy_rollouts",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: list_policy_rollouts",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ListPolicyRollouts(ctx, token, policyID)
+}
+
+func (l loggingMiddleware) RollbackPolicyRollout(ctx context.Context, token string, id string) (_ fleet.PolicyRollout, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: rollback_policy_rollout",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: rollback_policy_rollout",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RollbackPolicyRollout(ctx, token, id)
+}
+
+func (l loggingMiddleware) ResumePolicyRollout(ctx context.Context, token string, id string) (_ fleet.PolicyRollout, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: resume_policy_rollout",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: resume_policy_rollout",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ResumePolicyRollout(ctx, token, id)
+}
+
+func (l loggingMiddleware) CreateEnrollmentToken(ctx context.Context, token string, et fleet.EnrollmentToken) (_ fleet.EnrollmentToken, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: create_enrollment_token",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: create_enrollment_token",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.CreateEnrollmentToken(ctx, token, et)
+}
+
+func (l loggingMiddleware) ListEnrollmentTokens(ctx context.Context, token string) (_ []fleet.EnrollmentToken, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: list_enrollment_tokens",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: list_enrollment_tokens",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ListEnrollmentTokens(ctx, token)
+}
+
+func (l loggingMiddleware) RevokeEnrollmentToken(ctx context.Context, token string, id string) (err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: revoke_enrollment_token",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: revoke_enrollment_token",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RevokeEnrollmentToken(ctx, token, id)
+}
+
+func (l loggingMiddleware) EnrollAgent(ctx context.Context, enrollmentToken string, a fleet.Agent) (_ fleet.Agent, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: enroll_agent",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: enroll_agent",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.EnrollAgent(ctx, enrollmentToken, a)
+}
+
+func (l loggingMiddleware) ViewPolicyStatusInternal(ctx context.Context, ownerID string, policyID string, version int32, targets []fleet.PolicyStatusTarget) (_ fleet.PolicyStatus, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_policy_status_internal",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_policy_status_internal",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewPolicyStatusInternal(ctx, ownerID, policyID, version, targets)
+}
+
 func NewLoggingMiddleware(svc fleet.Service, logger *zap.Logger) fleet.Service {
 	return &loggingMiddleware{logger, svc}
 }
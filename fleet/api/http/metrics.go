@@ -10,6 +10,7 @@ import (
 	"github.com/mainflux/mainflux"
 	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
 	"time"
 )
 
@@ -61,6 +62,353 @@ func (m metricsMiddleware) ResetAgent(ct context.Context, token string, agentID
 	return m.svc.ResetAgent(ct, token, agentID)
 }
 
+func (m metricsMiddleware) PreviewAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (fleet.AgentPolicyRPCPayload, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.AgentPolicyRPCPayload{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "previewAgentPolicy",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.PreviewAgentPolicy(ctx, token, agentID, policyID)
+}
+
+func (m metricsMiddleware) TestAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (fleet.PolicyTestResultRPCPayload, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.PolicyTestResultRPCPayload{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "testAgentPolicy",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.TestAgentPolicy(ctx, token, agentID, policyID)
+}
+
+func (m metricsMiddleware) ListAgentRPCs(ctx context.Context, token string, agentID string, limit uint64) ([]fleet.AgentRPC, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "listAgentRPCs",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ListAgentRPCs(ctx, token, agentID, limit)
+}
+
+func (m metricsMiddleware) RequestAgentDiagnostics(ctx context.Context, token string, agentID string) (fleet.DiagnosticsBundle, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.DiagnosticsBundle{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "requestAgentDiagnostics",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RequestAgentDiagnostics(ctx, token, agentID)
+}
+
+func (m metricsMiddleware) ViewAgentDiagnostics(ctx context.Context, token string, agentID string, bundleID string) (fleet.DiagnosticsBundle, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.DiagnosticsBundle{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewAgentDiagnostics",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewAgentDiagnostics(ctx, token, agentID, bundleID)
+}
+
+func (m metricsMiddleware) SetAgentMaintenance(ctx context.Context, token string, agentID string, mt fleet.Maintenance) (fleet.Agent, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.Agent{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "setAgentMaintenance",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.SetAgentMaintenance(ctx, token, agentID, mt)
+}
+
+func (m metricsMiddleware) ClearAgentMaintenance(ctx context.Context, token string, agentID string) (fleet.Agent, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.Agent{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "clearAgentMaintenance",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ClearAgentMaintenance(ctx, token, agentID)
+}
+
+func (m metricsMiddleware) SetAgentGroupMaintenance(ctx context.Context, token string, groupID string, mt fleet.Maintenance) (fleet.AgentGroup, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.AgentGroup{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "setAgentGroupMaintenance",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", groupID,
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.SetAgentGroupMaintenance(ctx, token, groupID, mt)
+}
+
+func (m metricsMiddleware) ClearAgentGroupMaintenance(ctx context.Context, token string, groupID string) (fleet.AgentGroup, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.AgentGroup{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "clearAgentGroupMaintenance",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", groupID,
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ClearAgentGroupMaintenance(ctx, token, groupID)
+}
+
+func (m metricsMiddleware) CheckAgentMaintenance(ctx context.Context) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "checkAgentMaintenance",
+			"owner_id", "",
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.CheckAgentMaintenance(ctx)
+}
+
+func (m metricsMiddleware) ViewFleetSummary(ctx context.Context, token string, tags types.Tags) (fleet.FleetSummary, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.FleetSummary{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewFleetSummary",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewFleetSummary(ctx, token, tags)
+}
+
+func (m metricsMiddleware) SetAgentVersionPolicy(ctx context.Context, token string, p fleet.AgentVersionPolicy) (fleet.AgentVersionPolicy, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.AgentVersionPolicy{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "setAgentVersionPolicy",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.SetAgentVersionPolicy(ctx, token, p)
+}
+
+func (m metricsMiddleware) ViewAgentVersionPolicy(ctx context.Context, token string) (fleet.AgentVersionPolicy, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.AgentVersionPolicy{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewAgentVersionPolicy",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewAgentVersionPolicy(ctx, token)
+}
+
+func (m metricsMiddleware) AgentComplianceReport(ctx context.Context, token string) (fleet.ComplianceReport, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.ComplianceReport{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "agentComplianceReport",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.AgentComplianceReport(ctx, token)
+}
+
+func (m metricsMiddleware) RotateAgentCredentials(ctx context.Context, token string, agentID string) (fleet.AgentCredentials, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.AgentCredentials{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "rotateAgentCredentials",
+			"owner_id", ownerID,
+			"agent_id", agentID,
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RotateAgentCredentials(ctx, token, agentID)
+}
+
+func (m metricsMiddleware) RotateAgentGroupCredentials(ctx context.Context, token string, groupID string) ([]fleet.AgentCredentials, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "rotateAgentGroupCredentials",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", groupID,
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RotateAgentGroupCredentials(ctx, token, groupID)
+}
+
 func (m metricsMiddleware) ViewAgentInfoByChannelIDInternal(ctx context.Context, channelID string) (agent fleet.Agent, _ error) {
 	defer func(begin time.Time) {
 		labels := []string{
@@ -427,6 +775,228 @@ func (m metricsMiddleware) identify(token string) (string, error) {
 	return res.GetId(), nil
 }
 
+func (m metricsMiddleware) CreateEnrollmentToken(ctx context.Context, token string, et fleet.EnrollmentToken) (fleet.EnrollmentToken, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.EnrollmentToken{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "createEnrollmentToken",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.CreateEnrollmentToken(ctx, token, et)
+}
+
+func (m metricsMiddleware) ListEnrollmentTokens(ctx context.Context, token string) ([]fleet.EnrollmentToken, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "listEnrollmentTokens",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ListEnrollmentTokens(ctx, token)
+}
+
+func (m metricsMiddleware) RevokeEnrollmentToken(ctx context.Context, token string, id string) error {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "revokeEnrollmentToken",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RevokeEnrollmentToken(ctx, token, id)
+}
+
+func (m metricsMiddleware) EnrollAgent(ctx context.Context, enrollmentToken string, a fleet.Agent) (fleet.Agent, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "enrollAgent",
+			"owner_id", "",
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.EnrollAgent(ctx, enrollmentToken, a)
+}
+
+func (m metricsMiddleware) StartPolicyRolloutInternal(ctx context.Context, ownerID string, policyID string, version int32, groupIDs []string, strategy fleet.RolloutStrategy) (fleet.PolicyRollout, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "startPolicyRolloutInternal",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.StartPolicyRolloutInternal(ctx, ownerID, policyID, version, groupIDs, strategy)
+}
+
+func (m metricsMiddleware) CheckPolicyRollouts(ctx context.Context) ([]fleet.PolicyRollout, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "checkPolicyRollouts",
+			"owner_id", "",
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.CheckPolicyRollouts(ctx)
+}
+
+func (m metricsMiddleware) ViewPolicyRollout(ctx context.Context, token string, id string) (fleet.PolicyRollout, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.PolicyRollout{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewPolicyRollout",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewPolicyRollout(ctx, token, id)
+}
+
+func (m metricsMiddleware) ListPolicyRollouts(ctx context.Context, token string, policyID string) ([]fleet.PolicyRollout, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "listPolicyRollouts",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ListPolicyRollouts(ctx, token, policyID)
+}
+
+func (m metricsMiddleware) RollbackPolicyRollout(ctx context.Context, token string, id string) (fleet.PolicyRollout, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.PolicyRollout{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "rollbackPolicyRollout",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RollbackPolicyRollout(ctx, token, id)
+}
+
+func (m metricsMiddleware) ResumePolicyRollout(ctx context.Context, token string, id string) (fleet.PolicyRollout, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return fleet.PolicyRollout{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "resumePolicyRollout",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ResumePolicyRollout(ctx, token, id)
+}
+
+func (m metricsMiddleware) ViewPolicyStatusInternal(ctx context.Context, ownerID string, policyID string, version int32, targets []fleet.PolicyStatusTarget) (fleet.PolicyStatus, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewPolicyStatusInternal",
+			"owner_id", ownerID,
+			"agent_id", "",
+			"group_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewPolicyStatusInternal(ctx, ownerID, policyID, version, targets)
+}
+
 // MetricsMiddleware instruments core service by tracking request count and latency.
 func MetricsMiddleware(auth mainflux.AuthServiceClient, svc fleet.Service, counter metrics.Counter, latency metrics.Histogram) fleet.Service {
 	return &metricsMiddleware{
@@ -12,6 +12,7 @@ import (
 	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/pkg/types"
+	"time"
 )
 
 const (
@@ -187,3 +188,194 @@ func (req *listAgentBackendsReq) validate() error {
 	}
 	return nil
 }
+
+type previewAgentPolicyReq struct {
+	token    string
+	id       string
+	policyID string
+}
+
+func (req previewAgentPolicyReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.id == "" || req.policyID == "" {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
+
+type testAgentPolicyReq struct {
+	token    string
+	id       string
+	PolicyID string `json:"policy_id"`
+}
+
+func (req testAgentPolicyReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.id == "" || req.PolicyID == "" {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
+
+type listPolicyRolloutsReq struct {
+	token    string
+	policyID string
+}
+
+func (req listPolicyRolloutsReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+type listAgentRPCsReq struct {
+	token string
+	id    string
+	limit uint64
+}
+
+func (req listAgentRPCsReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.id == "" {
+		return errors.ErrMalformedEntity
+	}
+	if req.limit == 0 || req.limit > fleet.MaxAgentRPCsLimit {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
+
+type viewAgentDiagnosticsReq struct {
+	token    string
+	id       string
+	bundleID string
+}
+
+func (req viewAgentDiagnosticsReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.id == "" || req.bundleID == "" {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
+
+type fleetSummaryReq struct {
+	token string
+	tags  types.Tags
+}
+
+func (req fleetSummaryReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+type addEnrollmentTokenReq struct {
+	token     string
+	Name      string     `json:"name,omitempty"`
+	OrbTags   types.Tags `json:"orb_tags,omitempty"`
+	MaxUses   int        `json:"max_uses,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at,omitempty"`
+}
+
+func (req addEnrollmentTokenReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if len(req.Name) > maxNameSize || req.MaxUses < 0 {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
+
+type listEnrollmentTokensReq struct {
+	token string
+}
+
+func (req listEnrollmentTokensReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+type setAgentVersionPolicyReq struct {
+	token       string
+	Agent       fleet.VersionRequirement            `json:"agent"`
+	Backends    map[string]fleet.VersionRequirement `json:"backends,omitempty"`
+	Enforcement string                              `json:"enforcement,omitempty"`
+}
+
+func (req setAgentVersionPolicyReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	for name := range req.Backends {
+		if name == "" {
+			return errors.ErrMalformedEntity
+		}
+	}
+	return nil
+}
+
+type setMaintenanceReq struct {
+	token  string
+	id     string
+	Reason string    `json:"reason,omitempty"`
+	Start  time.Time `json:"start,omitempty"`
+	End    time.Time `json:"end,omitempty"`
+}
+
+func (req setMaintenanceReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.id == "" {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
+
+func (req setMaintenanceReq) maintenance() fleet.Maintenance {
+	return fleet.Maintenance{Reason: req.Reason, Start: req.Start, End: req.End}
+}
+
+type viewAgentVersionPolicyReq struct {
+	token string
+}
+
+func (req viewAgentVersionPolicyReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+type enrollAgentReq struct {
+	enrollmentToken string
+	Name            string     `json:"name,omitempty"`
+	AgentTags       types.Tags `json:"agent_tags,omitempty"`
+}
+
+func (req enrollAgentReq) validate() error {
+	if req.enrollmentToken == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.Name == "" {
+		return errors.ErrMalformedEntity
+	}
+	if _, err := types.NewIdentifier(req.Name); err != nil {
+		return errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+	return nil
+}
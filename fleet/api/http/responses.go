@@ -5,6 +5,7 @@
 package http
 
 import (
+	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/types"
 	"net/http"
 	"time"
@@ -16,12 +17,13 @@ var (
 )
 
 type agentGroupRes struct {
-	ID             string         `json:"id"`
-	Name           string         `json:"name"`
-	Description    string         `json:"description,omitempty"`
-	Tags           types.Tags     `json:"tags"`
-	TsCreated      time.Time      `json:"ts_created,omitempty"`
-	MatchingAgents types.Metadata `json:"matching_agents,omitempty"`
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description,omitempty"`
+	Tags           types.Tags      `json:"tags"`
+	TsCreated      time.Time       `json:"ts_created,omitempty"`
+	MatchingAgents types.Metadata  `json:"matching_agents,omitempty"`
+	Maintenance    *maintenanceRes `json:"maintenance,omitempty"`
 	created        bool
 }
 
@@ -59,18 +61,20 @@ func (res agentGroupsPageRes) Empty() bool {
 }
 
 type agentRes struct {
-	ID            string         `json:"id"`
-	Name          string         `json:"name"`
-	State         string         `json:"state"`
-	Key           string         `json:"key,omitempty"`
-	ChannelID     string         `json:"channel_id,omitempty"`
-	AgentTags     types.Tags     `json:"agent_tags"`
-	OrbTags       types.Tags     `json:"orb_tags"`
-	AgentMetadata types.Metadata `json:"agent_metadata"`
-	LastHBData    types.Metadata `json:"last_hb_data"`
-	TsCreated     time.Time      `json:"ts_created"`
-	TsLastHB      time.Time      `json:"ts_last_hb"`
-	PolicyState   types.Metadata `json:"policy_state,omitempty"`
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	State         string          `json:"state"`
+	Key           string          `json:"key,omitempty"`
+	ChannelID     string          `json:"channel_id,omitempty"`
+	AgentTags     types.Tags      `json:"agent_tags"`
+	OrbTags       types.Tags      `json:"orb_tags"`
+	AgentMetadata types.Metadata  `json:"agent_metadata"`
+	LastHBData    types.Metadata  `json:"last_hb_data"`
+	TsCreated     time.Time       `json:"ts_created"`
+	TsLastHB      time.Time       `json:"ts_last_hb"`
+	PolicyState   types.Metadata  `json:"policy_state,omitempty"`
+	Maintenance   *maintenanceRes `json:"maintenance,omitempty"`
+	InMaintenance bool            `json:"in_maintenance"`
 	created       bool
 }
 
@@ -198,3 +202,369 @@ func (s matchingGroupsRes) Headers() map[string]string {
 func (s matchingGroupsRes) Empty() bool {
 	return false
 }
+
+type agentPolicyPreviewRes struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Backend string      `json:"backend"`
+	Format  string      `json:"format,omitempty"`
+	Version int32       `json:"version"`
+	Data    interface{} `json:"data"`
+}
+
+func (s agentPolicyPreviewRes) Code() int {
+	return http.StatusOK
+}
+
+func (s agentPolicyPreviewRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s agentPolicyPreviewRes) Empty() bool {
+	return false
+}
+
+type policyTestRes struct {
+	PolicyID string `json:"policy_id"`
+	Backend  string `json:"backend"`
+	Valid    bool   `json:"valid"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (s policyTestRes) Code() int {
+	return http.StatusOK
+}
+
+func (s policyTestRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s policyTestRes) Empty() bool {
+	return false
+}
+
+type agentCredentialsRes struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ChannelID string `json:"channel_id"`
+	Key       string `json:"key,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (s agentCredentialsRes) Code() int {
+	return http.StatusOK
+}
+
+func (s agentCredentialsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s agentCredentialsRes) Empty() bool {
+	return false
+}
+
+type agentsCredentialsRes struct {
+	Rotated int                   `json:"rotated"`
+	Agents  []agentCredentialsRes `json:"agents"`
+}
+
+func (s agentsCredentialsRes) Code() int {
+	return http.StatusOK
+}
+
+func (s agentsCredentialsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s agentsCredentialsRes) Empty() bool {
+	return false
+}
+
+type agentRPCRes struct {
+	CorrelationID string    `json:"correlation_id"`
+	Func          string    `json:"func"`
+	ChannelID     string    `json:"channel_id"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	Attempts      int       `json:"attempts"`
+	TsCreated     time.Time `json:"ts_created"`
+	TsLastSent    time.Time `json:"ts_last_sent"`
+	TsUpdated     time.Time `json:"ts_updated"`
+}
+
+type agentRPCsRes struct {
+	RPCs []agentRPCRes `json:"rpcs"`
+}
+
+func (s agentRPCsRes) Code() int {
+	return http.StatusOK
+}
+
+func (s agentRPCsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s agentRPCsRes) Empty() bool {
+	return false
+}
+
+type diagnosticsBundleRes struct {
+	ID             string     `json:"id"`
+	AgentID        string     `json:"agent_id"`
+	Status         string     `json:"status"`
+	Error          string     `json:"error,omitempty"`
+	Chunks         int        `json:"chunks"`
+	ChunksReceived int        `json:"chunks_received"`
+	TsCreated      time.Time  `json:"ts_created"`
+	TsCompleted    *time.Time `json:"ts_completed,omitempty"`
+}
+
+func toDiagnosticsBundleRes(b fleet.DiagnosticsBundle) diagnosticsBundleRes {
+	res := diagnosticsBundleRes{
+		ID:             b.ID,
+		AgentID:        b.AgentID,
+		Status:         b.Status,
+		Error:          b.Error,
+		Chunks:         b.Chunks,
+		ChunksReceived: b.ChunksReceived,
+		TsCreated:      b.Created,
+	}
+	if !b.Completed.IsZero() {
+		res.TsCompleted = &b.Completed
+	}
+	return res
+}
+
+// Code is accepted while the agent is still collecting the bundle
+func (s diagnosticsBundleRes) Code() int {
+	if s.Status == fleet.DiagnosticsFailed {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusAccepted
+}
+
+func (s diagnosticsBundleRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s diagnosticsBundleRes) Empty() bool {
+	return false
+}
+
+// diagnosticsDownloadRes is a complete diagnostic bundle, written as is by encodeDiagnosticsResponse
+type diagnosticsDownloadRes struct {
+	filename string
+	data     []byte
+}
+
+type policyRolloutRes struct {
+	ID             string                `json:"id"`
+	PolicyID       string                `json:"policy_id"`
+	Version        int32                 `json:"version"`
+	GroupIDs       []string              `json:"group_ids"`
+	Strategy       fleet.RolloutStrategy `json:"strategy"`
+	State          string                `json:"state"`
+	CurrentWave    int                   `json:"current_wave"`
+	Waves          int                   `json:"waves"`
+	Progress       map[string]int        `json:"progress"`
+	Agents         []fleet.RolloutAgent  `json:"agents"`
+	Error          string                `json:"error,omitempty"`
+	TsCreated      time.Time             `json:"ts_created"`
+	TsLastModified time.Time             `json:"ts_last_modified"`
+}
+
+func (s policyRolloutRes) Code() int {
+	return http.StatusOK
+}
+
+func (s policyRolloutRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s policyRolloutRes) Empty() bool {
+	return false
+}
+
+type policyRolloutsRes struct {
+	Rollouts []policyRolloutRes `json:"rollouts"`
+}
+
+func (s policyRolloutsRes) Code() int {
+	return http.StatusOK
+}
+
+func (s policyRolloutsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s policyRolloutsRes) Empty() bool {
+	return false
+}
+
+type enrollmentTokenRes struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Token     string     `json:"token,omitempty"`
+	OrbTags   types.Tags `json:"orb_tags"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Revoked   bool       `json:"revoked"`
+	TsCreated time.Time  `json:"ts_created"`
+	created   bool
+}
+
+func (s enrollmentTokenRes) Code() int {
+	if s.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (s enrollmentTokenRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s enrollmentTokenRes) Empty() bool {
+	return false
+}
+
+type enrollmentTokensRes struct {
+	Tokens []enrollmentTokenRes `json:"tokens"`
+}
+
+func (s enrollmentTokensRes) Code() int {
+	return http.StatusOK
+}
+
+func (s enrollmentTokensRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s enrollmentTokensRes) Empty() bool {
+	return false
+}
+
+type agentVersionPolicyRes struct {
+	Agent          fleet.VersionRequirement            `json:"agent"`
+	Backends       map[string]fleet.VersionRequirement `json:"backends"`
+	Enforcement    string                              `json:"enforcement"`
+	TsLastModified time.Time                           `json:"ts_last_modified"`
+}
+
+func (s agentVersionPolicyRes) Code() int {
+	return http.StatusOK
+}
+
+func (s agentVersionPolicyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s agentVersionPolicyRes) Empty() bool {
+	return false
+}
+
+type versionComplianceRes struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+	Count   int    `json:"count"`
+}
+
+type groupComplianceRes struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	fleet.ComplianceCounts
+}
+
+type agentComplianceRes struct {
+	ID       string                               `json:"id"`
+	Name     string                               `json:"name"`
+	Status   string                               `json:"status"`
+	Agent    fleet.ComponentCompliance            `json:"orb_agent"`
+	Backends map[string]fleet.ComponentCompliance `json:"backends"`
+}
+
+type complianceReportRes struct {
+	Policy agentVersionPolicyRes `json:"policy"`
+	fleet.ComplianceCounts
+	Versions []versionComplianceRes `json:"versions"`
+	Groups   []groupComplianceRes   `json:"groups"`
+	Agents   []agentComplianceRes   `json:"agents"`
+}
+
+func (s complianceReportRes) Code() int {
+	return http.StatusOK
+}
+
+func (s complianceReportRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s complianceReportRes) Empty() bool {
+	return false
+}
+
+type groupSizeRes struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Total  int    `json:"total"`
+	Online int    `json:"online"`
+}
+
+type failingPolicyRes struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+type failingPolicyAgentRes struct {
+	ID       string             `json:"id"`
+	Name     string             `json:"name"`
+	Policies []failingPolicyRes `json:"policies"`
+}
+
+type fleetSummaryRes struct {
+	Total                    int                       `json:"total"`
+	States                   map[string]int            `json:"states"`
+	AgentVersions            map[string]int            `json:"agent_versions"`
+	BackendVersions          map[string]map[string]int `json:"backend_versions"`
+	BackendStates            map[string]map[string]int `json:"backend_states"`
+	PolicyStates             map[string]int            `json:"policy_states"`
+	Groups                   []groupSizeRes            `json:"groups"`
+	FailingPolicyAgentsTotal int                       `json:"failing_policy_agents_total"`
+	FailingPolicyAgents      []failingPolicyAgentRes   `json:"failing_policy_agents"`
+}
+
+func (s fleetSummaryRes) Code() int {
+	return http.StatusOK
+}
+
+func (s fleetSummaryRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (s fleetSummaryRes) Empty() bool {
+	return false
+}
+
+type maintenanceRes struct {
+	Reason string     `json:"reason,omitempty"`
+	Start  *time.Time `json:"start,omitempty"`
+	End    *time.Time `json:"end,omitempty"`
+	Active bool       `json:"active"`
+}
+
+func toMaintenanceRes(m *fleet.Maintenance) *maintenanceRes {
+	if m == nil {
+		return nil
+	}
+	res := &maintenanceRes{Reason: m.Reason, Active: m.Active(time.Now())}
+	if !m.Start.IsZero() {
+		res.Start = &m.Start
+	}
+	if !m.End.IsZero() {
+		res.End = &m.End
+	}
+	return res
+}
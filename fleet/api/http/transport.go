@@ -7,8 +7,10 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	kitot "github.com/go-kit/kit/tracing/opentracing"
@@ -22,6 +24,7 @@ import (
 	"github.com/orb-community/orb/pkg/db"
 	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/pkg/types"
+	"github.com/orb-community/orb/policies/template"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -34,6 +37,7 @@ const (
 	dirKey      = "dir"
 	metadataKey = "metadata"
 	tagsKey     = "tags"
+	policyIDKey = "policy_id"
 	defOffset   = 0
 	defLimit    = 10
 )
@@ -75,6 +79,21 @@ func MakeHandler(tracer opentracing.Tracer, svcName string, svc fleet.Service) h
 		decodeValidateAgentGroup,
 		types.EncodeResponse,
 		opts...))
+	r.Put("/agent_groups/:id/maintenance", kithttp.NewServer(
+		kitot.TraceServer(tracer, "set_agent_group_maintenance")(setAgentGroupMaintenanceEndpoint(svc)),
+		decodeSetMaintenance,
+		types.EncodeResponse,
+		opts...))
+	r.Delete("/agent_groups/:id/maintenance", kithttp.NewServer(
+		kitot.TraceServer(tracer, "clear_agent_group_maintenance")(clearAgentGroupMaintenanceEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Post("/agent_groups/:id/rpc/rotate_credentials", kithttp.NewServer(
+		kitot.TraceServer(tracer, "rotate_agent_group_credentials")(rotateAgentGroupCredentialsEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
 
 	r.Post("/agents", kithttp.NewServer(
 		kitot.TraceServer(tracer, "create_agent")(addAgentEndpoint(svc)),
@@ -91,11 +110,111 @@ func MakeHandler(tracer opentracing.Tracer, svcName string, svc fleet.Service) h
 		decodeListBackends,
 		types.EncodeResponse,
 		opts...))
+	r.Post("/agents/enroll", kithttp.NewServer(
+		kitot.TraceServer(tracer, "enroll_agent")(enrollAgentEndpoint(svc)),
+		decodeEnrollAgent,
+		types.EncodeResponse,
+		opts...))
+	r.Post("/agents/enrollment_tokens", kithttp.NewServer(
+		kitot.TraceServer(tracer, "create_enrollment_token")(addEnrollmentTokenEndpoint(svc)),
+		decodeAddEnrollmentToken,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/agents/enrollment_tokens", kithttp.NewServer(
+		kitot.TraceServer(tracer, "list_enrollment_tokens")(listEnrollmentTokensEndpoint(svc)),
+		decodeListEnrollmentTokens,
+		types.EncodeResponse,
+		opts...))
+	r.Delete("/agents/enrollment_tokens/:id", kithttp.NewServer(
+		kitot.TraceServer(tracer, "revoke_enrollment_token")(revokeEnrollmentTokenEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/fleet/summary", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_fleet_summary")(viewFleetSummaryEndpoint(svc)),
+		decodeFleetSummary,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/agents/version_policy", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_agent_version_policy")(viewAgentVersionPolicyEndpoint(svc)),
+		decodeViewAgentVersionPolicy,
+		types.EncodeResponse,
+		opts...))
+	r.Put("/agents/version_policy", kithttp.NewServer(
+		kitot.TraceServer(tracer, "set_agent_version_policy")(setAgentVersionPolicyEndpoint(svc)),
+		decodeSetAgentVersionPolicy,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/agents/compliance", kithttp.NewServer(
+		kitot.TraceServer(tracer, "agent_compliance_report")(agentComplianceReportEndpoint(svc)),
+		decodeViewAgentVersionPolicy,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/agents/:id/rpc", kithttp.NewServer(
+		kitot.TraceServer(tracer, "list_agent_rpcs")(listAgentRPCsEndpoint(svc)),
+		decodeListAgentRPCs,
+		types.EncodeResponse,
+		opts...))
 	r.Post("/agents/:id/rpc/reset", kithttp.NewServer(
 		kitot.TraceServer(tracer, "reset_agent")(resetAgentEndpoint(svc)),
 		decodeView,
 		types.EncodeResponse,
 		opts...))
+	r.Post("/agents/:id/rpc/rotate_credentials", kithttp.NewServer(
+		kitot.TraceServer(tracer, "rotate_agent_credentials")(rotateAgentCredentialsEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Post("/agents/:id/rpc/diagnostics", kithttp.NewServer(
+		kitot.TraceServer(tracer, "request_agent_diagnostics")(requestAgentDiagnosticsEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/agents/:id/diagnostics/:bundleID", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_agent_diagnostics")(viewAgentDiagnosticsEndpoint(svc)),
+		decodeViewAgentDiagnostics,
+		encodeDiagnosticsResponse,
+		opts...))
+	r.Put("/agents/:id/maintenance", kithttp.NewServer(
+		kitot.TraceServer(tracer, "set_agent_maintenance")(setAgentMaintenanceEndpoint(svc)),
+		decodeSetMaintenance,
+		types.EncodeResponse,
+		opts...))
+	r.Delete("/agents/:id/maintenance", kithttp.NewServer(
+		kitot.TraceServer(tracer, "clear_agent_maintenance")(clearAgentMaintenanceEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Post("/agents/:id/rpc/policy_test", kithttp.NewServer(
+		kitot.TraceServer(tracer, "test_agent_policy")(testAgentPolicyEndpoint(svc)),
+		decodeTestAgentPolicy,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/policy_rollouts", kithttp.NewServer(
+		kitot.TraceServer(tracer, "list_policy_rollouts")(listPolicyRolloutsEndpoint(svc)),
+		decodeListPolicyRollouts,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/policy_rollouts/:id", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_policy_rollout")(viewPolicyRolloutEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Post("/policy_rollouts/:id/rollback", kithttp.NewServer(
+		kitot.TraceServer(tracer, "rollback_policy_rollout")(rollbackPolicyRolloutEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Post("/policy_rollouts/:id/resume", kithttp.NewServer(
+		kitot.TraceServer(tracer, "resume_policy_rollout")(resumePolicyRolloutEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
+	r.Get("/agents/:id/policies/:policy_id/preview", kithttp.NewServer(
+		kitot.TraceServer(tracer, "preview_agent_policy")(previewAgentPolicyEndpoint(svc)),
+		decodePreviewAgentPolicy,
+		types.EncodeResponse,
+		opts...))
 	r.Get("/agents/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "edit_agent")(viewAgentEndpoint(svc)),
 		decodeView,
@@ -156,6 +275,147 @@ func decodeView(_ context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
+func decodeAddEnrollmentToken(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return nil, errors.ErrUnsupportedContentType
+	}
+
+	req := addEnrollmentTokenReq{token: parseJwt(r)}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
+func decodeListEnrollmentTokens(_ context.Context, r *http.Request) (interface{}, error) {
+	return listEnrollmentTokensReq{token: parseJwt(r)}, nil
+}
+
+func decodeFleetSummary(_ context.Context, r *http.Request) (interface{}, error) {
+	t, err := httputil.ReadTagQuery(r, tagsKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fleetSummaryReq{token: parseJwt(r), tags: t}, nil
+}
+
+func decodeSetAgentVersionPolicy(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return nil, errors.ErrUnsupportedContentType
+	}
+
+	req := setAgentVersionPolicyReq{token: parseJwt(r)}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
+func decodeSetMaintenance(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return nil, errors.ErrUnsupportedContentType
+	}
+
+	req := setMaintenanceReq{token: parseJwt(r), id: bone.GetValue(r, "id")}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
+func decodeViewAgentVersionPolicy(_ context.Context, r *http.Request) (interface{}, error) {
+	return viewAgentVersionPolicyReq{token: parseJwt(r)}, nil
+}
+
+// decodeEnrollAgent reads the enrollment token from the bearer authorization, agents have no user token to send
+func decodeEnrollAgent(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return nil, errors.ErrUnsupportedContentType
+	}
+
+	req := enrollAgentReq{enrollmentToken: parseJwt(r)}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
+func decodeListPolicyRollouts(_ context.Context, r *http.Request) (interface{}, error) {
+	policyID, err := httputil.ReadStringQuery(r, policyIDKey, "")
+	if err != nil {
+		return nil, err
+	}
+	req := listPolicyRolloutsReq{
+		token:    parseJwt(r),
+		policyID: policyID,
+	}
+	return req, nil
+}
+
+func decodeViewAgentDiagnostics(_ context.Context, r *http.Request) (interface{}, error) {
+	req := viewAgentDiagnosticsReq{
+		token:    parseJwt(r),
+		id:       bone.GetValue(r, "id"),
+		bundleID: bone.GetValue(r, "bundleID"),
+	}
+	return req, nil
+}
+
+// encodeDiagnosticsResponse writes a complete diagnostic bundle as a gzipped tarball download, and the status of the
+// other ones as JSON
+func encodeDiagnosticsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	res, ok := response.(diagnosticsDownloadRes)
+	if !ok {
+		return types.EncodeResponse(ctx, w, response)
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", res.filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.data)))
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(res.data)
+	return err
+}
+
+func decodeListAgentRPCs(_ context.Context, r *http.Request) (interface{}, error) {
+	l, err := httputil.ReadUintQuery(r, limitKey, fleet.DefaultAgentRPCsLimit)
+	if err != nil {
+		return nil, err
+	}
+	req := listAgentRPCsReq{
+		token: parseJwt(r),
+		id:    bone.GetValue(r, "id"),
+		limit: l,
+	}
+	return req, nil
+}
+
+func decodePreviewAgentPolicy(_ context.Context, r *http.Request) (interface{}, error) {
+	req := previewAgentPolicyReq{
+		token:    parseJwt(r),
+		id:       bone.GetValue(r, "id"),
+		policyID: bone.GetValue(r, "policy_id"),
+	}
+	return req, nil
+}
+
+func decodeTestAgentPolicy(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.ErrUnsupportedContentType
+	}
+	req := testAgentPolicyReq{
+		token: parseJwt(r),
+		id:    bone.GetValue(r, "id"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+	return req, nil
+}
+
 func decodeAgentGroupUpdate(_ context.Context, r *http.Request) (interface{}, error) {
 	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
 		return nil, errors.ErrUnsupportedContentType
@@ -290,11 +550,25 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 		case errors.Contains(errorVal, errors.ErrConflict):
 			w.WriteHeader(http.StatusConflict)
 
-		case errors.Contains(errorVal, db.ErrScanMetadata):
+		case errors.Contains(errorVal, db.ErrScanMetadata),
+			errors.Contains(errorVal, template.ErrMissingVariable),
+			errors.Contains(errorVal, template.ErrInvalidTemplate):
 			w.WriteHeader(http.StatusUnprocessableEntity)
 
-		case errors.Contains(errorVal, fleet.ErrCreateAgentGroup):
+		case errors.Contains(errorVal, fleet.ErrCreateAgentGroup),
+			errors.Contains(errorVal, fleet.ErrMalformedEnrollmentToken),
+			errors.Contains(errorVal, fleet.ErrMalformedVersionPolicy),
+			errors.Contains(errorVal, fleet.ErrMalformedMaintenance):
 			w.WriteHeader(http.StatusBadRequest)
+		case errors.Contains(errorVal, fleet.ErrRolloutNotInProgress),
+			errors.Contains(errorVal, fleet.ErrRolloutNotHalted),
+			errors.Contains(errorVal, fleet.ErrAgentNotOnline):
+			w.WriteHeader(http.StatusConflict)
+		case errors.Contains(errorVal, fleet.ErrPolicyTestTimeout),
+			errors.Contains(errorVal, fleet.ErrCredentialsRotationTimeout):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case errors.Contains(errorVal, fleet.ErrStageAgentCredentials):
+			w.WriteHeader(http.StatusBadGateway)
 
 		case errors.Contains(errorVal, io.ErrUnexpectedEOF),
 			errors.Contains(errorVal, io.EOF):
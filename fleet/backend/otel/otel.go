@@ -66,5 +66,11 @@ func Register(auth mainflux.AuthServiceClient, agentRepo fleet.AgentRepository)
 		auth:        auth,
 		agentRepo:   agentRepo,
 	})
+	backend.Register("otel-embedded", &otelBackend{
+		Backend:     "otel-embedded",
+		Description: "OpenTelemetry configuration YAML, run by the collector embedded in the agent",
+		auth:        auth,
+		agentRepo:   agentRepo,
+	})
 	return true
 }
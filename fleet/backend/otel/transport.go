@@ -15,17 +15,17 @@ import (
 
 func MakeOtelHandler(tracer opentracing.Tracer, dio otelBackend, opts []kithttp.ServerOption, r *bone.Mux) {
 
-	r.Get("/agents/backends/otel/handlers", kithttp.NewServer(
+	r.Get("/agents/backends/"+dio.Backend+"/handlers", kithttp.NewServer(
 		kitot.TraceServer(tracer, "view_agent_backend_handler")(viewAgentBackendHandlerEndpoint(dio)),
 		decodeBackendView,
 		types.EncodeResponse,
 		opts...))
-	r.Get("/agents/backends/otel/inputs", kithttp.NewServer(
+	r.Get("/agents/backends/"+dio.Backend+"/inputs", kithttp.NewServer(
 		kitot.TraceServer(tracer, "view_agent_backend_input")(viewAgentBackendInputEndpoint(dio)),
 		decodeBackendView,
 		types.EncodeResponse,
 		opts...))
-	r.Get("/agents/backends/otel/taps", kithttp.NewServer(
+	r.Get("/agents/backends/"+dio.Backend+"/taps", kithttp.NewServer(
 		kitot.TraceServer(tracer, "view_agent_backend_taps")(viewAgentBackendTapsEndpoint(dio)),
 		decodeBackendView,
 		types.EncodeResponse,
@@ -0,0 +1,23 @@
+package probe
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mainflux/mainflux"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+func viewAgentBackendHandlerEndpoint(dio probeBackend) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		_, err = dio.auth.Identify(ctx, &mainflux.Token{Value: req.token})
+		if err != nil {
+			return "", errors.Wrap(errors.ErrUnauthorizedAccess, err)
+		}
+		return dio.handlers(), nil
+	}
+}
@@ -0,0 +1,65 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package probe
+
+import (
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/orb-community/orb/fleet/backend"
+	"github.com/orb-community/orb/pkg/probe"
+	"github.com/orb-community/orb/pkg/types"
+)
+
+var _ backend.Backend = (*probeBackend)(nil)
+
+const CurrentSchemaVersion = "1.0"
+
+type probeBackend struct {
+	auth        mainflux.AuthServiceClient
+	Backend     string
+	Description string
+}
+
+func (p probeBackend) Metadata() interface{} {
+	return struct {
+		Backend       string `json:"backend"`
+		Description   string `json:"description"`
+		SchemaVersion string `json:"schema_version"`
+	}{
+		Backend:       p.Backend,
+		Description:   p.Description,
+		SchemaVersion: CurrentSchemaVersion,
+	}
+}
+
+func (p probeBackend) MakeHandler(tracer opentracing.Tracer, opts []kithttp.ServerOption, r *bone.Mux) {
+	MakeProbeHandler(tracer, p, opts, r)
+}
+
+// handlers lists the check types a probe policy may use, with the values their options accept
+func (p probeBackend) handlers() types.Metadata {
+	return types.Metadata{
+		probe.CheckDNS: types.Metadata{
+			"qtype":        probe.DNSQueryTypes,
+			"protocol":     []string{"udp", "tcp"},
+			"expect_rcode": probe.DNSRcodes,
+		},
+		probe.CheckHTTP: types.Metadata{
+			"method": []string{"GET"},
+		},
+		probe.CheckTCP: types.Metadata{},
+	}
+}
+
+func Register(auth mainflux.AuthServiceClient) bool {
+	backend.Register("probe", &probeBackend{
+		Backend:     "probe",
+		Description: "Synthetic DNS, HTTP and TCP checks run by the agent",
+		auth:        auth,
+	})
+	return true
+}
@@ -17,14 +17,24 @@ import (
 	"github.com/mainflux/mainflux/pkg/messaging"
 	mfnats "github.com/mainflux/mainflux/pkg/messaging/nats"
 	"github.com/orb-community/orb/buildinfo"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
 	"github.com/orb-community/orb/policies/pb"
+	"github.com/orb-community/orb/policies/template"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
+	"strings"
 	"time"
 )
 
 const publisher = "orb-fleet"
 
+// AgentEventPublisher publishes the agent changes made by the agent comms, which bypass the fleet service
+type AgentEventPublisher interface {
+	// PublishAgentUpdate notifies the consumers caching the agent that it changed
+	PublishAgentUpdate(ctx context.Context, agent Agent) error
+}
+
 type AgentCommsService interface {
 	// Start set up communication with the message bus to communicate with agents
 	Start() error
@@ -53,21 +63,55 @@ type AgentCommsService interface {
 	NotifyAgentReset(ctx context.Context, agent Agent, fullReset bool, reason string) error
 	// NotifyGroupDatasetEdit RPC core -> Agent: Notify Agent an already created Dataset goes invalid or valid
 	NotifyGroupDatasetEdit(ctx context.Context, ag AgentGroup, datasetID, policyID, ownerID string, valid bool) error
+	// NotifyAgentPolicyUpdate RPC core -> Agent: Notify a specific Agent that a Policy of one of its groups has been updated,
+	// returning ErrAgentPolicySkipped when the agent is in maintenance or excluded from the policy
+	NotifyAgentPolicyUpdate(ctx context.Context, a Agent, groupID string, policyID string) error
+	// RenderAgentPolicy Render a Policy with the template variables of the Agent, as it would be sent to the Agent
+	RenderAgentPolicy(ctx context.Context, a Agent, policyID string) (AgentPolicyRPCPayload, error)
+	// TestAgentPolicy RPC core -> Agent -> core: Ask the Agent to validate a Policy with its backend, waiting for the result
+	TestAgentPolicy(ctx context.Context, a Agent, policyID string) (PolicyTestResultRPCPayload, error)
+	// StageAgentCredentials RPC core -> Agent -> core: Hand the Agent a new key, waiting for it to be stored
+	StageAgentCredentials(ctx context.Context, a Agent, requestID string, key string) error
+	// CommitAgentCredentials RPC core -> Agent: Notify the Agent its previous key was revoked, so it reconnects with the new one
+	CommitAgentCredentials(ctx context.Context, a Agent, requestID string) error
+	// RequestAgentDiagnostics RPC core -> Agent: Ask the Agent to collect a diagnostic bundle and upload it in chunks
+	RequestAgentDiagnostics(ctx context.Context, a Agent, bundleID string) error
+	// RetryAgentRPCs sends again the RPCs Agents did not acknowledge in time, and times out those with no attempts left
+	RetryAgentRPCs(ctx context.Context) error
 }
 
 var _ AgentCommsService = (*fleetCommsService)(nil)
 
+// ErrAgentPolicySkipped indicates the agent was not sent the policy update, being in maintenance or excluded from it
+var ErrAgentPolicySkipped = errors.New("agent skipped the policy update")
+
 const CapabilitiesTopic = "agent"
 const HeartbeatsTopic = "hb"
 const RPCToCoreTopic = "tocore"
 const RPCFromCoreTopic = "fromcore"
 const LogTopic = "log"
 
+// PolicyTestTopic is where the fleet instance receiving a policy test result relays it to the one waiting for it
+const PolicyTestTopic = "policy_test"
+
+// PolicyTestTimeout is how long to wait for the agent to report the result of a policy test
+const PolicyTestTimeout = 30 * time.Second
+
+// CredentialsTopic is where the fleet instance receiving a credentials acknowledgement relays it to the one rotating them
+const CredentialsTopic = "credentials"
+
+// CredentialsRotationTimeout is how long to wait for the agent to acknowledge it stored its new credentials
+const CredentialsRotationTimeout = 30 * time.Second
+
 type fleetCommsService struct {
 	logger              *zap.Logger
 	agentRepo           AgentRepository
 	agentGroupRepo      AgentGroupRepository
+	agentRPCRepo        AgentRPCRepository
+	versionPolicyRepo   AgentVersionPolicyRepository
+	diagnosticsRepo     AgentDiagnosticsRepository
 	policyClient        pb.PolicyServiceClient
+	agentEvents         AgentEventPublisher
 	asyncContext        context.Context
 	cancelAsyncContexts context.CancelFunc
 
@@ -99,7 +143,7 @@ func (svc fleetCommsService) NotifyGroupDatasetEdit(ctx context.Context, ag Agen
 		action = "remove"
 	}
 
-	payload := []AgentPolicyRPCPayload{{
+	payload := AgentPolicyRPCPayload{
 		Action:    action,
 		ID:        policyID,
 		Name:      p.Name,
@@ -108,32 +152,13 @@ func (svc fleetCommsService) NotifyGroupDatasetEdit(ctx context.Context, ag Agen
 		Data:      pdata,
 		DatasetID: datasetID,
 		Format:    p.Format,
-	}}
-
-	data := AgentPolicyRPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
-		Func:          AgentPolicyRPCFunc,
-		Payload:       payload,
-		FullList:      false,
 	}
 
-	body, err := json.Marshal(data)
+	excluded, err := svc.datasetExclusions(ctx, ownerID, datasetID, valid)
 	if err != nil {
 		return err
 	}
-
-	msg := messaging.Message{
-		Channel:   ag.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-
-	return nil
+	return svc.publishGroupPolicy(ctx, ag, ownerID, payload, excluded)
 }
 
 func (svc fleetCommsService) NotifyGroupNewDataset(ctx context.Context, ag AgentGroup, datasetID string, policyID string, ownerID string) error {
@@ -153,7 +178,7 @@ func (svc fleetCommsService) NotifyGroupNewDataset(ctx context.Context, ag Agent
 		}
 	}
 
-	payload := []AgentPolicyRPCPayload{{
+	payload := AgentPolicyRPCPayload{
 		Action:       "manage",
 		ID:           policyID,
 		Name:         p.Name,
@@ -162,32 +187,13 @@ func (svc fleetCommsService) NotifyGroupNewDataset(ctx context.Context, ag Agent
 		Data:         pdata,
 		DatasetID:    datasetID,
 		AgentGroupID: ag.ID,
-	}}
-
-	data := AgentPolicyRPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
-		Func:          AgentPolicyRPCFunc,
-		Payload:       payload,
-		FullList:      false,
 	}
 
-	body, err := json.Marshal(data)
+	excluded, err := svc.datasetExclusions(ctx, ownerID, datasetID, true)
 	if err != nil {
 		return err
 	}
-
-	msg := messaging.Message{
-		Channel:   ag.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-
-	return nil
+	return svc.publishGroupPolicy(ctx, ag, ownerID, payload, excluded)
 }
 
 func (svc fleetCommsService) NotifyAgentNewGroupMembership(ctx context.Context, a Agent, ag AgentGroup) error {
@@ -197,29 +203,12 @@ func (svc fleetCommsService) NotifyAgentNewGroupMembership(ctx context.Context,
 	}
 
 	data := RPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          GroupMembershipRPCFunc,
 		Payload:       payload,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   a.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-
-	return nil
-
+	return svc.publishRPC(ctx, a.MFChannelID, agentRPCSchemaVersion(a), []string{a.MFThingID}, data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyAgentAllDatasets(ctx context.Context, a Agent) error {
@@ -243,14 +232,16 @@ func (svc fleetCommsService) NotifyAgentAllDatasets(ctx context.Context, a Agent
 		return err
 	}
 
+	// agents in maintenance run no policies, the empty full list removes them
 	var payload []AgentPolicyRPCPayload
-	if len(groups) > 0 {
-		p, err := svc.policyClient.RetrievePoliciesByGroups(ctx, &pb.PoliciesByGroupsReq{GroupIDs: groupIDs, OwnerID: a.MFOwnerID})
+	if len(groups) > 0 && !a.InMaintenance {
+		// the datasets excluding the agent are left out
+		p, err := svc.policyClient.RetrievePoliciesByGroups(ctx, &pb.PoliciesByGroupsReq{GroupIDs: groupIDs, OwnerID: a.MFOwnerID, AgentID: a.MFThingID})
 		if err != nil {
 			return err
 		}
-		payload = make([]AgentPolicyRPCPayload, len(p.Policies))
-		for i, policy := range p.Policies {
+		payload = make([]AgentPolicyRPCPayload, 0, len(p.Policies))
+		for _, policy := range p.Policies {
 
 			var pdata interface{}
 			svc.logger.Debug("policy format", zap.String("policy_id", policy.Id), zap.String("policy_format", policy.Format))
@@ -264,7 +255,15 @@ func (svc fleetCommsService) NotifyAgentAllDatasets(ctx context.Context, a Agent
 				}
 			}
 
-			payload[i] = AgentPolicyRPCPayload{
+			pdata, err = renderAgentPolicy(a, pdata)
+			if err != nil {
+				// the other policies are still applied, this one is reported once the agent has the variables
+				svc.logger.Warn("skipping policy, failed to render template for agent", zap.String("policy_id", policy.Id),
+					zap.String("agent_id", a.MFThingID), zap.Error(err))
+				continue
+			}
+
+			payload = append(payload, AgentPolicyRPCPayload{
 				Action:       "manage",
 				ID:           policy.Id,
 				Name:         policy.Name,
@@ -274,7 +273,7 @@ func (svc fleetCommsService) NotifyAgentAllDatasets(ctx context.Context, a Agent
 				Data:         pdata,
 				DatasetID:    policy.DatasetId,
 				AgentGroupID: policy.AgentGroupId,
-			}
+			})
 
 		}
 	} else {
@@ -286,29 +285,14 @@ func (svc fleetCommsService) NotifyAgentAllDatasets(ctx context.Context, a Agent
 	}
 
 	data := AgentPolicyRPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          AgentPolicyRPCFunc,
 		Payload:       payload,
 		FullList:      true,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   a.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-
-	return nil
+	svc.supersedeAgentRPCs(ctx, a.MFThingID, AgentPolicyRPCFunc, DatasetRemovedRPCFunc)
+	return svc.publishRPC(ctx, a.MFChannelID, agentRPCSchemaVersion(a), []string{a.MFThingID}, data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyAgentGroupMemberships(ctx context.Context, a Agent) error {
@@ -331,29 +315,13 @@ func (svc fleetCommsService) NotifyAgentGroupMemberships(ctx context.Context, a
 	}
 
 	data := RPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          GroupMembershipRPCFunc,
 		Payload:       payload,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   a.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-
-	return nil
-
+	svc.supersedeAgentRPCs(ctx, a.MFThingID, GroupMembershipRPCFunc, GroupRemovedRPCFunc)
+	return svc.publishRPC(ctx, a.MFChannelID, agentRPCSchemaVersion(a), []string{a.MFThingID}, data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyGroupRemoval(ctx context.Context, ag AgentGroup) error {
@@ -375,27 +343,12 @@ func (svc fleetCommsService) NotifyGroupRemoval(ctx context.Context, ag AgentGro
 	}
 
 	data := RPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          GroupRemovedRPCFunc,
 		Payload:       payload,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   ag.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-	return nil
+	return svc.publishRPC(ctx, ag.MFChannelID, groupRPCSchemaVersion(), svc.groupAgentIDs(ctx, ag.MFOwnerID, ag.ID), data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyGroupPolicyUpdate(ctx context.Context, ag AgentGroup, policyID string, ownerID string) error {
@@ -415,7 +368,7 @@ func (svc fleetCommsService) NotifyGroupPolicyUpdate(ctx context.Context, ag Age
 		}
 	}
 
-	payload := []AgentPolicyRPCPayload{{
+	payload := AgentPolicyRPCPayload{
 		Action:       "manage",
 		ID:           policyID,
 		Name:         p.Name,
@@ -424,32 +377,13 @@ func (svc fleetCommsService) NotifyGroupPolicyUpdate(ctx context.Context, ag Age
 		Version:      p.Version,
 		Data:         pdata,
 		Format:       p.Format,
-	}}
-
-	data := AgentPolicyRPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
-		Func:          AgentPolicyRPCFunc,
-		Payload:       payload,
-		FullList:      false,
 	}
 
-	body, err := json.Marshal(data)
+	excluded, err := svc.groupPolicyExclusions(ctx, ownerID, ag.ID, policyID)
 	if err != nil {
 		return err
 	}
-
-	msg := messaging.Message{
-		Channel:   ag.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-
-	return nil
+	return svc.publishGroupPolicy(ctx, ag, ownerID, payload, excluded)
 }
 
 func (svc fleetCommsService) NotifyGroupPolicyRemoval(ctx context.Context, ag AgentGroup, policyID string, policyName string, backend string) error {
@@ -466,28 +400,13 @@ func (svc fleetCommsService) NotifyGroupPolicyRemoval(ctx context.Context, ag Ag
 	payloads = append(payloads, payload)
 
 	data := AgentPolicyRPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          AgentPolicyRPCFunc,
 		Payload:       payloads,
 		FullList:      false,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   ag.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-	return nil
+	return svc.publishRPC(ctx, ag.MFChannelID, groupRPCSchemaVersion(), svc.groupAgentIDs(ctx, ag.MFOwnerID, ag.ID), data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyGroupDatasetRemoval(ctx context.Context, ag AgentGroup, dsID string, policyID string) error {
@@ -498,53 +417,23 @@ func (svc fleetCommsService) NotifyGroupDatasetRemoval(ctx context.Context, ag A
 	}
 
 	data := RPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          DatasetRemovedRPCFunc,
 		Payload:       payload,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   ag.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-	return nil
+	return svc.publishRPC(ctx, ag.MFChannelID, groupRPCSchemaVersion(), svc.groupAgentIDs(ctx, ag.MFOwnerID, ag.ID), data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyAgentStop(ctx context.Context, agent Agent, reason string) error {
 	payload := AgentStopRPCPayload{Reason: reason}
 	data := RPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          AgentStopRPCFunc,
 		Payload:       payload,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   agent.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-	return nil
+	return svc.publishRPC(ctx, agent.MFChannelID, agentRPCSchemaVersion(agent), []string{agent.MFThingID}, data.CorrelationID, data.Func, data)
 }
 
 func (svc fleetCommsService) NotifyAgentReset(ctx context.Context, agent Agent, fullReset bool, reason string) error {
@@ -553,73 +442,95 @@ func (svc fleetCommsService) NotifyAgentReset(ctx context.Context, agent Agent,
 		Reason:    reason,
 	}
 	data := RPC{
-		SchemaVersion: CurrentRPCSchemaVersion,
+		CorrelationID: uuid.NewString(),
 		Func:          AgentResetRPCFunc,
 		Payload:       payload,
 	}
 
-	body, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	msg := messaging.Message{
-		Channel:   agent.MFChannelID,
-		Subtopic:  RPCFromCoreTopic,
-		Publisher: publisher,
-		Payload:   body,
-		Created:   time.Now().UnixNano(),
-	}
-	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
-		return err
-	}
-	return nil
+	return svc.publishRPC(ctx, agent.MFChannelID, agentRPCSchemaVersion(agent), []string{agent.MFThingID}, data.CorrelationID, data.Func, data)
 }
 
-func NewFleetCommsService(logger *zap.Logger, policyClient pb.PolicyServiceClient, agentRepo AgentRepository, agentGroupRepo AgentGroupRepository, agentPubSub mfnats.PubSub) AgentCommsService {
+func NewFleetCommsService(logger *zap.Logger, policyClient pb.PolicyServiceClient, agentRepo AgentRepository, agentGroupRepo AgentGroupRepository, agentRPCRepo AgentRPCRepository, versionPolicyRepo AgentVersionPolicyRepository, diagnosticsRepo AgentDiagnosticsRepository, agentEvents AgentEventPublisher, agentPubSub mfnats.PubSub) AgentCommsService {
 	return &fleetCommsService{
-		logger:         logger,
-		agentRepo:      agentRepo,
-		agentGroupRepo: agentGroupRepo,
-		agentPubSub:    agentPubSub,
-		policyClient:   policyClient,
+		logger:            logger,
+		agentRepo:         agentRepo,
+		agentGroupRepo:    agentGroupRepo,
+		agentRPCRepo:      agentRPCRepo,
+		versionPolicyRepo: versionPolicyRepo,
+		diagnosticsRepo:   diagnosticsRepo,
+		agentEvents:       agentEvents,
+		agentPubSub:       agentPubSub,
+		policyClient:      policyClient,
 	}
 }
 
 func (svc fleetCommsService) handleCapabilities(ctx context.Context, thingID string, channelID string, payload []byte) error {
-	var versionCheck SchemaVersionCheck
-	if err := json.Unmarshal(payload, &versionCheck); err != nil {
-		return ErrSchemaMalformed
-	}
-	if versionCheck.SchemaVersion != CurrentCapabilitiesSchemaVersion {
-		return ErrSchemaVersion
+	capabilities, version, err := DecodeCapabilities(payload)
+	if err != nil {
+		return err
 	}
-	var capabilities Capabilities
-	if err := json.Unmarshal(payload, &capabilities); err != nil {
-		return ErrSchemaMalformed
+	schemaVersions, err := negotiateAgentSchemaVersions(version, capabilities.SchemaVersions)
+	if err != nil {
+		svc.logger.Warn("agent speaks no supported schema version", zap.String("agent_id", thingID),
+			zap.Any("schema_versions", capabilities.SchemaVersions))
+		return err
 	}
 
 	agent, err := svc.agentRepo.RetrieveByIDWithChannel(context.Background(), thingID, channelID)
+	known := err == nil
 	if err != nil {
 		agent = Agent{MFThingID: thingID, MFChannelID: channelID}
 	}
+	// agent tags drive group membership, an agent reloading its configuration may have changed them
+	tagsChanged := known && !sameTags(agent.AgentTags, capabilities.AgentTags)
+	enrollmentTokenID, enrolled := agent.AgentMetadata[enrollmentTokenKey]
 	agent.AgentMetadata = make(map[string]interface{})
+	if enrolled {
+		agent.AgentMetadata[enrollmentTokenKey] = enrollmentTokenID
+	}
 	agent.AgentMetadata["backends"] = capabilities.Backends
 	agent.AgentMetadata["orb_agent"] = capabilities.OrbAgent
+	agent.AgentMetadata[agentSchemaVersionsKey] = schemaVersions
 	agent.AgentTags = capabilities.AgentTags
 
 	err = svc.checkVersion(ctx, buildinfo.GetMinAgentVersion(), capabilities.OrbAgent.Version, &agent)
 	if err != nil {
 		return err
 	}
+	if known && agent.State != UpgradeRequired {
+		if err := svc.checkVersionPolicy(ctx, &agent); err != nil {
+			return err
+		}
+	}
 
 	err = svc.agentRepo.UpdateDataByIDWithChannel(context.Background(), agent)
 	if err != nil {
 		return err
 	}
+	if tagsChanged {
+		svc.logger.Info("agent tags changed, notifying group memberships", zap.String("agent_id", thingID))
+		if err := svc.agentEvents.PublishAgentUpdate(ctx, agent); err != nil {
+			svc.logger.Error("failed to publish agent update", zap.String("agent_id", thingID), zap.Error(err))
+		}
+		if err := svc.NotifyAgentGroupMemberships(ctx, agent); err != nil {
+			svc.logger.Error("notify group membership failure", zap.Error(err))
+		}
+	}
 	return nil
 }
 
+func sameTags(current types.Tags, received types.Tags) bool {
+	if len(current) != len(received) {
+		return false
+	}
+	for key, value := range received {
+		if v, ok := current[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
 func (svc fleetCommsService) checkVersion(ctx context.Context, minVersion string, agentVersion string, agent *Agent) error {
 	mVersion, err := version.NewVersion(minVersion)
 	if err != nil {
@@ -641,17 +552,41 @@ func (svc fleetCommsService) checkVersion(ctx context.Context, minVersion string
 	return nil
 }
 
-func (svc fleetCommsService) handleHeartbeat(ctx context.Context, thingID string, channelID string, payload []byte) error {
-	var versionCheck SchemaVersionCheck
-	if err := json.Unmarshal(payload, &versionCheck); err != nil {
-		return ErrSchemaMalformed
+// checkVersionPolicy stops the agents below the minimum versions of their owner, unless it only wants a warning
+func (svc fleetCommsService) checkVersionPolicy(ctx context.Context, agent *Agent) error {
+	policy, err := svc.versionPolicyRepo.RetrieveByOwner(ctx, agent.MFOwnerID)
+	if errors.Contains(err, ErrNotFound) {
+		return nil
 	}
-	if versionCheck.SchemaVersion != CurrentHeartbeatSchemaVersion {
-		return ErrSchemaVersion
+	if err != nil {
+		// the capabilities are still worth saving, the policy is checked again on the next connection
+		svc.logger.Error("failed to retrieve the version policy of the agent owner", zap.String("agent_id", agent.MFThingID),
+			zap.String("owner_id", agent.MFOwnerID), zap.Error(err))
+		return nil
 	}
-	var hb Heartbeat
-	if err := json.Unmarshal(payload, &hb); err != nil {
-		return ErrSchemaMalformed
+
+	c := policy.Compliance(*agent)
+	if c.Status != ComplianceUnsupported {
+		return nil
+	}
+	if policy.Enforcement == VersionEnforcementWarn {
+		svc.logger.Warn("agent runs versions unsupported by its owner", zap.String("agent_id", agent.MFThingID),
+			zap.Any("agent", c.Agent), zap.Any("backends", c.Backends))
+		return nil
+	}
+	err = svc.NotifyAgentStop(ctx, *agent, fmt.Sprintf("The orb-agent or backend versions are below the minimum versions required by the owner. %s",
+		strings.Join(policy.unsupportedVersions(c), " ")))
+	if err != nil {
+		return err
+	}
+	agent.State = UpgradeRequired
+	return nil
+}
+
+func (svc fleetCommsService) handleHeartbeat(ctx context.Context, thingID string, channelID string, payload []byte) error {
+	hb, _, err := DecodeHeartbeat(payload)
+	if err != nil {
+		return err
 	}
 	agent := Agent{MFThingID: thingID, MFChannelID: channelID}
 	agent.LastHBData = make(map[string]interface{})
@@ -668,7 +603,7 @@ func (svc fleetCommsService) handleHeartbeat(ctx context.Context, thingID string
 		agent.LastHBData["policy_state"] = hb.PolicyState
 		agent.LastHBData["group_state"] = hb.GroupState
 	}
-	err := svc.agentRepo.UpdateHeartbeatByIDWithChannel(context.Background(), agent)
+	err = svc.agentRepo.UpdateHeartbeatByIDWithChannel(context.Background(), agent)
 	if err != nil {
 		return err
 	}
@@ -676,12 +611,9 @@ func (svc fleetCommsService) handleHeartbeat(ctx context.Context, thingID string
 }
 
 func (svc fleetCommsService) handleRPCToCore(ctx context.Context, thingID string, channelID string, payload []byte) error {
-	var versionCheck SchemaVersionCheck
-	if err := json.Unmarshal(payload, &versionCheck); err != nil {
-		return ErrSchemaMalformed
-	}
-	if versionCheck.SchemaVersion != CurrentRPCSchemaVersion {
-		return ErrSchemaVersion
+	version, err := schemaVersion(payload, SupportedRPCSchemaVersions)
+	if err != nil {
+		return err
 	}
 	var rpc RPC
 	if err := json.Unmarshal(payload, &rpc); err != nil {
@@ -691,13 +623,56 @@ func (svc fleetCommsService) handleRPCToCore(ctx context.Context, thingID string
 	// dispatch
 	switch rpc.Func {
 	case GroupMembershipReqRPCFunc:
-		if err := svc.NotifyAgentGroupMemberships(ctx, Agent{MFThingID: thingID, MFChannelID: channelID}); err != nil {
+		// loaded for the schema version negotiated with the agent
+		a, err := svc.agentRepo.RetrieveByIDWithChannel(ctx, thingID, channelID)
+		if err == nil {
+			err = svc.NotifyAgentGroupMemberships(ctx, a)
+		}
+		if err != nil {
 			svc.logger.Error("notify group membership failure", zap.Error(err))
-			return nil
 		}
+		svc.ackAgentRequest(channelID, version, rpc.CorrelationID, err)
 	case AgentPoliciesReqRPCFunc:
-		if err := svc.NotifyAgentAllDatasets(ctx, Agent{MFThingID: thingID, MFChannelID: channelID}); err != nil {
+		err := svc.NotifyAgentAllDatasets(ctx, Agent{MFThingID: thingID, MFChannelID: channelID})
+		if err != nil {
 			svc.logger.Error("notify agent policies failure", zap.Error(err))
+		}
+		svc.ackAgentRequest(channelID, version, rpc.CorrelationID, err)
+	case PolicyTestResultRPCFunc:
+		var r PolicyTestResultRPC
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return ErrSchemaMalformed
+		}
+		if err := svc.relayAgentReply(thingID, channelID, PolicyTestTopic, r.Payload.RequestID, r.Payload); err != nil {
+			svc.logger.Error("relay policy test result failure", zap.Error(err))
+			return nil
+		}
+	case RPCAckRPCFunc:
+		var r RPCAckRPC
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return ErrSchemaMalformed
+		}
+		if err := svc.handleRPCAck(ctx, thingID, r.Payload); err != nil {
+			svc.logger.Error("agent RPC acknowledgement failure", zap.String("agent_id", thingID), zap.Error(err))
+			return nil
+		}
+	case AgentCredentialsAckRPCFunc:
+		var r AgentCredentialsAckRPC
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return ErrSchemaMalformed
+		}
+		if err := svc.relayAgentReply(thingID, channelID, CredentialsTopic, r.Payload.RequestID, r.Payload); err != nil {
+			svc.logger.Error("relay agent credentials acknowledgement failure", zap.Error(err))
+			return nil
+		}
+	case AgentDiagnosticsChunkRPCFunc:
+		var r AgentDiagnosticsChunkRPC
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return ErrSchemaMalformed
+		}
+		if err := svc.handleDiagnosticsChunk(ctx, thingID, r.Payload); err != nil {
+			svc.logger.Error("agent diagnostic bundle chunk failure", zap.String("agent_id", thingID),
+				zap.String("bundle_id", r.Payload.BundleID), zap.Error(err))
 			return nil
 		}
 	default:
@@ -809,3 +784,558 @@ func (svc fleetCommsService) Stop() error {
 	svc.cancelAsyncContexts()
 	return nil
 }
+
+// publishGroupPolicy publishes the policy on the agent group channel, unless the policy has template variables or
+// agents of the group are in maintenance or excluded from the dataset, then it is rendered and published on the
+// channel of each agent of the group running it
+func (svc fleetCommsService) publishGroupPolicy(ctx context.Context, ag AgentGroup, ownerID string, payload AgentPolicyRPCPayload, excluded map[string]bool) error {
+	if payload.Action != "manage" {
+		return svc.publishPolicies(ctx, ag.MFChannelID, groupRPCSchemaVersion(), svc.groupAgentIDs(ctx, ownerID, ag.ID), []AgentPolicyRPCPayload{payload})
+	}
+
+	// offline agents receive the rendered policies on the full list sent when they connect
+	agents, err := svc.agentRepo.RetrieveAllByAgentGroupID(ctx, ownerID, ag.ID, true)
+	if err != nil {
+		return err
+	}
+	if !template.HasVariables(payload.Data) && allRunGroupPolicy(agents, excluded) {
+		ids := make([]string, len(agents))
+		for i, a := range agents {
+			ids[i] = a.MFThingID
+		}
+		return svc.publishPolicies(ctx, ag.MFChannelID, groupRPCSchemaVersion(), ids, []AgentPolicyRPCPayload{payload})
+	}
+	for _, member := range agents {
+		if !runsGroupPolicy(member, excluded) {
+			continue
+		}
+		a, err := svc.agentRepo.RetrieveByIDWithChannel(ctx, member.MFThingID, member.MFChannelID)
+		if err != nil {
+			svc.logger.Error("failed to retrieve agent to render policy", zap.String("agent_id", member.MFThingID), zap.Error(err))
+			continue
+		}
+		agentPayload := payload
+		agentPayload.Data, err = renderAgentPolicy(a, payload.Data)
+		if err != nil {
+			svc.logger.Warn("skipping policy, failed to render template for agent", zap.String("policy_id", payload.ID),
+				zap.String("agent_id", a.MFThingID), zap.Error(err))
+			continue
+		}
+		if err := svc.publishPolicies(ctx, a.MFChannelID, agentRPCSchemaVersion(a), []string{a.MFThingID}, []AgentPolicyRPCPayload{agentPayload}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runsGroupPolicy tells whether a member of the group is sent the policy, the agents in maintenance and the ones
+// excluded from the dataset are not
+func runsGroupPolicy(a Agent, excluded map[string]bool) bool {
+	return !a.InMaintenance && !excluded[a.MFThingID]
+}
+
+func allRunGroupPolicy(agents []Agent, excluded map[string]bool) bool {
+	for _, a := range agents {
+		if !runsGroupPolicy(a, excluded) {
+			return false
+		}
+	}
+	return true
+}
+
+// datasetExclusions retrieves the agents excluded from a dataset, only when its policy is sent as removing it
+// reaches every agent of the group
+func (svc fleetCommsService) datasetExclusions(ctx context.Context, ownerID string, datasetID string, manage bool) (map[string]bool, error) {
+	if !manage {
+		return nil, nil
+	}
+	ds, err := svc.policyClient.RetrieveDataset(ctx, &pb.DatasetByIDReq{DatasetID: datasetID, OwnerID: ownerID})
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool, len(ds.ExcludedAgentIds))
+	for _, id := range ds.ExcludedAgentIds {
+		excluded[id] = true
+	}
+	return excluded, nil
+}
+
+// groupPolicyExclusions retrieves the agents excluded from every dataset binding the policy to the group
+func (svc fleetCommsService) groupPolicyExclusions(ctx context.Context, ownerID string, groupID string, policyID string) (map[string]bool, error) {
+	list, err := svc.policyClient.RetrievePoliciesByGroups(ctx, &pb.PoliciesByGroupsReq{GroupIDs: []string{groupID}, OwnerID: ownerID})
+	if err != nil {
+		return nil, err
+	}
+	var excluded map[string]bool
+	for _, p := range list.Policies {
+		if p.Id != policyID {
+			continue
+		}
+		inDataset := make(map[string]bool, len(p.ExcludedAgentIds))
+		for _, id := range p.ExcludedAgentIds {
+			if excluded == nil || excluded[id] {
+				inDataset[id] = true
+			}
+		}
+		excluded = inDataset
+	}
+	return excluded, nil
+}
+
+func (svc fleetCommsService) publishPolicies(ctx context.Context, channelID string, schemaVersion string, agentIDs []string, payload []AgentPolicyRPCPayload) error {
+	data := AgentPolicyRPC{
+		CorrelationID: uuid.NewString(),
+		Func:          AgentPolicyRPCFunc,
+		Payload:       payload,
+		FullList:      false,
+	}
+	return svc.publishRPC(ctx, channelID, schemaVersion, agentIDs, data.CorrelationID, data.Func, data)
+}
+
+// publishRPC publishes an RPC in the given schema version on a channel, tracking its delivery to each of the given
+// agents until they acknowledge it. Tracking is best effort, an RPC that could not be tracked is still published
+func (svc fleetCommsService) publishRPC(ctx context.Context, channelID string, schemaVersion string, agentIDs []string, correlationID string, rpcFunc string, data interface{}) error {
+	body, err := encodeRPC(schemaVersion, data)
+	if err != nil {
+		return err
+	}
+
+	// tracked first, so the acknowledgement cannot arrive before
+	for _, agentID := range agentIDs {
+		rpc := AgentRPC{
+			CorrelationID: correlationID,
+			AgentID:       agentID,
+			ChannelID:     channelID,
+			Func:          rpcFunc,
+			Payload:       body,
+			Status:        RPCPending,
+			Attempts:      1,
+		}
+		if err := svc.agentRPCRepo.Save(ctx, rpc); err != nil {
+			svc.logger.Warn("failed to track agent RPC", zap.String("agent_id", agentID), zap.String("func", rpcFunc),
+				zap.String("correlation_id", correlationID), zap.Error(err))
+		}
+	}
+
+	msg := messaging.Message{
+		Channel:   channelID,
+		Subtopic:  RPCFromCoreTopic,
+		Publisher: publisher,
+		Payload:   body,
+		Created:   time.Now().UnixNano(),
+	}
+	return svc.agentPubSub.Publish(msg.Channel, msg)
+}
+
+// supersedeAgentRPCs stops sending again the RPCs of the given funcs the agent did not acknowledge yet, before a full
+// list replacing them is published, so a late retry cannot roll the agent back to an older state
+func (svc fleetCommsService) supersedeAgentRPCs(ctx context.Context, agentID string, rpcFuncs ...string) {
+	count, err := svc.agentRPCRepo.Supersede(ctx, agentID, rpcFuncs)
+	if err != nil {
+		svc.logger.Warn("failed to supersede agent RPCs", zap.String("agent_id", agentID), zap.Strings("funcs", rpcFuncs), zap.Error(err))
+		return
+	}
+	if count > 0 {
+		svc.logger.Debug("agent RPCs superseded by a full list", zap.String("agent_id", agentID), zap.Int64("superseded", count))
+	}
+}
+
+// agentRPCSchemaVersion is the RPC schema version negotiated with the agent, read from the metadata of the agent as
+// loaded, the oldest supported one until the agent advertised the versions it speaks
+func agentRPCSchemaVersion(a Agent) string {
+	if versions, ok := agentSchemaVersions(a); ok && SupportsSchemaVersion(SupportedRPCSchemaVersions, versions.RPC) {
+		return versions.RPC
+	}
+	return SupportedRPCSchemaVersions[0]
+}
+
+// groupRPCSchemaVersion is the RPC schema version of the RPCs published on group channels, the oldest supported one
+// so every agent of the group understands them
+func groupRPCSchemaVersion() string {
+	return SupportedRPCSchemaVersions[0]
+}
+
+// groupAgentIDs lists the agents an RPC published on a group channel is tracked for. Offline agents are left out,
+// they receive their full group memberships and policies when they connect
+func (svc fleetCommsService) groupAgentIDs(ctx context.Context, ownerID string, groupID string) []string {
+	agents, err := svc.agentRepo.RetrieveAllByAgentGroupID(ctx, ownerID, groupID, true)
+	if err != nil {
+		svc.logger.Warn("failed to retrieve agent group members to track RPC", zap.String("group_id", groupID), zap.Error(err))
+		return nil
+	}
+	ids := make([]string, len(agents))
+	for i, a := range agents {
+		ids[i] = a.MFThingID
+	}
+	return ids
+}
+
+func (svc fleetCommsService) RetryAgentRPCs(ctx context.Context) error {
+	sentBefore := time.Now().Add(-RPCAckTimeout)
+	expired, err := svc.agentRPCRepo.ExpirePending(ctx, sentBefore, RPCMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		svc.logger.Warn("agents did not acknowledge RPCs", zap.Int64("timed_out", expired))
+	}
+
+	rpcs, err := svc.agentRPCRepo.ClaimRetries(ctx, sentBefore, RPCMaxAttempts)
+	if err != nil {
+		return err
+	}
+	// an RPC published on a group channel is tracked for each agent of the group, but sent again once
+	sent := make(map[string]bool)
+	for _, rpc := range rpcs {
+		key := rpc.ChannelID + "." + rpc.CorrelationID
+		if sent[key] {
+			continue
+		}
+		sent[key] = true
+		msg := messaging.Message{
+			Channel:   rpc.ChannelID,
+			Subtopic:  RPCFromCoreTopic,
+			Publisher: publisher,
+			Payload:   rpc.Payload,
+			Created:   time.Now().UnixNano(),
+		}
+		if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
+			svc.logger.Error("failed to send agent RPC again", zap.String("correlation_id", rpc.CorrelationID), zap.Error(err))
+			continue
+		}
+		svc.logger.Debug("agent RPC sent again", zap.String("agent_id", rpc.AgentID), zap.String("func", rpc.Func),
+			zap.String("correlation_id", rpc.CorrelationID), zap.Int("attempts", rpc.Attempts))
+	}
+
+	if _, err := svc.agentRPCRepo.DeleteOlderThan(ctx, time.Now().Add(-RPCRetention)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ackAgentRequest acknowledges a request carrying a correlation id, once the replies it asked for are published, so
+// the agent stops sending it again. Requests of agents not tagging them are not acknowledged
+func (svc fleetCommsService) ackAgentRequest(channelID string, schemaVersion string, correlationID string, reqErr error) {
+	if correlationID == "" {
+		return
+	}
+	data := RPCAckRPC{
+		Func: RPCAckRPCFunc,
+		Payload: RPCAckRPCPayload{
+			CorrelationID: correlationID,
+			Status:        RPCApplied,
+		},
+	}
+	if reqErr != nil {
+		data.Payload.Status = RPCFailed
+		data.Payload.Error = reqErr.Error()
+	}
+	body, err := encodeRPC(schemaVersion, data)
+	if err != nil {
+		svc.logger.Error("failed to encode agent request acknowledgement", zap.String("correlation_id", correlationID), zap.Error(err))
+		return
+	}
+	// not tracked, the agent sends the request again when the acknowledgement is lost
+	msg := messaging.Message{
+		Channel:   channelID,
+		Subtopic:  RPCFromCoreTopic,
+		Publisher: publisher,
+		Payload:   body,
+		Created:   time.Now().UnixNano(),
+	}
+	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
+		svc.logger.Error("failed to acknowledge agent request", zap.String("correlation_id", correlationID), zap.Error(err))
+	}
+}
+
+// handleRPCAck records the outcome an agent reported for an RPC it received
+func (svc fleetCommsService) handleRPCAck(ctx context.Context, thingID string, ack RPCAckRPCPayload) error {
+	if _, err := uuid.Parse(ack.CorrelationID); err != nil {
+		return ErrSchemaMalformed
+	}
+	switch ack.Status {
+	case RPCApplied, RPCFailed, RPCIgnored:
+	default:
+		return ErrSchemaMalformed
+	}
+	if ack.Status == RPCFailed {
+		svc.logger.Warn("agent failed to handle RPC", zap.String("agent_id", thingID), zap.String("correlation_id", ack.CorrelationID),
+			zap.String("error", ack.Error))
+	}
+	return svc.agentRPCRepo.UpdateStatus(ctx, thingID, ack.CorrelationID, ack.Status, ack.Error)
+}
+
+func (svc fleetCommsService) NotifyAgentPolicyUpdate(ctx context.Context, a Agent, groupID string, policyID string) error {
+	// MQTT he doesn't have OwnerID, we need to look it up
+	a, err := svc.agentRepo.RetrieveByIDWithChannel(ctx, a.MFThingID, a.MFChannelID)
+	if err != nil {
+		return err
+	}
+	// the policy is sent with the others when the agent leaves maintenance
+	if a.InMaintenance {
+		return ErrAgentPolicySkipped
+	}
+	excluded, err := svc.groupPolicyExclusions(ctx, a.MFOwnerID, groupID, policyID)
+	if err != nil {
+		return err
+	}
+	if excluded[a.MFThingID] {
+		return ErrAgentPolicySkipped
+	}
+
+	payload, err := svc.RenderAgentPolicy(ctx, a, policyID)
+	if err != nil {
+		return err
+	}
+	payload.AgentGroupID = groupID
+
+	return svc.publishPolicies(ctx, a.MFChannelID, agentRPCSchemaVersion(a), []string{a.MFThingID}, []AgentPolicyRPCPayload{payload})
+}
+
+func (svc fleetCommsService) RenderAgentPolicy(ctx context.Context, a Agent, policyID string) (AgentPolicyRPCPayload, error) {
+	p, err := svc.policyClient.RetrievePolicy(ctx, &pb.PolicyByIDReq{PolicyID: policyID, OwnerID: a.MFOwnerID})
+	if err != nil {
+		return AgentPolicyRPCPayload{}, err
+	}
+	var pdata interface{}
+	if p.Format == "yaml" {
+		if err := yaml.Unmarshal(p.Data, &pdata); err != nil {
+			return AgentPolicyRPCPayload{}, err
+		}
+	} else {
+		if err := json.Unmarshal(p.Data, &pdata); err != nil {
+			return AgentPolicyRPCPayload{}, err
+		}
+	}
+
+	pdata, err = renderAgentPolicy(a, pdata)
+	if err != nil {
+		return AgentPolicyRPCPayload{}, err
+	}
+
+	return AgentPolicyRPCPayload{
+		Action:  "manage",
+		ID:      p.Id,
+		Name:    p.Name,
+		Backend: p.Backend,
+		Version: p.Version,
+		Format:  p.Format,
+		Data:    pdata,
+	}, nil
+}
+
+// renderAgentPolicy replaces the template variables of the policy data with the agent values
+func renderAgentPolicy(a Agent, pdata interface{}) (interface{}, error) {
+	if !template.HasVariables(pdata) {
+		return pdata, nil
+	}
+	var orbTags types.Tags
+	if a.OrbTags != nil {
+		orbTags = *a.OrbTags
+	}
+	return template.Render(pdata, template.NewAgent(a.MFThingID, a.Name.String(), a.AgentTags, orbTags))
+}
+
+func (svc fleetCommsService) TestAgentPolicy(ctx context.Context, a Agent, policyID string) (PolicyTestResultRPCPayload, error) {
+	policy, err := svc.RenderAgentPolicy(ctx, a, policyID)
+	if err != nil {
+		return PolicyTestResultRPCPayload{}, err
+	}
+
+	requestID := uuid.NewString()
+	data := PolicyTestRPC{
+		Func: PolicyTestRPCFunc,
+		Payload: PolicyTestRPCPayload{
+			RequestID: requestID,
+			Policy:    policy,
+		},
+	}
+	reply, err := svc.requestAgent(ctx, a, PolicyTestTopic, requestID, data, PolicyTestTimeout, ErrPolicyTestTimeout)
+	if err != nil {
+		return PolicyTestResultRPCPayload{}, err
+	}
+	var result PolicyTestResultRPCPayload
+	if err := json.Unmarshal(reply, &result); err != nil {
+		return PolicyTestResultRPCPayload{}, err
+	}
+	return result, nil
+}
+
+func (svc fleetCommsService) StageAgentCredentials(ctx context.Context, a Agent, requestID string, key string) error {
+	data := AgentCredentialsRPC{
+		Func: AgentCredentialsRPCFunc,
+		Payload: AgentCredentialsRPCPayload{
+			RequestID: requestID,
+			Key:       key,
+		},
+	}
+	reply, err := svc.requestAgent(ctx, a, CredentialsTopic, requestID, data, CredentialsRotationTimeout, ErrCredentialsRotationTimeout)
+	if err != nil {
+		return err
+	}
+	var ack AgentCredentialsAckRPCPayload
+	if err := json.Unmarshal(reply, &ack); err != nil {
+		return err
+	}
+	if ack.Error != "" {
+		return errors.Wrap(ErrStageAgentCredentials, errors.New(ack.Error))
+	}
+	return nil
+}
+
+func (svc fleetCommsService) CommitAgentCredentials(ctx context.Context, a Agent, requestID string) error {
+	data := AgentCredentialsCommitRPC{
+		Func:    AgentCredentialsCommitRPCFunc,
+		Payload: AgentCredentialsCommitRPCPayload{RequestID: requestID},
+	}
+	body, err := encodeRPC(agentRPCSchemaVersion(a), data)
+	if err != nil {
+		return err
+	}
+	msg := messaging.Message{
+		Channel:   a.MFChannelID,
+		Subtopic:  RPCFromCoreTopic,
+		Publisher: publisher,
+		Payload:   body,
+		Created:   time.Now().UnixNano(),
+	}
+	return svc.agentPubSub.Publish(msg.Channel, msg)
+}
+
+// requestAgent sends an RPC to the agent and waits for its reply. The reply may reach any fleet instance, which
+// relays it on a subject of the topic and request id only this request listens to
+func (svc fleetCommsService) requestAgent(ctx context.Context, a Agent, topic string, requestID string, rpc interface{}, timeout time.Duration, errTimeout error) ([]byte, error) {
+	replies := make(chan []byte, 1)
+	subject := fmt.Sprintf("channels.%s.%s.%s", a.MFChannelID, topic, requestID)
+	err := svc.agentPubSub.Subscribe(subject, func(msg messaging.Message) error {
+		if msg.Publisher != a.MFThingID {
+			return nil
+		}
+		select {
+		case replies <- msg.Payload:
+		default:
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := svc.agentPubSub.Unsubscribe(subject); err != nil {
+			svc.logger.Warn("failed to unsubscribe from agent reply", zap.String("topic", topic), zap.String("request_id", requestID), zap.Error(err))
+		}
+	}()
+
+	body, err := encodeRPC(agentRPCSchemaVersion(a), rpc)
+	if err != nil {
+		return nil, err
+	}
+	msg := messaging.Message{
+		Channel:   a.MFChannelID,
+		Subtopic:  RPCFromCoreTopic,
+		Publisher: publisher,
+		Payload:   body,
+		Created:   time.Now().UnixNano(),
+	}
+	if err := svc.agentPubSub.Publish(msg.Channel, msg); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-timer.C:
+		return nil, errTimeout
+	case <-ctx.Done():
+		return nil, errTimeout
+	}
+}
+
+// relayAgentReply forwards the reply of an agent to the fleet instance waiting for it
+func (svc fleetCommsService) relayAgentReply(thingID string, channelID string, topic string, requestID string, reply interface{}) error {
+	// the request id ends up in a subject, so it must be exactly what was sent to the agent
+	if id, err := uuid.Parse(requestID); err != nil || id.String() != requestID {
+		return ErrSchemaMalformed
+	}
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	msg := messaging.Message{
+		Channel:   channelID,
+		Subtopic:  requestID,
+		Publisher: thingID,
+		Payload:   body,
+		Created:   time.Now().UnixNano(),
+	}
+	return svc.agentPubSub.Publish(fmt.Sprintf("%s.%s", channelID, topic), msg)
+}
+
+func (svc fleetCommsService) RequestAgentDiagnostics(ctx context.Context, a Agent, bundleID string) error {
+	data := AgentDiagnosticsRPC{
+		CorrelationID: uuid.NewString(),
+		Func:          AgentDiagnosticsRPCFunc,
+		Payload: AgentDiagnosticsRPCPayload{
+			BundleID:  bundleID,
+			ChunkSize: DiagnosticsChunkSize,
+		},
+	}
+	return svc.publishRPC(ctx, a.MFChannelID, agentRPCSchemaVersion(a), []string{a.MFThingID}, data.CorrelationID, data.Func, data)
+}
+
+// handleDiagnosticsChunk stores a chunk of a diagnostic bundle, assembling the bundle once all of them were received.
+// Chunks may reach different fleet instances, so they are kept in the repository until then
+func (svc fleetCommsService) handleDiagnosticsChunk(ctx context.Context, thingID string, chunk AgentDiagnosticsChunkRPCPayload) error {
+	if _, err := uuid.Parse(chunk.BundleID); err != nil {
+		return ErrSchemaMalformed
+	}
+	b, err := svc.diagnosticsRepo.RetrieveByID(ctx, chunk.BundleID)
+	if err != nil {
+		return err
+	}
+	if b.AgentID != thingID {
+		return ErrNotFound
+	}
+	if b.Status != DiagnosticsCollecting {
+		return nil
+	}
+
+	if chunk.Error != "" {
+		svc.logger.Warn("agent failed to collect diagnostic bundle", zap.String("agent_id", thingID),
+			zap.String("bundle_id", chunk.BundleID), zap.String("error", chunk.Error))
+		return svc.diagnosticsRepo.Fail(ctx, chunk.BundleID, chunk.Error)
+	}
+	if chunk.Total < 1 || chunk.Total > DiagnosticsMaxChunks || chunk.Seq < 0 || chunk.Seq >= chunk.Total ||
+		len(chunk.Data) > DiagnosticsChunkSize || (b.Chunks > 0 && chunk.Total != b.Chunks) {
+		return ErrSchemaMalformed
+	}
+
+	received, err := svc.diagnosticsRepo.SaveChunk(ctx, chunk.BundleID, chunk.Seq, chunk.Total, chunk.Data)
+	if err != nil {
+		return err
+	}
+	if received < chunk.Total {
+		return nil
+	}
+
+	chunks, err := svc.diagnosticsRepo.RetrieveChunks(ctx, chunk.BundleID)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	for _, c := range chunks {
+		data = append(data, c...)
+	}
+	if err := svc.diagnosticsRepo.Complete(ctx, chunk.BundleID, data); err != nil {
+		// another fleet instance received the last chunk at the same time and already completed the bundle
+		if errors.Contains(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	svc.logger.Info("agent diagnostic bundle received", zap.String("agent_id", thingID), zap.String("bundle_id", chunk.BundleID),
+		zap.Int("size", len(data)))
+	return nil
+}
@@ -10,6 +10,8 @@ type RPC struct {
 	SchemaVersion string      `json:"schema_version"`
 	Func          string      `json:"func"`
 	Payload       interface{} `json:"payload"`
+	// CorrelationID identifies a core to edge RPC the agent acknowledges with an RPCAckRPC
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // Core -> Edge
@@ -20,6 +22,7 @@ type GroupMembershipRPC struct {
 	SchemaVersion string                    `json:"schema_version"`
 	Func          string                    `json:"func"`
 	Payload       GroupMembershipRPCPayload `json:"payload"`
+	CorrelationID string                    `json:"correlation_id,omitempty"`
 }
 
 type GroupMembershipData struct {
@@ -40,6 +43,7 @@ type AgentPolicyRPC struct {
 	Func          string                  `json:"func"`
 	Payload       []AgentPolicyRPCPayload `json:"payload"`
 	FullList      bool                    `json:"full_list"`
+	CorrelationID string                  `json:"correlation_id,omitempty"`
 }
 
 type AgentPolicyRPCPayload struct {
@@ -60,6 +64,7 @@ type GroupRemovedRPC struct {
 	SchemaVersion string                 `json:"schema_version"`
 	Func          string                 `json:"func"`
 	Payload       GroupRemovedRPCPayload `json:"payload"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
 }
 
 type GroupRemovedRPCPayload struct {
@@ -74,6 +79,7 @@ type DatasetRemovedRPC struct {
 	SchemaVersion string                   `json:"schema_version"`
 	Func          string                   `json:"func"`
 	Payload       DatasetRemovedRPCPayload `json:"payload"`
+	CorrelationID string                   `json:"correlation_id,omitempty"`
 }
 
 type DatasetRemovedRPCPayload struct {
@@ -91,6 +97,7 @@ type AgentStopRPC struct {
 	SchemaVersion string              `json:"schema_version"`
 	Func          string              `json:"func"`
 	Payload       AgentStopRPCPayload `json:"payload"`
+	CorrelationID string              `json:"correlation_id,omitempty"`
 }
 
 const AgentResetRPCFunc = "agent_reset"
@@ -104,6 +111,64 @@ type AgentResetRPC struct {
 	SchemaVersion string               `json:"schema_version"`
 	Func          string               `json:"func"`
 	Payload       AgentResetRPCPayload `json:"payload"`
+	CorrelationID string               `json:"correlation_id,omitempty"`
+}
+
+const PolicyTestRPCFunc = "policy_test"
+
+// PolicyTestRPC asks the agent to validate a policy with its backend, without running it
+type PolicyTestRPC struct {
+	SchemaVersion string               `json:"schema_version"`
+	Func          string               `json:"func"`
+	Payload       PolicyTestRPCPayload `json:"payload"`
+}
+
+type PolicyTestRPCPayload struct {
+	RequestID string                `json:"request_id"`
+	Policy    AgentPolicyRPCPayload `json:"policy"`
+}
+
+const AgentCredentialsRPCFunc = "agent_credentials"
+
+// AgentCredentialsRPC hands the agent a new key, which it stores without using it until the rotation is committed
+type AgentCredentialsRPC struct {
+	SchemaVersion string                     `json:"schema_version"`
+	Func          string                     `json:"func"`
+	Payload       AgentCredentialsRPCPayload `json:"payload"`
+}
+
+type AgentCredentialsRPCPayload struct {
+	RequestID string `json:"request_id"`
+	Key       string `json:"key"`
+}
+
+const AgentCredentialsCommitRPCFunc = "agent_credentials_commit"
+
+// AgentCredentialsCommitRPC tells the agent its previous key was revoked, so it reconnects with the stored one
+type AgentCredentialsCommitRPC struct {
+	SchemaVersion string                           `json:"schema_version"`
+	Func          string                           `json:"func"`
+	Payload       AgentCredentialsCommitRPCPayload `json:"payload"`
+}
+
+type AgentCredentialsCommitRPCPayload struct {
+	RequestID string `json:"request_id"`
+}
+
+const AgentDiagnosticsRPCFunc = "agent_diagnostics"
+
+// AgentDiagnosticsRPC asks the agent to collect a diagnostic bundle and upload it in AgentDiagnosticsChunkRPCs
+type AgentDiagnosticsRPC struct {
+	SchemaVersion string                     `json:"schema_version"`
+	Func          string                     `json:"func"`
+	Payload       AgentDiagnosticsRPCPayload `json:"payload"`
+	CorrelationID string                     `json:"correlation_id,omitempty"`
+}
+
+type AgentDiagnosticsRPCPayload struct {
+	BundleID string `json:"bundle_id"`
+	// ChunkSize is the most bundle bytes to upload per chunk
+	ChunkSize int `json:"chunk_size"`
 }
 
 // Edge -> Core
@@ -136,3 +201,67 @@ type AgentMetricsRPCPayload struct {
 	BEVersion  string   `json:"be_version"`
 	Data       []byte   `json:"data"`
 }
+
+const PolicyTestResultRPCFunc = "policy_test_result"
+
+type PolicyTestResultRPC struct {
+	SchemaVersion string                     `json:"schema_version"`
+	Func          string                     `json:"func"`
+	Payload       PolicyTestResultRPCPayload `json:"payload"`
+}
+
+type PolicyTestResultRPCPayload struct {
+	RequestID string `json:"request_id"`
+	PolicyID  string `json:"policy_id"`
+	Backend   string `json:"backend"`
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+}
+
+const AgentCredentialsAckRPCFunc = "agent_credentials_ack"
+
+type AgentCredentialsAckRPC struct {
+	SchemaVersion string                        `json:"schema_version"`
+	Func          string                        `json:"func"`
+	Payload       AgentCredentialsAckRPCPayload `json:"payload"`
+}
+
+type AgentCredentialsAckRPCPayload struct {
+	RequestID string `json:"request_id"`
+	Error     string `json:"error,omitempty"`
+}
+
+const AgentDiagnosticsChunkRPCFunc = "agent_diagnostics_chunk"
+
+type AgentDiagnosticsChunkRPC struct {
+	SchemaVersion string                          `json:"schema_version"`
+	Func          string                          `json:"func"`
+	Payload       AgentDiagnosticsChunkRPCPayload `json:"payload"`
+}
+
+// AgentDiagnosticsChunkRPCPayload is the chunk Seq of the Total ones of a diagnostic bundle. An agent failing to
+// collect the bundle sends a single chunk with the Error instead
+type AgentDiagnosticsChunkRPCPayload struct {
+	BundleID string `json:"bundle_id"`
+	Seq      int    `json:"seq"`
+	Total    int    `json:"total"`
+	Data     []byte `json:"data,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+const RPCAckRPCFunc = "rpc_ack"
+
+// RPCAckRPC reports the outcome of a core to edge RPC carrying a correlation id. Core sends it back to the agent
+// for the edge to core requests carrying one
+type RPCAckRPC struct {
+	SchemaVersion string           `json:"schema_version"`
+	Func          string           `json:"func"`
+	Payload       RPCAckRPCPayload `json:"payload"`
+}
+
+type RPCAckRPCPayload struct {
+	CorrelationID string `json:"correlation_id"`
+	// Status is one of RPCApplied, RPCFailed or RPCIgnored
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
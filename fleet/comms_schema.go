@@ -0,0 +1,238 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	SchemaKindCapabilities = "capabilities"
+	SchemaKindHeartbeat    = "heartbeat"
+	SchemaKindRPC          = "rpc"
+
+	// SchemaVersionsCheckFreq is how often the number of agents speaking each schema version is measured
+	SchemaVersionsCheckFreq = time.Minute
+
+	// agentSchemaVersionsKey is the agent metadata key of the schema versions negotiated with the agent
+	agentSchemaVersionsKey = "schema_versions"
+)
+
+// The schema versions fleet speaks for each kind of message, oldest first. A version is dropped from a window once
+// no supported agent release speaks it anymore
+var (
+	SupportedCapabilitiesSchemaVersions = []string{CurrentCapabilitiesSchemaVersion}
+	SupportedHeartbeatSchemaVersions    = []string{CurrentHeartbeatSchemaVersion}
+	SupportedRPCSchemaVersions          = []string{CurrentRPCSchemaVersion}
+)
+
+// SchemaVersions lists the schema versions spoken for each kind of message
+type SchemaVersions struct {
+	Capabilities []string `json:"capabilities"`
+	Heartbeat    []string `json:"heartbeat"`
+	RPC          []string `json:"rpc"`
+}
+
+// legacySchemaVersions is what the agents that do not advertise their schema versions speak
+var legacySchemaVersions = SchemaVersions{
+	Capabilities: []string{"1.0"},
+	Heartbeat:    []string{"1.0"},
+	RPC:          []string{"1.0"},
+}
+
+// AgentSchemaVersions is the schema version of each kind of message fleet and an agent agreed on
+type AgentSchemaVersions struct {
+	Capabilities string `json:"capabilities"`
+	Heartbeat    string `json:"heartbeat"`
+	RPC          string `json:"rpc"`
+}
+
+// SchemaVersionCount is the number of online agents speaking a schema version for a kind of message
+type SchemaVersionCount struct {
+	Kind    string
+	Version string
+	Count   int
+}
+
+// decoders from each supported schema version into the internal model
+var (
+	capabilitiesDecoders = map[string]func(payload []byte) (Capabilities, error){
+		"1.0": decodeCapabilitiesV1,
+	}
+	heartbeatDecoders = map[string]func(payload []byte) (Heartbeat, error){
+		"1.0": decodeHeartbeatV1,
+	}
+	// rpcEncoders translate the RPCs fleet builds, always in the current schema, into each supported schema version.
+	// The version itself is stamped by encodeRPC
+	rpcEncoders = map[string]func(rpc interface{}) ([]byte, error){
+		"1.0": json.Marshal,
+	}
+)
+
+func decodeCapabilitiesV1(payload []byte) (Capabilities, error) {
+	var capabilities Capabilities
+	if err := json.Unmarshal(payload, &capabilities); err != nil {
+		return Capabilities{}, ErrSchemaMalformed
+	}
+	// advertising schema versions was added to 1.0, agents released before speak the legacy versions
+	if len(capabilities.SchemaVersions.RPC) == 0 {
+		capabilities.SchemaVersions = legacySchemaVersions
+	}
+	return capabilities, nil
+}
+
+func decodeHeartbeatV1(payload []byte) (Heartbeat, error) {
+	var hb Heartbeat
+	if err := json.Unmarshal(payload, &hb); err != nil {
+		return Heartbeat{}, ErrSchemaMalformed
+	}
+	return hb, nil
+}
+
+// DecodeCapabilities decodes capabilities in any supported schema version, returning the version they were in
+func DecodeCapabilities(payload []byte) (Capabilities, string, error) {
+	version, err := schemaVersion(payload, SupportedCapabilitiesSchemaVersions)
+	if err != nil {
+		return Capabilities{}, "", err
+	}
+	capabilities, err := capabilitiesDecoders[version](payload)
+	return capabilities, version, err
+}
+
+// DecodeHeartbeat decodes a heartbeat in any supported schema version, returning the version it was in
+func DecodeHeartbeat(payload []byte) (Heartbeat, string, error) {
+	version, err := schemaVersion(payload, SupportedHeartbeatSchemaVersions)
+	if err != nil {
+		return Heartbeat{}, "", err
+	}
+	hb, err := heartbeatDecoders[version](payload)
+	return hb, version, err
+}
+
+// schemaVersion reads the schema version of a message, which must be one of the supported versions
+func schemaVersion(payload []byte, supported []string) (string, error) {
+	var versionCheck SchemaVersionCheck
+	if err := json.Unmarshal(payload, &versionCheck); err != nil {
+		return "", ErrSchemaMalformed
+	}
+	if !SupportsSchemaVersion(supported, versionCheck.SchemaVersion) {
+		return "", ErrSchemaVersion
+	}
+	return versionCheck.SchemaVersion, nil
+}
+
+// SupportsSchemaVersion tells whether a version is part of the supported ones
+func SupportsSchemaVersion(supported []string, version string) bool {
+	for _, v := range supported {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateSchemaVersion chooses the most recent of the supported versions, oldest first, the agent also speaks
+func NegotiateSchemaVersion(supported []string, advertised []string) (string, error) {
+	for i := len(supported) - 1; i >= 0; i-- {
+		if SupportsSchemaVersion(advertised, supported[i]) {
+			return supported[i], nil
+		}
+	}
+	return "", ErrSchemaVersion
+}
+
+// negotiateAgentSchemaVersions agrees with an agent on the schema version of each kind of message
+func negotiateAgentSchemaVersions(capabilitiesVersion string, advertised SchemaVersions) (AgentSchemaVersions, error) {
+	heartbeat, err := NegotiateSchemaVersion(SupportedHeartbeatSchemaVersions, advertised.Heartbeat)
+	if err != nil {
+		return AgentSchemaVersions{}, err
+	}
+	rpc, err := NegotiateSchemaVersion(SupportedRPCSchemaVersions, advertised.RPC)
+	if err != nil {
+		return AgentSchemaVersions{}, err
+	}
+	return AgentSchemaVersions{
+		Capabilities: capabilitiesVersion,
+		Heartbeat:    heartbeat,
+		RPC:          rpc,
+	}, nil
+}
+
+// agentSchemaVersions retrieves the schema versions negotiated with an agent from its metadata
+func agentSchemaVersions(a Agent) (AgentSchemaVersions, bool) {
+	md, ok := a.AgentMetadata[agentSchemaVersionsKey]
+	if !ok {
+		return AgentSchemaVersions{}, false
+	}
+	// stored as is, or decoded back from the database as a generic map
+	body, err := json.Marshal(md)
+	if err != nil {
+		return AgentSchemaVersions{}, false
+	}
+	var versions AgentSchemaVersions
+	if err := json.Unmarshal(body, &versions); err != nil || versions.RPC == "" {
+		return AgentSchemaVersions{}, false
+	}
+	return versions, true
+}
+
+// encodeRPC encodes an RPC in the given schema version, stamping the version on it
+func encodeRPC(version string, rpc interface{}) ([]byte, error) {
+	encode, ok := rpcEncoders[version]
+	if !ok {
+		return nil, ErrSchemaVersion
+	}
+	body, err := encode(rpc)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	fields["schema_version"], err = json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// MonitorSchemaVersions measures the number of online agents speaking each schema version every freq until the
+// context is done
+func MonitorSchemaVersions(ctx context.Context, logger *zap.Logger, agentRepo AgentRepository, gauge metrics.Gauge, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	// a schema version the last online agent speaking it moved away from is set to zero, otherwise the gauge would
+	// keep counting agents long upgraded
+	measured := make(map[SchemaVersionCount]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			counts, err := agentRepo.RetrieveSchemaVersionCounts(ctx, legacySchemaVersions)
+			if err != nil {
+				logger.Error("failed to count agents per schema version", zap.Error(err))
+				continue
+			}
+			current := make(map[SchemaVersionCount]bool)
+			for _, c := range counts {
+				gauge.With("kind", c.Kind, "version", c.Version).Set(float64(c.Count))
+				current[SchemaVersionCount{Kind: c.Kind, Version: c.Version}] = true
+			}
+			for c := range measured {
+				if !current[c] {
+					gauge.With("kind", c.Kind, "version", c.Version).Set(0)
+				}
+			}
+			measured = current
+		}
+	}
+}
@@ -0,0 +1,140 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeRPCV2 is a schema version renaming the payload of the RPCs to params
+func encodeRPCV2(rpc interface{}) ([]byte, error) {
+	body, err := json.Marshal(rpc)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	fields["params"] = fields["payload"]
+	delete(fields, "payload")
+	return json.Marshal(fields)
+}
+
+func TestNegotiatedRPCSchemaVersion(t *testing.T) {
+	supported := SupportedRPCSchemaVersions
+	SupportedRPCSchemaVersions = []string{CurrentRPCSchemaVersion, "2.0"}
+	rpcEncoders["2.0"] = encodeRPCV2
+	defer func() {
+		SupportedRPCSchemaVersions = supported
+		delete(rpcEncoders, "2.0")
+	}()
+
+	rpc := RPC{
+		Func:    GroupMembershipRPCFunc,
+		Payload: GroupMembershipRPCPayload{FullList: true},
+	}
+
+	cases := map[string]struct {
+		advertised []string
+		version    string
+		field      string
+	}{
+		"agent speaking the current version only": {
+			advertised: []string{CurrentRPCSchemaVersion},
+			version:    CurrentRPCSchemaVersion,
+			field:      "payload",
+		},
+		"agent speaking the second version": {
+			advertised: []string{CurrentRPCSchemaVersion, "2.0"},
+			version:    "2.0",
+			field:      "params",
+		},
+		"agent speaking a version fleet lacks": {
+			advertised: []string{CurrentRPCSchemaVersion, "3.0"},
+			version:    CurrentRPCSchemaVersion,
+			field:      "payload",
+		},
+		"agent that did not advertise its versions": {
+			advertised: nil,
+			version:    CurrentRPCSchemaVersion,
+			field:      "payload",
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			a := Agent{AgentMetadata: map[string]interface{}{}}
+			if tc.advertised != nil {
+				versions, err := negotiateAgentSchemaVersions(CurrentCapabilitiesSchemaVersion, SchemaVersions{
+					Heartbeat: []string{CurrentHeartbeatSchemaVersion},
+					RPC:       tc.advertised,
+				})
+				require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+				a.AgentMetadata[agentSchemaVersionsKey] = versions
+			}
+
+			body, err := encodeRPC(agentRPCSchemaVersion(a), rpc)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+
+			var wire map[string]interface{}
+			require.Nil(t, json.Unmarshal(body, &wire), fmt.Sprintf("%s: invalid RPC %s", desc, body))
+			assert.Equal(t, tc.version, wire["schema_version"], fmt.Sprintf("%s: expected schema version %s got %v", desc, tc.version, wire["schema_version"]))
+			assert.Contains(t, wire, tc.field, fmt.Sprintf("%s: expected field %s in %s", desc, tc.field, body))
+		})
+	}
+}
+
+func TestNegotiateAgentSchemaVersions(t *testing.T) {
+	supported := SupportedRPCSchemaVersions
+	SupportedRPCSchemaVersions = []string{CurrentRPCSchemaVersion, "2.0"}
+	defer func() {
+		SupportedRPCSchemaVersions = supported
+	}()
+
+	cases := map[string]struct {
+		advertised SchemaVersions
+		versions   AgentSchemaVersions
+		err        error
+	}{
+		"agent speaking every version fleet does": {
+			advertised: SchemaVersions{Heartbeat: []string{CurrentHeartbeatSchemaVersion}, RPC: []string{CurrentRPCSchemaVersion, "2.0"}},
+			versions:   AgentSchemaVersions{Capabilities: CurrentCapabilitiesSchemaVersion, Heartbeat: CurrentHeartbeatSchemaVersion, RPC: "2.0"},
+			err:        nil,
+		},
+		"agent ahead of fleet falling back to the common version": {
+			advertised: SchemaVersions{Heartbeat: []string{CurrentHeartbeatSchemaVersion}, RPC: []string{"2.0", "3.0"}},
+			versions:   AgentSchemaVersions{Capabilities: CurrentCapabilitiesSchemaVersion, Heartbeat: CurrentHeartbeatSchemaVersion, RPC: "2.0"},
+			err:        nil,
+		},
+		"agent behind fleet": {
+			advertised: SchemaVersions{Heartbeat: []string{CurrentHeartbeatSchemaVersion}, RPC: []string{CurrentRPCSchemaVersion}},
+			versions:   AgentSchemaVersions{Capabilities: CurrentCapabilitiesSchemaVersion, Heartbeat: CurrentHeartbeatSchemaVersion, RPC: CurrentRPCSchemaVersion},
+			err:        nil,
+		},
+		"agent without a heartbeat version in common": {
+			advertised: SchemaVersions{Heartbeat: []string{"3.0"}, RPC: []string{CurrentRPCSchemaVersion}},
+			err:        ErrSchemaVersion,
+		},
+		"agent without an RPC version in common": {
+			advertised: SchemaVersions{Heartbeat: []string{CurrentHeartbeatSchemaVersion}, RPC: []string{"3.0"}},
+			err:        ErrSchemaVersion,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			versions, err := negotiateAgentSchemaVersions(CurrentCapabilitiesSchemaVersion, tc.advertised)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			assert.Equal(t, tc.versions, versions, fmt.Sprintf("%s: expected %v got %v", desc, tc.versions, versions))
+		})
+	}
+}
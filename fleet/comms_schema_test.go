@@ -0,0 +1,191 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNegotiateSchemaVersion(t *testing.T) {
+	cases := map[string]struct {
+		supported  []string
+		advertised []string
+		version    string
+		err        error
+	}{
+		"most recent version both speak": {
+			supported:  []string{"1.0", "1.1", "2.0"},
+			advertised: []string{"1.0", "1.1"},
+			version:    "1.1",
+			err:        nil,
+		},
+		"agent ahead of fleet": {
+			supported:  []string{"1.0"},
+			advertised: []string{"1.0", "1.1"},
+			version:    "1.0",
+			err:        nil,
+		},
+		"no version in common": {
+			supported:  []string{"2.0"},
+			advertised: []string{"1.0"},
+			version:    "",
+			err:        fleet.ErrSchemaVersion,
+		},
+		"nothing advertised": {
+			supported:  []string{"1.0"},
+			advertised: nil,
+			version:    "",
+			err:        fleet.ErrSchemaVersion,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			version, err := fleet.NegotiateSchemaVersion(tc.supported, tc.advertised)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			assert.Equal(t, tc.version, version, fmt.Sprintf("%s: expected %s got %s", desc, tc.version, version))
+		})
+	}
+}
+
+func TestDecodeCapabilities(t *testing.T) {
+	cases := map[string]struct {
+		payload  string
+		versions fleet.SchemaVersions
+		err      error
+	}{
+		"capabilities advertising schema versions": {
+			payload:  `{"schema_version":"1.0","orb_agent":{"version":"1.0.0"},"schema_versions":{"capabilities":["1.0"],"heartbeat":["1.0"],"rpc":["1.0","1.1"]}}`,
+			versions: fleet.SchemaVersions{Capabilities: []string{"1.0"}, Heartbeat: []string{"1.0"}, RPC: []string{"1.0", "1.1"}},
+			err:      nil,
+		},
+		"capabilities of an agent released before advertising schema versions": {
+			payload:  `{"schema_version":"1.0","orb_agent":{"version":"1.0.0"}}`,
+			versions: fleet.SchemaVersions{Capabilities: []string{"1.0"}, Heartbeat: []string{"1.0"}, RPC: []string{"1.0"}},
+			err:      nil,
+		},
+		"capabilities in an unsupported schema version": {
+			payload: `{"schema_version":"0.9","orb_agent":{"version":"1.0.0"}}`,
+			err:     fleet.ErrSchemaVersion,
+		},
+		"malformed capabilities": {
+			payload: `{"schema_version":"1.0","orb_agent":"1.0.0"}`,
+			err:     fleet.ErrSchemaMalformed,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			capabilities, _, err := fleet.DecodeCapabilities([]byte(tc.payload))
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, tc.versions, capabilities.SchemaVersions, fmt.Sprintf("%s: expected %v got %v", desc, tc.versions, capabilities.SchemaVersions))
+			}
+		})
+	}
+}
+
+func TestDecodeHeartbeat(t *testing.T) {
+	cases := map[string]struct {
+		payload string
+		state   fleet.State
+		err     error
+	}{
+		"heartbeat in a supported schema version": {
+			payload: `{"schema_version":"1.0","state":1}`,
+			state:   fleet.Online,
+			err:     nil,
+		},
+		"heartbeat in an unsupported schema version": {
+			payload: `{"schema_version":"2.0","state":1}`,
+			err:     fleet.ErrSchemaVersion,
+		},
+		"malformed heartbeat": {
+			payload: `{"schema_version":1}`,
+			err:     fleet.ErrSchemaMalformed,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			hb, _, err := fleet.DecodeHeartbeat([]byte(tc.payload))
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, tc.state, hb.State, fmt.Sprintf("%s: expected %s got %s", desc, tc.state, hb.State))
+			}
+		})
+	}
+}
+
+type recordingGauge struct {
+	mu     *sync.Mutex
+	values map[string]float64
+	labels []string
+}
+
+func (g recordingGauge) With(labelValues ...string) metrics.Gauge {
+	return recordingGauge{mu: g.mu, values: g.values, labels: append(append([]string{}, g.labels...), labelValues...)}
+}
+
+func (g recordingGauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[strings.Join(g.labels, ",")] = value
+}
+
+func (g recordingGauge) Add(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[strings.Join(g.labels, ",")] += delta
+}
+
+func TestMonitorSchemaVersions(t *testing.T) {
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	current := fleet.AgentSchemaVersions{Capabilities: "1.0", Heartbeat: "1.0", RPC: "1.1"}
+	for i, md := range []map[string]interface{}{
+		{"schema_versions": current},
+		{},
+	} {
+		name, err := types.NewIdentifier(fmt.Sprintf("agent-%d", i))
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		err = agentRepo.Save(context.Background(), fleet.Agent{
+			Name:          name,
+			MFThingID:     fmt.Sprintf("agent-%d", i),
+			MFOwnerID:     "owner",
+			State:         fleet.Online,
+			AgentMetadata: md,
+		})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	gauge := recordingGauge{mu: &sync.Mutex{}, values: make(map[string]float64)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	fleet.MonitorSchemaVersions(ctx, zap.NewNop(), agentRepo, gauge, 5*time.Millisecond)
+
+	expected := map[string]float64{
+		"kind,capabilities,version,1.0": 2,
+		"kind,heartbeat,version,1.0":    2,
+		"kind,rpc,version,1.0":          1,
+		"kind,rpc,version,1.1":          1,
+	}
+	gauge.mu.Lock()
+	defer gauge.mu.Unlock()
+	assert.Equal(t, expected, gauge.values, fmt.Sprintf("expected %v got %v", expected, gauge.values))
+}
@@ -2,6 +2,7 @@ package fleet_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gofrs/uuid"
 	"github.com/mainflux/mainflux"
@@ -18,6 +19,7 @@ import (
 	policyGRPC "github.com/orb-community/orb/policies/api/grpc"
 	plmocks "github.com/orb-community/orb/policies/mocks"
 	"github.com/orb-community/orb/policies/pb"
+	"github.com/orb-community/orb/policies/template"
 	sinkmocks "github.com/orb-community/orb/sinks/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,7 +55,7 @@ func newFleetService(auth mainflux.AuthServiceClient, url string, agentGroupRepo
 	mfsdk := mfsdk.NewSDK(config)
 	pktvisor.Register(auth, agentRepo)
 	aDone := make(chan bool)
-	return fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, agentComms, mfsdk, aDone)
+	return fleet.NewFleetService(logger, auth, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), agentComms, mfsdk, fleet.NewThingKeyService(url), "", aDone)
 }
 
 func newPoliciesService(auth mainflux.AuthServiceClient) policies.Service {
@@ -89,6 +91,10 @@ func bufDialer(context.Context, string) (net.Conn, error) {
 }
 
 func newCommsService(agentGroupRepo fleet.AgentGroupRepository, agentRepo fleet.AgentRepository) fleet.AgentCommsService {
+	return newTrackingCommsService(agentGroupRepo, agentRepo, flmocks.NewAgentRPCRepository())
+}
+
+func newTrackingCommsService(agentGroupRepo fleet.AgentGroupRepository, agentRepo fleet.AgentRepository, agentRPCRepo fleet.AgentRPCRepository) fleet.AgentCommsService {
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		log.Fatalf("error: %v", err)
@@ -111,7 +117,7 @@ func newCommsService(agentGroupRepo fleet.AgentGroupRepository, agentRepo fleet.
 		log.Fatalf("Failed to create PubSub %v", err)
 	}
 
-	return fleet.NewFleetCommsService(logger, policyClient, agentRepo, agentGroupRepo, agentPubSub)
+	return fleet.NewFleetCommsService(logger, policyClient, agentRepo, agentGroupRepo, agentRPCRepo, flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), flmocks.NewAgentEventPublisher(), agentPubSub)
 }
 
 func TestNotifyGroupNewDataset(t *testing.T) {
@@ -270,6 +276,87 @@ func TestNotifyAgentAllDatasets(t *testing.T) {
 	}
 }
 
+func TestNotifyAgentAllDatasetsExclusions(t *testing.T) {
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	agentRPCRepo := flmocks.NewAgentRPCRepository()
+
+	commsSVC := newTrackingCommsService(agentGroupRepo, agentRepo, agentRPCRepo)
+
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetSVC := newFleetService(users, thingsServer.URL, agentGroupRepo, agentRepo)
+
+	validGroupName, err := types.NewIdentifier("group-exclusions")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	group, err := fleetSVC.CreateAgentGroup(context.Background(), "token", fleet.AgentGroup{
+		Name: validGroupName,
+		Tags: &types.Tags{"exclusions": "true"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// saved directly as exclusions take the agent IDs things gives, UUIDs
+	agents := make(map[string]fleet.Agent)
+	for _, name := range []string{"agent-excluded", "agent-included"} {
+		validName, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		thingID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		channelID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		a := fleet.Agent{
+			Name:        validName,
+			MFOwnerID:   group.MFOwnerID,
+			MFThingID:   thingID.String(),
+			MFChannelID: channelID.String(),
+			AgentTags:   map[string]string{"exclusions": "true"},
+		}
+		require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+		agents[name] = a
+	}
+
+	policy := createPolicy(t, policiesSVC, "policy-exclusions")
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	validDatasetName, err := types.NewIdentifier("dataset-exclusions")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	dataset, err := policiesSVC.AddDataset(context.Background(), token, policies.Dataset{
+		Name:             validDatasetName,
+		PolicyID:         policy.ID,
+		AgentGroupIDs:    []string{group.ID},
+		SinkIDs:          &[]string{sinkID.String()},
+		ExcludedAgentIDs: &[]string{agents["agent-excluded"].MFThingID},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		agent   fleet.Agent
+		running bool
+	}{
+		"agent excluded from the dataset": {agent: agents["agent-excluded"], running: false},
+		"agent of the group":              {agent: agents["agent-included"], running: true},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := commsSVC.NotifyAgentAllDatasets(context.Background(), tc.agent)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+
+			rpcs, err := agentRPCRepo.RetrieveAllByAgent(context.Background(), tc.agent.MFThingID, fleet.DefaultAgentRPCsLimit)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			require.Len(t, rpcs, 1, fmt.Sprintf("%s: expected 1 tracked RPC got %d", desc, len(rpcs)))
+			var sent fleet.AgentPolicyRPC
+			require.Nil(t, json.Unmarshal(rpcs[0].Payload, &sent), "expected the published RPC as payload")
+			running := false
+			for _, p := range sent.Payload {
+				if p.DatasetID == dataset.ID {
+					running = true
+				}
+			}
+			assert.Equal(t, tc.running, running, fmt.Sprintf("%s: expected running %t got %t", desc, tc.running, running))
+		})
+	}
+}
+
 func TestNotifyGroupRemoval(t *testing.T) {
 	agentGroupRepo := flmocks.NewAgentGroupRepository()
 	agentRepo := flmocks.NewAgentRepositoryMock()
@@ -478,6 +565,76 @@ func TestNotifyAgentStop(t *testing.T) {
 	}
 }
 
+func TestAgentRPCDelivery(t *testing.T) {
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	agentRPCRepo := flmocks.NewAgentRPCRepository()
+
+	commsSVC := newTrackingCommsService(agentGroupRepo, agentRepo, agentRPCRepo)
+
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetSVC := newFleetService(users, thingsServer.URL, agentGroupRepo, agentRepo)
+
+	agent, err := createAgent(t, "agent-rpc-delivery", fleetSVC)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = commsSVC.NotifyAgentStop(context.Background(), agent, "")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	rpcs, err := agentRPCRepo.RetrieveAllByAgent(context.Background(), agent.MFThingID, fleet.DefaultAgentRPCsLimit)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, rpcs, 1, fmt.Sprintf("expected 1 tracked RPC got %d", len(rpcs)))
+
+	rpc := rpcs[0]
+	assert.NotEmpty(t, rpc.CorrelationID, "expected a correlation id")
+	assert.Equal(t, fleet.AgentStopRPCFunc, rpc.Func, fmt.Sprintf("expected %s got %s", fleet.AgentStopRPCFunc, rpc.Func))
+	assert.Equal(t, agent.MFChannelID, rpc.ChannelID, fmt.Sprintf("expected %s got %s", agent.MFChannelID, rpc.ChannelID))
+	assert.Equal(t, fleet.RPCPending, rpc.Status, fmt.Sprintf("expected %s got %s", fleet.RPCPending, rpc.Status))
+
+	var sent fleet.AgentStopRPC
+	require.Nil(t, json.Unmarshal(rpc.Payload, &sent), "expected the published RPC as payload")
+	assert.Equal(t, rpc.CorrelationID, sent.CorrelationID, fmt.Sprintf("expected %s got %s", rpc.CorrelationID, sent.CorrelationID))
+
+	// not acknowledged yet, but not due to be sent again either
+	err = commsSVC.RetryAgentRPCs(context.Background())
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	rpcs, err = agentRPCRepo.RetrieveAllByAgent(context.Background(), agent.MFThingID, fleet.DefaultAgentRPCsLimit)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 1, rpcs[0].Attempts, fmt.Sprintf("expected 1 attempt got %d", rpcs[0].Attempts))
+}
+
+func TestAgentRPCSupersededByFullList(t *testing.T) {
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	agentRPCRepo := flmocks.NewAgentRPCRepository()
+
+	commsSVC := newTrackingCommsService(agentGroupRepo, agentRepo, agentRPCRepo)
+
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetSVC := newFleetService(users, thingsServer.URL, agentGroupRepo, agentRepo)
+
+	agent, err := createAgent(t, "agent-rpc-superseded", fleetSVC)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = commsSVC.NotifyAgentNewGroupMembership(context.Background(), agent, agentGroup)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = commsSVC.NotifyAgentStop(context.Background(), agent, "")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = commsSVC.NotifyAgentGroupMemberships(context.Background(), agent)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	rpcs, err := agentRPCRepo.RetrieveAllByAgent(context.Background(), agent.MFThingID, fleet.DefaultAgentRPCsLimit)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, rpcs, 3, fmt.Sprintf("expected 3 tracked RPCs got %d", len(rpcs)))
+
+	statuses := make(map[string][]string)
+	for _, rpc := range rpcs {
+		statuses[rpc.Func] = append(statuses[rpc.Func], rpc.Status)
+	}
+	assert.ElementsMatch(t, []string{fleet.RPCSuperseded, fleet.RPCPending}, statuses[fleet.GroupMembershipRPCFunc], "the full group list should supersede the pending membership")
+	assert.Equal(t, []string{fleet.RPCPending}, statuses[fleet.AgentStopRPCFunc], "RPCs of other funcs should stay pending")
+}
+
 func TestNotifyAgentNewGroupMembership(t *testing.T) {
 	agentGroupRepo := flmocks.NewAgentGroupRepository()
 	agentRepo := flmocks.NewAgentRepositoryMock()
@@ -575,6 +732,89 @@ func TestNotifyGroupDatasetEdit(t *testing.T) {
 	}
 }
 
+func TestRenderAgentPolicy(t *testing.T) {
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+
+	commsSVC := newCommsService(agentGroupRepo, agentRepo)
+
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetSVC := newFleetService(users, thingsServer.URL, agentGroupRepo, agentRepo)
+
+	validAgentName, err := types.NewIdentifier("agent-template")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	ag, err := fleetSVC.CreateAgent(context.Background(), token, fleet.Agent{
+		Name:      validAgentName,
+		AgentTags: map[string]string{"iface": "ens5"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	policyName, err := types.NewIdentifier("policy-template")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	policy, err := policiesSVC.AddPolicy(context.Background(), token, policies.Policy{
+		Name:    policyName,
+		Backend: "pktvisor",
+		PolicyData: `
+handlers:
+  modules:
+    default_dns:
+      type: dns
+input:
+  input_type: pcap
+  config:
+    iface: '{{ agent.tags.iface }}'
+    host_spec: '{{ agent.tags.host_spec | default "192.168.0.0/24" }}'
+kind: collection`,
+		Format: "yaml",
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	noTagsAgent := ag
+	noTagsAgent.AgentTags = nil
+
+	cases := map[string]struct {
+		agent    fleet.Agent
+		policyID string
+		config   map[string]interface{}
+		err      error
+	}{
+		"Render policy with agent tags and defaults": {
+			agent:    ag,
+			policyID: policy.ID,
+			config:   map[string]interface{}{"iface": "ens5", "host_spec": "192.168.0.0/24"},
+			err:      nil,
+		},
+		"Render policy with agent missing a variable": {
+			agent:    noTagsAgent,
+			policyID: policy.ID,
+			err:      template.ErrMissingVariable,
+		},
+		"Render non-existent policy": {
+			agent:    ag,
+			policyID: wrongID,
+			err:      status.Error(codes.Internal, "internal server error"),
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			payload, err := commsSVC.RenderAgentPolicy(context.Background(), tc.agent, tc.policyID)
+			if tc.err == nil {
+				require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+				input := payload.Data.(map[string]interface{})["input"].(map[string]interface{})
+				assert.Equal(t, tc.config, input["config"], fmt.Sprintf("%s: unexpected rendered policy", desc))
+				return
+			}
+			if st, ok := status.FromError(tc.err); ok {
+				assert.Equal(t, st.Code(), status.Code(err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+				return
+			}
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		})
+	}
+}
+
 func createPolicy(t *testing.T, svc policies.Service, name string) policies.Policy {
 	t.Helper()
 	ID, err := uuid.NewV4()
@@ -628,11 +868,11 @@ func createDataset(t *testing.T, svc policies.Service, name string, groupID stri
 	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		ID:           ID.String(),
-		Name:         validName,
-		PolicyID:     policyID.String(),
-		AgentGroupID: groupID,
-		SinkIDs:      &sinkIDs,
+		ID:            ID.String(),
+		Name:          validName,
+		PolicyID:      policyID.String(),
+		AgentGroupIDs: []string{groupID},
+		SinkIDs:       &sinkIDs,
 	}
 
 	res, err := svc.AddDataset(context.Background(), token, dataset)
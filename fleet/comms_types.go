@@ -43,6 +43,8 @@ type Capabilities struct {
 	OrbAgent      OrbAgentInfo           `json:"orb_agent"`
 	AgentTags     map[string]string      `json:"agent_tags"`
 	Backends      map[string]BackendInfo `json:"backends"`
+	// SchemaVersions the agent speaks, fleet chooses the schema of the messages it sends the agent among them
+	SchemaVersions SchemaVersions `json:"schema_versions"`
 }
 
 const CurrentHeartbeatSchemaVersion = "1.0"
@@ -54,6 +56,13 @@ type BackendStateInfo struct {
 	LastError         string    `json:"last_error,omitempty"`
 	LastRestartTS     time.Time `json:"last_restart_ts,omitempty"`
 	LastRestartReason string    `json:"last_restart_reason,omitempty"`
+	// Components is the health of each component of backends running their pipelines in process
+	Components map[string]ComponentStateInfo `json:"components,omitempty"`
+}
+
+type ComponentStateInfo struct {
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
 }
 
 type PolicyStateInfo struct {
@@ -65,8 +74,13 @@ type PolicyStateInfo struct {
 	LastScrapeBytes int64     `json:"last_scrape_bytes,omitempty"`
 	LastScrapeTS    time.Time `json:"last_scrape_ts,omitempty"`
 	Backend         string    `json:"backend,omitempty"`
+	// Source is PolicySourceLocal for policies defined in the agent configuration, empty for the ones sent by fleet
+	Source string `json:"source,omitempty"`
 }
 
+// PolicySourceLocal marks the policies an agent applies from its own configuration
+const PolicySourceLocal = "local"
+
 type GroupStateInfo struct {
 	GroupName    string `json:"name"`
 	GroupChannel string `json:"channel"`
@@ -0,0 +1,131 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	enrollmentTokenPrefix = "orbet_"
+	enrollmentTokenBytes  = 32
+)
+
+var errNoServiceKey = errors.New("agent enrollment requires the mainflux service key")
+
+func hashEnrollmentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newEnrollmentTokenSecret() (string, error) {
+	b := make([]byte, enrollmentTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return enrollmentTokenPrefix + hex.EncodeToString(b), nil
+}
+
+func (svc fleetService) CreateEnrollmentToken(ctx context.Context, token string, et EnrollmentToken) (EnrollmentToken, error) {
+	res, err := svc.auth.Identify(ctx, &mainflux.Token{Value: token})
+	if err != nil {
+		return EnrollmentToken{}, errors.Wrap(errors.ErrUnauthorizedAccess, err)
+	}
+
+	now := time.Now()
+	if et.MaxUses == 0 {
+		et.MaxUses = 1
+	}
+	if et.ExpiresAt.IsZero() {
+		et.ExpiresAt = now.Add(DefaultEnrollmentTokenTTL)
+	}
+	if err := et.Validate(now); err != nil {
+		return EnrollmentToken{}, err
+	}
+
+	secret, err := newEnrollmentTokenSecret()
+	if err != nil {
+		return EnrollmentToken{}, err
+	}
+	et.MFOwnerID = res.GetId()
+	et.OwnerEmail = res.GetEmail()
+	et.TokenHash = hashEnrollmentToken(secret)
+	et.Uses = 0
+	et.Revoked = false
+
+	id, err := svc.enrollmentTokenRepo.Save(ctx, et)
+	if err != nil {
+		return EnrollmentToken{}, err
+	}
+	et.ID = id
+	et.Token = secret
+	et.Created = now
+	return et, nil
+}
+
+func (svc fleetService) ListEnrollmentTokens(ctx context.Context, token string) ([]EnrollmentToken, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return nil, err
+	}
+	return svc.enrollmentTokenRepo.RetrieveAllByOwner(ctx, ownerID)
+}
+
+func (svc fleetService) RevokeEnrollmentToken(ctx context.Context, token string, id string) error {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return err
+	}
+	return svc.enrollmentTokenRepo.Revoke(ctx, ownerID, id)
+}
+
+func (svc fleetService) EnrollAgent(ctx context.Context, enrollmentToken string, a Agent) (Agent, error) {
+	if enrollmentToken == "" {
+		return Agent{}, errors.Wrap(errors.ErrUnauthorizedAccess, ErrInvalidEnrollmentToken)
+	}
+	et, err := svc.enrollmentTokenRepo.Consume(ctx, hashEnrollmentToken(enrollmentToken))
+	if err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return Agent{}, errors.Wrap(errors.ErrUnauthorizedAccess, ErrInvalidEnrollmentToken)
+		}
+		return Agent{}, err
+	}
+
+	agent, err := svc.enrollAgent(ctx, et, a)
+	if err != nil {
+		if errR := svc.enrollmentTokenRepo.Release(ctx, et.ID); errR != nil {
+			svc.logger.Error("failed to release enrollment token use", zap.String("enrollment_token_id", et.ID), zap.Error(errR))
+		}
+		return Agent{}, err
+	}
+	svc.logger.Info("agent enrolled", zap.String("agent_id", agent.MFThingID), zap.String("owner_id", agent.MFOwnerID),
+		zap.String("enrollment_token_id", et.ID))
+	return agent, nil
+}
+
+// enrollAgent creates the agent of the token owner with the service key, no key of the owner is available without
+// their credentials. Its Thing and RPC channel belong to the service account, which manages them from then on
+func (svc fleetService) enrollAgent(ctx context.Context, et EnrollmentToken, a Agent) (Agent, error) {
+	if svc.mfServiceKey == "" {
+		return Agent{}, errNoServiceKey
+	}
+
+	orbTags := et.OrbTags
+	if orbTags == nil {
+		orbTags = make(map[string]string)
+	}
+	a.OrbTags = &orbTags
+	a.MFOwnerID = et.MFOwnerID
+	a.AgentMetadata = map[string]interface{}{enrollmentTokenKey: et.ID}
+	return svc.createAgent(ctx, svc.mfServiceKey, a)
+}
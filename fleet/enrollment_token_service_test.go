@@ -0,0 +1,140 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateEnrollmentToken(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetService := newService(users, thingsServer.URL)
+
+	cases := map[string]struct {
+		et    fleet.EnrollmentToken
+		token string
+		err   error
+	}{
+		"create an enrollment token with defaults": {
+			et:    fleet.EnrollmentToken{},
+			token: token,
+			err:   nil,
+		},
+		"create an enrollment token with limits": {
+			et:    fleet.EnrollmentToken{Name: "edge", MaxUses: 100, ExpiresAt: time.Now().Add(time.Hour)},
+			token: token,
+			err:   nil,
+		},
+		"create an enrollment token with wrong credentials": {
+			et:    fleet.EnrollmentToken{},
+			token: "wrong",
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+		"create an expired enrollment token": {
+			et:    fleet.EnrollmentToken{ExpiresAt: time.Now().Add(-time.Hour)},
+			token: token,
+			err:   fleet.ErrMalformedEnrollmentToken,
+		},
+		"create an enrollment token valid for too long": {
+			et:    fleet.EnrollmentToken{ExpiresAt: time.Now().Add(2 * fleet.MaxEnrollmentTokenTTL)},
+			token: token,
+			err:   fleet.ErrMalformedEnrollmentToken,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			et, err := fleetService.CreateEnrollmentToken(context.Background(), tc.token, tc.et)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err != nil {
+				return
+			}
+			assert.NotEmpty(t, et.Token, fmt.Sprintf("%s: expected the token secret", desc))
+			assert.NotEqual(t, et.Token, et.TokenHash, fmt.Sprintf("%s: expected the secret to be hashed", desc))
+			assert.GreaterOrEqual(t, et.MaxUses, 1, fmt.Sprintf("%s: expected a usage limit", desc))
+			assert.True(t, et.ExpiresAt.After(time.Now()), fmt.Sprintf("%s: expected an expiration", desc))
+		})
+	}
+
+	tokens, err := fleetService.ListEnrollmentTokens(context.Background(), token)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Len(t, tokens, 2, "expected the created enrollment tokens to be listed")
+	for _, et := range tokens {
+		assert.Empty(t, et.Token, "expected listed enrollment tokens not to carry their secret")
+	}
+}
+
+func TestEnrollAgent(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email, serviceKey: serviceEmail})
+	thingsServer := newThingsServer(newThingsService(users))
+	fleetService := newService(users, thingsServer.URL)
+
+	valid, err := fleetService.CreateEnrollmentToken(context.Background(), token, fleet.EnrollmentToken{OrbTags: types.Tags{"site": "ams1"}, MaxUses: 5})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	single, err := fleetService.CreateEnrollmentToken(context.Background(), token, fleet.EnrollmentToken{MaxUses: 1})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	revoked, err := fleetService.CreateEnrollmentToken(context.Background(), token, fleet.EnrollmentToken{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Nil(t, fleetService.RevokeEnrollmentToken(context.Background(), token, revoked.ID), "unexpected error revoking enrollment token")
+
+	enroll := func(enrollmentToken string, name string) (fleet.Agent, error) {
+		nameID, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		return fleetService.EnrollAgent(context.Background(), enrollmentToken, fleet.Agent{Name: nameID, AgentTags: types.Tags{"role": "dns"}})
+	}
+
+	a, err := enroll(valid.Token, "enrolled-agent")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, email, a.MFOwnerID, "expected the agent to belong to the enrollment token owner")
+	assert.NotEmpty(t, a.MFKeyID, "expected agent credentials")
+	assert.Equal(t, "ams1", (*a.OrbTags)["site"], "expected the enrollment token orb tags to be bound")
+	assert.Equal(t, valid.ID, a.AgentMetadata["enrollment_token_id"], "expected the agent to record its enrollment token")
+
+	// a failed enrollment gives its use back
+	_, err = enroll(single.Token, "enrolled-agent")
+	assert.NotNil(t, err, "expected an error enrolling a duplicated agent")
+	_, err = enroll(single.Token, "single-agent")
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		token string
+		err   error
+	}{
+		"enroll with a used up token": {
+			token: single.Token,
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+		"enroll with a revoked token": {
+			token: revoked.Token,
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+		"enroll with an unknown token": {
+			token: "orbet_unknown",
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+		"enroll with a user token": {
+			token: token,
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, err := enroll(tc.token, "rejected-agent")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		})
+	}
+}
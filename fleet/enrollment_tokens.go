@@ -0,0 +1,88 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+)
+
+const (
+	// DefaultEnrollmentTokenTTL is how long an enrollment token is valid when no expiration is given
+	DefaultEnrollmentTokenTTL = 24 * time.Hour
+	// MaxEnrollmentTokenTTL is the longest an enrollment token may be valid for
+	MaxEnrollmentTokenTTL = 365 * 24 * time.Hour
+
+	// enrollmentTokenKey is the agent metadata key of the enrollment token an agent enrolled with
+	enrollmentTokenKey = "enrollment_token_id"
+)
+
+var (
+	// ErrInvalidEnrollmentToken indicates an enrollment token that does not exist, was revoked, expired or was used up
+	ErrInvalidEnrollmentToken = errors.New("invalid, revoked, expired or exhausted enrollment token")
+	// ErrMalformedEnrollmentToken indicates an enrollment token specification out of range
+	ErrMalformedEnrollmentToken = errors.New("malformed enrollment token specification")
+)
+
+// EnrollmentToken lets agents provision themselves on behalf of its owner without a user API token.
+// Only the hash of the secret is stored, the secret itself is returned once when the token is created
+type EnrollmentToken struct {
+	ID         string
+	Name       string
+	MFOwnerID  string
+	OwnerEmail string
+	// Token is the secret, only set on the token returned by CreateEnrollmentToken
+	Token     string
+	TokenHash string
+	// OrbTags are bound to every agent enrolled with the token
+	OrbTags   types.Tags
+	MaxUses   int
+	Uses      int
+	ExpiresAt time.Time
+	Revoked   bool
+	Created   time.Time
+}
+
+// Validate checks the usage limit and expiration of a new token
+func (et EnrollmentToken) Validate(now time.Time) error {
+	if et.MaxUses < 1 {
+		return errors.Wrap(ErrMalformedEnrollmentToken, errors.New("max uses must be at least 1"))
+	}
+	if !et.ExpiresAt.After(now) {
+		return errors.Wrap(ErrMalformedEnrollmentToken, errors.New("expiration must be in the future"))
+	}
+	if et.ExpiresAt.After(now.Add(MaxEnrollmentTokenTTL)) {
+		return errors.Wrap(ErrMalformedEnrollmentToken, errors.New("expiration must be within a year"))
+	}
+	return nil
+}
+
+type EnrollmentTokenService interface {
+	// CreateEnrollmentToken issues a new enrollment token, the returned one carrying its secret
+	CreateEnrollmentToken(ctx context.Context, token string, et EnrollmentToken) (EnrollmentToken, error)
+	// ListEnrollmentTokens retrieves the enrollment tokens of an owner, newest first, without their secret
+	ListEnrollmentTokens(ctx context.Context, token string) ([]EnrollmentToken, error)
+	// RevokeEnrollmentToken stops an enrollment token from enrolling further agents
+	RevokeEnrollmentToken(ctx context.Context, token string, id string) error
+	// EnrollAgent creates an agent on behalf of the owner of a valid enrollment token, binding the token orb tags to it,
+	// and returns it with its MQTT credentials
+	EnrollAgent(ctx context.Context, enrollmentToken string, a Agent) (Agent, error)
+}
+
+type EnrollmentTokenRepository interface {
+	// Save persists a new enrollment token, returning its id
+	Save(ctx context.Context, et EnrollmentToken) (string, error)
+	// RetrieveAllByOwner retrieves the enrollment tokens of an owner, newest first
+	RetrieveAllByOwner(ctx context.Context, ownerID string) ([]EnrollmentToken, error)
+	// Revoke marks an enrollment token of an owner as revoked
+	Revoke(ctx context.Context, ownerID string, id string) error
+	// Consume atomically counts a use of the token with the given hash, as long as it is not revoked, expired or used up
+	Consume(ctx context.Context, tokenHash string) (EnrollmentToken, error)
+	// Release gives back a use of an enrollment token, for enrollments that failed after consuming it
+	Release(ctx context.Context, id string) error
+}
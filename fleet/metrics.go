@@ -222,6 +222,132 @@ func (c commsMetricsMiddleware) NotifyAgentReset(ctx context.Context, agent Agen
 	return c.svc.NotifyAgentReset(ctx, agent, fullReset, reason)
 }
 
+func (c commsMetricsMiddleware) NotifyAgentPolicyUpdate(ctx context.Context, a Agent, groupID string, policyID string) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "NotifyAgentPolicyUpdate",
+			"agent_id", a.MFThingID,
+			"agent_name", a.Name.String(),
+			"group_id", groupID,
+			"group_name", "",
+			"owner_id", a.MFOwnerID,
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.NotifyAgentPolicyUpdate(ctx, a, groupID, policyID)
+}
+
+func (c commsMetricsMiddleware) RenderAgentPolicy(ctx context.Context, a Agent, policyID string) (AgentPolicyRPCPayload, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "RenderAgentPolicy",
+			"agent_id", a.MFThingID,
+			"agent_name", a.Name.String(),
+			"group_id", "",
+			"group_name", "",
+			"owner_id", a.MFOwnerID,
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.RenderAgentPolicy(ctx, a, policyID)
+}
+
+func (c commsMetricsMiddleware) TestAgentPolicy(ctx context.Context, a Agent, policyID string) (PolicyTestResultRPCPayload, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "TestAgentPolicy",
+			"agent_id", a.MFThingID,
+			"agent_name", a.Name.String(),
+			"group_id", "",
+			"group_name", "",
+			"owner_id", a.MFOwnerID,
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.TestAgentPolicy(ctx, a, policyID)
+}
+
+func (c commsMetricsMiddleware) StageAgentCredentials(ctx context.Context, a Agent, requestID string, key string) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "StageAgentCredentials",
+			"agent_id", a.MFThingID,
+			"agent_name", a.Name.String(),
+			"group_id", "",
+			"group_name", "",
+			"owner_id", a.MFOwnerID,
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.StageAgentCredentials(ctx, a, requestID, key)
+}
+
+func (c commsMetricsMiddleware) CommitAgentCredentials(ctx context.Context, a Agent, requestID string) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "CommitAgentCredentials",
+			"agent_id", a.MFThingID,
+			"agent_name", a.Name.String(),
+			"group_id", "",
+			"group_name", "",
+			"owner_id", a.MFOwnerID,
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.CommitAgentCredentials(ctx, a, requestID)
+}
+
+func (c commsMetricsMiddleware) RequestAgentDiagnostics(ctx context.Context, a Agent, bundleID string) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "RequestAgentDiagnostics",
+			"agent_id", a.MFThingID,
+			"agent_name", a.Name.String(),
+			"group_id", "",
+			"group_name", "",
+			"owner_id", a.MFOwnerID,
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.RequestAgentDiagnostics(ctx, a, bundleID)
+}
+
+func (c commsMetricsMiddleware) RetryAgentRPCs(ctx context.Context) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "RetryAgentRPCs",
+			"agent_id", "",
+			"agent_name", "",
+			"group_id", "",
+			"group_name", "",
+			"owner_id", "",
+		}
+
+		c.requestCounter.With(labels...).Add(1)
+		c.requestLatency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+	return c.svc.RetryAgentRPCs(ctx)
+}
+
 func CommsMetricsMiddleware(svc AgentCommsService, counter metrics.Counter, latency metrics.Histogram) AgentCommsService {
 	return &commsMetricsMiddleware{
 		requestCounter: counter,
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/pkg/types"
@@ -47,8 +48,16 @@ func (a agentRepositoryMock) RetrieveMatchingAgents(_ context.Context, _ string,
 	return nil, nil
 }
 
-func (a agentRepositoryMock) UpdateHeartbeatByIDWithChannel(_ context.Context, _ fleet.Agent) error {
-	panic("implement me")
+func (a agentRepositoryMock) UpdateHeartbeatByIDWithChannel(_ context.Context, agent fleet.Agent) error {
+	current, ok := a.agentsMock[agent.MFThingID]
+	if !ok || current.MFChannelID != agent.MFChannelID {
+		return fleet.ErrNotFound
+	}
+	current.LastHBData = agent.LastHBData
+	current.LastHB = time.Now()
+	current.State = agent.State
+	a.agentsMock[agent.MFThingID] = current
+	return nil
 }
 
 func (a agentRepositoryMock) Save(_ context.Context, agent fleet.Agent) error {
@@ -78,8 +87,109 @@ func (a agentRepositoryMock) UpdateAgentByID(_ context.Context, ownerID string,
 	return fleet.ErrNotFound
 }
 
-func (a agentRepositoryMock) UpdateDataByIDWithChannel(_ context.Context, _ fleet.Agent) error {
-	panic("implement me")
+func (a agentRepositoryMock) UpdateDataByIDWithChannel(_ context.Context, agent fleet.Agent) error {
+	current, ok := a.agentsMock[agent.MFThingID]
+	if !ok || current.MFChannelID != agent.MFChannelID {
+		return fleet.ErrNotFound
+	}
+	current.AgentTags = agent.AgentTags
+	current.AgentMetadata = agent.AgentMetadata
+	current.State = agent.State
+	a.agentsMock[agent.MFThingID] = current
+	return nil
+}
+
+func (a agentRepositoryMock) RetrieveSchemaVersionCounts(_ context.Context, legacy fleet.SchemaVersions) ([]fleet.SchemaVersionCount, error) {
+	counts := make(map[fleet.SchemaVersionCount]int)
+	for _, ag := range a.agentsMock {
+		if ag.State != fleet.Online {
+			continue
+		}
+		versions := fleet.AgentSchemaVersions{
+			Capabilities: legacy.Capabilities[len(legacy.Capabilities)-1],
+			Heartbeat:    legacy.Heartbeat[len(legacy.Heartbeat)-1],
+			RPC:          legacy.RPC[len(legacy.RPC)-1],
+		}
+		if stored, ok := ag.AgentMetadata["schema_versions"].(fleet.AgentSchemaVersions); ok {
+			versions = stored
+		}
+		counts[fleet.SchemaVersionCount{Kind: fleet.SchemaKindCapabilities, Version: versions.Capabilities}]++
+		counts[fleet.SchemaVersionCount{Kind: fleet.SchemaKindHeartbeat, Version: versions.Heartbeat}]++
+		counts[fleet.SchemaVersionCount{Kind: fleet.SchemaKindRPC, Version: versions.RPC}]++
+	}
+	var items []fleet.SchemaVersionCount
+	for c, n := range counts {
+		c.Count = n
+		items = append(items, c)
+	}
+	return items, nil
+}
+
+func (a agentRepositoryMock) RetrieveSummary(_ context.Context, owner string, tags types.Tags) (fleet.FleetSummary, error) {
+	summary := fleet.NewFleetSummary()
+	for _, ag := range a.agentsMock {
+		if owner != "" && ag.MFOwnerID != owner {
+			continue
+		}
+		agentTags := types.Tags{}
+		for k, v := range ag.AgentTags {
+			agentTags[k] = v
+		}
+		if ag.OrbTags != nil {
+			for k, v := range *ag.OrbTags {
+				agentTags[k] = v
+			}
+		}
+		matches := true
+		for k, v := range tags {
+			if agentTags[k] != v {
+				matches = false
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		var md struct {
+			OrbAgent fleet.OrbAgentInfo           `json:"orb_agent"`
+			Backends map[string]fleet.BackendInfo `json:"backends"`
+		}
+		if body, err := json.Marshal(ag.AgentMetadata); err == nil {
+			_ = json.Unmarshal(body, &md)
+		}
+		var hb struct {
+			BackendState map[string]fleet.BackendStateInfo `json:"backend_state"`
+			PolicyState  map[string]fleet.PolicyStateInfo  `json:"policy_state"`
+		}
+		if body, err := json.Marshal(ag.LastHBData); err == nil {
+			_ = json.Unmarshal(body, &hb)
+		}
+
+		summary.Add(fleet.SummaryKindState, "", ag.State.String(), 1)
+		summary.Add(fleet.SummaryKindAgentVersion, "", md.OrbAgent.Version, 1)
+		for name, b := range md.Backends {
+			summary.Add(fleet.SummaryKindBackendVersion, name, b.Version, 1)
+		}
+		for name, b := range hb.BackendState {
+			summary.Add(fleet.SummaryKindBackendState, name, b.State, 1)
+		}
+		var failing []fleet.FailingPolicy
+		for id, ps := range hb.PolicyState {
+			summary.Add(fleet.SummaryKindPolicyState, "", ps.State, 1)
+			if ps.State == fleet.PolicyFailedToApply {
+				failing = append(failing, fleet.FailingPolicy{PolicyID: id, PolicyName: ps.Name, Error: ps.Error})
+			}
+		}
+		if len(failing) > 0 {
+			summary.Add(fleet.SummaryKindFailingPolicyAgents, "", "", 1)
+			summary.FailingPolicyAgents = append(summary.FailingPolicyAgents, fleet.FailingPolicyAgent{
+				AgentID:   ag.MFThingID,
+				AgentName: ag.Name.String(),
+				Policies:  failing,
+			})
+		}
+	}
+	return summary, nil
 }
 
 func (a agentRepositoryMock) RetrieveByIDWithChannel(_ context.Context, thingID string, channelID string) (fleet.Agent, error) {
@@ -133,6 +243,18 @@ func (a agentRepositoryMock) RetrieveAllByAgentGroupID(_ context.Context, owner
 	return agents, nil
 }
 
+func (a agentRepositoryMock) RetrieveAllByAgentGroupIDs(ctx context.Context, owner string, agentGroupIDs []string) (map[string][]fleet.Agent, error) {
+	members := make(map[string][]fleet.Agent, len(agentGroupIDs))
+	for _, groupID := range agentGroupIDs {
+		agents, err := a.RetrieveAllByAgentGroupID(ctx, owner, groupID, false)
+		if err != nil {
+			return nil, err
+		}
+		members[groupID] = agents
+	}
+	return members, nil
+}
+
 func (a agentRepositoryMock) Delete(_ context.Context, ownerID, thingID string) error {
 	if _, ok := a.agentsMock[thingID]; ok {
 		if a.agentsMock[thingID].MFOwnerID == ownerID {
@@ -143,6 +265,40 @@ func (a agentRepositoryMock) Delete(_ context.Context, ownerID, thingID string)
 	return nil
 }
 
+func (a agentRepositoryMock) SetMaintenance(_ context.Context, ownerID string, thingID string, m *fleet.Maintenance) error {
+	current, ok := a.agentsMock[thingID]
+	if !ok || current.MFOwnerID != ownerID {
+		return fleet.ErrNotFound
+	}
+	current.Maintenance = m
+	a.agentsMock[thingID] = current
+	return nil
+}
+
+func (a agentRepositoryMock) UpdateInMaintenance(_ context.Context, thingID string, inMaintenance bool) error {
+	current, ok := a.agentsMock[thingID]
+	if !ok {
+		return fleet.ErrNotFound
+	}
+	current.InMaintenance = inMaintenance
+	a.agentsMock[thingID] = current
+	return nil
+}
+
+func (a agentRepositoryMock) RetrieveAllInMaintenance(_ context.Context, owner string) ([]fleet.Agent, error) {
+	var agents []fleet.Agent
+	for _, ag := range a.agentsMock {
+		if owner != "" && ag.MFOwnerID != owner {
+			continue
+		}
+		open := ag.Maintenance != nil && (ag.Maintenance.End.IsZero() || ag.Maintenance.End.After(time.Now()))
+		if ag.InMaintenance || open {
+			agents = append(agents, ag)
+		}
+	}
+	return agents, nil
+}
+
 func NewAgentRepositoryMock() fleet.AgentRepository {
 	return &agentRepositoryMock{
 		agentsMock: make(map[string]fleet.Agent),
@@ -0,0 +1,152 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+var _ fleet.AgentDiagnosticsRepository = (*agentDiagnosticsRepositoryMock)(nil)
+
+type agentDiagnosticsRepositoryMock struct {
+	mu         sync.Mutex
+	bundleMock map[string]fleet.DiagnosticsBundle
+	chunkMock  map[string]map[int][]byte
+}
+
+func NewAgentDiagnosticsRepository() fleet.AgentDiagnosticsRepository {
+	return &agentDiagnosticsRepositoryMock{
+		bundleMock: make(map[string]fleet.DiagnosticsBundle),
+		chunkMock:  make(map[string]map[int][]byte),
+	}
+}
+
+func (r *agentDiagnosticsRepositoryMock) Save(_ context.Context, b fleet.DiagnosticsBundle) error {
+	if b.ID == "" || b.AgentID == "" || b.MFOwnerID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.bundleMock[b.ID]; ok {
+		return errors.ErrConflict
+	}
+	r.bundleMock[b.ID] = b
+	r.chunkMock[b.ID] = make(map[int][]byte)
+	return nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) RetrieveByID(_ context.Context, bundleID string) (fleet.DiagnosticsBundle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.bundleMock[bundleID]
+	if !ok {
+		return fleet.DiagnosticsBundle{}, fleet.ErrNotFound
+	}
+	b.ChunksReceived = len(r.chunkMock[bundleID])
+	if b.Status == fleet.DiagnosticsComplete {
+		b.ChunksReceived = b.Chunks
+	}
+	return b, nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) SaveChunk(_ context.Context, bundleID string, seq int, total int, data []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.bundleMock[bundleID]
+	if !ok || b.Status != fleet.DiagnosticsCollecting {
+		return 0, fleet.ErrNotFound
+	}
+	if b.Chunks == 0 {
+		b.Chunks = total
+		r.bundleMock[bundleID] = b
+	}
+	if _, ok := r.chunkMock[bundleID][seq]; !ok {
+		r.chunkMock[bundleID][seq] = data
+	}
+	return len(r.chunkMock[bundleID]), nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) RetrieveChunks(_ context.Context, bundleID string) ([][]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seqs := make([]int, 0, len(r.chunkMock[bundleID]))
+	for seq := range r.chunkMock[bundleID] {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	chunks := make([][]byte, 0, len(seqs))
+	for _, seq := range seqs {
+		chunks = append(chunks, r.chunkMock[bundleID][seq])
+	}
+	return chunks, nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) Complete(_ context.Context, bundleID string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.bundleMock[bundleID]
+	if !ok || b.Status != fleet.DiagnosticsCollecting {
+		return fleet.ErrNotFound
+	}
+	b.Status = fleet.DiagnosticsComplete
+	b.Data = data
+	b.Completed = time.Now()
+	r.bundleMock[bundleID] = b
+	delete(r.chunkMock, bundleID)
+	return nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) Fail(_ context.Context, bundleID string, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.bundleMock[bundleID]
+	if !ok || b.Status != fleet.DiagnosticsCollecting {
+		return fleet.ErrNotFound
+	}
+	b.Status = fleet.DiagnosticsFailed
+	b.Error = errMsg
+	b.Completed = time.Now()
+	r.bundleMock[bundleID] = b
+	delete(r.chunkMock, bundleID)
+	return nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) ExpireCollecting(_ context.Context, createdBefore time.Time, errMsg string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for id, b := range r.bundleMock {
+		if b.Status == fleet.DiagnosticsCollecting && b.Created.Before(createdBefore) {
+			b.Status = fleet.DiagnosticsFailed
+			b.Error = errMsg
+			b.Completed = time.Now()
+			r.bundleMock[id] = b
+			delete(r.chunkMock, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *agentDiagnosticsRepositoryMock) DeleteOlderThan(_ context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for id, b := range r.bundleMock {
+		if b.Created.Before(t) {
+			delete(r.bundleMock, id)
+			delete(r.chunkMock, id)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,24 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package mocks
+
+import (
+	"context"
+
+	"github.com/orb-community/orb/fleet"
+)
+
+var _ fleet.AgentEventPublisher = (*agentEventPublisherMock)(nil)
+
+type agentEventPublisherMock struct{}
+
+// NewAgentEventPublisher returns a publisher discarding the agent events
+func NewAgentEventPublisher() fleet.AgentEventPublisher {
+	return agentEventPublisherMock{}
+}
+
+func (agentEventPublisherMock) PublishAgentUpdate(_ context.Context, _ fleet.Agent) error {
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/errors"
 	"reflect"
+	"time"
 )
 
 var _ fleet.AgentGroupRepository = (*agentGroupRepositoryMock)(nil)
@@ -52,7 +53,7 @@ func (a *agentGroupRepositoryMock) RetrieveAllByAgent(ctx context.Context, agent
 	}
 
 	for _, v := range a.agentGroupMock {
-		if v.MFOwnerID == agent.MFOwnerID && (reflect.DeepEqual(v.Tags, agent.AgentTags) || reflect.DeepEqual(v.Tags, agent.OrbTags)) {
+		if v.MFOwnerID == agent.MFOwnerID && v.Tags != nil && (reflect.DeepEqual(*v.Tags, agent.AgentTags) || reflect.DeepEqual(*v.Tags, agent.OrbTags)) {
 			agentGroups = append(agentGroups, v)
 		}
 	}
@@ -130,3 +131,26 @@ func (a *agentGroupRepositoryMock) RetrieveMatchingGroups(ctx context.Context, o
 	}
 	return fleet.MatchingGroups{OwnerID: ownerID, Groups: groups}, nil
 }
+
+func (a *agentGroupRepositoryMock) SetMaintenance(ctx context.Context, ownerID string, groupID string, m *fleet.Maintenance) error {
+	group, ok := a.agentGroupMock[groupID]
+	if !ok || group.MFOwnerID != ownerID {
+		return fleet.ErrNotFound
+	}
+	group.Maintenance = m
+	a.agentGroupMock[groupID] = group
+	return nil
+}
+
+func (a *agentGroupRepositoryMock) RetrieveAllInMaintenance(ctx context.Context, owner string) ([]fleet.AgentGroup, error) {
+	var groups []fleet.AgentGroup
+	for _, group := range a.agentGroupMock {
+		if group.Maintenance == nil || (owner != "" && group.MFOwnerID != owner) {
+			continue
+		}
+		if group.Maintenance.End.IsZero() || group.Maintenance.End.After(time.Now()) {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,156 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+var _ fleet.AgentRPCRepository = (*agentRPCRepositoryMock)(nil)
+
+type agentRPCRepositoryMock struct {
+	mu      sync.Mutex
+	rpcMock map[string]fleet.AgentRPC
+}
+
+func NewAgentRPCRepository() fleet.AgentRPCRepository {
+	return &agentRPCRepositoryMock{
+		rpcMock: make(map[string]fleet.AgentRPC),
+	}
+}
+
+func agentRPCKey(agentID string, correlationID string) string {
+	return agentID + "." + correlationID
+}
+
+func (r *agentRPCRepositoryMock) Save(_ context.Context, rpc fleet.AgentRPC) error {
+	if rpc.CorrelationID == "" || rpc.AgentID == "" || rpc.ChannelID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := agentRPCKey(rpc.AgentID, rpc.CorrelationID)
+	if _, ok := r.rpcMock[key]; ok {
+		return nil
+	}
+	rpc.Created = time.Now()
+	rpc.LastSent = rpc.Created
+	rpc.Updated = rpc.Created
+	r.rpcMock[key] = rpc
+	return nil
+}
+
+func (r *agentRPCRepositoryMock) UpdateStatus(_ context.Context, agentID string, correlationID string, status string, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := agentRPCKey(agentID, correlationID)
+	rpc, ok := r.rpcMock[key]
+	if !ok || (rpc.Status != fleet.RPCPending && rpc.Status != fleet.RPCTimedOut && rpc.Status != fleet.RPCSuperseded) {
+		return fleet.ErrNotFound
+	}
+	rpc.Status = status
+	rpc.Error = errMsg
+	rpc.Updated = time.Now()
+	r.rpcMock[key] = rpc
+	return nil
+}
+
+func (r *agentRPCRepositoryMock) RetrieveAllByAgent(_ context.Context, agentID string, limit uint64) ([]fleet.AgentRPC, error) {
+	rpcs := r.filter(func(rpc fleet.AgentRPC) bool {
+		return rpc.AgentID == agentID
+	})
+	if uint64(len(rpcs)) > limit {
+		rpcs = rpcs[:limit]
+	}
+	return rpcs, nil
+}
+
+func (r *agentRPCRepositoryMock) ClaimRetries(_ context.Context, sentBefore time.Time, maxAttempts int) ([]fleet.AgentRPC, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rpcs []fleet.AgentRPC
+	for key, rpc := range r.rpcMock {
+		if rpc.Status == fleet.RPCPending && rpc.LastSent.Before(sentBefore) && rpc.Attempts < maxAttempts {
+			rpc.Attempts++
+			rpc.LastSent = time.Now()
+			r.rpcMock[key] = rpc
+			rpcs = append(rpcs, rpc)
+		}
+	}
+	return rpcs, nil
+}
+
+func (r *agentRPCRepositoryMock) ExpirePending(_ context.Context, sentBefore time.Time, maxAttempts int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for key, rpc := range r.rpcMock {
+		if rpc.Status == fleet.RPCPending && rpc.LastSent.Before(sentBefore) && rpc.Attempts >= maxAttempts {
+			rpc.Status = fleet.RPCTimedOut
+			rpc.Updated = time.Now()
+			r.rpcMock[key] = rpc
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *agentRPCRepositoryMock) Supersede(_ context.Context, agentID string, rpcFuncs []string) (int64, error) {
+	if agentID == "" {
+		return 0, errors.ErrMalformedEntity
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	funcs := make(map[string]bool, len(rpcFuncs))
+	for _, f := range rpcFuncs {
+		funcs[f] = true
+	}
+	var count int64
+	for key, rpc := range r.rpcMock {
+		if rpc.AgentID == agentID && rpc.Status == fleet.RPCPending && funcs[rpc.Func] {
+			rpc.Status = fleet.RPCSuperseded
+			rpc.Updated = time.Now()
+			r.rpcMock[key] = rpc
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *agentRPCRepositoryMock) DeleteOlderThan(_ context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for key, rpc := range r.rpcMock {
+		if rpc.Created.Before(t) {
+			delete(r.rpcMock, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *agentRPCRepositoryMock) filter(match func(fleet.AgentRPC) bool) []fleet.AgentRPC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rpcs []fleet.AgentRPC
+	for _, rpc := range r.rpcMock {
+		if match(rpc) {
+			rpcs = append(rpcs, rpc)
+		}
+	}
+	sort.Slice(rpcs, func(i, j int) bool {
+		return rpcs[i].Created.After(rpcs[j].Created)
+	})
+	return rpcs
+}
@@ -0,0 +1,53 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+var _ fleet.AgentVersionPolicyRepository = (*agentVersionPolicyRepositoryMock)(nil)
+
+type agentVersionPolicyRepositoryMock struct {
+	mu         sync.Mutex
+	policyMock map[string]fleet.AgentVersionPolicy
+}
+
+func NewAgentVersionPolicyRepository() fleet.AgentVersionPolicyRepository {
+	return &agentVersionPolicyRepositoryMock{
+		policyMock: make(map[string]fleet.AgentVersionPolicy),
+	}
+}
+
+func (r *agentVersionPolicyRepositoryMock) Save(_ context.Context, p fleet.AgentVersionPolicy) error {
+	if p.MFOwnerID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.policyMock[p.MFOwnerID] = p
+	return nil
+}
+
+func (r *agentVersionPolicyRepositoryMock) RetrieveByOwner(_ context.Context, ownerID string) (fleet.AgentVersionPolicy, error) {
+	if ownerID == "" {
+		return fleet.AgentVersionPolicy{}, errors.ErrMalformedEntity
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.policyMock[ownerID]
+	if !ok {
+		return fleet.AgentVersionPolicy{}, fleet.ErrNotFound
+	}
+	return p, nil
+}
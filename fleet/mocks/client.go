@@ -34,6 +34,12 @@ func (g fleetGrpcClientMock) RetrieveAgentGroup(ctx context.Context, in *pb.Agen
 	return &pb.AgentGroupRes{}, nil
 }
 
+// RetrievePolicyStatus reports a single running agent per target group, so callers can tell which groups were asked for
+func (g fleetGrpcClientMock) RetrievePolicyStatus(ctx context.Context, in *pb.PolicyStatusReq, opts ...grpc.CallOption) (*pb.PolicyStatusRes, error) {
+	total := int32(len(in.GetTargets()))
+	return &pb.PolicyStatusRes{Total: total, States: map[string]int32{"running": total}}, nil
+}
+
 func NewClient() pb.FleetServiceClient {
 	return &fleetGrpcClientMock{}
 }
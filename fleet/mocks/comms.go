@@ -38,6 +38,41 @@ func (ac agentCommsServiceMock) NotifyGroupDatasetRemoval(_ context.Context, _ f
 	return nil
 }
 
+func (ac agentCommsServiceMock) NotifyAgentPolicyUpdate(ctx context.Context, a fleet.Agent, _ string, _ string) error {
+	a, err := ac.aRepoMock.RetrieveByIDWithChannel(ctx, a.MFThingID, a.MFChannelID)
+	if err != nil {
+		return err
+	}
+	if a.InMaintenance {
+		return fleet.ErrAgentPolicySkipped
+	}
+	return nil
+}
+
+func (ac agentCommsServiceMock) TestAgentPolicy(_ context.Context, _ fleet.Agent, policyID string) (fleet.PolicyTestResultRPCPayload, error) {
+	return fleet.PolicyTestResultRPCPayload{PolicyID: policyID, Backend: "pktvisor", Valid: true}, nil
+}
+
+func (ac agentCommsServiceMock) StageAgentCredentials(_ context.Context, _ fleet.Agent, _ string, _ string) error {
+	return nil
+}
+
+func (ac agentCommsServiceMock) CommitAgentCredentials(_ context.Context, _ fleet.Agent, _ string) error {
+	return nil
+}
+
+func (ac agentCommsServiceMock) RequestAgentDiagnostics(_ context.Context, _ fleet.Agent, _ string) error {
+	return nil
+}
+
+func (ac agentCommsServiceMock) RetryAgentRPCs(_ context.Context) error {
+	return nil
+}
+
+func (ac agentCommsServiceMock) RenderAgentPolicy(_ context.Context, _ fleet.Agent, policyID string) (fleet.AgentPolicyRPCPayload, error) {
+	return fleet.AgentPolicyRPCPayload{Action: "manage", ID: policyID}, nil
+}
+
 func NewFleetCommService(agentRepo fleet.AgentRepository, agentGroupRepo fleet.AgentGroupRepository) fleet.AgentCommsService {
 	return &agentCommsServiceMock{
 		aRepoMock:      agentRepo,
@@ -0,0 +1,102 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+var _ fleet.EnrollmentTokenRepository = (*enrollmentTokenRepositoryMock)(nil)
+
+type enrollmentTokenRepositoryMock struct {
+	mu         sync.Mutex
+	tokensMock map[string]fleet.EnrollmentToken
+}
+
+func NewEnrollmentTokenRepository() fleet.EnrollmentTokenRepository {
+	return &enrollmentTokenRepositoryMock{
+		tokensMock: make(map[string]fleet.EnrollmentToken),
+	}
+}
+
+func (r *enrollmentTokenRepositoryMock) Save(_ context.Context, et fleet.EnrollmentToken) (string, error) {
+	ID, err := uuid.NewV4()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	et.ID = ID.String()
+	et.Token = ""
+	et.Created = time.Now()
+	r.tokensMock[et.ID] = et
+	return et.ID, nil
+}
+
+func (r *enrollmentTokenRepositoryMock) RetrieveAllByOwner(_ context.Context, ownerID string) ([]fleet.EnrollmentToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []fleet.EnrollmentToken
+	for _, et := range r.tokensMock {
+		if et.MFOwnerID == ownerID {
+			tokens = append(tokens, et)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Created.After(tokens[j].Created)
+	})
+	return tokens, nil
+}
+
+func (r *enrollmentTokenRepositoryMock) Revoke(_ context.Context, ownerID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	et, ok := r.tokensMock[id]
+	if !ok || et.MFOwnerID != ownerID {
+		return fleet.ErrNotFound
+	}
+	et.Revoked = true
+	r.tokensMock[id] = et
+	return nil
+}
+
+func (r *enrollmentTokenRepositoryMock) Consume(_ context.Context, tokenHash string) (fleet.EnrollmentToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, et := range r.tokensMock {
+		if et.TokenHash != tokenHash {
+			continue
+		}
+		if et.Revoked || !et.ExpiresAt.After(time.Now()) || et.Uses >= et.MaxUses {
+			return fleet.EnrollmentToken{}, fleet.ErrNotFound
+		}
+		et.Uses++
+		r.tokensMock[id] = et
+		return et, nil
+	}
+	return fleet.EnrollmentToken{}, fleet.ErrNotFound
+}
+
+func (r *enrollmentTokenRepositoryMock) Release(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	et, ok := r.tokensMock[id]
+	if !ok {
+		return fleet.ErrNotFound
+	}
+	if et.Uses > 0 {
+		et.Uses--
+	}
+	r.tokensMock[id] = et
+	return nil
+}
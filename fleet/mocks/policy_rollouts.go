@@ -0,0 +1,100 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+var _ fleet.PolicyRolloutRepository = (*policyRolloutRepositoryMock)(nil)
+
+type policyRolloutRepositoryMock struct {
+	mu          sync.Mutex
+	rolloutMock map[string]fleet.PolicyRollout
+}
+
+func NewPolicyRolloutRepository() fleet.PolicyRolloutRepository {
+	return &policyRolloutRepositoryMock{
+		rolloutMock: make(map[string]fleet.PolicyRollout),
+	}
+}
+
+func (r *policyRolloutRepositoryMock) Save(_ context.Context, rollout fleet.PolicyRollout) (string, error) {
+	ID, err := uuid.NewV4()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rollout.ID = ID.String()
+	rollout.Created = time.Now()
+	rollout.LastModified = rollout.Created
+	rollout.Agents = append([]fleet.RolloutAgent(nil), rollout.Agents...)
+	r.rolloutMock[rollout.ID] = rollout
+	return rollout.ID, nil
+}
+
+func (r *policyRolloutRepositoryMock) Update(_ context.Context, rollout fleet.PolicyRollout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.rolloutMock[rollout.ID]
+	if !ok || current.MFOwnerID != rollout.MFOwnerID {
+		return fleet.ErrNotFound
+	}
+	current.State = rollout.State
+	current.CurrentWave = rollout.CurrentWave
+	current.Agents = append([]fleet.RolloutAgent(nil), rollout.Agents...)
+	current.Error = rollout.Error
+	current.LastModified = time.Now()
+	r.rolloutMock[rollout.ID] = current
+	return nil
+}
+
+func (r *policyRolloutRepositoryMock) RetrieveByID(_ context.Context, ownerID string, id string) (fleet.PolicyRollout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rollout, ok := r.rolloutMock[id]
+	if !ok || rollout.MFOwnerID != ownerID {
+		return fleet.PolicyRollout{}, fleet.ErrNotFound
+	}
+	rollout.Agents = append([]fleet.RolloutAgent(nil), rollout.Agents...)
+	return rollout, nil
+}
+
+func (r *policyRolloutRepositoryMock) RetrieveAllByOwner(_ context.Context, ownerID string, policyID string) ([]fleet.PolicyRollout, error) {
+	return r.filter(func(rollout fleet.PolicyRollout) bool {
+		return rollout.MFOwnerID == ownerID && (policyID == "" || rollout.PolicyID == policyID)
+	}), nil
+}
+
+func (r *policyRolloutRepositoryMock) RetrieveAllInProgress(_ context.Context) ([]fleet.PolicyRollout, error) {
+	return r.filter(func(rollout fleet.PolicyRollout) bool {
+		return rollout.State == fleet.RolloutInProgress
+	}), nil
+}
+
+func (r *policyRolloutRepositoryMock) filter(match func(fleet.PolicyRollout) bool) []fleet.PolicyRollout {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rollouts []fleet.PolicyRollout
+	for _, rollout := range r.rolloutMock {
+		if match(rollout) {
+			rollout.Agents = append([]fleet.RolloutAgent(nil), rollout.Agents...)
+			rollouts = append(rollouts, rollout)
+		}
+	}
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].Created.After(rollouts[j].Created)
+	})
+	return rollouts
+}
@@ -26,6 +26,7 @@ type FleetServiceClient interface {
 	RetrieveAgentGroup(ctx context.Context, in *AgentGroupByIDReq, opts ...grpc.CallOption) (*AgentGroupRes, error)
 	RetrieveOwnerByChannelID(ctx context.Context, in *OwnerByChannelIDReq, opts ...grpc.CallOption) (*OwnerRes, error)
 	RetrieveAgentInfoByChannelID(ctx context.Context, in *AgentInfoByChannelIDReq, opts ...grpc.CallOption) (*AgentInfoRes, error)
+	RetrievePolicyStatus(ctx context.Context, in *PolicyStatusReq, opts ...grpc.CallOption) (*PolicyStatusRes, error)
 }
 
 type fleetServiceClient struct {
@@ -72,6 +73,15 @@ func (c *fleetServiceClient) RetrieveAgentInfoByChannelID(ctx context.Context, i
 	return out, nil
 }
 
+func (c *fleetServiceClient) RetrievePolicyStatus(ctx context.Context, in *PolicyStatusReq, opts ...grpc.CallOption) (*PolicyStatusRes, error) {
+	out := new(PolicyStatusRes)
+	err := c.cc.Invoke(ctx, "/fleet.FleetService/RetrievePolicyStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FleetServiceServer is the server API for FleetService service.
 // All implementations must embed UnimplementedFleetServiceServer
 // for forward compatibility
@@ -80,6 +90,7 @@ type FleetServiceServer interface {
 	RetrieveAgentGroup(context.Context, *AgentGroupByIDReq) (*AgentGroupRes, error)
 	RetrieveOwnerByChannelID(context.Context, *OwnerByChannelIDReq) (*OwnerRes, error)
 	RetrieveAgentInfoByChannelID(context.Context, *AgentInfoByChannelIDReq) (*AgentInfoRes, error)
+	RetrievePolicyStatus(context.Context, *PolicyStatusReq) (*PolicyStatusRes, error)
 	mustEmbedUnimplementedFleetServiceServer()
 }
 
@@ -99,6 +110,9 @@ func (UnimplementedFleetServiceServer) RetrieveOwnerByChannelID(context.Context,
 func (UnimplementedFleetServiceServer) RetrieveAgentInfoByChannelID(context.Context, *AgentInfoByChannelIDReq) (*AgentInfoRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RetrieveAgentInfoByChannelID not implemented")
 }
+func (UnimplementedFleetServiceServer) RetrievePolicyStatus(context.Context, *PolicyStatusReq) (*PolicyStatusRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetrievePolicyStatus not implemented")
+}
 func (UnimplementedFleetServiceServer) mustEmbedUnimplementedFleetServiceServer() {}
 
 // UnsafeFleetServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -184,6 +198,24 @@ func _FleetService_RetrieveAgentInfoByChannelID_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FleetService_RetrievePolicyStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PolicyStatusReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetServiceServer).RetrievePolicyStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fleet.FleetService/RetrievePolicyStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetServiceServer).RetrievePolicyStatus(ctx, req.(*PolicyStatusReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FleetService_ServiceDesc is the grpc.ServiceDesc for FleetService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -207,6 +239,10 @@ var FleetService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RetrieveAgentInfoByChannelID",
 			Handler:    _FleetService_RetrieveAgentInfoByChannelID_Handler,
 		},
+		{
+			MethodName: "RetrievePolicyStatus",
+			Handler:    _FleetService_RetrievePolicyStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "fleet/pb/fleet.proto",
@@ -0,0 +1,356 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// PolicyFailedToApply is the heartbeat state of a policy the agent could not apply
+	PolicyFailedToApply = "failed_to_apply"
+	policyRunning       = "running"
+	backendError        = "backend_error"
+)
+
+func (svc fleetService) StartPolicyRolloutInternal(ctx context.Context, ownerID string, policyID string, version int32, groupIDs []string, strategy RolloutStrategy) (PolicyRollout, error) {
+	if err := strategy.Validate(); err != nil {
+		return PolicyRollout{}, err
+	}
+
+	// a newer version of the policy replaces the rollouts of the previous ones
+	previous, err := svc.policyRolloutRepo.RetrieveAllByOwner(ctx, ownerID, policyID)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+	for _, r := range previous {
+		if r.State != RolloutInProgress && r.State != RolloutHalted {
+			continue
+		}
+		r.State = RolloutSuperseded
+		if err := svc.policyRolloutRepo.Update(ctx, r); err != nil {
+			return PolicyRollout{}, err
+		}
+	}
+
+	rollout := PolicyRollout{
+		MFOwnerID: ownerID,
+		PolicyID:  policyID,
+		Version:   version,
+		GroupIDs:  groupIDs,
+		Strategy:  strategy,
+		State:     RolloutInProgress,
+	}
+
+	seen := make(map[string]bool)
+	for _, groupID := range groupIDs {
+		agents, err := svc.agentRepo.RetrieveAllByAgentGroupID(ctx, ownerID, groupID, true)
+		if err != nil {
+			return PolicyRollout{}, err
+		}
+		for _, a := range agents {
+			if seen[a.MFThingID] {
+				continue
+			}
+			seen[a.MFThingID] = true
+			rollout.Agents = append(rollout.Agents, RolloutAgent{
+				AgentID:   a.MFThingID,
+				ChannelID: a.MFChannelID,
+				GroupID:   groupID,
+				State:     RolloutAgentPending,
+			})
+		}
+	}
+	rollout.Waves = assignRolloutWaves(rollout.Agents, strategy)
+	if rollout.Waves == 0 {
+		rollout.State = RolloutCompleted
+	}
+
+	rollout.ID, err = svc.policyRolloutRepo.Save(ctx, rollout)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+
+	if rollout.State == RolloutInProgress {
+		svc.notifyRolloutWave(ctx, &rollout)
+		if err := svc.policyRolloutRepo.Update(ctx, rollout); err != nil {
+			return PolicyRollout{}, err
+		}
+	}
+
+	return rollout, nil
+}
+
+// assignRolloutWaves splits the agents in the canary wave and the following ones, returning the number of waves
+func assignRolloutWaves(agents []RolloutAgent, strategy RolloutStrategy) int {
+	if len(agents) == 0 {
+		return 0
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].AgentID < agents[j].AgentID
+	})
+
+	named := make(map[string]bool, len(strategy.CanaryAgents))
+	for _, id := range strategy.CanaryAgents {
+		named[id] = true
+	}
+	canaryPercent := strategy.CanaryPercent
+	if canaryPercent == 0 && len(named) == 0 {
+		canaryPercent = DefaultCanaryPercent
+	}
+	canarySize := percentOf(len(agents), canaryPercent)
+	waveSize := len(agents)
+	if strategy.WavePercent > 0 {
+		waveSize = percentOf(len(agents), strategy.WavePercent)
+	}
+
+	// named canaries first, then the canary percentage taken from the remaining agents
+	var rest []int
+	for i := range agents {
+		if named[agents[i].AgentID] {
+			agents[i].Wave = 0
+			continue
+		}
+		rest = append(rest, i)
+	}
+	waves := 1
+	for n, i := range rest {
+		if n < canarySize {
+			agents[i].Wave = 0
+			continue
+		}
+		agents[i].Wave = 1 + (n-canarySize)/waveSize
+		if agents[i].Wave+1 > waves {
+			waves = agents[i].Wave + 1
+		}
+	}
+	return waves
+}
+
+// percentOf rounds up, so a non-zero percentage always takes at least one agent
+func percentOf(total int, percent int) int {
+	if percent <= 0 {
+		return 0
+	}
+	return (total*percent + 99) / 100
+}
+
+// notifyRolloutWave sends the policy update to the pending agents of the current wave
+func (svc fleetService) notifyRolloutWave(ctx context.Context, rollout *PolicyRollout) {
+	for i := range rollout.Agents {
+		ra := &rollout.Agents[i]
+		if ra.Wave != rollout.CurrentWave || ra.State != RolloutAgentPending {
+			continue
+		}
+		ra.TsNotified = time.Now()
+		err := svc.agentComms.NotifyAgentPolicyUpdate(ctx, Agent{MFThingID: ra.AgentID, MFChannelID: ra.ChannelID}, ra.GroupID, rollout.PolicyID)
+		// the agent will not report the new version, it must not hold the wave
+		if errors.Contains(err, ErrAgentPolicySkipped) {
+			ra.State = RolloutAgentSkipped
+			ra.Error = err.Error()
+			continue
+		}
+		if err != nil {
+			svc.logger.Error("failed to notify agent of policy rollout", zap.String("rollout_id", rollout.ID),
+				zap.String("agent_id", ra.AgentID), zap.Error(err))
+			ra.State = RolloutAgentFailed
+			ra.Error = err.Error()
+			continue
+		}
+		ra.State = RolloutAgentNotified
+	}
+}
+
+func (svc fleetService) CheckPolicyRollouts(ctx context.Context) ([]PolicyRollout, error) {
+	rollouts, err := svc.policyRolloutRepo.RetrieveAllInProgress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []PolicyRollout
+	for _, rollout := range rollouts {
+		if !svc.checkPolicyRollout(ctx, &rollout) {
+			continue
+		}
+		if err := svc.policyRolloutRepo.Update(ctx, rollout); err != nil {
+			svc.logger.Error("failed to update policy rollout", zap.String("rollout_id", rollout.ID), zap.Error(err))
+			continue
+		}
+		if rollout.State == RolloutRolledBack {
+			rolledBack = append(rolledBack, rollout)
+		}
+	}
+	return rolledBack, nil
+}
+
+// checkPolicyRollout updates the agents of the current wave from their heartbeats, halting or rolling back
+// on the first failure, and moving on to the next wave once all of them run the new version.
+// It returns whether the rollout changed.
+func (svc fleetService) checkPolicyRollout(ctx context.Context, rollout *PolicyRollout) bool {
+	changed := false
+	var failures []string
+	done := true
+	var lastNotified time.Time
+	for i := range rollout.Agents {
+		ra := &rollout.Agents[i]
+		if ra.Wave != rollout.CurrentWave {
+			continue
+		}
+		if ra.State == RolloutAgentNotified {
+			state, reason := svc.rolloutAgentState(ctx, rollout, *ra)
+			if state != ra.State {
+				ra.State = state
+				ra.Error = reason
+				changed = true
+			}
+		}
+		switch ra.State {
+		case RolloutAgentFailed:
+			failures = append(failures, fmt.Sprintf("agent %s: %s", ra.AgentID, ra.Error))
+		case RolloutAgentNotified, RolloutAgentPending:
+			done = false
+		}
+		if ra.TsNotified.After(lastNotified) {
+			lastNotified = ra.TsNotified
+		}
+	}
+
+	if len(failures) > 0 {
+		rollout.Error = fmt.Sprintf("wave %d failed, %s", rollout.CurrentWave, failures[0])
+		if rollout.Strategy.AutoRollback {
+			rollout.State = RolloutRolledBack
+		} else {
+			rollout.State = RolloutHalted
+		}
+		return true
+	}
+	if !done {
+		return changed
+	}
+
+	if rollout.CurrentWave+1 >= rollout.Waves {
+		rollout.State = RolloutCompleted
+		return true
+	}
+	if time.Since(lastNotified) < time.Duration(rollout.Strategy.WaveIntervalSeconds)*time.Second {
+		return changed
+	}
+	rollout.CurrentWave++
+	svc.notifyRolloutWave(ctx, rollout)
+	return true
+}
+
+// rolloutAgentState tells from its last heartbeat whether a notified agent applied, or failed to apply, the rollout version
+func (svc fleetService) rolloutAgentState(ctx context.Context, rollout *PolicyRollout, ra RolloutAgent) (string, string) {
+	a, err := svc.agentRepo.RetrieveByID(ctx, rollout.MFOwnerID, ra.AgentID)
+	if err != nil {
+		if errors.Contains(err, errors.ErrNotFound) || errors.Contains(err, ErrNotFound) {
+			return RolloutAgentSkipped, "agent was removed"
+		}
+		return RolloutAgentNotified, ""
+	}
+
+	jsonHb, err := json.Marshal(a.LastHBData)
+	if err != nil {
+		return RolloutAgentNotified, ""
+	}
+	var hb Heartbeat
+	if err = json.Unmarshal(jsonHb, &hb); err != nil {
+		return RolloutAgentNotified, ""
+	}
+
+	if ps, ok := hb.PolicyState[rollout.PolicyID]; ok && ps.Version >= rollout.Version {
+		if ps.State == PolicyFailedToApply {
+			return RolloutAgentFailed, ps.Error
+		}
+		if bs, ok := hb.BackendState[ps.Backend]; ok && bs.State == backendError {
+			return RolloutAgentFailed, bs.Error
+		}
+		if ps.State == policyRunning {
+			return RolloutAgentApplied, ""
+		}
+	}
+
+	if time.Since(ra.TsNotified) > RolloutWaveTimeout {
+		return RolloutAgentFailed, "timed out waiting for the agent to run the new policy version"
+	}
+	return RolloutAgentNotified, ""
+}
+
+func (svc fleetService) ViewPolicyRollout(ctx context.Context, token string, id string) (PolicyRollout, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+	return svc.policyRolloutRepo.RetrieveByID(ctx, ownerID, id)
+}
+
+func (svc fleetService) ListPolicyRollouts(ctx context.Context, token string, policyID string) ([]PolicyRollout, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return nil, err
+	}
+	return svc.policyRolloutRepo.RetrieveAllByOwner(ctx, ownerID, policyID)
+}
+
+func (svc fleetService) RollbackPolicyRollout(ctx context.Context, token string, id string) (PolicyRollout, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+
+	rollout, err := svc.policyRolloutRepo.RetrieveByID(ctx, ownerID, id)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+	if rollout.State != RolloutInProgress && rollout.State != RolloutHalted {
+		return PolicyRollout{}, ErrRolloutNotInProgress
+	}
+
+	rollout.State = RolloutRolledBack
+	if rollout.Error == "" {
+		rollout.Error = "rollback requested"
+	}
+	if err := svc.policyRolloutRepo.Update(ctx, rollout); err != nil {
+		return PolicyRollout{}, err
+	}
+	return rollout, nil
+}
+
+func (svc fleetService) ResumePolicyRollout(ctx context.Context, token string, id string) (PolicyRollout, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+
+	rollout, err := svc.policyRolloutRepo.RetrieveByID(ctx, ownerID, id)
+	if err != nil {
+		return PolicyRollout{}, err
+	}
+	if rollout.State != RolloutHalted {
+		return PolicyRollout{}, ErrRolloutNotHalted
+	}
+
+	// the failures are accepted, they keep their error but no longer hold the wave
+	for i := range rollout.Agents {
+		ra := &rollout.Agents[i]
+		if ra.Wave == rollout.CurrentWave && ra.State == RolloutAgentFailed {
+			ra.State = RolloutAgentSkipped
+		}
+	}
+	rollout.State = RolloutInProgress
+	rollout.Error = ""
+	if err := svc.policyRolloutRepo.Update(ctx, rollout); err != nil {
+		return PolicyRollout{}, err
+	}
+	return rollout, nil
+}
@@ -0,0 +1,325 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofrs/uuid"
+	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+)
+
+const rolloutPolicyID = "policy-1"
+
+func newRolloutService(t *testing.T, agents int) (fleet.Service, fleet.AgentRepository) {
+	t.Helper()
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	agentGroupRepo := flmocks.NewAgentGroupRepository()
+	agentRepo := flmocks.NewAgentRepositoryMock()
+	agentComms := flmocks.NewFleetCommService(agentRepo, agentGroupRepo)
+	logger, _ := zap.NewDevelopment()
+	svc := fleet.NewFleetService(logger, users, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), agentComms, mfsdk.NewSDK(mfsdk.Config{}), fleet.NewThingKeyService(""), "", make(chan bool))
+
+	for i := 0; i < agents; i++ {
+		require.Nil(t, agentRepo.Save(context.Background(), newRolloutAgent(t, i)), "unexpected error saving agent")
+	}
+	return svc, agentRepo
+}
+
+func newRolloutAgent(t *testing.T, i int) fleet.Agent {
+	t.Helper()
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	name, err := types.NewIdentifier(fmt.Sprintf("rollout-agent-%d", i))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	return fleet.Agent{
+		Name:        name,
+		MFOwnerID:   email,
+		MFThingID:   id.String(),
+		MFChannelID: id.String(),
+	}
+}
+
+// setPolicyState replaces the agent with one reporting the given policy state on its heartbeat
+func setPolicyState(t *testing.T, repo fleet.AgentRepository, agentID string, version int32, state string) {
+	t.Helper()
+	a, err := repo.RetrieveByID(context.Background(), email, agentID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	a.LastHBData = types.Metadata{
+		"policy_state": map[string]interface{}{
+			rolloutPolicyID: map[string]interface{}{
+				"name":    "policy",
+				"state":   state,
+				"version": version,
+				"backend": "pktvisor",
+			},
+		},
+	}
+	require.Nil(t, repo.Delete(context.Background(), email, agentID), "unexpected error deleting agent")
+	require.Nil(t, repo.Save(context.Background(), a), "unexpected error saving agent")
+}
+
+func agentsOnWave(r fleet.PolicyRollout, wave int) []fleet.RolloutAgent {
+	var agents []fleet.RolloutAgent
+	for _, ra := range r.Agents {
+		if ra.Wave == wave {
+			agents = append(agents, ra)
+		}
+	}
+	return agents
+}
+
+func TestStartPolicyRollout(t *testing.T) {
+	cases := map[string]struct {
+		agents   int
+		strategy fleet.RolloutStrategy
+		waves    int
+		canaries int
+		state    string
+		err      error
+	}{
+		"start rollout with canary and waves": {
+			agents:   4,
+			strategy: fleet.RolloutStrategy{CanaryPercent: 25, WavePercent: 50},
+			waves:    3,
+			canaries: 1,
+			state:    fleet.RolloutInProgress,
+		},
+		"start rollout with default canary": {
+			agents:   4,
+			strategy: fleet.RolloutStrategy{},
+			waves:    2,
+			canaries: 1,
+			state:    fleet.RolloutInProgress,
+		},
+		"start rollout without agents": {
+			agents:   0,
+			strategy: fleet.RolloutStrategy{},
+			waves:    0,
+			state:    fleet.RolloutCompleted,
+		},
+		"start rollout with invalid strategy": {
+			agents:   1,
+			strategy: fleet.RolloutStrategy{CanaryPercent: 101},
+			err:      fleet.ErrInvalidRollout,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			svc, _ := newRolloutService(t, tc.agents)
+			r, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 2, []string{"group-1"}, tc.strategy)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err != nil {
+				return
+			}
+			assert.Equal(t, tc.state, r.State, fmt.Sprintf("%s: expected state %s got %s", desc, tc.state, r.State))
+			assert.Equal(t, tc.waves, r.Waves, fmt.Sprintf("%s: expected %d waves got %d", desc, tc.waves, r.Waves))
+			canaries := agentsOnWave(r, 0)
+			assert.Len(t, canaries, tc.canaries, fmt.Sprintf("%s: expected %d canaries got %d", desc, tc.canaries, len(canaries)))
+			for _, ra := range canaries {
+				assert.Equal(t, fleet.RolloutAgentNotified, ra.State, fmt.Sprintf("%s: expected canary to be notified", desc))
+			}
+		})
+	}
+}
+
+func TestCheckPolicyRollouts(t *testing.T) {
+	svc, agentRepo := newRolloutService(t, 4)
+	strategy := fleet.RolloutStrategy{CanaryPercent: 25, WavePercent: 50, AutoRollback: true}
+	r, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 2, []string{"group-1"}, strategy)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// the canary has not reported the new version yet
+	rolledBack, err := svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Empty(t, rolledBack, "expected no rolled back rollouts")
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 0, r.CurrentWave, "expected rollout to wait on the canary wave")
+
+	// the canary runs the new version, the next wave is notified
+	setPolicyState(t, agentRepo, agentsOnWave(r, 0)[0].AgentID, 2, "running")
+	_, err = svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 1, r.CurrentWave, "expected rollout to move to the next wave")
+	wave := agentsOnWave(r, 1)
+	require.Len(t, wave, 2, "expected two agents on the second wave")
+	for _, ra := range wave {
+		assert.Equal(t, fleet.RolloutAgentNotified, ra.State, "expected agent of the second wave to be notified")
+	}
+
+	// an agent of the wave fails to apply it, the rollout is rolled back
+	setPolicyState(t, agentRepo, wave[0].AgentID, 2, "failed_to_apply")
+	rolledBack, err = svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, rolledBack, 1, "expected the rollout to be rolled back")
+	assert.Equal(t, fleet.RolloutRolledBack, rolledBack[0].State, "expected the rollout to be rolled back")
+	assert.Equal(t, fleet.RolloutAgentPending, agentsOnWave(rolledBack[0], 2)[0].State, "expected last wave to be left pending")
+}
+
+func TestCheckPolicyRolloutsHalt(t *testing.T) {
+	svc, agentRepo := newRolloutService(t, 2)
+	r, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 2, []string{"group-1"}, fleet.RolloutStrategy{CanaryPercent: 50})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// an older version failing is not the rollout failing
+	setPolicyState(t, agentRepo, agentsOnWave(r, 0)[0].AgentID, 1, "failed_to_apply")
+	_, err = svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.RolloutInProgress, r.State, "expected rollout to still be in progress")
+
+	setPolicyState(t, agentRepo, agentsOnWave(r, 0)[0].AgentID, 2, "failed_to_apply")
+	rolledBack, err := svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Empty(t, rolledBack, "expected no rolled back rollouts")
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.RolloutHalted, r.State, "expected rollout to be halted")
+	assert.NotEmpty(t, r.Error, "expected halted rollout to report the failure")
+}
+
+func TestCheckPolicyRolloutsSkipped(t *testing.T) {
+	svc, agentRepo := newRolloutService(t, 1)
+	a, err := agentRepo.RetrieveAll(context.Background(), email, fleet.PageMetadata{Limit: 1})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, a.Agents, 1, "expected the rollout agent")
+	maintained := a.Agents[0]
+	maintained.InMaintenance = true
+	require.Nil(t, agentRepo.Delete(context.Background(), email, maintained.MFThingID), "unexpected error deleting agent")
+	require.Nil(t, agentRepo.Save(context.Background(), maintained), "unexpected error saving agent")
+
+	r, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 2, []string{"group-1"}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.RolloutAgentSkipped, agentsOnWave(r, 0)[0].State, "expected agent in maintenance to be skipped")
+
+	// the skipped agent does not report the new version, the rollout does not wait on it
+	_, err = svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.RolloutCompleted, r.State, "expected rollout to complete")
+}
+
+func TestRollbackPolicyRollout(t *testing.T) {
+	svc, _ := newRolloutService(t, 2)
+	superseded, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 2, []string{"group-1"}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	current, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 3, []string{"group-1"}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	rollouts, err := svc.ListPolicyRollouts(context.Background(), token, rolloutPolicyID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, rollouts, 2, "expected both rollouts of the policy")
+
+	cases := map[string]struct {
+		id    string
+		token string
+		state string
+		err   error
+	}{
+		"rollback in progress rollout": {
+			id:    current.ID,
+			token: token,
+			state: fleet.RolloutRolledBack,
+		},
+		"rollback superseded rollout": {
+			id:    superseded.ID,
+			token: token,
+			err:   fleet.ErrRolloutNotInProgress,
+		},
+		"rollback non-existing rollout": {
+			id:    "9bb1b244-a199-93c2-aa03-28067b431e2c",
+			token: token,
+			err:   fleet.ErrNotFound,
+		},
+		"rollback rollout with wrong credentials": {
+			id:    current.ID,
+			token: "wrong",
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			r, err := svc.RollbackPolicyRollout(context.Background(), tc.token, tc.id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, tc.state, r.State, fmt.Sprintf("%s: expected state %s got %s", desc, tc.state, r.State))
+			}
+		})
+	}
+}
+
+func TestResumePolicyRollout(t *testing.T) {
+	svc, agentRepo := newRolloutService(t, 2)
+	r, err := svc.StartPolicyRolloutInternal(context.Background(), email, rolloutPolicyID, 2, []string{"group-1"}, fleet.RolloutStrategy{CanaryPercent: 50})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	inProgress, err := svc.StartPolicyRolloutInternal(context.Background(), email, "policy-2", 2, []string{"group-1"}, fleet.RolloutStrategy{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	canaryID := agentsOnWave(r, 0)[0].AgentID
+	setPolicyState(t, agentRepo, canaryID, 2, "failed_to_apply")
+	_, err = svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id    string
+		token string
+		err   error
+	}{
+		"resume halted rollout": {
+			id:    r.ID,
+			token: token,
+		},
+		"resume in progress rollout": {
+			id:    inProgress.ID,
+			token: token,
+			err:   fleet.ErrRolloutNotHalted,
+		},
+		"resume non-existing rollout": {
+			id:    "9bb1b244-a199-93c2-aa03-28067b431e2c",
+			token: token,
+			err:   fleet.ErrNotFound,
+		},
+		"resume rollout with wrong credentials": {
+			id:    r.ID,
+			token: "wrong",
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, err := svc.ResumePolicyRollout(context.Background(), tc.token, tc.id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		})
+	}
+
+	// the failed canary is skipped and the next wave is notified
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.RolloutInProgress, r.State, "expected rollout to be in progress again")
+	assert.Empty(t, r.Error, "expected the failure of the rollout to be cleared")
+	assert.Equal(t, fleet.RolloutAgentSkipped, agentsOnWave(r, 0)[0].State, "expected failed canary to be skipped")
+
+	_, err = svc.CheckPolicyRollouts(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	r, err = svc.ViewPolicyRollout(context.Background(), token, r.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 1, r.CurrentWave, "expected rollout to move to the next wave")
+}
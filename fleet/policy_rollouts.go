@@ -0,0 +1,137 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	RolloutInProgress = "in_progress"
+	RolloutCompleted  = "completed"
+	RolloutHalted     = "halted"
+	RolloutRolledBack = "rolled_back"
+	RolloutSuperseded = "superseded"
+
+	RolloutAgentPending  = "pending"
+	RolloutAgentNotified = "notified"
+	RolloutAgentApplied  = "applied"
+	RolloutAgentFailed   = "failed"
+	RolloutAgentSkipped  = "skipped"
+
+	// DefaultCanaryPercent is the share of agents receiving the update first when no canary is given
+	DefaultCanaryPercent = 10
+	// RolloutWaveTimeout is how long an agent has to report the new policy version running before it is considered failed
+	RolloutWaveTimeout = 5 * time.Minute
+	// RolloutCheckFreq is how often the in progress rollouts are checked against the agents heartbeats
+	RolloutCheckFreq = 15 * time.Second
+)
+
+var (
+	// ErrInvalidRollout indicates a malformed rollout strategy
+	ErrInvalidRollout = errors.New("invalid policy rollout strategy")
+	// ErrRolloutNotInProgress indicates an action that requires the rollout to be in progress
+	ErrRolloutNotInProgress = errors.New("policy rollout is not in progress")
+	// ErrRolloutNotHalted indicates an action that requires the rollout to be halted
+	ErrRolloutNotHalted = errors.New("policy rollout is not halted")
+)
+
+// RolloutStrategy describes how a policy update is staged across the agents running it:
+// canary agents first, then waves of the remaining ones, as long as no agent fails to apply it
+type RolloutStrategy struct {
+	CanaryPercent       int      `json:"canary_percent,omitempty"`
+	CanaryAgents        []string `json:"canary_agents,omitempty"`
+	WavePercent         int      `json:"wave_percent,omitempty"`
+	WaveIntervalSeconds int      `json:"wave_interval_seconds,omitempty"`
+	AutoRollback        bool     `json:"auto_rollback"`
+}
+
+// Validate checks the percentages and interval of the strategy are in range
+func (s RolloutStrategy) Validate() error {
+	if s.CanaryPercent < 0 || s.CanaryPercent > 100 || s.WavePercent < 0 || s.WavePercent > 100 {
+		return errors.Wrap(ErrInvalidRollout, errors.New("percentages must be between 0 and 100"))
+	}
+	if s.WaveIntervalSeconds < 0 {
+		return errors.Wrap(ErrInvalidRollout, errors.New("wave interval must not be negative"))
+	}
+	return nil
+}
+
+// RolloutAgent is an agent running the policy, with the wave it belongs to and how it took the update
+type RolloutAgent struct {
+	AgentID    string    `json:"agent_id"`
+	ChannelID  string    `json:"channel_id"`
+	GroupID    string    `json:"group_id"`
+	Wave       int       `json:"wave"`
+	State      string    `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	TsNotified time.Time `json:"ts_notified,omitempty"`
+}
+
+// PolicyRollout tracks a staged update of a policy to a given version
+type PolicyRollout struct {
+	ID           string
+	MFOwnerID    string
+	PolicyID     string
+	Version      int32
+	GroupIDs     []string
+	Strategy     RolloutStrategy
+	State        string
+	CurrentWave  int
+	Waves        int
+	Agents       []RolloutAgent
+	Error        string
+	Created      time.Time
+	LastModified time.Time
+}
+
+type PolicyRolloutService interface {
+	// StartPolicyRolloutInternal stages the update of a policy to the given version across the agents of the groups running it
+	StartPolicyRolloutInternal(ctx context.Context, ownerID string, policyID string, version int32, groupIDs []string, strategy RolloutStrategy) (PolicyRollout, error)
+	// CheckPolicyRollouts advances the in progress rollouts according to the agents heartbeats, returning the ones rolled back
+	CheckPolicyRollouts(ctx context.Context) ([]PolicyRollout, error)
+	// ViewPolicyRollout retrieves a policy rollout by id
+	ViewPolicyRollout(ctx context.Context, token string, id string) (PolicyRollout, error)
+	// ListPolicyRollouts retrieves the rollouts of an owner, optionally of a single policy, newest first
+	ListPolicyRollouts(ctx context.Context, token string, policyID string) ([]PolicyRollout, error)
+	// RollbackPolicyRollout stops an in progress or halted rollout and requests the policy to be restored to its previous version
+	RollbackPolicyRollout(ctx context.Context, token string, id string) (PolicyRollout, error)
+	// ResumePolicyRollout moves a halted rollout on, the agents of the current wave that failed are skipped
+	ResumePolicyRollout(ctx context.Context, token string, id string) (PolicyRollout, error)
+}
+
+type PolicyRolloutRepository interface {
+	// Save persists a new policy rollout, returning its id
+	Save(ctx context.Context, rollout PolicyRollout) (string, error)
+	// Update persists the state, wave and agents of a policy rollout
+	Update(ctx context.Context, rollout PolicyRollout) error
+	// RetrieveByID retrieves a policy rollout by owner and id
+	RetrieveByID(ctx context.Context, ownerID string, id string) (PolicyRollout, error)
+	// RetrieveAllByOwner retrieves the rollouts of an owner, only of the given policy when policyID is not empty
+	RetrieveAllByOwner(ctx context.Context, ownerID string, policyID string) ([]PolicyRollout, error)
+	// RetrieveAllInProgress retrieves the rollouts of all owners that are in progress
+	RetrieveAllInProgress(ctx context.Context) ([]PolicyRollout, error)
+}
+
+// MonitorPolicyRollouts checks the in progress rollouts every freq until the context is done.
+// It must be given the fully wrapped service, so that rollbacks reach the event store.
+func MonitorPolicyRollouts(ctx context.Context, logger *zap.Logger, svc Service, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.CheckPolicyRollouts(ctx); err != nil {
+				logger.Error("failed to check policy rollouts", zap.Error(err))
+			}
+		}
+	}
+}
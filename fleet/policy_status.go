@@ -0,0 +1,67 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+)
+
+const (
+	// PolicyNoTapMatch is the heartbeat state of a policy whose input did not match any tap of the agent
+	PolicyNoTapMatch = "no_tap_match"
+	// PolicyStatusOffline counts the targeted agents which are not online, whatever their last heartbeat reported
+	PolicyStatusOffline = "offline"
+	// PolicyStatusNotReported counts the online agents whose heartbeat does not include the policy yet
+	PolicyStatusNotReported = "not_reported"
+)
+
+// PolicyStatusTarget is an agent group running a policy through a dataset, with the agents the dataset excludes
+type PolicyStatusTarget struct {
+	AgentGroupID     string
+	ExcludedAgentIDs []string
+}
+
+// PolicyStatusError counts the targeted agents reporting the same error for a policy
+type PolicyStatusError struct {
+	Error string
+	Count int
+}
+
+// StalePolicyAgent is a targeted agent reporting a version of the policy older than the current one
+type StalePolicyAgent struct {
+	AgentID   string
+	AgentName string
+	Version   int32
+}
+
+// PolicyStatus aggregates the state of a policy reported in the last heartbeats of the agents it targets
+type PolicyStatus struct {
+	Total int
+	// States counts the targeted agents by the state they report for the policy, PolicyStatusOffline for the ones
+	// which are not online
+	States map[string]int
+	// Errors lists the distinct errors reported for the policy, most frequent first
+	Errors []PolicyStatusError
+	// StaleAgents lists the agents reporting a previous version of the policy, by name
+	StaleAgents []StalePolicyAgent
+}
+
+// NewPolicyStatus returns an empty status, with the states always reported already counted at zero
+func NewPolicyStatus() PolicyStatus {
+	return PolicyStatus{
+		States: map[string]int{
+			policyRunning:       0,
+			PolicyFailedToApply: 0,
+			PolicyNoTapMatch:    0,
+			PolicyStatusOffline: 0,
+		},
+	}
+}
+
+type PolicyStatusService interface {
+	// ViewPolicyStatusInternal aggregates the heartbeats of the agents of the target groups for the policy, comparing
+	// the version they report with the given one
+	ViewPolicyStatusInternal(ctx context.Context, ownerID string, policyID string, version int32, targets []PolicyStatusTarget) (PolicyStatus, error)
+}
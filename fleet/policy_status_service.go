@@ -0,0 +1,104 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+func (svc fleetService) ViewPolicyStatusInternal(ctx context.Context, ownerID string, policyID string, version int32, targets []PolicyStatusTarget) (PolicyStatus, error) {
+	if ownerID == "" || policyID == "" {
+		return PolicyStatus{}, ErrMalformedEntity
+	}
+
+	groupIDs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		groupIDs = append(groupIDs, t.AgentGroupID)
+	}
+	members, err := svc.agentRepo.RetrieveAllByAgentGroupIDs(ctx, ownerID, groupIDs)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	// an agent is targeted as long as one of its groups runs the policy without excluding it
+	targeted := make(map[string]Agent)
+	for _, t := range targets {
+		excluded := make(map[string]bool, len(t.ExcludedAgentIDs))
+		for _, id := range t.ExcludedAgentIDs {
+			excluded[id] = true
+		}
+		for _, a := range members[t.AgentGroupID] {
+			if !excluded[a.MFThingID] {
+				targeted[a.MFThingID] = a
+			}
+		}
+	}
+
+	status := NewPolicyStatus()
+	errorCounts := make(map[string]int)
+	for _, a := range targeted {
+		status.Total++
+		ps, reported := svc.reportedPolicyState(a, policyID)
+		switch {
+		case a.State != Online:
+			status.States[PolicyStatusOffline]++
+		case !reported:
+			status.States[PolicyStatusNotReported]++
+		default:
+			status.States[ps.State]++
+		}
+		if !reported {
+			continue
+		}
+		if ps.Error != "" {
+			errorCounts[ps.Error]++
+		}
+		if ps.Version < version {
+			status.StaleAgents = append(status.StaleAgents, StalePolicyAgent{
+				AgentID:   a.MFThingID,
+				AgentName: a.Name.String(),
+				Version:   ps.Version,
+			})
+		}
+	}
+
+	for e, count := range errorCounts {
+		status.Errors = append(status.Errors, PolicyStatusError{Error: e, Count: count})
+	}
+	sort.Slice(status.Errors, func(i, j int) bool {
+		if status.Errors[i].Count != status.Errors[j].Count {
+			return status.Errors[i].Count > status.Errors[j].Count
+		}
+		return status.Errors[i].Error < status.Errors[j].Error
+	})
+	sort.Slice(status.StaleAgents, func(i, j int) bool {
+		if status.StaleAgents[i].AgentName != status.StaleAgents[j].AgentName {
+			return status.StaleAgents[i].AgentName < status.StaleAgents[j].AgentName
+		}
+		return status.StaleAgents[i].AgentID < status.StaleAgents[j].AgentID
+	})
+
+	return status, nil
+}
+
+// reportedPolicyState returns the state of the policy in the last heartbeat of the agent, if it reported one
+func (svc fleetService) reportedPolicyState(a Agent, policyID string) (PolicyStateInfo, bool) {
+	jsonHb, err := json.Marshal(a.LastHBData)
+	if err != nil {
+		svc.logger.Error("failed to marshal heartbeat data", zap.String("agent_id", a.MFThingID), zap.Error(err))
+		return PolicyStateInfo{}, false
+	}
+	var hb Heartbeat
+	if err = json.Unmarshal(jsonHb, &hb); err != nil {
+		svc.logger.Error("failed to unmarshal heartbeat data", zap.String("agent_id", a.MFThingID), zap.Error(err))
+		return PolicyStateInfo{}, false
+	}
+	ps, ok := hb.PolicyState[policyID]
+	return ps, ok
+}
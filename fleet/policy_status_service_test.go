@@ -0,0 +1,140 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// saveStatusAgent saves an agent in the given state, reporting the policy state on its heartbeat unless it is empty
+func saveStatusAgent(t *testing.T, repo fleet.AgentRepository, i int, state fleet.State, policyState string, version int32, policyErr string) fleet.Agent {
+	t.Helper()
+	a := newRolloutAgent(t, i)
+	a.State = state
+	if policyState != "" {
+		a.LastHBData = types.Metadata{
+			"policy_state": map[string]interface{}{
+				rolloutPolicyID: map[string]interface{}{
+					"name":    "policy",
+					"state":   policyState,
+					"version": version,
+					"error":   policyErr,
+				},
+			},
+		}
+	}
+	require.Nil(t, repo.Save(context.Background(), a), "unexpected error saving agent")
+	return a
+}
+
+func TestViewPolicyStatusInternal(t *testing.T) {
+	svc, agentRepo := newRolloutService(t, 0)
+	running := saveStatusAgent(t, agentRepo, 0, fleet.Online, "running", 2, "")
+	stale := saveStatusAgent(t, agentRepo, 1, fleet.Online, "running", 1, "")
+	failing := saveStatusAgent(t, agentRepo, 2, fleet.Online, fleet.PolicyFailedToApply, 2, "invalid tap")
+	saveStatusAgent(t, agentRepo, 3, fleet.Online, fleet.PolicyFailedToApply, 2, "invalid tap")
+	saveStatusAgent(t, agentRepo, 4, fleet.Online, fleet.PolicyNoTapMatch, 2, "")
+	saveStatusAgent(t, agentRepo, 5, fleet.Offline, "running", 2, "")
+	saveStatusAgent(t, agentRepo, 6, fleet.Online, "", 0, "")
+
+	cases := map[string]struct {
+		ownerID  string
+		policyID string
+		targets  []fleet.PolicyStatusTarget
+		total    int
+		states   map[string]int
+		errors   []fleet.PolicyStatusError
+		stale    []string
+		err      error
+	}{
+		"view status of a policy across the agents of the group": {
+			ownerID:  email,
+			policyID: rolloutPolicyID,
+			targets:  []fleet.PolicyStatusTarget{{AgentGroupID: "group-1"}},
+			total:    7,
+			states: map[string]int{
+				"running":                     2,
+				fleet.PolicyFailedToApply:     2,
+				fleet.PolicyNoTapMatch:        1,
+				fleet.PolicyStatusOffline:     1,
+				fleet.PolicyStatusNotReported: 1,
+			},
+			errors: []fleet.PolicyStatusError{{Error: "invalid tap", Count: 2}},
+			stale:  []string{stale.MFThingID},
+		},
+		"view status of a policy leaving out the excluded agents": {
+			ownerID:  email,
+			policyID: rolloutPolicyID,
+			targets:  []fleet.PolicyStatusTarget{{AgentGroupID: "group-1", ExcludedAgentIDs: []string{stale.MFThingID, failing.MFThingID}}},
+			total:    5,
+			states: map[string]int{
+				"running":                     1,
+				fleet.PolicyFailedToApply:     1,
+				fleet.PolicyNoTapMatch:        1,
+				fleet.PolicyStatusOffline:     1,
+				fleet.PolicyStatusNotReported: 1,
+			},
+			errors: []fleet.PolicyStatusError{{Error: "invalid tap", Count: 1}},
+		},
+		"view status of a policy with an agent excluded by only one of its groups": {
+			ownerID:  email,
+			policyID: rolloutPolicyID,
+			targets: []fleet.PolicyStatusTarget{
+				{AgentGroupID: "group-1", ExcludedAgentIDs: []string{running.MFThingID}},
+				{AgentGroupID: "group-2"},
+			},
+			total: 7,
+			states: map[string]int{
+				"running":                     2,
+				fleet.PolicyFailedToApply:     2,
+				fleet.PolicyNoTapMatch:        1,
+				fleet.PolicyStatusOffline:     1,
+				fleet.PolicyStatusNotReported: 1,
+			},
+			errors: []fleet.PolicyStatusError{{Error: "invalid tap", Count: 2}},
+			stale:  []string{stale.MFThingID},
+		},
+		"view status of a policy without targets": {
+			ownerID:  email,
+			policyID: rolloutPolicyID,
+			total:    0,
+			states: map[string]int{
+				"running":                 0,
+				fleet.PolicyFailedToApply: 0,
+				fleet.PolicyNoTapMatch:    0,
+				fleet.PolicyStatusOffline: 0,
+			},
+		},
+		"view status without policy id": {
+			ownerID: email,
+			err:     fleet.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			status, err := svc.ViewPolicyStatusInternal(context.Background(), tc.ownerID, tc.policyID, 2, tc.targets)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err != nil {
+				return
+			}
+			assert.Equal(t, tc.total, status.Total, fmt.Sprintf("%s: expected %d got %d", desc, tc.total, status.Total))
+			assert.Equal(t, tc.states, status.States, fmt.Sprintf("%s: expected %v got %v", desc, tc.states, status.States))
+			assert.Equal(t, tc.errors, status.Errors, fmt.Sprintf("%s: expected %v got %v", desc, tc.errors, status.Errors))
+			var staleIDs []string
+			for _, a := range status.StaleAgents {
+				staleIDs = append(staleIDs, a.AgentID)
+			}
+			assert.Equal(t, tc.stale, staleIDs, fmt.Sprintf("%s: expected %v got %v", desc, tc.stale, staleIDs))
+		})
+	}
+}
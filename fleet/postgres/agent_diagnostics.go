@@ -0,0 +1,256 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var _ fleet.AgentDiagnosticsRepository = (*agentDiagnosticsRepository)(nil)
+
+type agentDiagnosticsRepository struct {
+	db     Database
+	logger *zap.Logger
+}
+
+func (r agentDiagnosticsRepository) Save(ctx context.Context, b fleet.DiagnosticsBundle) error {
+	q := `INSERT INTO agent_diagnostics (id, agent_id, mf_owner_id, status)
+			VALUES (:id, :agent_id, :mf_owner_id, :status)`
+
+	if b.ID == "" || b.AgentID == "" || b.MFOwnerID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	if _, err := r.db.NamedExecContext(ctx, q, toDBDiagnosticsBundle(b)); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return errors.Wrap(errors.ErrMalformedEntity, err)
+			case db.ErrDuplicate:
+				return errors.Wrap(errors.ErrConflict, err)
+			}
+		}
+		return errors.Wrap(db.ErrSaveDB, err)
+	}
+	return nil
+}
+
+func (r agentDiagnosticsRepository) RetrieveByID(ctx context.Context, bundleID string) (fleet.DiagnosticsBundle, error) {
+	q := `SELECT id, agent_id, mf_owner_id, status, error, chunks, data, ts_created, ts_completed,
+				CASE WHEN status = $2 THEN chunks
+					ELSE (SELECT COUNT(*) FROM agent_diagnostic_chunks c WHERE c.bundle_id = d.id) END AS chunks_received
+			FROM agent_diagnostics d WHERE id = $1`
+
+	var dbb dbDiagnosticsBundle
+	if err := r.db.QueryRowxContext(ctx, q, bundleID, fleet.DiagnosticsComplete).StructScan(&dbb); err != nil {
+		if err == sql.ErrNoRows {
+			return fleet.DiagnosticsBundle{}, errors.Wrap(fleet.ErrNotFound, err)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == db.ErrInvalid {
+			return fleet.DiagnosticsBundle{}, errors.Wrap(fleet.ErrNotFound, err)
+		}
+		return fleet.DiagnosticsBundle{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	return toDiagnosticsBundle(dbb), nil
+}
+
+func (r agentDiagnosticsRepository) SaveChunk(ctx context.Context, bundleID string, seq int, total int, data []byte) (int, error) {
+	// the chunk inserted by the statement is not visible to its own count, hence counted apart
+	q := `WITH bundle AS (
+				UPDATE agent_diagnostics SET chunks = :total WHERE id = :bundle_id AND status = :collecting RETURNING id
+			), chunk AS (
+				INSERT INTO agent_diagnostic_chunks (bundle_id, seq, data) SELECT id, :seq, :data FROM bundle
+				ON CONFLICT (bundle_id, seq) DO NOTHING RETURNING seq
+			)
+			SELECT (SELECT COUNT(*) FROM bundle) AS collecting,
+				(SELECT COUNT(*) FROM agent_diagnostic_chunks WHERE bundle_id = :bundle_id) + (SELECT COUNT(*) FROM chunk) AS received`
+
+	params := map[string]interface{}{
+		"bundle_id":  bundleID,
+		"seq":        seq,
+		"total":      total,
+		"data":       data,
+		"collecting": fleet.DiagnosticsCollecting,
+	}
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return 0, errors.Wrap(db.ErrSaveDB, err)
+	}
+	defer rows.Close()
+
+	var res struct {
+		Collecting int `db:"collecting"`
+		Received   int `db:"received"`
+	}
+	if rows.Next() {
+		if err := rows.StructScan(&res); err != nil {
+			return 0, errors.Wrap(db.ErrSaveDB, err)
+		}
+	}
+	if res.Collecting == 0 {
+		return 0, fleet.ErrNotFound
+	}
+	return res.Received, nil
+}
+
+func (r agentDiagnosticsRepository) RetrieveChunks(ctx context.Context, bundleID string) ([][]byte, error) {
+	q := `SELECT data FROM agent_diagnostic_chunks WHERE bundle_id = :bundle_id ORDER BY seq`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, map[string]interface{}{"bundle_id": bundleID})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var chunks [][]byte
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		chunks = append(chunks, data)
+	}
+	return chunks, nil
+}
+
+func (r agentDiagnosticsRepository) Complete(ctx context.Context, bundleID string, data []byte) error {
+	q := `UPDATE agent_diagnostics SET status = :complete, data = :data, ts_completed = CURRENT_TIMESTAMP
+			WHERE id = :bundle_id AND status = :collecting RETURNING id`
+
+	params := map[string]interface{}{
+		"bundle_id":  bundleID,
+		"data":       data,
+		"complete":   fleet.DiagnosticsComplete,
+		"collecting": fleet.DiagnosticsCollecting,
+	}
+	count, err := r.closeBundles(ctx, q, params)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fleet.ErrNotFound
+	}
+	return nil
+}
+
+func (r agentDiagnosticsRepository) Fail(ctx context.Context, bundleID string, errMsg string) error {
+	q := `UPDATE agent_diagnostics SET status = :failed, error = :error, ts_completed = CURRENT_TIMESTAMP
+			WHERE id = :bundle_id AND status = :collecting RETURNING id`
+
+	params := map[string]interface{}{
+		"bundle_id":  bundleID,
+		"error":      errMsg,
+		"failed":     fleet.DiagnosticsFailed,
+		"collecting": fleet.DiagnosticsCollecting,
+	}
+	count, err := r.closeBundles(ctx, q, params)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fleet.ErrNotFound
+	}
+	return nil
+}
+
+func (r agentDiagnosticsRepository) ExpireCollecting(ctx context.Context, createdBefore time.Time, errMsg string) (int64, error) {
+	q := `UPDATE agent_diagnostics SET status = :failed, error = :error, ts_completed = CURRENT_TIMESTAMP
+			WHERE status = :collecting AND ts_created < :created_before RETURNING id`
+
+	params := map[string]interface{}{
+		"created_before": createdBefore,
+		"error":          errMsg,
+		"failed":         fleet.DiagnosticsFailed,
+		"collecting":     fleet.DiagnosticsCollecting,
+	}
+	return r.closeBundles(ctx, q, params)
+}
+
+func (r agentDiagnosticsRepository) DeleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	q := `DELETE FROM agent_diagnostics WHERE ts_created < :before`
+
+	res, err := r.db.NamedExecContext(ctx, q, map[string]interface{}{"before": t})
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrRemoveEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrRemoveEntity, err)
+	}
+	return count, nil
+}
+
+// closeBundles runs an update of the bundle status returning the ids of the updated bundles, dropping their chunks in
+// the same statement, and counts them
+func (r agentDiagnosticsRepository) closeBundles(ctx context.Context, update string, params map[string]interface{}) (int64, error) {
+	q := `WITH closed AS (` + update + `), dropped AS (
+				DELETE FROM agent_diagnostic_chunks WHERE bundle_id IN (SELECT id FROM closed)
+			)
+			SELECT COUNT(*) FROM closed`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	defer rows.Close()
+
+	var count int64
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, errors.Wrap(fleet.ErrUpdateEntity, err)
+		}
+	}
+	return count, nil
+}
+
+type dbDiagnosticsBundle struct {
+	ID             string       `db:"id"`
+	AgentID        string       `db:"agent_id"`
+	MFOwnerID      string       `db:"mf_owner_id"`
+	Status         string       `db:"status"`
+	Error          string       `db:"error"`
+	Chunks         int          `db:"chunks"`
+	ChunksReceived int          `db:"chunks_received"`
+	Data           []byte       `db:"data"`
+	Created        time.Time    `db:"ts_created"`
+	Completed      sql.NullTime `db:"ts_completed"`
+}
+
+func toDBDiagnosticsBundle(b fleet.DiagnosticsBundle) dbDiagnosticsBundle {
+	return dbDiagnosticsBundle{
+		ID:        b.ID,
+		AgentID:   b.AgentID,
+		MFOwnerID: b.MFOwnerID,
+		Status:    b.Status,
+	}
+}
+
+func toDiagnosticsBundle(dbb dbDiagnosticsBundle) fleet.DiagnosticsBundle {
+	return fleet.DiagnosticsBundle{
+		ID:             dbb.ID,
+		AgentID:        dbb.AgentID,
+		MFOwnerID:      dbb.MFOwnerID,
+		Status:         dbb.Status,
+		Error:          dbb.Error,
+		Chunks:         dbb.Chunks,
+		ChunksReceived: dbb.ChunksReceived,
+		Data:           dbb.Data,
+		Created:        dbb.Created,
+		Completed:      dbb.Completed.Time,
+	}
+}
+
+func NewAgentDiagnosticsRepository(db Database, logger *zap.Logger) fleet.AgentDiagnosticsRepository {
+	return &agentDiagnosticsRepository{db: db, logger: logger}
+}
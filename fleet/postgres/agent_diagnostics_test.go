@@ -0,0 +1,195 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/fleet/postgres"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveDiagnosticsBundle(t *testing.T, repo fleet.AgentDiagnosticsRepository, agent fleet.Agent) fleet.DiagnosticsBundle {
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	b := fleet.DiagnosticsBundle{
+		ID:        id.String(),
+		AgentID:   agent.MFThingID,
+		MFOwnerID: agent.MFOwnerID,
+		Status:    fleet.DiagnosticsCollecting,
+	}
+	err = repo.Save(context.Background(), b)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	return b
+}
+
+func TestAgentDiagnosticsSave(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	diagRepo := postgres.NewAgentDiagnosticsRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "diagnostics-save-agent")
+	bundle := saveDiagnosticsBundle(t, diagRepo, agent)
+
+	cases := map[string]struct {
+		bundle fleet.DiagnosticsBundle
+		err    error
+	}{
+		"save existing bundle": {
+			bundle: bundle,
+			err:    errors.ErrConflict,
+		},
+		"save bundle without agent": {
+			bundle: fleet.DiagnosticsBundle{ID: bundle.AgentID, MFOwnerID: agent.MFOwnerID},
+			err:    errors.ErrMalformedEntity,
+		},
+		"save bundle with invalid id": {
+			bundle: fleet.DiagnosticsBundle{ID: wrongValue, AgentID: agent.MFThingID, MFOwnerID: agent.MFOwnerID, Status: fleet.DiagnosticsCollecting},
+			err:    errors.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := diagRepo.Save(context.Background(), tc.bundle)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", desc, tc.err, err))
+		})
+	}
+
+	got, err := diagRepo.RetrieveByID(context.Background(), bundle.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsCollecting, got.Status, fmt.Sprintf("expected status %s got %s", fleet.DiagnosticsCollecting, got.Status))
+
+	_, err = diagRepo.RetrieveByID(context.Background(), wrongValue)
+	assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("expected '%s' got '%s'", fleet.ErrNotFound, err))
+}
+
+func TestAgentDiagnosticsChunks(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	diagRepo := postgres.NewAgentDiagnosticsRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "diagnostics-chunks-agent")
+	bundle := saveDiagnosticsBundle(t, diagRepo, agent)
+
+	cases := []struct {
+		desc     string
+		seq      int
+		data     string
+		received int
+	}{
+		{desc: "save last chunk first", seq: 1, data: "world", received: 1},
+		{desc: "save first chunk", seq: 0, data: "hello ", received: 2},
+		{desc: "save chunk sent again", seq: 0, data: "hello ", received: 2},
+	}
+
+	// the chunks received depend on the previous ones, hence run in order
+	for _, tc := range cases {
+		received, err := diagRepo.SaveChunk(context.Background(), bundle.ID, tc.seq, 2, []byte(tc.data))
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.received, received, fmt.Sprintf("%s: expected %d chunks got %d", tc.desc, tc.received, received))
+	}
+
+	got, err := diagRepo.RetrieveByID(context.Background(), bundle.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 2, got.Chunks, fmt.Sprintf("expected %d chunks announced got %d", 2, got.Chunks))
+	assert.Equal(t, 2, got.ChunksReceived, fmt.Sprintf("expected %d chunks received got %d", 2, got.ChunksReceived))
+
+	chunks, err := diagRepo.RetrieveChunks(context.Background(), bundle.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	var data []byte
+	for _, c := range chunks {
+		data = append(data, c...)
+	}
+	assert.Equal(t, "hello world", string(data), fmt.Sprintf("expected chunks in order got %s", data))
+
+	err = diagRepo.Complete(context.Background(), bundle.ID, data)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	got, err = diagRepo.RetrieveByID(context.Background(), bundle.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsComplete, got.Status, fmt.Sprintf("expected status %s got %s", fleet.DiagnosticsComplete, got.Status))
+	assert.Equal(t, "hello world", string(got.Data), fmt.Sprintf("expected the assembled bundle got %s", got.Data))
+	assert.Equal(t, 2, got.ChunksReceived, fmt.Sprintf("complete bundle: expected %d chunks received got %d", 2, got.ChunksReceived))
+
+	chunks, err = diagRepo.RetrieveChunks(context.Background(), bundle.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Empty(t, chunks, "complete bundle: expected its chunks to be dropped")
+
+	_, err = diagRepo.SaveChunk(context.Background(), bundle.ID, 0, 2, []byte("late"))
+	assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("chunk of complete bundle: expected '%s' got '%s'", fleet.ErrNotFound, err))
+}
+
+func TestAgentDiagnosticsClose(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	diagRepo := postgres.NewAgentDiagnosticsRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "diagnostics-close-agent")
+	failed := saveDiagnosticsBundle(t, diagRepo, agent)
+	expired := saveDiagnosticsBundle(t, diagRepo, agent)
+
+	_, err := diagRepo.SaveChunk(context.Background(), failed.ID, 0, 2, []byte("chunk"))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc  string
+		close func() error
+		err   error
+	}{
+		{
+			desc:  "fail collecting bundle",
+			close: func() error { return diagRepo.Fail(context.Background(), failed.ID, "disk full") },
+			err:   nil,
+		},
+		{
+			desc:  "fail bundle already failed",
+			close: func() error { return diagRepo.Fail(context.Background(), failed.ID, "disk full") },
+			err:   fleet.ErrNotFound,
+		},
+		{
+			desc:  "complete failed bundle",
+			close: func() error { return diagRepo.Complete(context.Background(), failed.ID, []byte("data")) },
+			err:   fleet.ErrNotFound,
+		},
+	}
+
+	// closing depends on the previous status of the bundle, hence run in order
+	for _, tc := range cases {
+		err := tc.close()
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+	}
+
+	got, err := diagRepo.RetrieveByID(context.Background(), failed.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsFailed, got.Status, fmt.Sprintf("expected status %s got %s", fleet.DiagnosticsFailed, got.Status))
+	assert.Equal(t, "disk full", got.Error, fmt.Sprintf("expected error %s got %s", "disk full", got.Error))
+	chunks, err := diagRepo.RetrieveChunks(context.Background(), failed.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Empty(t, chunks, "failed bundle: expected its chunks to be dropped")
+
+	_, err = diagRepo.ExpireCollecting(context.Background(), time.Now().Add(-time.Hour), "timed out")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	got, err = diagRepo.RetrieveByID(context.Background(), expired.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsCollecting, got.Status, fmt.Sprintf("recent bundle: expected status %s got %s", fleet.DiagnosticsCollecting, got.Status))
+
+	_, err = diagRepo.ExpireCollecting(context.Background(), time.Now().Add(time.Hour), "timed out")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	got, err = diagRepo.RetrieveByID(context.Background(), expired.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, fleet.DiagnosticsFailed, got.Status, fmt.Sprintf("old bundle: expected status %s got %s", fleet.DiagnosticsFailed, got.Status))
+	assert.Equal(t, "timed out", got.Error, fmt.Sprintf("old bundle: expected error %s got %s", "timed out", got.Error))
+
+	_, err = diagRepo.DeleteOlderThan(context.Background(), time.Now().Add(time.Hour))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = diagRepo.RetrieveByID(context.Background(), expired.ID)
+	assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("deleted bundle: expected '%s' got '%s'", fleet.ErrNotFound, err))
+}
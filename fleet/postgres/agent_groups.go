@@ -64,6 +64,10 @@ func (a agentGroupRepository) RetrieveAllAgentGroupsByOwner(ctx context.Context,
 			mf_channel_id,
 			tags,
 			ts_created,
+			maintenance,
+			maintenance_reason,
+			ts_maintenance_start,
+			ts_maintenance_end,
 			json_build_object('total', total, 'online', online) AS matching_agents
 		from
 			(select
@@ -74,6 +78,10 @@ func (a agentGroupRepository) RetrieveAllAgentGroupsByOwner(ctx context.Context,
 				ag.mf_channel_id,
 				ag.tags,
 				ag.ts_created,
+				ag.maintenance,
+				ag.maintenance_reason,
+				ag.ts_maintenance_start,
+				ag.ts_maintenance_end,
 				sum(case when agm.agent_groups_id is not null then 1 else 0 end) as total,
 				sum(case when agm.agent_state = 'online' then 1 else 0 end) as online
 			from agent_groups ag
@@ -87,7 +95,11 @@ func (a agentGroupRepository) RetrieveAllAgentGroupsByOwner(ctx context.Context,
 					ag.mf_owner_id,
 					ag.mf_channel_id,
 					ag.tags,
-					ag.ts_created)
+					ag.ts_created,
+					ag.maintenance,
+					ag.maintenance_reason,
+					ag.ts_maintenance_start,
+					ag.ts_maintenance_end)
 			as agent_groups ORDER BY %s %s LIMIT :limit OFFSET :offset;`, nameQuery, tagsQuery, metadataQuery, orderQuery, dirQuery)
 
 	params := map[string]interface{}{
@@ -176,6 +188,10 @@ func (a agentGroupRepository) RetrieveByID(ctx context.Context, groupID string,
 		mf_channel_id,
 		tags,
 		ts_created,
+		maintenance,
+		maintenance_reason,
+		ts_maintenance_start,
+		ts_maintenance_end,
 		json_build_object('total', total, 'online', online) AS matching_agents
 	from
 	(select
@@ -186,6 +202,10 @@ func (a agentGroupRepository) RetrieveByID(ctx context.Context, groupID string,
 		ag.mf_channel_id,
 		ag.tags,
 		ag.ts_created,
+		ag.maintenance,
+		ag.maintenance_reason,
+		ag.ts_maintenance_start,
+		ag.ts_maintenance_end,
 		sum(case when agm.agent_groups_id is not null then 1 else 0 end) as total,
 		sum(case when agm.agent_state = 'online' then 1 else 0 end) as online
 	from agent_groups ag
@@ -199,7 +219,11 @@ func (a agentGroupRepository) RetrieveByID(ctx context.Context, groupID string,
 		ag.mf_owner_id,
 		ag.mf_channel_id,
 		ag.tags,
-		ag.ts_created) as agent_groups`
+		ag.ts_created,
+		ag.maintenance,
+		ag.maintenance_reason,
+		ag.ts_maintenance_start,
+		ag.ts_maintenance_end) as agent_groups`
 
 	if groupID == "" || ownerID == "" {
 		return fleet.AgentGroup{}, errors.ErrMalformedEntity
@@ -376,6 +400,7 @@ type dbAgentGroup struct {
 	Tags           db.Tags          `db:"tags"`
 	Created        time.Time        `db:"ts_created"`
 	MatchingAgents db.Metadata      `db:"matching_agents"`
+	dbMaintenance
 }
 
 type dbMatchingGroups struct {
@@ -383,6 +408,69 @@ type dbMatchingGroups struct {
 	GroupName types.Identifier `db:"group_name"`
 }
 
+func (a agentGroupRepository) SetMaintenance(ctx context.Context, ownerID string, groupID string, m *fleet.Maintenance) error {
+	q := `UPDATE agent_groups SET (maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end)
+			= (:maintenance, :maintenance_reason, :ts_maintenance_start, :ts_maintenance_end)
+			WHERE mf_owner_id = :mf_owner_id AND id = :id;`
+
+	if groupID == "" || ownerID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	groupDB := dbAgentGroup{ID: groupID, MFOwnerID: ownerID, dbMaintenance: toDBMaintenance(m)}
+	res, err := a.db.NamedExecContext(ctx, q, groupDB)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid:
+				return errors.Wrap(fleet.ErrNotFound, err)
+			case db.ErrTruncation:
+				return errors.Wrap(fleet.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return fleet.ErrNotFound
+	}
+	return nil
+}
+
+func (a agentGroupRepository) RetrieveAllInMaintenance(ctx context.Context, owner string) ([]fleet.AgentGroup, error) {
+	oq := ""
+	if owner != "" {
+		oq = ` AND mf_owner_id = :mf_owner_id`
+	}
+	q := fmt.Sprintf(`SELECT id, name, mf_owner_id, mf_channel_id, maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end
+		FROM agent_groups WHERE %s%s`, openMaintenanceQuery, oq)
+
+	rows, err := a.db.NamedQueryContext(ctx, q, map[string]interface{}{"mf_owner_id": owner})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []fleet.AgentGroup
+	for rows.Next() {
+		dbg := dbAgentGroup{}
+		if err := rows.StructScan(&dbg); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		group, err := toAgentGroup(dbg)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		items = append(items, group)
+	}
+	return items, nil
+}
+
 func toDBAgentGroup(group fleet.AgentGroup) (dbAgentGroup, error) {
 
 	var description string
@@ -423,6 +511,7 @@ func toAgentGroup(dba dbAgentGroup) (fleet.AgentGroup, error) {
 		Created:        dba.Created,
 		Tags:           &groupTags,
 		MatchingAgents: types.Metadata(dba.MatchingAgents),
+		Maintenance:    toMaintenance(dba.dbMaintenance),
 	}, nil
 
 }
@@ -0,0 +1,237 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var _ fleet.AgentRPCRepository = (*agentRPCRepository)(nil)
+
+const agentRPCColumns = `correlation_id, agent_id, channel_id, func, payload, status, error, attempts, ts_created, ts_last_sent, ts_updated`
+
+type agentRPCRepository struct {
+	db     Database
+	logger *zap.Logger
+}
+
+func (r agentRPCRepository) Save(ctx context.Context, rpc fleet.AgentRPC) error {
+	q := `INSERT INTO agent_rpcs (correlation_id, agent_id, channel_id, func, payload, status, attempts)
+			VALUES (:correlation_id, :agent_id, :channel_id, :func, :payload, :status, :attempts)
+			ON CONFLICT (correlation_id, agent_id) DO NOTHING`
+
+	if rpc.CorrelationID == "" || rpc.AgentID == "" || rpc.ChannelID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	if _, err := r.db.NamedExecContext(ctx, q, toDBAgentRPC(rpc)); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return errors.Wrap(errors.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(db.ErrSaveDB, err)
+	}
+	return nil
+}
+
+func (r agentRPCRepository) UpdateStatus(ctx context.Context, agentID string, correlationID string, status string, errMsg string) error {
+	q := `UPDATE agent_rpcs SET status = :status, error = :error, ts_updated = CURRENT_TIMESTAMP
+			WHERE agent_id = :agent_id AND correlation_id = :correlation_id AND status IN (:pending, :timed_out, :superseded)`
+
+	params := map[string]interface{}{
+		"agent_id":       agentID,
+		"correlation_id": correlationID,
+		"status":         status,
+		"error":          errMsg,
+		"pending":        fleet.RPCPending,
+		"timed_out":      fleet.RPCTimedOut,
+		"superseded":     fleet.RPCSuperseded,
+	}
+	res, err := r.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return errors.Wrap(fleet.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return fleet.ErrNotFound
+	}
+	return nil
+}
+
+func (r agentRPCRepository) RetrieveAllByAgent(ctx context.Context, agentID string, limit uint64) ([]fleet.AgentRPC, error) {
+	q := `SELECT ` + agentRPCColumns + ` FROM agent_rpcs WHERE agent_id = :agent_id ORDER BY ts_created DESC LIMIT :limit`
+
+	params := map[string]interface{}{
+		"agent_id": agentID,
+		"limit":    limit,
+	}
+	return r.retrieve(ctx, q, params)
+}
+
+func (r agentRPCRepository) ClaimRetries(ctx context.Context, sentBefore time.Time, maxAttempts int) ([]fleet.AgentRPC, error) {
+	q := `UPDATE agent_rpcs SET attempts = attempts + 1, ts_last_sent = CURRENT_TIMESTAMP
+			WHERE status = :status AND ts_last_sent < :sent_before AND attempts < :max_attempts
+			RETURNING ` + agentRPCColumns
+
+	params := map[string]interface{}{
+		"status":       fleet.RPCPending,
+		"sent_before":  sentBefore,
+		"max_attempts": maxAttempts,
+	}
+	return r.retrieve(ctx, q, params)
+}
+
+func (r agentRPCRepository) ExpirePending(ctx context.Context, sentBefore time.Time, maxAttempts int) (int64, error) {
+	q := `UPDATE agent_rpcs SET status = :timed_out, ts_updated = CURRENT_TIMESTAMP
+			WHERE status = :status AND ts_last_sent < :sent_before AND attempts >= :max_attempts`
+
+	params := map[string]interface{}{
+		"timed_out":    fleet.RPCTimedOut,
+		"status":       fleet.RPCPending,
+		"sent_before":  sentBefore,
+		"max_attempts": maxAttempts,
+	}
+	res, err := r.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	return count, nil
+}
+
+func (r agentRPCRepository) Supersede(ctx context.Context, agentID string, rpcFuncs []string) (int64, error) {
+	q := `UPDATE agent_rpcs SET status = :superseded, ts_updated = CURRENT_TIMESTAMP
+			WHERE agent_id = :agent_id AND status = :status AND func = ANY(:funcs)`
+
+	if agentID == "" {
+		return 0, errors.ErrMalformedEntity
+	}
+
+	params := map[string]interface{}{
+		"superseded": fleet.RPCSuperseded,
+		"agent_id":   agentID,
+		"status":     fleet.RPCPending,
+		"funcs":      pq.Array(rpcFuncs),
+	}
+	res, err := r.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return 0, errors.Wrap(fleet.ErrMalformedEntity, err)
+			}
+		}
+		return 0, errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	return count, nil
+}
+
+func (r agentRPCRepository) DeleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	q := `DELETE FROM agent_rpcs WHERE ts_created < :before`
+
+	res, err := r.db.NamedExecContext(ctx, q, map[string]interface{}{"before": t})
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrRemoveEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(fleet.ErrRemoveEntity, err)
+	}
+	return count, nil
+}
+
+func (r agentRPCRepository) retrieve(ctx context.Context, q string, params map[string]interface{}) ([]fleet.AgentRPC, error) {
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []fleet.AgentRPC
+	for rows.Next() {
+		dbr := dbAgentRPC{}
+		if err := rows.StructScan(&dbr); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		items = append(items, toAgentRPC(dbr))
+	}
+	return items, nil
+}
+
+type dbAgentRPC struct {
+	CorrelationID string    `db:"correlation_id"`
+	AgentID       string    `db:"agent_id"`
+	ChannelID     string    `db:"channel_id"`
+	Func          string    `db:"func"`
+	Payload       []byte    `db:"payload"`
+	Status        string    `db:"status"`
+	Error         string    `db:"error"`
+	Attempts      int       `db:"attempts"`
+	Created       time.Time `db:"ts_created"`
+	LastSent      time.Time `db:"ts_last_sent"`
+	Updated       time.Time `db:"ts_updated"`
+}
+
+func toDBAgentRPC(rpc fleet.AgentRPC) dbAgentRPC {
+	return dbAgentRPC{
+		CorrelationID: rpc.CorrelationID,
+		AgentID:       rpc.AgentID,
+		ChannelID:     rpc.ChannelID,
+		Func:          rpc.Func,
+		Payload:       rpc.Payload,
+		Status:        rpc.Status,
+		Error:         rpc.Error,
+		Attempts:      rpc.Attempts,
+	}
+}
+
+func toAgentRPC(dbr dbAgentRPC) fleet.AgentRPC {
+	return fleet.AgentRPC{
+		CorrelationID: dbr.CorrelationID,
+		AgentID:       dbr.AgentID,
+		ChannelID:     dbr.ChannelID,
+		Func:          dbr.Func,
+		Payload:       dbr.Payload,
+		Status:        dbr.Status,
+		Error:         dbr.Error,
+		Attempts:      dbr.Attempts,
+		Created:       dbr.Created,
+		LastSent:      dbr.LastSent,
+		Updated:       dbr.Updated,
+	}
+}
+
+func NewAgentRPCRepository(db Database, logger *zap.Logger) fleet.AgentRPCRepository {
+	return &agentRPCRepository{db: db, logger: logger}
+}
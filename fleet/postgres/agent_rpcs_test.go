@@ -0,0 +1,264 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/fleet/postgres"
+	orbdb "github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saveAgent saves an agent the rows referencing agents can belong to
+func saveAgent(t *testing.T, name string) fleet.Agent {
+	agentRepo := postgres.NewAgentRepository(postgres.NewDatabase(db), logger)
+
+	thID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nameID, err := types.NewIdentifier(name)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	agent := fleet.Agent{
+		Name:        nameID,
+		MFThingID:   thID.String(),
+		MFOwnerID:   oID.String(),
+		MFChannelID: chID.String(),
+	}
+	err = agentRepo.Save(context.Background(), agent)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	return agent
+}
+
+func saveAgentRPC(t *testing.T, repo fleet.AgentRPCRepository, agent fleet.Agent, rpcFunc string) fleet.AgentRPC {
+	corrID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	rpc := fleet.AgentRPC{
+		CorrelationID: corrID.String(),
+		AgentID:       agent.MFThingID,
+		ChannelID:     agent.MFChannelID,
+		Func:          rpcFunc,
+		Payload:       []byte(`{"func":"` + rpcFunc + `"}`),
+		Status:        fleet.RPCPending,
+		Attempts:      1,
+	}
+	err = repo.Save(context.Background(), rpc)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	return rpc
+}
+
+func retrieveAgentRPC(t *testing.T, repo fleet.AgentRPCRepository, rpc fleet.AgentRPC) fleet.AgentRPC {
+	rpcs, err := repo.RetrieveAllByAgent(context.Background(), rpc.AgentID, 100)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	for _, r := range rpcs {
+		if r.CorrelationID == rpc.CorrelationID {
+			return r
+		}
+	}
+	require.FailNow(t, fmt.Sprintf("rpc %s not found", rpc.CorrelationID))
+	return fleet.AgentRPC{}
+}
+
+func TestAgentRPCSave(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	rpcRepo := postgres.NewAgentRPCRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "rpc-save-agent")
+	rpc := saveAgentRPC(t, rpcRepo, agent, fleet.AgentPolicyRPCFunc)
+
+	unknownAgent := rpc
+	unknownAgent.AgentID = rpc.ChannelID
+
+	cases := map[string]struct {
+		rpc fleet.AgentRPC
+		err error
+	}{
+		"save an RPC already tracked": {
+			rpc: rpc,
+			err: nil,
+		},
+		"save RPC without correlation id": {
+			rpc: fleet.AgentRPC{AgentID: agent.MFThingID, ChannelID: agent.MFChannelID, Func: fleet.AgentPolicyRPCFunc},
+			err: errors.ErrMalformedEntity,
+		},
+		"save RPC with invalid correlation id": {
+			rpc: fleet.AgentRPC{CorrelationID: wrongValue, AgentID: agent.MFThingID, ChannelID: agent.MFChannelID, Func: fleet.AgentPolicyRPCFunc, Payload: []byte("{}")},
+			err: errors.ErrMalformedEntity,
+		},
+		"save RPC of unknown agent": {
+			rpc: unknownAgent,
+			err: orbdb.ErrSaveDB,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := rpcRepo.Save(context.Background(), tc.rpc)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", desc, tc.err, err))
+		})
+	}
+
+	got := retrieveAgentRPC(t, rpcRepo, rpc)
+	assert.Equal(t, rpc.Payload, got.Payload, fmt.Sprintf("expected payload %s got %s", rpc.Payload, got.Payload))
+	assert.Equal(t, fleet.RPCPending, got.Status, fmt.Sprintf("expected status %s got %s", fleet.RPCPending, got.Status))
+}
+
+func TestAgentRPCUpdateStatus(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	rpcRepo := postgres.NewAgentRPCRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "rpc-status-agent")
+	applied := saveAgentRPC(t, rpcRepo, agent, fleet.AgentPolicyRPCFunc)
+	failed := saveAgentRPC(t, rpcRepo, agent, fleet.GroupMembershipRPCFunc)
+
+	cases := []struct {
+		desc   string
+		rpc    fleet.AgentRPC
+		status string
+		errMsg string
+		err    error
+	}{
+		{desc: "acknowledge pending RPC as applied", rpc: applied, status: fleet.RPCApplied, err: nil},
+		{desc: "acknowledge pending RPC as failed", rpc: failed, status: fleet.RPCFailed, errMsg: "invalid policy", err: nil},
+		{desc: "acknowledge RPC already acknowledged", rpc: applied, status: fleet.RPCFailed, err: fleet.ErrNotFound},
+		{desc: "acknowledge unknown RPC", rpc: fleet.AgentRPC{AgentID: agent.MFThingID, CorrelationID: agent.MFChannelID}, status: fleet.RPCApplied, err: fleet.ErrNotFound},
+		{desc: "acknowledge RPC with invalid correlation id", rpc: fleet.AgentRPC{AgentID: agent.MFThingID, CorrelationID: wrongValue}, status: fleet.RPCApplied, err: fleet.ErrMalformedEntity},
+	}
+
+	// acknowledgements depend on the previous ones, hence run in order
+	for _, tc := range cases {
+		err := rpcRepo.UpdateStatus(context.Background(), tc.rpc.AgentID, tc.rpc.CorrelationID, tc.status, tc.errMsg)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+	}
+
+	got := retrieveAgentRPC(t, rpcRepo, applied)
+	assert.Equal(t, fleet.RPCApplied, got.Status, fmt.Sprintf("expected status %s got %s", fleet.RPCApplied, got.Status))
+	got = retrieveAgentRPC(t, rpcRepo, failed)
+	assert.Equal(t, fleet.RPCFailed, got.Status, fmt.Sprintf("expected status %s got %s", fleet.RPCFailed, got.Status))
+	assert.Equal(t, "invalid policy", got.Error, fmt.Sprintf("expected error %s got %s", "invalid policy", got.Error))
+}
+
+func TestAgentRPCRetries(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	rpcRepo := postgres.NewAgentRPCRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "rpc-retries-agent")
+	pending := saveAgentRPC(t, rpcRepo, agent, fleet.AgentPolicyRPCFunc)
+	applied := saveAgentRPC(t, rpcRepo, agent, fleet.GroupMembershipRPCFunc)
+	err := rpcRepo.UpdateStatus(context.Background(), agent.MFThingID, applied.CorrelationID, fleet.RPCApplied, "")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	claimed := func(sentBefore time.Time, maxAttempts int) []string {
+		rpcs, err := rpcRepo.ClaimRetries(context.Background(), sentBefore, maxAttempts)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		var ids []string
+		for _, r := range rpcs {
+			if r.AgentID == agent.MFThingID {
+				ids = append(ids, r.CorrelationID)
+			}
+		}
+		return ids
+	}
+
+	ids := claimed(time.Now().Add(-time.Hour), 3)
+	assert.Empty(t, ids, fmt.Sprintf("RPC sent recently: expected no retry got %v", ids))
+
+	ids = claimed(time.Now().Add(time.Hour), 3)
+	assert.Equal(t, []string{pending.CorrelationID}, ids, fmt.Sprintf("pending RPC: expected retry of %s got %v", pending.CorrelationID, ids))
+	assert.Equal(t, 2, retrieveAgentRPC(t, rpcRepo, pending).Attempts, "expected the retry to count as an attempt")
+
+	ids = claimed(time.Now().Add(time.Hour), 2)
+	assert.Empty(t, ids, fmt.Sprintf("RPC out of attempts: expected no retry got %v", ids))
+
+	_, err = rpcRepo.ExpirePending(context.Background(), time.Now().Add(time.Hour), 2)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	got := retrieveAgentRPC(t, rpcRepo, pending)
+	assert.Equal(t, fleet.RPCTimedOut, got.Status, fmt.Sprintf("RPC out of attempts: expected status %s got %s", fleet.RPCTimedOut, got.Status))
+	got = retrieveAgentRPC(t, rpcRepo, applied)
+	assert.Equal(t, fleet.RPCApplied, got.Status, fmt.Sprintf("acknowledged RPC: expected status %s got %s", fleet.RPCApplied, got.Status))
+
+	// an agent acknowledging after fleet gave up on the RPC still records the outcome
+	err = rpcRepo.UpdateStatus(context.Background(), agent.MFThingID, pending.CorrelationID, fleet.RPCApplied, "")
+	assert.Nil(t, err, fmt.Sprintf("late acknowledgement: expected no error got '%s'", err))
+}
+
+func TestAgentRPCSupersede(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	rpcRepo := postgres.NewAgentRPCRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "rpc-supersede-agent")
+	other := saveAgent(t, "rpc-supersede-other")
+	policy := saveAgentRPC(t, rpcRepo, agent, fleet.AgentPolicyRPCFunc)
+	membership := saveAgentRPC(t, rpcRepo, agent, fleet.GroupMembershipRPCFunc)
+	otherPolicy := saveAgentRPC(t, rpcRepo, other, fleet.AgentPolicyRPCFunc)
+
+	count, err := rpcRepo.Supersede(context.Background(), agent.MFThingID, []string{fleet.AgentPolicyRPCFunc})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, int64(1), count, fmt.Sprintf("expected %d superseded RPC got %d", 1, count))
+
+	cases := map[string]struct {
+		rpc    fleet.AgentRPC
+		status string
+	}{
+		"RPC of the superseded func": {
+			rpc:    policy,
+			status: fleet.RPCSuperseded,
+		},
+		"RPC of another func": {
+			rpc:    membership,
+			status: fleet.RPCPending,
+		},
+		"RPC of another agent": {
+			rpc:    otherPolicy,
+			status: fleet.RPCPending,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			got := retrieveAgentRPC(t, rpcRepo, tc.rpc)
+			assert.Equal(t, tc.status, got.Status, fmt.Sprintf("%s: expected status %s got %s", desc, tc.status, got.Status))
+		})
+	}
+
+	_, err = rpcRepo.Supersede(context.Background(), "", []string{fleet.AgentPolicyRPCFunc})
+	assert.True(t, errors.Contains(err, errors.ErrMalformedEntity), fmt.Sprintf("expected '%s' got '%s'", errors.ErrMalformedEntity, err))
+}
+
+func TestAgentRPCDeleteOlderThan(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	rpcRepo := postgres.NewAgentRPCRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "rpc-delete-agent")
+	saveAgentRPC(t, rpcRepo, agent, fleet.AgentPolicyRPCFunc)
+
+	_, err := rpcRepo.DeleteOlderThan(context.Background(), time.Now().Add(-time.Hour))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	rpcs, err := rpcRepo.RetrieveAllByAgent(context.Background(), agent.MFThingID, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 1, len(rpcs), fmt.Sprintf("recent RPC: expected %d RPC got %d", 1, len(rpcs)))
+
+	_, err = rpcRepo.DeleteOlderThan(context.Background(), time.Now().Add(time.Hour))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	rpcs, err = rpcRepo.RetrieveAllByAgent(context.Background(), agent.MFThingID, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 0, len(rpcs), fmt.Sprintf("old RPC: expected %d RPC got %d", 0, len(rpcs)))
+}
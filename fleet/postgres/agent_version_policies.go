@@ -0,0 +1,123 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var _ fleet.AgentVersionPolicyRepository = (*agentVersionPolicyRepository)(nil)
+
+type agentVersionPolicyRepository struct {
+	db     Database
+	logger *zap.Logger
+}
+
+func (r agentVersionPolicyRepository) Save(ctx context.Context, p fleet.AgentVersionPolicy) error {
+	q := `INSERT INTO agent_version_policies (mf_owner_id, agent, backends, enforcement, ts_last_modified)
+			VALUES (:mf_owner_id, :agent, :backends, :enforcement, CURRENT_TIMESTAMP)
+			ON CONFLICT (mf_owner_id) DO UPDATE SET agent = EXCLUDED.agent, backends = EXCLUDED.backends,
+				enforcement = EXCLUDED.enforcement, ts_last_modified = EXCLUDED.ts_last_modified`
+
+	if p.MFOwnerID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	dbp, err := toDBAgentVersionPolicy(p)
+	if err != nil {
+		return errors.Wrap(db.ErrSaveDB, err)
+	}
+
+	if _, err := r.db.NamedExecContext(ctx, q, dbp); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return errors.Wrap(errors.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(db.ErrSaveDB, err)
+	}
+	return nil
+}
+
+func (r agentVersionPolicyRepository) RetrieveByOwner(ctx context.Context, ownerID string) (fleet.AgentVersionPolicy, error) {
+	q := `SELECT mf_owner_id, agent, backends, enforcement, ts_last_modified FROM agent_version_policies WHERE mf_owner_id = $1`
+
+	if ownerID == "" {
+		return fleet.AgentVersionPolicy{}, errors.ErrMalformedEntity
+	}
+
+	var dbp dbAgentVersionPolicy
+	if err := r.db.QueryRowxContext(ctx, q, ownerID).StructScan(&dbp); err != nil {
+		if err == sql.ErrNoRows {
+			return fleet.AgentVersionPolicy{}, errors.Wrap(fleet.ErrNotFound, err)
+		}
+		return fleet.AgentVersionPolicy{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	p, err := toAgentVersionPolicy(dbp)
+	if err != nil {
+		return fleet.AgentVersionPolicy{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	return p, nil
+}
+
+type dbAgentVersionPolicy struct {
+	MFOwnerID    string    `db:"mf_owner_id"`
+	Agent        string    `db:"agent"`
+	Backends     string    `db:"backends"`
+	Enforcement  string    `db:"enforcement"`
+	LastModified time.Time `db:"ts_last_modified"`
+}
+
+func toDBAgentVersionPolicy(p fleet.AgentVersionPolicy) (dbAgentVersionPolicy, error) {
+	agent, err := json.Marshal(p.Agent)
+	if err != nil {
+		return dbAgentVersionPolicy{}, err
+	}
+	backendRequirements := p.Backends
+	if backendRequirements == nil {
+		backendRequirements = map[string]fleet.VersionRequirement{}
+	}
+	backends, err := json.Marshal(backendRequirements)
+	if err != nil {
+		return dbAgentVersionPolicy{}, err
+	}
+
+	return dbAgentVersionPolicy{
+		MFOwnerID:   p.MFOwnerID,
+		Agent:       string(agent),
+		Backends:    string(backends),
+		Enforcement: p.Enforcement,
+	}, nil
+}
+
+func toAgentVersionPolicy(dbp dbAgentVersionPolicy) (fleet.AgentVersionPolicy, error) {
+	p := fleet.AgentVersionPolicy{
+		MFOwnerID:    dbp.MFOwnerID,
+		Enforcement:  dbp.Enforcement,
+		LastModified: dbp.LastModified,
+	}
+	if err := json.Unmarshal([]byte(dbp.Agent), &p.Agent); err != nil {
+		return fleet.AgentVersionPolicy{}, err
+	}
+	if err := json.Unmarshal([]byte(dbp.Backends), &p.Backends); err != nil {
+		return fleet.AgentVersionPolicy{}, err
+	}
+	return p, nil
+}
+
+func NewAgentVersionPolicyRepository(db Database, logger *zap.Logger) fleet.AgentVersionPolicyRepository {
+	return &agentVersionPolicyRepository{db: db, logger: logger}
+}
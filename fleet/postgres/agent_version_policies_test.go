@@ -0,0 +1,122 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/fleet/postgres"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentVersionPolicySave(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	policyRepo := postgres.NewAgentVersionPolicyRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	policy := fleet.AgentVersionPolicy{
+		MFOwnerID:   oID.String(),
+		Agent:       fleet.VersionRequirement{Minimum: "0.20.0", Recommended: "0.22.0"},
+		Backends:    map[string]fleet.VersionRequirement{"pktvisor": {Minimum: "4.2.0"}},
+		Enforcement: fleet.VersionEnforcementStop,
+	}
+	updated := fleet.AgentVersionPolicy{
+		MFOwnerID:   oID.String(),
+		Agent:       fleet.VersionRequirement{Minimum: "0.21.0"},
+		Enforcement: fleet.VersionEnforcementWarn,
+	}
+
+	cases := []struct {
+		desc     string
+		policy   fleet.AgentVersionPolicy
+		expected fleet.AgentVersionPolicy
+		err      error
+	}{
+		{
+			desc:     "save new version policy",
+			policy:   policy,
+			expected: policy,
+			err:      nil,
+		},
+		{
+			desc:     "save version policy replacing the previous one",
+			policy:   updated,
+			expected: fleet.AgentVersionPolicy{MFOwnerID: oID.String(), Agent: updated.Agent, Backends: map[string]fleet.VersionRequirement{}, Enforcement: fleet.VersionEnforcementWarn},
+			err:      nil,
+		},
+		{
+			desc:   "save version policy without owner",
+			policy: fleet.AgentVersionPolicy{Enforcement: fleet.VersionEnforcementStop},
+			err:    errors.ErrMalformedEntity,
+		},
+		{
+			desc:   "save version policy with invalid owner",
+			policy: fleet.AgentVersionPolicy{MFOwnerID: wrongValue, Enforcement: fleet.VersionEnforcementStop},
+			err:    errors.ErrMalformedEntity,
+		},
+	}
+
+	// the policy of an owner is replaced by the next one saved, hence run in order
+	for _, tc := range cases {
+		err := policyRepo.Save(context.Background(), tc.policy)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+
+		got, err := policyRepo.RetrieveByOwner(context.Background(), tc.policy.MFOwnerID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.False(t, got.LastModified.IsZero(), fmt.Sprintf("%s: expected the modification time to be set", tc.desc))
+		got.LastModified = tc.expected.LastModified
+		assert.Equal(t, tc.expected, got, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.expected, got))
+	}
+}
+
+func TestAgentVersionPolicyRetrieveByOwner(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	policyRepo := postgres.NewAgentVersionPolicyRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	unknownID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	err = policyRepo.Save(context.Background(), fleet.DefaultAgentVersionPolicy(oID.String()))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		ownerID string
+		err     error
+	}{
+		"retrieve version policy of owner": {
+			ownerID: oID.String(),
+			err:     nil,
+		},
+		"retrieve version policy of owner without one": {
+			ownerID: unknownID.String(),
+			err:     fleet.ErrNotFound,
+		},
+		"retrieve version policy without owner": {
+			ownerID: "",
+			err:     errors.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, err := policyRepo.RetrieveByOwner(context.Background(), tc.ownerID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", desc, tc.err, err))
+		})
+	}
+}
@@ -72,7 +72,8 @@ func (r agentRepository) RetrieveMatchingAgents(ctx context.Context, ownerID str
 
 func (r agentRepository) RetrieveAllByAgentGroupID(ctx context.Context, owner string, agentGroupID string, onlinishOnly bool) ([]fleet.Agent, error) {
 
-	q := `SELECT agent_mf_thing_id AS mf_thing_id, agent_mf_channel_id AS mf_channel_id FROM agent_group_membership 
+	q := `SELECT agent_mf_thing_id AS mf_thing_id, agent_mf_channel_id AS mf_channel_id, agent_state AS state,
+				agent_in_maintenance AS in_maintenance FROM agent_group_membership 
 			WHERE mf_owner_id = :mf_owner_id AND agent_groups_id = :group_id`
 
 	if onlinishOnly {
@@ -112,6 +113,51 @@ func (r agentRepository) RetrieveAllByAgentGroupID(ctx context.Context, owner st
 	return items, nil
 }
 
+func (r agentRepository) RetrieveAllByAgentGroupIDs(ctx context.Context, owner string, agentGroupIDs []string) (map[string][]fleet.Agent, error) {
+	q := `SELECT agent_group_membership.agent_groups_id, agents.mf_thing_id, agents.name, agents.mf_owner_id, agents.mf_channel_id,
+				agents.ts_created, agents.orb_tags, agents.agent_tags, agents.agent_metadata, agents.state, agents.last_hb_data,
+				agents.ts_last_hb, agents.maintenance, agents.maintenance_reason, agents.ts_maintenance_start,
+				agents.ts_maintenance_end, agents.in_maintenance
+			FROM agents JOIN agent_group_membership ON agents.mf_thing_id = agent_group_membership.agent_mf_thing_id
+			WHERE agent_group_membership.mf_owner_id = :mf_owner_id AND agent_group_membership.agent_groups_id = ANY(CAST(:group_ids AS UUID[]))`
+
+	if owner == "" {
+		return nil, errors.ErrMalformedEntity
+	}
+
+	members := make(map[string][]fleet.Agent, len(agentGroupIDs))
+	if len(agentGroupIDs) == 0 {
+		return members, nil
+	}
+
+	params := map[string]interface{}{
+		"mf_owner_id": owner,
+		"group_ids":   pq.Array(agentGroupIDs),
+	}
+
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dbth := dbGroupMember{}
+		if err := rows.StructScan(&dbth); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+
+		th, err := toAgent(dbth.dbAgent)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrViewEntity, err)
+		}
+
+		members[dbth.AgentGroupID] = append(members[dbth.AgentGroupID], th)
+	}
+
+	return members, nil
+}
+
 func (r agentRepository) RetrieveAll(ctx context.Context, owner string, pm fleet.PageMetadata) (fleet.Page, error) {
 	nq, name := getNameQuery(pm.Name)
 	oq := getOrderQuery(pm.Order)
@@ -125,14 +171,17 @@ func (r agentRepository) RetrieveAll(ctx context.Context, owner string, pm fleet
 		return fleet.Page{}, errors.Wrap(errors.ErrSelectEntity, err)
 	}
 
-	q := fmt.Sprintf(`SELECT mf_thing_id, name, mf_owner_id, mf_channel_id, ts_created, orb_tags, agent_tags, agent_metadata, state, last_hb_data, ts_last_hb
+	q := fmt.Sprintf(`SELECT mf_thing_id, name, mf_owner_id, mf_channel_id, ts_created, orb_tags, agent_tags, agent_metadata, state, last_hb_data, ts_last_hb,
+						maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end, in_maintenance
 				from (
 				select
 						mf_thing_id, name, mf_owner_id, mf_channel_id, ts_created, orb_tags, agent_tags, agent_metadata, state, last_hb_data, ts_last_hb, 
+						maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end, in_maintenance,
 						coalesce(agent_tags || orb_tags, agent_tags, orb_tags) as tags
 				from agents where mf_owner_id = :mf_owner_id
 				group by 
 						mf_thing_id, name, mf_owner_id, mf_channel_id, ts_created, orb_tags, agent_tags, agent_metadata, state, last_hb_data, ts_last_hb, 
+						maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end, in_maintenance,
 						coalesce(agent_tags || orb_tags, agent_tags, orb_tags)) as agts
 				WHERE 1=1 %s%s%s 
 				ORDER BY %s %s LIMIT :limit OFFSET :offset;`, tmq, mq, nq, oq, dq)
@@ -299,7 +348,9 @@ func (r agentRepository) UpdateHeartbeatByIDWithChannel(ctx context.Context, age
 
 func (r agentRepository) RetrieveByIDWithChannel(ctx context.Context, thingID string, channelID string) (fleet.Agent, error) {
 
-	q := `SELECT mf_thing_id, name, mf_owner_id, mf_channel_id, ts_created, orb_tags, agent_tags, agent_metadata, state, last_hb_data, ts_last_hb FROM agents WHERE mf_thing_id = $1 AND mf_channel_id = $2;`
+	q := `SELECT mf_thing_id, name, mf_owner_id, mf_channel_id, ts_created, orb_tags, agent_tags, agent_metadata, state, last_hb_data, ts_last_hb,
+			maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end, in_maintenance
+		FROM agents WHERE mf_thing_id = $1 AND mf_channel_id = $2;`
 
 	dba := dbAgent{}
 
@@ -399,7 +450,12 @@ func (r agentRepository) RetrieveByID(ctx context.Context, ownerID string, thing
 			agent_metadata, 
 			state, 
 			last_hb_data, 
-			ts_last_hb 
+			ts_last_hb,
+			maintenance,
+			maintenance_reason,
+			ts_maintenance_start,
+			ts_maintenance_end,
+			in_maintenance
 		FROM agents 
 		WHERE 
 			mf_thing_id = $1 
@@ -483,7 +539,8 @@ func (r agentRepository) RetrieveAgentInfoByChannelID(ctx context.Context, chann
 
 func (r agentRepository) SetStaleStatus(ctx context.Context, duration time.Duration) (int64, error) {
 
-	q := `UPDATE agents SET state = :state WHERE state <> 'stale' AND state <> 'offline' AND ts_last_hb <= now() - :duration * interval '1 seconds';`
+	// agents in maintenance may be shut down for the window, they are not reported as stale
+	q := `UPDATE agents SET state = :state WHERE state <> 'stale' AND state <> 'offline' AND NOT in_maintenance AND ts_last_hb <= now() - :duration * interval '1 seconds';`
 
 	params := map[string]interface{}{
 		"duration": duration.Seconds(),
@@ -511,6 +568,292 @@ func (r agentRepository) SetStaleStatus(ctx context.Context, duration time.Durat
 	return cnt, nil
 }
 
+func (r agentRepository) SetMaintenance(ctx context.Context, ownerID string, thingID string, m *fleet.Maintenance) error {
+	q := `UPDATE agents SET (maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end)
+			= (:maintenance, :maintenance_reason, :ts_maintenance_start, :ts_maintenance_end)
+			WHERE mf_thing_id = :mf_thing_id AND mf_owner_id = :mf_owner_id;`
+
+	if thingID == "" || ownerID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	dba := dbAgent{MFThingID: thingID, MFOwnerID: ownerID, dbMaintenance: toDBMaintenance(m)}
+	res, err := r.db.NamedExecContext(ctx, q, dba)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid:
+				return errors.Wrap(errors.ErrNotFound, err)
+			case db.ErrTruncation:
+				return errors.Wrap(errors.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(db.ErrUpdateDB, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(errors.ErrUpdateEntity, err)
+	}
+	if cnt == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+func (r agentRepository) UpdateInMaintenance(ctx context.Context, thingID string, inMaintenance bool) error {
+	q := `UPDATE agents SET in_maintenance = :in_maintenance WHERE mf_thing_id = :mf_thing_id;`
+
+	dba := dbAgent{MFThingID: thingID, InMaintenance: inMaintenance}
+	res, err := r.db.NamedExecContext(ctx, q, dba)
+	if err != nil {
+		return errors.Wrap(db.ErrUpdateDB, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(errors.ErrUpdateEntity, err)
+	}
+	if cnt == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+func (r agentRepository) RetrieveAllInMaintenance(ctx context.Context, owner string) ([]fleet.Agent, error) {
+	oq := ""
+	if owner != "" {
+		oq = ` AND mf_owner_id = :mf_owner_id`
+	}
+	q := fmt.Sprintf(`SELECT mf_thing_id, name, mf_owner_id, mf_channel_id, state,
+			maintenance, maintenance_reason, ts_maintenance_start, ts_maintenance_end, in_maintenance
+		FROM agents WHERE (in_maintenance OR %s)%s`, openMaintenanceQuery, oq)
+
+	rows, err := r.db.NamedQueryContext(ctx, q, map[string]interface{}{"mf_owner_id": owner})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []fleet.Agent
+	for rows.Next() {
+		dba := dbAgent{}
+		if err := rows.StructScan(&dba); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		a, err := toAgent(dba)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrViewEntity, err)
+		}
+		items = append(items, a)
+	}
+	return items, nil
+}
+
+func (r agentRepository) RetrieveSchemaVersionCounts(ctx context.Context, legacy fleet.SchemaVersions) ([]fleet.SchemaVersionCount, error) {
+	q := `SELECT kind, version, COUNT(*) AS count FROM (
+			SELECT :capabilities_kind AS kind, COALESCE(agent_metadata->'schema_versions'->>'capabilities', :capabilities_legacy) AS version FROM agents WHERE state = :state
+			UNION ALL
+			SELECT :heartbeat_kind AS kind, COALESCE(agent_metadata->'schema_versions'->>'heartbeat', :heartbeat_legacy) AS version FROM agents WHERE state = :state
+			UNION ALL
+			SELECT :rpc_kind AS kind, COALESCE(agent_metadata->'schema_versions'->>'rpc', :rpc_legacy) AS version FROM agents WHERE state = :state
+		) AS versions GROUP BY kind, version`
+
+	if len(legacy.Capabilities) == 0 || len(legacy.Heartbeat) == 0 || len(legacy.RPC) == 0 {
+		return nil, errors.ErrMalformedEntity
+	}
+
+	params := map[string]interface{}{
+		"state":               fleet.Online.String(),
+		"capabilities_kind":   fleet.SchemaKindCapabilities,
+		"capabilities_legacy": legacy.Capabilities[len(legacy.Capabilities)-1],
+		"heartbeat_kind":      fleet.SchemaKindHeartbeat,
+		"heartbeat_legacy":    legacy.Heartbeat[len(legacy.Heartbeat)-1],
+		"rpc_kind":            fleet.SchemaKindRPC,
+		"rpc_legacy":          legacy.RPC[len(legacy.RPC)-1],
+	}
+
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []fleet.SchemaVersionCount
+	for rows.Next() {
+		dbc := dbSchemaVersionCount{}
+		if err := rows.StructScan(&dbc); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		items = append(items, fleet.SchemaVersionCount{Kind: dbc.Kind, Version: dbc.Version, Count: dbc.Count})
+	}
+	return items, nil
+}
+
+// summaryAgentsQuery selects the agents summarized, of an owner or all of them, matching the tags
+const summaryAgentsQuery = `WITH agts AS (
+		SELECT mf_thing_id, name, state, agent_metadata, last_hb_data FROM (
+			SELECT mf_thing_id, name, mf_owner_id, state, agent_metadata, last_hb_data,
+				coalesce(agent_tags || orb_tags, agent_tags, orb_tags) AS tags
+			FROM agents) AS a
+		WHERE 1=1 %s%s)`
+
+// jsonbObject guards jsonb_each against heartbeat data that is not an object
+func jsonbObject(path string) string {
+	return fmt.Sprintf(`CASE WHEN jsonb_typeof(%s) = 'object' THEN %s ELSE '{}'::jsonb END`, path, path)
+}
+
+func (r agentRepository) RetrieveSummary(ctx context.Context, owner string, tags types.Tags) (fleet.FleetSummary, error) {
+	oq := ""
+	if owner != "" {
+		oq = ` AND mf_owner_id = :mf_owner_id`
+	}
+	t, tmq, err := getTagsQuery(tags)
+	if err != nil {
+		return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	with := fmt.Sprintf(summaryAgentsQuery, oq, tmq)
+	failing := fmt.Sprintf(`EXISTS (SELECT 1 FROM jsonb_each(%s) AS fp WHERE fp.value->>'state' = :failed)`,
+		jsonbObject("last_hb_data->'policy_state'"))
+
+	params := map[string]interface{}{
+		"mf_owner_id":           owner,
+		"tags":                  t,
+		"failed":                fleet.PolicyFailedToApply,
+		"limit":                 fleet.FleetSummaryFailingAgentsLimit,
+		"state_kind":            fleet.SummaryKindState,
+		"agent_version_kind":    fleet.SummaryKindAgentVersion,
+		"backend_version_kind":  fleet.SummaryKindBackendVersion,
+		"backend_state_kind":    fleet.SummaryKindBackendState,
+		"policy_state_kind":     fleet.SummaryKindPolicyState,
+		"failing_policies_kind": fleet.SummaryKindFailingPolicyAgents,
+	}
+
+	q := with + fmt.Sprintf(` SELECT kind, backend, value, COUNT(*) AS count FROM (
+			SELECT :state_kind AS kind, '' AS backend, state::text AS value FROM agts
+			UNION ALL
+			SELECT :agent_version_kind, '', COALESCE(agent_metadata->'orb_agent'->>'version', '') FROM agts
+			UNION ALL
+			SELECT :backend_version_kind, b.key, COALESCE(b.value->>'version', '') FROM agts, jsonb_each(%s) AS b
+			UNION ALL
+			SELECT :backend_state_kind, b.key, COALESCE(b.value->>'state', '') FROM agts, jsonb_each(%s) AS b
+			UNION ALL
+			SELECT :policy_state_kind, '', COALESCE(p.value->>'state', '') FROM agts, jsonb_each(%s) AS p
+			UNION ALL
+			SELECT :failing_policies_kind, '', '' FROM agts WHERE %s
+		) AS counts GROUP BY kind, backend, value`,
+		jsonbObject("agent_metadata->'backends'"), jsonbObject("last_hb_data->'backend_state'"),
+		jsonbObject("last_hb_data->'policy_state'"), failing)
+
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	summary := fleet.NewFleetSummary()
+	for rows.Next() {
+		dbc := dbSummaryCount{}
+		if err := rows.StructScan(&dbc); err != nil {
+			return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		summary.Add(dbc.Kind, dbc.Backend, dbc.Value, dbc.Count)
+	}
+
+	q = with + fmt.Sprintf(`, failing AS (SELECT mf_thing_id, name, last_hb_data FROM agts WHERE %s ORDER BY name LIMIT :limit)
+		SELECT mf_thing_id, name, p.key AS policy_id, COALESCE(p.value->>'name', '') AS policy_name,
+			COALESCE(p.value->>'error', '') AS error
+		FROM failing, jsonb_each(%s) AS p WHERE p.value->>'state' = :failed
+		ORDER BY name, policy_name`, failing, jsonbObject("last_hb_data->'policy_state'"))
+
+	frows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer frows.Close()
+
+	for frows.Next() {
+		dbf := dbFailingPolicy{}
+		if err := frows.StructScan(&dbf); err != nil {
+			return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		n := len(summary.FailingPolicyAgents)
+		if n == 0 || summary.FailingPolicyAgents[n-1].AgentID != dbf.MFThingID {
+			summary.FailingPolicyAgents = append(summary.FailingPolicyAgents, fleet.FailingPolicyAgent{
+				AgentID:   dbf.MFThingID,
+				AgentName: dbf.Name,
+			})
+			n++
+		}
+		summary.FailingPolicyAgents[n-1].Policies = append(summary.FailingPolicyAgents[n-1].Policies, fleet.FailingPolicy{
+			PolicyID:   dbf.PolicyID,
+			PolicyName: dbf.PolicyName,
+			Error:      dbf.Error,
+		})
+	}
+
+	// group sizes only make sense within an owner
+	if owner == "" {
+		return summary, nil
+	}
+	q = with + ` SELECT ag.id, ag.name, COUNT(agts.mf_thing_id) AS total,
+			COUNT(agts.mf_thing_id) FILTER (WHERE agts.state = 'online') AS online
+		FROM agent_groups ag
+			LEFT JOIN agent_group_membership agm ON agm.agent_groups_id = ag.id
+			LEFT JOIN agts ON agts.mf_thing_id = agm.agent_mf_thing_id
+		WHERE ag.mf_owner_id = :mf_owner_id
+		GROUP BY ag.id, ag.name ORDER BY ag.name`
+
+	grows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer grows.Close()
+
+	for grows.Next() {
+		dbg := dbGroupSize{}
+		if err := grows.StructScan(&dbg); err != nil {
+			return fleet.FleetSummary{}, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		summary.Groups = append(summary.Groups, fleet.GroupSize{
+			GroupID:   dbg.ID,
+			GroupName: dbg.Name,
+			Total:     dbg.Total,
+			Online:    dbg.Online,
+		})
+	}
+	return summary, nil
+}
+
+type dbSummaryCount struct {
+	Kind    string `db:"kind"`
+	Backend string `db:"backend"`
+	Value   string `db:"value"`
+	Count   int    `db:"count"`
+}
+
+type dbFailingPolicy struct {
+	MFThingID  string `db:"mf_thing_id"`
+	Name       string `db:"name"`
+	PolicyID   string `db:"policy_id"`
+	PolicyName string `db:"policy_name"`
+	Error      string `db:"error"`
+}
+
+type dbGroupSize struct {
+	ID     string `db:"id"`
+	Name   string `db:"name"`
+	Total  int    `db:"total"`
+	Online int    `db:"online"`
+}
+
+type dbSchemaVersionCount struct {
+	Kind    string `db:"kind"`
+	Version string `db:"version"`
+	Count   int    `db:"count"`
+}
+
 type dbAgent struct {
 	Name          types.Identifier `db:"name"`
 	MFOwnerID     string           `db:"mf_owner_id"`
@@ -523,6 +866,14 @@ type dbAgent struct {
 	Created       time.Time        `db:"ts_created"`
 	LastHBData    db.Metadata      `db:"last_hb_data"`
 	LastHB        sql.NullTime     `db:"ts_last_hb"`
+	InMaintenance bool             `db:"in_maintenance"`
+	dbMaintenance
+}
+
+// dbGroupMember is an agent along with one of the groups it belongs to
+type dbGroupMember struct {
+	AgentGroupID string `db:"agent_groups_id"`
+	dbAgent
 }
 
 type dbMatchingAgent struct {
@@ -547,6 +898,8 @@ func toDBAgent(agent fleet.Agent) (dbAgent, error) {
 		State:         agent.State,
 		Created:       agent.Created,
 		LastHBData:    db.Metadata(agent.LastHBData),
+		InMaintenance: agent.InMaintenance,
+		dbMaintenance: toDBMaintenance(agent.Maintenance),
 	}
 
 	if !agent.LastHB.IsZero() {
@@ -574,6 +927,8 @@ func toAgent(dba dbAgent) (fleet.Agent, error) {
 		State:         dba.State,
 		LastHBData:    types.Metadata(dba.LastHBData),
 		LastHB:        dba.LastHB.Time,
+		Maintenance:   toMaintenance(dba.dbMaintenance),
+		InMaintenance: dba.InMaintenance,
 	}
 
 	return agent, nil
@@ -13,7 +13,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gofrs/uuid"
+	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
 	"github.com/orb-community/orb/fleet"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
 	"github.com/orb-community/orb/fleet/postgres"
 	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/pkg/types"
@@ -1225,3 +1227,287 @@ func TestSetAgentStale(t *testing.T) {
 	}
 
 }
+
+func TestPolicyStatusByAgentGroup(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	agentRepo := postgres.NewAgentRepository(dbMiddleware, logger)
+	agentGroupRepo := postgres.NewAgentGroupRepository(dbMiddleware, logger)
+	users := flmocks.NewAuthService(map[string]string{"token": "user@example.com"})
+	agentComms := flmocks.NewFleetCommService(agentRepo, agentGroupRepo)
+	svc := fleet.NewFleetService(logger, users, agentRepo, agentGroupRepo, flmocks.NewPolicyRolloutRepository(), flmocks.NewEnrollmentTokenRepository(), flmocks.NewAgentRPCRepository(), flmocks.NewAgentVersionPolicyRepository(), flmocks.NewAgentDiagnosticsRepository(), agentComms, mfsdk.NewSDK(mfsdk.Config{}), fleet.NewThingKeyService(""), "", make(chan bool))
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	policyID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	tags := types.Tags{"region": "EU"}
+	groupNameID, err := types.NewIdentifier("my-group")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	groupID, err := agentGroupRepo.Save(context.Background(), fleet.AgentGroup{
+		Name:        groupNameID,
+		MFOwnerID:   oID.String(),
+		MFChannelID: chID.String(),
+		Tags:        &tags,
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	states := map[string]struct {
+		state   string
+		version int32
+		err     string
+	}{
+		"agent-running": {state: "running", version: 1},
+		"agent-failed":  {state: fleet.PolicyFailedToApply, version: 2, err: "invalid tap"},
+	}
+	for name, s := range states {
+		thID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		agentChID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		nameID, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		agent := fleet.Agent{
+			Name:        nameID,
+			MFThingID:   thID.String(),
+			MFOwnerID:   oID.String(),
+			MFChannelID: agentChID.String(),
+			AgentTags:   tags,
+		}
+		err = agentRepo.Save(context.Background(), agent)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+		agent.State = fleet.Online
+		agent.LastHBData = types.Metadata{
+			"policy_state": map[string]interface{}{
+				policyID.String(): map[string]interface{}{
+					"name":    "policy",
+					"state":   s.state,
+					"version": s.version,
+					"error":   s.err,
+				},
+			},
+		}
+		err = agentRepo.UpdateHeartbeatByIDWithChannel(context.Background(), agent)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	status, err := svc.ViewPolicyStatusInternal(context.Background(), oID.String(), policyID.String(), 2, []fleet.PolicyStatusTarget{{AgentGroupID: groupID}})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	assert.Equal(t, 2, status.Total, fmt.Sprintf("expected %d got %d", 2, status.Total))
+	assert.Equal(t, 1, status.States["running"], fmt.Sprintf("expected %d got %d", 1, status.States["running"]))
+	assert.Equal(t, 1, status.States[fleet.PolicyFailedToApply], fmt.Sprintf("expected %d got %d", 1, status.States[fleet.PolicyFailedToApply]))
+	assert.Equal(t, 0, status.States[fleet.PolicyStatusNotReported], fmt.Sprintf("expected %d got %d", 0, status.States[fleet.PolicyStatusNotReported]))
+	assert.Equal(t, []fleet.PolicyStatusError{{Error: "invalid tap", Count: 1}}, status.Errors)
+	require.Len(t, status.StaleAgents, 1, "the agent reporting the previous version should be stale")
+	assert.Equal(t, "agent-running", status.StaleAgents[0].AgentName, fmt.Sprintf("expected %s got %s", "agent-running", status.StaleAgents[0].AgentName))
+}
+
+func TestAgentRetrieveSummary(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	agentRepo := postgres.NewAgentRepository(dbMiddleware, logger)
+	agentGroupRepo := postgres.NewAgentGroupRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	otherOID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	unknownOID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	groups := make(map[string]string)
+	for _, region := range []string{"eu", "us"} {
+		chID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		nameID, err := types.NewIdentifier("group-" + region)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		groups[region], err = agentGroupRepo.Save(context.Background(), fleet.AgentGroup{
+			Name:        nameID,
+			MFOwnerID:   oID.String(),
+			MFChannelID: chID.String(),
+			Tags:        &types.Tags{"region": region},
+		})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	policy := func(name string, state string, errMsg string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "state": state, "error": errMsg}
+	}
+	agents := []struct {
+		name     string
+		owner    string
+		region   string
+		state    fleet.State
+		metadata types.Metadata
+		hb       types.Metadata
+	}{
+		{
+			name:   "summary-agent-1",
+			owner:  oID.String(),
+			region: "eu",
+			state:  fleet.Online,
+			metadata: types.Metadata{
+				"orb_agent": map[string]interface{}{"version": "0.21.0"},
+				"backends":  map[string]interface{}{"pktvisor": map[string]interface{}{"version": "4.2.0"}},
+			},
+			hb: types.Metadata{
+				"backend_state": map[string]interface{}{"pktvisor": map[string]interface{}{"state": "running"}},
+				"policy_state": map[string]interface{}{
+					"policy-1": policy("policy-1", "running", ""),
+					"policy-2": policy("policy-2", fleet.PolicyFailedToApply, "invalid tap"),
+				},
+			},
+		},
+		{
+			name:   "summary-agent-2",
+			owner:  oID.String(),
+			region: "eu",
+			state:  fleet.Online,
+			metadata: types.Metadata{
+				"orb_agent": map[string]interface{}{"version": "0.21.0"},
+				"backends": map[string]interface{}{
+					"pktvisor": map[string]interface{}{"version": "4.3.0"},
+					"otel":     map[string]interface{}{"version": "0.1.0"},
+				},
+			},
+			hb: types.Metadata{
+				"backend_state": map[string]interface{}{
+					"pktvisor": map[string]interface{}{"state": "running"},
+					"otel":     map[string]interface{}{"state": "failed"},
+				},
+				"policy_state": map[string]interface{}{"policy-1": policy("policy-1", "running", "")},
+			},
+		},
+		{
+			name:   "summary-agent-3",
+			owner:  oID.String(),
+			region: "us",
+			state:  fleet.Stale,
+			metadata: types.Metadata{
+				"orb_agent": map[string]interface{}{"version": "0.20.0"},
+				"backends":  map[string]interface{}{"pktvisor": map[string]interface{}{"version": "4.2.0"}},
+			},
+			hb: types.Metadata{
+				"backend_state": map[string]interface{}{"pktvisor": map[string]interface{}{"state": "running"}},
+				"policy_state":  map[string]interface{}{"policy-3": policy("policy-3", fleet.PolicyFailedToApply, "invalid filter")},
+			},
+		},
+		{
+			name:     "summary-agent-4",
+			owner:    oID.String(),
+			region:   "us",
+			state:    fleet.New,
+			metadata: types.Metadata{},
+		},
+		{
+			name:   "summary-agent-other",
+			owner:  otherOID.String(),
+			region: "eu",
+			state:  fleet.Online,
+			metadata: types.Metadata{
+				"orb_agent": map[string]interface{}{"version": "0.21.0"},
+			},
+			hb: types.Metadata{
+				"policy_state": map[string]interface{}{"policy-4": policy("policy-4", fleet.PolicyFailedToApply, "invalid tap")},
+			},
+		},
+	}
+
+	ids := make(map[string]string)
+	for _, a := range agents {
+		thID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		chID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		nameID, err := types.NewIdentifier(a.name)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		agent := fleet.Agent{
+			Name:          nameID,
+			MFThingID:     thID.String(),
+			MFOwnerID:     a.owner,
+			MFChannelID:   chID.String(),
+			AgentTags:     types.Tags{"region": a.region},
+			AgentMetadata: a.metadata,
+			State:         a.state,
+		}
+		err = agentRepo.Save(context.Background(), agent)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		ids[a.name] = thID.String()
+
+		if a.hb != nil {
+			agent.LastHBData = a.hb
+			err = agentRepo.UpdateHeartbeatByIDWithChannel(context.Background(), agent)
+			require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		}
+	}
+
+	cases := map[string]struct {
+		owner   string
+		tags    types.Tags
+		summary fleet.FleetSummary
+	}{
+		"summarize the agents of an owner": {
+			owner: oID.String(),
+			summary: fleet.FleetSummary{
+				Total:         4,
+				States:        map[string]int{"online": 2, "stale": 1, "new": 1},
+				AgentVersions: map[string]int{"0.21.0": 2, "0.20.0": 1, "": 1},
+				BackendVersions: map[string]map[string]int{
+					"pktvisor": {"4.2.0": 2, "4.3.0": 1},
+					"otel":     {"0.1.0": 1},
+				},
+				BackendStates: map[string]map[string]int{
+					"pktvisor": {"running": 3},
+					"otel":     {"failed": 1},
+				},
+				PolicyStates: map[string]int{"running": 2, fleet.PolicyFailedToApply: 2},
+				Groups: []fleet.GroupSize{
+					{GroupID: groups["eu"], GroupName: "group-eu", Total: 2, Online: 2},
+					{GroupID: groups["us"], GroupName: "group-us", Total: 2, Online: 0},
+				},
+				FailingPolicyAgentsTotal: 2,
+				FailingPolicyAgents: []fleet.FailingPolicyAgent{
+					{AgentID: ids["summary-agent-1"], AgentName: "summary-agent-1", Policies: []fleet.FailingPolicy{{PolicyID: "policy-2", PolicyName: "policy-2", Error: "invalid tap"}}},
+					{AgentID: ids["summary-agent-3"], AgentName: "summary-agent-3", Policies: []fleet.FailingPolicy{{PolicyID: "policy-3", PolicyName: "policy-3", Error: "invalid filter"}}},
+				},
+			},
+		},
+		"summarize the agents of an owner matching tags": {
+			owner: oID.String(),
+			tags:  types.Tags{"region": "us"},
+			summary: fleet.FleetSummary{
+				Total:           2,
+				States:          map[string]int{"stale": 1, "new": 1},
+				AgentVersions:   map[string]int{"0.20.0": 1, "": 1},
+				BackendVersions: map[string]map[string]int{"pktvisor": {"4.2.0": 1}},
+				BackendStates:   map[string]map[string]int{"pktvisor": {"running": 1}},
+				PolicyStates:    map[string]int{fleet.PolicyFailedToApply: 1},
+				Groups: []fleet.GroupSize{
+					{GroupID: groups["eu"], GroupName: "group-eu", Total: 0, Online: 0},
+					{GroupID: groups["us"], GroupName: "group-us", Total: 2, Online: 0},
+				},
+				FailingPolicyAgentsTotal: 1,
+				FailingPolicyAgents: []fleet.FailingPolicyAgent{
+					{AgentID: ids["summary-agent-3"], AgentName: "summary-agent-3", Policies: []fleet.FailingPolicy{{PolicyID: "policy-3", PolicyName: "policy-3", Error: "invalid filter"}}},
+				},
+			},
+		},
+		"summarize the agents of an owner without agents": {
+			owner:   unknownOID.String(),
+			summary: fleet.NewFleetSummary(),
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			summary, err := agentRepo.RetrieveSummary(context.Background(), tc.owner, tc.tags)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.Equal(t, tc.summary, summary, fmt.Sprintf("%s: expected %v got %v", desc, tc.summary, summary))
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"go.uber.org/zap"
+)
+
+var _ fleet.EnrollmentTokenRepository = (*enrollmentTokenRepository)(nil)
+
+const enrollmentTokenColumns = `id, name, mf_owner_id, owner_email, token_hash, orb_tags, max_uses, uses, ts_expires, revoked, ts_created`
+
+type enrollmentTokenRepository struct {
+	db     Database
+	logger *zap.Logger
+}
+
+func (r enrollmentTokenRepository) Save(ctx context.Context, et fleet.EnrollmentToken) (string, error) {
+	q := `INSERT INTO enrollment_tokens (name, mf_owner_id, owner_email, token_hash, orb_tags, max_uses, ts_expires)
+			VALUES (:name, :mf_owner_id, :owner_email, :token_hash, :orb_tags, :max_uses, :ts_expires) RETURNING id`
+
+	if et.MFOwnerID == "" || et.TokenHash == "" {
+		return "", errors.ErrMalformedEntity
+	}
+
+	row, err := r.db.NamedQueryContext(ctx, q, toDBEnrollmentToken(et))
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return "", errors.Wrap(errors.ErrMalformedEntity, err)
+			case db.ErrDuplicate:
+				return "", errors.Wrap(errors.ErrConflict, err)
+			}
+		}
+		return "", errors.Wrap(db.ErrSaveDB, err)
+	}
+	defer row.Close()
+
+	var id string
+	if row.Next() {
+		if err := row.Scan(&id); err != nil {
+			return "", errors.Wrap(db.ErrSaveDB, err)
+		}
+	}
+	return id, nil
+}
+
+func (r enrollmentTokenRepository) RetrieveAllByOwner(ctx context.Context, ownerID string) ([]fleet.EnrollmentToken, error) {
+	q := `SELECT ` + enrollmentTokenColumns + ` FROM enrollment_tokens WHERE mf_owner_id = :mf_owner_id ORDER BY ts_created DESC`
+
+	params := map[string]interface{}{
+		"mf_owner_id": ownerID,
+	}
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []fleet.EnrollmentToken
+	for rows.Next() {
+		dbet := dbEnrollmentToken{}
+		if err := rows.StructScan(&dbet); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		items = append(items, toEnrollmentToken(dbet))
+	}
+	return items, nil
+}
+
+func (r enrollmentTokenRepository) Revoke(ctx context.Context, ownerID string, id string) error {
+	q := `UPDATE enrollment_tokens SET revoked = TRUE WHERE mf_owner_id = :mf_owner_id AND id = :id;`
+
+	params := map[string]interface{}{
+		"mf_owner_id": ownerID,
+		"id":          id,
+	}
+	res, err := r.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == db.ErrInvalid {
+			return errors.Wrap(fleet.ErrNotFound, err)
+		}
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return fleet.ErrNotFound
+	}
+	return nil
+}
+
+func (r enrollmentTokenRepository) Consume(ctx context.Context, tokenHash string) (fleet.EnrollmentToken, error) {
+	q := `UPDATE enrollment_tokens SET uses = uses + 1
+			WHERE token_hash = $1 AND NOT revoked AND ts_expires > CURRENT_TIMESTAMP AND uses < max_uses
+			RETURNING ` + enrollmentTokenColumns
+
+	var dbet dbEnrollmentToken
+	if err := r.db.QueryRowxContext(ctx, q, tokenHash).StructScan(&dbet); err != nil {
+		if err == sql.ErrNoRows {
+			return fleet.EnrollmentToken{}, errors.Wrap(fleet.ErrNotFound, err)
+		}
+		return fleet.EnrollmentToken{}, errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	return toEnrollmentToken(dbet), nil
+}
+
+func (r enrollmentTokenRepository) Release(ctx context.Context, id string) error {
+	q := `UPDATE enrollment_tokens SET uses = uses - 1 WHERE id = :id AND uses > 0;`
+
+	params := map[string]interface{}{
+		"id": id,
+	}
+	if _, err := r.db.NamedExecContext(ctx, q, params); err != nil {
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	return nil
+}
+
+type dbEnrollmentToken struct {
+	ID         string    `db:"id"`
+	Name       string    `db:"name"`
+	MFOwnerID  string    `db:"mf_owner_id"`
+	OwnerEmail string    `db:"owner_email"`
+	TokenHash  string    `db:"token_hash"`
+	OrbTags    db.Tags   `db:"orb_tags"`
+	MaxUses    int       `db:"max_uses"`
+	Uses       int       `db:"uses"`
+	ExpiresAt  time.Time `db:"ts_expires"`
+	Revoked    bool      `db:"revoked"`
+	Created    time.Time `db:"ts_created"`
+}
+
+func toDBEnrollmentToken(et fleet.EnrollmentToken) dbEnrollmentToken {
+	orbTags := types.Tags{}
+	if et.OrbTags != nil {
+		orbTags = et.OrbTags
+	}
+	return dbEnrollmentToken{
+		ID:         et.ID,
+		Name:       et.Name,
+		MFOwnerID:  et.MFOwnerID,
+		OwnerEmail: et.OwnerEmail,
+		TokenHash:  et.TokenHash,
+		OrbTags:    db.Tags(orbTags),
+		MaxUses:    et.MaxUses,
+		Uses:       et.Uses,
+		ExpiresAt:  et.ExpiresAt,
+		Revoked:    et.Revoked,
+	}
+}
+
+func toEnrollmentToken(dbet dbEnrollmentToken) fleet.EnrollmentToken {
+	return fleet.EnrollmentToken{
+		ID:         dbet.ID,
+		Name:       dbet.Name,
+		MFOwnerID:  dbet.MFOwnerID,
+		OwnerEmail: dbet.OwnerEmail,
+		TokenHash:  dbet.TokenHash,
+		OrbTags:    types.Tags(dbet.OrbTags),
+		MaxUses:    dbet.MaxUses,
+		Uses:       dbet.Uses,
+		ExpiresAt:  dbet.ExpiresAt,
+		Revoked:    dbet.Revoked,
+		Created:    dbet.Created,
+	}
+}
+
+func NewEnrollmentTokenRepository(db Database, logger *zap.Logger) fleet.EnrollmentTokenRepository {
+	return &enrollmentTokenRepository{db: db, logger: logger}
+}
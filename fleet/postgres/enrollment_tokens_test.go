@@ -0,0 +1,184 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/fleet/postgres"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveEnrollmentToken(t *testing.T, repo fleet.EnrollmentTokenRepository, ownerID string, maxUses int, expiresAt time.Time) fleet.EnrollmentToken {
+	hash, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	et := fleet.EnrollmentToken{
+		Name:       "token",
+		MFOwnerID:  ownerID,
+		OwnerEmail: "owner@example.com",
+		TokenHash:  hash.String(),
+		OrbTags:    types.Tags{"region": "eu"},
+		MaxUses:    maxUses,
+		ExpiresAt:  expiresAt,
+	}
+	et.ID, err = repo.Save(context.Background(), et)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	return et
+}
+
+func TestEnrollmentTokenSave(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	tokenRepo := postgres.NewEnrollmentTokenRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	existing := saveEnrollmentToken(t, tokenRepo, oID.String(), 1, time.Now().Add(time.Hour))
+
+	cases := map[string]struct {
+		token fleet.EnrollmentToken
+		err   error
+	}{
+		"save new enrollment token": {
+			token: fleet.EnrollmentToken{MFOwnerID: oID.String(), OwnerEmail: "owner@example.com", TokenHash: "new-hash", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			err:   nil,
+		},
+		"save enrollment token with an existing hash": {
+			token: fleet.EnrollmentToken{MFOwnerID: oID.String(), OwnerEmail: "owner@example.com", TokenHash: existing.TokenHash, MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			err:   errors.ErrConflict,
+		},
+		"save enrollment token without hash": {
+			token: fleet.EnrollmentToken{MFOwnerID: oID.String(), OwnerEmail: "owner@example.com", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			err:   errors.ErrMalformedEntity,
+		},
+		"save enrollment token with invalid owner": {
+			token: fleet.EnrollmentToken{MFOwnerID: wrongValue, OwnerEmail: "owner@example.com", TokenHash: "other-hash", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			err:   errors.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, err := tokenRepo.Save(context.Background(), tc.token)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", desc, tc.err, err))
+		})
+	}
+
+	tokens, err := tokenRepo.RetrieveAllByOwner(context.Background(), oID.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 2, len(tokens), fmt.Sprintf("expected %d tokens got %d", 2, len(tokens)))
+}
+
+func TestEnrollmentTokenConsume(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	tokenRepo := postgres.NewEnrollmentTokenRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	valid := saveEnrollmentToken(t, tokenRepo, oID.String(), 2, time.Now().Add(time.Hour))
+	expired := saveEnrollmentToken(t, tokenRepo, oID.String(), 2, time.Now().Add(-time.Hour))
+	revoked := saveEnrollmentToken(t, tokenRepo, oID.String(), 2, time.Now().Add(time.Hour))
+	err = tokenRepo.Revoke(context.Background(), oID.String(), revoked.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc string
+		hash string
+		uses int
+		err  error
+	}{
+		{desc: "consume valid token", hash: valid.TokenHash, uses: 1, err: nil},
+		{desc: "consume valid token again", hash: valid.TokenHash, uses: 2, err: nil},
+		{desc: "consume token out of uses", hash: valid.TokenHash, err: fleet.ErrNotFound},
+		{desc: "consume expired token", hash: expired.TokenHash, err: fleet.ErrNotFound},
+		{desc: "consume revoked token", hash: revoked.TokenHash, err: fleet.ErrNotFound},
+		{desc: "consume unknown token", hash: "unknown-hash", err: fleet.ErrNotFound},
+	}
+
+	// consumptions depend on the previous ones, hence run in order
+	for _, tc := range cases {
+		et, err := tokenRepo.Consume(context.Background(), tc.hash)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.uses, et.Uses, fmt.Sprintf("%s: expected %d uses got %d", tc.desc, tc.uses, et.Uses))
+			assert.Equal(t, types.Tags{"region": "eu"}, et.OrbTags, fmt.Sprintf("%s: expected the tags of the token got %v", tc.desc, et.OrbTags))
+		}
+	}
+}
+
+func TestEnrollmentTokenConsumeConcurrently(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	tokenRepo := postgres.NewEnrollmentTokenRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	token := saveEnrollmentToken(t, tokenRepo, oID.String(), 1, time.Now().Add(time.Hour))
+
+	const enrollments = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, enrollments)
+	for i := 0; i < enrollments; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tokenRepo.Consume(context.Background(), token.TokenHash)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	consumed := 0
+	for err := range errs {
+		if err == nil {
+			consumed++
+			continue
+		}
+		assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("expected '%s' got '%s'", fleet.ErrNotFound, err))
+	}
+	assert.Equal(t, 1, consumed, fmt.Sprintf("single use token: expected %d enrollment got %d", 1, consumed))
+
+	tokens, err := tokenRepo.RetrieveAllByOwner(context.Background(), oID.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Equal(t, 1, len(tokens), fmt.Sprintf("expected %d token got %d", 1, len(tokens)))
+	assert.Equal(t, 1, tokens[0].Uses, fmt.Sprintf("expected %d use got %d", 1, tokens[0].Uses))
+}
+
+func TestEnrollmentTokenRelease(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	tokenRepo := postgres.NewEnrollmentTokenRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	token := saveEnrollmentToken(t, tokenRepo, oID.String(), 1, time.Now().Add(time.Hour))
+
+	_, err = tokenRepo.Consume(context.Background(), token.TokenHash)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// releasing more than consumed must not give the token extra uses
+	for i := 0; i < 2; i++ {
+		err = tokenRepo.Release(context.Background(), token.ID)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	_, err = tokenRepo.Consume(context.Background(), token.TokenHash)
+	assert.Nil(t, err, fmt.Sprintf("released token: expected no error got '%s'", err))
+
+	_, err = tokenRepo.Consume(context.Background(), token.TokenHash)
+	assert.True(t, errors.Contains(err, fleet.ErrNotFound), fmt.Sprintf("token used again: expected '%s' got '%s'", fleet.ErrNotFound, err))
+}
@@ -117,6 +117,152 @@ func migrateDB(db *sqlx.DB) error {
 					  AND (agent_groups.tags <@ coalesce(agents.agent_tags || agents.orb_tags, agents.agent_tags, agents.orb_tags))`,
 				},
 			},
+			{
+				Id: "fleet_3",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS policy_rollouts (
+						id                 UUID NOT NULL DEFAULT gen_random_uuid(),
+						mf_owner_id        UUID NOT NULL,
+						policy_id          UUID NOT NULL,
+						version            INTEGER NOT NULL,
+						group_ids          JSONB NOT NULL DEFAULT '[]',
+						strategy           JSONB NOT NULL DEFAULT '{}',
+						state              TEXT NOT NULL,
+						current_wave       INTEGER NOT NULL DEFAULT 0,
+						waves              INTEGER NOT NULL DEFAULT 0,
+						agents             JSONB NOT NULL DEFAULT '[]',
+						error              TEXT NOT NULL DEFAULT '',
+						ts_created         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						ts_last_modified   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						PRIMARY KEY (id)
+					)`,
+					`CREATE INDEX ON policy_rollouts (mf_owner_id, policy_id)`,
+					`CREATE INDEX ON policy_rollouts (state)`,
+				},
+				Down: []string{
+					"DROP TABLE policy_rollouts",
+				},
+			},
+			{
+				Id: "fleet_4",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS enrollment_tokens (
+						id                 UUID NOT NULL DEFAULT gen_random_uuid(),
+						name               TEXT NOT NULL DEFAULT '',
+						mf_owner_id        UUID NOT NULL,
+						owner_email        TEXT NOT NULL,
+						token_hash         TEXT NOT NULL,
+						orb_tags           JSONB NOT NULL DEFAULT '{}',
+						max_uses           INTEGER NOT NULL,
+						uses               INTEGER NOT NULL DEFAULT 0,
+						ts_expires         TIMESTAMPTZ NOT NULL,
+						revoked            BOOLEAN NOT NULL DEFAULT FALSE,
+						ts_created         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						PRIMARY KEY (id),
+						UNIQUE (token_hash)
+					)`,
+					`CREATE INDEX ON enrollment_tokens (mf_owner_id)`,
+				},
+				Down: []string{
+					"DROP TABLE enrollment_tokens",
+				},
+			},
+			{
+				Id: "fleet_5",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS agent_rpcs (
+						correlation_id     UUID NOT NULL,
+						agent_id           UUID NOT NULL REFERENCES agents (mf_thing_id) ON DELETE CASCADE,
+						channel_id         UUID NOT NULL,
+						func               TEXT NOT NULL,
+						payload            BYTEA NOT NULL,
+						status             TEXT NOT NULL DEFAULT 'pending',
+						error              TEXT NOT NULL DEFAULT '',
+						attempts           INTEGER NOT NULL DEFAULT 1,
+						ts_created         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						ts_last_sent       TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						ts_updated         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						PRIMARY KEY (correlation_id, agent_id)
+					)`,
+					`CREATE INDEX ON agent_rpcs (agent_id, ts_created DESC)`,
+					`CREATE INDEX ON agent_rpcs (status, ts_last_sent)`,
+				},
+				Down: []string{
+					"DROP TABLE agent_rpcs",
+				},
+			},
+			{
+				Id: "fleet_6",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS agent_version_policies (
+						mf_owner_id        UUID PRIMARY KEY,
+						agent              JSONB NOT NULL DEFAULT '{}',
+						backends           JSONB NOT NULL DEFAULT '{}',
+						enforcement        TEXT NOT NULL DEFAULT 'stop',
+						ts_last_modified   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+					)`,
+				},
+				Down: []string{
+					"DROP TABLE agent_version_policies",
+				},
+			},
+			{
+				Id: "fleet_7",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS agent_diagnostics (
+						id                 UUID NOT NULL,
+						agent_id           UUID NOT NULL REFERENCES agents (mf_thing_id) ON DELETE CASCADE,
+						mf_owner_id        UUID NOT NULL,
+						status             TEXT NOT NULL DEFAULT 'collecting',
+						error              TEXT NOT NULL DEFAULT '',
+						chunks             INTEGER NOT NULL DEFAULT 0,
+						data               BYTEA,
+						ts_created         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+						ts_completed       TIMESTAMPTZ,
+						PRIMARY KEY (id)
+					)`,
+					`CREATE INDEX ON agent_diagnostics (status, ts_created)`,
+					`CREATE TABLE IF NOT EXISTS agent_diagnostic_chunks (
+						bundle_id          UUID NOT NULL REFERENCES agent_diagnostics (id) ON DELETE CASCADE,
+						seq                INTEGER NOT NULL,
+						data               BYTEA NOT NULL,
+						PRIMARY KEY (bundle_id, seq)
+					)`,
+				},
+				Down: []string{
+					"DROP TABLE agent_diagnostic_chunks",
+					"DROP TABLE agent_diagnostics",
+				},
+			},
+			{
+				Id: "fleet_8",
+				Up: []string{
+					`ALTER TABLE agents
+						ADD COLUMN IF NOT EXISTS maintenance          BOOLEAN NOT NULL DEFAULT FALSE,
+						ADD COLUMN IF NOT EXISTS maintenance_reason   TEXT NOT NULL DEFAULT '',
+						ADD COLUMN IF NOT EXISTS ts_maintenance_start TIMESTAMPTZ,
+						ADD COLUMN IF NOT EXISTS ts_maintenance_end   TIMESTAMPTZ,
+						ADD COLUMN IF NOT EXISTS in_maintenance       BOOLEAN NOT NULL DEFAULT FALSE`,
+					`ALTER TABLE agent_groups
+						ADD COLUMN IF NOT EXISTS maintenance          BOOLEAN NOT NULL DEFAULT FALSE,
+						ADD COLUMN IF NOT EXISTS maintenance_reason   TEXT NOT NULL DEFAULT '',
+						ADD COLUMN IF NOT EXISTS ts_maintenance_start TIMESTAMPTZ,
+						ADD COLUMN IF NOT EXISTS ts_maintenance_end   TIMESTAMPTZ`,
+					`CREATE or REPLACE VIEW agent_group_membership(agent_groups_id, agent_groups_name, agent_mf_thing_id, agent_mf_channel_id, group_mf_channel_id, mf_owner_id, agent_state, agent_in_maintenance) as
+					SELECT agent_groups.id,
+						   agent_groups.name,
+						   agents.mf_thing_id,
+						   agents.mf_channel_id,
+						   agent_groups.mf_channel_id,
+						   agent_groups.mf_owner_id,
+						   agents.state,
+						   agents.in_maintenance
+					FROM agents,
+						 agent_groups
+					WHERE agent_groups.mf_owner_id = agents.mf_owner_id
+					  AND (agent_groups.tags <@ coalesce(agents.agent_tags || agents.orb_tags, agents.agent_tags, agents.orb_tags))`,
+				},
+			},
 		},
 	}
 
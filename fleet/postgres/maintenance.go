@@ -0,0 +1,45 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/orb-community/orb/fleet"
+)
+
+// openMaintenanceQuery matches the rows with a maintenance window not over yet
+const openMaintenanceQuery = `maintenance AND (ts_maintenance_end IS NULL OR ts_maintenance_end > now())`
+
+// dbMaintenance holds the maintenance window columns shared by agents and agent groups
+type dbMaintenance struct {
+	Maintenance       bool         `db:"maintenance"`
+	MaintenanceReason string       `db:"maintenance_reason"`
+	MaintenanceStart  sql.NullTime `db:"ts_maintenance_start"`
+	MaintenanceEnd    sql.NullTime `db:"ts_maintenance_end"`
+}
+
+func toDBMaintenance(m *fleet.Maintenance) dbMaintenance {
+	if m == nil {
+		return dbMaintenance{}
+	}
+	return dbMaintenance{
+		Maintenance:       true,
+		MaintenanceReason: m.Reason,
+		MaintenanceStart:  sql.NullTime{Time: m.Start, Valid: !m.Start.IsZero()},
+		MaintenanceEnd:    sql.NullTime{Time: m.End, Valid: !m.End.IsZero()},
+	}
+}
+
+func toMaintenance(dbm dbMaintenance) *fleet.Maintenance {
+	if !dbm.Maintenance {
+		return nil
+	}
+	return &fleet.Maintenance{
+		Reason: dbm.MaintenanceReason,
+		Start:  dbm.MaintenanceStart.Time,
+		End:    dbm.MaintenanceEnd.Time,
+	}
+}
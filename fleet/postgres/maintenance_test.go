@@ -0,0 +1,190 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/fleet/postgres"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentMaintenance(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	agentRepo := postgres.NewAgentRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "maintenance-agent")
+
+	unknownID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	now := time.Now()
+	cases := []struct {
+		desc     string
+		thingID  string
+		m        *fleet.Maintenance
+		err      error
+		expected *fleet.Maintenance
+	}{
+		{
+			desc:     "set open ended maintenance",
+			thingID:  agent.MFThingID,
+			m:        &fleet.Maintenance{Reason: "upgrade"},
+			expected: &fleet.Maintenance{Reason: "upgrade"},
+		},
+		{
+			desc:     "set maintenance ending later",
+			thingID:  agent.MFThingID,
+			m:        &fleet.Maintenance{Reason: "upgrade", Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+			expected: &fleet.Maintenance{Reason: "upgrade", Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		},
+		{
+			desc:     "set maintenance already over",
+			thingID:  agent.MFThingID,
+			m:        &fleet.Maintenance{Reason: "upgrade", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+			expected: nil,
+		},
+		{
+			desc:     "clear maintenance",
+			thingID:  agent.MFThingID,
+			m:        nil,
+			expected: nil,
+		},
+		{
+			desc:    "set maintenance of unknown agent",
+			thingID: unknownID.String(),
+			m:       &fleet.Maintenance{Reason: "upgrade"},
+			err:     errors.ErrNotFound,
+		},
+		{
+			desc:    "set maintenance of agent with invalid id",
+			thingID: wrongValue,
+			m:       &fleet.Maintenance{Reason: "upgrade"},
+			err:     errors.ErrNotFound,
+		},
+	}
+
+	// each maintenance window replaces the previous one, hence run in order
+	for _, tc := range cases {
+		err := agentRepo.SetMaintenance(context.Background(), agent.MFOwnerID, tc.thingID, tc.m)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+
+		agents, err := agentRepo.RetrieveAllInMaintenance(context.Background(), agent.MFOwnerID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		if tc.expected == nil {
+			assert.Empty(t, agents, fmt.Sprintf("%s: expected no agent in maintenance got %v", tc.desc, agents))
+			continue
+		}
+		require.Equal(t, 1, len(agents), fmt.Sprintf("%s: expected %d agent in maintenance got %d", tc.desc, 1, len(agents)))
+		got := agents[0].Maintenance
+		require.NotNil(t, got, fmt.Sprintf("%s: expected the maintenance window of the agent", tc.desc))
+		assert.Equal(t, tc.expected.Reason, got.Reason, fmt.Sprintf("%s: expected reason %s got %s", tc.desc, tc.expected.Reason, got.Reason))
+		assert.WithinDuration(t, tc.expected.End, got.End, time.Millisecond, fmt.Sprintf("%s: expected end %s got %s", tc.desc, tc.expected.End, got.End))
+	}
+}
+
+func TestAgentInMaintenance(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	agentRepo := postgres.NewAgentRepository(dbMiddleware, logger)
+
+	agent := saveAgent(t, "in-maintenance-agent")
+
+	unknownID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc          string
+		thingID       string
+		inMaintenance bool
+		err           error
+		count         int
+	}{
+		{desc: "agent entering a group maintenance", thingID: agent.MFThingID, inMaintenance: true, count: 1},
+		{desc: "agent leaving a group maintenance", thingID: agent.MFThingID, inMaintenance: false, count: 0},
+		{desc: "unknown agent entering a group maintenance", thingID: unknownID.String(), inMaintenance: true, err: errors.ErrNotFound},
+	}
+
+	// the agent leaves the maintenance it entered before, hence run in order
+	for _, tc := range cases {
+		err := agentRepo.UpdateInMaintenance(context.Background(), tc.thingID, tc.inMaintenance)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+
+		agents, err := agentRepo.RetrieveAllInMaintenance(context.Background(), agent.MFOwnerID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.count, len(agents), fmt.Sprintf("%s: expected %d agent in maintenance got %d", tc.desc, tc.count, len(agents)))
+	}
+}
+
+func TestAgentGroupMaintenance(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	groupRepo := postgres.NewAgentGroupRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nameID, err := types.NewIdentifier("maintenance-group")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	groupID, err := groupRepo.Save(context.Background(), fleet.AgentGroup{
+		Name:        nameID,
+		MFOwnerID:   oID.String(),
+		MFChannelID: chID.String(),
+		Tags:        &types.Tags{"testkey": "testvalue"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	unknownID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	now := time.Now()
+	cases := []struct {
+		desc    string
+		groupID string
+		m       *fleet.Maintenance
+		err     error
+		count   int
+	}{
+		{desc: "set group maintenance ending later", groupID: groupID, m: &fleet.Maintenance{Reason: "upgrade", End: now.Add(time.Hour)}, count: 1},
+		{desc: "set group maintenance already over", groupID: groupID, m: &fleet.Maintenance{Reason: "upgrade", End: now.Add(-time.Hour)}, count: 0},
+		{desc: "set open ended group maintenance", groupID: groupID, m: &fleet.Maintenance{Reason: "upgrade"}, count: 1},
+		{desc: "clear group maintenance", groupID: groupID, m: nil, count: 0},
+		{desc: "set maintenance of unknown group", groupID: unknownID.String(), m: &fleet.Maintenance{Reason: "upgrade"}, err: fleet.ErrNotFound},
+		{desc: "set maintenance of group with invalid id", groupID: wrongValue, m: &fleet.Maintenance{Reason: "upgrade"}, err: fleet.ErrNotFound},
+	}
+
+	// each maintenance window replaces the previous one, hence run in order
+	for _, tc := range cases {
+		err := groupRepo.SetMaintenance(context.Background(), oID.String(), tc.groupID, tc.m)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected '%s' got '%s'", tc.desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+
+		groups, err := groupRepo.RetrieveAllInMaintenance(context.Background(), oID.String())
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.count, len(groups), fmt.Sprintf("%s: expected %d group in maintenance got %d", tc.desc, tc.count, len(groups)))
+		if tc.count == 1 {
+			require.NotNil(t, groups[0].Maintenance, fmt.Sprintf("%s: expected the maintenance window of the group", tc.desc))
+			assert.Equal(t, tc.m.Reason, groups[0].Maintenance.Reason, fmt.Sprintf("%s: expected reason %s got %s", tc.desc, tc.m.Reason, groups[0].Maintenance.Reason))
+		}
+	}
+}
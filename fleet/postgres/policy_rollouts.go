@@ -0,0 +1,239 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var _ fleet.PolicyRolloutRepository = (*policyRolloutRepository)(nil)
+
+const policyRolloutColumns = `id, mf_owner_id, policy_id, version, group_ids, strategy, state, current_wave, waves, agents, error, ts_created, ts_last_modified`
+
+type policyRolloutRepository struct {
+	db     Database
+	logger *zap.Logger
+}
+
+func (r policyRolloutRepository) Save(ctx context.Context, rollout fleet.PolicyRollout) (string, error) {
+	q := `INSERT INTO policy_rollouts (mf_owner_id, policy_id, version, group_ids, strategy, state, current_wave, waves, agents, error)
+			VALUES (:mf_owner_id, :policy_id, :version, :group_ids, :strategy, :state, :current_wave, :waves, :agents, :error) RETURNING id`
+
+	if rollout.MFOwnerID == "" || rollout.PolicyID == "" {
+		return "", errors.ErrMalformedEntity
+	}
+
+	dbr, err := toDBPolicyRollout(rollout)
+	if err != nil {
+		return "", errors.Wrap(db.ErrSaveDB, err)
+	}
+
+	row, err := r.db.NamedQueryContext(ctx, q, dbr)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return "", errors.Wrap(errors.ErrMalformedEntity, err)
+			}
+		}
+		return "", errors.Wrap(db.ErrSaveDB, err)
+	}
+	defer row.Close()
+
+	var id string
+	if row.Next() {
+		if err := row.Scan(&id); err != nil {
+			return "", errors.Wrap(db.ErrSaveDB, err)
+		}
+	}
+	return id, nil
+}
+
+func (r policyRolloutRepository) Update(ctx context.Context, rollout fleet.PolicyRollout) error {
+	q := `UPDATE policy_rollouts SET state = :state, current_wave = :current_wave, agents = :agents, error = :error, ts_last_modified = CURRENT_TIMESTAMP
+			WHERE mf_owner_id = :mf_owner_id AND id = :id;`
+
+	dbr, err := toDBPolicyRollout(rollout)
+	if err != nil {
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+
+	res, err := r.db.NamedExecContext(ctx, q, dbr)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return errors.Wrap(fleet.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(fleet.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return fleet.ErrNotFound
+	}
+	return nil
+}
+
+func (r policyRolloutRepository) RetrieveByID(ctx context.Context, ownerID string, id string) (fleet.PolicyRollout, error) {
+	q := `SELECT ` + policyRolloutColumns + ` FROM policy_rollouts WHERE id = $1 AND mf_owner_id = $2`
+
+	if id == "" || ownerID == "" {
+		return fleet.PolicyRollout{}, errors.ErrMalformedEntity
+	}
+
+	var dbr dbPolicyRollout
+	if err := r.db.QueryRowxContext(ctx, q, id, ownerID).StructScan(&dbr); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && db.ErrInvalid == pqErr.Code.Name() {
+			return fleet.PolicyRollout{}, errors.Wrap(errors.ErrNotFound, err)
+		}
+		return fleet.PolicyRollout{}, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	return toPolicyRollout(dbr)
+}
+
+func (r policyRolloutRepository) RetrieveAllByOwner(ctx context.Context, ownerID string, policyID string) ([]fleet.PolicyRollout, error) {
+	q := `SELECT ` + policyRolloutColumns + ` FROM policy_rollouts WHERE mf_owner_id = :mf_owner_id`
+	if policyID != "" {
+		q += ` AND policy_id = :policy_id`
+	}
+	q += ` ORDER BY ts_created DESC`
+
+	params := map[string]interface{}{
+		"mf_owner_id": ownerID,
+		"policy_id":   policyID,
+	}
+	return r.retrieve(ctx, q, params)
+}
+
+func (r policyRolloutRepository) RetrieveAllInProgress(ctx context.Context) ([]fleet.PolicyRollout, error) {
+	q := `SELECT ` + policyRolloutColumns + ` FROM policy_rollouts WHERE state = :state ORDER BY ts_created`
+
+	params := map[string]interface{}{
+		"state": fleet.RolloutInProgress,
+	}
+	return r.retrieve(ctx, q, params)
+}
+
+func (r policyRolloutRepository) retrieve(ctx context.Context, q string, params map[string]interface{}) ([]fleet.PolicyRollout, error) {
+	rows, err := r.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []fleet.PolicyRollout
+	for rows.Next() {
+		dbr := dbPolicyRollout{}
+		if err := rows.StructScan(&dbr); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+
+		rollout, err := toPolicyRollout(dbr)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrViewEntity, err)
+		}
+		items = append(items, rollout)
+	}
+	return items, nil
+}
+
+type dbPolicyRollout struct {
+	ID           string    `db:"id"`
+	MFOwnerID    string    `db:"mf_owner_id"`
+	PolicyID     string    `db:"policy_id"`
+	Version      int32     `db:"version"`
+	GroupIDs     string    `db:"group_ids"`
+	Strategy     string    `db:"strategy"`
+	State        string    `db:"state"`
+	CurrentWave  int       `db:"current_wave"`
+	Waves        int       `db:"waves"`
+	Agents       string    `db:"agents"`
+	Error        string    `db:"error"`
+	Created      time.Time `db:"ts_created"`
+	LastModified time.Time `db:"ts_last_modified"`
+}
+
+func toDBPolicyRollout(rollout fleet.PolicyRollout) (dbPolicyRollout, error) {
+	groupIDs := rollout.GroupIDs
+	if groupIDs == nil {
+		groupIDs = []string{}
+	}
+	groups, err := json.Marshal(groupIDs)
+	if err != nil {
+		return dbPolicyRollout{}, err
+	}
+	strategy, err := json.Marshal(rollout.Strategy)
+	if err != nil {
+		return dbPolicyRollout{}, err
+	}
+	rolloutAgents := rollout.Agents
+	if rolloutAgents == nil {
+		rolloutAgents = []fleet.RolloutAgent{}
+	}
+	agents, err := json.Marshal(rolloutAgents)
+	if err != nil {
+		return dbPolicyRollout{}, err
+	}
+
+	return dbPolicyRollout{
+		ID:          rollout.ID,
+		MFOwnerID:   rollout.MFOwnerID,
+		PolicyID:    rollout.PolicyID,
+		Version:     rollout.Version,
+		GroupIDs:    string(groups),
+		Strategy:    string(strategy),
+		State:       rollout.State,
+		CurrentWave: rollout.CurrentWave,
+		Waves:       rollout.Waves,
+		Agents:      string(agents),
+		Error:       rollout.Error,
+	}, nil
+}
+
+func toPolicyRollout(dbr dbPolicyRollout) (fleet.PolicyRollout, error) {
+	rollout := fleet.PolicyRollout{
+		ID:           dbr.ID,
+		MFOwnerID:    dbr.MFOwnerID,
+		PolicyID:     dbr.PolicyID,
+		Version:      dbr.Version,
+		State:        dbr.State,
+		CurrentWave:  dbr.CurrentWave,
+		Waves:        dbr.Waves,
+		Error:        dbr.Error,
+		Created:      dbr.Created,
+		LastModified: dbr.LastModified,
+	}
+	if err := json.Unmarshal([]byte(dbr.GroupIDs), &rollout.GroupIDs); err != nil {
+		return fleet.PolicyRollout{}, err
+	}
+	if err := json.Unmarshal([]byte(dbr.Strategy), &rollout.Strategy); err != nil {
+		return fleet.PolicyRollout{}, err
+	}
+	if err := json.Unmarshal([]byte(dbr.Agents), &rollout.Agents); err != nil {
+		return fleet.PolicyRollout{}, err
+	}
+	return rollout, nil
+}
+
+func NewPolicyRolloutRepository(db Database, logger *zap.Logger) fleet.PolicyRolloutRepository {
+	return &policyRolloutRepository{db: db, logger: logger}
+}
@@ -9,39 +9,47 @@
 package consumer
 
 import (
+	"github.com/orb-community/orb/fleet"
 	"github.com/orb-community/orb/pkg/types"
 	"time"
 )
 
 type createDatasetEvent struct {
-	id           string
-	ownerID      string
-	name         string
-	agentGroupID string
-	policyID     string
-	sinkIDs      []string
-	timestamp    time.Time
+	id        string
+	ownerID   string
+	name      string
+	groupsIDs []string
+	policyID  string
+	sinkIDs   []string
+	// schedulePaused is set when the dataset is created outside its schedule
+	schedulePaused bool
+	timestamp      time.Time
 }
 
 type removeDatasetEvent struct {
-	id           string
-	ownerID      string
-	agentGroupID string
-	datasetID    string
-	policyID     string
-	timestamp    time.Time
+	id        string
+	ownerID   string
+	groupsIDs []string
+	datasetID string
+	policyID  string
+	timestamp time.Time
 }
 
 type updateDatasetEvent struct {
 	id            string
 	ownerID       string
-	agentGroupID  string
+	groupsIDs     []string
 	policyID      string
 	datasetID     string
 	valid         bool
 	turnedValid   bool
 	turnedInvalid bool
-	timestamp     time.Time
+	// addedGroupIDs and removedGroupIDs list the groups the edit added to and removed from the dataset
+	addedGroupIDs   []string
+	removedGroupIDs []string
+	// exclusionsChanged lists the agents excluded or included again by the edit
+	exclusionsChanged []string
+	timestamp         time.Time
 }
 
 type updatePolicyEvent struct {
@@ -49,6 +57,8 @@ type updatePolicyEvent struct {
 	ownerID   string
 	groupsIDs []string
 	policy    types.Metadata
+	version   int32
+	rollout   *fleet.RolloutStrategy
 	timestamp time.Time
 }
 
@@ -10,6 +10,7 @@ package consumer
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/go-redis/redis/v8"
 	"github.com/orb-community/orb/fleet"
 	"go.uber.org/zap"
@@ -109,11 +110,12 @@ func (es eventStore) Subscribe(context context.Context) error {
 
 func decodeDatasetCreate(event map[string]interface{}) createDatasetEvent {
 	val := createDatasetEvent{
-		id:           read(event, "id", ""),
-		ownerID:      read(event, "owner_id", ""),
-		name:         read(event, "name", ""),
-		agentGroupID: read(event, "group_id", ""),
-		policyID:     read(event, "policy_id", ""),
+		id:             read(event, "id", ""),
+		ownerID:        read(event, "owner_id", ""),
+		name:           read(event, "name", ""),
+		groupsIDs:      readDatasetGroups(event),
+		policyID:       read(event, "policy_id", ""),
+		schedulePaused: readBool(event, "schedule_paused", false),
 	}
 	strsinks := read(event, "sink_ids", "")
 	val.sinkIDs = strings.Split(strsinks, ",")
@@ -123,56 +125,129 @@ func decodeDatasetCreate(event map[string]interface{}) createDatasetEvent {
 // the policy service is notifying that a new dataset has been created
 // notify all agents in the AgentGroup specified in the dataset about the new agent policy
 func (es eventStore) handleDatasetCreate(ctx context.Context, e createDatasetEvent) error {
-
-	ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, e.agentGroupID, e.ownerID)
-	if err != nil {
-		es.logger.Error("Failed to retrieve dataset", zap.String("group_id", e.agentGroupID), zap.String("owner_id", e.ownerID), zap.Error(err))
-		return err
+	// the agents receive the dataset once its schedule starts, as a dataset update
+	if e.schedulePaused {
+		return nil
 	}
 
-	return es.commsService.NotifyGroupNewDataset(ctx, ag, e.id, e.policyID, e.ownerID)
+	for _, groupID := range e.groupsIDs {
+		ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, groupID, e.ownerID)
+		if err != nil {
+			es.logger.Error("Failed to retrieve dataset", zap.String("group_id", groupID), zap.String("owner_id", e.ownerID), zap.Error(err))
+			return err
+		}
+
+		if err := es.commsService.NotifyGroupNewDataset(ctx, ag, e.id, e.policyID, e.ownerID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func decodeDatasetRemove(event map[string]interface{}) removeDatasetEvent {
 	return removeDatasetEvent{
-		id:           read(event, "id", ""),
-		ownerID:      read(event, "owner_id", ""),
-		agentGroupID: read(event, "group_id", ""),
-		datasetID:    read(event, "dataset_id", ""),
-		policyID:     read(event, "policy_id", ""),
+		id:        read(event, "id", ""),
+		ownerID:   read(event, "owner_id", ""),
+		groupsIDs: readDatasetGroups(event),
+		datasetID: read(event, "dataset_id", ""),
+		policyID:  read(event, "policy_id", ""),
 	}
 }
 
 func (es eventStore) handleDatasetRemove(ctx context.Context, e removeDatasetEvent) error {
-	ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, e.agentGroupID, e.ownerID)
-	if err != nil {
-		return err
-	}
+	for _, groupID := range e.groupsIDs {
+		ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, groupID, e.ownerID)
+		if err != nil {
+			return err
+		}
 
-	return es.commsService.NotifyGroupDatasetRemoval(ctx, ag, e.datasetID, e.policyID)
+		if err := es.commsService.NotifyGroupDatasetRemoval(ctx, ag, e.datasetID, e.policyID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func decodeDatasetUpdate(event map[string]interface{}) updateDatasetEvent {
-	return updateDatasetEvent{
+	val := updateDatasetEvent{
 		id:            read(event, "id", ""),
 		ownerID:       read(event, "owner_id", ""),
-		agentGroupID:  read(event, "group_id", ""),
+		groupsIDs:     readDatasetGroups(event),
 		datasetID:     read(event, "dataset_id", ""),
 		policyID:      read(event, "policy_id", ""),
 		valid:         readBool(event, "valid", false),
 		turnedValid:   readBool(event, "turned_valid", false),
 		turnedInvalid: readBool(event, "turned_invalid", false),
 	}
+	if added := read(event, "added_group_ids", ""); added != "" {
+		val.addedGroupIDs = strings.Split(added, ",")
+	}
+	if removed := read(event, "removed_group_ids", ""); removed != "" {
+		val.removedGroupIDs = strings.Split(removed, ",")
+	}
+	if changed := read(event, "exclusions_changed", ""); changed != "" {
+		val.exclusionsChanged = strings.Split(changed, ",")
+	}
+	return val
 }
 
 func (es eventStore) handleDatasetUpdate(ctx context.Context, e updateDatasetEvent) error {
+	// the agents of the groups removed from the dataset drop it, whether it is valid or not
+	for _, groupID := range e.removedGroupIDs {
+		ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, groupID, e.ownerID)
+		if err != nil {
+			return err
+		}
+
+		if err := es.commsService.NotifyGroupDatasetRemoval(ctx, ag, e.id, e.policyID); err != nil {
+			return err
+		}
+	}
+
 	if e.turnedValid || e.turnedInvalid {
-		ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, e.agentGroupID, e.ownerID)
+		for _, groupID := range e.groupsIDs {
+			ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, groupID, e.ownerID)
+			if err != nil {
+				return err
+			}
+
+			if err := es.commsService.NotifyGroupDatasetEdit(ctx, ag, e.id, e.policyID, e.ownerID, e.valid); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !e.valid {
+		return nil
+	}
+
+	// the agents of the groups added to the dataset receive it as a new one
+	for _, groupID := range e.addedGroupIDs {
+		ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, groupID, e.ownerID)
 		if err != nil {
 			return err
 		}
 
-		return es.commsService.NotifyGroupDatasetEdit(ctx, ag, e.id, e.policyID, e.ownerID, e.valid)
+		if err := es.commsService.NotifyGroupNewDataset(ctx, ag, e.id, e.policyID, e.ownerID); err != nil {
+			return err
+		}
+	}
+
+	// the agents excluded or included again receive their full policy list, offline ones get it when they connect
+	for _, agentID := range e.exclusionsChanged {
+		a, err := es.fleetService.ViewAgentByIDInternal(ctx, e.ownerID, agentID)
+		if err != nil {
+			es.logger.Warn("failed to retrieve agent of dataset exclusions", zap.String("dataset_id", e.id),
+				zap.String("agent_id", agentID), zap.Error(err))
+			continue
+		}
+		if a.State != fleet.Online {
+			continue
+		}
+		if err := es.commsService.NotifyAgentAllDatasets(ctx, a); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -187,12 +262,37 @@ func decodePolicyUpdate(event map[string]interface{}) (updatePolicyEvent, error)
 	strgroups := read(event, "groups_ids", "")
 	val.groupsIDs = strings.Split(strgroups, ",")
 
+	version, err := strconv.ParseInt(read(event, "version", "0"), 10, 32)
+	if err != nil {
+		return updatePolicyEvent{}, err
+	}
+	val.version = int32(version)
+
+	if rollout := read(event, "rollout", ""); rollout != "" {
+		val.rollout = &fleet.RolloutStrategy{}
+		if err := json.Unmarshal([]byte(rollout), val.rollout); err != nil {
+			return updatePolicyEvent{}, err
+		}
+	}
+
 	return val, nil
 }
 
 // the policy service is notifying that a policy has been updated
 // notify all agents in the AgentGroup specified in the dataset about the policy update
 func (es eventStore) handlePolicyUpdate(ctx context.Context, e updatePolicyEvent) error {
+	// staged updates are sent to the agents of the groups wave by wave instead
+	if e.rollout != nil {
+		var groupIDs []string
+		for _, id := range e.groupsIDs {
+			if id != "" {
+				groupIDs = append(groupIDs, id)
+			}
+		}
+		_, err := es.fleetService.StartPolicyRolloutInternal(ctx, e.ownerID, e.id, e.version, groupIDs, *e.rollout)
+		return err
+	}
+
 	for _, a := range e.groupsIDs {
 		ag, err := es.fleetService.ViewAgentGroupByIDInternal(ctx, a, e.ownerID)
 		if err != nil {
@@ -257,3 +357,16 @@ func readBool(event map[string]interface{}, key string, def bool) bool {
 
 	return boolVal
 }
+
+// readDatasetGroups reads the groups of a dataset event, the ones sent before datasets targeted several groups only
+// have a group_id
+func readDatasetGroups(event map[string]interface{}) []string {
+	groups := read(event, "groups_ids", "")
+	if groups == "" {
+		groups = read(event, "group_id", "")
+	}
+	if groups == "" {
+		return nil
+	}
+	return strings.Split(groups, ",")
+}
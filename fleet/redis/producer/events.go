@@ -9,14 +9,21 @@
 package producer
 
 import (
+	"strconv"
 	"time"
 )
 
 const (
 	AgentPrefix      = "agent."
 	AgentCreate      = AgentPrefix + "create"
+	AgentUpdate      = AgentPrefix + "update"
+	AgentRemove      = AgentPrefix + "remove"
 	AgentGroupPrefix = "agent_group."
+	AgentGroupCreate = AgentGroupPrefix + "create"
+	AgentGroupUpdate = AgentGroupPrefix + "update"
 	AgentGroupRemove = AgentGroupPrefix + "remove"
+	RolloutPrefix    = "policy_rollout."
+	RolloutRollback  = RolloutPrefix + "rollback"
 )
 
 type event interface {
@@ -26,6 +33,9 @@ type event interface {
 var (
 	_ event = (*createAgentEvent)(nil)
 	_ event = (*removeAgentGroupEvent)(nil)
+	_ event = (*agentEvent)(nil)
+	_ event = (*agentGroupEvent)(nil)
+	_ event = (*rolloutRollbackEvent)(nil)
 )
 
 type createAgentEvent struct {
@@ -61,3 +71,61 @@ func (cce createAgentEvent) encode() map[string]interface{} {
 		"operation": AgentCreate,
 	}
 }
+
+// agentEvent is published when an agent is updated or removed, so consumers caching agents by channel can evict it
+type agentEvent struct {
+	agentID   string
+	ownerID   string
+	channelID string
+	operation string
+	timestamp time.Time
+}
+
+func (ae agentEvent) encode() map[string]interface{} {
+	return map[string]interface{}{
+		"agent_id":   ae.agentID,
+		"owner_id":   ae.ownerID,
+		"channel_id": ae.channelID,
+		"timestamp":  ae.timestamp.Unix(),
+		"operation":  ae.operation,
+	}
+}
+
+// agentGroupEvent is published when an agent group is created or updated, as it changes the groups its owner agents match
+type agentGroupEvent struct {
+	groupID   string
+	ownerID   string
+	operation string
+	timestamp time.Time
+}
+
+func (age agentGroupEvent) encode() map[string]interface{} {
+	return map[string]interface{}{
+		"group_id":  age.groupID,
+		"owner_id":  age.ownerID,
+		"timestamp": age.timestamp.Unix(),
+		"operation": age.operation,
+	}
+}
+
+// rolloutRollbackEvent requests the policies service to restore the policy version preceding a failed rollout
+type rolloutRollbackEvent struct {
+	rolloutID string
+	policyID  string
+	ownerID   string
+	version   int32
+	reason    string
+	timestamp time.Time
+}
+
+func (rre rolloutRollbackEvent) encode() map[string]interface{} {
+	return map[string]interface{}{
+		"rollout_id": rre.rolloutID,
+		"policy_id":  rre.policyID,
+		"owner_id":   rre.ownerID,
+		"version":    strconv.FormatInt(int64(rre.version), 10),
+		"reason":     rre.reason,
+		"timestamp":  rre.timestamp.Unix(),
+		"operation":  RolloutRollback,
+	}
+}
@@ -10,8 +10,11 @@ package producer
 
 import (
 	"context"
+	"time"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/types"
 	"go.uber.org/zap"
 )
 
@@ -20,7 +23,10 @@ const (
 	streamLen = 1000
 )
 
-var _ fleet.Service = (*eventStore)(nil)
+var (
+	_ fleet.Service             = (*eventStore)(nil)
+	_ fleet.AgentEventPublisher = (*eventStore)(nil)
+)
 
 type eventStore struct {
 	svc    fleet.Service
@@ -32,6 +38,74 @@ func (es eventStore) ViewAgentMatchingGroupsByIDInternal(ctx context.Context, ag
 	return es.svc.ViewAgentMatchingGroupsByIDInternal(ctx, agentID, ownerID)
 }
 
+func (es eventStore) PreviewAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (fleet.AgentPolicyRPCPayload, error) {
+	return es.svc.PreviewAgentPolicy(ctx, token, agentID, policyID)
+}
+
+func (es eventStore) TestAgentPolicy(ctx context.Context, token string, agentID string, policyID string) (fleet.PolicyTestResultRPCPayload, error) {
+	return es.svc.TestAgentPolicy(ctx, token, agentID, policyID)
+}
+
+func (es eventStore) ListAgentRPCs(ctx context.Context, token string, agentID string, limit uint64) ([]fleet.AgentRPC, error) {
+	return es.svc.ListAgentRPCs(ctx, token, agentID, limit)
+}
+
+func (es eventStore) RequestAgentDiagnostics(ctx context.Context, token string, agentID string) (fleet.DiagnosticsBundle, error) {
+	return es.svc.RequestAgentDiagnostics(ctx, token, agentID)
+}
+
+func (es eventStore) ViewAgentDiagnostics(ctx context.Context, token string, agentID string, bundleID string) (fleet.DiagnosticsBundle, error) {
+	return es.svc.ViewAgentDiagnostics(ctx, token, agentID, bundleID)
+}
+
+func (es eventStore) SetAgentMaintenance(ctx context.Context, token string, agentID string, m fleet.Maintenance) (fleet.Agent, error) {
+	return es.svc.SetAgentMaintenance(ctx, token, agentID, m)
+}
+
+func (es eventStore) ClearAgentMaintenance(ctx context.Context, token string, agentID string) (fleet.Agent, error) {
+	return es.svc.ClearAgentMaintenance(ctx, token, agentID)
+}
+
+func (es eventStore) SetAgentGroupMaintenance(ctx context.Context, token string, groupID string, m fleet.Maintenance) (fleet.AgentGroup, error) {
+	return es.svc.SetAgentGroupMaintenance(ctx, token, groupID, m)
+}
+
+func (es eventStore) ClearAgentGroupMaintenance(ctx context.Context, token string, groupID string) (fleet.AgentGroup, error) {
+	return es.svc.ClearAgentGroupMaintenance(ctx, token, groupID)
+}
+
+func (es eventStore) CheckAgentMaintenance(ctx context.Context) error {
+	return es.svc.CheckAgentMaintenance(ctx)
+}
+
+func (es eventStore) ViewPolicyStatusInternal(ctx context.Context, ownerID string, policyID string, version int32, targets []fleet.PolicyStatusTarget) (fleet.PolicyStatus, error) {
+	return es.svc.ViewPolicyStatusInternal(ctx, ownerID, policyID, version, targets)
+}
+
+func (es eventStore) ViewFleetSummary(ctx context.Context, token string, tags types.Tags) (fleet.FleetSummary, error) {
+	return es.svc.ViewFleetSummary(ctx, token, tags)
+}
+
+func (es eventStore) SetAgentVersionPolicy(ctx context.Context, token string, p fleet.AgentVersionPolicy) (fleet.AgentVersionPolicy, error) {
+	return es.svc.SetAgentVersionPolicy(ctx, token, p)
+}
+
+func (es eventStore) ViewAgentVersionPolicy(ctx context.Context, token string) (fleet.AgentVersionPolicy, error) {
+	return es.svc.ViewAgentVersionPolicy(ctx, token)
+}
+
+func (es eventStore) AgentComplianceReport(ctx context.Context, token string) (fleet.ComplianceReport, error) {
+	return es.svc.AgentComplianceReport(ctx, token)
+}
+
+func (es eventStore) RotateAgentCredentials(ctx context.Context, token string, agentID string) (fleet.AgentCredentials, error) {
+	return es.svc.RotateAgentCredentials(ctx, token, agentID)
+}
+
+func (es eventStore) RotateAgentGroupCredentials(ctx context.Context, token string, groupID string) ([]fleet.AgentCredentials, error) {
+	return es.svc.RotateAgentGroupCredentials(ctx, token, groupID)
+}
+
 func (es eventStore) ResetAgent(ct context.Context, token string, agentID string) error {
 	return es.svc.ResetAgent(ct, token, agentID)
 }
@@ -61,7 +135,27 @@ func (es eventStore) ViewAgentMatchingGroupsByID(ctx context.Context, token stri
 }
 
 func (es eventStore) EditAgent(ctx context.Context, token string, agent fleet.Agent) (fleet.Agent, error) {
-	return es.svc.EditAgent(ctx, token, agent)
+	ag, err := es.svc.EditAgent(ctx, token, agent)
+	if err != nil {
+		return ag, err
+	}
+
+	if err := es.PublishAgentUpdate(ctx, ag); err != nil {
+		return ag, err
+	}
+
+	return ag, nil
+}
+
+func (es eventStore) PublishAgentUpdate(ctx context.Context, agent fleet.Agent) error {
+	event := agentEvent{
+		agentID:   agent.MFThingID,
+		ownerID:   agent.MFOwnerID,
+		channelID: agent.MFChannelID,
+		operation: AgentUpdate,
+		timestamp: time.Now(),
+	}
+	return es.publish(ctx, event)
 }
 
 func (es eventStore) ViewAgentGroupByIDInternal(ctx context.Context, groupID string, ownerID string) (fleet.AgentGroup, error) {
@@ -77,7 +171,22 @@ func (es eventStore) ListAgentGroups(ctx context.Context, token string, pm fleet
 }
 
 func (es eventStore) EditAgentGroup(ctx context.Context, token string, ag fleet.AgentGroup) (fleet.AgentGroup, error) {
-	return es.svc.EditAgentGroup(ctx, token, ag)
+	group, err := es.svc.EditAgentGroup(ctx, token, ag)
+	if err != nil {
+		return group, err
+	}
+
+	event := agentGroupEvent{
+		groupID:   group.ID,
+		ownerID:   group.MFOwnerID,
+		operation: AgentGroupUpdate,
+		timestamp: time.Now(),
+	}
+	if err := es.publish(ctx, event); err != nil {
+		return group, err
+	}
+
+	return group, nil
 }
 
 func (es eventStore) ListAgents(ctx context.Context, token string, pm fleet.PageMetadata) (fleet.Page, error) {
@@ -89,7 +198,22 @@ func (es eventStore) CreateAgent(ctx context.Context, token string, a fleet.Agen
 }
 
 func (es eventStore) CreateAgentGroup(ctx context.Context, token string, s fleet.AgentGroup) (fleet.AgentGroup, error) {
-	return es.svc.CreateAgentGroup(ctx, token, s)
+	group, err := es.svc.CreateAgentGroup(ctx, token, s)
+	if err != nil {
+		return group, err
+	}
+
+	event := agentGroupEvent{
+		groupID:   group.ID,
+		ownerID:   group.MFOwnerID,
+		operation: AgentGroupCreate,
+		timestamp: time.Now(),
+	}
+	if err := es.publish(ctx, event); err != nil {
+		return group, err
+	}
+
+	return group, nil
 }
 
 func (es eventStore) RemoveAgentGroup(ctx context.Context, token string, groupID string) (err error) {
@@ -127,13 +251,114 @@ func (es eventStore) ValidateAgent(ctx context.Context, token string, a fleet.Ag
 }
 
 func (es eventStore) RemoveAgent(ctx context.Context, token, thingID string) (err error) {
-	return es.svc.RemoveAgent(ctx, token, thingID)
+	ag, err := es.svc.ViewAgentByID(ctx, token, thingID)
+	if err != nil {
+		// removing a non-existent agent is a no-op, there is nothing to notify
+		return es.svc.RemoveAgent(ctx, token, thingID)
+	}
+
+	if err := es.svc.RemoveAgent(ctx, token, thingID); err != nil {
+		return err
+	}
+
+	event := agentEvent{
+		agentID:   ag.MFThingID,
+		ownerID:   ag.MFOwnerID,
+		channelID: ag.MFChannelID,
+		operation: AgentRemove,
+		timestamp: time.Now(),
+	}
+	return es.publish(ctx, event)
+}
+
+func (es eventStore) publish(ctx context.Context, event event) error {
+	record := &redis.XAddArgs{
+		Stream: streamID,
+		MaxLen: streamLen,
+		Approx: true,
+		Values: event.encode(),
+	}
+	if err := es.client.XAdd(ctx, record).Err(); err != nil {
+		es.logger.Error("error sending event to event store", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (es eventStore) StartPolicyRolloutInternal(ctx context.Context, ownerID string, policyID string, version int32, groupIDs []string, strategy fleet.RolloutStrategy) (fleet.PolicyRollout, error) {
+	return es.svc.StartPolicyRolloutInternal(ctx, ownerID, policyID, version, groupIDs, strategy)
+}
+
+func (es eventStore) CheckPolicyRollouts(ctx context.Context) ([]fleet.PolicyRollout, error) {
+	rolledBack, err := es.svc.CheckPolicyRollouts(ctx)
+	if err != nil {
+		return rolledBack, err
+	}
+
+	for _, rollout := range rolledBack {
+		if err := es.publishRollback(ctx, rollout); err != nil {
+			return rolledBack, err
+		}
+	}
+	return rolledBack, nil
+}
+
+func (es eventStore) ViewPolicyRollout(ctx context.Context, token string, id string) (fleet.PolicyRollout, error) {
+	return es.svc.ViewPolicyRollout(ctx, token, id)
+}
+
+func (es eventStore) ListPolicyRollouts(ctx context.Context, token string, policyID string) ([]fleet.PolicyRollout, error) {
+	return es.svc.ListPolicyRollouts(ctx, token, policyID)
+}
+
+func (es eventStore) RollbackPolicyRollout(ctx context.Context, token string, id string) (fleet.PolicyRollout, error) {
+	rollout, err := es.svc.RollbackPolicyRollout(ctx, token, id)
+	if err != nil {
+		return rollout, err
+	}
+
+	if err := es.publishRollback(ctx, rollout); err != nil {
+		return rollout, err
+	}
+	return rollout, nil
+}
+
+func (es eventStore) ResumePolicyRollout(ctx context.Context, token string, id string) (fleet.PolicyRollout, error) {
+	return es.svc.ResumePolicyRollout(ctx, token, id)
+}
+
+func (es eventStore) publishRollback(ctx context.Context, rollout fleet.PolicyRollout) error {
+	event := rolloutRollbackEvent{
+		rolloutID: rollout.ID,
+		policyID:  rollout.PolicyID,
+		ownerID:   rollout.MFOwnerID,
+		version:   rollout.Version,
+		reason:    rollout.Error,
+		timestamp: time.Now(),
+	}
+	return es.publish(ctx, event)
 }
 
 func (es eventStore) GetPolicyState(ctx context.Context, agent fleet.Agent) (map[string]interface{}, error) {
 	return es.svc.GetPolicyState(ctx, agent)
 }
 
+func (es eventStore) CreateEnrollmentToken(ctx context.Context, token string, et fleet.EnrollmentToken) (fleet.EnrollmentToken, error) {
+	return es.svc.CreateEnrollmentToken(ctx, token, et)
+}
+
+func (es eventStore) ListEnrollmentTokens(ctx context.Context, token string) ([]fleet.EnrollmentToken, error) {
+	return es.svc.ListEnrollmentTokens(ctx, token)
+}
+
+func (es eventStore) RevokeEnrollmentToken(ctx context.Context, token string, id string) error {
+	return es.svc.RevokeEnrollmentToken(ctx, token, id)
+}
+
+func (es eventStore) EnrollAgent(ctx context.Context, enrollmentToken string, a fleet.Agent) (fleet.Agent, error) {
+	return es.svc.EnrollAgent(ctx, enrollmentToken, a)
+}
+
 // NewEventStoreMiddleware returns wrapper around fleet service that sends
 // events to event store.
 func NewEventStoreMiddleware(svc fleet.Service, client *redis.Client, logger *zap.Logger) fleet.Service {
@@ -144,3 +369,11 @@ func NewEventStoreMiddleware(svc fleet.Service, client *redis.Client, logger *za
 		logger: l,
 	}
 }
+
+// NewAgentEventPublisher returns the publisher of the agent events raised outside the fleet service, such as by the agent comms
+func NewAgentEventPublisher(client *redis.Client, logger *zap.Logger) fleet.AgentEventPublisher {
+	return eventStore{
+		client: client,
+		logger: logger.Named("event_store_middleware"),
+	}
+}
@@ -21,6 +21,13 @@ import (
 type Service interface {
 	AgentService
 	AgentGroupService
+	PolicyRolloutService
+	EnrollmentTokenService
+	AgentCredentialsService
+	AgentVersionPolicyService
+	AgentDiagnosticsService
+	AgentMaintenanceService
+	PolicyStatusService
 }
 
 // PageMetadata contains page metadata that helps navigation.
@@ -42,10 +49,23 @@ type fleetService struct {
 	// for AuthN/AuthZ
 	auth mainflux.AuthServiceClient
 	// for Thing manipulation
-	mfsdk mfsdk.SDK
+	mfsdk     mfsdk.SDK
+	thingKeys ThingKeyService
+	// mfServiceKey provisions the things of the agents enrolled without a user token
+	mfServiceKey string
 	// Agents and Agent Groups
 	agentRepo            AgentRepository
 	agentGroupRepository AgentGroupRepository
+	// Staged policy updates
+	policyRolloutRepo PolicyRolloutRepository
+	// Agent self provisioning
+	enrollmentTokenRepo EnrollmentTokenRepository
+	// Core to agent RPC delivery
+	agentRPCRepo AgentRPCRepository
+	// Owner agent version requirements
+	versionPolicyRepo AgentVersionPolicyRepository
+	// Agent diagnostic bundles
+	diagnosticsRepo AgentDiagnosticsRepository
 	// Agent Comms
 	agentComms AgentCommsService
 
@@ -97,7 +117,7 @@ func (svc fleetService) thing(token, id string, name string, md map[string]inter
 	return thing, nil
 }
 
-func NewFleetService(logger *zap.Logger, auth mainflux.AuthServiceClient, agentRepo AgentRepository, agentGroupRepository AgentGroupRepository, agentComms AgentCommsService, mfsdk mfsdk.SDK, aDone chan bool) Service {
+func NewFleetService(logger *zap.Logger, auth mainflux.AuthServiceClient, agentRepo AgentRepository, agentGroupRepository AgentGroupRepository, policyRolloutRepo PolicyRolloutRepository, enrollmentTokenRepo EnrollmentTokenRepository, agentRPCRepo AgentRPCRepository, versionPolicyRepo AgentVersionPolicyRepository, diagnosticsRepo AgentDiagnosticsRepository, agentComms AgentCommsService, mfsdk mfsdk.SDK, thingKeys ThingKeyService, mfServiceKey string, aDone chan bool) Service {
 
 	aTicker := time.NewTicker(HeartbeatFreq)
 
@@ -106,8 +126,15 @@ func NewFleetService(logger *zap.Logger, auth mainflux.AuthServiceClient, agentR
 		auth:                 auth,
 		agentRepo:            agentRepo,
 		agentGroupRepository: agentGroupRepository,
+		policyRolloutRepo:    policyRolloutRepo,
+		enrollmentTokenRepo:  enrollmentTokenRepo,
+		agentRPCRepo:         agentRPCRepo,
+		versionPolicyRepo:    versionPolicyRepo,
+		diagnosticsRepo:      diagnosticsRepo,
 		agentComms:           agentComms,
 		mfsdk:                mfsdk,
+		thingKeys:            thingKeys,
+		mfServiceKey:         mfServiceKey,
 		aTicker:              aTicker,
 		aDone:                aDone,
 	}
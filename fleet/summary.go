@@ -0,0 +1,168 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	SummaryKindState          = "state"
+	SummaryKindAgentVersion   = "agent_version"
+	SummaryKindBackendVersion = "backend_version"
+	SummaryKindBackendState   = "backend_state"
+	SummaryKindPolicyState    = "policy_state"
+	// SummaryKindFailingPolicyAgents counts the agents with at least one policy failing to apply
+	SummaryKindFailingPolicyAgents = "failing_policy_agents"
+
+	// FleetSummaryCheckFreq is how often the fleet summary of all the owners is exported as metrics
+	FleetSummaryCheckFreq = time.Minute
+	// FleetSummaryFailingAgentsLimit is how many agents with failing policies a summary lists
+	FleetSummaryFailingAgentsLimit = 100
+)
+
+// FleetSummary counts the agents of an owner by state, version, and by the backend and policy states of their last
+// heartbeat
+type FleetSummary struct {
+	Total  int
+	States map[string]int
+	// AgentVersions counts the agents by orb-agent version, empty for the ones which did not report it yet
+	AgentVersions map[string]int
+	// BackendVersions counts the agents by backend name and version
+	BackendVersions map[string]map[string]int
+	// BackendStates counts the agents by backend name and running status
+	BackendStates map[string]map[string]int
+	// PolicyStates counts the policies applied by the agents by state
+	PolicyStates map[string]int
+	// Groups sizes, only summarized for an owner
+	Groups []GroupSize
+	// FailingPolicyAgentsTotal is the number of agents with failing policies, of which FailingPolicyAgents lists up to
+	// FleetSummaryFailingAgentsLimit
+	FailingPolicyAgentsTotal int
+	FailingPolicyAgents      []FailingPolicyAgent
+}
+
+// NewFleetSummary returns an empty summary, ready to count
+func NewFleetSummary() FleetSummary {
+	return FleetSummary{
+		States:          make(map[string]int),
+		AgentVersions:   make(map[string]int),
+		BackendVersions: make(map[string]map[string]int),
+		BackendStates:   make(map[string]map[string]int),
+		PolicyStates:    make(map[string]int),
+	}
+}
+
+// Add counts agents of a summary kind, backend is only set for the backend kinds
+func (s *FleetSummary) Add(kind string, backend string, value string, count int) {
+	switch kind {
+	case SummaryKindState:
+		s.States[value] += count
+		s.Total += count
+	case SummaryKindAgentVersion:
+		s.AgentVersions[value] += count
+	case SummaryKindBackendVersion:
+		if s.BackendVersions[backend] == nil {
+			s.BackendVersions[backend] = make(map[string]int)
+		}
+		s.BackendVersions[backend][value] += count
+	case SummaryKindBackendState:
+		if s.BackendStates[backend] == nil {
+			s.BackendStates[backend] = make(map[string]int)
+		}
+		s.BackendStates[backend][value] += count
+	case SummaryKindPolicyState:
+		s.PolicyStates[value] += count
+	case SummaryKindFailingPolicyAgents:
+		s.FailingPolicyAgentsTotal += count
+	}
+}
+
+// GroupSize is the number of agents of a group
+type GroupSize struct {
+	GroupID   string
+	GroupName string
+	Total     int
+	Online    int
+}
+
+// FailingPolicyAgent is an agent with policies failing to apply
+type FailingPolicyAgent struct {
+	AgentID   string
+	AgentName string
+	Policies  []FailingPolicy
+}
+
+type FailingPolicy struct {
+	PolicyID   string
+	PolicyName string
+	Error      string
+}
+
+// summaryGaugeLabels identifies a gauge value by its summary kind, backend and value labels
+type summaryGaugeLabels struct {
+	kind    string
+	backend string
+	value   string
+}
+
+// MonitorFleetSummary exports the fleet summary of all the owners every freq until the context is done
+func MonitorFleetSummary(ctx context.Context, logger *zap.Logger, agentRepo AgentRepository, gauge metrics.Gauge, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	// labels missing from the latest summary, such as a state every agent left or a retired backend version, are
+	// reset to zero so the dashboards stop showing their last count
+	measured := make(map[summaryGaugeLabels]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			summary, err := agentRepo.RetrieveSummary(ctx, "", nil)
+			if err != nil {
+				logger.Error("failed to summarize the fleet", zap.Error(err))
+				continue
+			}
+			current := make(map[summaryGaugeLabels]float64)
+			for state, count := range summary.States {
+				current[summaryGaugeLabels{kind: SummaryKindState, value: state}] = float64(count)
+			}
+			for v, count := range summary.AgentVersions {
+				current[summaryGaugeLabels{kind: SummaryKindAgentVersion, value: v}] = float64(count)
+			}
+			for backend, versions := range summary.BackendVersions {
+				for v, count := range versions {
+					current[summaryGaugeLabels{kind: SummaryKindBackendVersion, backend: backend, value: v}] = float64(count)
+				}
+			}
+			for backend, states := range summary.BackendStates {
+				for state, count := range states {
+					current[summaryGaugeLabels{kind: SummaryKindBackendState, backend: backend, value: state}] = float64(count)
+				}
+			}
+			for state, count := range summary.PolicyStates {
+				current[summaryGaugeLabels{kind: SummaryKindPolicyState, value: state}] = float64(count)
+			}
+			current[summaryGaugeLabels{kind: SummaryKindFailingPolicyAgents}] = float64(summary.FailingPolicyAgentsTotal)
+
+			for l, count := range current {
+				gauge.With("kind", l.kind, "backend", l.backend, "value", l.value).Set(count)
+			}
+			for l := range measured {
+				if _, ok := current[l]; !ok {
+					gauge.With("kind", l.kind, "backend", l.backend, "value", l.value).Set(0)
+				}
+			}
+			measured = make(map[summaryGaugeLabels]bool, len(current))
+			for l := range current {
+				measured[l] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// saveSummaryAgents saves an online agent running pktvisor with a failing policy, and an offline one
+func saveSummaryAgents(t *testing.T, agentRepo fleet.AgentRepository) {
+	t.Helper()
+	failing := newVersionedAgent(t, "summary-failing", "0.22.0", "4.3.0")
+	failing.State = fleet.Online
+	failing.AgentTags = types.Tags{"region": "eu"}
+	failing.LastHBData = types.Metadata{
+		"backend_state": map[string]fleet.BackendStateInfo{"pktvisor": {State: "running"}},
+		"policy_state": map[string]fleet.PolicyStateInfo{
+			"policy-1": {Name: "dns", State: "running"},
+			"policy-2": {Name: "net", State: fleet.PolicyFailedToApply, Error: "no tap"},
+		},
+	}
+	offline := newVersionedAgent(t, "summary-offline", "0.21.0", "")
+	offline.State = fleet.Offline
+	offline.AgentTags = types.Tags{"region": "us"}
+
+	for _, a := range []fleet.Agent{failing, offline} {
+		require.Nil(t, agentRepo.Save(context.Background(), a), "unexpected error saving agent")
+	}
+}
+
+func TestViewFleetSummary(t *testing.T) {
+	svc, agentRepo := newRolloutService(t, 0)
+	saveSummaryAgents(t, agentRepo)
+
+	cases := map[string]struct {
+		token   string
+		tags    types.Tags
+		total   int
+		failing int
+		err     error
+	}{
+		"summarize all the agents": {
+			token:   token,
+			total:   2,
+			failing: 1,
+			err:     nil,
+		},
+		"summarize the agents matching tags": {
+			token:   token,
+			tags:    types.Tags{"region": "us"},
+			total:   1,
+			failing: 0,
+			err:     nil,
+		},
+		"summarize with wrong credentials": {
+			token: invalidToken,
+			err:   fleet.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			summary, err := svc.ViewFleetSummary(context.Background(), tc.token, tc.tags)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.total, summary.Total, fmt.Sprintf("%s: expected %d agents got %d", desc, tc.total, summary.Total))
+			assert.Equal(t, tc.failing, summary.FailingPolicyAgentsTotal, fmt.Sprintf("%s: expected %d failing agents got %d", desc, tc.failing, summary.FailingPolicyAgentsTotal))
+		})
+	}
+
+	summary, err := svc.ViewFleetSummary(context.Background(), token, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, map[string]int{"online": 1, "offline": 1}, summary.States)
+	assert.Equal(t, map[string]int{"0.22.0": 1, "0.21.0": 1}, summary.AgentVersions)
+	assert.Equal(t, map[string]map[string]int{"pktvisor": {"4.3.0": 1}}, summary.BackendVersions)
+	assert.Equal(t, map[string]map[string]int{"pktvisor": {"running": 1}}, summary.BackendStates)
+	assert.Equal(t, map[string]int{"running": 1, fleet.PolicyFailedToApply: 1}, summary.PolicyStates)
+	require.Len(t, summary.FailingPolicyAgents, 1, fmt.Sprintf("expected 1 failing agent got %d", len(summary.FailingPolicyAgents)))
+	expected := []fleet.FailingPolicy{{PolicyID: "policy-2", PolicyName: "net", Error: "no tap"}}
+	assert.Equal(t, expected, summary.FailingPolicyAgents[0].Policies, fmt.Sprintf("expected %v got %v", expected, summary.FailingPolicyAgents[0].Policies))
+}
+
+func TestMonitorFleetSummary(t *testing.T) {
+	_, agentRepo := newRolloutService(t, 0)
+	saveSummaryAgents(t, agentRepo)
+
+	gauge := recordingGauge{mu: &sync.Mutex{}, values: make(map[string]float64)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	fleet.MonitorFleetSummary(ctx, zap.NewNop(), agentRepo, gauge, 5*time.Millisecond)
+
+	expected := map[string]float64{
+		"kind,state,backend,,value,online":                  1,
+		"kind,state,backend,,value,offline":                 1,
+		"kind,agent_version,backend,,value,0.22.0":          1,
+		"kind,agent_version,backend,,value,0.21.0":          1,
+		"kind,backend_version,backend,pktvisor,value,4.3.0": 1,
+		"kind,backend_state,backend,pktvisor,value,running": 1,
+		"kind,policy_state,backend,,value,running":          1,
+		"kind,policy_state,backend,,value,failed_to_apply":  1,
+		"kind,failing_policy_agents,backend,,value,":        1,
+	}
+	gauge.mu.Lock()
+	defer gauge.mu.Unlock()
+	assert.Equal(t, expected, gauge.values, fmt.Sprintf("expected %v got %v", expected, gauge.values))
+}
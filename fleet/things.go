@@ -0,0 +1,68 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+// ThingKeyService changes the key of a Mainflux Thing, which the Mainflux SDK does not support
+type ThingKeyService interface {
+	// UpdateThingKey replaces the key of a Thing, revoking the previous one
+	UpdateThingKey(token string, thingID string, key string) error
+}
+
+var _ ThingKeyService = (*thingKeyService)(nil)
+
+type thingKeyService struct {
+	thingsURL string
+	client    *http.Client
+}
+
+func (s thingKeyService) UpdateThingKey(token string, thingID string, key string) error {
+	body, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/things/%s/key", s.thingsURL, thingID)
+	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(ErrThings, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.Wrap(errThingNotFound, errors.New(resp.Status))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.Wrap(errors.ErrUnauthorizedAccess, errors.New(resp.Status))
+	default:
+		return errors.Wrap(ErrThings, errors.New(resp.Status))
+	}
+}
+
+func NewThingKeyService(thingsURL string) ThingKeyService {
+	return &thingKeyService{
+		thingsURL: strings.TrimSuffix(thingsURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 type MFSDKConfig struct {
 	ThingsURL string `mapstructure:"things_url"`
+	// ServiceKey is the key of the Mainflux account the services provision things with, on behalf of their owners
+	ServiceKey string `mapstructure:"service_key"`
 }
 
 type GRPCConfig struct {
@@ -86,6 +88,7 @@ func LoadMFSDKConfig(prefix string) MFSDKConfig {
 	cfg.SetEnvPrefix(fmt.Sprintf("%s_sdk", prefix))
 
 	cfg.SetDefault("things_url", "http://localhost")
+	cfg.SetDefault("service_key", "")
 
 	cfg.AllowEmptyEnv(true)
 	cfg.AutomaticEnv()
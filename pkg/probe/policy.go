@@ -0,0 +1,255 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+// Package probe describes the synthetic checks of a probe policy, shared by the
+// policies service validating them and the agent running them.
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+const (
+	CheckDNS  = "dns"
+	CheckHTTP = "http"
+	CheckTCP  = "tcp"
+
+	DefaultInterval = 60 * time.Second
+	DefaultTimeout  = 5 * time.Second
+	MinInterval     = time.Second
+)
+
+// ErrInvalidPolicy indicates a probe policy with malformed or inconsistent checks
+var ErrInvalidPolicy = errors.New("invalid probe policy")
+
+// DNSQueryTypes are the record types a dns check may query
+var DNSQueryTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "PTR", "SOA", "SRV", "TXT"}
+
+// DNSRcodes are the response codes a dns check may expect
+var DNSRcodes = []string{"NOERROR", "FORMERR", "SERVFAIL", "NXDOMAIN", "NOTIMP", "REFUSED"}
+
+type Policy struct {
+	Checks []Check `json:"checks"`
+}
+
+type Check struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Interval and Timeout are durations such as 30s, defaulting to DefaultInterval and DefaultTimeout
+	Interval string     `json:"interval,omitempty"`
+	Timeout  string     `json:"timeout,omitempty"`
+	DNS      *DNSCheck  `json:"dns,omitempty"`
+	HTTP     *HTTPCheck `json:"http,omitempty"`
+	TCP      *TCPCheck  `json:"tcp,omitempty"`
+}
+
+type DNSCheck struct {
+	// Server is the resolver address, port 53 if omitted
+	Server string `json:"server"`
+	Query  string `json:"query"`
+	// QType defaults to A
+	QType string `json:"qtype,omitempty"`
+	// Protocol is udp or tcp, defaulting to udp
+	Protocol string `json:"protocol,omitempty"`
+	// ExpectRcode defaults to NOERROR
+	ExpectRcode string `json:"expect_rcode,omitempty"`
+}
+
+type HTTPCheck struct {
+	URL string `json:"url"`
+	// ExpectStatus are the accepted status codes, any 2xx if empty
+	ExpectStatus       []int `json:"expect_status,omitempty"`
+	InsecureSkipVerify bool  `json:"insecure_skip_verify,omitempty"`
+}
+
+type TCPCheck struct {
+	Address string `json:"address"`
+}
+
+// Decode reads a probe policy from its metadata, rejecting unknown fields so typos are not silently ignored
+func Decode(data interface{}) (Policy, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Policy{}, errors.Wrap(ErrInvalidPolicy, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var p Policy
+	if err := decoder.Decode(&p); err != nil {
+		return Policy{}, errors.Wrap(ErrInvalidPolicy, err)
+	}
+	return p, nil
+}
+
+// Validate checks every check is complete and consistent. Values holding agent template
+// variables are only checked once rendered, on the agent
+func (p Policy) Validate() error {
+	if len(p.Checks) == 0 {
+		return errors.Wrap(ErrInvalidPolicy, fmt.Errorf("no checks defined"))
+	}
+	names := make(map[string]bool, len(p.Checks))
+	for i, c := range p.Checks {
+		if c.Name == "" {
+			return errors.Wrap(ErrInvalidPolicy, fmt.Errorf("check %d has no name", i))
+		}
+		if names[c.Name] {
+			return errors.Wrap(ErrInvalidPolicy, fmt.Errorf("check name '%s' is duplicated", c.Name))
+		}
+		names[c.Name] = true
+		if err := c.validate(); err != nil {
+			return errors.Wrap(ErrInvalidPolicy, fmt.Errorf("check '%s': %w", c.Name, err))
+		}
+	}
+	return nil
+}
+
+func (c Check) validate() error {
+	interval, err := parseDuration(c.Interval, DefaultInterval)
+	if err != nil {
+		return fmt.Errorf("invalid interval: %w", err)
+	}
+	timeout, err := parseDuration(c.Timeout, DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+	if !templated(c.Interval) && interval < MinInterval {
+		return fmt.Errorf("interval must be at least %s", MinInterval)
+	}
+	if !templated(c.Interval) && !templated(c.Timeout) && (timeout <= 0 || timeout > interval) {
+		return fmt.Errorf("timeout must be positive and not longer than the interval")
+	}
+
+	configured := 0
+	for _, set := range []bool{c.DNS != nil, c.HTTP != nil, c.TCP != nil} {
+		if set {
+			configured++
+		}
+	}
+	if configured != 1 {
+		return fmt.Errorf("exactly one of dns, http or tcp must be configured")
+	}
+	switch c.Type {
+	case CheckDNS:
+		if c.DNS == nil {
+			return fmt.Errorf("dns check without dns configuration")
+		}
+		return c.DNS.validate()
+	case CheckHTTP:
+		if c.HTTP == nil {
+			return fmt.Errorf("http check without http configuration")
+		}
+		return c.HTTP.validate()
+	case CheckTCP:
+		if c.TCP == nil {
+			return fmt.Errorf("tcp check without tcp configuration")
+		}
+		return c.TCP.validate()
+	}
+	return fmt.Errorf("unknown check type '%s'", c.Type)
+}
+
+func (d *DNSCheck) validate() error {
+	if d.Server == "" {
+		return fmt.Errorf("dns server is required")
+	}
+	if d.Query == "" {
+		return fmt.Errorf("dns query is required")
+	}
+	if d.QType != "" && !templated(d.QType) && !contains(DNSQueryTypes, strings.ToUpper(d.QType)) {
+		return fmt.Errorf("unsupported dns query type '%s'", d.QType)
+	}
+	if d.Protocol != "" && !templated(d.Protocol) && d.Protocol != "udp" && d.Protocol != "tcp" {
+		return fmt.Errorf("dns protocol must be udp or tcp")
+	}
+	if d.ExpectRcode != "" && !templated(d.ExpectRcode) && !contains(DNSRcodes, strings.ToUpper(d.ExpectRcode)) {
+		return fmt.Errorf("unsupported dns rcode '%s'", d.ExpectRcode)
+	}
+	return nil
+}
+
+func (h *HTTPCheck) validate() error {
+	if h.URL == "" {
+		return fmt.Errorf("http url is required")
+	}
+	if !templated(h.URL) {
+		u, err := url.Parse(h.URL)
+		if err != nil {
+			return fmt.Errorf("invalid http url: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("http url must be an absolute http or https url")
+		}
+	}
+	for _, status := range h.ExpectStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("invalid expected http status %d", status)
+		}
+	}
+	return nil
+}
+
+func (t *TCPCheck) validate() error {
+	if t.Address == "" {
+		return fmt.Errorf("tcp address is required")
+	}
+	if !templated(t.Address) {
+		if _, _, err := net.SplitHostPort(t.Address); err != nil {
+			return fmt.Errorf("tcp address must be host:port: %w", err)
+		}
+	}
+	return nil
+}
+
+// IntervalDuration returns the interval between runs of the check
+func (c Check) IntervalDuration() time.Duration {
+	d, _ := parseDuration(c.Interval, DefaultInterval)
+	return d
+}
+
+// TimeoutDuration returns how long a run of the check may take
+func (c Check) TimeoutDuration() time.Duration {
+	d, _ := parseDuration(c.Timeout, DefaultTimeout)
+	return d
+}
+
+// Target returns what the check probes, for labelling its results
+func (c Check) Target() string {
+	switch {
+	case c.DNS != nil:
+		return c.DNS.Query
+	case c.HTTP != nil:
+		return c.HTTP.URL
+	case c.TCP != nil:
+		return c.TCP.Address
+	}
+	return ""
+}
+
+func parseDuration(value string, def time.Duration) (time.Duration, error) {
+	if value == "" || templated(value) {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func templated(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
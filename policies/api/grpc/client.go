@@ -46,7 +46,8 @@ func (client grpcClient) RetrieveDatasetsByGroups(ctx context.Context, in *pb.Da
 	ir := res.(datasetListRes)
 	dsList := make([]*pb.DatasetRes, len(ir.datasets))
 	for i, ds := range ir.datasets {
-		dsList[i] = &pb.DatasetRes{Id: ds.id, SinkIds: ds.sinkIDs, PolicyId: ds.policyID, AgentGroupId: ds.agentGroupID}
+		dsList[i] = &pb.DatasetRes{Id: ds.id, SinkIds: ds.sinkIDs, PolicyId: ds.policyID, AgentGroupId: ds.agentGroupID(),
+			AgentGroupIds: ds.agentGroupIDs, ExcludedAgentIds: ds.excludedAgentIDs}
 	}
 	return &pb.DatasetsRes{DatasetList: dsList}, nil
 
@@ -76,6 +77,7 @@ func (client grpcClient) RetrievePoliciesByGroups(ctx context.Context, in *pb.Po
 	ar := accessByGroupIDReq{
 		GroupIDs: in.GroupIDs,
 		OwnerID:  in.OwnerID,
+		AgentID:  in.AgentID,
 	}
 	res, err := client.retrievePoliciesByGroups(ctx, ar)
 	if err != nil {
@@ -87,14 +89,15 @@ func (client grpcClient) RetrievePoliciesByGroups(ctx context.Context, in *pb.Po
 	plist := make([]*pb.PolicyInDSRes, len(ir.policies))
 	for i, p := range ir.policies {
 		plist[i] = &pb.PolicyInDSRes{
-			Id:           p.id,
-			Name:         p.name,
-			Data:         p.data,
-			Backend:      p.backend,
-			Version:      p.version,
-			DatasetId:    p.datasetID,
-			AgentGroupId: p.agentGroupID,
-			Format:       p.format,
+			Id:               p.id,
+			Name:             p.name,
+			Data:             p.data,
+			Backend:          p.backend,
+			Version:          p.version,
+			DatasetId:        p.datasetID,
+			AgentGroupId:     p.agentGroupID,
+			Format:           p.format,
+			ExcludedAgentIds: p.excludedAgentIDs,
 		}
 	}
 	return &pb.PolicyInDSListRes{Policies: plist}, nil
@@ -114,10 +117,12 @@ func (client grpcClient) RetrieveDataset(ctx context.Context, in *pb.DatasetByID
 	}
 	ir := res.(datasetRes)
 	return &pb.DatasetRes{
-		Id:           ir.id,
-		AgentGroupId: ir.agentGroupID,
-		PolicyId:     ir.policyID,
-		SinkIds:      ir.sinkIDs,
+		Id:               ir.id,
+		AgentGroupId:     ir.agentGroupID(),
+		AgentGroupIds:    ir.agentGroupIDs,
+		PolicyId:         ir.policyID,
+		SinkIds:          ir.sinkIDs,
+		ExcludedAgentIds: ir.excludedAgentIDs,
 	}, nil
 }
 
@@ -161,7 +166,7 @@ func encodeRetrievePolicyRequest(_ context.Context, grpcReq interface{}) (interf
 
 func encodeRetrievePoliciesByGroupsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(accessByGroupIDReq)
-	return &pb.PoliciesByGroupsReq{GroupIDs: req.GroupIDs, OwnerID: req.OwnerID}, nil
+	return &pb.PoliciesByGroupsReq{GroupIDs: req.GroupIDs, OwnerID: req.OwnerID, AgentID: req.AgentID}, nil
 }
 
 func encodeRetrieveDatasetRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -179,25 +184,35 @@ func decodePolicyResponse(_ context.Context, grpcRes interface{}) (interface{},
 func decodeDatasetResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(*pb.DatasetRes)
 	return datasetRes{
-		id:           res.GetId(),
-		agentGroupID: res.GetAgentGroupId(),
-		policyID:     res.GetPolicyId(),
-		sinkIDs:      res.GetSinkIds(),
+		id:               res.GetId(),
+		agentGroupIDs:    datasetGroupIDs(res),
+		policyID:         res.GetPolicyId(),
+		sinkIDs:          res.GetSinkIds(),
+		excludedAgentIDs: res.GetExcludedAgentIds(),
 	}, nil
 }
 
+// datasetGroupIDs reads the groups of the dataset, servers not sending the group list yet only send its first group
+func datasetGroupIDs(res *pb.DatasetRes) []string {
+	if len(res.GetAgentGroupIds()) > 0 || res.GetAgentGroupId() == "" {
+		return res.GetAgentGroupIds()
+	}
+	return []string{res.GetAgentGroupId()}
+}
+
 func decodePolicyListResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(*pb.PolicyInDSListRes)
 	policies := make([]policyInDSRes, len(res.Policies))
 	for i, p := range res.Policies {
 		policies[i] = policyInDSRes{id: p.GetId(),
-			name:         p.GetName(),
-			data:         p.GetData(),
-			version:      p.GetVersion(),
-			backend:      p.GetBackend(),
-			datasetID:    p.GetDatasetId(),
-			agentGroupID: p.GetAgentGroupId(),
-			format:       p.GetFormat(),
+			name:             p.GetName(),
+			data:             p.GetData(),
+			version:          p.GetVersion(),
+			backend:          p.GetBackend(),
+			datasetID:        p.GetDatasetId(),
+			agentGroupID:     p.GetAgentGroupId(),
+			format:           p.GetFormat(),
+			excludedAgentIDs: p.GetExcludedAgentIds(),
 		}
 	}
 	return policyInDSListRes{policies: policies}, nil
@@ -64,7 +64,7 @@ func retrievePoliciesByGroupsEndpoint(svc policies.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		plist, err := svc.ListPoliciesByGroupIDInternal(ctx, req.GroupIDs, req.OwnerID)
+		plist, err := svc.ListPoliciesByGroupIDInternal(ctx, req.GroupIDs, req.OwnerID, req.AgentID)
 		if err != nil {
 			return policyInDSListRes{}, err
 		}
@@ -75,14 +75,15 @@ func retrievePoliciesByGroupsEndpoint(svc policies.Service) endpoint.Endpoint {
 				return policyInDSListRes{}, err
 			}
 			policies[i] = policyInDSRes{
-				id:           policy.ID,
-				name:         policy.Name.String(),
-				backend:      policy.Backend,
-				version:      policy.Version,
-				format:       format,
-				data:         data,
-				datasetID:    policy.DatasetID,
-				agentGroupID: policy.AgentGroupID,
+				id:               policy.ID,
+				name:             policy.Name.String(),
+				backend:          policy.Backend,
+				version:          policy.Version,
+				format:           format,
+				data:             data,
+				datasetID:        policy.DatasetID,
+				agentGroupID:     policy.AgentGroupID,
+				excludedAgentIDs: policy.ExcludedAgentIDs,
 			}
 		}
 
@@ -101,10 +102,11 @@ func retrieveDatasetEnpoint(svc policies.Service) endpoint.Endpoint {
 			return nil, err
 		}
 		return datasetRes{
-			id:           dataset.ID,
-			agentGroupID: dataset.AgentGroupID,
-			policyID:     dataset.PolicyID,
-			sinkIDs:      *dataset.SinkIDs,
+			id:               dataset.ID,
+			agentGroupIDs:    dataset.AgentGroupIDs,
+			policyID:         dataset.PolicyID,
+			sinkIDs:          *dataset.SinkIDs,
+			excludedAgentIDs: datasetExclusions(dataset),
 		}, nil
 	}
 }
@@ -123,13 +125,21 @@ func retrieveDatasetsByGroupsEndpoint(svc policies.Service) endpoint.Endpoint {
 		datasets := make([]datasetRes, len(dsList))
 		for i, ds := range dsList {
 			datasets[i] = datasetRes{
-				id:           ds.ID,
-				agentGroupID: ds.AgentGroupID,
-				sinkIDs:      *ds.SinkIDs,
-				policyID:     ds.PolicyID,
+				id:               ds.ID,
+				agentGroupIDs:    ds.AgentGroupIDs,
+				sinkIDs:          *ds.SinkIDs,
+				policyID:         ds.PolicyID,
+				excludedAgentIDs: datasetExclusions(ds),
 			}
 		}
 
 		return datasetListRes{datasets: datasets}, nil
 	}
 }
+
+func datasetExclusions(ds policies.Dataset) []string {
+	if ds.ExcludedAgentIDs == nil {
+		return nil
+	}
+	return *ds.ExcludedAgentIDs
+}
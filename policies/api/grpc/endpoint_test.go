@@ -75,7 +75,7 @@ func TestRetrievePoliciesByGroups(t *testing.T) {
 		code    codes.Code
 	}{
 		"retrieve existing policy by group": {
-			ids:     []string{dataset.AgentGroupID},
+			ids:     []string{dataset.AgentGroupIDs[0]},
 			results: 1,
 			code:    codes.OK,
 		},
@@ -26,6 +26,8 @@ func (req accessByIDReq) validate() error {
 type accessByGroupIDReq struct {
 	GroupIDs []string
 	OwnerID  string
+	// AgentID leaves out the datasets excluding the agent, when set
+	AgentID string
 }
 
 func (req accessByGroupIDReq) validate() error {
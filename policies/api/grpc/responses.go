@@ -18,14 +18,15 @@ type policyRes struct {
 }
 
 type policyInDSRes struct {
-	id           string
-	name         string
-	backend      string
-	version      int32
-	data         []byte
-	datasetID    string
-	agentGroupID string
-	format       string
+	id               string
+	name             string
+	backend          string
+	version          int32
+	data             []byte
+	datasetID        string
+	agentGroupID     string
+	format           string
+	excludedAgentIDs []string
 }
 
 type policyInDSListRes struct {
@@ -33,10 +34,19 @@ type policyInDSListRes struct {
 }
 
 type datasetRes struct {
-	id           string
-	agentGroupID string
-	policyID     string
-	sinkIDs      []string
+	id               string
+	agentGroupIDs    []string
+	policyID         string
+	sinkIDs          []string
+	excludedAgentIDs []string
+}
+
+// agentGroupID is the single group sent to clients not reading the group list yet
+func (res datasetRes) agentGroupID() string {
+	if len(res.agentGroupIDs) == 0 {
+		return ""
+	}
+	return res.agentGroupIDs[0]
 }
 
 type datasetListRes struct {
@@ -100,7 +100,7 @@ func decodeRetrievePolicyRequest(_ context.Context, grpcReq interface{}) (interf
 
 func decodeRetrievePoliciesByGroupRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.PoliciesByGroupsReq)
-	return accessByGroupIDReq{GroupIDs: req.GroupIDs, OwnerID: req.OwnerID}, nil
+	return accessByGroupIDReq{GroupIDs: req.GroupIDs, OwnerID: req.OwnerID, AgentID: req.AgentID}, nil
 }
 
 func decodeRetrieveDatasetRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -135,13 +135,14 @@ func encodePolicyInDSListResponse(_ context.Context, grpcRes interface{}) (inter
 	for i, p := range res.policies {
 		l.Debug("policy format", zap.String("format", p.format), zap.String("policy_id", p.id))
 		plist[i] = &pb.PolicyInDSRes{Id: p.id,
-			Name:         p.name,
-			Data:         p.data,
-			Backend:      p.backend,
-			Version:      p.version,
-			DatasetId:    p.datasetID,
-			AgentGroupId: p.agentGroupID,
-			Format:       p.format,
+			Name:             p.name,
+			Data:             p.data,
+			Backend:          p.backend,
+			Version:          p.version,
+			DatasetId:        p.datasetID,
+			AgentGroupId:     p.agentGroupID,
+			Format:           p.format,
+			ExcludedAgentIds: p.excludedAgentIDs,
 		}
 	}
 	return &pb.PolicyInDSListRes{Policies: plist}, nil
@@ -151,10 +152,12 @@ func encodeDatasetResponse(_ context.Context, grpcRes interface{}) (interface{},
 	res := grpcRes.(datasetRes)
 
 	return &pb.DatasetRes{
-		Id:           res.id,
-		AgentGroupId: res.agentGroupID,
-		PolicyId:     res.policyID,
-		SinkIds:      res.sinkIDs,
+		Id:               res.id,
+		AgentGroupId:     res.agentGroupID(),
+		AgentGroupIds:    res.agentGroupIDs,
+		PolicyId:         res.policyID,
+		SinkIds:          res.sinkIDs,
+		ExcludedAgentIds: res.excludedAgentIDs,
 	}, nil
 }
 
@@ -163,7 +166,8 @@ func encodeDatasetListResponse(_ context.Context, grpcRes interface{}) (interfac
 
 	dsList := make([]*pb.DatasetRes, len(res.datasets))
 	for i, ds := range res.datasets {
-		dsList[i] = &pb.DatasetRes{Id: ds.id, PolicyId: ds.policyID, AgentGroupId: ds.agentGroupID, SinkIds: ds.sinkIDs}
+		dsList[i] = &pb.DatasetRes{Id: ds.id, PolicyId: ds.policyID, AgentGroupId: ds.agentGroupID(), AgentGroupIds: ds.agentGroupIDs,
+			SinkIds: ds.sinkIDs, ExcludedAgentIds: ds.excludedAgentIDs}
 	}
 	return &pb.DatasetsRes{DatasetList: dsList}, nil
 }
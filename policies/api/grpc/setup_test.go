@@ -75,10 +75,10 @@ func newService(tokens map[string]string) policies.Service {
 	gID, _ := uuid.NewV4()
 	gname, _ := types.NewIdentifier("testdataset")
 	dataset = policies.Dataset{
-		Name:         gname,
-		MFOwnerID:    oID.String(),
-		AgentGroupID: gID.String(),
-		PolicyID:     policyid,
+		Name:          gname,
+		MFOwnerID:     oID.String(),
+		AgentGroupIDs: []string{gID.String()},
+		PolicyID:      policyid,
 	}
 	datasetid, _ := repo.SaveDataset(context.Background(), dataset)
 	dataset.ID = datasetid
@@ -96,6 +96,21 @@ func viewPolicyEndpoint(svc policies.Service) endpoint.Endpoint {
 	}
 }
 
+func viewPolicyStatusEndpoint(svc policies.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		status, err := svc.ViewPolicyStatus(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toPolicyStatusRes(status), nil
+	}
+}
+
 func listPoliciesEndpoint(svc policies.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(listResourcesReq)
@@ -159,6 +174,7 @@ func editPoliciyEndpoint(svc policies.Service) endpoint.Endpoint {
 			Policy:      req.Policy,
 			PolicyData:  req.PolicyData,
 			Format:      req.Format,
+			Rollout:     req.Rollout,
 		}
 
 		res, err := svc.EditPolicy(ctx, req.token, plcy)
@@ -208,11 +224,13 @@ func addDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		d := policies.Dataset{
-			Name:         nID,
-			AgentGroupID: req.AgentGroupID,
-			PolicyID:     req.PolicyID,
-			SinkIDs:      &req.SinkIDs,
-			Tags:         req.Tags,
+			Name:             nID,
+			AgentGroupIDs:    req.groupIDs(),
+			PolicyID:         req.PolicyID,
+			SinkIDs:          &req.SinkIDs,
+			Tags:             req.Tags,
+			ExcludedAgentIDs: &req.ExcludedAgentIDs,
+			Schedule:         req.Schedule,
 		}
 
 		saved, err := svc.AddDataset(ctx, req.token, d)
@@ -221,16 +239,20 @@ func addDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		res := datasetRes{
-			ID:           saved.ID,
-			Name:         saved.Name.String(),
-			Valid:        saved.Valid,
-			AgentGroupID: saved.AgentGroupID,
-			PolicyID:     saved.PolicyID,
-			SinkIDs:      *saved.SinkIDs,
-			Metadata:     saved.Metadata,
-			TsCreated:    saved.Created,
-			Tags:         saved.Tags,
-			created:      true,
+			ID:               saved.ID,
+			Name:             saved.Name.String(),
+			Valid:            saved.Valid,
+			AgentGroupID:     firstAgentGroupID(saved),
+			AgentGroupIDs:    agentGroupIDs(saved),
+			PolicyID:         saved.PolicyID,
+			SinkIDs:          *saved.SinkIDs,
+			Metadata:         saved.Metadata,
+			TsCreated:        saved.Created,
+			Tags:             saved.Tags,
+			ExcludedAgentIDs: excludedAgentIDs(saved),
+			Schedule:         saved.Schedule,
+			ScheduleState:    saved.ScheduleState(),
+			created:          true,
 		}
 
 		return res, nil
@@ -253,10 +275,13 @@ func editDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		dataset := policies.Dataset{
-			Name:    nameID,
-			ID:      req.id,
-			Tags:    req.Tags,
-			SinkIDs: req.SinkIDs,
+			Name:             nameID,
+			ID:               req.id,
+			Tags:             req.Tags,
+			AgentGroupIDs:    req.groupIDs(),
+			SinkIDs:          req.SinkIDs,
+			ExcludedAgentIDs: req.ExcludedAgentIDs,
+			Schedule:         req.Schedule,
 		}
 
 		ds, err := svc.EditDataset(ctx, req.token, dataset)
@@ -265,15 +290,19 @@ func editDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		res := datasetRes{
-			ID:           ds.ID,
-			Name:         ds.Name.String(),
-			Valid:        ds.Valid,
-			AgentGroupID: ds.AgentGroupID,
-			PolicyID:     ds.PolicyID,
-			SinkIDs:      *ds.SinkIDs,
-			Metadata:     ds.Metadata,
-			TsCreated:    ds.Created,
-			Tags:         ds.Tags,
+			ID:               ds.ID,
+			Name:             ds.Name.String(),
+			Valid:            ds.Valid,
+			AgentGroupID:     firstAgentGroupID(ds),
+			AgentGroupIDs:    agentGroupIDs(ds),
+			PolicyID:         ds.PolicyID,
+			SinkIDs:          *ds.SinkIDs,
+			Metadata:         ds.Metadata,
+			TsCreated:        ds.Created,
+			Tags:             ds.Tags,
+			ExcludedAgentIDs: excludedAgentIDs(ds),
+			Schedule:         ds.Schedule,
+			ScheduleState:    ds.ScheduleState(),
 		}
 
 		return res, nil
@@ -347,11 +376,13 @@ func validateDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		d := policies.Dataset{
-			Name:         nID,
-			AgentGroupID: req.AgentGroupID,
-			PolicyID:     req.PolicyID,
-			SinkIDs:      &req.SinkIDs,
-			Tags:         req.Tags,
+			Name:             nID,
+			AgentGroupIDs:    req.groupIDs(),
+			PolicyID:         req.PolicyID,
+			SinkIDs:          &req.SinkIDs,
+			Tags:             req.Tags,
+			ExcludedAgentIDs: &req.ExcludedAgentIDs,
+			Schedule:         req.Schedule,
 		}
 
 		validated, err := svc.ValidateDataset(ctx, req.token, d)
@@ -360,12 +391,13 @@ func validateDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		res := validateDatasetRes{
-			Name:         validated.Name.String(),
-			Valid:        true,
-			Tags:         validated.Tags,
-			AgentGroupID: validated.AgentGroupID,
-			PolicyID:     validated.PolicyID,
-			SinkIDs:      *validated.SinkIDs,
+			Name:          validated.Name.String(),
+			Valid:         true,
+			Tags:          validated.Tags,
+			AgentGroupID:  firstAgentGroupID(validated),
+			AgentGroupIDs: agentGroupIDs(validated),
+			PolicyID:      validated.PolicyID,
+			SinkIDs:       *validated.SinkIDs,
 		}
 
 		return res, nil
@@ -385,12 +417,16 @@ func viewDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 
 		res := datasetRes{
-			ID:           dataset.ID,
-			Name:         dataset.Name.String(),
-			PolicyID:     dataset.PolicyID,
-			AgentGroupID: dataset.AgentGroupID,
-			Valid:        dataset.Valid,
-			TsCreated:    dataset.Created,
+			ID:               dataset.ID,
+			Name:             dataset.Name.String(),
+			PolicyID:         dataset.PolicyID,
+			AgentGroupID:     firstAgentGroupID(dataset),
+			AgentGroupIDs:    agentGroupIDs(dataset),
+			Valid:            dataset.Valid,
+			TsCreated:        dataset.Created,
+			ExcludedAgentIDs: excludedAgentIDs(dataset),
+			Schedule:         dataset.Schedule,
+			ScheduleState:    dataset.ScheduleState(),
 		}
 		if dataset.SinkIDs != nil {
 			res.SinkIDs = *dataset.SinkIDs
@@ -401,6 +437,21 @@ func viewDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 	}
 }
 
+func viewDatasetPolicyStatusEndpoint(svc policies.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewResourceReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		status, err := svc.ViewDatasetPolicyStatus(ctx, req.token, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return toPolicyStatusRes(status), nil
+	}
+}
+
 func listDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(listResourcesReq)
@@ -425,13 +476,17 @@ func listDatasetEndpoint(svc policies.Service) endpoint.Endpoint {
 		}
 		for _, dataset := range page.Datasets {
 			view := datasetRes{
-				ID:           dataset.ID,
-				Name:         dataset.Name.String(),
-				PolicyID:     dataset.PolicyID,
-				AgentGroupID: dataset.AgentGroupID,
-				TsCreated:    dataset.Created,
-				Valid:        dataset.Valid,
-				Tags:         dataset.Tags,
+				ID:               dataset.ID,
+				Name:             dataset.Name.String(),
+				PolicyID:         dataset.PolicyID,
+				AgentGroupID:     firstAgentGroupID(dataset),
+				AgentGroupIDs:    agentGroupIDs(dataset),
+				TsCreated:        dataset.Created,
+				Valid:            dataset.Valid,
+				Tags:             dataset.Tags,
+				ExcludedAgentIDs: excludedAgentIDs(dataset),
+				Schedule:         dataset.Schedule,
+				ScheduleState:    dataset.ScheduleState(),
 			}
 			if dataset.SinkIDs != nil {
 				view.SinkIDs = *dataset.SinkIDs
@@ -471,3 +526,44 @@ func duplicatePolicyEndpoint(svc policies.Service) endpoint.Endpoint {
 		return res, nil
 	}
 }
+
+// firstAgentGroupID is the group still sent as the single group of the dataset
+func firstAgentGroupID(ds policies.Dataset) string {
+	if len(ds.AgentGroupIDs) == 0 {
+		return ""
+	}
+	return ds.AgentGroupIDs[0]
+}
+
+func agentGroupIDs(ds policies.Dataset) []string {
+	if ds.AgentGroupIDs == nil {
+		return []string{}
+	}
+	return ds.AgentGroupIDs
+}
+
+func excludedAgentIDs(ds policies.Dataset) []string {
+	if ds.ExcludedAgentIDs == nil || *ds.ExcludedAgentIDs == nil {
+		return []string{}
+	}
+	return *ds.ExcludedAgentIDs
+}
+
+func toPolicyStatusRes(status policies.PolicyStatus) policyStatusRes {
+	res := policyStatusRes{
+		PolicyID:    status.PolicyID,
+		DatasetID:   status.DatasetID,
+		Version:     status.Version,
+		Total:       status.Total,
+		States:      status.States,
+		Errors:      []policyStatusErrorRes{},
+		StaleAgents: []stalePolicyAgentRes{},
+	}
+	for _, e := range status.Errors {
+		res.Errors = append(res.Errors, policyStatusErrorRes{Error: e.Error, Count: e.Count})
+	}
+	for _, a := range status.StaleAgents {
+		res.StaleAgents = append(res.StaleAgents, stalePolicyAgentRes{AgentID: a.AgentID, AgentName: a.AgentName, Version: a.Version})
+	}
+	return res
+}
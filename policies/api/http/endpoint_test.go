@@ -848,11 +848,11 @@ func TestDatasetEdition(t *testing.T) {
 
 	sinkIDs := []string{"f5b2d342-211d-a9ab-1233-63199a3fc16f", "03679425-aa69-4574-bf62-e0fe71b80939"}
 	ds := policies.Dataset{
-		Name:         validName,
-		AgentGroupID: "8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db",
-		PolicyID:     policy.ID,
-		SinkIDs:      &sinkIDs,
-		Tags:         map[string]string{"region": "eu", "node_type": "dns"},
+		Name:          validName,
+		AgentGroupIDs: []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db"},
+		PolicyID:      policy.ID,
+		SinkIDs:       &sinkIDs,
+		Tags:          map[string]string{"region": "eu", "node_type": "dns"},
 	}
 	dataset, err := cli.service.AddDataset(context.Background(), token, ds)
 	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
@@ -1101,6 +1101,15 @@ func TestDatasetValidation(t *testing.T) {
 	}
 	invalidNameJson, _ := json.Marshal(invalidNameDataset)
 
+	groupsDataset := dataset
+	groupsDataset.AgentGroupID = ""
+	groupsDataset.AgentGroupIDs = []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db", "b1c1a014-9725-4b7b-abb1-968501190a90"}
+	groupsJson, _ := json.Marshal(groupsDataset)
+
+	noGroupDataset := dataset
+	noGroupDataset.AgentGroupID = ""
+	noGroupJson, _ := json.Marshal(noGroupDataset)
+
 	var (
 		invalidJson      = `{`
 		invalidTagJson   = "{\n    \"name\": \"my-dataset-json\",\n    \"agent_group_id\": \"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db\",\n    \"agent_policy_id\": \"86b7b412-1b7f-f5bc-c78b-f79087d6e49b\",\n    \"sink_ids\": \"f5b2d342-211d-a9ab-1233-63199a3fc16f\"\n,\n    \"tags\": \"invalidTag\"}"
@@ -1121,6 +1130,20 @@ func TestDatasetValidation(t *testing.T) {
 			status:      http.StatusOK,
 			location:    "/policies/dataset/validate",
 		},
+		"Validate a dataset targeting several agent groups": {
+			req:         string(groupsJson),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusOK,
+			location:    "/policies/dataset/validate",
+		},
+		"Validate a dataset without agent groups": {
+			req:         string(noGroupJson),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			location:    "/policies/dataset/validate",
+		},
 		"Validate a invalid yaml": {
 			req:         invalidJson,
 			contentType: contentType,
@@ -1242,6 +1265,80 @@ func TestViewDataset(t *testing.T) {
 
 }
 
+func TestViewPolicyStatus(t *testing.T) {
+	cli := newClientServer(t)
+	policy := createPolicy(t, &cli, "policy")
+
+	groupID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	validName, err := types.NewIdentifier("dataset")
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	dataset, err := cli.service.AddDataset(context.Background(), token, policies.Dataset{
+		Name:          validName,
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policy.ID,
+	})
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	cases := map[string]struct {
+		url    string
+		token  string
+		status int
+		total  int
+	}{
+		"view status of an existing policy": {
+			url:    fmt.Sprintf("%s/policies/agent/%s/status", cli.server.URL, policy.ID),
+			token:  token,
+			status: http.StatusOK,
+			total:  1,
+		},
+		"view status of the policy of an existing dataset": {
+			url:    fmt.Sprintf("%s/policies/dataset/%s/status", cli.server.URL, dataset.ID),
+			token:  token,
+			status: http.StatusOK,
+			total:  1,
+		},
+		"view status of a non-existing policy": {
+			url:    fmt.Sprintf("%s/policies/agent/%s/status", cli.server.URL, "d0967904-8824-4ed1-b11c-9a92f9e4e43c"),
+			token:  token,
+			status: http.StatusNotFound,
+		},
+		"view status of a non-existing dataset": {
+			url:    fmt.Sprintf("%s/policies/dataset/%s/status", cli.server.URL, "d0967904-8824-4ed1-b11c-9a92f9e4e43c"),
+			token:  token,
+			status: http.StatusNotFound,
+		},
+		"view status of a policy with an invalid token": {
+			url:    fmt.Sprintf("%s/policies/agent/%s/status", cli.server.URL, policy.ID),
+			token:  "invalid",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: cli.server.Client(),
+				method: http.MethodGet,
+				url:    tc.url,
+				token:  fmt.Sprintf("Bearer %s", tc.token),
+			}
+			res, err := req.make()
+			require.Nil(t, err, fmt.Sprintf("%s: Unexpected error: %s", desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+			if tc.status != http.StatusOK {
+				return
+			}
+			var body policyStatusRes
+			err = json.NewDecoder(res.Body).Decode(&body)
+			require.Nil(t, err, fmt.Sprintf("%s: Unexpected error: %s", desc, err))
+			assert.Equal(t, policy.ID, body.PolicyID, fmt.Sprintf("%s: expected %s got %s", desc, policy.ID, body.PolicyID))
+			assert.Equal(t, tc.total, body.Total, fmt.Sprintf("%s: expected %d got %d", desc, tc.total, body.Total))
+		})
+	}
+}
+
 func TestListDataset(t *testing.T) {
 	cli := newClientServer(t)
 
@@ -1252,7 +1349,7 @@ func TestListDataset(t *testing.T) {
 			ID:           d.ID,
 			Name:         d.Name.String(),
 			PolicyID:     d.PolicyID,
-			AgentGroupID: d.AgentGroupID,
+			AgentGroupID: d.AgentGroupIDs[0],
 			created:      true,
 		})
 	}
@@ -1532,10 +1629,10 @@ func createDataset(t *testing.T, cli *clientServer, name string) policies.Datase
 	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		ID:           ID.String(),
-		Name:         validName,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
+		ID:            ID.String(),
+		Name:          validName,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
 	}
 
 	res, err := cli.service.AddDataset(context.Background(), token, dataset)
@@ -1572,12 +1669,13 @@ type policiesPageRes struct {
 }
 
 type addDatasetReq struct {
-	Name         string     `json:"name"`
-	AgentGroupID string     `json:"agent_group_id"`
-	PolicyID     string     `json:"agent_policy_id"`
-	SinkIDs      []string   `json:"sink_ids"`
-	Tags         types.Tags `json:"tags"`
-	token        string
+	Name          string     `json:"name"`
+	AgentGroupID  string     `json:"agent_group_id,omitempty"`
+	AgentGroupIDs []string   `json:"agent_group_ids,omitempty"`
+	PolicyID      string     `json:"agent_policy_id"`
+	SinkIDs       []string   `json:"sink_ids"`
+	Tags          types.Tags `json:"tags"`
+	token         string
 }
 
 type datasetRes struct {
@@ -1589,6 +1687,12 @@ type datasetRes struct {
 	created      bool
 }
 
+type policyStatusRes struct {
+	PolicyID string         `json:"policy_id"`
+	Total    int            `json:"total"`
+	States   map[string]int `json:"states"`
+}
+
 type datasetPageRes struct {
 	Total    uint64       `json:"total"`
 	Offset   uint64       `json:"offset"`
@@ -32,6 +32,34 @@ func (l loggingMiddleware) ListDatasetsByGroupIDInternal(ctx context.Context, gr
 	return l.svc.ListDatasetsByGroupIDInternal(ctx, groupIDs, ownerID)
 }
 
+func (l loggingMiddleware) RollbackPolicyInternal(ctx context.Context, ownerID string, policyID string, version int32) (_ policies.Policy, _ []policies.Dataset, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: rollback_policy_internal",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: rollback_policy_internal",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RollbackPolicyInternal(ctx, ownerID, policyID, version)
+}
+
+func (l loggingMiddleware) CheckDatasetSchedules(ctx context.Context) (_ []policies.Dataset, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: check_dataset_schedules",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: check_dataset_schedules",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.CheckDatasetSchedules(ctx)
+}
+
 func (l loggingMiddleware) RemoveAllDatasetsByPolicyIDInternal(ctx context.Context, token string, policyID string) (err error) {
 	defer func(begin time.Time) {
 		if err != nil {
@@ -200,7 +228,7 @@ func (l loggingMiddleware) ViewPolicyByIDInternal(ctx context.Context, policyID
 	return l.svc.ViewPolicyByIDInternal(ctx, policyID, ownerID)
 }
 
-func (l loggingMiddleware) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string) (_ []policies.PolicyInDataset, err error) {
+func (l loggingMiddleware) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string, agentID string) (_ []policies.PolicyInDataset, err error) {
 	defer func(begin time.Time) {
 		if err != nil {
 			l.logger.Warn("method call: list_policies_by_groups",
@@ -211,7 +239,7 @@ func (l loggingMiddleware) ListPoliciesByGroupIDInternal(ctx context.Context, gr
 				zap.Duration("duration", time.Since(begin)))
 		}
 	}(time.Now())
-	return l.svc.ListPoliciesByGroupIDInternal(ctx, groupIDs, ownerID)
+	return l.svc.ListPoliciesByGroupIDInternal(ctx, groupIDs, ownerID, agentID)
 }
 
 func (l loggingMiddleware) AddDataset(ctx context.Context, token string, d policies.Dataset) (_ policies.Dataset, err error) {
@@ -284,6 +312,34 @@ func (l loggingMiddleware) ViewDatasetByID(ctx context.Context, token string, da
 	return l.svc.ViewDatasetByID(ctx, token, datasetID)
 }
 
+func (l loggingMiddleware) ViewPolicyStatus(ctx context.Context, token string, policyID string) (_ policies.PolicyStatus, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_policy_status",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_policy_status",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewPolicyStatus(ctx, token, policyID)
+}
+
+func (l loggingMiddleware) ViewDatasetPolicyStatus(ctx context.Context, token string, datasetID string) (_ policies.PolicyStatus, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_dataset_policy_status",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_dataset_policy_status",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewDatasetPolicyStatus(ctx, token, datasetID)
+}
+
 func (l loggingMiddleware) ListDatasets(ctx context.Context, token string, pm policies.PageMetadata) (_ policies.PageDataset, err error) {
 	defer func(begin time.Time) {
 		if err != nil {
@@ -39,6 +39,40 @@ func (m metricsMiddleware) ListDatasetsByGroupIDInternal(ctx context.Context, gr
 	return m.svc.ListDatasetsByGroupIDInternal(ctx, groupIDs, ownerID)
 }
 
+func (m metricsMiddleware) RollbackPolicyInternal(ctx context.Context, ownerID string, policyID string, version int32) (policies.Policy, []policies.Dataset, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "rollbackPolicyInternal",
+			"owner_id", ownerID,
+			"policy_id", policyID,
+			"dataset_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RollbackPolicyInternal(ctx, ownerID, policyID, version)
+}
+
+func (m metricsMiddleware) CheckDatasetSchedules(ctx context.Context) ([]policies.Dataset, error) {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "checkDatasetSchedules",
+			"owner_id", "",
+			"policy_id", "",
+			"dataset_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.CheckDatasetSchedules(ctx)
+}
+
 func (m metricsMiddleware) RemoveAllDatasetsByPolicyIDInternal(ctx context.Context, token string, policyID string) error {
 	ownerID, err := m.identify(token)
 	if err != nil {
@@ -249,7 +283,7 @@ func (m metricsMiddleware) ViewPolicyByID(ctx context.Context, token string, pol
 	return m.svc.ViewPolicyByID(ctx, token, policyID)
 }
 
-func (m metricsMiddleware) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string) ([]policies.PolicyInDataset, error) {
+func (m metricsMiddleware) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string, agentID string) ([]policies.PolicyInDataset, error) {
 	defer func(begin time.Time) {
 		labels := []string{
 			"method", "listPoliciesByGroupIDInternal",
@@ -263,7 +297,7 @@ func (m metricsMiddleware) ListPoliciesByGroupIDInternal(ctx context.Context, gr
 
 	}(time.Now())
 
-	return m.svc.ListPoliciesByGroupIDInternal(ctx, groupIDs, ownerID)
+	return m.svc.ListPoliciesByGroupIDInternal(ctx, groupIDs, ownerID, agentID)
 }
 
 func (m metricsMiddleware) ViewPolicyByIDInternal(ctx context.Context, policyID string, ownerID string) (policies.Policy, error) {
@@ -410,6 +444,50 @@ func (m metricsMiddleware) ViewDatasetByID(ctx context.Context, token string, da
 	return m.svc.ViewDatasetByID(ctx, token, datasetID)
 }
 
+func (m metricsMiddleware) ViewPolicyStatus(ctx context.Context, token string, policyID string) (policies.PolicyStatus, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return policies.PolicyStatus{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewPolicyStatus",
+			"owner_id", ownerID,
+			"policy_id", policyID,
+			"dataset_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewPolicyStatus(ctx, token, policyID)
+}
+
+func (m metricsMiddleware) ViewDatasetPolicyStatus(ctx context.Context, token string, datasetID string) (policies.PolicyStatus, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return policies.PolicyStatus{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewDatasetPolicyStatus",
+			"owner_id", ownerID,
+			"policy_id", "",
+			"dataset_id", datasetID,
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewDatasetPolicyStatus(ctx, token, datasetID)
+}
+
 func (m metricsMiddleware) ListDatasets(ctx context.Context, token string, pm policies.PageMetadata) (policies.PageDataset, error) {
 	ownerID, err := m.identify(token)
 	if err != nil {
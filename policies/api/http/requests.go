@@ -88,12 +88,13 @@ func (req viewResourceReq) validate() error {
 type updatePolicyReq struct {
 	id          string
 	token       string
-	Name        string         `json:"name,omitempty"`
-	Description *string        `json:"description,omitempty"`
-	Tags        types.Tags     `json:"tags,omitempty"`
-	Format      string         `json:"format,omitempty"`
-	Policy      types.Metadata `json:"policy,omitempty"`
-	PolicyData  string         `json:"policy_data,omitempty"`
+	Name        string                    `json:"name,omitempty"`
+	Description *string                   `json:"description,omitempty"`
+	Tags        types.Tags                `json:"tags,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Policy      types.Metadata            `json:"policy,omitempty"`
+	PolicyData  string                    `json:"policy_data,omitempty"`
+	Rollout     *policies.RolloutStrategy `json:"rollout,omitempty"`
 }
 
 func (req updatePolicyReq) validate() error {
@@ -106,16 +107,24 @@ func (req updatePolicyReq) validate() error {
 		return errors.ErrMalformedEntity
 	}
 
+	// only a change of the policy content can be staged
+	if req.Rollout != nil && req.PolicyData == "" && req.Policy == nil {
+		return errors.ErrMalformedEntity
+	}
+
 	return nil
 }
 
 type addDatasetReq struct {
-	Name         string     `json:"name"`
-	AgentGroupID string     `json:"agent_group_id"`
-	PolicyID     string     `json:"agent_policy_id"`
-	SinkIDs      []string   `json:"sink_ids"`
-	Tags         types.Tags `json:"tags"`
-	token        string
+	Name             string                    `json:"name"`
+	AgentGroupID     string                    `json:"agent_group_id"`
+	AgentGroupIDs    []string                  `json:"agent_group_ids"`
+	PolicyID         string                    `json:"agent_policy_id"`
+	SinkIDs          []string                  `json:"sink_ids"`
+	Tags             types.Tags                `json:"tags"`
+	ExcludedAgentIDs []string                  `json:"excluded_agent_ids"`
+	Schedule         *policies.DatasetSchedule `json:"schedule"`
+	token            string
 }
 
 func (req addDatasetReq) validate() error {
@@ -123,10 +132,16 @@ func (req addDatasetReq) validate() error {
 		return errors.ErrUnauthorizedAccess
 	}
 
-	if req.Name == "" || req.AgentGroupID == "" || req.PolicyID == "" || len(req.SinkIDs) == 0 {
+	if req.Name == "" || len(req.groupIDs()) == 0 || req.PolicyID == "" || len(req.SinkIDs) == 0 {
 		return errors.ErrMalformedEntity
 	}
 
+	for _, groupID := range req.AgentGroupIDs {
+		if groupID == "" {
+			return errors.ErrMalformedEntity
+		}
+	}
+
 	_, err := types.NewIdentifier(req.Name)
 	if err != nil {
 		return errors.Wrap(errors.ErrMalformedEntity, err)
@@ -135,6 +150,24 @@ func (req addDatasetReq) validate() error {
 	return nil
 }
 
+// groupIDs lists the groups of the dataset once each, agent_group_id, kept from when datasets had a single group, first
+func (req addDatasetReq) groupIDs() []string {
+	return uniqueGroupIDs(append([]string{req.AgentGroupID}, req.AgentGroupIDs...))
+}
+
+func uniqueGroupIDs(ids []string) []string {
+	var groupIDs []string
+	seen := make(map[string]bool)
+	for _, groupID := range ids {
+		if groupID == "" || seen[groupID] {
+			continue
+		}
+		seen[groupID] = true
+		groupIDs = append(groupIDs, groupID)
+	}
+	return groupIDs
+}
+
 type listResourcesReq struct {
 	token        string
 	pageMetadata policies.PageMetadata
@@ -171,11 +204,14 @@ func (req *listResourcesReq) validate() error {
 }
 
 type updateDatasetReq struct {
-	Name    string `json:"name,omitempty"`
-	id      string
-	token   string
-	Tags    types.Tags `json:"tags,omitempty"`
-	SinkIDs *[]string  `json:"sink_ids,omitempty"`
+	Name             string `json:"name,omitempty"`
+	id               string
+	token            string
+	Tags             types.Tags                `json:"tags,omitempty"`
+	AgentGroupIDs    *[]string                 `json:"agent_group_ids,omitempty"`
+	SinkIDs          *[]string                 `json:"sink_ids,omitempty"`
+	ExcludedAgentIDs *[]string                 `json:"excluded_agent_ids,omitempty"`
+	Schedule         *policies.DatasetSchedule `json:"schedule,omitempty"`
 }
 
 func (req updateDatasetReq) validate() error {
@@ -186,13 +222,33 @@ func (req updateDatasetReq) validate() error {
 		return errors.ErrUnauthorizedAccess
 	}
 
-	if req.Name == "" && req.Tags == nil && req.SinkIDs == nil {
+	if req.Name == "" && req.Tags == nil && req.AgentGroupIDs == nil && req.SinkIDs == nil && req.ExcludedAgentIDs == nil && req.Schedule == nil {
 		return errors.ErrMalformedEntity
 	}
 
+	// a dataset keeps at least one group, it is removed rather than left without any
+	if req.AgentGroupIDs != nil {
+		if len(*req.AgentGroupIDs) == 0 {
+			return errors.ErrMalformedEntity
+		}
+		for _, groupID := range *req.AgentGroupIDs {
+			if groupID == "" {
+				return errors.ErrMalformedEntity
+			}
+		}
+	}
+
 	return nil
 }
 
+// groupIDs lists the edited groups of the dataset once each, nil keeps the current ones
+func (req updateDatasetReq) groupIDs() []string {
+	if req.AgentGroupIDs == nil {
+		return nil
+	}
+	return uniqueGroupIDs(*req.AgentGroupIDs)
+}
+
 type duplicatePolicyReq struct {
 	id    string
 	token string
@@ -6,6 +6,7 @@ package http
 
 import (
 	"github.com/orb-community/orb/pkg/types"
+	"github.com/orb-community/orb/policies"
 	"net/http"
 	"time"
 )
@@ -119,12 +120,13 @@ func (s policyValidateRes) Empty() bool {
 }
 
 type validateDatasetRes struct {
-	Name         string
-	AgentGroupID string
-	PolicyID     string
-	SinkIDs      []string
-	Valid        bool
-	Tags         types.Tags
+	Name          string
+	AgentGroupID  string
+	AgentGroupIDs []string
+	PolicyID      string
+	SinkIDs       []string
+	Valid         bool
+	Tags          types.Tags
 }
 
 func (s validateDatasetRes) Code() int {
@@ -140,16 +142,20 @@ func (s validateDatasetRes) Empty() bool {
 }
 
 type datasetRes struct {
-	ID           string         `json:"id"`
-	Name         string         `json:"name"`
-	Valid        bool           `json:"valid"`
-	AgentGroupID string         `json:"agent_group_id"`
-	PolicyID     string         `json:"agent_policy_id"`
-	SinkIDs      []string       `json:"sink_ids"`
-	Metadata     types.Metadata `json:"metadata"`
-	TsCreated    time.Time      `json:"ts_created"`
-	Tags         types.Tags     `json:"tags"`
-	created      bool
+	ID               string                    `json:"id"`
+	Name             string                    `json:"name"`
+	Valid            bool                      `json:"valid"`
+	AgentGroupID     string                    `json:"agent_group_id"`
+	AgentGroupIDs    []string                  `json:"agent_group_ids"`
+	PolicyID         string                    `json:"agent_policy_id"`
+	SinkIDs          []string                  `json:"sink_ids"`
+	Metadata         types.Metadata            `json:"metadata"`
+	TsCreated        time.Time                 `json:"ts_created"`
+	Tags             types.Tags                `json:"tags"`
+	ExcludedAgentIDs []string                  `json:"excluded_agent_ids"`
+	Schedule         *policies.DatasetSchedule `json:"schedule,omitempty"`
+	ScheduleState    string                    `json:"schedule_state,omitempty"`
+	created          bool
 }
 
 func (s datasetRes) Code() int {
@@ -185,6 +191,39 @@ func (res datasetPageRes) Empty() bool {
 	return false
 }
 
+type policyStatusErrorRes struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+type stalePolicyAgentRes struct {
+	AgentID   string `json:"agent_id"`
+	AgentName string `json:"agent_name"`
+	Version   int32  `json:"version"`
+}
+
+type policyStatusRes struct {
+	PolicyID    string                 `json:"policy_id"`
+	DatasetID   string                 `json:"dataset_id,omitempty"`
+	Version     int32                  `json:"version"`
+	Total       int                    `json:"total"`
+	States      map[string]int         `json:"states"`
+	Errors      []policyStatusErrorRes `json:"errors"`
+	StaleAgents []stalePolicyAgentRes  `json:"stale_agents"`
+}
+
+func (res policyStatusRes) Code() int {
+	return http.StatusOK
+}
+
+func (res policyStatusRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res policyStatusRes) Empty() bool {
+	return false
+}
+
 type pageRes struct {
 	Total  uint64 `json:"total"`
 	Offset uint64 `json:"offset"`
@@ -54,6 +54,11 @@ func MakeHandler(tracer opentracing.Tracer, svcName string, svc policies.Service
 		decodeView,
 		types.EncodeResponse,
 		opts...))
+	r.Get("/policies/agent/:id/status", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_policy_status")(viewPolicyStatusEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
 	r.Get("/policies/agent", kithttp.NewServer(
 		kitot.TraceServer(tracer, "list_policies")(listPoliciesEndpoint(svc)),
 		decodeList,
@@ -100,6 +105,11 @@ func MakeHandler(tracer opentracing.Tracer, svcName string, svc policies.Service
 		decodeView,
 		types.EncodeResponse,
 		opts...))
+	r.Get("/policies/dataset/:id/status", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_dataset_policy_status")(viewDatasetPolicyStatusEndpoint(svc)),
+		decodeView,
+		types.EncodeResponse,
+		opts...))
 	r.Get("/policies/dataset", kithttp.NewServer(
 		kitot.TraceServer(tracer, "list_datasets")(listDatasetEndpoint(svc)),
 		decodeList,
@@ -36,5 +36,6 @@ func (o otelBackend) Validate(_ types.Metadata) error {
 func Register(logger *zap.Logger) bool {
 	l := logger.Named("otel-backend")
 	backend.Register("otel", &otelBackend{logger: l})
+	backend.Register("otel-embedded", &otelBackend{logger: l})
 	return true
 }
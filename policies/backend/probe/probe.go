@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package probe
+
+import (
+	"errors"
+
+	"github.com/ghodss/yaml"
+	"github.com/orb-community/orb/pkg/probe"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/orb-community/orb/policies/backend"
+)
+
+var _ backend.Backend = (*probeBackend)(nil)
+
+type probeBackend struct {
+}
+
+func (p probeBackend) Validate(policy types.Metadata) error {
+	checks, err := probe.Decode(policy)
+	if err != nil {
+		return err
+	}
+	return checks.Validate()
+}
+
+func (p probeBackend) convertFromYAML(policy string) (types.Metadata, error) {
+	ret := types.Metadata{}
+	if err := yaml.Unmarshal([]byte(policy), &ret); err != nil {
+		return types.Metadata{}, err
+	}
+	if _, ok := ret["checks"]; !ok {
+		return types.Metadata{}, errors.New("malformed yaml policy")
+	}
+	return ret, nil
+}
+
+func (p probeBackend) ConvertFromFormat(format string, policy string) (types.Metadata, error) {
+	switch format {
+	case "yaml":
+		return p.convertFromYAML(policy)
+	default:
+		return nil, errors.New("unsupported format")
+	}
+}
+
+func (p probeBackend) SupportsFormat(format string) bool {
+	switch format {
+	case "yaml":
+		return true
+	}
+	return false
+}
+
+func Register() bool {
+	backend.Register("probe", &probeBackend{})
+	return true
+}
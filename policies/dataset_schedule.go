@@ -0,0 +1,314 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package policies
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// DatasetScheduleCheckFreq is how often the dataset schedules are evaluated
+	DatasetScheduleCheckFreq = time.Minute
+	// maxScheduleWindowDuration bounds how long a recurring schedule window lasts
+	maxScheduleWindowDuration = 7 * 24 * time.Hour
+
+	ScheduleStateActive   = "active"
+	ScheduleStateInactive = "inactive"
+)
+
+// ErrMalformedSchedule indicates a dataset schedule with an unknown timezone, a malformed window or ending before it starts
+var ErrMalformedSchedule = errors.New("malformed dataset schedule")
+
+// DatasetSchedule limits when a dataset runs its policy: between Start and End, when they are set, and within one of
+// the Windows, when there is any
+type DatasetSchedule struct {
+	// Timezone of the windows, UTC when empty
+	Timezone string           `json:"timezone,omitempty"`
+	Start    *time.Time       `json:"start,omitempty"`
+	End      *time.Time       `json:"end,omitempty"`
+	Windows  []ScheduleWindow `json:"windows,omitempty"`
+}
+
+// ScheduleWindow opens at every minute matching the cron expression, such as "0 9 * * 1-5", and stays open for Duration,
+// such as "8h"
+type ScheduleWindow struct {
+	Cron     string `json:"cron"`
+	Duration string `json:"duration"`
+}
+
+// IsEmpty tells whether the schedule puts no limit at all, which is how an edit clears it
+func (s *DatasetSchedule) IsEmpty() bool {
+	return s == nil || (s.Timezone == "" && s.Start == nil && s.End == nil && len(s.Windows) == 0)
+}
+
+// Validate checks the timezone, the bounds and the windows of the schedule
+func (s DatasetSchedule) Validate() error {
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return errors.Wrap(ErrMalformedSchedule, err)
+	}
+	if s.Start != nil && s.End != nil && !s.End.After(*s.Start) {
+		return errors.Wrap(ErrMalformedSchedule, errors.New("end must be after start"))
+	}
+	for _, w := range s.Windows {
+		if _, _, err := w.parse(); err != nil {
+			return errors.Wrap(ErrMalformedSchedule, err)
+		}
+	}
+	return nil
+}
+
+// Active tells whether the dataset runs its policy at the given time, which it always does without a schedule
+func (s *DatasetSchedule) Active(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	if s.Start != nil && t.Before(*s.Start) {
+		return false
+	}
+	if s.End != nil && !t.Before(*s.End) {
+		return false
+	}
+	if len(s.Windows) == 0 {
+		return true
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+	for _, w := range s.Windows {
+		c, d, err := w.parse()
+		if err != nil {
+			continue
+		}
+		// the window is open if it opened at any minute within its duration before t
+		for m := t.Truncate(time.Minute); t.Sub(m) < d; m = m.Add(-time.Minute) {
+			if c.matches(m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w ScheduleWindow) parse() (cronSpec, time.Duration, error) {
+	c, err := parseCron(w.Cron)
+	if err != nil {
+		return cronSpec{}, 0, err
+	}
+	d, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return cronSpec{}, 0, err
+	}
+	if d < time.Minute || d > maxScheduleWindowDuration {
+		return cronSpec{}, 0, fmt.Errorf("window duration must be between 1m and %s", maxScheduleWindowDuration)
+	}
+	return c, d, nil
+}
+
+// cronSpec holds the minutes, hours, days of month, months and days of week a cron expression matches
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// anyDom and anyDow are set by a "*", the day matches on either field when both are restricted
+	anyDom, anyDow bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// parseCron parses the five fields of a cron expression, each a "*" or a list of values and ranges with an optional step
+func parseCron(expr string) (cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return cronSpec{}, fmt.Errorf("cron expression %q must have %d fields", expr, len(cronFields))
+	}
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return cronSpec{}, fmt.Errorf("invalid %s in cron expression %q: %w", cronFields[i].name, expr, err)
+		}
+		bits[i] = b
+	}
+	// both 0 and 7 are sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return cronSpec{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDom: fields[2] == "*",
+		anyDow: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			rng, step = part[:i], s
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c cronSpec) matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// ScheduleState tells whether the schedule of the dataset currently lets it run, empty when it has no schedule
+func (ds Dataset) ScheduleState() string {
+	if ds.Schedule == nil {
+		return ""
+	}
+	if ds.SchedulePaused {
+		return ScheduleStateInactive
+	}
+	return ScheduleStateActive
+}
+
+// validateDatasetSchedule checks the schedule of a dataset, if any
+func validateDatasetSchedule(s *DatasetSchedule) error {
+	if s == nil {
+		return nil
+	}
+	if err := s.Validate(); err != nil {
+		return errors.Wrap(ErrMalformedEntity, err)
+	}
+	return nil
+}
+
+func (s policiesService) CheckDatasetSchedules(ctx context.Context) ([]Dataset, error) {
+	datasets, err := s.repo.RetrieveScheduledDatasets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var changed []Dataset
+	for _, ds := range datasets {
+		paused := !ds.Schedule.Active(now)
+		if paused == ds.SchedulePaused {
+			continue
+		}
+		err := s.repo.UpdateDatasetSchedulePaused(ctx, ds.MFOwnerID, ds.ID, paused)
+		if errors.Contains(err, ErrNotFound) {
+			// another replica paused or resumed it first, and published the change
+			continue
+		}
+		if err != nil {
+			s.logger.Error("failed to update dataset schedule", zap.String("dataset_id", ds.ID), zap.Error(err))
+			continue
+		}
+		ds.SchedulePaused = paused
+
+		wasValid := ds.Valid
+		if err := s.applyDatasetSchedule(ctx, &ds, paused); err != nil {
+			s.logger.Error("failed to apply dataset schedule", zap.String("dataset_id", ds.ID), zap.Error(err))
+			continue
+		}
+		if ds.Valid != wasValid {
+			changed = append(changed, ds)
+		}
+	}
+	return changed, nil
+}
+
+// applyDatasetSchedule inactivates the dataset just paused, or activates again the one just resumed as long as its
+// policy, agent group and sinks are still there
+func (s policiesService) applyDatasetSchedule(ctx context.Context, ds *Dataset, paused bool) error {
+
+	if paused {
+		if !ds.Valid {
+			return nil
+		}
+		if err := s.repo.InactivateDatasetByID(ctx, ds.ID, ds.MFOwnerID); err != nil {
+			return errors.Wrap(ErrInactivateDataset, err)
+		}
+		ds.Valid = false
+		return nil
+	}
+
+	if ds.Valid || ds.SinkIDs == nil || len(*ds.SinkIDs) == 0 {
+		return nil
+	}
+	if s.validateDatasetPolicy(ctx, ds.MFOwnerID, ds.PolicyID) != nil || s.validateDatasetAgentGroups(ctx, ds.MFOwnerID, ds.AgentGroupIDs) != nil {
+		return nil
+	}
+	if err := s.repo.ActivateDatasetByID(ctx, ds.ID, ds.MFOwnerID); err != nil {
+		return err
+	}
+	ds.Valid = true
+	return nil
+}
+
+// MonitorDatasetSchedules evaluates the dataset schedules every freq until the context is done.
+// It must be given the fully wrapped service, so that the datasets paused or resumed reach the event store.
+func MonitorDatasetSchedules(ctx context.Context, logger *zap.Logger, svc Service, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.CheckDatasetSchedules(ctx); err != nil {
+				logger.Error("failed to check dataset schedules", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,252 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package policies_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	flmocks "github.com/orb-community/orb/fleet/mocks"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/orb-community/orb/policies"
+	plmocks "github.com/orb-community/orb/policies/mocks"
+	sinkmocks "github.com/orb-community/orb/sinks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDatasetScheduleActive(t *testing.T) {
+	// monday 2023-01-02 10:30 UTC
+	monday := time.Date(2023, 1, 2, 10, 30, 0, 0, time.UTC)
+	before := monday.Add(-time.Hour)
+	after := monday.Add(time.Hour)
+
+	cases := map[string]struct {
+		schedule *policies.DatasetSchedule
+		at       time.Time
+		active   bool
+	}{
+		"dataset without schedule": {
+			schedule: nil,
+			at:       monday,
+			active:   true,
+		},
+		"dataset within start and end": {
+			schedule: &policies.DatasetSchedule{Start: &before, End: &after},
+			at:       monday,
+			active:   true,
+		},
+		"dataset before start": {
+			schedule: &policies.DatasetSchedule{Start: &after},
+			at:       monday,
+			active:   false,
+		},
+		"dataset at end": {
+			schedule: &policies.DatasetSchedule{End: &monday},
+			at:       monday,
+			active:   false,
+		},
+		"dataset within a weekday window": {
+			schedule: &policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 9 * * 1-5", Duration: "8h"}}},
+			at:       monday,
+			active:   true,
+		},
+		"dataset after a weekday window closed": {
+			schedule: &policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 9 * * 1-5", Duration: "1h"}}},
+			at:       monday,
+			active:   false,
+		},
+		"dataset outside a weekend window": {
+			schedule: &policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 0 * * 0,6", Duration: "24h"}}},
+			at:       monday,
+			active:   false,
+		},
+		"dataset within a window opened the day before": {
+			schedule: &policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 22 * * 0", Duration: "13h"}}},
+			at:       monday,
+			active:   true,
+		},
+		"dataset within a window of another timezone": {
+			schedule: &policies.DatasetSchedule{
+				Timezone: "America/New_York",
+				Windows:  []policies.ScheduleWindow{{Cron: "0 5 * * *", Duration: "1h"}},
+			},
+			at:     monday,
+			active: true,
+		},
+		"dataset within a window but after end": {
+			schedule: &policies.DatasetSchedule{
+				End:     &before,
+				Windows: []policies.ScheduleWindow{{Cron: "*/15 * * * *", Duration: "5m"}},
+			},
+			at:     monday,
+			active: false,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			active := tc.schedule.Active(tc.at)
+			assert.Equal(t, tc.active, active, fmt.Sprintf("%s: expected %t got %t", desc, tc.active, active))
+		})
+	}
+}
+
+func TestDatasetScheduleValidate(t *testing.T) {
+	start := time.Now()
+	end := start.Add(-time.Hour)
+
+	cases := map[string]struct {
+		schedule policies.DatasetSchedule
+		err      error
+	}{
+		"validate a schedule with windows": {
+			schedule: policies.DatasetSchedule{
+				Timezone: "Europe/Lisbon",
+				Windows:  []policies.ScheduleWindow{{Cron: "0 9-17/2 1,15 * *", Duration: "30m"}},
+			},
+			err: nil,
+		},
+		"validate a schedule with an unknown timezone": {
+			schedule: policies.DatasetSchedule{Timezone: "Mars/Olympus"},
+			err:      policies.ErrMalformedSchedule,
+		},
+		"validate a schedule ending before it starts": {
+			schedule: policies.DatasetSchedule{Start: &start, End: &end},
+			err:      policies.ErrMalformedSchedule,
+		},
+		"validate a schedule with a cron expression missing fields": {
+			schedule: policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 9 * *", Duration: "1h"}}},
+			err:      policies.ErrMalformedSchedule,
+		},
+		"validate a schedule with a cron value out of range": {
+			schedule: policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 24 * * *", Duration: "1h"}}},
+			err:      policies.ErrMalformedSchedule,
+		},
+		"validate a schedule with a window longer than a week": {
+			schedule: policies.DatasetSchedule{Windows: []policies.ScheduleWindow{{Cron: "0 0 * * 1", Duration: "200h"}}},
+			err:      policies.ErrMalformedSchedule,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := tc.schedule.Validate()
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		})
+	}
+}
+
+func TestCheckDatasetSchedules(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	policy := createPolicy(t, svc, "policy")
+
+	groupID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	sinkIDs := []string{sinkID.String()}
+
+	boundary := time.Now().Add(200 * time.Millisecond)
+	saveScheduled := func(name string, valid bool, schedule policies.DatasetSchedule) policies.Dataset {
+		validName, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		ds, err := svc.AddDataset(context.Background(), token, policies.Dataset{
+			Name:          validName,
+			Valid:         valid,
+			AgentGroupIDs: []string{groupID.String()},
+			PolicyID:      policy.ID,
+			SinkIDs:       &sinkIDs,
+			Schedule:      &schedule,
+		})
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		return ds
+	}
+
+	starting := saveScheduled("starting", false, policies.DatasetSchedule{Start: &boundary})
+	assert.Equal(t, policies.ScheduleStateInactive, starting.ScheduleState(), "dataset created before its start must be inactive")
+	ending := saveScheduled("ending", true, policies.DatasetSchedule{End: &boundary})
+	assert.Equal(t, policies.ScheduleStateActive, ending.ScheduleState(), "dataset created before its end must be active")
+
+	changed, err := svc.CheckDatasetSchedules(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	assert.Empty(t, changed, "no dataset should change before its schedule boundary")
+
+	time.Sleep(time.Until(boundary) + 50*time.Millisecond)
+
+	changed, err = svc.CheckDatasetSchedules(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	valid := make(map[string]bool)
+	for _, ds := range changed {
+		valid[ds.ID] = ds.Valid
+	}
+	assert.Equal(t, map[string]bool{starting.ID: true, ending.ID: false}, valid, "datasets should turn valid and invalid at their schedule boundary")
+
+	changed, err = svc.CheckDatasetSchedules(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	assert.Empty(t, changed, "datasets already paused or resumed should not change again")
+}
+
+// staleScheduleRepository returns the scheduled datasets as they were when the snapshot was taken, like a replica that
+// read them just before another one paused or resumed them
+type staleScheduleRepository struct {
+	policies.Repository
+	snapshot []policies.Dataset
+}
+
+func (r staleScheduleRepository) RetrieveScheduledDatasets(_ context.Context) ([]policies.Dataset, error) {
+	return r.snapshot, nil
+}
+
+func TestCheckDatasetSchedulesReplicas(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	repo := plmocks.NewPoliciesRepository()
+	svc := policies.New(zap.NewNop(), users, repo, flmocks.NewClient(), sinkmocks.NewClient())
+
+	policy := createPolicy(t, svc, "policy")
+
+	groupID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	sinkIDs := []string{sinkID.String()}
+
+	end := time.Now().Add(100 * time.Millisecond)
+	validName, err := types.NewIdentifier("ended")
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	ended, err := svc.AddDataset(context.Background(), token, policies.Dataset{
+		Name:          validName,
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policy.ID,
+		SinkIDs:       &sinkIDs,
+		Schedule:      &policies.DatasetSchedule{End: &end},
+	})
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	time.Sleep(time.Until(end) + 50*time.Millisecond)
+
+	snapshot, err := repo.RetrieveScheduledDatasets(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	stale := policies.New(zap.NewNop(), users, staleScheduleRepository{Repository: repo, snapshot: snapshot}, flmocks.NewClient(), sinkmocks.NewClient())
+
+	changed, err := svc.CheckDatasetSchedules(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	require.Equal(t, 1, len(changed), fmt.Sprintf("expected %d dataset paused got %d", 1, len(changed)))
+	assert.Equal(t, ended.ID, changed[0].ID, fmt.Sprintf("expected dataset %s paused got %s", ended.ID, changed[0].ID))
+
+	changed, err = stale.CheckDatasetSchedules(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	assert.Empty(t, changed, "dataset already paused by another replica should not change again")
+}
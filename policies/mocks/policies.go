@@ -9,6 +9,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/policies"
+	"reflect"
 )
 
 var _ policies.Repository = (*mockPoliciesRepository)(nil)
@@ -19,17 +20,14 @@ type mockPoliciesRepository struct {
 	dataSetCounter uint64
 	ddb            map[string]policies.Dataset
 	gdb            map[string][]policies.PolicyInDataset
+	prevdb         map[string]policies.Policy
 }
 
 func (m *mockPoliciesRepository) RetrieveDatasetsByGroupID(ctx context.Context, groupIDs []string, ownerID string) ([]policies.Dataset, error) {
 	var datasetList []policies.Dataset
 	for _, d := range m.ddb {
-		if d.MFOwnerID == ownerID {
-			for _, groupID := range groupIDs {
-				if groupID == d.AgentGroupID {
-					datasetList = append(datasetList, d)
-				}
-			}
+		if d.MFOwnerID == ownerID && targetsAny(d, groupIDs) {
+			datasetList = append(datasetList, d)
 		}
 	}
 
@@ -47,6 +45,27 @@ func (m *mockPoliciesRepository) ActivateDatasetByID(ctx context.Context, datase
 	return nil
 }
 
+func (m *mockPoliciesRepository) RetrieveScheduledDatasets(ctx context.Context) ([]policies.Dataset, error) {
+	var datasetList []policies.Dataset
+	for _, d := range m.ddb {
+		if d.Schedule != nil {
+			datasetList = append(datasetList, d)
+		}
+	}
+
+	return datasetList, nil
+}
+
+func (m *mockPoliciesRepository) UpdateDatasetSchedulePaused(ctx context.Context, ownerID string, datasetID string, paused bool) error {
+	ds, ok := m.ddb[datasetID]
+	if !ok || ds.MFOwnerID != ownerID || ds.SchedulePaused == paused {
+		return policies.ErrNotFound
+	}
+	ds.SchedulePaused = paused
+	m.ddb[datasetID] = ds
+	return nil
+}
+
 func (m *mockPoliciesRepository) RetrieveAllPoliciesInternal(ctx context.Context, ownerID string) ([]policies.Policy, error) {
 	var policyList []policies.Policy
 	for _, p := range m.pdb {
@@ -121,7 +140,9 @@ func (m *mockPoliciesRepository) UpdateDataset(ctx context.Context, ownerID stri
 		}
 		ds.MFOwnerID = ownerID
 
-		ds.AgentGroupID = m.ddb[ds.ID].AgentGroupID
+		if ds.AgentGroupIDs == nil {
+			ds.AgentGroupIDs = m.ddb[ds.ID].AgentGroupIDs
+		}
 		ds.PolicyID = m.ddb[ds.ID].PolicyID
 		ds.Valid = m.ddb[ds.ID].Valid
 
@@ -169,12 +190,32 @@ func (m *mockPoliciesRepository) UpdatePolicy(ctx context.Context, owner string,
 			return policies.ErrUpdateEntity
 		}
 		pol.MFOwnerID = owner
+		current := m.pdb[pol.ID]
+		if !reflect.DeepEqual(current.Policy, pol.Policy) || current.PolicyData != pol.PolicyData {
+			m.prevdb[pol.ID] = current
+		}
 		m.pdb[pol.ID] = pol
 		return nil
 	}
 	return policies.ErrNotFound
 }
 
+func (m *mockPoliciesRepository) RollbackPolicy(ctx context.Context, ownerID string, policyID string, version int32) error {
+	current, ok := m.pdb[policyID]
+	if !ok || current.MFOwnerID != ownerID {
+		return policies.ErrNotFound
+	}
+	prev, ok := m.prevdb[policyID]
+	if !ok || current.Version != version {
+		return policies.ErrRollbackPolicy
+	}
+	m.prevdb[policyID] = current
+	current.Policy, current.PolicyData = prev.Policy, prev.PolicyData
+	current.Version++
+	m.pdb[policyID] = current
+	return nil
+}
+
 func (m *mockPoliciesRepository) DeleteAllDatasetsPolicy(ctx context.Context, policyID string, ownerID string) error {
 	for _, dataset := range m.ddb {
 		if dataset.PolicyID == policyID && dataset.MFOwnerID == ownerID {
@@ -187,9 +228,10 @@ func (m *mockPoliciesRepository) DeleteAllDatasetsPolicy(ctx context.Context, po
 
 func NewPoliciesRepository() policies.Repository {
 	return &mockPoliciesRepository{
-		pdb: make(map[string]policies.Policy),
-		ddb: make(map[string]policies.Dataset),
-		gdb: make(map[string][]policies.PolicyInDataset),
+		pdb:    make(map[string]policies.Policy),
+		ddb:    make(map[string]policies.Dataset),
+		gdb:    make(map[string][]policies.PolicyInDataset),
+		prevdb: make(map[string]policies.Policy),
 	}
 }
 
@@ -246,18 +288,46 @@ func (m *mockPoliciesRepository) RetrievePolicyByID(ctx context.Context, policyI
 	return policies.Policy{}, policies.ErrNotFound
 }
 
-func (m *mockPoliciesRepository) RetrievePoliciesByGroupID(ctx context.Context, groupIDs []string, ownerID string) (ret []policies.PolicyInDataset, err error) {
+func (m *mockPoliciesRepository) RetrievePoliciesByGroupID(ctx context.Context, groupIDs []string, ownerID string, agentID string) (ret []policies.PolicyInDataset, err error) {
 	if len(groupIDs) == 0 || ownerID == "" {
 		return nil, errors.ErrMalformedEntity
 	}
 
+	// a dataset is listed once, even when it targets several of the given groups
+	listed := make(map[string]bool)
+	ret = []policies.PolicyInDataset{}
 	for _, d := range groupIDs {
-		ret = make([]policies.PolicyInDataset, len(m.gdb[d]))
-		copy(ret, m.gdb[d])
+		for _, p := range m.gdb[d] {
+			if listed[p.DatasetID] || (agentID != "" && excludes(p.ExcludedAgentIDs, agentID)) {
+				continue
+			}
+			listed[p.DatasetID] = true
+			ret = append(ret, p)
+		}
 	}
 	return ret, nil
 }
 
+func targetsAny(ds policies.Dataset, groupIDs []string) bool {
+	for _, groupID := range groupIDs {
+		for _, id := range ds.AgentGroupIDs {
+			if id == groupID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func excludes(agentIDs []string, agentID string) bool {
+	for _, id := range agentIDs {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *mockPoliciesRepository) SaveDataset(ctx context.Context, dataset policies.Dataset) (string, error) {
 	for _, d := range m.ddb {
 		if d.Name == dataset.Name && d.MFOwnerID == dataset.MFOwnerID {
@@ -269,11 +339,12 @@ func (m *mockPoliciesRepository) SaveDataset(ctx context.Context, dataset polici
 	dataset.ID = ID.String()
 	m.ddb[dataset.ID] = dataset
 
-	if _, ok := m.gdb[dataset.AgentGroupID]; !ok {
-		m.gdb[dataset.AgentGroupID] = make([]policies.PolicyInDataset, 1)
-		m.gdb[dataset.AgentGroupID][0] = policies.PolicyInDataset{Policy: m.pdb[dataset.PolicyID], DatasetID: dataset.ID, AgentGroupID: dataset.AgentGroupID}
-	} else {
-		m.gdb[dataset.AgentGroupID] = append(m.gdb[dataset.AgentGroupID], policies.PolicyInDataset{Policy: m.pdb[dataset.PolicyID], DatasetID: dataset.ID, AgentGroupID: dataset.AgentGroupID})
+	var excluded []string
+	if dataset.ExcludedAgentIDs != nil {
+		excluded = *dataset.ExcludedAgentIDs
+	}
+	for _, groupID := range dataset.AgentGroupIDs {
+		m.gdb[groupID] = append(m.gdb[groupID], policies.PolicyInDataset{Policy: m.pdb[dataset.PolicyID], DatasetID: dataset.ID, AgentGroupID: groupID, ExcludedAgentIDs: excluded})
 	}
 	m.dataSetCounter++
 	return ID.String(), nil
@@ -291,7 +362,7 @@ func (m *mockPoliciesRepository) RetrieveDatasetByID(ctx context.Context, datase
 
 func (m *mockPoliciesRepository) InactivateDatasetByGroupID(ctx context.Context, groupID string, ownerID string) error {
 	for _, ds := range m.ddb {
-		if ds.AgentGroupID == groupID && ds.MFOwnerID == ownerID {
+		if len(ds.AgentGroupIDs) == 1 && ds.AgentGroupIDs[0] == groupID && ds.MFOwnerID == ownerID {
 			ds.Valid = false
 			return nil
 		}
@@ -328,12 +399,19 @@ func (m *mockPoliciesRepository) RetrieveAllDatasetsByOwner(ctx context.Context,
 }
 
 func (m *mockPoliciesRepository) DeleteAgentGroupFromAllDatasets(ctx context.Context, groupID string, ownerID string) error {
-	for _, ds := range m.ddb {
-		if ds.MFOwnerID == ownerID {
-			if ds.AgentGroupID == groupID {
-				ds.AgentGroupID = ""
+	for id, ds := range m.ddb {
+		if ds.MFOwnerID != ownerID || !targetsAny(ds, []string{groupID}) {
+			continue
+		}
+		var remaining []string
+		for _, g := range ds.AgentGroupIDs {
+			if g != groupID {
+				remaining = append(remaining, g)
 			}
 		}
+		ds.AgentGroupIDs = remaining
+		ds.Valid = ds.Valid && len(remaining) > 0
+		m.ddb[id] = ds
 	}
 	return nil
 }
@@ -25,25 +25,35 @@ type Policy struct {
 	Format        string
 	Created       time.Time
 	LastModified  time.Time
+	// Rollout is only set on edits that stage the update, it is not persisted
+	Rollout *RolloutStrategy
 }
 
 type Dataset struct {
-	ID           string
-	Name         types.Identifier
-	MFOwnerID    string
-	Valid        bool
-	AgentGroupID string
-	PolicyID     string
-	Metadata     types.Metadata
-	Created      time.Time
-	Tags         types.Tags
-	SinkIDs      *[]string
+	ID        string
+	Name      types.Identifier
+	MFOwnerID string
+	Valid     bool
+	// AgentGroupIDs are the groups whose agents run the policy, the dataset is invalid without any
+	AgentGroupIDs []string
+	PolicyID      string
+	Metadata      types.Metadata
+	Created       time.Time
+	Tags          types.Tags
+	SinkIDs       *[]string
+	// ExcludedAgentIDs are the agents of the groups that do not run the policy
+	ExcludedAgentIDs *[]string
+	// Schedule limits when the dataset runs its policy, it always does without one
+	Schedule *DatasetSchedule
+	// SchedulePaused is set while the schedule keeps the dataset inactive
+	SchedulePaused bool
 }
 
 type PolicyInDataset struct {
 	Policy
-	DatasetID    string
-	AgentGroupID string
+	DatasetID        string
+	AgentGroupID     string
+	ExcludedAgentIDs []string
 }
 
 type Page struct {
@@ -69,8 +79,9 @@ type Service interface {
 	// ViewPolicyByIDInternal gRPC version of retrieving policy by id with no token
 	ViewPolicyByIDInternal(ctx context.Context, policyID string, ownerID string) (Policy, error)
 
-	// ListPoliciesByGroupIDInternal gRPC version of retrieving list of policies belonging to specified agent group with no token
-	ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string) ([]PolicyInDataset, error)
+	// ListPoliciesByGroupIDInternal gRPC version of retrieving list of policies belonging to specified agent group with no token,
+	// leaving out the datasets excluding the provided agent, if any
+	ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string, agentID string) ([]PolicyInDataset, error)
 
 	// EditPolicy edit a existing policy by id with a valid token
 	EditPolicy(ctx context.Context, token string, pol Policy) (Policy, error)
@@ -81,7 +92,7 @@ type Service interface {
 	// AddDataset creates new Dataset
 	AddDataset(ctx context.Context, token string, d Dataset) (Dataset, error)
 
-	// InactivateDatasetByGroupID inactivate a dataset
+	// InactivateDatasetByGroupID inactivate the datasets whose only agent group is the given one
 	InactivateDatasetByGroupID(ctx context.Context, groupID string, token string) error
 
 	// ListDatasetsByPolicyIDInternal retrieves the subset of Datasets by policyID owned by the specified user
@@ -101,6 +112,12 @@ type Service interface {
 	// ViewDatasetByID retrieving dataset by id with token
 	ViewDatasetByID(ctx context.Context, token string, datasetID string) (Dataset, error)
 
+	// ViewPolicyStatus retrieves the state of a policy across the agents of its datasets, from their heartbeats
+	ViewPolicyStatus(ctx context.Context, token string, policyID string) (PolicyStatus, error)
+
+	// ViewDatasetPolicyStatus retrieves the state of the policy of a dataset across the agents of the dataset only
+	ViewDatasetPolicyStatus(ctx context.Context, token string, datasetID string) (PolicyStatus, error)
+
 	// ViewDatasetByIDInternal retrieving dataset by id with provided ownerID
 	ViewDatasetByIDInternal(ctx context.Context, ownerID string, datasetID string) (Dataset, error)
 
@@ -113,7 +130,7 @@ type Service interface {
 	// DeleteSinkFromAllDatasetsInternal removes a sink from a dataset
 	DeleteSinkFromAllDatasetsInternal(ctx context.Context, sinkID string, ownerID string) ([]Dataset, error)
 
-	// DeleteAgentGroupFromAllDatasets removes an agent group from a dataset
+	// DeleteAgentGroupFromAllDatasets removes an agent group from the datasets, inactivating the ones left without groups
 	DeleteAgentGroupFromAllDatasets(ctx context.Context, groupID string, token string) error
 
 	// DuplicatePolicy duplicates existing agent Policy
@@ -124,6 +141,14 @@ type Service interface {
 
 	// ListDatasetsByGroupIDInternal gRPC version of retrieving list of datasets belonging to specified agent group with no token
 	ListDatasetsByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string) ([]Dataset, error)
+
+	// CheckDatasetSchedules pauses the datasets whose schedule ended and resumes the ones whose schedule started,
+	// returning the datasets that turned valid or invalid
+	CheckDatasetSchedules(ctx context.Context) ([]Dataset, error)
+
+	// RollbackPolicyInternal restores the content a policy had before the given version, if it is still the current one,
+	// returning the restored policy and the datasets using it
+	RollbackPolicyInternal(ctx context.Context, ownerID string, policyID string, version int32) (Policy, []Dataset, error)
 }
 
 type Repository interface {
@@ -134,8 +159,8 @@ type Repository interface {
 	// RetrievePolicyByID Retrieve policy by id
 	RetrievePolicyByID(ctx context.Context, policyID string, ownerID string) (Policy, error)
 
-	// RetrievePoliciesByGroupID Retrieve policy list by group id
-	RetrievePoliciesByGroupID(ctx context.Context, groupIDs []string, ownerID string) ([]PolicyInDataset, error)
+	// RetrievePoliciesByGroupID Retrieve policy list by group id, leaving out the datasets excluding the provided agent, if any
+	RetrievePoliciesByGroupID(ctx context.Context, groupIDs []string, ownerID string, agentID string) ([]PolicyInDataset, error)
 
 	// RetrieveAll retrieves the subset of Policies owned by the specified user
 	RetrieveAll(ctx context.Context, ownerID string, pm PageMetadata) (Page, error)
@@ -150,7 +175,7 @@ type Repository interface {
 	// error response.
 	SaveDataset(ctx context.Context, dataset Dataset) (string, error)
 
-	// InactivateDatasetByGroupID inactivate a dataset
+	// InactivateDatasetByGroupID inactivate the datasets whose only agent group is the given one
 	InactivateDatasetByGroupID(ctx context.Context, groupID string, ownerID string) error
 
 	// InactivateDatasetByPolicyID inactivate a dataset by policy id
@@ -180,12 +205,22 @@ type Repository interface {
 	// ActivateDatasetByID Activate a dataset
 	ActivateDatasetByID(ctx context.Context, datasetID string, ownerID string) error
 
-	// DeleteAgentGroupFromAllDatasets removes agent group from a dataset
+	// DeleteAgentGroupFromAllDatasets removes agent group from the datasets, inactivating the ones left without groups
 	DeleteAgentGroupFromAllDatasets(ctx context.Context, groupID string, ownerID string) error
 
 	// DeleteAllDatasetsPolicy removes all datasets by policyID
 	DeleteAllDatasetsPolicy(ctx context.Context, policyID string, ownerID string) error
 
-	// RetrieveDatasetsByGroupID Retrieve dataset list by group id
+	// RetrieveDatasetsByGroupID Retrieve the valid datasets targeting any of the groups
 	RetrieveDatasetsByGroupID(ctx context.Context, groupIDs []string, ownerID string) ([]Dataset, error)
+
+	// RetrieveScheduledDatasets retrieves the datasets of all owners that have a schedule
+	RetrieveScheduledDatasets(ctx context.Context) ([]Dataset, error)
+
+	// UpdateDatasetSchedulePaused sets whether the schedule of a dataset currently keeps it inactive, failing with
+	// ErrNotFound when it already does
+	UpdateDatasetSchedulePaused(ctx context.Context, ownerID string, datasetID string, paused bool) error
+
+	// RollbackPolicy swaps the policy content with the one it had before the given version, bumping its version
+	RollbackPolicy(ctx context.Context, ownerID string, policyID string, version int32) error
 }
@@ -11,6 +11,7 @@ package policies
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc/status"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/pkg/types"
 	"github.com/orb-community/orb/policies/backend"
+	"github.com/orb-community/orb/policies/template"
 	sinkpb "github.com/orb-community/orb/sinks/pb"
 )
 
@@ -50,11 +52,11 @@ func (s policiesService) ListPolicies(ctx context.Context, token string, pm Page
 	return s.repo.RetrieveAll(ctx, ownerID, pm)
 }
 
-func (s policiesService) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string) ([]PolicyInDataset, error) {
+func (s policiesService) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string, agentID string) ([]PolicyInDataset, error) {
 	if len(groupIDs) == 0 || ownerID == "" {
 		return nil, ErrMalformedEntity
 	}
-	return s.repo.RetrievePoliciesByGroupID(ctx, groupIDs, ownerID)
+	return s.repo.RetrievePoliciesByGroupID(ctx, groupIDs, ownerID, agentID)
 }
 
 func (s policiesService) ViewPolicyByIDInternal(ctx context.Context, policyID string, ownerID string) (Policy, error) {
@@ -72,6 +74,18 @@ func (s policiesService) AddDataset(ctx context.Context, token string, d Dataset
 
 	d.MFOwnerID = mfOwnerID
 
+	if err := validateDatasetExclusions(d.ExcludedAgentIDs); err != nil {
+		return Dataset{}, err
+	}
+
+	if d.Schedule.IsEmpty() {
+		d.Schedule = nil
+	}
+	if err := validateDatasetSchedule(d.Schedule); err != nil {
+		return Dataset{}, err
+	}
+	d.SchedulePaused = !d.Schedule.Active(time.Now())
+
 	id, err := s.repo.SaveDataset(ctx, d)
 	if err != nil {
 		return Dataset{}, errors.Wrap(ErrCreateDataset, err)
@@ -133,6 +147,12 @@ func (s policiesService) EditPolicy(ctx context.Context, token string, pol Polic
 		return Policy{}, err
 	}
 
+	if pol.Rollout != nil {
+		if err := pol.Rollout.Validate(); err != nil {
+			return Policy{}, errors.Wrap(errors.ErrMalformedEntity, err)
+		}
+	}
+
 	// Used to get the policy backend and validate it
 	currentPol, err := s.repo.RetrievePolicyByID(ctx, pol.ID, ownerID)
 	if err != nil {
@@ -264,6 +284,10 @@ func validatePolicyBackend(p *Policy) (err error) {
 		p.Format = "json"
 	}
 
+	if err = template.Validate(p.Policy); err != nil {
+		return errors.Wrap(ErrValidatePolicy, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
 	err = backend.GetBackend(p.Backend).Validate(p.Policy)
 	if err != nil {
 		return errors.Wrap(ErrCreatePolicy, err)
@@ -308,10 +332,34 @@ func (s policiesService) EditDataset(ctx context.Context, token string, ds Datas
 		ds.Tags = currentDataset.Tags
 	}
 
+	if ds.AgentGroupIDs == nil {
+		ds.AgentGroupIDs = currentDataset.AgentGroupIDs
+	} else if err := s.validateDatasetAgentGroups(ctx, ds.MFOwnerID, ds.AgentGroupIDs); err != nil {
+		return Dataset{}, err
+	}
+
 	if ds.SinkIDs == nil {
 		ds.SinkIDs = currentDataset.SinkIDs
 	}
 
+	if ds.ExcludedAgentIDs == nil {
+		ds.ExcludedAgentIDs = currentDataset.ExcludedAgentIDs
+	}
+	if err := validateDatasetExclusions(ds.ExcludedAgentIDs); err != nil {
+		return Dataset{}, err
+	}
+
+	// an empty schedule clears the current one
+	if ds.Schedule == nil {
+		ds.Schedule = currentDataset.Schedule
+	} else if ds.Schedule.IsEmpty() {
+		ds.Schedule = nil
+	}
+	if err := validateDatasetSchedule(ds.Schedule); err != nil {
+		return Dataset{}, err
+	}
+	ds.SchedulePaused = !ds.Schedule.Active(time.Now())
+
 	err = s.validateDatasetSink(ctx, ds.MFOwnerID, *ds.SinkIDs)
 	if err != nil {
 		return Dataset{}, err
@@ -327,8 +375,19 @@ func (s policiesService) EditDataset(ctx context.Context, token string, ds Datas
 		return Dataset{}, err
 	}
 
+	if datasetEdited.SchedulePaused {
+		if datasetEdited.Valid {
+			err = s.repo.InactivateDatasetByID(ctx, datasetEdited.ID, datasetEdited.MFOwnerID)
+			if err != nil {
+				return Dataset{}, errors.Wrap(ErrInactivateDataset, err)
+			}
+			datasetEdited.Valid = false
+		}
+		return datasetEdited, nil
+	}
+
 	errValidatePolicy := s.validateDatasetPolicy(ctx, datasetEdited.MFOwnerID, datasetEdited.PolicyID)
-	errValidateAGroup := s.validateDatasetAgentGroup(ctx, datasetEdited.MFOwnerID, datasetEdited.AgentGroupID)
+	errValidateAGroup := s.validateDatasetAgentGroups(ctx, datasetEdited.MFOwnerID, datasetEdited.AgentGroupIDs)
 
 	if errValidatePolicy == nil && errValidateAGroup == nil {
 		err = s.repo.ActivateDatasetByID(ctx, datasetEdited.ID, datasetEdited.MFOwnerID)
@@ -366,12 +425,22 @@ func (s policiesService) ValidateDataset(ctx context.Context, token string, d Da
 		return Dataset{}, err
 	}
 
+	err = validateDatasetExclusions(d.ExcludedAgentIDs)
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	err = validateDatasetSchedule(d.Schedule)
+	if err != nil {
+		return Dataset{}, err
+	}
+
 	err = s.validateDatasetPolicy(ctx, d.MFOwnerID, d.PolicyID)
 	if err != nil {
 		return Dataset{}, err
 	}
 
-	err = s.validateDatasetAgentGroup(ctx, d.MFOwnerID, d.AgentGroupID)
+	err = s.validateDatasetAgentGroups(ctx, d.MFOwnerID, d.AgentGroupIDs)
 	if err != nil {
 		return Dataset{}, err
 	}
@@ -435,6 +504,20 @@ func (s policiesService) validateDatasetSink(ctx context.Context, ownerID string
 	return nil
 }
 
+// validateDatasetExclusions checks the agents excluded from a dataset are agent IDs, whether they belong to the group
+// is not checked as group memberships change with the agent tags
+func validateDatasetExclusions(agentIDs *[]string) error {
+	if agentIDs == nil {
+		return nil
+	}
+	for _, agentID := range *agentIDs {
+		if _, err := uuid.FromString(agentID); err != nil {
+			return errors.Wrap(errors.New("invalid excluded agent id"), ErrMalformedEntity)
+		}
+	}
+	return nil
+}
+
 func (s policiesService) validateDatasetPolicy(ctx context.Context, ownerID string, policyID string) error {
 	_, err := uuid.FromString(policyID)
 	if err != nil {
@@ -448,18 +531,24 @@ func (s policiesService) validateDatasetPolicy(ctx context.Context, ownerID stri
 	return nil
 }
 
-func (s policiesService) validateDatasetAgentGroup(ctx context.Context, ownerID string, aGroupID string) error {
-	_, err := uuid.FromString(aGroupID)
-	if err != nil {
-		return errors.Wrap(errors.New("invalid agent group id"), ErrMalformedEntity)
+func (s policiesService) validateDatasetAgentGroups(ctx context.Context, ownerID string, aGroupIDs []string) error {
+	if len(aGroupIDs) == 0 {
+		return errors.Wrap(errors.New("missing agent group id"), ErrMalformedEntity)
 	}
 
-	_, err = s.fleetGrpcClient.RetrieveAgentGroup(ctx, &pb.AgentGroupByIDReq{
-		AgentGroupID: aGroupID,
-		OwnerID:      ownerID,
-	})
-	if err != nil {
-		return errors.Wrap(errors.New("agent group id does not exist"), err)
+	for _, aGroupID := range aGroupIDs {
+		_, err := uuid.FromString(aGroupID)
+		if err != nil {
+			return errors.Wrap(errors.New("invalid agent group id"), ErrMalformedEntity)
+		}
+
+		_, err = s.fleetGrpcClient.RetrieveAgentGroup(ctx, &pb.AgentGroupByIDReq{
+			AgentGroupID: aGroupID,
+			OwnerID:      ownerID,
+		})
+		if err != nil {
+			return errors.Wrap(errors.New("agent group id does not exist"), err)
+		}
 	}
 	return nil
 }
@@ -296,6 +296,18 @@ func TestEditPolicy(t *testing.T) {
 			token: token,
 			err:   policies.ErrValidatePolicy,
 		},
+		"update a existing policy with invalid rollout": {
+			policy: policies.Policy{
+				ID:         policy.ID,
+				Name:       nameID,
+				MFOwnerID:  policy.MFOwnerID,
+				PolicyData: policy_data,
+				Format:     format,
+				Rollout:    &policies.RolloutStrategy{WavePercent: 150},
+			},
+			token: token,
+			err:   errors.ErrMalformedEntity,
+		},
 		"update a existing policy with omitted description": {
 			policy: policies.Policy{
 				ID:        policyTestDescriptionAttribute.ID,
@@ -382,6 +394,67 @@ func TestEditPolicy(t *testing.T) {
 
 }
 
+func TestRollbackPolicyInternal(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	policy := createPolicy(t, svc, "policy")
+	unchanged := createPolicy(t, svc, "policy-unchanged")
+
+	edited, err := svc.EditPolicy(context.Background(), token, policies.Policy{
+		ID:         policy.ID,
+		PolicyData: policy_data + "\nconfig:\n  merge_like_handlers: true",
+		Format:     format,
+	})
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	current, err := svc.ViewPolicyByID(context.Background(), token, edited.ID)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	res, _, err := svc.RollbackPolicyInternal(context.Background(), email, policy.ID, current.Version)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	assert.Equal(t, policy_data, res.PolicyData, "expected previous policy data to be restored")
+	assert.Equal(t, current.Version+1, res.Version, fmt.Sprintf("expected version %d got %d", current.Version+1, res.Version))
+
+	cases := map[string]struct {
+		ownerID  string
+		policyID string
+		version  int32
+		err      error
+	}{
+		"rollback a policy edited since": {
+			ownerID:  email,
+			policyID: policy.ID,
+			version:  current.Version,
+			err:      policies.ErrRollbackPolicy,
+		},
+		"rollback a policy never edited": {
+			ownerID:  email,
+			policyID: unchanged.ID,
+			version:  unchanged.Version,
+			err:      policies.ErrRollbackPolicy,
+		},
+		"rollback a non-existing policy": {
+			ownerID:  email,
+			policyID: wrongID,
+			version:  current.Version,
+			err:      policies.ErrNotFound,
+		},
+		"rollback a policy without owner": {
+			ownerID:  "",
+			policyID: policy.ID,
+			version:  current.Version,
+			err:      policies.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, _, err := svc.RollbackPolicyInternal(context.Background(), tc.ownerID, tc.policyID, tc.version)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		})
+	}
+}
+
 func TestRemovePolicy(t *testing.T) {
 	users := flmocks.NewAuthService(map[string]string{token: email})
 	svc := newService(users)
@@ -485,6 +558,30 @@ func TestCreatePolicy(t *testing.T) {
 		Format:      format,
 	}
 
+	templateName, _ := types.NewIdentifier("my-template-policy")
+	templatePolicy := policy
+	templatePolicy.Name = templateName
+	templatePolicy.PolicyData = `input:
+  input_type: pcap
+  config:
+    iface: '{{ agent.tags.iface | default "eth0" }}'
+handlers:
+  modules:
+    default_dns:
+      type: dns
+kind: collection`
+
+	invalidTemplatePolicy := templatePolicy
+	invalidTemplatePolicy.PolicyData = `input:
+  input_type: pcap
+  config:
+    iface: '{{ agent.iface }}'
+handlers:
+  modules:
+    default_dns:
+      type: dns
+kind: collection`
+
 	cases := map[string]struct {
 		policy policies.Policy
 		token  string
@@ -500,6 +597,16 @@ func TestCreatePolicy(t *testing.T) {
 			token:  invalidToken,
 			err:    policies.ErrUnauthorizedAccess,
 		},
+		"create a policy with agent template variables": {
+			policy: templatePolicy,
+			token:  token,
+			err:    nil,
+		},
+		"create a policy with an invalid template": {
+			policy: invalidTemplatePolicy,
+			token:  token,
+			err:    errors.ErrMalformedEntity,
+		},
 	}
 
 	for desc, tc := range cases {
@@ -569,11 +676,11 @@ func TestEditDataset(t *testing.T) {
 
 	sinkIDs := []string{sinkID.String()}
 	dataset := policies.Dataset{
-		Name:         validName,
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policy.ID,
-		SinkIDs:      &sinkIDs,
+		Name:          validName,
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policy.ID,
+		SinkIDs:       &sinkIDs,
 	}
 
 	dataset, err = svc.AddDataset(context.Background(), token, dataset)
@@ -681,6 +788,55 @@ func TestEditDataset(t *testing.T) {
 	}
 }
 
+func TestEditDatasetAgentGroups(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	replaced := createDataset(t, svc, "replaced")
+	kept := createDataset(t, svc, "kept")
+	invalid := createDataset(t, svc, "invalid")
+
+	groupID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	cases := map[string]struct {
+		dataset  policies.Dataset
+		groupIDs []string
+		expected []string
+		err      error
+	}{
+		"replace the groups of a dataset": {
+			dataset:  replaced,
+			groupIDs: []string{replaced.AgentGroupIDs[0], groupID.String()},
+			expected: []string{replaced.AgentGroupIDs[0], groupID.String()},
+			err:      nil,
+		},
+		"keep the groups of a dataset when omitted": {
+			dataset:  kept,
+			groupIDs: nil,
+			expected: kept.AgentGroupIDs,
+			err:      nil,
+		},
+		"replace the groups of a dataset with an invalid group": {
+			dataset:  invalid,
+			groupIDs: []string{"invalid"},
+			expected: invalid.AgentGroupIDs,
+			err:      policies.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, err := svc.EditDataset(context.Background(), token, policies.Dataset{ID: tc.dataset.ID, AgentGroupIDs: tc.groupIDs})
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+
+			ds, err := svc.ViewDatasetByID(context.Background(), token, tc.dataset.ID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.Equal(t, tc.expected, ds.AgentGroupIDs, fmt.Sprintf("%s: expected groups %v got %v", desc, tc.expected, ds.AgentGroupIDs))
+		})
+	}
+}
+
 func TestRemoveDataset(t *testing.T) {
 	users := flmocks.NewAuthService(map[string]string{token: email})
 	svc := newService(users)
@@ -727,13 +883,13 @@ func TestValidateDataset(t *testing.T) {
 		emptySinkIDs               []string
 		invalidSinkIDs             = []string{"invalid"}
 		sinkIDsArray               = []string{"f5b2d342-211d-a9ab-1233-63199a3fc16f", "03679425-aa69-4574-bf62-e0fe71b80939"}
-		dataset                    = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db", PolicyID: policy.ID, SinkIDs: &sinkIDsArray, Valid: true}
-		datasetEmptySinkID         = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db", PolicyID: policy.ID, SinkIDs: &emptySinkIDs, Valid: true}
-		datasetEmptyPolicyID       = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db", PolicyID: "", SinkIDs: &sinkIDsArray, Valid: true}
-		datasetEmptyAgentGroupID   = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "", PolicyID: policy.ID, SinkIDs: &sinkIDsArray, Valid: true}
-		datasetInvalidSinkID       = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db", PolicyID: policy.ID, SinkIDs: &invalidSinkIDs, Valid: true}
-		datasetInvalidPolicyID     = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db", PolicyID: "invalid", SinkIDs: &sinkIDsArray, Valid: true}
-		datasetInvalidAgentGroupID = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupID: "invalid", PolicyID: policy.ID, SinkIDs: &sinkIDsArray, Valid: true}
+		dataset                    = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupIDs: []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db"}, PolicyID: policy.ID, SinkIDs: &sinkIDsArray, Valid: true}
+		datasetEmptySinkID         = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupIDs: []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db"}, PolicyID: policy.ID, SinkIDs: &emptySinkIDs, Valid: true}
+		datasetEmptyPolicyID       = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupIDs: []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db"}, PolicyID: "", SinkIDs: &sinkIDsArray, Valid: true}
+		datasetEmptyAgentGroupID   = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, PolicyID: policy.ID, SinkIDs: &sinkIDsArray, Valid: true}
+		datasetInvalidSinkID       = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupIDs: []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db"}, PolicyID: policy.ID, SinkIDs: &invalidSinkIDs, Valid: true}
+		datasetInvalidPolicyID     = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupIDs: []string{"8fd6d12d-6a26-5d85-dc35-f9ba8f4d93db"}, PolicyID: "invalid", SinkIDs: &sinkIDsArray, Valid: true}
+		datasetInvalidAgentGroupID = policies.Dataset{Name: nameID, Tags: map[string]string{"region": "eu", "node_type": "dns"}, AgentGroupIDs: []string{"invalid"}, PolicyID: policy.ID, SinkIDs: &sinkIDsArray, Valid: true}
 	)
 
 	cases := map[string]struct {
@@ -960,11 +1116,11 @@ func TestListPoliciesByGroupIDInternal(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		dataset := policies.Dataset{
-			ID:           ID.String(),
-			Name:         validName,
-			PolicyID:     policy.ID,
-			AgentGroupID: agentGroupID.String(),
-			SinkIDs:      &sinkIDs,
+			ID:            ID.String(),
+			Name:          validName,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &sinkIDs,
 		}
 
 		ds, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1017,7 +1173,7 @@ func TestListPoliciesByGroupIDInternal(t *testing.T) {
 
 	for desc, tc := range cases {
 		t.Run(desc, func(t *testing.T) {
-			policies, err := svc.ListPoliciesByGroupIDInternal(context.Background(), tc.groupID, tc.ownerID)
+			policies, err := svc.ListPoliciesByGroupIDInternal(context.Background(), tc.groupID, tc.ownerID, "")
 			size := uint64(len(policies))
 			assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d", desc, tc.size, size))
 			assert.Equal(t, tc.policies, policies, fmt.Sprintf("%s: expected %p got %p", desc, tc.policies, policies))
@@ -1027,6 +1183,115 @@ func TestListPoliciesByGroupIDInternal(t *testing.T) {
 	}
 }
 
+func TestListPoliciesByGroupIDInternalExclusions(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	agentGroupID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	agentID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	otherAgentID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	policy := createPolicy(t, svc, "policy")
+
+	exclusions := map[string][]string{
+		"dataset-all":       nil,
+		"dataset-excluding": {agentID.String()},
+	}
+	for name, excluded := range exclusions {
+		validName, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		excluded := excluded
+		_, err = svc.AddDataset(context.Background(), token, policies.Dataset{
+			Name:             validName,
+			PolicyID:         policy.ID,
+			AgentGroupIDs:    []string{agentGroupID.String()},
+			SinkIDs:          &[]string{sinkID.String()},
+			ExcludedAgentIDs: &excluded,
+		})
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	}
+
+	invalidName, err := types.NewIdentifier("dataset-invalid")
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	_, err = svc.AddDataset(context.Background(), token, policies.Dataset{
+		Name:             invalidName,
+		PolicyID:         policy.ID,
+		AgentGroupIDs:    []string{agentGroupID.String()},
+		SinkIDs:          &[]string{sinkID.String()},
+		ExcludedAgentIDs: &[]string{"not-an-agent-id"},
+	})
+	assert.True(t, errors.Contains(err, policies.ErrMalformedEntity), fmt.Sprintf("expected %s got %s", policies.ErrMalformedEntity, err))
+
+	oID, _ := identify(token, users)
+	cases := map[string]struct {
+		agentID string
+		size    int
+	}{
+		"list the policies of the group":                    {agentID: "", size: 2},
+		"list the policies of an agent excluded":            {agentID: agentID.String(), size: 1},
+		"list the policies of an agent not excluded at all": {agentID: otherAgentID.String(), size: 2},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			plist, err := svc.ListPoliciesByGroupIDInternal(context.Background(), []string{agentGroupID.String()}, oID, tc.agentID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.Equal(t, tc.size, len(plist), fmt.Sprintf("%s: expected %d got %d", desc, tc.size, len(plist)))
+		})
+	}
+}
+
+func TestListPoliciesByGroupIDInternalMultipleGroups(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	groupIDs := make([]string, 3)
+	for i := range groupIDs {
+		groupID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		groupIDs[i] = groupID.String()
+	}
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	policy := createPolicy(t, svc, "policy")
+
+	validName, err := types.NewIdentifier("dataset-groups")
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	ds, err := svc.AddDataset(context.Background(), token, policies.Dataset{
+		Name:          validName,
+		PolicyID:      policy.ID,
+		AgentGroupIDs: groupIDs[:2],
+		SinkIDs:       &[]string{sinkID.String()},
+	})
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	oID, _ := identify(token, users)
+	cases := map[string]struct {
+		groupIDs []string
+		size     int
+	}{
+		"list the policies of the first group of the dataset":  {groupIDs: groupIDs[:1], size: 1},
+		"list the policies of the second group of the dataset": {groupIDs: groupIDs[1:2], size: 1},
+		"list the policies of both groups of the dataset":      {groupIDs: groupIDs[:2], size: 1},
+		"list the policies of a group out of the dataset":      {groupIDs: groupIDs[2:], size: 0},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			plist, err := svc.ListPoliciesByGroupIDInternal(context.Background(), tc.groupIDs, oID, "")
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+			assert.Equal(t, tc.size, len(plist), fmt.Sprintf("%s: expected %d got %d", desc, tc.size, len(plist)))
+			for _, p := range plist {
+				assert.Equal(t, ds.ID, p.DatasetID, fmt.Sprintf("%s: expected %s got %s", desc, ds.ID, p.DatasetID))
+			}
+		})
+	}
+}
+
 func TestRetrievePolicyByIDInternal(t *testing.T) {
 	users := flmocks.NewAuthService(map[string]string{token: email})
 	svc := newService(users)
@@ -1098,11 +1363,11 @@ func TestListDatasetsByPolicyIDInternal(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		dataset := policies.Dataset{
-			ID:           ID.String(),
-			Name:         validName,
-			PolicyID:     policy.ID,
-			AgentGroupID: agentGroupID.String(),
-			SinkIDs:      &sinkIDs,
+			ID:            ID.String(),
+			Name:          validName,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &sinkIDs,
 		}
 
 		ds, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1210,11 +1475,11 @@ func TestInactivateDatasetsByGroupID(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		dataset := policies.Dataset{
-			ID:           ID.String(),
-			Name:         validName,
-			PolicyID:     policy.ID,
-			AgentGroupID: agentGroupID.String(),
-			SinkIDs:      &sinkIDs,
+			ID:            ID.String(),
+			Name:          validName,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &sinkIDs,
 		}
 
 		ds, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1304,11 +1569,11 @@ func createDataset(t *testing.T, svc policies.Service, name string) policies.Dat
 	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		ID:           ID.String(),
-		Name:         validName,
-		PolicyID:     policyID.String(),
-		AgentGroupID: agentGroupID.String(),
-		SinkIDs:      &sinkIDs,
+		ID:            ID.String(),
+		Name:          validName,
+		PolicyID:      policyID.String(),
+		AgentGroupIDs: []string{agentGroupID.String()},
+		SinkIDs:       &sinkIDs,
 	}
 
 	res, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1346,11 +1611,11 @@ func TestDeleteSinkFromDataset(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		dataset := policies.Dataset{
-			ID:           ID.String(),
-			Name:         validName,
-			PolicyID:     policy.ID,
-			AgentGroupID: agentGroupID.String(),
-			SinkIDs:      &sinkIDs,
+			ID:            ID.String(),
+			Name:          validName,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &sinkIDs,
 		}
 
 		ds, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1422,11 +1687,11 @@ func TestInactivateDatasetByID(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		dataset := policies.Dataset{
-			ID:           ID.String(),
-			Name:         validName,
-			PolicyID:     policy.ID,
-			AgentGroupID: agentGroupID.String(),
-			SinkIDs:      &sinkIDs,
+			ID:            ID.String(),
+			Name:          validName,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &sinkIDs,
 		}
 
 		ds, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1497,11 +1762,11 @@ func TestDeleteAGroupFromDataset(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		dataset := policies.Dataset{
-			ID:           ID.String(),
-			Name:         validName,
-			PolicyID:     policy.ID,
-			AgentGroupID: agentGroupID.String(),
-			SinkIDs:      &sinkIDs,
+			ID:            ID.String(),
+			Name:          validName,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &sinkIDs,
 		}
 
 		ds, err := svc.AddDataset(context.Background(), token, dataset)
@@ -1542,6 +1807,46 @@ func TestDeleteAGroupFromDataset(t *testing.T) {
 	}
 }
 
+func TestDeleteAGroupFromMultiGroupDataset(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	groupIDs := make([]string, 2)
+	for i := range groupIDs {
+		groupID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		groupIDs[i] = groupID.String()
+	}
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	policy := createPolicy(t, svc, "policy")
+
+	validName, err := types.NewIdentifier("dataset-groups")
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	ds, err := svc.AddDataset(context.Background(), token, policies.Dataset{
+		Name:          validName,
+		PolicyID:      policy.ID,
+		AgentGroupIDs: groupIDs,
+		SinkIDs:       &[]string{sinkID.String()},
+		Valid:         true,
+	})
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	err = svc.DeleteAgentGroupFromAllDatasets(context.Background(), groupIDs[0], token)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	ds, err = svc.ViewDatasetByID(context.Background(), token, ds.ID)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	assert.Equal(t, groupIDs[1:], ds.AgentGroupIDs, "the removed group should leave the dataset")
+	assert.True(t, ds.Valid, "a dataset with groups left should stay valid")
+
+	err = svc.DeleteAgentGroupFromAllDatasets(context.Background(), groupIDs[1], token)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	ds, err = svc.ViewDatasetByID(context.Background(), token, ds.ID)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	assert.Empty(t, ds.AgentGroupIDs, "the removed group should leave the dataset")
+	assert.False(t, ds.Valid, "a dataset without groups should be invalid")
+}
+
 func TestRemoveAllDatasetsByPolicyIDInternal(t *testing.T) {
 	users := flmocks.NewAuthService(map[string]string{token: email})
 	svc := newService(users)
@@ -1554,11 +1859,11 @@ func TestRemoveAllDatasetsByPolicyIDInternal(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
 
 		svc.AddDataset(context.Background(), token, policies.Dataset{
-			Name:         nameID,
-			MFOwnerID:    ds.MFOwnerID,
-			AgentGroupID: ds.AgentGroupID,
-			PolicyID:     policy.ID,
-			SinkIDs:      ds.SinkIDs,
+			Name:          nameID,
+			MFOwnerID:     ds.MFOwnerID,
+			AgentGroupIDs: []string{ds.AgentGroupIDs[0]},
+			PolicyID:      policy.ID,
+			SinkIDs:       ds.SinkIDs,
 		})
 	}
 
@@ -1727,3 +2032,87 @@ func identify(token string, auth mainflux.AuthServiceClient) (string, error) {
 
 	return res.GetId(), nil
 }
+
+func TestViewPolicyStatus(t *testing.T) {
+	users := flmocks.NewAuthService(map[string]string{token: email})
+	svc := newService(users)
+
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	policy := createPolicy(t, svc, "policy")
+
+	validity := map[string]bool{
+		"dataset-1":       true,
+		"dataset-2":       true,
+		"dataset-invalid": false,
+	}
+	datasets := make(map[string]policies.Dataset)
+	for name, valid := range validity {
+		validName, err := types.NewIdentifier(name)
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		agentGroupID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+		datasets[name], err = svc.AddDataset(context.Background(), token, policies.Dataset{
+			Name:          validName,
+			Valid:         valid,
+			PolicyID:      policy.ID,
+			AgentGroupIDs: []string{agentGroupID.String()},
+			SinkIDs:       &[]string{sinkID.String()},
+		})
+		require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+	}
+
+	wrongID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	cases := map[string]struct {
+		view  func(ctx context.Context, token string, id string) (policies.PolicyStatus, error)
+		id    string
+		token string
+		total int
+		err   error
+	}{
+		"view status of a policy across its valid datasets": {
+			view:  svc.ViewPolicyStatus,
+			id:    policy.ID,
+			token: token,
+			total: 2,
+		},
+		"view status of a policy on a single dataset": {
+			view:  svc.ViewDatasetPolicyStatus,
+			id:    datasets["dataset-1"].ID,
+			token: token,
+			total: 1,
+		},
+		"view status of a policy on an invalid dataset": {
+			view:  svc.ViewDatasetPolicyStatus,
+			id:    datasets["dataset-invalid"].ID,
+			token: token,
+			total: 0,
+		},
+		"view status of a non-existing policy": {
+			view:  svc.ViewPolicyStatus,
+			id:    wrongID.String(),
+			token: token,
+			err:   policies.ErrNotFound,
+		},
+		"view status of a policy with an invalid token": {
+			view:  svc.ViewPolicyStatus,
+			id:    policy.ID,
+			token: invalidToken,
+			err:   policies.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			status, err := tc.view(context.Background(), tc.token, tc.id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err != nil {
+				return
+			}
+			assert.Equal(t, policy.ID, status.PolicyID, fmt.Sprintf("%s: expected %s got %s", desc, policy.ID, status.PolicyID))
+			assert.Equal(t, tc.total, status.Total, fmt.Sprintf("%s: expected %d got %d", desc, tc.total, status.Total))
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package policies
+
+import (
+	"context"
+
+	fleetpb "github.com/orb-community/orb/fleet/pb"
+)
+
+// PolicyStatusError counts the agents reporting the same error for a policy
+type PolicyStatusError struct {
+	Error string
+	Count int
+}
+
+// StalePolicyAgent is an agent reporting a version of the policy older than the current one
+type StalePolicyAgent struct {
+	AgentID   string
+	AgentName string
+	Version   int32
+}
+
+// PolicyStatus is the state of a policy across the agents it targets, as fleet aggregates it from their heartbeats.
+// DatasetID is only set when the status is restricted to the agents of a single dataset.
+type PolicyStatus struct {
+	PolicyID    string
+	DatasetID   string
+	Version     int32
+	Total       int
+	States      map[string]int
+	Errors      []PolicyStatusError
+	StaleAgents []StalePolicyAgent
+}
+
+func (s policiesService) ViewPolicyStatus(ctx context.Context, token string, policyID string) (PolicyStatus, error) {
+	ownerID, err := s.identify(token)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	pol, err := s.repo.RetrievePolicyByID(ctx, policyID, ownerID)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	datasets, err := s.repo.RetrieveDatasetsByPolicyID(ctx, policyID, ownerID)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	return s.policyStatus(ctx, pol, datasets, "")
+}
+
+func (s policiesService) ViewDatasetPolicyStatus(ctx context.Context, token string, datasetID string) (PolicyStatus, error) {
+	ownerID, err := s.identify(token)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	ds, err := s.repo.RetrieveDatasetByID(ctx, datasetID, ownerID)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	pol, err := s.repo.RetrievePolicyByID(ctx, ds.PolicyID, ownerID)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	return s.policyStatus(ctx, pol, []Dataset{ds}, ds.ID)
+}
+
+// policyStatus asks fleet for the status of the policy on every agent group of the valid datasets
+func (s policiesService) policyStatus(ctx context.Context, pol Policy, datasets []Dataset, datasetID string) (PolicyStatus, error) {
+	req := &fleetpb.PolicyStatusReq{
+		OwnerID:  pol.MFOwnerID,
+		PolicyID: pol.ID,
+		Version:  pol.Version,
+	}
+	for _, ds := range datasets {
+		if !ds.Valid {
+			continue
+		}
+		for _, groupID := range ds.AgentGroupIDs {
+			target := &fleetpb.PolicyStatusTarget{AgentGroupID: groupID}
+			if ds.ExcludedAgentIDs != nil {
+				target.ExcludedAgentIDs = *ds.ExcludedAgentIDs
+			}
+			req.Targets = append(req.Targets, target)
+		}
+	}
+
+	res, err := s.fleetGrpcClient.RetrievePolicyStatus(ctx, req)
+	if err != nil {
+		return PolicyStatus{}, err
+	}
+
+	status := PolicyStatus{
+		PolicyID:  pol.ID,
+		DatasetID: datasetID,
+		Version:   pol.Version,
+		Total:     int(res.GetTotal()),
+		States:    make(map[string]int, len(res.GetStates())),
+	}
+	for state, count := range res.GetStates() {
+		status.States[state] = int(count)
+	}
+	for _, e := range res.GetErrors() {
+		status.Errors = append(status.Errors, PolicyStatusError{Error: e.GetError(), Count: int(e.GetCount())})
+	}
+	for _, a := range res.GetStaleAgents() {
+		status.StaleAgents = append(status.StaleAgents, StalePolicyAgent{
+			AgentID:   a.GetAgentID(),
+			AgentName: a.GetAgentName(),
+			Version:   a.GetVersion(),
+		})
+	}
+	return status, nil
+}
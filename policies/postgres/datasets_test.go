@@ -50,14 +50,14 @@ func TestDatasetSave(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	// Conflict scenario
@@ -81,12 +81,12 @@ func TestDatasetSave(t *testing.T) {
 		},
 		"create new dataset with empty ownerID": {
 			dataset: policies.Dataset{
-				Name:         nameID,
-				MFOwnerID:    "",
-				Valid:        true,
-				AgentGroupID: groupID.String(),
-				PolicyID:     policyID.String(),
-				SinkIDs:      &sinkIDs,
+				Name:          nameID,
+				MFOwnerID:     "",
+				Valid:         true,
+				AgentGroupIDs: []string{groupID.String()},
+				PolicyID:      policyID.String(),
+				SinkIDs:       &sinkIDs,
 			},
 			err: errors.ErrMalformedEntity,
 		},
@@ -127,14 +127,14 @@ func TestDatasetUpdate(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dsID, err := repo.SaveDataset(context.Background(), dataset)
@@ -152,15 +152,15 @@ func TestDatasetUpdate(t *testing.T) {
 		},
 		"update a non-existing dataset": {
 			dataset: policies.Dataset{
-				Name:         nameID,
-				MFOwnerID:    oID.String(),
-				Valid:        true,
-				AgentGroupID: groupID.String(),
-				PolicyID:     policyID.String(),
-				SinkIDs:      &sinkIDs,
-				Metadata:     types.Metadata{"testkey": "testvalue"},
-				Created:      time.Time{},
-				ID:           wrongID.String(),
+				Name:          nameID,
+				MFOwnerID:     oID.String(),
+				Valid:         true,
+				AgentGroupIDs: []string{groupID.String()},
+				PolicyID:      policyID.String(),
+				SinkIDs:       &sinkIDs,
+				Metadata:      types.Metadata{"testkey": "testvalue"},
+				Created:       time.Time{},
+				ID:            wrongID.String(),
 			},
 			err: policies.ErrNotFound,
 		},
@@ -201,14 +201,14 @@ func TestDatasetDelete(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dsID, err := repo.SaveDataset(context.Background(), dataset)
@@ -265,14 +265,14 @@ func TestDatasetRetrieveByID(t *testing.T) {
 	}
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	id, err := repo.SaveDataset(context.Background(), dataset)
@@ -451,14 +451,14 @@ func TestInactivateDatasetByGroupID(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dsID, err := repo.SaveDataset(context.Background(), dataset)
@@ -473,11 +473,11 @@ func TestInactivateDatasetByGroupID(t *testing.T) {
 	}{
 		"inactivate a existing dataset by group ID": {
 			ownerID: dataset.MFOwnerID,
-			groupID: dataset.AgentGroupID,
+			groupID: dataset.AgentGroupIDs[0],
 			err:     nil,
 		},
 		"inactivate a dataset with non-existent owner": {
-			groupID: dataset.AgentGroupID,
+			groupID: dataset.AgentGroupIDs[0],
 			ownerID: wrongOID.String(),
 			err:     policies.ErrInactivateDataset,
 		},
@@ -520,14 +520,14 @@ func TestInactivateDatasetByPolicyID(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dsID, err := repo.SaveDataset(context.Background(), dataset)
@@ -655,14 +655,14 @@ func TestInactivateDatasetByID(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dataset2 := dataset
@@ -746,14 +746,14 @@ func TestDeleteSinkFromDataset(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	deleteSinkArray := []string{sinkIDs[0]}
@@ -849,14 +849,14 @@ func TestActivateDatasetByID(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        false,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         false,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dataset2 := dataset
@@ -940,14 +940,14 @@ func TestDeleteAgentGroupFromDataset(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dataset2 := dataset
@@ -973,7 +973,7 @@ func TestDeleteAgentGroupFromDataset(t *testing.T) {
 	}{
 		"delete a agent group from existing dataset": {
 			owner:    dataset.MFOwnerID,
-			groupID:  dataset.AgentGroupID,
+			groupID:  dataset.AgentGroupIDs[0],
 			contains: false,
 			dataset:  dataset,
 			err:      nil,
@@ -986,7 +986,7 @@ func TestDeleteAgentGroupFromDataset(t *testing.T) {
 			err:      nil,
 		},
 		"delete a agent group from a dataset with an invalid ownerID": {
-			groupID:  dataset2.AgentGroupID,
+			groupID:  dataset2.AgentGroupIDs[0],
 			owner:    "",
 			contains: true,
 			dataset:  dataset2,
@@ -1004,14 +1004,83 @@ func TestDeleteAgentGroupFromDataset(t *testing.T) {
 
 			switch tc.contains {
 			case false:
-				assert.NotEqual(t, d.AgentGroupID, tc.groupID, fmt.Sprintf("%s: expected '%s' to not contains '%s'", desc, d.AgentGroupID, tc.groupID))
+				assert.NotContains(t, d.AgentGroupIDs, tc.groupID, fmt.Sprintf("%s: expected '%v' to not contains '%s'", desc, d.AgentGroupIDs, tc.groupID))
 			case true:
-				assert.Equal(t, d.AgentGroupID, tc.groupID, fmt.Sprintf("%s: expected '%s' to contains '%s'", desc, d.AgentGroupID, tc.groupID))
+				assert.Contains(t, d.AgentGroupIDs, tc.groupID, fmt.Sprintf("%s: expected '%v' to contains '%s'", desc, d.AgentGroupIDs, tc.groupID))
 			}
 		})
 	}
 }
 
+func TestDeleteAgentGroupFromMultiGroupDataset(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	repo := postgres.NewPoliciesRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	groupIDs := make([]string, 2)
+	for i := 0; i < 2; i++ {
+		groupID, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		groupIDs[i] = groupID.String()
+	}
+
+	policyID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	sinkID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nameID, err := types.NewIdentifier("mydataset")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	dataset := policies.Dataset{
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: groupIDs,
+		PolicyID:      policyID.String(),
+		SinkIDs:       &[]string{sinkID.String()},
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+	}
+
+	dataset.ID, err = repo.SaveDataset(context.Background(), dataset)
+	require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		groupID  string
+		groupIDs []string
+		valid    bool
+	}{
+		{
+			desc:     "delete one of the agent groups of a dataset",
+			groupID:  groupIDs[0],
+			groupIDs: groupIDs[1:],
+			valid:    true,
+		},
+		{
+			desc:     "delete the last agent group of a dataset",
+			groupID:  groupIDs[1],
+			groupIDs: []string{},
+			valid:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := repo.DeleteAgentGroupFromAllDatasets(context.Background(), tc.groupID, dataset.MFOwnerID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+			d, err := repo.RetrieveDatasetByID(context.Background(), dataset.ID, dataset.MFOwnerID)
+			require.Nil(t, err, fmt.Sprintf("Unexpected error: %s", err))
+			assert.Equal(t, tc.groupIDs, d.AgentGroupIDs, fmt.Sprintf("%s: expected '%v' got '%v'", tc.desc, tc.groupIDs, d.AgentGroupIDs))
+			assert.Equal(t, tc.valid, d.Valid, fmt.Sprintf("%s: expected '%t' got '%t'", tc.desc, tc.valid, d.Valid))
+		})
+	}
+}
+
 func TestDeleteAllDatasetsPolicy(t *testing.T) {
 	dbMiddleware := postgres.NewDatabase(db)
 	repo := postgres.NewPoliciesRepository(dbMiddleware, logger)
@@ -1039,14 +1108,14 @@ func TestDeleteAllDatasetsPolicy(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         nameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID.String(),
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          nameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID.String(),
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 
 	dsID, err := repo.SaveDataset(context.Background(), dataset)
@@ -1122,14 +1191,14 @@ func TestDatasetsRetrieveByGroup(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	dataset := policies.Dataset{
-		Name:         dsnameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID,
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:          dsnameID,
+		MFOwnerID:     oID.String(),
+		Valid:         true,
+		AgentGroupIDs: []string{groupID.String()},
+		PolicyID:      policyID,
+		SinkIDs:       &sinkIDs,
+		Metadata:      types.Metadata{"testkey": "testvalue"},
+		Created:       time.Time{},
 	}
 	id, err := repo.SaveDataset(context.Background(), dataset)
 	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
@@ -105,6 +105,38 @@ func migrateDB(db *sqlx.DB) error {
 					format TEXT NOT NULL DEFAULT ''`,
 				},
 			},
+			{
+				Id: "policies_5",
+				Up: []string{
+					`ALTER TABLE IF EXISTS agent_policies ADD COLUMN IF NOT EXISTS
+					previous_policy JSONB`,
+					`ALTER TABLE IF EXISTS agent_policies ADD COLUMN IF NOT EXISTS
+					previous_policy_data TEXT NOT NULL DEFAULT ''`,
+				},
+			},
+			{
+				Id: "policies_6",
+				Up: []string{
+					`ALTER TABLE IF EXISTS datasets ADD COLUMN IF NOT EXISTS
+					excluded_agent_ids UUID[] NOT NULL DEFAULT '{}'`,
+				},
+			},
+			{
+				Id: "policies_7",
+				Up: []string{
+					`ALTER TABLE IF EXISTS datasets ADD COLUMN IF NOT EXISTS schedule JSONB,
+					ADD COLUMN IF NOT EXISTS schedule_paused BOOLEAN NOT NULL DEFAULT FALSE`,
+				},
+			},
+			{
+				Id: "policies_8",
+				Up: []string{
+					`ALTER TABLE IF EXISTS datasets ADD COLUMN IF NOT EXISTS
+					agent_group_ids UUID[] NOT NULL DEFAULT '{}'`,
+					`UPDATE datasets SET agent_group_ids = ARRAY[agent_group_id] WHERE agent_group_id IS NOT NULL`,
+					`ALTER TABLE IF EXISTS datasets DROP COLUMN IF EXISTS agent_group_id`,
+				},
+			},
 		},
 	}
 
@@ -35,9 +35,9 @@ type policiesRepository struct {
 }
 
 func (r policiesRepository) RetrieveDatasetsByGroupID(ctx context.Context, groupIDs []string, ownerID string) ([]policies.Dataset, error) {
-	q := `SELECT id, agent_group_id, sink_ids, agent_policy_id
+	q := `SELECT id, agent_group_ids, sink_ids, agent_policy_id, excluded_agent_ids
 			FROM datasets
-			WHERE valid = TRUE AND agent_group_id IN (?) AND mf_owner_id = ?`
+			WHERE valid = TRUE AND agent_group_ids && ARRAY[?]::UUID[] AND mf_owner_id = ?`
 
 	if len(groupIDs) == 0 || ownerID == "" {
 		return nil, errors.ErrMalformedEntity
@@ -64,7 +64,8 @@ func (r policiesRepository) RetrieveDatasetsByGroupID(ctx context.Context, group
 		}
 
 		th := toDataset(dbth)
-		items = append(items, policies.Dataset{ID: th.ID, PolicyID: th.PolicyID, SinkIDs: th.SinkIDs, AgentGroupID: th.AgentGroupID})
+		items = append(items, policies.Dataset{ID: th.ID, PolicyID: th.PolicyID, SinkIDs: th.SinkIDs, AgentGroupIDs: th.AgentGroupIDs,
+			ExcludedAgentIDs: th.ExcludedAgentIDs})
 	}
 
 	return items, nil
@@ -89,7 +90,11 @@ func (r policiesRepository) DeletePolicy(ctx context.Context, ownerID string, po
 }
 
 func (r policiesRepository) UpdatePolicy(ctx context.Context, owner string, plcy policies.Policy) error {
-	q := `UPDATE agent_policies SET name = :name, description = :description, orb_tags = :orb_tags, policy = :policy, version = :version, ts_last_modified = CURRENT_TIMESTAMP, policy_data = :policy_data WHERE mf_owner_id = :mf_owner_id AND id = :id;`
+	// the content being replaced is kept, so a staged rollout of the update can be rolled back
+	q := `UPDATE agent_policies SET name = :name, description = :description, orb_tags = :orb_tags,
+			previous_policy = CASE WHEN policy IS DISTINCT FROM :policy OR policy_data IS DISTINCT FROM :policy_data THEN policy ELSE previous_policy END,
+			previous_policy_data = CASE WHEN policy IS DISTINCT FROM :policy OR policy_data IS DISTINCT FROM :policy_data THEN policy_data ELSE previous_policy_data END,
+			policy = :policy, version = :version, ts_last_modified = CURRENT_TIMESTAMP, policy_data = :policy_data WHERE mf_owner_id = :mf_owner_id AND id = :id;`
 	plcyDB, err := toDBPolicy(plcy)
 	if err != nil {
 		return errors.Wrap(policies.ErrUpdateEntity, err)
@@ -123,6 +128,41 @@ func (r policiesRepository) UpdatePolicy(ctx context.Context, owner string, plcy
 	return nil
 }
 
+func (r policiesRepository) RollbackPolicy(ctx context.Context, ownerID string, policyID string, version int32) error {
+	q := `UPDATE agent_policies SET policy = previous_policy, policy_data = previous_policy_data,
+			previous_policy = policy, previous_policy_data = policy_data, version = version + 1, ts_last_modified = CURRENT_TIMESTAMP
+			WHERE mf_owner_id = :mf_owner_id AND id = :id AND version = :version AND previous_policy IS NOT NULL;`
+
+	if ownerID == "" || policyID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	params := map[string]interface{}{
+		"mf_owner_id": ownerID,
+		"id":          policyID,
+		"version":     version,
+	}
+	res, err := r.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == db.ErrInvalid {
+			return errors.Wrap(policies.ErrNotFound, err)
+		}
+		return errors.Wrap(policies.ErrUpdateEntity, err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(policies.ErrUpdateEntity, err)
+	}
+
+	if count == 0 {
+		return policies.ErrRollbackPolicy
+	}
+
+	return nil
+}
+
 func (r policiesRepository) RetrieveAll(ctx context.Context, owner string, pm policies.PageMetadata) (policies.Page, error) {
 	nameQuery, name := getNameQuery(pm.Name)
 	orderQuery := getOrderQuery(pm.Order)
@@ -182,11 +222,13 @@ func (r policiesRepository) RetrieveAll(ctx context.Context, owner string, pm po
 	return page, nil
 }
 
-func (r policiesRepository) RetrievePoliciesByGroupID(ctx context.Context, groupIDs []string, ownerID string) ([]policies.PolicyInDataset, error) {
+func (r policiesRepository) RetrievePoliciesByGroupID(ctx context.Context, groupIDs []string, ownerID string, agentID string) ([]policies.PolicyInDataset, error) {
 
-	q := `SELECT agent_policies.id AS id, datasets.id AS dataset_id, agent_policies.name AS name, 
-             agent_group_id, agent_policies.mf_owner_id, orb_tags, backend, version, policy, format, agent_policies.ts_created
-			FROM agent_policies, datasets
+	// a dataset is listed once, with the first of its groups among the given ones
+	q := `SELECT DISTINCT ON (datasets.id) agent_policies.id AS id, datasets.id AS dataset_id, agent_policies.name AS name, 
+             agent_group_id, agent_policies.mf_owner_id, orb_tags, backend, version, policy, format, agent_policies.ts_created,
+             excluded_agent_ids
+			FROM agent_policies, datasets, unnest(datasets.agent_group_ids) WITH ORDINALITY AS dataset_groups(agent_group_id, group_position)
 			WHERE agent_policies.id = datasets.agent_policy_id AND agent_policies.mf_owner_id = datasets.mf_owner_id AND valid = TRUE AND
 				agent_group_id IN (?) AND agent_policies.mf_owner_id = ?`
 
@@ -194,7 +236,14 @@ func (r policiesRepository) RetrievePoliciesByGroupID(ctx context.Context, group
 		return nil, errors.ErrMalformedEntity
 	}
 
-	query, args, err := sqlx.In(q, groupIDs, ownerID)
+	args := []interface{}{groupIDs, ownerID}
+	if agentID != "" {
+		q += ` AND NOT (excluded_agent_ids @> ARRAY[?]::UUID[])`
+		args = append(args, agentID)
+	}
+	q += ` ORDER BY datasets.id, group_position`
+
+	query, args, err := sqlx.In(q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +265,8 @@ func (r policiesRepository) RetrievePoliciesByGroupID(ctx context.Context, group
 
 		th := toPolicy(dbth)
 		r.logger.Debug("policy format", zap.String("format", th.Format))
-		items = append(items, policies.PolicyInDataset{Policy: th, DatasetID: dbth.DataSetID, AgentGroupID: dbth.AgentGroupID})
+		items = append(items, policies.PolicyInDataset{Policy: th, DatasetID: dbth.DataSetID, AgentGroupID: dbth.AgentGroupID,
+			ExcludedAgentIDs: dbth.ExcludedAgentIDs})
 	}
 
 	return items, nil
@@ -241,14 +291,25 @@ func (r policiesRepository) RetrievePolicyByID(ctx context.Context, policyID str
 }
 
 func (r policiesRepository) UpdateDataset(ctx context.Context, ownerID string, ds policies.Dataset) error {
-	q := `UPDATE datasets SET tags = :tags, sink_ids = :sink_ids, name = :name WHERE mf_owner_id = :mf_owner_id AND id = :id;`
+	q := `UPDATE datasets SET tags = :tags, sink_ids = :sink_ids, name = :name, agent_group_ids = :agent_group_ids,
+			excluded_agent_ids = :excluded_agent_ids, schedule = :schedule, schedule_paused = :schedule_paused
+			WHERE mf_owner_id = :mf_owner_id AND id = :id;`
+
+	schedule, err := toDBSchedule(ds.Schedule)
+	if err != nil {
+		return errors.Wrap(policies.ErrMalformedEntity, err)
+	}
 
 	params := map[string]interface{}{
-		"mf_owner_id": ds.MFOwnerID,
-		"tags":        db.Tags(ds.Tags),
-		"sink_ids":    pq.Array(ds.SinkIDs),
-		"id":          ds.ID,
-		"name":        ds.Name,
+		"mf_owner_id":        ds.MFOwnerID,
+		"tags":               db.Tags(ds.Tags),
+		"sink_ids":           pq.Array(ds.SinkIDs),
+		"id":                 ds.ID,
+		"name":               ds.Name,
+		"agent_group_ids":    toDBAgentGroupIDs(ds.AgentGroupIDs),
+		"excluded_agent_ids": toDBExcludedAgentIDs(ds.ExcludedAgentIDs),
+		"schedule":           schedule,
+		"schedule_paused":    ds.SchedulePaused,
 	}
 
 	res, err := r.db.NamedExecContext(ctx, q, params)
@@ -309,8 +370,8 @@ func (r policiesRepository) DeleteDataset(ctx context.Context, ownerID string, d
 
 func (r policiesRepository) SaveDataset(ctx context.Context, dataset policies.Dataset) (string, error) {
 
-	q := `INSERT INTO datasets (name, mf_owner_id, metadata, valid, agent_group_id, agent_policy_id, sink_ids, tags)         
-			  VALUES (:name, :mf_owner_id, :metadata, :valid, :agent_group_id, :agent_policy_id, :sink_ids_str, :tags) RETURNING id`
+	q := `INSERT INTO datasets (name, mf_owner_id, metadata, valid, agent_group_ids, agent_policy_id, sink_ids, tags, excluded_agent_ids, schedule, schedule_paused)         
+			  VALUES (:name, :mf_owner_id, :metadata, :valid, :agent_group_ids, :agent_policy_id, :sink_ids_str, :tags, :excluded_agent_ids, :schedule, :schedule_paused) RETURNING id`
 
 	if !dataset.Name.IsValid() || dataset.MFOwnerID == "" {
 		return "", errors.ErrMalformedEntity
@@ -346,7 +407,8 @@ func (r policiesRepository) SaveDataset(ctx context.Context, dataset policies.Da
 }
 
 func (r policiesRepository) InactivateDatasetByGroupID(ctx context.Context, groupID string, ownerID string) error {
-	q := `UPDATE datasets SET valid = false WHERE mf_owner_id = :mf_owner_id and agent_group_id = :agent_group_id`
+	q := `UPDATE datasets SET valid = false WHERE mf_owner_id = :mf_owner_id AND cardinality(agent_group_ids) = 1 AND
+			:agent_group_id = ANY(agent_group_ids)`
 
 	params := map[string]interface{}{
 		"agent_group_id": groupID,
@@ -438,7 +500,7 @@ func (r policiesRepository) SavePolicy(ctx context.Context, policy policies.Poli
 
 func (r policiesRepository) RetrieveDatasetsByPolicyID(ctx context.Context, policyID string, ownerID string) ([]policies.Dataset, error) {
 
-	q := `SELECT id, name, mf_owner_id, valid, agent_group_id, agent_policy_id, sink_ids, metadata, ts_created 
+	q := `SELECT id, name, mf_owner_id, valid, agent_group_ids, agent_policy_id, sink_ids, metadata, ts_created, excluded_agent_ids, schedule, schedule_paused 
 			FROM datasets
 			WHERE agent_policy_id = ? AND mf_owner_id = ?`
 
@@ -474,7 +536,8 @@ func (r policiesRepository) RetrieveDatasetsByPolicyID(ctx context.Context, poli
 }
 
 func (r policiesRepository) RetrieveDatasetByID(ctx context.Context, datasetID string, ownerID string) (policies.Dataset, error) {
-	q := `SELECT id, name, mf_owner_id, valid, agent_group_id, agent_policy_id, sink_ids, metadata, ts_created FROM datasets WHERE id = $1 AND mf_owner_id = $2`
+	q := `SELECT id, name, mf_owner_id, valid, agent_group_ids, agent_policy_id, sink_ids, metadata, ts_created, excluded_agent_ids, schedule, schedule_paused
+			FROM datasets WHERE id = $1 AND mf_owner_id = $2`
 
 	if datasetID == "" || ownerID == "" {
 		return policies.Dataset{}, errors.ErrMalformedEntity
@@ -496,7 +559,7 @@ func (r policiesRepository) RetrieveAllDatasetsByOwner(ctx context.Context, owne
 	orderQuery := getOrderQuery(pm.Order)
 	dirQuery := getDirQuery(pm.Dir)
 
-	q := fmt.Sprintf(`SELECT id, name, mf_owner_id, valid, agent_group_id, agent_policy_id, sink_ids, metadata, tags, ts_created 
+	q := fmt.Sprintf(`SELECT id, name, mf_owner_id, valid, agent_group_ids, agent_policy_id, sink_ids, metadata, tags, ts_created, excluded_agent_ids, schedule, schedule_paused 
 			FROM datasets
 			WHERE mf_owner_id = :mf_owner_id %s ORDER BY %s %s LIMIT :limit OFFSET :offset;`, nameQuery, orderQuery, dirQuery)
 
@@ -545,6 +608,65 @@ func (r policiesRepository) RetrieveAllDatasetsByOwner(ctx context.Context, owne
 	return pageDataset, nil
 }
 
+func (r policiesRepository) RetrieveScheduledDatasets(ctx context.Context) ([]policies.Dataset, error) {
+	q := `SELECT id, name, mf_owner_id, valid, agent_group_ids, agent_policy_id, sink_ids, metadata, tags, ts_created, excluded_agent_ids, schedule, schedule_paused
+			FROM datasets WHERE schedule IS NOT NULL`
+
+	rows, err := r.db.QueryxContext(ctx, q)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []policies.Dataset
+	for rows.Next() {
+		var dbth dbDataset
+		if err := rows.StructScan(&dbth); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		items = append(items, toDataset(dbth))
+	}
+
+	return items, nil
+}
+
+func (r policiesRepository) UpdateDatasetSchedulePaused(ctx context.Context, ownerID string, datasetID string, paused bool) error {
+	// only one of the replicas checking the schedules at once gets to change the dataset
+	q := `UPDATE datasets SET schedule_paused = :schedule_paused
+			WHERE mf_owner_id = :mf_owner_id AND id = :id AND schedule_paused = NOT :schedule_paused`
+
+	if ownerID == "" || datasetID == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	params := map[string]interface{}{
+		"mf_owner_id":     ownerID,
+		"id":              datasetID,
+		"schedule_paused": paused,
+	}
+
+	res, err := r.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case db.ErrInvalid, db.ErrTruncation:
+				return errors.Wrap(policies.ErrMalformedEntity, err)
+			}
+		}
+		return errors.Wrap(policies.ErrUpdateEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(policies.ErrUpdateEntity, err)
+	}
+	if cnt == 0 {
+		return policies.ErrNotFound
+	}
+	return nil
+}
+
 func (r policiesRepository) InactivateDatasetByID(ctx context.Context, id string, ownerID string) error {
 	q := `UPDATE datasets SET valid = false WHERE mf_owner_id = :mf_owner_id AND :id = id`
 
@@ -635,7 +757,10 @@ func (r policiesRepository) ActivateDatasetByID(ctx context.Context, id string,
 }
 
 func (r policiesRepository) DeleteAgentGroupFromAllDatasets(ctx context.Context, groupID string, ownerID string) error {
-	q := `UPDATE datasets SET agent_group_id = null WHERE mf_owner_id = :mf_owner_id AND agent_group_id = :agent_group_id`
+	// the update reads the groups before the removal, so the datasets losing their last group turn invalid
+	q := `UPDATE datasets SET agent_group_ids = array_remove(agent_group_ids, :agent_group_id),
+			valid = valid AND cardinality(agent_group_ids) > 1
+			WHERE mf_owner_id = :mf_owner_id AND :agent_group_id = ANY(agent_group_ids)`
 
 	if ownerID == "" {
 		return errors.ErrMalformedEntity
@@ -696,21 +821,22 @@ func (r policiesRepository) DeleteAllDatasetsPolicy(ctx context.Context, policyI
 }
 
 type dbPolicy struct {
-	ID            string           `db:"id"`
-	Name          types.Identifier `db:"name"`
-	MFOwnerID     string           `db:"mf_owner_id"`
-	Backend       string           `db:"backend"`
-	SchemaVersion string           `db:"schema_version"`
-	Description   string           `db:"description"`
-	OrbTags       db.Tags          `db:"orb_tags"`
-	Policy        db.Metadata      `db:"policy"`
-	PolicyData    string           `db:"policy_data"`
-	Format        string           `db:"format"`
-	Version       int32            `db:"version"`
-	Created       time.Time        `db:"ts_created"`
-	DataSetID     string           `db:"dataset_id"`
-	AgentGroupID  string           `db:"agent_group_id"`
-	LastModified  time.Time        `db:"ts_last_modified"`
+	ID               string           `db:"id"`
+	Name             types.Identifier `db:"name"`
+	MFOwnerID        string           `db:"mf_owner_id"`
+	Backend          string           `db:"backend"`
+	SchemaVersion    string           `db:"schema_version"`
+	Description      string           `db:"description"`
+	OrbTags          db.Tags          `db:"orb_tags"`
+	Policy           db.Metadata      `db:"policy"`
+	PolicyData       string           `db:"policy_data"`
+	Format           string           `db:"format"`
+	Version          int32            `db:"version"`
+	Created          time.Time        `db:"ts_created"`
+	DataSetID        string           `db:"dataset_id"`
+	AgentGroupID     string           `db:"agent_group_id"`
+	LastModified     time.Time        `db:"ts_last_modified"`
+	ExcludedAgentIDs pq.StringArray   `db:"excluded_agent_ids"`
 }
 
 func toDBPolicy(policy policies.Policy) (dbPolicy, error) {
@@ -745,17 +871,20 @@ func toDBPolicy(policy policies.Policy) (dbPolicy, error) {
 }
 
 type dbDataset struct {
-	ID           string           `db:"id"`
-	Name         types.Identifier `db:"name"`
-	MFOwnerID    string           `db:"mf_owner_id"`
-	Metadata     db.Metadata      `db:"metadata"`
-	Valid        bool             `db:"valid"`
-	AgentGroupID sql.NullString   `db:"agent_group_id"`
-	PolicyID     sql.NullString   `db:"agent_policy_id"`
-	TsCreated    time.Time        `db:"ts_created"`
-	Tags         db.Tags          `db:"tags"`
-	SinkIDs      pq.StringArray   `db:"sink_ids"`
-	SinksIDsStr  interface{}      `db:"sink_ids_str"`
+	ID               string           `db:"id"`
+	Name             types.Identifier `db:"name"`
+	MFOwnerID        string           `db:"mf_owner_id"`
+	Metadata         db.Metadata      `db:"metadata"`
+	Valid            bool             `db:"valid"`
+	AgentGroupIDs    pq.StringArray   `db:"agent_group_ids"`
+	PolicyID         sql.NullString   `db:"agent_policy_id"`
+	TsCreated        time.Time        `db:"ts_created"`
+	Tags             db.Tags          `db:"tags"`
+	SinkIDs          pq.StringArray   `db:"sink_ids"`
+	SinksIDsStr      interface{}      `db:"sink_ids_str"`
+	ExcludedAgentIDs pq.StringArray   `db:"excluded_agent_ids"`
+	Schedule         sql.NullString   `db:"schedule"`
+	SchedulePaused   bool             `db:"schedule_paused"`
 }
 
 func toDBDataset(dataset policies.Dataset) (dbDataset, error) {
@@ -774,12 +903,17 @@ func toDBDataset(dataset policies.Dataset) (dbDataset, error) {
 		Tags:        db.Tags(dataset.Tags),
 		SinksIDsStr: pq.Array(dataset.SinkIDs),
 	}
+	d.ExcludedAgentIDs = toDBExcludedAgentIDs(dataset.ExcludedAgentIDs)
+	d.Schedule, err = toDBSchedule(dataset.Schedule)
+	if err != nil {
+		return dbDataset{}, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+	d.SchedulePaused = dataset.SchedulePaused
 
-	d.Valid = true
-	if dataset.AgentGroupID != "" {
-		d.AgentGroupID = sql.NullString{String: dataset.AgentGroupID, Valid: true}
-	} else {
-		d.AgentGroupID = sql.NullString{Valid: false}
+	// a dataset created outside its schedule stays invalid until the schedule starts
+	d.Valid = !dataset.SchedulePaused
+	d.AgentGroupIDs = toDBAgentGroupIDs(dataset.AgentGroupIDs)
+	if len(dataset.AgentGroupIDs) == 0 {
 		d.Valid = false
 	}
 	if dataset.PolicyID != "" {
@@ -821,21 +955,64 @@ func toPolicy(dba dbPolicy) policies.Policy {
 
 func toDataset(dba dbDataset) policies.Dataset {
 	dataset := policies.Dataset{
-		ID:           dba.ID,
-		Name:         dba.Name,
-		MFOwnerID:    dba.MFOwnerID,
-		Valid:        dba.Valid,
-		AgentGroupID: dba.AgentGroupID.String,
-		PolicyID:     dba.PolicyID.String,
-		SinkIDs:      (*[]string)(&dba.SinkIDs),
-		Metadata:     types.Metadata(dba.Metadata),
-		Created:      dba.TsCreated,
-		Tags:         types.Tags(dba.Tags),
-	}
+		ID:            dba.ID,
+		Name:          dba.Name,
+		MFOwnerID:     dba.MFOwnerID,
+		Valid:         dba.Valid,
+		AgentGroupIDs: []string(dba.AgentGroupIDs),
+		PolicyID:      dba.PolicyID.String,
+		SinkIDs:       (*[]string)(&dba.SinkIDs),
+		Metadata:      types.Metadata(dba.Metadata),
+		Created:       dba.TsCreated,
+		Tags:          types.Tags(dba.Tags),
+	}
+	excluded := []string(dba.ExcludedAgentIDs)
+	dataset.ExcludedAgentIDs = &excluded
+	dataset.Schedule = toSchedule(dba.Schedule)
+	dataset.SchedulePaused = dba.SchedulePaused
 
 	return dataset
 }
 
+// toDBSchedule is the value of the schedule column, null when the dataset has no schedule
+func toDBSchedule(schedule *policies.DatasetSchedule) (sql.NullString, error) {
+	if schedule == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(schedule)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func toSchedule(schedule sql.NullString) *policies.DatasetSchedule {
+	if !schedule.Valid {
+		return nil
+	}
+	var s policies.DatasetSchedule
+	if err := json.Unmarshal([]byte(schedule.String), &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+// toDBAgentGroupIDs is the value of the agent groups column, which is never null
+func toDBAgentGroupIDs(groupIDs []string) pq.StringArray {
+	if groupIDs == nil {
+		return pq.StringArray{}
+	}
+	return groupIDs
+}
+
+// toDBExcludedAgentIDs is the value of the excluded agents column, which is never null
+func toDBExcludedAgentIDs(agentIDs *[]string) pq.StringArray {
+	if agentIDs == nil || *agentIDs == nil {
+		return pq.StringArray{}
+	}
+	return *agentIDs
+}
+
 func getNameQuery(name string) (string, string) {
 	if name == "" {
 		return "", ""
@@ -294,15 +294,22 @@ func TestAgentPoliciesRetrieveByGroup(t *testing.T) {
 	dsnameID, err := types.NewIdentifier("mydataset")
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
+	excludedID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	agentID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
 	dataset := policies.Dataset{
-		Name:         dsnameID,
-		MFOwnerID:    oID.String(),
-		Valid:        true,
-		AgentGroupID: groupID.String(),
-		PolicyID:     policyID,
-		SinkIDs:      &sinkIDs,
-		Metadata:     types.Metadata{"testkey": "testvalue"},
-		Created:      time.Time{},
+		Name:             dsnameID,
+		MFOwnerID:        oID.String(),
+		Valid:            true,
+		AgentGroupIDs:    []string{groupID.String()},
+		PolicyID:         policyID,
+		SinkIDs:          &sinkIDs,
+		Metadata:         types.Metadata{"testkey": "testvalue"},
+		Created:          time.Time{},
+		ExcludedAgentIDs: &[]string{excludedID.String()},
 	}
 	dsID, err := repo.SaveDataset(context.Background(), dataset)
 	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
@@ -310,6 +317,7 @@ func TestAgentPoliciesRetrieveByGroup(t *testing.T) {
 	cases := map[string]struct {
 		groupID []string
 		ownerID string
+		agentID string
 		dsID    string
 		results int
 		err     error
@@ -321,6 +329,22 @@ func TestAgentPoliciesRetrieveByGroup(t *testing.T) {
 			results: 1,
 			err:     nil,
 		},
+		"retrieve existing policies by group ID and ownerID for an agent not excluded": {
+			groupID: []string{groupID.String()},
+			ownerID: policy.MFOwnerID,
+			agentID: agentID.String(),
+			dsID:    dsID,
+			results: 1,
+			err:     nil,
+		},
+		"retrieve existing policies by group ID and ownerID for an agent excluded": {
+			groupID: []string{groupID.String()},
+			ownerID: policy.MFOwnerID,
+			agentID: excludedID.String(),
+			dsID:    dsID,
+			results: 0,
+			err:     nil,
+		},
 		"retrieve non existing policies by group ID and ownerID": {
 			groupID: []string{policy.MFOwnerID},
 			ownerID: policy.MFOwnerID,
@@ -346,7 +370,7 @@ func TestAgentPoliciesRetrieveByGroup(t *testing.T) {
 
 	for desc, tc := range cases {
 		t.Run(desc, func(t *testing.T) {
-			plist, err := repo.RetrievePoliciesByGroupID(context.Background(), tc.groupID, tc.ownerID)
+			plist, err := repo.RetrievePoliciesByGroupID(context.Background(), tc.groupID, tc.ownerID, tc.agentID)
 			if err == nil {
 				assert.Equal(t, tc.results, len(plist), fmt.Sprintf("%s: expected %d got %d\n", desc, tc.results, len(plist)))
 				if tc.results > 0 {
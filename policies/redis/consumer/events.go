@@ -16,6 +16,14 @@ type removeAgentGroupEvent struct {
 	timestamp time.Time
 }
 
+type rolloutRollbackEvent struct {
+	rolloutID string
+	policyID  string
+	ownerID   string
+	version   int32
+	reason    string
+}
+
 type removeSinkEvent struct {
 	sinkID    string
 	ownerID   string
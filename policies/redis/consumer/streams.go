@@ -11,8 +11,10 @@ package consumer
 import (
 	"context"
 	"github.com/go-redis/redis/v8"
+	"github.com/orb-community/orb/pkg/errors"
 	"github.com/orb-community/orb/policies"
 	"go.uber.org/zap"
+	"strconv"
 )
 
 const (
@@ -22,6 +24,8 @@ const (
 
 	agentGroupPrefix = "agent_group."
 	agentGroupRemove = agentGroupPrefix + "remove"
+	rolloutPrefix    = "policy_rollout."
+	rolloutRollback  = rolloutPrefix + "rollback"
 	sinkPrefix       = "sinks."
 	sinkRemove       = sinkPrefix + "remove"
 
@@ -75,6 +79,12 @@ func (es eventStore) SubscribeToFleet(context context.Context) error {
 			case agentGroupRemove:
 				rte := decodeAgentGroupRemove(event)
 				err = es.handleAgentGroupRemove(context, rte.groupID, rte.token)
+			case rolloutRollback:
+				var rre rolloutRollbackEvent
+				rre, err = decodeRolloutRollback(event)
+				if err == nil {
+					err = es.handleRolloutRollback(context, rre)
+				}
 			}
 			if err != nil {
 				es.logger.Error("Failed to handle event", zap.String("operation", event["operation"].(string)), zap.Error(err))
@@ -135,15 +145,37 @@ func decodeSinkRemove(event map[string]interface{}) removeSinkEvent {
 	}
 }
 
-// Inactivate a Dataset after AgentGroup deletion
-func (es eventStore) handleAgentGroupRemove(ctx context.Context, groupID string, token string) error {
-
-	err := es.policiesService.InactivateDatasetByGroupID(ctx, groupID, token)
+func decodeRolloutRollback(event map[string]interface{}) (rolloutRollbackEvent, error) {
+	val := rolloutRollbackEvent{
+		rolloutID: read(event, "rollout_id", ""),
+		policyID:  read(event, "policy_id", ""),
+		ownerID:   read(event, "owner_id", ""),
+		reason:    read(event, "reason", ""),
+	}
+	version, err := strconv.ParseInt(read(event, "version", "0"), 10, 32)
 	if err != nil {
-		return err
+		return rolloutRollbackEvent{}, err
+	}
+	val.version = int32(version)
+	return val, nil
+}
+
+// Restore the previous content of a policy after its staged rollout failed or was rolled back
+func (es eventStore) handleRolloutRollback(ctx context.Context, e rolloutRollbackEvent) error {
+	_, _, err := es.policiesService.RollbackPolicyInternal(ctx, e.ownerID, e.policyID, e.version)
+	if err != nil && errors.Contains(err, policies.ErrRollbackPolicy) {
+		// the policy was edited again since the rollout started, the newer version wins
+		es.logger.Warn("policy rollout not rolled back", zap.String("rollout_id", e.rolloutID),
+			zap.String("policy_id", e.policyID), zap.Error(err))
+		return nil
 	}
+	return err
+}
+
+// Remove the AgentGroup from the Datasets after its deletion, inactivating the ones left without groups
+func (es eventStore) handleAgentGroupRemove(ctx context.Context, groupID string, token string) error {
 
-	err = es.policiesService.DeleteAgentGroupFromAllDatasets(ctx, groupID, token)
+	err := es.policiesService.DeleteAgentGroupFromAllDatasets(ctx, groupID, token)
 	if err != nil {
 		return err
 	}
@@ -36,34 +36,41 @@ var (
 )
 
 type createDatasetEvent struct {
-	id           string
-	ownerID      string
-	name         string
-	agentGroupID string
-	policyID     string
-	sinkIDs      string
-	timestamp    time.Time
+	id       string
+	ownerID  string
+	name     string
+	groupIDs string
+	policyID string
+	sinkIDs  string
+	// schedulePaused is set when the dataset is created outside its schedule, so agents do not run it yet
+	schedulePaused bool
+	timestamp      time.Time
 }
 
 type removeDatasetEvent struct {
-	id           string
-	ownerID      string
-	agentGroupID string
-	datasetID    string
-	policyID     string
-	timestamp    time.Time
+	id        string
+	ownerID   string
+	groupIDs  string
+	datasetID string
+	policyID  string
+	timestamp time.Time
 }
 
 type updateDatasetEvent struct {
 	id            string
 	ownerID       string
-	agentGroupID  string
+	groupIDs      string
 	datasetID     string
 	policyID      string
 	valid         bool
 	turnedValid   bool
 	turnedInvalid bool
-	timestamp     time.Time
+	// addedGroupIDs and removedGroupIDs list the groups the edit added to and removed from the dataset
+	addedGroupIDs   string
+	removedGroupIDs string
+	// exclusionsChanged lists the agents excluded or included again by the edit
+	exclusionsChanged string
+	timestamp         time.Time
 }
 
 type createPolicyEvent struct {
@@ -77,6 +84,8 @@ type updatePolicyEvent struct {
 	id        string
 	ownerID   string
 	groupIDs  string
+	version   int32
+	rollout   string
 	timestamp time.Time
 }
 
@@ -90,23 +99,27 @@ type removePolicyEvent struct {
 }
 
 func (cce createDatasetEvent) Encode() map[string]interface{} {
-	return map[string]interface{}{
-		"id":        cce.id,
-		"owner_id":  cce.ownerID,
-		"name":      cce.name,
-		"group_id":  cce.agentGroupID,
-		"policy_id": cce.policyID,
-		"sink_ids":  cce.sinkIDs,
-		"timestamp": cce.timestamp.Unix(),
-		"operation": DatasetCreate,
+	val := map[string]interface{}{
+		"id":         cce.id,
+		"owner_id":   cce.ownerID,
+		"name":       cce.name,
+		"groups_ids": cce.groupIDs,
+		"policy_id":  cce.policyID,
+		"sink_ids":   cce.sinkIDs,
+		"timestamp":  cce.timestamp.Unix(),
+		"operation":  DatasetCreate,
 	}
+	if cce.schedulePaused {
+		val["schedule_paused"] = cce.schedulePaused
+	}
+	return val
 }
 
 func (cce removeDatasetEvent) Encode() map[string]interface{} {
 	return map[string]interface{}{
 		"id":         cce.id,
 		"owner_id":   cce.ownerID,
-		"group_id":   cce.agentGroupID,
+		"groups_ids": cce.groupIDs,
 		"dataset_id": cce.datasetID,
 		"policy_id":  cce.policyID,
 		"timestamp":  cce.timestamp.Unix(),
@@ -115,10 +128,10 @@ func (cce removeDatasetEvent) Encode() map[string]interface{} {
 }
 
 func (cce updateDatasetEvent) Encode() map[string]interface{} {
-	return map[string]interface{}{
+	val := map[string]interface{}{
 		"id":             cce.id,
 		"owner_id":       cce.ownerID,
-		"group_id":       cce.agentGroupID,
+		"groups_ids":     cce.groupIDs,
 		"policy_id":      cce.policyID,
 		"valid":          cce.valid,
 		"turned_valid":   cce.turnedValid,
@@ -126,6 +139,16 @@ func (cce updateDatasetEvent) Encode() map[string]interface{} {
 		"timestamp":      cce.timestamp.Unix(),
 		"operation":      DatasetUpdate,
 	}
+	if cce.addedGroupIDs != "" {
+		val["added_group_ids"] = cce.addedGroupIDs
+	}
+	if cce.removedGroupIDs != "" {
+		val["removed_group_ids"] = cce.removedGroupIDs
+	}
+	if cce.exclusionsChanged != "" {
+		val["exclusions_changed"] = cce.exclusionsChanged
+	}
+	return val
 }
 
 func (cce createPolicyEvent) Encode() map[string]interface{} {
@@ -139,13 +162,18 @@ func (cce createPolicyEvent) Encode() map[string]interface{} {
 }
 
 func (cce updatePolicyEvent) Encode() map[string]interface{} {
-	return map[string]interface{}{
+	val := map[string]interface{}{
 		"id":         cce.id,
 		"owner_id":   cce.ownerID,
 		"groups_ids": cce.groupIDs,
+		"version":    cce.version,
 		"timestamp":  cce.timestamp.Unix(),
 		"operation":  PolicyUpdate,
 	}
+	if cce.rollout != "" {
+		val["rollout"] = cce.rollout
+	}
+	return val
 }
 
 func (cce removePolicyEvent) Encode() map[string]interface{} {
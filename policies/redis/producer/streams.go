@@ -10,6 +10,7 @@ package producer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/orb-community/orb/pkg/errors"
@@ -55,11 +56,11 @@ func (e eventStore) RemoveDataset(ctx context.Context, token string, dsID string
 	}
 
 	event := removeDatasetEvent{
-		id:           dsID,
-		ownerID:      ds.MFOwnerID,
-		agentGroupID: ds.AgentGroupID,
-		policyID:     ds.PolicyID,
-		datasetID:    ds.ID,
+		id:        dsID,
+		ownerID:   ds.MFOwnerID,
+		groupIDs:  strings.Join(ds.AgentGroupIDs, ","),
+		policyID:  ds.PolicyID,
+		datasetID: ds.ID,
 	}
 	record := &redis.XAddArgs{
 		Stream: streamID,
@@ -89,11 +90,11 @@ func (e eventStore) EditDataset(ctx context.Context, token string, ds policies.D
 	}
 
 	event := updateDatasetEvent{
-		id:           editedDataset.ID,
-		ownerID:      editedDataset.MFOwnerID,
-		agentGroupID: editedDataset.AgentGroupID,
-		policyID:     editedDataset.PolicyID,
-		valid:        editedDataset.Valid,
+		id:       editedDataset.ID,
+		ownerID:  editedDataset.MFOwnerID,
+		groupIDs: strings.Join(editedDataset.AgentGroupIDs, ","),
+		policyID: editedDataset.PolicyID,
+		valid:    editedDataset.Valid,
 	}
 
 	if previousDataset.Valid == false && editedDataset.Valid == true {
@@ -106,6 +107,9 @@ func (e eventStore) EditDataset(ctx context.Context, token string, ds policies.D
 		event.turnedValid = false
 		event.turnedInvalid = false
 	}
+	event.addedGroupIDs = strings.Join(missingGroups(editedDataset.AgentGroupIDs, previousDataset.AgentGroupIDs), ",")
+	event.removedGroupIDs = strings.Join(missingGroups(previousDataset.AgentGroupIDs, editedDataset.AgentGroupIDs), ",")
+	event.exclusionsChanged = strings.Join(changedExclusions(previousDataset, editedDataset), ",")
 
 	record := &redis.XAddArgs{
 		Stream: streamID,
@@ -122,6 +126,48 @@ func (e eventStore) EditDataset(ctx context.Context, token string, ds policies.D
 	return editedDataset, nil
 }
 
+// missingGroups lists the groups not in others
+func missingGroups(groupIDs []string, others []string) []string {
+	known := make(map[string]bool, len(others))
+	for _, id := range others {
+		known[id] = true
+	}
+	var missing []string
+	for _, id := range groupIDs {
+		if !known[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// changedExclusions lists the agents excluded from only one of the datasets
+func changedExclusions(previous policies.Dataset, edited policies.Dataset) []string {
+	before, after := exclusionSet(previous), exclusionSet(edited)
+	var changed []string
+	for id := range before {
+		if !after[id] {
+			changed = append(changed, id)
+		}
+	}
+	for id := range after {
+		if !before[id] {
+			changed = append(changed, id)
+		}
+	}
+	return changed
+}
+
+func exclusionSet(ds policies.Dataset) map[string]bool {
+	set := make(map[string]bool)
+	if ds.ExcludedAgentIDs != nil {
+		for _, id := range *ds.ExcludedAgentIDs {
+			set[id] = true
+		}
+	}
+	return set
+}
+
 func (e eventStore) RemovePolicy(ctx context.Context, token string, policyID string) error {
 	policy, err := e.svc.ViewPolicyByID(ctx, token, policyID)
 	if err != nil {
@@ -145,11 +191,11 @@ func (e eventStore) RemovePolicy(ctx context.Context, token string, policyID str
 		return nil
 	}
 
-	var groupsIDs []string
+	var groupIDs []string
 	var ownerID string
 	for _, ds := range datasets {
 		ownerID = ds.MFOwnerID
-		groupsIDs = append(groupsIDs, ds.AgentGroupID)
+		groupIDs = append(groupIDs, ds.AgentGroupIDs...)
 	}
 
 	event := removePolicyEvent{
@@ -157,7 +203,7 @@ func (e eventStore) RemovePolicy(ctx context.Context, token string, policyID str
 		ownerID:  ownerID,
 		name:     policy.Name.String(),
 		backend:  policy.Backend,
-		groupIDs: strings.Join(groupsIDs, ","),
+		groupIDs: strings.Join(groupIDs, ","),
 	}
 	record := &redis.XAddArgs{
 		Stream: streamID,
@@ -189,9 +235,9 @@ func (e eventStore) EditPolicy(ctx context.Context, token string, pol policies.P
 		return policies.Policy{}, err
 	}
 
-	var groupsIDs []string
+	var groupIDs []string
 	for _, ds := range datasets {
-		groupsIDs = append(groupsIDs, ds.AgentGroupID)
+		groupIDs = append(groupIDs, ds.AgentGroupIDs...)
 	}
 
 	err = validatePolicyBackend(&editedPol, editedPol.Format, editedPol.PolicyData)
@@ -202,7 +248,15 @@ func (e eventStore) EditPolicy(ctx context.Context, token string, pol policies.P
 	event := updatePolicyEvent{
 		id:       editedPol.ID,
 		ownerID:  editedPol.MFOwnerID,
-		groupIDs: strings.Join(groupsIDs, ","),
+		groupIDs: strings.Join(groupIDs, ","),
+		version:  editedPol.Version,
+	}
+	if pol.Rollout != nil {
+		rollout, err := json.Marshal(pol.Rollout)
+		if err != nil {
+			return policies.Policy{}, err
+		}
+		event.rollout = string(rollout)
 	}
 	record := &redis.XAddArgs{
 		Stream: streamID,
@@ -219,6 +273,70 @@ func (e eventStore) EditPolicy(ctx context.Context, token string, pol policies.P
 	return editedPol, nil
 }
 
+func (e eventStore) RollbackPolicyInternal(ctx context.Context, ownerID string, policyID string, version int32) (policies.Policy, []policies.Dataset, error) {
+	pol, datasets, err := e.svc.RollbackPolicyInternal(ctx, ownerID, policyID, version)
+	if err != nil {
+		return policies.Policy{}, nil, err
+	}
+
+	var groupIDs []string
+	for _, ds := range datasets {
+		groupIDs = append(groupIDs, ds.AgentGroupIDs...)
+	}
+
+	// the restored policy is pushed to every agent at once, not staged again
+	event := updatePolicyEvent{
+		id:       pol.ID,
+		ownerID:  pol.MFOwnerID,
+		groupIDs: strings.Join(groupIDs, ","),
+		version:  pol.Version,
+	}
+	record := &redis.XAddArgs{
+		Stream: streamID,
+		MaxLen: streamLen,
+		Approx: true,
+		Values: event.Encode(),
+	}
+	err = e.client.XAdd(ctx, record).Err()
+	if err != nil {
+		e.logger.Error("error sending event to event store", zap.Error(err))
+		return pol, datasets, err
+	}
+
+	return pol, datasets, nil
+}
+
+func (e eventStore) CheckDatasetSchedules(ctx context.Context) ([]policies.Dataset, error) {
+	datasets, err := e.svc.CheckDatasetSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ds := range datasets {
+		event := updateDatasetEvent{
+			id:            ds.ID,
+			ownerID:       ds.MFOwnerID,
+			groupIDs:      strings.Join(ds.AgentGroupIDs, ","),
+			policyID:      ds.PolicyID,
+			datasetID:     ds.ID,
+			valid:         ds.Valid,
+			turnedValid:   ds.Valid,
+			turnedInvalid: !ds.Valid,
+		}
+		record := &redis.XAddArgs{
+			Stream: streamID,
+			MaxLen: streamLen,
+			Approx: true,
+			Values: event.Encode(),
+		}
+		if err := e.client.XAdd(ctx, record).Err(); err != nil {
+			e.logger.Error("error sending event to event store", zap.Error(err))
+		}
+	}
+
+	return datasets, nil
+}
+
 func (e eventStore) AddPolicy(ctx context.Context, token string, p policies.Policy) (policies.Policy, error) {
 	return e.svc.AddPolicy(ctx, token, p)
 }
@@ -235,8 +353,8 @@ func (e eventStore) ViewPolicyByIDInternal(ctx context.Context, policyID string,
 	return e.svc.ViewPolicyByIDInternal(ctx, policyID, ownerID)
 }
 
-func (e eventStore) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string) ([]policies.PolicyInDataset, error) {
-	return e.svc.ListPoliciesByGroupIDInternal(ctx, groupIDs, ownerID)
+func (e eventStore) ListPoliciesByGroupIDInternal(ctx context.Context, groupIDs []string, ownerID string, agentID string) ([]policies.PolicyInDataset, error) {
+	return e.svc.ListPoliciesByGroupIDInternal(ctx, groupIDs, ownerID, agentID)
 }
 
 func (e eventStore) AddDataset(ctx context.Context, token string, d policies.Dataset) (policies.Dataset, error) {
@@ -246,12 +364,13 @@ func (e eventStore) AddDataset(ctx context.Context, token string, d policies.Dat
 	}
 
 	event := createDatasetEvent{
-		id:           ds.ID,
-		ownerID:      ds.MFOwnerID,
-		name:         ds.Name.String(),
-		agentGroupID: ds.AgentGroupID,
-		policyID:     ds.PolicyID,
-		sinkIDs:      strings.Join(*ds.SinkIDs, ","),
+		id:             ds.ID,
+		ownerID:        ds.MFOwnerID,
+		name:           ds.Name.String(),
+		groupIDs:       strings.Join(ds.AgentGroupIDs, ","),
+		policyID:       ds.PolicyID,
+		sinkIDs:        strings.Join(*ds.SinkIDs, ","),
+		schedulePaused: ds.SchedulePaused,
 	}
 	record := &redis.XAddArgs{
 		Stream: streamID,
@@ -293,7 +412,7 @@ func (e eventStore) InactivateDatasetByIDInternal(ctx context.Context, ownerID s
 	event := updateDatasetEvent{
 		id:            datasetID,
 		ownerID:       ds.MFOwnerID,
-		agentGroupID:  ds.AgentGroupID,
+		groupIDs:      strings.Join(ds.AgentGroupIDs, ","),
 		policyID:      ds.PolicyID,
 		datasetID:     ds.ID,
 		valid:         false,
@@ -373,3 +492,11 @@ func (e eventStore) ListDatasets(ctx context.Context, token string, pm policies.
 func (e eventStore) ViewDatasetByID(ctx context.Context, token string, datasetID string) (policies.Dataset, error) {
 	return e.svc.ViewDatasetByID(ctx, token, datasetID)
 }
+
+func (e eventStore) ViewPolicyStatus(ctx context.Context, token string, policyID string) (policies.PolicyStatus, error) {
+	return e.svc.ViewPolicyStatus(ctx, token, policyID)
+}
+
+func (e eventStore) ViewDatasetPolicyStatus(ctx context.Context, token string, datasetID string) (policies.PolicyStatus, error) {
+	return e.svc.ViewDatasetPolicyStatus(ctx, token, datasetID)
+}
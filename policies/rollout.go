@@ -0,0 +1,40 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package policies
+
+import (
+	"context"
+
+	"github.com/orb-community/orb/fleet"
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+// ErrRollbackPolicy indicates the policy has no previous version, or it was edited again since the version to roll back
+var ErrRollbackPolicy = errors.New("failed to rollback policy")
+
+// RolloutStrategy stages a policy update across the agents running it, instead of pushing it to all of them at once.
+// Fleet owns the rollout, this is only validated and forwarded with the policy update event.
+type RolloutStrategy = fleet.RolloutStrategy
+
+func (s policiesService) RollbackPolicyInternal(ctx context.Context, ownerID string, policyID string, version int32) (Policy, []Dataset, error) {
+	if ownerID == "" || policyID == "" {
+		return Policy{}, nil, ErrMalformedEntity
+	}
+
+	if err := s.repo.RollbackPolicy(ctx, ownerID, policyID, version); err != nil {
+		return Policy{}, nil, err
+	}
+
+	pol, err := s.repo.RetrievePolicyByID(ctx, policyID, ownerID)
+	if err != nil {
+		return Policy{}, nil, err
+	}
+
+	datasets, err := s.repo.RetrieveDatasetsByPolicyID(ctx, policyID, ownerID)
+	if err != nil {
+		return Policy{}, nil, err
+	}
+	return pol, datasets, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/orb-community/orb/pkg/types"
 	"github.com/orb-community/orb/policies/backend/orb"
 	"github.com/orb-community/orb/policies/backend/pktvisor"
+	"github.com/orb-community/orb/policies/backend/probe"
 	sinkpb "github.com/orb-community/orb/sinks/pb"
 	"go.uber.org/zap"
 )
@@ -54,6 +55,7 @@ func New(logger *zap.Logger, auth mainflux.AuthServiceClient, repo Repository, f
 	//TODO it might not need the logger here, just added for debugging for now
 	otel.Register(logger)
 	pktvisor.Register()
+	probe.Register()
 
 	return &policiesService{
 		logger:          logger,
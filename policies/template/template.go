@@ -0,0 +1,210 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+// Package template renders the per agent variables of a policy, such as
+// `{{ agent.tags.iface | default "eth0" }}`, so a single policy can be shared
+// by agents with different configurations.
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+)
+
+const (
+	VarAgentID         = "agent.id"
+	VarAgentName       = "agent.name"
+	VarAgentTagsPrefix = "agent.tags."
+)
+
+var (
+	// ErrInvalidTemplate indicates a malformed expression or an unknown variable in a policy
+	ErrInvalidTemplate = errors.New("invalid policy template")
+	// ErrMissingVariable indicates the agent has no value, nor the expression a default, for a variable
+	ErrMissingVariable = errors.New("missing policy template variable")
+
+	expressionRe = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	variableRe   = regexp.MustCompile(`^\s*([A-Za-z0-9_.\-]+)\s*(?:\|\s*default\s+(?:"([^"]*)"|'([^']*)')\s*)?$`)
+)
+
+// Agent holds the values available to the templates, Tags are the agent tags merged with the orb tags
+type Agent struct {
+	ID   string
+	Name string
+	Tags map[string]string
+}
+
+// NewAgent returns the template values of an agent, orb tags take precedence over agent tags as in group matching
+func NewAgent(id, name string, agentTags, orbTags map[string]string) Agent {
+	tags := make(map[string]string, len(agentTags)+len(orbTags))
+	for k, v := range agentTags {
+		tags[k] = v
+	}
+	for k, v := range orbTags {
+		tags[k] = v
+	}
+	return Agent{ID: id, Name: name, Tags: tags}
+}
+
+type expression struct {
+	variable   string
+	def        string
+	hasDefault bool
+}
+
+func parseExpression(expr string) (expression, error) {
+	m := variableRe.FindStringSubmatch(expr)
+	if m == nil {
+		return expression{}, errors.Wrap(ErrInvalidTemplate, fmt.Errorf("malformed expression '{{%s}}'", expr))
+	}
+	e := expression{variable: m[1]}
+	if strings.Contains(expr, "|") {
+		e.hasDefault = true
+		e.def = m[2] + m[3]
+	}
+	switch {
+	case e.variable == VarAgentID, e.variable == VarAgentName:
+	case strings.HasPrefix(e.variable, VarAgentTagsPrefix) && len(e.variable) > len(VarAgentTagsPrefix):
+	default:
+		return expression{}, errors.Wrap(ErrInvalidTemplate, fmt.Errorf("unknown variable '%s'", e.variable))
+	}
+	return e, nil
+}
+
+func (e expression) value(agent Agent) (string, bool) {
+	var val string
+	switch e.variable {
+	case VarAgentID:
+		val = agent.ID
+	case VarAgentName:
+		val = agent.Name
+	default:
+		val = agent.Tags[strings.TrimPrefix(e.variable, VarAgentTagsPrefix)]
+	}
+	if val == "" {
+		return e.def, e.hasDefault
+	}
+	return val, true
+}
+
+// HasVariables tells if any string of the policy contains a template expression
+func HasVariables(policy interface{}) bool {
+	found := false
+	_ = walk(policy, func(s string) (string, error) {
+		if strings.Contains(s, "{{") {
+			found = true
+		}
+		return s, nil
+	})
+	return found
+}
+
+// Validate checks all the expressions of the policy are well-formed and use known variables
+func Validate(policy interface{}) error {
+	return walk(policy, func(s string) (string, error) {
+		_, err := renderString(s, nil)
+		return s, err
+	})
+}
+
+// Render returns a copy of the policy with the expressions replaced by the agent values,
+// failing with ErrMissingVariable listing the variables the agent has no value for
+func Render(policy interface{}, agent Agent) (interface{}, error) {
+	missing := map[string]bool{}
+	rendered, err := render(policy, func(s string) (string, error) {
+		return renderString(s, func(e expression) string {
+			val, ok := e.value(agent)
+			if !ok {
+				missing[e.variable] = true
+			}
+			return val
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		vars := make([]string, 0, len(missing))
+		for v := range missing {
+			vars = append(vars, v)
+		}
+		sort.Strings(vars)
+		return nil, errors.Wrap(ErrMissingVariable, fmt.Errorf("agent has no value for %s", strings.Join(vars, ", ")))
+	}
+	return rendered, nil
+}
+
+// renderString replaces the expressions of s with the resolved values, only validating when resolve is nil
+func renderString(s string, resolve func(expression) string) (string, error) {
+	var err error
+	out := expressionRe.ReplaceAllStringFunc(s, func(match string) string {
+		e, perr := parseExpression(match[2 : len(match)-2])
+		if perr != nil {
+			if err == nil {
+				err = perr
+			}
+			return match
+		}
+		if resolve == nil {
+			return match
+		}
+		return resolve(e)
+	})
+	if err != nil {
+		return "", err
+	}
+	if rest := expressionRe.ReplaceAllString(s, ""); strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return "", errors.Wrap(ErrInvalidTemplate, fmt.Errorf("unbalanced braces in '%s'", s))
+	}
+	return out, nil
+}
+
+func walk(policy interface{}, fn func(string) (string, error)) error {
+	_, err := render(policy, fn)
+	return err
+}
+
+// render copies the policy applying fn to every string value
+func render(policy interface{}, fn func(string) (string, error)) (interface{}, error) {
+	switch v := policy.(type) {
+	case string:
+		return fn(v)
+	case types.Metadata:
+		return renderMap(v, fn)
+	case map[string]interface{}:
+		return renderMap(v, fn)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := render(item, fn)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func renderMap(m map[string]interface{}, fn func(string) (string, error)) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(m))
+	for k, item := range m {
+		if strings.Contains(k, "{{") {
+			return nil, errors.Wrap(ErrInvalidTemplate, fmt.Errorf("expressions are not supported on keys: '%s'", k))
+		}
+		r, err := render(item, fn)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = r
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package template_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/orb-community/orb/policies/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAgent(t *testing.T) {
+	agent := template.NewAgent("id", "name", map[string]string{"iface": "eth0", "site": "lab"}, map[string]string{"iface": "ens5"})
+	assert.Equal(t, map[string]string{"iface": "ens5", "site": "lab"}, agent.Tags)
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		policy interface{}
+		err    error
+	}{
+		"static policy": {
+			policy: types.Metadata{"input": map[string]interface{}{"tap": "default_pcap"}},
+			err:    nil,
+		},
+		"policy with agent variables": {
+			policy: types.Metadata{
+				"input": map[string]interface{}{"iface": "{{ agent.tags.iface }}"},
+				"name":  "{{agent.name}}-{{ agent.id }}",
+				"list":  []interface{}{`{{ agent.tags.site | default "lab" }}`, 10},
+			},
+			err: nil,
+		},
+		"policy with unknown variable": {
+			policy: types.Metadata{"iface": "{{ agent.iface }}"},
+			err:    template.ErrInvalidTemplate,
+		},
+		"policy with empty tag variable": {
+			policy: types.Metadata{"iface": "{{ agent.tags. }}"},
+			err:    template.ErrInvalidTemplate,
+		},
+		"policy with malformed default": {
+			policy: types.Metadata{"iface": "{{ agent.tags.iface | default eth0 }}"},
+			err:    template.ErrInvalidTemplate,
+		},
+		"policy with unbalanced braces": {
+			policy: types.Metadata{"iface": "{{ agent.tags.iface"},
+			err:    template.ErrInvalidTemplate,
+		},
+		"policy with variable on key": {
+			policy: types.Metadata{"{{ agent.name }}": "value"},
+			err:    template.ErrInvalidTemplate,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			err := template.Validate(tc.policy)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		})
+	}
+}
+
+func TestHasVariables(t *testing.T) {
+	assert.True(t, template.HasVariables(types.Metadata{"a": []interface{}{"{{ agent.name }}"}}))
+	assert.False(t, template.HasVariables(types.Metadata{"a": []interface{}{"eth0", 1}}))
+}
+
+func TestRender(t *testing.T) {
+	agent := template.NewAgent("agent-id", "agent-name", map[string]string{"iface": "ens5"}, nil)
+	policy := map[string]interface{}{
+		"input": map[string]interface{}{
+			"iface":   "{{ agent.tags.iface }}",
+			"filter":  `{{ agent.tags.filter | default "udp port 53" }}`,
+			"enabled": true,
+		},
+		"name":  "{{agent.name}}/{{agent.id}}",
+		"ports": []interface{}{"{{ agent.tags.port | default '53' }}", 853},
+	}
+
+	cases := map[string]struct {
+		agent    template.Agent
+		expected interface{}
+		err      error
+	}{
+		"render with agent values and defaults": {
+			agent: agent,
+			expected: map[string]interface{}{
+				"input": map[string]interface{}{
+					"iface":   "ens5",
+					"filter":  "udp port 53",
+					"enabled": true,
+				},
+				"name":  "agent-name/agent-id",
+				"ports": []interface{}{"53", 853},
+			},
+			err: nil,
+		},
+		"render with missing variable": {
+			agent: template.NewAgent("agent-id", "agent-name", nil, nil),
+			err:   template.ErrMissingVariable,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			rendered, err := template.Render(policy, tc.agent)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, tc.expected, rendered, fmt.Sprintf("%s: unexpected rendered policy", desc))
+			}
+		})
+	}
+
+	// the original policy is left untouched to be rendered for other agents
+	require.Equal(t, "{{ agent.tags.iface }}", policy["input"].(map[string]interface{})["iface"])
+}
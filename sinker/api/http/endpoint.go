@@ -0,0 +1,61 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package http
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/orb-community/orb/sinker"
+)
+
+func listDeadLettersEndpoint(svc sinker.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listDeadLettersReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		letters, err := svc.ListDeadLetters(ctx, req.token, int64(req.limit))
+		if err != nil {
+			return nil, err
+		}
+
+		res := deadLettersPageRes{
+			DeadLetters: []deadLetterRes{},
+		}
+		for _, letter := range letters {
+			res.DeadLetters = append(res.DeadLetters, deadLetterRes{
+				ID:         letter.ID,
+				Reason:     letter.Reason,
+				Error:      letter.Error,
+				Signal:     letter.Signal,
+				Channel:    letter.Channel,
+				Publisher:  letter.Publisher,
+				Subtopic:   letter.Subtopic,
+				PolicyID:   letter.PolicyID,
+				DatasetIDs: letter.DatasetIDs,
+				SinkID:     letter.SinkID,
+				Size:       len(letter.Payload),
+				Timestamp:  letter.Timestamp,
+			})
+		}
+		return res, nil
+	}
+}
+
+func replayDeadLetterEndpoint(svc sinker.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(replayDeadLetterReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.ReplayDeadLetter(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+		return replayDeadLetterRes{}, nil
+	}
+}
@@ -0,0 +1,37 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package http
+
+import "github.com/orb-community/orb/pkg/errors"
+
+type listDeadLettersReq struct {
+	token string
+	limit uint64
+}
+
+func (req listDeadLettersReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.limit == 0 || req.limit > maxLimit {
+		return errors.ErrInvalidQueryParams
+	}
+	return nil
+}
+
+type replayDeadLetterReq struct {
+	token string
+	id    string
+}
+
+func (req replayDeadLetterReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if req.id == "" {
+		return errors.ErrMalformedEntity
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package http
+
+import (
+	"net/http"
+	"time"
+)
+
+type deadLetterRes struct {
+	ID         string    `json:"id"`
+	Reason     string    `json:"reason"`
+	Error      string    `json:"error,omitempty"`
+	Signal     string    `json:"signal"`
+	Channel    string    `json:"channel"`
+	Publisher  string    `json:"publisher"`
+	Subtopic   string    `json:"subtopic"`
+	PolicyID   string    `json:"policy_id,omitempty"`
+	DatasetIDs []string  `json:"dataset_ids,omitempty"`
+	SinkID     string    `json:"sink_id,omitempty"`
+	Size       int       `json:"size"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+type deadLettersPageRes struct {
+	DeadLetters []deadLetterRes `json:"dead_letters"`
+}
+
+func (res deadLettersPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res deadLettersPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res deadLettersPageRes) Empty() bool {
+	return false
+}
+
+type replayDeadLetterRes struct{}
+
+func (res replayDeadLetterRes) Code() int {
+	return http.StatusAccepted
+}
+
+func (res replayDeadLetterRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res replayDeadLetterRes) Empty() bool {
+	return true
+}
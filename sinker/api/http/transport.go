@@ -0,0 +1,107 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	kitot "github.com/go-kit/kit/tracing/opentracing"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/go-zoo/bone"
+	"github.com/opentracing/opentracing-go"
+	"github.com/orb-community/orb/buildinfo"
+	"github.com/orb-community/orb/internal/httputil"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/pkg/types"
+	"github.com/orb-community/orb/sinker"
+	"github.com/orb-community/orb/sinker/otel/bridgeservice"
+	"github.com/orb-community/orb/sinker/redis/producer"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	limitKey = "limit"
+	defLimit = 100
+	maxLimit = 1000
+)
+
+// MakeHandler returns the sinker API, the dead letters of an owner are only reachable with its token
+func MakeHandler(tracer opentracing.Tracer, svcName string, svc sinker.Service) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+	r := bone.New()
+	r.Get("/dead_letters", kithttp.NewServer(
+		kitot.TraceServer(tracer, "list_dead_letters")(listDeadLettersEndpoint(svc)),
+		decodeListDeadLetters,
+		types.EncodeResponse,
+		opts...,
+	))
+	r.Post("/dead_letters/:id/replay", kithttp.NewServer(
+		kitot.TraceServer(tracer, "replay_dead_letter")(replayDeadLetterEndpoint(svc)),
+		decodeReplayDeadLetter,
+		types.EncodeResponse,
+		opts...,
+	))
+
+	r.GetFunc("/version", buildinfo.Version(svcName))
+	r.Handle("/metrics", promhttp.Handler())
+
+	return r
+}
+
+func decodeListDeadLetters(_ context.Context, r *http.Request) (interface{}, error) {
+	l, err := httputil.ReadUintQuery(r, limitKey, defLimit)
+	if err != nil {
+		return nil, err
+	}
+	req := listDeadLettersReq{token: parseJwt(r), limit: l}
+	return req, nil
+}
+
+func decodeReplayDeadLetter(_ context.Context, r *http.Request) (interface{}, error) {
+	req := replayDeadLetterReq{
+		token: parseJwt(r),
+		id:    bone.GetValue(r, "id"),
+	}
+	return req, nil
+}
+
+func parseJwt(r *http.Request) (token string) {
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		token = r.Header.Get("Authorization")[7:]
+	}
+	return
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	switch errorVal := err.(type) {
+	case errors.Error:
+		w.Header().Set("Content-Type", types.ContentType)
+		switch {
+		case errors.Contains(errorVal, errors.ErrUnauthorizedAccess):
+			w.WriteHeader(http.StatusUnauthorized)
+		case errors.Contains(errorVal, errors.ErrInvalidQueryParams),
+			errors.Contains(errorVal, errors.ErrMalformedEntity):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Contains(errorVal, producer.ErrDeadLetterNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Contains(errorVal, bridgeservice.ErrReplayUnavailable):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		if errorVal.Msg() != "" {
+			if err := json.NewEncoder(w).Encode(types.ErrorRes{Err: errorVal.Msg()}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -22,23 +22,35 @@ type BridgeService interface {
 	NotifyActiveSink(ctx context.Context, mfOwnerId, sinkId, state, message string) error
 	GetSinkIdsFromPolicyID(ctx context.Context, mfOwnerId string, policyID string) (map[string]string, error)
 	IncreamentMessageCounter(publisher, subtopic, channel, protocol string)
+	TrackMessageProcessing(signal string) func()
+	RecordDeadLetter(ctx context.Context, letter producer.DeadLetter)
+	RecordSinkUsage(mfOwnerId, sinkId string, bytes, dataPoints int)
 }
 
 func NewBridgeService(logger *zap.Logger,
 	defaultCacheExpiration time.Duration,
 	sinkActivity producer.SinkActivityProducer,
+	sinkUsage producer.SinkUsageProducer,
+	deadLetters producer.DeadLetterStore,
 	policiesClient policiespb.PolicyServiceClient,
 	sinksClient sinkspb.SinkServiceClient,
-	fleetClient fleetpb.FleetServiceClient, messageInputCounter metrics.Counter) SinkerOtelBridgeService {
+	fleetClient fleetpb.FleetServiceClient, messageInputCounter metrics.Counter, processingGauge metrics.Gauge,
+	deadLetterCounter metrics.Counter) SinkerOtelBridgeService {
 	return SinkerOtelBridgeService{
 		defaultCacheExpiration: defaultCacheExpiration,
 		inMemoryCache:          *cache.New(defaultCacheExpiration, defaultCacheExpiration*2),
 		logger:                 logger,
 		sinkerActivitySvc:      sinkActivity,
+		sinkUsageSvc:           sinkUsage,
+		sinkUsage:              newSinkUsageAccumulator(),
+		deadLetters:            deadLetters,
+		replays:                newReplayHandlers(),
 		policiesClient:         policiesClient,
 		fleetClient:            fleetClient,
 		sinksClient:            sinksClient,
 		messageInputCounter:    messageInputCounter,
+		processingGauge:        processingGauge,
+		deadLetterCounter:      deadLetterCounter,
 	}
 }
 
@@ -47,10 +59,16 @@ type SinkerOtelBridgeService struct {
 	defaultCacheExpiration time.Duration
 	logger                 *zap.Logger
 	sinkerActivitySvc      producer.SinkActivityProducer
+	sinkUsageSvc           producer.SinkUsageProducer
+	sinkUsage              *sinkUsageAccumulator
+	deadLetters            producer.DeadLetterStore
+	replays                *replayHandlers
 	policiesClient         policiespb.PolicyServiceClient
 	fleetClient            fleetpb.FleetServiceClient
 	sinksClient            sinkspb.SinkServiceClient
 	messageInputCounter    metrics.Counter
+	processingGauge        metrics.Gauge
+	deadLetterCounter      metrics.Counter
 }
 
 // IncrementMessageCounter add to our metrics the number of messages received
@@ -65,6 +83,41 @@ func (bs *SinkerOtelBridgeService) IncrementMessageCounter(publisher, subtopic,
 	bs.messageInputCounter.With(labels...).Add(1)
 }
 
+// TrackMessageProcessing counts a message of the given signal as being processed by this replica, until the returned func is called
+func (bs *SinkerOtelBridgeService) TrackMessageProcessing(signal string) func() {
+	gauge := bs.processingGauge.With("signal", signal)
+	gauge.Add(1)
+	return func() {
+		gauge.Add(-1)
+	}
+}
+
+// RecordDeadLetter keeps an agent message that could not be routed or exported, counting it by reason. A message
+// of unknown owner is only counted, as no one could list or replay it
+func (bs *SinkerOtelBridgeService) RecordDeadLetter(ctx context.Context, letter producer.DeadLetter) {
+	if letter.OwnerID == "" {
+		bs.deadLetterCounter.With("reason", letter.Reason, "signal", letter.Signal, "kept", "false").Add(1)
+		bs.logger.Warn("agent message not exported and of unknown owner, dropping it",
+			zap.String("reason", letter.Reason),
+			zap.String("signal", letter.Signal),
+			zap.String("channel", letter.Channel),
+			zap.String("error", letter.Error))
+		return
+	}
+	bs.deadLetterCounter.With("reason", letter.Reason, "signal", letter.Signal, "kept", "true").Add(1)
+	bs.logger.Warn("agent message not exported, keeping it as dead letter",
+		zap.String("reason", letter.Reason),
+		zap.String("signal", letter.Signal),
+		zap.String("channel", letter.Channel),
+		zap.String("policy_id", letter.PolicyID),
+		zap.Strings("dataset_ids", letter.DatasetIDs),
+		zap.String("sink_id", letter.SinkID),
+		zap.String("error", letter.Error))
+	letter.Timestamp = time.Now()
+	// the store logs its own failures, the message is lost either way
+	_ = bs.deadLetters.PublishDeadLetter(ctx, letter)
+}
+
 // NotifyActiveSink notify the sinker that a sink is active
 func (bs *SinkerOtelBridgeService) NotifyActiveSink(ctx context.Context, mfOwnerId, sinkId, size string) error {
 	cacheKey := fmt.Sprintf("active_sink-%s-%s", mfOwnerId, sinkId)
@@ -0,0 +1,76 @@
+package bridgeservice
+
+import (
+	"fmt"
+	"strings"
+
+	fleetpb "github.com/orb-community/orb/fleet/pb"
+	"go.uber.org/zap"
+)
+
+// The cache entries are evicted as soon as the entities they were built from change,
+// the cache expiration is kept only as a safety net for missed events.
+
+// InvalidateAgent evicts the agent cached for the channel
+func (bs *SinkerOtelBridgeService) InvalidateAgent(channelID string) {
+	bs.evict(fmt.Sprintf("agent-%s", channelID))
+}
+
+// InvalidateOwnerAgents evicts all cached agents of the owner, since their matching groups may have changed
+func (bs *SinkerOtelBridgeService) InvalidateOwnerAgents(ownerID string) {
+	bs.evictMatching("agent-", func(value interface{}) bool {
+		agent, ok := value.(*fleetpb.AgentInfoRes)
+		return ok && agent.OwnerID == ownerID
+	})
+}
+
+// InvalidateAgentGroup evicts the cached agents that are members of the agent group
+func (bs *SinkerOtelBridgeService) InvalidateAgentGroup(groupID string) {
+	bs.evictMatching("agent-", func(value interface{}) bool {
+		agent, ok := value.(*fleetpb.AgentInfoRes)
+		return ok && contains(agent.AgentGroupIDs, groupID)
+	})
+}
+
+// InvalidatePolicy evicts the cached policy
+func (bs *SinkerOtelBridgeService) InvalidatePolicy(policyID string) {
+	bs.evict(fmt.Sprintf("policy-%s", policyID))
+}
+
+// InvalidateDataset evicts the sinks cached for the dataset
+func (bs *SinkerOtelBridgeService) InvalidateDataset(ownerID, datasetID string) {
+	bs.evict(fmt.Sprintf("ds-%s-%s", ownerID, datasetID))
+}
+
+// InvalidateSink evicts the cached datasets of the owner that route to the sink
+func (bs *SinkerOtelBridgeService) InvalidateSink(ownerID, sinkID string) {
+	bs.evictMatching(fmt.Sprintf("ds-%s-", ownerID), func(value interface{}) bool {
+		sinkIDs, ok := value.([]string)
+		return ok && contains(sinkIDs, sinkID)
+	})
+}
+
+func (bs *SinkerOtelBridgeService) evict(key string) {
+	if _, found := bs.inMemoryCache.Get(key); found {
+		bs.inMemoryCache.Delete(key)
+		bs.logger.Debug("evicted cache entry", zap.String("key", key))
+	}
+}
+
+func (bs *SinkerOtelBridgeService) evictMatching(prefix string, match func(value interface{}) bool) {
+	for key, item := range bs.inMemoryCache.Items() {
+		if strings.HasPrefix(key, prefix) && match(item.Object) {
+			bs.inMemoryCache.Delete(key)
+			bs.logger.Debug("evicted cache entry", zap.String("key", key))
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package bridgeservice
+
+import (
+	"context"
+	"sync"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/sinker/redis/producer"
+)
+
+// ErrReplayUnavailable indicates no receiver of the dead letter signal is running on this replica
+var ErrReplayUnavailable = errors.New("no receiver to replay the dead letter signal")
+
+// ReplayHandler processes again the scope a dead letter failed on, exporting only to its sink when it failed on export
+type ReplayHandler func(ctx context.Context, letter producer.DeadLetter)
+
+// replayHandlers keeps the replay handler of each signal, it is shared between copies of the bridge service
+type replayHandlers struct {
+	mu       sync.RWMutex
+	handlers map[string]ReplayHandler
+}
+
+func newReplayHandlers() *replayHandlers {
+	return &replayHandlers{handlers: make(map[string]ReplayHandler)}
+}
+
+// RegisterReplayHandler sets the handler replaying the dead letters of the signal
+func (bs *SinkerOtelBridgeService) RegisterReplayHandler(signal string, handler ReplayHandler) {
+	bs.replays.mu.Lock()
+	defer bs.replays.mu.Unlock()
+	bs.replays.handlers[signal] = handler
+}
+
+// ReplayDeadLetter hands a dead letter to the receiver of its signal, which records a new dead letter if it fails again
+func (bs *SinkerOtelBridgeService) ReplayDeadLetter(ctx context.Context, letter producer.DeadLetter) error {
+	bs.replays.mu.RLock()
+	handler, ok := bs.replays.handlers[letter.Signal]
+	bs.replays.mu.RUnlock()
+	if !ok {
+		return ErrReplayUnavailable
+	}
+	handler(ctx, letter)
+	return nil
+}
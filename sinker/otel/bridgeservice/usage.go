@@ -0,0 +1,102 @@
+package bridgeservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/orb-community/orb/sinker/redis/producer"
+	"go.uber.org/zap"
+)
+
+const (
+	// SinkUsageWindow is the size of the time window sink usage is rolled up into
+	SinkUsageWindow = time.Hour
+	// SinkUsageFlushInterval is how often the accumulated sink usage is published to the sinks service
+	SinkUsageFlushInterval = time.Minute
+)
+
+type sinkUsageKey struct {
+	ownerID     string
+	sinkID      string
+	windowStart time.Time
+}
+
+type sinkUsageCounters struct {
+	bytes      int64
+	dataPoints int64
+	messages   int64
+}
+
+// sinkUsageAccumulator keeps the usage per sink until it is flushed, it is shared between copies of the bridge service
+type sinkUsageAccumulator struct {
+	mu       sync.Mutex
+	counters map[sinkUsageKey]*sinkUsageCounters
+}
+
+func newSinkUsageAccumulator() *sinkUsageAccumulator {
+	return &sinkUsageAccumulator{counters: make(map[sinkUsageKey]*sinkUsageCounters)}
+}
+
+func (a *sinkUsageAccumulator) add(ownerID, sinkID string, ts time.Time, bytes, dataPoints int64) {
+	key := sinkUsageKey{ownerID: ownerID, sinkID: sinkID, windowStart: ts.UTC().Truncate(SinkUsageWindow)}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c, ok := a.counters[key]
+	if !ok {
+		c = &sinkUsageCounters{}
+		a.counters[key] = c
+	}
+	c.bytes += bytes
+	c.dataPoints += dataPoints
+	c.messages++
+}
+
+// drain returns the accumulated usage and resets the accumulator
+func (a *sinkUsageAccumulator) drain() map[sinkUsageKey]*sinkUsageCounters {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	drained := a.counters
+	a.counters = make(map[sinkUsageKey]*sinkUsageCounters)
+	return drained
+}
+
+// RecordSinkUsage accumulates the bytes, data points and messages exported to a sink, log records and spans counting
+// as data points
+func (bs *SinkerOtelBridgeService) RecordSinkUsage(mfOwnerId, sinkId string, bytes, dataPoints int) {
+	bs.sinkUsage.add(mfOwnerId, sinkId, time.Now(), int64(bytes), int64(dataPoints))
+}
+
+// FlushSinkUsage publish the accumulated sink usage on every tick, until the context is done
+func (bs *SinkerOtelBridgeService) FlushSinkUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// best effort to not lose the last window on shutdown
+			bs.publishSinkUsage(context.Background())
+			return
+		case <-ticker.C:
+			bs.publishSinkUsage(ctx)
+		}
+	}
+}
+
+func (bs *SinkerOtelBridgeService) publishSinkUsage(ctx context.Context) {
+	for key, counters := range bs.sinkUsage.drain() {
+		event := producer.SinkUsageEvent{
+			OwnerID:     key.ownerID,
+			SinkID:      key.sinkID,
+			Bytes:       counters.bytes,
+			DataPoints:  counters.dataPoints,
+			Messages:    counters.messages,
+			WindowStart: key.windowStart,
+			Timestamp:   time.Now(),
+		}
+		if err := bs.sinkUsageSvc.PublishSinkUsage(ctx, event); err != nil {
+			bs.logger.Error("error publishing sink usage", zap.String("sink_id", key.sinkID),
+				zap.String("owner_id", key.ownerID), zap.Error(err))
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/orb-community/orb/sinker/redis/producer"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
@@ -25,6 +26,7 @@ type internalLogsReceiver struct {
 
 func (r *OrbReceiver) MessageLogsInbound(msg messaging.Message) error {
 	go func() {
+		defer r.sinkerService.TrackMessageProcessing("logs")()
 		r.cfg.Logger.Debug("received agent message",
 			zap.String("subtopic", msg.Subtopic),
 			zap.String("channel", msg.Channel),
@@ -32,11 +34,11 @@ func (r *OrbReceiver) MessageLogsInbound(msg messaging.Message) error {
 			zap.Int64("created", msg.Created),
 			zap.String("publisher", msg.Publisher))
 		r.cfg.Logger.Info("received log message, pushing to kafka exporter")
-		size := len(msg.Payload)
 		decompressedPayload := r.DecompressBrotli(msg.Payload)
 		lr, err := r.encoder.unmarshalLogsRequest(decompressedPayload)
 		if err != nil {
 			r.cfg.Logger.Error("error during unmarshalling, skipping message", zap.Error(err))
+			r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMalformedPayload, Signal: "logs"}, msg, err)
 			return
 		}
 
@@ -49,22 +51,45 @@ func (r *OrbReceiver) MessageLogsInbound(msg messaging.Message) error {
 
 		scopes := lr.Logs().ResourceLogs().At(0).ScopeLogs()
 		for i := 0; i < scopes.Len(); i++ {
-			r.ProccessLogsContext(scopes.At(i), msg.Channel, size)
+			r.ProccessLogsContext(scopes.At(i), msg, "")
 		}
 	}()
 	return nil
 }
 
-func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, channel string, size int) {
+// replayLogs processes again only the scope a dead letter failed on, and only for its sink when it failed on export
+func (r *OrbReceiver) replayLogs(_ context.Context, letter producer.DeadLetter) {
+	defer r.sinkerService.TrackMessageProcessing("logs")()
+	msg := letter.Message()
+	lr, err := r.encoder.unmarshalLogsRequest(r.DecompressBrotli(msg.Payload))
+	if err != nil {
+		r.cfg.Logger.Error("error during unmarshalling, skipping replay", zap.Error(err))
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMalformedPayload, Signal: "logs"}, msg, err)
+		return
+	}
+	if lr.Logs().ResourceLogs().Len() == 0 {
+		return
+	}
+	scopes := lr.Logs().ResourceLogs().At(0).ScopeLogs()
+	for i := 0; i < scopes.Len(); i++ {
+		if scopeFailedIn(scopes.At(i).Scope().Attributes(), letter) {
+			r.ProccessLogsContext(scopes.At(i), msg, letter.SinkID)
+		}
+	}
+}
+
+func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, msg messaging.Message, onlySinkID string) {
 	// Extract Datasets
 	attrDataset, ok := scope.Scope().Attributes().Get("dataset_ids")
 	if !ok {
 		r.cfg.Logger.Info("No datasetIDs information on logs scope attributes")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingDatasets, Signal: "logs"}, msg, nil)
 		return
 	}
 	datasets := attrDataset.AsString()
 	if datasets == "" {
 		r.cfg.Logger.Info("datasetIDs information on logs is empty")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingDatasets, Signal: "logs"}, msg, nil)
 		return
 	}
 	datasetIDs := strings.Split(datasets, ",")
@@ -72,11 +97,13 @@ func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, channel string,
 	attrPolID, ok := scope.Scope().Attributes().Get("policy_id")
 	if !ok {
 		r.cfg.Logger.Info("No policyID information on logs scope attributes")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingPolicy, Signal: "logs", DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	polID := attrPolID.AsString()
 	if polID == "" {
 		r.cfg.Logger.Info("policyID information on logs is empty")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingPolicy, Signal: "logs", DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	// Delete datasets_ids and policy_ids from scope attributes
@@ -85,10 +112,11 @@ func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, channel string,
 	// Add tags in Context
 	execCtx, execCancelF := context.WithCancel(r.ctx)
 	defer execCancelF()
-	agentPb, err := r.sinkerService.ExtractAgent(execCtx, channel)
+	agentPb, err := r.sinkerService.ExtractAgent(execCtx, msg.Channel)
 	if err != nil {
 		execCancelF()
 		r.cfg.Logger.Info("No data extracting agent information from fleet")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterUnknownAgent, Signal: "logs", PolicyID: polID, DatasetIDs: datasetIDs}, msg, err)
 		return
 	}
 	for k, v := range agentPb.OrbTags {
@@ -101,6 +129,11 @@ func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, channel string,
 	if err != nil {
 		execCancelF()
 		r.cfg.Logger.Info("No data extracting log sinks information from datasetIds = " + datasets)
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterNoSinks, Signal: "logs", PolicyID: polID, DatasetIDs: datasetIDs}, msg, err)
+		return
+	}
+	if len(sinkIds) == 0 {
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterNoSinks, Signal: "logs", PolicyID: polID, DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	attributeCtx := context.WithValue(r.ctx, "agent_name", agentPb.AgentName)
@@ -109,8 +142,7 @@ func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, channel string,
 	attributeCtx = context.WithValue(attributeCtx, "agent_groups", agentPb.AgentGroupIDs)
 	attributeCtx = context.WithValue(attributeCtx, "agent_ownerID", agentPb.OwnerID)
 	for sinkId := range sinkIds {
-		if err != nil {
-			r.cfg.Logger.Error("error notifying logs sink active, changing state, skipping sink", zap.String("sink-id", sinkId), zap.Error(err))
+		if onlySinkID != "" && sinkId != onlySinkID {
 			continue
 		}
 		attributeCtx = context.WithValue(attributeCtx, "sink_id", sinkId)
@@ -118,15 +150,19 @@ func (r *OrbReceiver) ProccessLogsContext(scope plog.ScopeLogs, channel string,
 		scope.CopyTo(lr.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty())
 		lr.ResourceLogs().At(0).Resource().Attributes().PutStr("service.name", agentPb.AgentName)
 		lr.ResourceLogs().At(0).Resource().Attributes().PutStr("service.instance.id", polID)
+		// the size exported to this sink, rather than of the whole agent message
+		size := (&plog.ProtoMarshaler{}).LogsSize(lr)
 		request := plogotlp.NewExportRequestFromLogs(lr)
-		_, err = r.exportLogs(attributeCtx, request)
+		_, err := r.exportLogs(attributeCtx, request)
 		if err != nil {
 			r.cfg.Logger.Error("error during logs export, skipping sink", zap.Error(err))
+			r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterExportFailed, Signal: "logs", PolicyID: polID, DatasetIDs: datasetIDs, SinkID: sinkId}, msg, err)
 			_ = r.cfg.SinkerService.NotifyActiveSink(r.ctx, agentPb.OwnerID, sinkId, "0")
 			continue
 		} else {
 			_ = r.cfg.SinkerService.NotifyActiveSink(r.ctx, agentPb.OwnerID, sinkId, strconv.Itoa(size))
 		}
+		r.sinkerService.RecordSinkUsage(agentPb.OwnerID, sinkId, size, lr.LogRecordCount())
 	}
 }
 
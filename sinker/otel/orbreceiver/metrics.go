@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/orb-community/orb/sinker/redis/producer"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -27,6 +28,7 @@ type internalMetricsReceiver struct {
 
 func (r *OrbReceiver) MessageMetricsInbound(msg messaging.Message) error {
 	go func() {
+		defer r.sinkerService.TrackMessageProcessing("metrics")()
 		r.cfg.Logger.Debug("received agent message",
 			zap.String("subtopic", msg.Subtopic),
 			zap.String("channel", msg.Channel),
@@ -34,11 +36,11 @@ func (r *OrbReceiver) MessageMetricsInbound(msg messaging.Message) error {
 			zap.Int64("created", msg.Created),
 			zap.String("publisher", msg.Publisher))
 		r.cfg.Logger.Debug("received metric message, pushing to kafka exporter", zap.String("publisher", msg.Publisher))
-		size := len(msg.Payload)
 		decompressedPayload := r.DecompressBrotli(msg.Payload)
 		mr, err := r.encoder.unmarshalMetricsRequest(decompressedPayload)
 		if err != nil {
 			r.cfg.Logger.Error("error during unmarshalling, skipping message", zap.Error(err))
+			r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMalformedPayload, Signal: "metrics"}, msg, err)
 			return
 		}
 
@@ -51,22 +53,45 @@ func (r *OrbReceiver) MessageMetricsInbound(msg messaging.Message) error {
 
 		scopes := mr.Metrics().ResourceMetrics().At(0).ScopeMetrics()
 		for i := 0; i < scopes.Len(); i++ {
-			r.ProccessMetricsContext(scopes.At(i), msg.Channel, size)
+			r.ProccessMetricsContext(scopes.At(i), msg, "")
 		}
 	}()
 	return nil
 }
 
-func (r *OrbReceiver) ProccessMetricsContext(scope pmetric.ScopeMetrics, channel string, size int) {
+// replayMetrics processes again only the scope a dead letter failed on, and only for its sink when it failed on export
+func (r *OrbReceiver) replayMetrics(_ context.Context, letter producer.DeadLetter) {
+	defer r.sinkerService.TrackMessageProcessing("metrics")()
+	msg := letter.Message()
+	mr, err := r.encoder.unmarshalMetricsRequest(r.DecompressBrotli(msg.Payload))
+	if err != nil {
+		r.cfg.Logger.Error("error during unmarshalling, skipping replay", zap.Error(err))
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMalformedPayload, Signal: "metrics"}, msg, err)
+		return
+	}
+	if mr.Metrics().ResourceMetrics().Len() == 0 {
+		return
+	}
+	scopes := mr.Metrics().ResourceMetrics().At(0).ScopeMetrics()
+	for i := 0; i < scopes.Len(); i++ {
+		if scopeFailedIn(scopes.At(i).Scope().Attributes(), letter) {
+			r.ProccessMetricsContext(scopes.At(i), msg, letter.SinkID)
+		}
+	}
+}
+
+func (r *OrbReceiver) ProccessMetricsContext(scope pmetric.ScopeMetrics, msg messaging.Message, onlySinkID string) {
 	// Extract Datasets
 	attrDataset, ok := scope.Scope().Attributes().Get("dataset_ids")
 	if !ok {
 		r.cfg.Logger.Info("No datasetIDs information on metrics scope attributes")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingDatasets, Signal: "metrics"}, msg, nil)
 		return
 	}
 	datasets := attrDataset.AsString()
 	if datasets == "" {
 		r.cfg.Logger.Info("datasetIDs information on metrics is empty")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingDatasets, Signal: "metrics"}, msg, nil)
 		return
 	}
 	datasetIDs := strings.Split(datasets, ",")
@@ -74,11 +99,13 @@ func (r *OrbReceiver) ProccessMetricsContext(scope pmetric.ScopeMetrics, channel
 	attrPolID, ok := scope.Scope().Attributes().Get("policy_id")
 	if !ok {
 		r.cfg.Logger.Info("No policyID information on metrics scope attributes")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingPolicy, Signal: "metrics", DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	polID := attrPolID.AsString()
 	if polID == "" {
 		r.cfg.Logger.Info("policyID information on metrics is empty")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingPolicy, Signal: "metrics", DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	// Delete datasets_ids and policy_ids from scope attributes
@@ -87,10 +114,11 @@ func (r *OrbReceiver) ProccessMetricsContext(scope pmetric.ScopeMetrics, channel
 	// Add tags in Context
 	execCtx, execCancelF := context.WithCancel(r.ctx)
 	defer execCancelF()
-	agentPb, err := r.sinkerService.ExtractAgent(execCtx, channel)
+	agentPb, err := r.sinkerService.ExtractAgent(execCtx, msg.Channel)
 	if err != nil {
 		execCancelF()
 		r.cfg.Logger.Info("No data extracting agent information from fleet")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterUnknownAgent, Signal: "metrics", PolicyID: polID, DatasetIDs: datasetIDs}, msg, err)
 		return
 	}
 	for k, v := range agentPb.OrbTags {
@@ -105,6 +133,11 @@ func (r *OrbReceiver) ProccessMetricsContext(scope pmetric.ScopeMetrics, channel
 	if err != nil {
 		execCancelF()
 		r.cfg.Logger.Info("No data extracting metrics sinks information from datasetIds = " + datasets)
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterNoSinks, Signal: "metrics", PolicyID: polID, DatasetIDs: datasetIDs}, msg, err)
+		return
+	}
+	if len(sinkIds) == 0 {
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterNoSinks, Signal: "metrics", PolicyID: polID, DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	attributeCtx := context.WithValue(r.ctx, "agent_name", agentPb.AgentName)
@@ -114,21 +147,28 @@ func (r *OrbReceiver) ProccessMetricsContext(scope pmetric.ScopeMetrics, channel
 	attributeCtx = context.WithValue(attributeCtx, "agent_ownerID", agentPb.OwnerID)
 
 	for sinkId := range sinkIds {
-		err := r.cfg.SinkerService.NotifyActiveSink(r.ctx, agentPb.OwnerID, sinkId, strconv.Itoa(size))
-		if err != nil {
-			r.cfg.Logger.Error("error notifying metrics sink active, changing state, skipping sink", zap.String("sink-id", sinkId), zap.Error(err))
+		if onlySinkID != "" && sinkId != onlySinkID {
+			continue
 		}
 		attributeCtx = context.WithValue(attributeCtx, "sink_id", sinkId)
 		mr := pmetric.NewMetrics()
 		scope.CopyTo(mr.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty())
 		mr.ResourceMetrics().At(0).Resource().Attributes().PutStr("service.name", agentPb.AgentName)
 		mr.ResourceMetrics().At(0).Resource().Attributes().PutStr("service.instance.id", polID)
+		// the size exported to this sink, rather than of the whole agent message
+		size := (&pmetric.ProtoMarshaler{}).MetricsSize(mr)
+		err := r.cfg.SinkerService.NotifyActiveSink(r.ctx, agentPb.OwnerID, sinkId, strconv.Itoa(size))
+		if err != nil {
+			r.cfg.Logger.Error("error notifying metrics sink active, changing state, skipping sink", zap.String("sink-id", sinkId), zap.Error(err))
+		}
 		request := pmetricotlp.NewExportRequestFromMetrics(mr)
 		_, err = r.exportMetrics(attributeCtx, request)
 		if err != nil {
 			r.cfg.Logger.Error("error during metrics export, skipping sink", zap.Error(err))
+			r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterExportFailed, Signal: "metrics", PolicyID: polID, DatasetIDs: datasetIDs, SinkID: sinkId}, msg, err)
 			continue
 		}
+		r.sinkerService.RecordSinkUsage(agentPb.OwnerID, sinkId, size, mr.DataPointCount())
 	}
 }
 
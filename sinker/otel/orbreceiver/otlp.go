@@ -20,12 +20,16 @@ import (
 	"fmt"
 	"go.opentelemetry.io/collector/receiver/receiverhelper"
 	"io"
+	"strings"
 	"sync"
 
 	"github.com/andybalholm/brotli"
+	"github.com/mainflux/mainflux/pkg/messaging"
 	"github.com/orb-community/orb/sinker/otel/bridgeservice"
+	"github.com/orb-community/orb/sinker/redis/producer"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/receiver"
 	"go.uber.org/zap"
 )
@@ -92,6 +96,41 @@ func (r *OrbReceiver) DecompressBrotli(data []byte) []byte {
 	return []byte(s)
 }
 
+// recordDeadLetter keeps the agent message the receiver could not route or export, to be inspected and replayed
+func (r *OrbReceiver) recordDeadLetter(letter producer.DeadLetter, msg messaging.Message, err error) {
+	letter.Channel = msg.Channel
+	letter.Publisher = msg.Publisher
+	letter.Subtopic = msg.Subtopic
+	letter.Protocol = msg.Protocol
+	letter.Payload = msg.Payload
+	if err != nil {
+		letter.Error = err.Error()
+	}
+	// the owner is the only one allowed to list and replay it, the letters of unknown agents have none and are dropped
+	if letter.Reason != producer.DeadLetterUnknownAgent {
+		if agentPb, err := r.sinkerService.ExtractAgent(r.ctx, msg.Channel); err == nil {
+			letter.OwnerID = agentPb.OwnerID
+		}
+	}
+	r.sinkerService.RecordDeadLetter(r.ctx, letter)
+}
+
+// scopeFailedIn tells whether the scope is the one the dead letter was kept for, by the dataset and policy read from it
+func scopeFailedIn(attributes pcommon.Map, letter producer.DeadLetter) bool {
+	var datasets, policyID string
+	if attr, ok := attributes.Get("dataset_ids"); ok {
+		datasets = attr.AsString()
+	}
+	if attr, ok := attributes.Get("policy_id"); ok {
+		policyID = attr.AsString()
+	}
+	if datasets != strings.Join(letter.DatasetIDs, ",") {
+		return false
+	}
+	// the policy is only read from scopes with datasets
+	return datasets == "" || policyID == letter.PolicyID
+}
+
 func (r *OrbReceiver) registerMetricsConsumer(mc consumer.Metrics) error {
 	if mc == nil {
 		return component.ErrNilNextConsumer
@@ -117,6 +156,7 @@ func (r *OrbReceiver) registerMetricsConsumer(mc consumer.Metrics) error {
 	if err = r.cfg.PubSub.Subscribe(otelTopic, r.MessageMetricsInbound); err != nil {
 		return err
 	}
+	r.sinkerService.RegisterReplayHandler("metrics", r.replayMetrics)
 	r.cfg.Logger.Info("started otel metrics consumer", zap.String("otel-topic", otelTopic))
 
 	return nil
@@ -147,6 +187,7 @@ func (r *OrbReceiver) registerLogsConsumer(lc consumer.Logs) error {
 	if err = r.cfg.PubSub.Subscribe(otelTopic, r.MessageLogsInbound); err != nil {
 		return err
 	}
+	r.sinkerService.RegisterReplayHandler("logs", r.replayLogs)
 	r.cfg.Logger.Info("started otel logs consumer", zap.String("otel-topic", otelTopic))
 
 	return nil
@@ -177,6 +218,7 @@ func (r *OrbReceiver) registerTracesConsumer(tc consumer.Traces) error {
 	if err = r.cfg.PubSub.Subscribe(otelTopic, r.MessageTracesInbound); err != nil {
 		return err
 	}
+	r.sinkerService.RegisterReplayHandler("traces", r.replayTraces)
 	r.cfg.Logger.Info("started otel traces consumer", zap.String("otel-topic", otelTopic))
 
 	return nil
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/orb-community/orb/sinker/redis/producer"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
@@ -25,6 +26,7 @@ type internalTracesReceiver struct {
 
 func (r *OrbReceiver) MessageTracesInbound(msg messaging.Message) error {
 	go func() {
+		defer r.sinkerService.TrackMessageProcessing("traces")()
 		r.cfg.Logger.Debug("received agent message",
 			zap.String("subtopic", msg.Subtopic),
 			zap.String("channel", msg.Channel),
@@ -32,11 +34,11 @@ func (r *OrbReceiver) MessageTracesInbound(msg messaging.Message) error {
 			zap.Int64("created", msg.Created),
 			zap.String("publisher", msg.Publisher))
 		r.cfg.Logger.Info("received trace message, pushing to kafka exporter")
-		size := len(msg.Payload)
 		decompressedPayload := r.DecompressBrotli(msg.Payload)
 		tr, err := r.encoder.unmarshalTracesRequest(decompressedPayload)
 		if err != nil {
 			r.cfg.Logger.Error("error during unmarshalling, skipping message", zap.Error(err))
+			r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMalformedPayload, Signal: "traces"}, msg, err)
 			return
 		}
 
@@ -49,22 +51,45 @@ func (r *OrbReceiver) MessageTracesInbound(msg messaging.Message) error {
 
 		scopes := tr.Traces().ResourceSpans().At(0).ScopeSpans()
 		for i := 0; i < scopes.Len(); i++ {
-			r.ProccessTracesContext(scopes.At(i), msg.Channel, size)
+			r.ProccessTracesContext(scopes.At(i), msg, "")
 		}
 	}()
 	return nil
 }
 
-func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, channel string, size int) {
+// replayTraces processes again only the scope a dead letter failed on, and only for its sink when it failed on export
+func (r *OrbReceiver) replayTraces(_ context.Context, letter producer.DeadLetter) {
+	defer r.sinkerService.TrackMessageProcessing("traces")()
+	msg := letter.Message()
+	tr, err := r.encoder.unmarshalTracesRequest(r.DecompressBrotli(msg.Payload))
+	if err != nil {
+		r.cfg.Logger.Error("error during unmarshalling, skipping replay", zap.Error(err))
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMalformedPayload, Signal: "traces"}, msg, err)
+		return
+	}
+	if tr.Traces().ResourceSpans().Len() == 0 {
+		return
+	}
+	scopes := tr.Traces().ResourceSpans().At(0).ScopeSpans()
+	for i := 0; i < scopes.Len(); i++ {
+		if scopeFailedIn(scopes.At(i).Scope().Attributes(), letter) {
+			r.ProccessTracesContext(scopes.At(i), msg, letter.SinkID)
+		}
+	}
+}
+
+func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, msg messaging.Message, onlySinkID string) {
 	// Extract Datasets
 	attrDataset, ok := scope.Scope().Attributes().Get("dataset_ids")
 	if !ok {
 		r.cfg.Logger.Info("No datasetIDs information on spans scope attributes")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingDatasets, Signal: "traces"}, msg, nil)
 		return
 	}
 	datasets := attrDataset.AsString()
 	if datasets == "" {
 		r.cfg.Logger.Info("datasetIDs information is empty")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingDatasets, Signal: "traces"}, msg, nil)
 		return
 	}
 	datasetIDs := strings.Split(datasets, ",")
@@ -72,11 +97,13 @@ func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, channel str
 	attrPolID, ok := scope.Scope().Attributes().Get("policy_id")
 	if !ok {
 		r.cfg.Logger.Info("No policyID information on spans scope attributes")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingPolicy, Signal: "traces", DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	polID := attrPolID.AsString()
 	if polID == "" {
 		r.cfg.Logger.Info("policyID information is empty")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterMissingPolicy, Signal: "traces", DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	// Delete datasets_ids and policy_ids from scope attributes
@@ -85,10 +112,11 @@ func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, channel str
 	// Add tags in Context
 	execCtx, execCancelF := context.WithCancel(r.ctx)
 	defer execCancelF()
-	agentPb, err := r.sinkerService.ExtractAgent(execCtx, channel)
+	agentPb, err := r.sinkerService.ExtractAgent(execCtx, msg.Channel)
 	if err != nil {
 		execCancelF()
 		r.cfg.Logger.Info("No data extracting agent information from fleet")
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterUnknownAgent, Signal: "traces", PolicyID: polID, DatasetIDs: datasetIDs}, msg, err)
 		return
 	}
 	for k, v := range agentPb.OrbTags {
@@ -101,6 +129,11 @@ func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, channel str
 	if err != nil {
 		execCancelF()
 		r.cfg.Logger.Info("No data extracting sinks information from datasetIds = " + datasets)
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterNoSinks, Signal: "traces", PolicyID: polID, DatasetIDs: datasetIDs}, msg, err)
+		return
+	}
+	if len(sinkIds) == 0 {
+		r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterNoSinks, Signal: "traces", PolicyID: polID, DatasetIDs: datasetIDs}, msg, nil)
 		return
 	}
 	attributeCtx := context.WithValue(r.ctx, "agent_name", agentPb.AgentName)
@@ -110,9 +143,7 @@ func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, channel str
 	attributeCtx = context.WithValue(attributeCtx, "agent_ownerID", agentPb.OwnerID)
 
 	for sinkId := range sinkIds {
-		err := r.cfg.SinkerService.NotifyActiveSink(r.ctx, agentPb.OwnerID, sinkId, strconv.Itoa(size))
-		if err != nil {
-			r.cfg.Logger.Error("error notifying sink active, changing state, skipping sink", zap.String("sink-id", sinkId), zap.Error(err))
+		if onlySinkID != "" && sinkId != onlySinkID {
 			continue
 		}
 		attributeCtx = context.WithValue(attributeCtx, "sink_id", sinkId)
@@ -120,12 +151,21 @@ func (r *OrbReceiver) ProccessTracesContext(scope ptrace.ScopeSpans, channel str
 		scope.CopyTo(lr.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty())
 		lr.ResourceSpans().At(0).Resource().Attributes().PutStr("service.name", agentPb.AgentName)
 		lr.ResourceSpans().At(0).Resource().Attributes().PutStr("service.instance.id", polID)
+		// the size exported to this sink, rather than of the whole agent message
+		size := (&ptrace.ProtoMarshaler{}).TracesSize(lr)
+		err := r.cfg.SinkerService.NotifyActiveSink(r.ctx, agentPb.OwnerID, sinkId, strconv.Itoa(size))
+		if err != nil {
+			r.cfg.Logger.Error("error notifying sink active, changing state, skipping sink", zap.String("sink-id", sinkId), zap.Error(err))
+			continue
+		}
 		request := ptraceotlp.NewExportRequestFromTraces(lr)
 		_, err = r.exportTraces(attributeCtx, request)
 		if err != nil {
 			r.cfg.Logger.Error("error during export, skipping sink", zap.Error(err))
+			r.recordDeadLetter(producer.DeadLetter{Reason: producer.DeadLetterExportFailed, Signal: "traces", PolicyID: polID, DatasetIDs: datasetIDs, SinkID: sinkId}, msg, err)
 			continue
 		}
+		r.sinkerService.RecordSinkUsage(agentPb.OwnerID, sinkId, size, lr.SpanCount())
 	}
 }
 
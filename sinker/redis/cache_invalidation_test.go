@@ -0,0 +1,62 @@
+package redis_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/go-redis/redis/v8"
+	policiespb "github.com/orb-community/orb/policies/pb"
+	"github.com/orb-community/orb/sinker/otel/bridgeservice"
+	"github.com/orb-community/orb/sinker/redis/consumer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type datasetClient struct {
+	policiespb.PolicyServiceClient
+	mu      sync.Mutex
+	sinkIDs []string
+}
+
+func (c *datasetClient) RetrieveDataset(_ context.Context, in *policiespb.DatasetByIDReq, _ ...grpc.CallOption) (*policiespb.DatasetRes, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &policiespb.DatasetRes{Id: in.DatasetID, SinkIds: c.sinkIDs}, nil
+}
+
+func TestEditedDatasetReroutes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pc := &datasetClient{sinkIDs: []string{"sink-a"}}
+	bs := bridgeservice.NewBridgeService(logger, time.Hour, nil, nil, nil, pc, nil, nil, discard.NewCounter(), discard.NewGauge(), discard.NewCounter())
+	listener := consumer.NewCacheInvalidationListener(logger, redisClient, &bs)
+	require.NoError(t, listener.SubscribeToEntityEvents(ctx))
+
+	sinks, err := bs.GetSinkIdsFromDatasetIDs(ctx, "owner-1", []string{"dataset-1"})
+	require.NoError(t, err)
+	require.Contains(t, sinks, "sink-a")
+
+	pc.mu.Lock()
+	pc.sinkIDs = []string{"sink-b"}
+	pc.mu.Unlock()
+	// published right away, the listener reads the events added since it subscribed
+	err = redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: "orb.policies",
+		Values: map[string]interface{}{
+			"id":        "dataset-1",
+			"owner_id":  "owner-1",
+			"operation": "dataset.update",
+		},
+	}).Err()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		sinks, err := bs.GetSinkIdsFromDatasetIDs(ctx, "owner-1", []string{"dataset-1"})
+		_, rerouted := sinks["sink-b"]
+		return err == nil && rerouted
+	}, 5*time.Second, 50*time.Millisecond, "dataset should be re-routed to the new sink within seconds")
+}
@@ -0,0 +1,152 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	policiesStream = "orb.policies"
+	fleetStream    = "orb.fleet"
+	sinksStream    = "orb.sinks"
+
+	datasetUpdate    = "dataset.update"
+	datasetRemove    = "dataset.remove"
+	policyUpdate     = "policy.update"
+	policyRemove     = "policy.remove"
+	agentUpdate      = "agent.update"
+	agentRemove      = "agent.remove"
+	agentGroupCreate = "agent_group.create"
+	agentGroupUpdate = "agent_group.update"
+	agentGroupRemove = "agent_group.remove"
+	sinkRemove       = "sinks.remove"
+)
+
+// CacheInvalidator evicts the entries of the sinker in-memory cache built from an entity
+type CacheInvalidator interface {
+	InvalidateAgent(channelID string)
+	InvalidateOwnerAgents(ownerID string)
+	InvalidateAgentGroup(groupID string)
+	InvalidatePolicy(policyID string)
+	InvalidateDataset(ownerID, datasetID string)
+	InvalidateSink(ownerID, sinkID string)
+}
+
+type CacheInvalidationListener interface {
+	// SubscribeToEntityEvents listen to the policies, fleet and sinks streams and evicts the affected cache entries
+	SubscribeToEntityEvents(ctx context.Context) error
+	// ReceiveMessage to be used to handle an event read from one of the streams
+	ReceiveMessage(ctx context.Context, stream string, message redis.XMessage) error
+}
+
+type cacheInvalidationListener struct {
+	logger       *zap.Logger
+	streamClient *redis.Client
+	invalidator  CacheInvalidator
+}
+
+func NewCacheInvalidationListener(l *zap.Logger, streamClient *redis.Client, invalidator CacheInvalidator) CacheInvalidationListener {
+	logger := l.Named("cache_invalidation_listener")
+	return &cacheInvalidationListener{logger: logger, streamClient: streamClient, invalidator: invalidator}
+}
+
+// SubscribeToEntityEvents reads the streams without a consumer group, every sinker replica has its own cache
+// and needs to see all events. Only the events added once it returns are read
+func (s *cacheInvalidationListener) SubscribeToEntityEvents(ctx context.Context) error {
+	streams := []string{policiesStream, fleetStream, sinksStream}
+	lastIDs := map[string]string{}
+	// "$" would be resolved again on every read, missing the events added between two reads
+	for _, stream := range streams {
+		id, err := s.streamTailID(ctx, stream)
+		if err != nil {
+			return err
+		}
+		lastIDs[stream] = id
+	}
+	go func(rLogger *zap.Logger) {
+		for {
+			select {
+			case <-ctx.Done():
+				rLogger.Info("closing cache_invalidation_listener routine")
+				return
+			default:
+				args := make([]string, 0, len(streams)*2)
+				args = append(args, streams...)
+				for _, stream := range streams {
+					args = append(args, lastIDs[stream])
+				}
+				res, err := s.streamClient.XRead(ctx, &redis.XReadArgs{
+					Streams: args,
+					Count:   100,
+				}).Result()
+				if err != nil || len(res) == 0 {
+					if err != nil && err != redis.Nil && ctx.Err() == nil {
+						rLogger.Error("failed to read streams", zap.Error(err))
+					}
+					continue
+				}
+				for _, stream := range res {
+					for _, msg := range stream.Messages {
+						lastIDs[stream.Stream] = msg.ID
+						if err := s.ReceiveMessage(ctx, stream.Stream, msg); err != nil {
+							rLogger.Error("failed to process message", zap.String("stream", stream.Stream), zap.Error(err))
+						}
+					}
+				}
+			}
+		}
+	}(s.logger.Named("goroutine_cache_invalidation_listener"))
+	return nil
+}
+
+// streamTailID returns the id of the last event of the stream, or the lowest id for a stream without events
+func (s *cacheInvalidationListener) streamTailID(ctx context.Context, stream string) (string, error) {
+	msgs, err := s.streamClient.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+	return msgs[0].ID, nil
+}
+
+func (s *cacheInvalidationListener) ReceiveMessage(_ context.Context, stream string, message redis.XMessage) error {
+	event := message.Values
+	operation := read(event, "operation")
+	switch stream {
+	case policiesStream:
+		switch operation {
+		case datasetUpdate, datasetRemove:
+			s.invalidator.InvalidateDataset(read(event, "owner_id"), read(event, "id"))
+		case policyUpdate, policyRemove:
+			s.invalidator.InvalidatePolicy(read(event, "id"))
+		}
+	case fleetStream:
+		switch operation {
+		case agentUpdate, agentRemove:
+			s.invalidator.InvalidateAgent(read(event, "channel_id"))
+		case agentGroupCreate, agentGroupUpdate:
+			s.invalidator.InvalidateOwnerAgents(read(event, "owner_id"))
+		case agentGroupRemove:
+			s.invalidator.InvalidateAgentGroup(read(event, "group_id"))
+		}
+	case sinksStream:
+		switch operation {
+		case sinkRemove:
+			s.invalidator.InvalidateSink(read(event, "owner"), read(event, "sink_id"))
+		}
+	}
+	s.logger.Debug("handled entity event", zap.String("stream", stream), zap.String("operation", operation))
+	return nil
+}
+
+func read(event map[string]interface{}, key string) string {
+	val, ok := event[key].(string)
+	if !ok {
+		return ""
+	}
+	return val
+}
@@ -0,0 +1,215 @@
+package consumer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/go-redis/redis/v8"
+	fleetpb "github.com/orb-community/orb/fleet/pb"
+	policiespb "github.com/orb-community/orb/policies/pb"
+	"github.com/orb-community/orb/sinker/otel/bridgeservice"
+	"github.com/orb-community/orb/sinker/redis/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const (
+	ownerID   = "8f2b3c4d-0000-4000-8000-000000000001"
+	datasetID = "dataset-1"
+	channelID = "channel-1"
+)
+
+type policiesClient struct {
+	policiespb.PolicyServiceClient
+	mu      sync.Mutex
+	sinkIDs []string
+	calls   int
+}
+
+func (c *policiesClient) RetrieveDataset(_ context.Context, in *policiespb.DatasetByIDReq, _ ...grpc.CallOption) (*policiespb.DatasetRes, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return &policiespb.DatasetRes{Id: in.DatasetID, SinkIds: c.sinkIDs}, nil
+}
+
+func (c *policiesClient) RetrievePolicy(_ context.Context, in *policiespb.PolicyByIDReq, _ ...grpc.CallOption) (*policiespb.PolicyRes, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return &policiespb.PolicyRes{Id: in.PolicyID}, nil
+}
+
+func (c *policiesClient) setSinks(sinkIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sinkIDs = sinkIDs
+}
+
+type fleetClient struct {
+	fleetpb.FleetServiceClient
+	mu      sync.Mutex
+	orbTags map[string]string
+	groups  []string
+}
+
+func (c *fleetClient) RetrieveAgentInfoByChannelID(_ context.Context, _ *fleetpb.AgentInfoByChannelIDReq, _ ...grpc.CallOption) (*fleetpb.AgentInfoRes, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &fleetpb.AgentInfoRes{OwnerID: ownerID, OrbTags: c.orbTags, AgentGroupIDs: c.groups}, nil
+}
+
+func (c *fleetClient) setTags(tags map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.orbTags = tags
+}
+
+func newBridge(pc *policiesClient, fc *fleetClient) *bridgeservice.SinkerOtelBridgeService {
+	// a long expiration to make sure only the events evict the entries
+	bs := bridgeservice.NewBridgeService(zap.NewNop(), time.Hour, nil, nil, nil, pc, nil, fc, discard.NewCounter(), discard.NewGauge(), discard.NewCounter())
+	return &bs
+}
+
+func TestDatasetEditReroutes(t *testing.T) {
+	ctx := context.Background()
+	pc := &policiesClient{sinkIDs: []string{"sink-a"}}
+	bs := newBridge(pc, &fleetClient{})
+	listener := consumer.NewCacheInvalidationListener(zap.NewNop(), nil, bs)
+
+	sinks, err := bs.GetSinkIdsFromDatasetIDs(ctx, ownerID, []string{datasetID})
+	require.NoError(t, err)
+	assert.Contains(t, sinks, "sink-a")
+
+	pc.setSinks("sink-b")
+	sinks, err = bs.GetSinkIdsFromDatasetIDs(ctx, ownerID, []string{datasetID})
+	require.NoError(t, err)
+	assert.Contains(t, sinks, "sink-a", "dataset should be served from cache before the event")
+
+	err = listener.ReceiveMessage(ctx, "orb.policies", redis.XMessage{ID: "1-0", Values: map[string]interface{}{
+		"id":        datasetID,
+		"owner_id":  ownerID,
+		"operation": "dataset.update",
+	}})
+	require.NoError(t, err)
+
+	sinks, err = bs.GetSinkIdsFromDatasetIDs(ctx, ownerID, []string{datasetID})
+	require.NoError(t, err)
+	assert.Contains(t, sinks, "sink-b")
+	assert.NotContains(t, sinks, "sink-a")
+}
+
+func TestCacheInvalidationEvents(t *testing.T) {
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		stream  string
+		values  map[string]interface{}
+		evicted bool
+	}{
+		"agent update evicts the agent": {
+			stream:  "orb.fleet",
+			values:  map[string]interface{}{"channel_id": channelID, "owner_id": ownerID, "operation": "agent.update"},
+			evicted: true,
+		},
+		"agent update of another agent keeps the agent": {
+			stream:  "orb.fleet",
+			values:  map[string]interface{}{"channel_id": "another", "owner_id": ownerID, "operation": "agent.update"},
+			evicted: false,
+		},
+		"agent group update evicts the owner agents": {
+			stream:  "orb.fleet",
+			values:  map[string]interface{}{"group_id": "group-2", "owner_id": ownerID, "operation": "agent_group.update"},
+			evicted: true,
+		},
+		"agent group update of another owner keeps the agent": {
+			stream:  "orb.fleet",
+			values:  map[string]interface{}{"group_id": "group-2", "owner_id": "another", "operation": "agent_group.update"},
+			evicted: false,
+		},
+		"agent group removal evicts the member agents": {
+			stream:  "orb.fleet",
+			values:  map[string]interface{}{"group_id": "group-1", "operation": "agent_group.remove"},
+			evicted: true,
+		},
+		"agent group removal of another group keeps the agent": {
+			stream:  "orb.fleet",
+			values:  map[string]interface{}{"group_id": "group-2", "operation": "agent_group.remove"},
+			evicted: false,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			fc := &fleetClient{orbTags: map[string]string{"region": "eu"}, groups: []string{"group-1"}}
+			bs := newBridge(&policiesClient{}, fc)
+			listener := consumer.NewCacheInvalidationListener(zap.NewNop(), nil, bs)
+
+			_, err := bs.ExtractAgent(ctx, channelID)
+			require.NoError(t, err)
+			fc.setTags(map[string]string{"region": "us"})
+
+			err = listener.ReceiveMessage(ctx, tc.stream, redis.XMessage{ID: "1-0", Values: tc.values})
+			require.NoError(t, err)
+
+			agent, err := bs.ExtractAgent(ctx, channelID)
+			require.NoError(t, err)
+			if tc.evicted {
+				assert.Equal(t, "us", agent.OrbTags["region"], "%s: expected the agent to be refreshed", desc)
+			} else {
+				assert.Equal(t, "eu", agent.OrbTags["region"], "%s: expected the agent to be cached", desc)
+			}
+		})
+	}
+}
+
+func TestSinkRemovalEvictsDatasets(t *testing.T) {
+	ctx := context.Background()
+	pc := &policiesClient{sinkIDs: []string{"sink-a", "sink-b"}}
+	bs := newBridge(pc, &fleetClient{})
+	listener := consumer.NewCacheInvalidationListener(zap.NewNop(), nil, bs)
+
+	_, err := bs.GetSinkIdsFromDatasetIDs(ctx, ownerID, []string{datasetID})
+	require.NoError(t, err)
+	pc.setSinks("sink-b")
+
+	err = listener.ReceiveMessage(ctx, "orb.sinks", redis.XMessage{ID: "1-0", Values: map[string]interface{}{
+		"sink_id":   "sink-a",
+		"owner":     ownerID,
+		"operation": "sinks.remove",
+	}})
+	require.NoError(t, err)
+
+	sinks, err := bs.GetSinkIdsFromDatasetIDs(ctx, ownerID, []string{datasetID})
+	require.NoError(t, err)
+	assert.NotContains(t, sinks, "sink-a")
+}
+
+func TestPolicyUpdateEvictsPolicy(t *testing.T) {
+	ctx := context.Background()
+	pc := &policiesClient{}
+	bs := newBridge(pc, &fleetClient{})
+	listener := consumer.NewCacheInvalidationListener(zap.NewNop(), nil, bs)
+
+	_, err := bs.GetPolicyName(ctx, "policy-1", ownerID)
+	require.NoError(t, err)
+	_, err = bs.GetPolicyName(ctx, "policy-1", ownerID)
+	require.NoError(t, err)
+	require.Equal(t, 1, pc.calls)
+
+	err = listener.ReceiveMessage(ctx, "orb.policies", redis.XMessage{ID: "1-0", Values: map[string]interface{}{
+		"id":        "policy-1",
+		"owner_id":  ownerID,
+		"operation": "policy.update",
+	}})
+	require.NoError(t, err)
+
+	_, err = bs.GetPolicyName(ctx, "policy-1", ownerID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pc.calls)
+}
@@ -0,0 +1,93 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/sinker/redis/producer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterStore(t *testing.T) {
+	store := producer.NewDeadLetterStore(logger, redisClient)
+	ctx := context.Background()
+
+	letters := []producer.DeadLetter{
+		{
+			OwnerID:   "owner-1",
+			Reason:    producer.DeadLetterMalformedPayload,
+			Signal:    "metrics",
+			Channel:   "channel-1",
+			Publisher: "agent-1",
+			Subtopic:  "otlp.pktvisor.m.policy-1",
+			Payload:   []byte{0x0b, 0x00, 0xff},
+		},
+		{
+			OwnerID:    "owner-1",
+			Reason:     producer.DeadLetterExportFailed,
+			Error:      "kafka unavailable",
+			Signal:     "logs",
+			Channel:    "channel-2",
+			PolicyID:   "policy-2",
+			DatasetIDs: []string{"dataset-1", "dataset-2"},
+			SinkID:     "sink-2",
+			Payload:    []byte("payload"),
+		},
+		{
+			OwnerID: "owner-2",
+			Reason:  producer.DeadLetterNoSinks,
+			Signal:  "traces",
+			Channel: "channel-3",
+			Payload: []byte("payload"),
+		},
+	}
+	for _, letter := range letters {
+		require.Nil(t, store.PublishDeadLetter(ctx, letter), "unexpected error publishing dead letter")
+	}
+
+	listed, err := store.ListDeadLetters(ctx, "owner-1", 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, listed, 2, "expected only the dead letters of the owner")
+	// newest first
+	assert.Equal(t, letters[1].DatasetIDs, listed[0].DatasetIDs, "expected dataset ids to be kept")
+	assert.Equal(t, letters[1].SinkID, listed[0].SinkID, "expected sink id to be kept")
+	assert.Equal(t, letters[0].Payload, listed[1].Payload, "expected the binary payload to be kept")
+
+	newest, err := store.ListDeadLetters(ctx, "owner-1", 1)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, newest, 1, "expected the dead letters up to limit")
+	assert.Equal(t, listed[0].ID, newest[0].ID, "expected the newest dead letter of the owner")
+
+	cases := map[string]struct {
+		id  string
+		err error
+	}{
+		"retrieve existing dead letter": {
+			id: listed[1].ID,
+		},
+		"retrieve non-existing dead letter": {
+			id:  "1-1",
+			err: producer.ErrDeadLetterNotFound,
+		},
+		"retrieve dead letter with invalid id": {
+			id:  "invalid",
+			err: producer.ErrDeadLetterNotFound,
+		},
+	}
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			letter, err := store.RetrieveDeadLetter(ctx, tc.id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, letters[0].Channel, letter.Channel, fmt.Sprintf("%s: expected channel %s got %s", desc, letters[0].Channel, letter.Channel))
+			}
+		})
+	}
+
+	require.Nil(t, store.RemoveDeadLetter(ctx, listed[1].ID), "unexpected error removing dead letter")
+	_, err = store.RetrieveDeadLetter(ctx, listed[1].ID)
+	assert.True(t, errors.Contains(err, producer.ErrDeadLetterNotFound), fmt.Sprintf("expected %s got %s", producer.ErrDeadLetterNotFound, err))
+}
@@ -0,0 +1,192 @@
+package producer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/orb-community/orb/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	deadLetterStream = "orb.sinker.dead_letters"
+	deadLetterMaxLen = 10000
+	// deadLetterPageSize is how many dead letters are read at once while looking for the ones of an owner
+	deadLetterPageSize = 100
+)
+
+// Reasons an agent message is not exported, kept as a dead letter when its owner is known
+const (
+	DeadLetterMalformedPayload = "malformed_payload"
+	DeadLetterMissingDatasets  = "missing_dataset_ids"
+	DeadLetterMissingPolicy    = "missing_policy_id"
+	DeadLetterUnknownAgent     = "unknown_agent"
+	DeadLetterNoSinks          = "no_sinks"
+	DeadLetterExportFailed     = "export_failed"
+)
+
+// ErrDeadLetterNotFound indicates the dead letter was replayed already or evicted from the stream
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+type DeadLetterStore interface {
+	// PublishDeadLetter keeps an agent message that could not be routed or exported, evicting the oldest ones past the stream bound
+	PublishDeadLetter(ctx context.Context, letter DeadLetter) error
+	// ListDeadLetters retrieves the newest dead letters of the owner, up to limit
+	ListDeadLetters(ctx context.Context, ownerID string, limit int64) ([]DeadLetter, error)
+	// RetrieveDeadLetter retrieves a dead letter by its stream id
+	RetrieveDeadLetter(ctx context.Context, id string) (DeadLetter, error)
+	// RemoveDeadLetter removes a dead letter by its stream id
+	RemoveDeadLetter(ctx context.Context, id string) error
+}
+
+// DeadLetter is an agent message with the reason it was not exported, carrying the original payload to replay it
+type DeadLetter struct {
+	ID         string
+	OwnerID    string
+	Reason     string
+	Error      string
+	Signal     string
+	Channel    string
+	Publisher  string
+	Subtopic   string
+	Protocol   string
+	PolicyID   string
+	DatasetIDs []string
+	SinkID     string
+	Payload    []byte
+	Timestamp  time.Time
+}
+
+func (d *DeadLetter) Encode() map[string]interface{} {
+	return map[string]interface{}{
+		"owner_id":    d.OwnerID,
+		"reason":      d.Reason,
+		"error":       d.Error,
+		"signal":      d.Signal,
+		"channel":     d.Channel,
+		"publisher":   d.Publisher,
+		"subtopic":    d.Subtopic,
+		"protocol":    d.Protocol,
+		"policy_id":   d.PolicyID,
+		"dataset_ids": strings.Join(d.DatasetIDs, ","),
+		"sink_id":     d.SinkID,
+		"payload":     string(d.Payload),
+		"timestamp":   d.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+// Message rebuilds the agent message the dead letter was kept from
+func (d *DeadLetter) Message() messaging.Message {
+	return messaging.Message{
+		Channel:   d.Channel,
+		Subtopic:  d.Subtopic,
+		Publisher: d.Publisher,
+		Protocol:  d.Protocol,
+		Payload:   d.Payload,
+		Created:   time.Now().UnixNano(),
+	}
+}
+
+func decodeDeadLetter(msg redis.XMessage) DeadLetter {
+	read := func(key string) string {
+		val, _ := msg.Values[key].(string)
+		return val
+	}
+	letter := DeadLetter{
+		ID:        msg.ID,
+		OwnerID:   read("owner_id"),
+		Reason:    read("reason"),
+		Error:     read("error"),
+		Signal:    read("signal"),
+		Channel:   read("channel"),
+		Publisher: read("publisher"),
+		Subtopic:  read("subtopic"),
+		Protocol:  read("protocol"),
+		PolicyID:  read("policy_id"),
+		SinkID:    read("sink_id"),
+		Payload:   []byte(read("payload")),
+	}
+	if datasets := read("dataset_ids"); datasets != "" {
+		letter.DatasetIDs = strings.Split(datasets, ",")
+	}
+	letter.Timestamp, _ = time.Parse(time.RFC3339Nano, read("timestamp"))
+	return letter
+}
+
+var _ DeadLetterStore = (*deadLetterStore)(nil)
+
+type deadLetterStore struct {
+	logger            *zap.Logger
+	redisStreamClient *redis.Client
+}
+
+func NewDeadLetterStore(l *zap.Logger, redisStreamClient *redis.Client) DeadLetterStore {
+	logger := l.Named("dead_letter_store")
+	return &deadLetterStore{logger: logger, redisStreamClient: redisStreamClient}
+}
+
+func (s *deadLetterStore) PublishDeadLetter(ctx context.Context, letter DeadLetter) error {
+	record := &redis.XAddArgs{
+		Stream: deadLetterStream,
+		Values: letter.Encode(),
+		MaxLen: deadLetterMaxLen,
+		Approx: true,
+	}
+	err := s.redisStreamClient.XAdd(ctx, record).Err()
+	if err != nil {
+		s.logger.Error("error sending dead letter to sinker event store", zap.Error(err))
+	}
+	return err
+}
+
+func (s *deadLetterStore) ListDeadLetters(ctx context.Context, ownerID string, limit int64) ([]DeadLetter, error) {
+	letters := []DeadLetter{}
+	end := "+"
+	for int64(len(letters)) < limit {
+		msgs, err := s.redisStreamClient.XRevRangeN(ctx, deadLetterStream, end, "-", deadLetterPageSize).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			// the range is inclusive, the last letter of the previous page comes first again
+			if msg.ID == end {
+				continue
+			}
+			letter := decodeDeadLetter(msg)
+			if letter.OwnerID != ownerID {
+				continue
+			}
+			letters = append(letters, letter)
+			if int64(len(letters)) == limit {
+				break
+			}
+		}
+		if len(msgs) < deadLetterPageSize {
+			break
+		}
+		end = msgs[len(msgs)-1].ID
+	}
+	return letters, nil
+}
+
+func (s *deadLetterStore) RetrieveDeadLetter(ctx context.Context, id string) (DeadLetter, error) {
+	msgs, err := s.redisStreamClient.XRange(ctx, deadLetterStream, id, id).Result()
+	if err != nil {
+		// an id that is not a stream id cannot match any dead letter
+		if strings.Contains(err.Error(), "Invalid stream ID") {
+			return DeadLetter{}, ErrDeadLetterNotFound
+		}
+		return DeadLetter{}, err
+	}
+	if len(msgs) == 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return decodeDeadLetter(msgs[0]), nil
+}
+
+func (s *deadLetterStore) RemoveDeadLetter(ctx context.Context, id string) error {
+	return s.redisStreamClient.XDel(ctx, deadLetterStream, id).Err()
+}
@@ -0,0 +1,64 @@
+package producer
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+type SinkUsageProducer interface {
+	// PublishSinkUsage to be used to publish the accumulated sink usage of a time window to the sinks service
+	PublishSinkUsage(ctx context.Context, event SinkUsageEvent) error
+}
+
+type SinkUsageEvent struct {
+	OwnerID     string
+	SinkID      string
+	Bytes       int64
+	DataPoints  int64
+	Messages    int64
+	WindowStart time.Time
+	Timestamp   time.Time
+}
+
+func (s *SinkUsageEvent) Encode() map[string]interface{} {
+	return map[string]interface{}{
+		"owner_id":     s.OwnerID,
+		"sink_id":      s.SinkID,
+		"bytes":        strconv.FormatInt(s.Bytes, 10),
+		"data_points":  strconv.FormatInt(s.DataPoints, 10),
+		"messages":     strconv.FormatInt(s.Messages, 10),
+		"window_start": s.WindowStart.Format(time.RFC3339),
+		"timestamp":    s.Timestamp.Format(time.RFC3339),
+	}
+}
+
+var _ SinkUsageProducer = (*sinkUsageProducer)(nil)
+
+type sinkUsageProducer struct {
+	logger            *zap.Logger
+	redisStreamClient *redis.Client
+}
+
+func NewSinkUsageProducer(l *zap.Logger, redisStreamClient *redis.Client) SinkUsageProducer {
+	logger := l.Named("sink_usage_producer")
+	return &sinkUsageProducer{logger: logger, redisStreamClient: redisStreamClient}
+}
+
+func (s *sinkUsageProducer) PublishSinkUsage(ctx context.Context, event SinkUsageEvent) error {
+	const maxLen = 10000
+	record := &redis.XAddArgs{
+		Stream: "orb.sink_usage",
+		Values: event.Encode(),
+		MaxLen: maxLen,
+		Approx: true,
+	}
+	err := s.redisStreamClient.XAdd(ctx, record).Err()
+	if err != nil {
+		s.logger.Error("error sending event to sinker event store", zap.Error(err))
+	}
+	return err
+}
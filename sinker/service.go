@@ -14,8 +14,10 @@ import (
 
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-redis/redis/v8"
+	"github.com/mainflux/mainflux"
 	mfnats "github.com/mainflux/mainflux/pkg/messaging/nats"
 	fleetpb "github.com/orb-community/orb/fleet/pb"
+	"github.com/orb-community/orb/pkg/errors"
 	policiespb "github.com/orb-community/orb/policies/pb"
 	"github.com/orb-community/orb/sinker/otel"
 	"github.com/orb-community/orb/sinker/otel/bridgeservice"
@@ -32,9 +34,15 @@ type Service interface {
 	Start() error
 	// Stop end communication with the message bus
 	Stop() error
+	// ListDeadLetters retrieves the newest agent messages of the owner that could not be routed or exported, up to limit
+	ListDeadLetters(ctx context.Context, token string, limit int64) ([]producer.DeadLetter, error)
+	// ReplayDeadLetter processes again the scope of the agent message a dead letter of the owner failed on, only for its
+	// sink when it failed on export, removing the dead letter
+	ReplayDeadLetter(ctx context.Context, token string, id string) error
 }
 
 type SinkerService struct {
+	auth                   mainflux.AuthServiceClient
 	pubSub                 mfnats.PubSub
 	otel                   bool
 	otelMetricsCancelFunct context.CancelFunc
@@ -46,6 +54,9 @@ type SinkerService struct {
 	cacheClient             *redis.Client
 	sinkTTLSvc              producer.SinkerKeyService
 	sinkActivitySvc         producer.SinkActivityProducer
+	sinkUsageSvc            producer.SinkUsageProducer
+	deadLetterSvc           producer.DeadLetterStore
+	bridgeService           *bridgeservice.SinkerOtelBridgeService
 	logger                  *zap.Logger
 
 	hbTicker *time.Ticker
@@ -59,6 +70,8 @@ type SinkerService struct {
 	requestCounter metrics.Counter
 
 	messageInputCounter metrics.Counter
+	processingGauge     metrics.Gauge
+	deadLetterCounter   metrics.Counter
 	cancelAsyncContext  context.CancelFunc
 	asyncContext        context.Context
 }
@@ -68,8 +81,6 @@ func (svc SinkerService) Start() error {
 	ctx = context.WithValue(ctx, "cache_expiry", svc.inMemoryCacheExpiration)
 	svc.asyncContext, svc.cancelAsyncContext = context.WithCancel(ctx)
 
-	svc.sinkTTLSvc = producer.NewSinkerKeyService(svc.logger, svc.cacheClient)
-	svc.sinkActivitySvc = producer.NewSinkActivityProducer(svc.logger, svc.streamClient, svc.sinkTTLSvc)
 	// Create Handle and Listener to Redis Key Events
 	sinkerIdleProducer := producer.NewSinkIdleProducer(svc.logger, svc.streamClient)
 	sinkerKeyExpirationListener := consumer.NewSinkerKeyExpirationListener(svc.logger, svc.cacheClient, sinkerIdleProducer)
@@ -96,12 +107,16 @@ func (svc SinkerService) startOtel(ctx context.Context) error {
 	if svc.otel {
 		var err error
 
-		bridgeService := bridgeservice.NewBridgeService(svc.logger, svc.inMemoryCacheExpiration, svc.sinkActivitySvc,
-			svc.policiesClient, svc.sinksClient, svc.fleetClient, svc.messageInputCounter)
-		svc.otelMetricsCancelFunct, err = otel.StartOtelMetricsComponents(ctx, &bridgeService, svc.logger, svc.otelKafkaUrl, svc.pubSub)
+		go svc.bridgeService.FlushSinkUsage(ctx, bridgeservice.SinkUsageFlushInterval)
+		cacheInvalidationListener := consumer.NewCacheInvalidationListener(svc.logger, svc.streamClient, svc.bridgeService)
+		if err := cacheInvalidationListener.SubscribeToEntityEvents(ctx); err != nil {
+			svc.logger.Error("error subscribing to entity events", zap.Error(err))
+			return err
+		}
+		svc.otelMetricsCancelFunct, err = otel.StartOtelMetricsComponents(ctx, svc.bridgeService, svc.logger, svc.otelKafkaUrl, svc.pubSub)
 
 		// starting Otel Logs components
-		svc.otelLogsCancelFunct, err = otel.StartOtelLogsComponents(ctx, &bridgeService, svc.logger, svc.otelKafkaUrl, svc.pubSub)
+		svc.otelLogsCancelFunct, err = otel.StartOtelLogsComponents(ctx, svc.bridgeService, svc.logger, svc.otelKafkaUrl, svc.pubSub)
 
 		if err != nil {
 			svc.logger.Error("error during StartOtelComponents", zap.Error(err))
@@ -111,6 +126,48 @@ func (svc SinkerService) startOtel(ctx context.Context) error {
 	return nil
 }
 
+func (svc SinkerService) identify(token string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := svc.auth.Identify(ctx, &mainflux.Token{Value: token})
+	if err != nil {
+		return "", errors.Wrap(errors.ErrUnauthorizedAccess, err)
+	}
+
+	return res.GetId(), nil
+}
+
+func (svc SinkerService) ListDeadLetters(ctx context.Context, token string, limit int64) ([]producer.DeadLetter, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return nil, err
+	}
+	return svc.deadLetterSvc.ListDeadLetters(ctx, ownerID, limit)
+}
+
+func (svc SinkerService) ReplayDeadLetter(ctx context.Context, token string, id string) error {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return err
+	}
+	letter, err := svc.deadLetterSvc.RetrieveDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	// dead letters of other owners are not disclosed
+	if letter.OwnerID != ownerID {
+		return producer.ErrDeadLetterNotFound
+	}
+	// replayed here rather than published again, so the scopes and sinks that did not fail are not exported twice
+	if err := svc.bridgeService.ReplayDeadLetter(ctx, letter); err != nil {
+		return err
+	}
+	svc.logger.Info("replayed dead letter", zap.String("id", id), zap.String("reason", letter.Reason),
+		zap.String("channel", letter.Channel), zap.String("policy_id", letter.PolicyID), zap.String("sink_id", letter.SinkID))
+	return svc.deadLetterSvc.RemoveDeadLetter(ctx, id)
+}
+
 func (svc SinkerService) Stop() error {
 	otelTopic := fmt.Sprintf("channels.*.%s", OtelMetricsTopic)
 	if err := svc.pubSub.Unsubscribe(otelTopic); err != nil {
@@ -128,6 +185,7 @@ func (svc SinkerService) Stop() error {
 
 // New instantiates the sinker service implementation.
 func New(logger *zap.Logger,
+	auth mainflux.AuthServiceClient,
 	pubSub mfnats.PubSub,
 	streamsClient *redis.Client,
 	cacheClient *redis.Client,
@@ -139,11 +197,20 @@ func New(logger *zap.Logger,
 	requestGauge metrics.Gauge,
 	requestCounter metrics.Counter,
 	inputCounter metrics.Counter,
+	processingGauge metrics.Gauge,
+	deadLetterCounter metrics.Counter,
 	defaultCacheExpiration time.Duration,
 ) Service {
+	sinkTTLSvc := producer.NewSinkerKeyService(logger, cacheClient)
+	sinkActivitySvc := producer.NewSinkActivityProducer(logger, streamsClient, sinkTTLSvc)
+	sinkUsageSvc := producer.NewSinkUsageProducer(logger, streamsClient)
+	deadLetterSvc := producer.NewDeadLetterStore(logger, streamsClient)
+	bridgeService := bridgeservice.NewBridgeService(logger, defaultCacheExpiration, sinkActivitySvc, sinkUsageSvc,
+		deadLetterSvc, policiesClient, sinksClient, fleetClient, inputCounter, processingGauge, deadLetterCounter)
 	return &SinkerService{
 		inMemoryCacheExpiration: defaultCacheExpiration,
 		logger:                  logger,
+		auth:                    auth,
 		pubSub:                  pubSub,
 		streamClient:            streamsClient,
 		cacheClient:             cacheClient,
@@ -153,6 +220,13 @@ func New(logger *zap.Logger,
 		requestGauge:            requestGauge,
 		requestCounter:          requestCounter,
 		messageInputCounter:     inputCounter,
+		processingGauge:         processingGauge,
+		deadLetterCounter:       deadLetterCounter,
+		sinkTTLSvc:              sinkTTLSvc,
+		sinkActivitySvc:         sinkActivitySvc,
+		sinkUsageSvc:            sinkUsageSvc,
+		deadLetterSvc:           deadLetterSvc,
+		bridgeService:           &bridgeService,
 		otel:                    enableOtel,
 		otelKafkaUrl:            otelKafkaUrl,
 	}
@@ -499,3 +499,69 @@ func validateSinkEndpoint(svc sinks.SinkService) endpoint.Endpoint {
 		return res, err
 	}
 }
+
+func viewSinkUsageEndpoint(svc sinks.SinkService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(usageReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		report, err := svc.ViewSinkUsage(ctx, req.token, req.id, req.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		res := buildUsageReportRes(report)
+		res.SinkID = req.id
+		return res, nil
+	}
+}
+
+func viewOwnerUsageEndpoint(svc sinks.SinkService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(usageReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		report, err := svc.ViewOwnerUsage(ctx, req.token, req.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		res := buildUsageReportRes(report)
+		for _, s := range report.Sinks {
+			res.Sinks = append(res.Sinks, usageRes{
+				SinkID:     s.SinkID,
+				Bytes:      s.Bytes,
+				DataPoints: s.DataPoints,
+				Messages:   s.Messages,
+			})
+		}
+		return res, nil
+	}
+}
+
+func buildUsageReportRes(report sinks.UsageReport) usageReportRes {
+	res := usageReportRes{
+		From:     report.From,
+		To:       report.To,
+		Interval: report.Interval,
+		Total: usageRes{
+			Bytes:      report.Total.Bytes,
+			DataPoints: report.Total.DataPoints,
+			Messages:   report.Total.Messages,
+		},
+		Windows: []usageRes{},
+	}
+	for _, w := range report.Windows {
+		res.Windows = append(res.Windows, usageRes{
+			WindowStart: w.WindowStart,
+			Bytes:       w.Bytes,
+			DataPoints:  w.DataPoints,
+			Messages:    w.Messages,
+		})
+	}
+	return res
+}
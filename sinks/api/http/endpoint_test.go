@@ -17,6 +17,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
@@ -1157,6 +1158,108 @@ func TestViewSink(t *testing.T) {
 
 }
 
+func TestViewSinkUsage(t *testing.T) {
+	service := newService(map[string]string{token: email})
+	server := newServer(service)
+	defer server.Close()
+	nameID, _ := types.NewIdentifier("my-sink")
+	description := "An example prometheus sink"
+	sink := sinks.Sink{
+		Name:        nameID,
+		Description: &description,
+		Backend:     "prometheus",
+		Config: map[string]interface{}{
+			"exporter":       map[string]interface{}{"remote_host": "https://orb.community/"},
+			"authentication": map[string]interface{}{"type": "basicauth", "username": "dbuser", "password": "dbpass"},
+		},
+		Tags: map[string]string{"cloud": "aws"},
+	}
+	sk, err := service.CreateSink(context.Background(), token, sink)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	windowStart := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	err = service.RecordSinkUsageInternal(context.Background(), sinks.SinkUsage{
+		SinkID:      sk.ID,
+		MFOwnerID:   sk.MFOwnerID,
+		WindowStart: windowStart,
+		Bytes:       1024,
+		DataPoints:  50,
+		Messages:    2,
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	period := "from=2023-01-01T00:00:00Z&to=2023-01-02T00:00:00Z"
+	cases := map[string]struct {
+		url    string
+		auth   string
+		status int
+		bytes  int64
+		sinks  int
+	}{
+		"view sink usage": {
+			url:    fmt.Sprintf("%s/sinks/%s/usage?%s", server.URL, sk.ID, period),
+			auth:   token,
+			status: http.StatusOK,
+			bytes:  1024,
+		},
+		"view sink usage by day": {
+			url:    fmt.Sprintf("%s/sinks/%s/usage?%s&interval=day", server.URL, sk.ID, period),
+			auth:   token,
+			status: http.StatusOK,
+			bytes:  1024,
+		},
+		"view owner usage": {
+			url:    fmt.Sprintf("%s/sinks/usage?%s", server.URL, period),
+			auth:   token,
+			status: http.StatusOK,
+			bytes:  1024,
+			sinks:  1,
+		},
+		"view sink usage with invalid interval": {
+			url:    fmt.Sprintf("%s/sinks/%s/usage?interval=week", server.URL, sk.ID),
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"view sink usage with invalid period": {
+			url:    fmt.Sprintf("%s/sinks/%s/usage?from=yesterday", server.URL, sk.ID),
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"view usage of non-existing sink": {
+			url:    fmt.Sprintf("%s/sinks/%s/usage", server.URL, wrongID.String()),
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		"view sink usage by passing invalid token": {
+			url:    fmt.Sprintf("%s/sinks/%s/usage", server.URL, sk.ID),
+			auth:   invalidToken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			req := testRequest{
+				client: server.Client(),
+				method: http.MethodGet,
+				url:    tc.url,
+				token:  fmt.Sprintf("Bearer %s", tc.auth),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+			if tc.status != http.StatusOK {
+				return
+			}
+			var body usageReportRes
+			err = json.NewDecoder(res.Body).Decode(&body)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+			assert.Equal(t, tc.bytes, body.Total.Bytes, fmt.Sprintf("%s: expected %d bytes got %d", desc, tc.bytes, body.Total.Bytes))
+			assert.Len(t, body.Windows, 1, fmt.Sprintf("%s: unexpected number of windows", desc))
+			assert.Len(t, body.Sinks, tc.sinks, fmt.Sprintf("%s: unexpected number of sinks", desc))
+		})
+	}
+}
+
 func TestDeleteSink(t *testing.T) {
 	nameID, _ := types.NewIdentifier("my-sink")
 	description := "An example prometheus sink"
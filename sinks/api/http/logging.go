@@ -49,6 +49,48 @@ func (l loggingMiddleware) ChangeSinkStateInternal(ctx context.Context, sinkID s
 	return l.svc.ChangeSinkStateInternal(ctx, sinkID, msg, ownerID, state)
 }
 
+func (l loggingMiddleware) RecordSinkUsageInternal(ctx context.Context, usage sinks.SinkUsage) (err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: record_sink_usage_internal",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: record_sink_usage_internal",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.RecordSinkUsageInternal(ctx, usage)
+}
+
+func (l loggingMiddleware) ViewSinkUsage(ctx context.Context, token string, key string, filter sinks.UsageFilter) (_ sinks.UsageReport, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_sink_usage",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_sink_usage",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewSinkUsage(ctx, token, key, filter)
+}
+
+func (l loggingMiddleware) ViewOwnerUsage(ctx context.Context, token string, filter sinks.UsageFilter) (_ sinks.UsageReport, err error) {
+	defer func(begin time.Time) {
+		if err != nil {
+			l.logger.Warn("method call: view_owner_usage",
+				zap.Error(err),
+				zap.Duration("duration", time.Since(begin)))
+		} else {
+			l.logger.Debug("method call: view_owner_usage",
+				zap.Duration("duration", time.Since(begin)))
+		}
+	}(time.Now())
+	return l.svc.ViewOwnerUsage(ctx, token, filter)
+}
+
 func (l loggingMiddleware) CreateSink(ctx context.Context, token string, s sinks.Sink) (_ sinks.Sink, err error) {
 	defer func(begin time.Time) {
 		if err != nil {
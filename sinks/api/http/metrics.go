@@ -47,6 +47,64 @@ func (m metricsMiddleware) ChangeSinkStateInternal(ctx context.Context, sinkID s
 	return m.svc.ChangeSinkStateInternal(ctx, sinkID, msg, ownerID, state)
 }
 
+func (m metricsMiddleware) RecordSinkUsageInternal(ctx context.Context, usage sinks.SinkUsage) error {
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "recordSinkUsageInternal",
+			"owner_id", usage.MFOwnerID,
+			"sink_id", usage.SinkID,
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.RecordSinkUsageInternal(ctx, usage)
+}
+
+func (m metricsMiddleware) ViewSinkUsage(ctx context.Context, token string, key string, filter sinks.UsageFilter) (sinks.UsageReport, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return sinks.UsageReport{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewSinkUsage",
+			"owner_id", ownerID,
+			"sink_id", key,
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewSinkUsage(ctx, token, key, filter)
+}
+
+func (m metricsMiddleware) ViewOwnerUsage(ctx context.Context, token string, filter sinks.UsageFilter) (sinks.UsageReport, error) {
+	ownerID, err := m.identify(token)
+	if err != nil {
+		return sinks.UsageReport{}, err
+	}
+
+	defer func(begin time.Time) {
+		labels := []string{
+			"method", "viewOwnerUsage",
+			"owner_id", ownerID,
+			"sink_id", "",
+		}
+
+		m.counter.With(labels...).Add(1)
+		m.latency.With(labels...).Observe(float64(time.Since(begin).Microseconds()))
+
+	}(time.Now())
+
+	return m.svc.ViewOwnerUsage(ctx, token, filter)
+}
+
 func (m metricsMiddleware) ListAuthenticationTypes(ctx context.Context, token string) ([]authentication_type.AuthenticationTypeConfig, error) {
 	return m.svc.ListAuthenticationTypes(ctx, token)
 }
@@ -290,3 +290,26 @@ func (req validateReq) validate() error {
 
 	return nil
 }
+
+type usageReq struct {
+	token  string
+	id     string
+	owner  bool
+	filter sinks.UsageFilter
+}
+
+func (req usageReq) validate() error {
+	if req.token == "" {
+		return errors.ErrUnauthorizedAccess
+	}
+	if !req.owner && req.id == "" {
+		return errors.ErrMalformedEntity
+	}
+	if req.filter.Interval != "" && req.filter.Interval != sinks.UsageIntervalHour && req.filter.Interval != sinks.UsageIntervalDay {
+		return errors.ErrInvalidQueryParams
+	}
+	if !req.filter.From.IsZero() && !req.filter.To.IsZero() && !req.filter.From.Before(req.filter.To) {
+		return errors.ErrInvalidQueryParams
+	}
+	return nil
+}
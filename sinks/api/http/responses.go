@@ -171,3 +171,33 @@ func (s validateSinkRes) Headers() map[string]string {
 func (s validateSinkRes) Empty() bool {
 	return false
 }
+
+type usageRes struct {
+	SinkID      string    `json:"sink_id,omitempty"`
+	WindowStart time.Time `json:"window_start,omitempty"`
+	Bytes       int64     `json:"bytes"`
+	DataPoints  int64     `json:"data_points"`
+	Messages    int64     `json:"messages"`
+}
+
+type usageReportRes struct {
+	SinkID   string     `json:"sink_id,omitempty"`
+	From     time.Time  `json:"from"`
+	To       time.Time  `json:"to"`
+	Interval string     `json:"interval"`
+	Total    usageRes   `json:"total"`
+	Windows  []usageRes `json:"windows"`
+	Sinks    []usageRes `json:"sinks,omitempty"`
+}
+
+func (res usageReportRes) Code() int {
+	return http.StatusOK
+}
+
+func (res usageReportRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res usageReportRes) Empty() bool {
+	return false
+}
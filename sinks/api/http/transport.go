@@ -10,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	kitot "github.com/go-kit/kit/tracing/opentracing"
 	kithttp "github.com/go-kit/kit/transport/http"
@@ -32,6 +33,9 @@ const (
 	dirKey      = "dir"
 	metadataKey = "metadata"
 	tagsKey     = "tags"
+	fromKey     = "from"
+	toKey       = "to"
+	intervalKey = "interval"
 	defOffset   = 0
 	defLimit    = 10
 )
@@ -89,6 +93,18 @@ func MakeHandler(tracer opentracing.Tracer, svcName string, svc sinks.SinkServic
 		types.EncodeResponse,
 		opts...,
 	))
+	r.Get("/sinks/usage", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_owner_usage")(viewOwnerUsageEndpoint(svc)),
+		decodeOwnerUsage,
+		types.EncodeResponse,
+		opts...,
+	))
+	r.Get("/sinks/:id/usage", kithttp.NewServer(
+		kitot.TraceServer(tracer, "view_sink_usage")(viewSinkUsageEndpoint(svc)),
+		decodeSinkUsage,
+		types.EncodeResponse,
+		opts...,
+	))
 	r.Get("/sinks/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "view_sink")(viewSinkEndpoint(svc)),
 		decodeView,
@@ -201,6 +217,60 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
+func decodeSinkUsage(_ context.Context, r *http.Request) (interface{}, error) {
+	filter, err := readUsageFilter(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req := usageReq{
+		token:  parseJwt(r),
+		id:     bone.GetValue(r, "id"),
+		filter: filter,
+	}
+	return req, nil
+}
+
+func decodeOwnerUsage(_ context.Context, r *http.Request) (interface{}, error) {
+	filter, err := readUsageFilter(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req := usageReq{
+		token:  parseJwt(r),
+		owner:  true,
+		filter: filter,
+	}
+	return req, nil
+}
+
+func readUsageFilter(r *http.Request) (filter sinks.UsageFilter, err error) {
+	from, err := httputil.ReadStringQuery(r, fromKey, "")
+	if err != nil {
+		return filter, err
+	}
+	to, err := httputil.ReadStringQuery(r, toKey, "")
+	if err != nil {
+		return filter, err
+	}
+	filter.Interval, err = httputil.ReadStringQuery(r, intervalKey, sinks.UsageIntervalHour)
+	if err != nil {
+		return filter, err
+	}
+	if from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, errors.Wrap(errors.ErrInvalidQueryParams, err)
+		}
+	}
+	if to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, errors.Wrap(errors.ErrInvalidQueryParams, err)
+		}
+	}
+	return filter, nil
+}
+
 func decodeDeleteRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	req := deleteSinkReq{
 		token: parseJwt(r),
@@ -289,6 +359,8 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 			w.WriteHeader(http.StatusBadRequest)
 		case errors.Contains(errorVal, sinks.ErrInvalidBackend):
 			w.WriteHeader(http.StatusBadRequest)
+		case errors.Contains(errorVal, sinks.ErrInvalidUsageFilter):
+			w.WriteHeader(http.StatusBadRequest)
 
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
@@ -17,7 +17,9 @@ import (
 	"github.com/orb-community/orb/sinks"
 	"github.com/orb-community/orb/sinks/authentication_type"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 var _ sinks.SinkRepository = (*sinkRepositoryMock)(nil)
@@ -28,6 +30,7 @@ type sinkRepositoryMock struct {
 	counter   uint64
 	passSvc   authentication_type.PasswordService
 	sinksMock immutable.Map[string, sinks.Sink]
+	usageMock []sinks.SinkUsage
 }
 
 func (s *sinkRepositoryMock) GetVersion(_ context.Context) (string, error) {
@@ -185,3 +188,66 @@ func (s *sinkRepositoryMock) Remove(_ context.Context, owner string, key string)
 	}
 	return nil
 }
+
+func (s *sinkRepositoryMock) SaveSinkUsage(_ context.Context, usage sinks.SinkUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, u := range s.usageMock {
+		if u.SinkID == usage.SinkID && u.WindowStart.Equal(usage.WindowStart) {
+			s.usageMock[i].Bytes += usage.Bytes
+			s.usageMock[i].DataPoints += usage.DataPoints
+			s.usageMock[i].Messages += usage.Messages
+			return nil
+		}
+	}
+	s.usageMock = append(s.usageMock, usage)
+	return nil
+}
+
+func (s *sinkRepositoryMock) RetrieveSinkUsage(_ context.Context, ownerID string, sinkID string, filter sinks.UsageFilter) ([]sinks.SinkUsage, error) {
+	return s.rollupUsage(ownerID, filter, func(u sinks.SinkUsage) sinks.SinkUsage {
+		if filter.Interval == sinks.UsageIntervalDay {
+			y, m, d := u.WindowStart.UTC().Date()
+			return sinks.SinkUsage{SinkID: sinkID, MFOwnerID: ownerID, WindowStart: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+		}
+		return sinks.SinkUsage{SinkID: sinkID, MFOwnerID: ownerID, WindowStart: u.WindowStart.UTC().Truncate(time.Hour)}
+	}, func(u sinks.SinkUsage) bool {
+		return sinkID == "" || u.SinkID == sinkID
+	}), nil
+}
+
+func (s *sinkRepositoryMock) RetrieveUsageBySink(_ context.Context, ownerID string, filter sinks.UsageFilter) ([]sinks.SinkUsage, error) {
+	return s.rollupUsage(ownerID, filter, func(u sinks.SinkUsage) sinks.SinkUsage {
+		return sinks.SinkUsage{SinkID: u.SinkID, MFOwnerID: ownerID}
+	}, func(sinks.SinkUsage) bool {
+		return true
+	}), nil
+}
+
+func (s *sinkRepositoryMock) rollupUsage(ownerID string, filter sinks.UsageFilter, key func(sinks.SinkUsage) sinks.SinkUsage, match func(sinks.SinkUsage) bool) []sinks.SinkUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []sinks.SinkUsage
+	index := make(map[sinks.SinkUsage]int)
+	for _, u := range s.usageMock {
+		if u.MFOwnerID != ownerID || !match(u) || u.WindowStart.Before(filter.From) || !u.WindowStart.Before(filter.To) {
+			continue
+		}
+		k := key(u)
+		i, ok := index[k]
+		if !ok {
+			i = len(items)
+			index[k] = i
+			items = append(items, k)
+		}
+		items[i].Bytes += u.Bytes
+		items[i].DataPoints += u.DataPoints
+		items[i].Messages += u.Messages
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].WindowStart.Before(items[j].WindowStart)
+	})
+	return items
+}
@@ -103,6 +103,24 @@ func migrateDB(db *sqlx.DB) error {
 					`ALTER TYPE public.sinks_state DROP VALUE IF EXISTS 'provisioning_error';`,
 				},
 			},
+			{
+				Id: "sinks_5",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS sink_usage (
+						sink_id          UUID NOT NULL,
+						mf_owner_id      UUID NOT NULL,
+						window_start     TIMESTAMPTZ NOT NULL,
+						bytes            BIGINT NOT NULL DEFAULT 0,
+						data_points      BIGINT NOT NULL DEFAULT 0,
+						messages         BIGINT NOT NULL DEFAULT 0,
+						PRIMARY KEY (sink_id, window_start)
+					)`,
+					`CREATE INDEX ON sink_usage (mf_owner_id, window_start)`,
+				},
+				Down: []string{
+					"DROP TABLE sink_usage",
+				},
+			},
 		},
 	}
 
@@ -595,6 +595,86 @@ func TestUpdateSinkState(t *testing.T) {
 
 }
 
+func TestSinkUsage(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	sinkRepo := postgres.NewSinksRepository(dbMiddleware, logger)
+
+	oID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nameID, err := types.NewIdentifier("my-sink-usage")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	sink := sinks.Sink{
+		Name:        nameID,
+		Description: &description,
+		Backend:     "prometheus",
+		Created:     time.Now(),
+		MFOwnerID:   oID.String(),
+		Config:      map[string]interface{}{"remote_host": "data", "username": "dbuser"},
+		Tags:        map[string]string{"cloud": "aws"},
+	}
+
+	sinkID, err := sinkRepo.Save(context.Background(), sink)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		usage := sinks.SinkUsage{
+			SinkID:      sinkID,
+			MFOwnerID:   oID.String(),
+			WindowStart: day.Add(time.Duration(i%2) * time.Hour),
+			Bytes:       100,
+			DataPoints:  10,
+			Messages:    1,
+		}
+		err = sinkRepo.SaveSinkUsage(context.Background(), usage)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	cases := map[string]struct {
+		sinkID   string
+		interval string
+		windows  int
+		bytes    int64
+	}{
+		"retrieve sink usage by hour": {
+			sinkID:   sinkID,
+			interval: sinks.UsageIntervalHour,
+			windows:  2,
+			bytes:    200,
+		},
+		"retrieve sink usage by day": {
+			sinkID:   sinkID,
+			interval: sinks.UsageIntervalDay,
+			windows:  1,
+			bytes:    400,
+		},
+		"retrieve owner usage by hour": {
+			sinkID:   "",
+			interval: sinks.UsageIntervalHour,
+			windows:  2,
+			bytes:    200,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			filter := sinks.UsageFilter{From: day, To: day.Add(24 * time.Hour), Interval: tc.interval}
+			usage, err := sinkRepo.RetrieveSinkUsage(context.Background(), oID.String(), tc.sinkID, filter)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", desc, err))
+			require.Len(t, usage, tc.windows, fmt.Sprintf("%s: unexpected number of windows", desc))
+			assert.Equal(t, tc.bytes, usage[0].Bytes, fmt.Sprintf("%s: expected %d bytes got %d", desc, tc.bytes, usage[0].Bytes))
+		})
+	}
+
+	bySink, err := sinkRepo.RetrieveUsageBySink(context.Background(), oID.String(), sinks.UsageFilter{From: day, To: day.Add(24 * time.Hour)})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	require.Len(t, bySink, 1)
+	assert.Equal(t, sinkID, bySink[0].SinkID)
+	assert.Equal(t, int64(4), bySink[0].Messages)
+}
+
 func testSortSinks(t *testing.T, pm sinks.PageMetadata, sks []sinks.Sink) {
 	t.Helper()
 	switch pm.Order {
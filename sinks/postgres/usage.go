@@ -0,0 +1,134 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+	"github.com/orb-community/orb/pkg/db"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/sinks"
+)
+
+func (s sinksRepository) SaveSinkUsage(ctx context.Context, usage sinks.SinkUsage) error {
+	dbu, err := toDBSinkUsage(usage)
+	if err != nil {
+		return errors.Wrap(db.ErrSaveDB, err)
+	}
+
+	q := `INSERT INTO sink_usage (sink_id, mf_owner_id, window_start, bytes, data_points, messages)
+			VALUES (:sink_id, :mf_owner_id, :window_start, :bytes, :data_points, :messages)
+			ON CONFLICT (sink_id, window_start) DO UPDATE SET
+				bytes = sink_usage.bytes + EXCLUDED.bytes,
+				data_points = sink_usage.data_points + EXCLUDED.data_points,
+				messages = sink_usage.messages + EXCLUDED.messages`
+
+	if _, err := s.db.NamedExecContext(ctx, q, dbu); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && (pqErr.Code.Name() == db.ErrInvalid || pqErr.Code.Name() == db.ErrTruncation) {
+			return errors.Wrap(errors.ErrMalformedEntity, err)
+		}
+		return errors.Wrap(db.ErrSaveDB, err)
+	}
+
+	return nil
+}
+
+func (s sinksRepository) RetrieveSinkUsage(ctx context.Context, ownerID string, sinkID string, filter sinks.UsageFilter) ([]sinks.SinkUsage, error) {
+	sinkQuery := ""
+	if sinkID != "" {
+		sinkQuery = "AND sink_id = :sink_id"
+	}
+
+	q := `SELECT date_trunc(:interval, window_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS window_start,
+			SUM(bytes) AS bytes, SUM(data_points) AS data_points, SUM(messages) AS messages
+			FROM sink_usage
+			WHERE mf_owner_id = :mf_owner_id ` + sinkQuery + `
+			AND window_start >= :from AND window_start < :to
+			GROUP BY 1 ORDER BY 1`
+
+	params := map[string]interface{}{
+		"interval":    filter.Interval,
+		"mf_owner_id": ownerID,
+		"sink_id":     sinkID,
+		"from":        filter.From,
+		"to":          filter.To,
+	}
+
+	return s.queryUsage(ctx, q, params, ownerID, sinkID)
+}
+
+func (s sinksRepository) RetrieveUsageBySink(ctx context.Context, ownerID string, filter sinks.UsageFilter) ([]sinks.SinkUsage, error) {
+	q := `SELECT sink_id, SUM(bytes) AS bytes, SUM(data_points) AS data_points, SUM(messages) AS messages
+			FROM sink_usage
+			WHERE mf_owner_id = :mf_owner_id AND window_start >= :from AND window_start < :to
+			GROUP BY sink_id ORDER BY bytes DESC`
+
+	params := map[string]interface{}{
+		"mf_owner_id": ownerID,
+		"from":        filter.From,
+		"to":          filter.To,
+	}
+
+	return s.queryUsage(ctx, q, params, ownerID, "")
+}
+
+func (s sinksRepository) queryUsage(ctx context.Context, q string, params map[string]interface{}, ownerID string, sinkID string) ([]sinks.SinkUsage, error) {
+	rows, err := s.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	var items []sinks.SinkUsage
+	for rows.Next() {
+		dbu := dbSinkUsage{SinkID: sinkID, MFOwnerID: ownerID}
+		if err := rows.StructScan(&dbu); err != nil {
+			return nil, errors.Wrap(errors.ErrSelectEntity, err)
+		}
+		items = append(items, toSinkUsage(dbu))
+	}
+
+	return items, nil
+}
+
+type dbSinkUsage struct {
+	SinkID      string    `db:"sink_id"`
+	MFOwnerID   string    `db:"mf_owner_id"`
+	WindowStart time.Time `db:"window_start"`
+	Bytes       int64     `db:"bytes"`
+	DataPoints  int64     `db:"data_points"`
+	Messages    int64     `db:"messages"`
+}
+
+func toDBSinkUsage(usage sinks.SinkUsage) (dbSinkUsage, error) {
+	var uID uuid.UUID
+	if err := uID.Scan(usage.MFOwnerID); err != nil {
+		return dbSinkUsage{}, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+
+	return dbSinkUsage{
+		SinkID:      usage.SinkID,
+		MFOwnerID:   uID.String(),
+		WindowStart: usage.WindowStart.UTC(),
+		Bytes:       usage.Bytes,
+		DataPoints:  usage.DataPoints,
+		Messages:    usage.Messages,
+	}, nil
+}
+
+func toSinkUsage(dbu dbSinkUsage) sinks.SinkUsage {
+	return sinks.SinkUsage{
+		SinkID:      dbu.SinkID,
+		MFOwnerID:   dbu.MFOwnerID,
+		WindowStart: dbu.WindowStart.UTC(),
+		Bytes:       dbu.Bytes,
+		DataPoints:  dbu.DataPoints,
+		Messages:    dbu.Messages,
+	}
+}
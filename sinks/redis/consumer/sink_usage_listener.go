@@ -0,0 +1,161 @@
+package consumer
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/orb-community/orb/pkg/errors"
+	"github.com/orb-community/orb/sinks"
+	redis2 "github.com/orb-community/orb/sinks/redis"
+
+	"go.uber.org/zap"
+)
+
+const (
+	sinkUsageStream   = "orb.sink_usage"
+	sinkUsageGroup    = "orb.sinks"
+	sinkUsageConsumer = "sinks_usage_consumer"
+	// sinkUsageClaimIdle is how long a message failing to be stored stays pending before it is processed again
+	sinkUsageClaimIdle = time.Minute
+)
+
+type SinkUsageListener interface {
+	SubscribeToSinkerUsage(ctx context.Context) error
+	ReceiveMessage(ctx context.Context, message redis.XMessage) error
+}
+
+type sinkUsageListener struct {
+	logger       *zap.Logger
+	streamClient *redis.Client
+	sinkService  sinks.SinkService
+}
+
+func NewSinkUsageListener(l *zap.Logger, streamClient *redis.Client, sinkService sinks.SinkService) SinkUsageListener {
+	logger := l.Named("sink_usage_listener")
+	return &sinkUsageListener{
+		logger:       logger,
+		streamClient: streamClient,
+		sinkService:  sinkService,
+	}
+}
+
+func (s *sinkUsageListener) SubscribeToSinkerUsage(ctx context.Context) error {
+	err := s.streamClient.XGroupCreateMkStream(ctx, sinkUsageStream, sinkUsageGroup, "$").Err()
+	if err != nil && err.Error() != redis2.Exists {
+		s.logger.Error("failed to create group", zap.Error(err))
+		return err
+	}
+	go func(rLogger *zap.Logger) {
+		for {
+			select {
+			case <-ctx.Done():
+				rLogger.Info("closing sink_usage_listener routine")
+				return
+			default:
+				streams, err := s.streamClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+					Group:    sinkUsageGroup,
+					Consumer: sinkUsageConsumer,
+					Streams:  []string{sinkUsageStream, ">"},
+					Count:    1000,
+				}).Result()
+				if err != nil || len(streams) == 0 {
+					if err != nil {
+						rLogger.Error("failed to read group", zap.Error(err))
+					}
+					continue
+				}
+				s.handleMessages(ctx, rLogger, streams[0].Messages)
+			}
+		}
+	}(s.logger.Named("goroutine_sink_usage_listener"))
+	go s.reclaimPending(ctx, s.logger.Named("goroutine_sink_usage_reclaim"))
+	return nil
+}
+
+func (s *sinkUsageListener) handleMessages(ctx context.Context, logger *zap.Logger, messages []redis.XMessage) {
+	for _, msg := range messages {
+		err := s.ReceiveMessage(ctx, msg)
+		if err != nil && !errors.Contains(err, errors.ErrMalformedEntity) {
+			// left pending, reclaimPending processes it again
+			logger.Error("failed to process message", zap.String("message_id", msg.ID), zap.Error(err))
+			continue
+		}
+		if err != nil {
+			logger.Error("dropping malformed message", zap.String("message_id", msg.ID), zap.Error(err))
+		}
+		// usage is additive, only ack once it is stored so it is not lost nor counted twice
+		s.streamClient.XAck(ctx, sinkUsageStream, sinkUsageGroup, msg.ID)
+	}
+}
+
+// reclaimPending processes again the messages left pending for sinkUsageClaimIdle, either failing to be stored or read
+// before a restart
+func (s *sinkUsageListener) reclaimPending(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(sinkUsageClaimIdle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := "0-0"
+			for {
+				messages, next, err := s.streamClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+					Stream:   sinkUsageStream,
+					Group:    sinkUsageGroup,
+					Consumer: sinkUsageConsumer,
+					MinIdle:  sinkUsageClaimIdle,
+					Start:    start,
+					Count:    1000,
+				}).Result()
+				if err != nil {
+					logger.Error("failed to claim pending messages", zap.Error(err))
+					break
+				}
+				s.handleMessages(ctx, logger, messages)
+				if next == "0-0" {
+					break
+				}
+				start = next
+			}
+		}
+	}
+}
+
+func (s *sinkUsageListener) ReceiveMessage(ctx context.Context, message redis.XMessage) error {
+	usage, err := decodeSinkUsage(message.Values)
+	if err != nil {
+		return err
+	}
+	return s.sinkService.RecordSinkUsageInternal(ctx, usage)
+}
+
+func decodeSinkUsage(content map[string]interface{}) (usage sinks.SinkUsage, err error) {
+	usage.MFOwnerID, _ = content["owner_id"].(string)
+	usage.SinkID, _ = content["sink_id"].(string)
+	if usage.Bytes, err = readInt64(content, "bytes"); err != nil {
+		return usage, err
+	}
+	if usage.DataPoints, err = readInt64(content, "data_points"); err != nil {
+		return usage, err
+	}
+	if usage.Messages, err = readInt64(content, "messages"); err != nil {
+		return usage, err
+	}
+	windowStart, _ := content["window_start"].(string)
+	if usage.WindowStart, err = time.Parse(time.RFC3339, windowStart); err != nil {
+		return usage, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+	return usage, nil
+}
+
+func readInt64(content map[string]interface{}, key string) (int64, error) {
+	val, _ := content[key].(string)
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+	return n, nil
+}
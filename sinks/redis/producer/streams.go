@@ -41,6 +41,18 @@ func (es sinksStreamProducer) ChangeSinkStateInternal(ctx context.Context, sinkI
 	return es.svc.ChangeSinkStateInternal(ctx, sinkID, msg, ownerID, state)
 }
 
+func (es sinksStreamProducer) RecordSinkUsageInternal(ctx context.Context, usage sinks.SinkUsage) error {
+	return es.svc.RecordSinkUsageInternal(ctx, usage)
+}
+
+func (es sinksStreamProducer) ViewSinkUsage(ctx context.Context, token string, key string, filter sinks.UsageFilter) (sinks.UsageReport, error) {
+	return es.svc.ViewSinkUsage(ctx, token, key, filter)
+}
+
+func (es sinksStreamProducer) ViewOwnerUsage(ctx context.Context, token string, filter sinks.UsageFilter) (sinks.UsageReport, error) {
+	return es.svc.ViewOwnerUsage(ctx, token, filter)
+}
+
 func (es sinksStreamProducer) ViewSinkInternal(ctx context.Context, ownerID string, key string) (sinks.Sink, error) {
 	return es.svc.ViewSinkInternal(ctx, ownerID, key)
 }
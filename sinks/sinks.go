@@ -180,6 +180,12 @@ type SinkService interface {
 	ValidateSink(ctx context.Context, token string, sink Sink) (Sink, error)
 	// ChangeSinkStateInternal change the sink internal state from new/idle/active
 	ChangeSinkStateInternal(ctx context.Context, sinkID string, msg string, ownerID string, state State) error
+	// RecordSinkUsageInternal accumulates the usage reported by sinker for a sink time window
+	RecordSinkUsageInternal(ctx context.Context, usage SinkUsage) error
+	// ViewSinkUsage retrieves the usage of a sink rolled up by the filter interval
+	ViewSinkUsage(ctx context.Context, token string, key string, filter UsageFilter) (UsageReport, error)
+	// ViewOwnerUsage retrieves the usage of all sinks of the owner rolled up by the filter interval
+	ViewOwnerUsage(ctx context.Context, token string, filter UsageFilter) (UsageReport, error)
 	// GetLogger gets service logger to log within gokit's packages
 	GetLogger() *zap.Logger
 }
@@ -202,6 +208,12 @@ type SinkRepository interface {
 	Remove(ctx context.Context, owner string, key string) error
 	// UpdateSinkState updates sink state like active, idle, new, unknown
 	UpdateSinkState(ctx context.Context, sinkID string, msg string, ownerID string, state State) error
+	// SaveSinkUsage adds the usage to the stored usage of the sink time window
+	SaveSinkUsage(ctx context.Context, usage SinkUsage) error
+	// RetrieveSinkUsage retrieves the usage rolled up by the filter interval, of a single sink or of all owner sinks when sinkID is empty
+	RetrieveSinkUsage(ctx context.Context, ownerID string, sinkID string, filter UsageFilter) ([]SinkUsage, error)
+	// RetrieveUsageBySink retrieves the usage totals of each owner sink within the filter period
+	RetrieveUsageBySink(ctx context.Context, ownerID string, filter UsageFilter) ([]SinkUsage, error)
 	// GetVersion for migrate service
 	GetVersion(ctx context.Context) (string, error)
 	// UpsertVersion for migrate service
@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
@@ -966,6 +967,116 @@ func TestViewSinkInternal(t *testing.T) {
 	}
 }
 
+func TestSinkUsage(t *testing.T) {
+	service := newService(map[string]string{token: email})
+	nameID, _ := types.NewIdentifier("my-sink")
+	description := "An example prometheus sink"
+	sink := sinks.Sink{
+		Name:        nameID,
+		Description: &description,
+		Backend:     "prometheus",
+		State:       sinks.Unknown,
+		Error:       "",
+		Config: types.Metadata{
+			"exporter":       map[string]interface{}{"remote_host": "https://orb.community/"},
+			"authentication": map[string]interface{}{"type": "basicauth", "username": "dbuser", "password": "dbpass"},
+		},
+		Tags: map[string]string{"cloud": "aws"},
+	}
+	wrongID, _ := uuid.NewV4()
+	sk, err := service.CreateSink(context.Background(), token, sink)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	day := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	for i := 0; i < 3; i++ {
+		// two deltas on the same window, as published by different sinker replicas
+		for j := 0; j < 2; j++ {
+			err = service.RecordSinkUsageInternal(context.Background(), sinks.SinkUsage{
+				SinkID:      sk.ID,
+				MFOwnerID:   sk.MFOwnerID,
+				WindowStart: day.Add(time.Duration(i) * time.Hour),
+				Bytes:       100,
+				DataPoints:  10,
+				Messages:    1,
+			})
+			require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		}
+	}
+	err = service.RecordSinkUsageInternal(context.Background(), sinks.SinkUsage{SinkID: sk.ID, Bytes: 100})
+	assert.True(t, errors.Contains(err, sinks.ErrMalformedEntity), fmt.Sprintf("expected %s got %s", sinks.ErrMalformedEntity, err))
+
+	cases := map[string]struct {
+		key     string
+		token   string
+		filter  sinks.UsageFilter
+		windows int
+		bytes   int64
+		err     error
+	}{
+		"view usage by hour": {
+			key:     sk.ID,
+			token:   token,
+			filter:  sinks.UsageFilter{From: day, To: day.Add(24 * time.Hour), Interval: sinks.UsageIntervalHour},
+			windows: 3,
+			bytes:   600,
+			err:     nil,
+		},
+		"view usage by day": {
+			key:     sk.ID,
+			token:   token,
+			filter:  sinks.UsageFilter{From: day, To: day.Add(24 * time.Hour), Interval: sinks.UsageIntervalDay},
+			windows: 1,
+			bytes:   600,
+			err:     nil,
+		},
+		"view usage restricted to the period": {
+			key:     sk.ID,
+			token:   token,
+			filter:  sinks.UsageFilter{From: day.Add(time.Hour), To: day.Add(2 * time.Hour)},
+			windows: 1,
+			bytes:   200,
+			err:     nil,
+		},
+		"view usage with invalid interval": {
+			key:    sk.ID,
+			token:  token,
+			filter: sinks.UsageFilter{Interval: "week"},
+			err:    sinks.ErrInvalidUsageFilter,
+		},
+		"view usage with wrong credentials": {
+			key:   sk.ID,
+			token: invalidToken,
+			err:   sinks.ErrUnauthorizedAccess,
+		},
+		"view usage of a non-existing sink": {
+			key:   wrongID.String(),
+			token: token,
+			err:   sinks.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		t.Run(desc, func(t *testing.T) {
+			report, err := service.ViewSinkUsage(context.Background(), tc.token, tc.key, tc.filter)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+			if err == nil {
+				assert.Len(t, report.Windows, tc.windows, fmt.Sprintf("%s: unexpected number of windows", desc))
+				assert.Equal(t, tc.bytes, report.Total.Bytes, fmt.Sprintf("%s: expected %d bytes got %d", desc, tc.bytes, report.Total.Bytes))
+			}
+		})
+	}
+
+	t.Run("view owner usage", func(t *testing.T) {
+		report, err := service.ViewOwnerUsage(context.Background(), token, sinks.UsageFilter{From: day, To: day.Add(24 * time.Hour)})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		assert.Equal(t, int64(600), report.Total.Bytes)
+		assert.Equal(t, int64(6), report.Total.Messages)
+		require.Len(t, report.Sinks, 1)
+		assert.Equal(t, sk.ID, report.Sinks[0].SinkID)
+		assert.Equal(t, int64(60), report.Sinks[0].DataPoints)
+	})
+}
+
 func testSortSinks(t *testing.T, pm sinks.PageMetadata, sks []sinks.Sink) {
 	switch pm.Order {
 	case "name":
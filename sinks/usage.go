@@ -0,0 +1,135 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+package sinks
+
+import (
+	"context"
+	"time"
+
+	"github.com/orb-community/orb/pkg/errors"
+)
+
+const (
+	UsageIntervalHour = "hour"
+	UsageIntervalDay  = "day"
+
+	// DefaultUsagePeriod is the period covered by a usage report when no start is given
+	DefaultUsagePeriod = 24 * time.Hour
+)
+
+// ErrInvalidUsageFilter indicates a malformed usage period or interval
+var ErrInvalidUsageFilter = errors.New("invalid usage filter")
+
+// SinkUsage is the amount of data exported to a sink during a time window, as accounted by sinker.
+// When aggregated by owner, SinkID is empty.
+type SinkUsage struct {
+	SinkID      string
+	MFOwnerID   string
+	WindowStart time.Time
+	Bytes       int64
+	DataPoints  int64
+	Messages    int64
+}
+
+func (u *SinkUsage) add(other SinkUsage) {
+	u.Bytes += other.Bytes
+	u.DataPoints += other.DataPoints
+	u.Messages += other.Messages
+}
+
+// UsageFilter restricts a usage report to [From, To) rolled up by Interval
+type UsageFilter struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+}
+
+// UsageReport contains the usage rolled up per window and its totals,
+// per sink totals are only filled for owner-level reports
+type UsageReport struct {
+	UsageFilter
+	Total   SinkUsage
+	Windows []SinkUsage
+	Sinks   []SinkUsage
+}
+
+func newUsageReport(filter UsageFilter, windows []SinkUsage) UsageReport {
+	report := UsageReport{
+		UsageFilter: filter,
+		Windows:     windows,
+	}
+	for _, w := range windows {
+		report.Total.add(w)
+	}
+	return report
+}
+
+// normalize fills the defaults of the filter and validates it
+func (f *UsageFilter) normalize() error {
+	if f.To.IsZero() {
+		f.To = time.Now()
+	}
+	if f.From.IsZero() {
+		f.From = f.To.Add(-DefaultUsagePeriod)
+	}
+	if f.Interval == "" {
+		f.Interval = UsageIntervalHour
+	}
+	if f.Interval != UsageIntervalHour && f.Interval != UsageIntervalDay {
+		return errors.Wrap(ErrInvalidUsageFilter, errors.New("interval must be hour or day"))
+	}
+	if !f.From.Before(f.To) {
+		return errors.Wrap(ErrInvalidUsageFilter, errors.New("from must be before to"))
+	}
+	f.From = f.From.UTC()
+	f.To = f.To.UTC()
+	return nil
+}
+
+func (svc sinkService) RecordSinkUsageInternal(ctx context.Context, usage SinkUsage) error {
+	if usage.SinkID == "" || usage.MFOwnerID == "" || usage.WindowStart.IsZero() {
+		return ErrMalformedEntity
+	}
+	return svc.sinkRepo.SaveSinkUsage(ctx, usage)
+}
+
+func (svc sinkService) ViewSinkUsage(ctx context.Context, token string, key string, filter UsageFilter) (UsageReport, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return UsageReport{}, err
+	}
+	if err := filter.normalize(); err != nil {
+		return UsageReport{}, err
+	}
+	if _, err := svc.sinkRepo.RetrieveByOwnerAndId(ctx, ownerID, key); err != nil {
+		return UsageReport{}, errors.Wrap(ErrNotFound, err)
+	}
+	windows, err := svc.sinkRepo.RetrieveSinkUsage(ctx, ownerID, key, filter)
+	if err != nil {
+		return UsageReport{}, err
+	}
+	return newUsageReport(filter, windows), nil
+}
+
+func (svc sinkService) ViewOwnerUsage(ctx context.Context, token string, filter UsageFilter) (UsageReport, error) {
+	ownerID, err := svc.identify(token)
+	if err != nil {
+		return UsageReport{}, err
+	}
+	if err := filter.normalize(); err != nil {
+		return UsageReport{}, err
+	}
+	windows, err := svc.sinkRepo.RetrieveSinkUsage(ctx, ownerID, "", filter)
+	if err != nil {
+		return UsageReport{}, err
+	}
+	perSink, err := svc.sinkRepo.RetrieveUsageBySink(ctx, ownerID, filter)
+	if err != nil {
+		return UsageReport{}, err
+	}
+	report := newUsageReport(filter, windows)
+	report.Sinks = perSink
+	return report, nil
+}